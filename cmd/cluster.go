@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/up"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+const contextMapFlagName = "context-map"
+
+var (
+	// newClusterClientset creates the Kubernetes client of a cluster. It is a variable so that unit tests can use a fake clientset.
+	newClusterClientset = func(cfg *config.Config) (kubernetes.Interface, error) {
+		return kubernetes.NewForConfig(cfg.KubeConfig)
+	}
+	// upRun runs up in a cluster. It is a variable so that unit tests can run up without a cluster.
+	upRun = up.Run
+	// remoteServicePollInterval and remoteServiceTimeout control how long to wait for the load balancer of a docker compose service that
+	// is resolved from other clusters.
+	remoteServicePollInterval = 2 * time.Second
+	remoteServiceTimeout      = 5 * time.Minute
+)
+
+// addContextMapFlag adds the flag that maps the clusters of docker compose services to kube config contexts.
+func addContextMapFlag(flags *pflag.FlagSet) {
+	flags.StringSliceP(contextMapFlagName, "", nil, "Maps the clusters of docker compose services (\"x-kube-compose\".\"cluster\") to "+
+		"kube config contexts, in the form CLUSTER=CONTEXT (e.g. 'data=shared-cluster'). Docker compose services without a cluster use the "+
+		"kube config context of the other flags")
+}
+
+// getContextMap returns the kube config contexts of the flag --context-map, keyed by cluster.
+func getContextMap(flags *pflag.FlagSet) (map[string]string, error) {
+	values, _ := flags.GetStringSlice(contextMapFlagName)
+	contextMap := map[string]string{}
+	for _, value := range values {
+		i := strings.IndexByte(value, '=')
+		if i < 0 || i == len(value)-1 {
+			return nil, fmt.Errorf("the flag --%s must have values of the form CLUSTER=CONTEXT, but got %#v", contextMapFlagName, value)
+		}
+		cluster, context := value[:i], value[i+1:]
+		if e := validation.IsDNS1123Label(cluster); len(e) > 0 {
+			return nil, fmt.Errorf("the flag --%s has an invalid cluster %#v: %s", contextMapFlagName, cluster, e[0])
+		}
+		if _, ok := contextMap[cluster]; ok {
+			return nil, fmt.Errorf("the flag --%s maps cluster %s more than once", contextMapFlagName, cluster)
+		}
+		contextMap[cluster] = context
+	}
+	return contextMap, nil
+}
+
+// describeCluster returns the description of a cluster in log messages.
+func describeCluster(cluster string) string {
+	if cluster == "" {
+		return "the cluster of the kube config context"
+	}
+	return "cluster " + cluster
+}
+
+// loadClusterConfigs loads the configuration of a command for each cluster of the docker compose services, in the order in which the
+// clusters are started (see config.Config.Clusters). The configuration of a cluster only has the docker compose services of the cluster
+// (see config.Config.TargetCluster) and connects to the kube config context that the flag --context-map maps the cluster to. If prepare
+// is not nil it is called with each configuration before the docker compose services of other clusters are removed.
+func loadClusterConfigs(cmd *cobra.Command, args []string, prepare func(cfg *config.Config) error) ([]*config.Config, error) {
+	contextMap, err := getContextMap(cmd.Flags())
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.Config)
+	}
+	defaultCfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := defaultCfg.Clusters()
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.Config)
+	}
+	if len(clusters) == 0 {
+		clusters = []string{""}
+	}
+	err = checkContextMap(clusters, contextMap)
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.Config)
+	}
+	cfgs := make([]*config.Config, len(clusters))
+	for i, cluster := range clusters {
+		cfg := defaultCfg
+		if cluster != "" {
+			kubeConfigOpts := getKubeConfigFlags(cmd.Flags())
+			kubeConfigOpts.Cluster = ""
+			kubeConfigOpts.Context = contextMap[cluster]
+			kubeConfigOpts.User = ""
+			cfg, err = loadCommandConfigWithKubeConfig(cmd, args, kubeConfigOpts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if prepare != nil {
+			err = prepare(cfg)
+			if err != nil {
+				return nil, err
+			}
+		}
+		cfg.TargetCluster(cluster)
+		cfgs[i] = cfg
+	}
+	return cfgs, nil
+}
+
+// checkContextMap returns an error if a cluster of the docker compose services is not mapped to a kube config context, and warns about
+// mapped clusters that have no docker compose services.
+func checkContextMap(clusters []string, contextMap map[string]string) error {
+	used := map[string]bool{}
+	for _, cluster := range clusters {
+		if cluster == "" {
+			continue
+		}
+		if _, ok := contextMap[cluster]; !ok {
+			return fmt.Errorf("docker compose services are in cluster %s, but the flag --%s does not map it to a kube config context",
+				cluster, contextMapFlagName)
+		}
+		used[cluster] = true
+	}
+	var unused []string
+	for cluster := range contextMap {
+		if !used[cluster] {
+			unused = append(unused, cluster)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		log.Warnf("the flag --%s maps clusters %s, which have no docker compose services", contextMapFlagName, strings.Join(unused, ", "))
+	}
+	return nil
+}
+
+// runUpClusters runs up in each cluster, in order. Up is detached in all clusters but the last, because the docker compose services of
+// later clusters can depend on the services of earlier clusters. Pods resolve the docker compose services of earlier clusters through the
+// load balancers of their Kubernetes services (see addRemoteServices).
+func runUpClusters(cfgs []*config.Config, opts *up.Options) error {
+	for i, cfg := range cfgs {
+		err := addRemoteServices(cfg, cfgs[:i])
+		if err != nil {
+			return err
+		}
+		clusterOpts := opts
+		if i < len(cfgs)-1 {
+			detachedOpts := *opts
+			detachedOpts.Detach = true
+			detachedOpts.RecommendResources = false
+			clusterOpts = &detachedOpts
+		}
+		if len(cfgs) > 1 {
+			log.Infof("starting the docker compose services of %s", describeCluster(cfg.Cluster))
+		}
+		err = upRun(cfg, clusterOpts)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRemoteServices adds the addresses of the docker compose services of the configurations of earlier clusters to cfg (see
+// config.Config.AddRemoteService). Docker compose services that cannot be reached from other clusters are skipped with a warning.
+func addRemoteServices(cfg *config.Config, earlierCfgs []*config.Config) error {
+	for _, earlierCfg := range earlierCfgs {
+		names := make([]string, 0, len(earlierCfg.Services))
+		for name := range earlierCfg.Services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			service := earlierCfg.Services[name]
+			if len(service.Ports) == 0 {
+				continue
+			}
+			host, err := getRemoteServiceHost(earlierCfg, service)
+			if err != nil {
+				return err
+			}
+			if host == "" {
+				log.Warnf("pods in %s cannot resolve docker compose service %s of %s, because its k8s service is not a LoadBalancer service",
+					describeCluster(cfg.Cluster), name, describeCluster(earlierCfg.Cluster))
+				continue
+			}
+			cfg.AddRemoteService(service, host)
+		}
+	}
+	return nil
+}
+
+// getRemoteServiceHost returns the address of the load balancer of the Kubernetes service of a docker compose service, waiting until
+// the load balancer is provisioned. The empty string is returned if the Kubernetes service does not exist or is not a LoadBalancer
+// service.
+func getRemoteServiceHost(cfg *config.Config, service *config.Service) (string, error) {
+	clientset, err := newClusterClientset(cfg)
+	if err != nil {
+		return "", exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	name := k8smeta.GetK8sName(service, cfg)
+	client := clientset.CoreV1().Services(cfg.GetNamespace(service))
+	deadline := time.Now().Add(remoteServiceTimeout)
+	for {
+		var k8sService *v1.Service
+		k8sService, err = client.Get(name, metav1.GetOptions{})
+		switch {
+		case k8sError.IsNotFound(err):
+			return "", nil
+		case err != nil:
+			return "", exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		case k8sService.Spec.Type != v1.ServiceTypeLoadBalancer:
+			return "", nil
+		}
+		for _, ingress := range k8sService.Status.LoadBalancer.Ingress {
+			if ingress.Hostname != "" {
+				return ingress.Hostname, nil
+			}
+			if ingress.IP != "" {
+				return ingress.IP, nil
+			}
+		}
+		if !time.Now().Before(deadline) {
+			return "", exitcode.Wrap(fmt.Errorf("timed out waiting for the load balancer of k8s service %s", name), exitcode.ClusterConnectivity)
+		}
+		time.Sleep(remoteServicePollInterval)
+	}
+}
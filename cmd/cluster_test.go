@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/up"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestClusterConfig(cluster string, services ...string) *config.Config {
+	cfg := &config.Config{
+		Cluster:       cluster,
+		EnvironmentID: "123",
+		Namespace:     "ns",
+		Services:      map[string]*config.Service{},
+	}
+	for _, name := range services {
+		cfg.Services[name] = &config.Service{
+			Cluster: cluster,
+			DockerComposeService: &dockerComposeConfig.Service{
+				Name: name,
+			},
+			NameEscaped: name,
+			Ports: []config.Port{
+				{Port: 5432, Protocol: "tcp"},
+			},
+		}
+	}
+	return cfg
+}
+
+func withFakeClusterClientset(clientset kubernetes.Interface, cb func()) {
+	orig := newClusterClientset
+	defer func() {
+		newClusterClientset = orig
+	}()
+	newClusterClientset = func(_ *config.Config) (kubernetes.Interface, error) {
+		return clientset, nil
+	}
+	cb()
+}
+
+func Test_GetContextMap_Success(t *testing.T) {
+	cmd := newTestUpCli()
+	_ = cmd.Flags().Set(contextMapFlagName, "data=shared-cluster,cache=other=context")
+	contextMap, err := getContextMap(cmd.Flags())
+	if err != nil || len(contextMap) != 2 || contextMap["data"] != "shared-cluster" || contextMap["cache"] != "other=context" {
+		t.Error(contextMap, err)
+	}
+}
+
+func Test_GetContextMap_Invalid(t *testing.T) {
+	for _, value := range []string{
+		"data",
+		"data=",
+		"=context",
+		"Not_Valid=context",
+		"data=a,data=b",
+	} {
+		cmd := newTestUpCli()
+		_ = cmd.Flags().Set(contextMapFlagName, value)
+		_, err := getContextMap(cmd.Flags())
+		if err == nil {
+			t.Error(value)
+		}
+	}
+}
+
+func Test_CheckContextMap_Unmapped(t *testing.T) {
+	err := checkContextMap([]string{"data", ""}, map[string]string{
+		"cache": "shared-cluster",
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_CheckContextMap_Success(t *testing.T) {
+	err := checkContextMap([]string{"data", ""}, map[string]string{
+		"data": "shared-cluster",
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_GetRemoteServiceHost_LoadBalancer(t *testing.T) {
+	cfg := newTestClusterConfig("data", "db")
+	clientset := fake.NewSimpleClientset(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-123",
+			Namespace: "ns",
+		},
+		Spec: v1.ServiceSpec{
+			Type: v1.ServiceTypeLoadBalancer,
+		},
+		Status: v1.ServiceStatus{
+			LoadBalancer: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{
+					{IP: "10.1.2.3"},
+				},
+			},
+		},
+	})
+	withFakeClusterClientset(clientset, func() {
+		host, err := getRemoteServiceHost(cfg, cfg.Services["db"])
+		if err != nil || host != "10.1.2.3" {
+			t.Error(host, err)
+		}
+	})
+}
+
+func Test_GetRemoteServiceHost_ClusterIP(t *testing.T) {
+	cfg := newTestClusterConfig("data", "db")
+	clientset := fake.NewSimpleClientset(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-123",
+			Namespace: "ns",
+		},
+		Spec: v1.ServiceSpec{
+			Type: v1.ServiceTypeClusterIP,
+		},
+	})
+	withFakeClusterClientset(clientset, func() {
+		host, err := getRemoteServiceHost(cfg, cfg.Services["db"])
+		if err != nil || host != "" {
+			t.Error(host, err)
+		}
+	})
+}
+
+func Test_GetRemoteServiceHost_NotFound(t *testing.T) {
+	cfg := newTestClusterConfig("data", "db")
+	withFakeClusterClientset(fake.NewSimpleClientset(), func() {
+		host, err := getRemoteServiceHost(cfg, cfg.Services["db"])
+		if err != nil || host != "" {
+			t.Error(host, err)
+		}
+	})
+}
+
+func Test_RunUpClusters_Success(t *testing.T) {
+	cfgData := newTestClusterConfig("data", "db")
+	cfgApp := newTestClusterConfig("", "app")
+	clientset := fake.NewSimpleClientset(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db-123",
+			Namespace: "ns",
+		},
+		Spec: v1.ServiceSpec{
+			Type: v1.ServiceTypeLoadBalancer,
+		},
+		Status: v1.ServiceStatus{
+			LoadBalancer: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{
+					{Hostname: "db.example.com"},
+				},
+			},
+		},
+	})
+	var detached []bool
+	orig := upRun
+	defer func() {
+		upRun = orig
+	}()
+	upRun = func(cfg *config.Config, opts *up.Options) error {
+		detached = append(detached, opts.Detach)
+		return nil
+	}
+	withFakeClusterClientset(clientset, func() {
+		err := runUpClusters([]*config.Config{cfgData, cfgApp}, &up.Options{})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	if len(detached) != 2 || !detached[0] || detached[1] || cfgApp.ExternalServices["db"] != "db.example.com" {
+		t.Error(detached, cfgApp.ExternalServices)
+	}
+}
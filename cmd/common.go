@@ -6,33 +6,35 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/kube-compose/kube-compose/internal/app/config"
-	"github.com/pkg/errors"
+	"github.com/kube-compose/kube-compose/internal/app/ephemeral"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/remote"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"k8s.io/apimachinery/pkg/util/validation"
 
 	// Plugin does not export any functions therefore it is ignored IE. "_"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 var envGetter = os.LookupEnv
 
-func setFromKubeConfig(cfg *config.Config) error {
-	loader := clientcmd.NewDefaultClientConfigLoadingRules()
-	overrides := clientcmd.ConfigOverrides{}
-	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, &overrides)
-	kubeConfig, err := clientConfig.ClientConfig()
-	if err != nil {
-		return errors.Wrap(err, "could not load kube config")
-	}
-	namespace, _, err := clientConfig.Namespace()
-	if err != nil {
-		return err
-	}
-	cfg.KubeConfig = kubeConfig
-	cfg.Namespace = namespace
-	return nil
+// exitWithError logs err and exits the process with the exit code of err (see package exitcode).
+func exitWithError(err error) {
+	log.Error(err)
+	os.Exit(int(exitcode.FromError(err)))
+}
+
+// getKubeConfigFlags returns the kube config options of the flags that kube-compose shares with kubectl.
+func getKubeConfigFlags(flags *pflag.FlagSet) *config.KubeConfigOptions {
+	opts := &config.KubeConfigOptions{}
+	opts.Cluster, _ = flags.GetString(clusterFlagName)
+	opts.Context, _ = flags.GetString(contextFlagName)
+	opts.KubeConfig, _ = flags.GetString(kubeConfigFlagName)
+	opts.User, _ = flags.GetString(userFlagName)
+	return opts
 }
 
 func getFileFlags(flags *pflag.FlagSet) ([]string, error) {
@@ -47,6 +49,20 @@ func getFileFlags(flags *pflag.FlagSet) ([]string, error) {
 	return files, nil
 }
 
+// resolveRemoteFiles fetches the docker compose files of the remote sources of files into the user's cache directory (see remote.Resolve).
+func resolveRemoteFiles(files []string) ([]string, error) {
+	for _, file := range files {
+		if remote.IsRemote(file) {
+			dir, err := remote.DefaultDir()
+			if err != nil {
+				return nil, err
+			}
+			return remote.Resolve(files, dir)
+		}
+	}
+	return files, nil
+}
+
 func getEnvIDFlag(flags *pflag.FlagSet) (string, error) {
 	var envID string
 	var exists bool
@@ -67,6 +83,19 @@ func getEnvIDFlag(flags *pflag.FlagSet) (string, error) {
 	return envID, nil
 }
 
+// getEphemeralNamespace returns the namespace of the ephemeral environment of the current project and git ref.
+func getEphemeralNamespace(files []string) (string, error) {
+	projectName, err := ephemeral.GetProjectName(files)
+	if err != nil {
+		return "", err
+	}
+	gitRef, err := ephemeral.GetGitRef()
+	if err != nil {
+		return "", err
+	}
+	return ephemeral.GetNamespace(projectName, gitRef), nil
+}
+
 func getNamespaceFlag(flags *pflag.FlagSet) (string, bool) {
 	var namespace string
 	var exists bool
@@ -81,28 +110,80 @@ func getNamespaceFlag(flags *pflag.FlagSet) (string, bool) {
 	return namespace, true
 }
 
+// getLoadOptions returns the docker compose files of the flag -f, of which remote sources are fetched, and the options to load them.
+func getLoadOptions(cmd *cobra.Command) ([]string, *dockerComposeConfig.LoadOptions, error) {
+	files, err := getFileFlags(cmd.Flags())
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err = resolveRemoteFiles(files)
+	if err != nil {
+		return nil, nil, err
+	}
+	loadOpts := &dockerComposeConfig.LoadOptions{}
+	loadOpts.XProperties, err = getProjectXProperties()
+	if err != nil {
+		return nil, nil, err
+	}
+	loadOpts.LocalFile, _ = cmd.Flags().GetString(localFileFlagName)
+	loadOpts.Template, _ = cmd.Flags().GetBool(templateFlagName)
+	return files, loadOpts, nil
+}
+
 func getCommandConfig(cmd *cobra.Command, args []string) (*config.Config, error) {
+	return loadCommandConfig(cmd, args, true)
+}
+
+// loadCommandConfig loads the configuration of a command from the docker compose files and flags, with the docker compose services of
+// args (or all docker compose services) in the filter. The kube config is only loaded if loadKubeConfig is true, so that commands that
+// do not connect to a cluster work without one.
+func loadCommandConfig(cmd *cobra.Command, args []string, loadKubeConfig bool) (*config.Config, error) {
+	var kubeConfigOpts *config.KubeConfigOptions
+	if loadKubeConfig {
+		kubeConfigOpts = getKubeConfigFlags(cmd.Flags())
+	}
+	return loadCommandConfigWithKubeConfig(cmd, args, kubeConfigOpts)
+}
+
+// loadCommandConfigWithKubeConfig is like loadCommandConfig, but loads the kube config with kubeConfigOpts instead of the flags, unless
+// kubeConfigOpts is nil.
+func loadCommandConfigWithKubeConfig(cmd *cobra.Command, args []string, kubeConfigOpts *config.KubeConfigOptions) (*config.Config, error) {
 	envID, err := getEnvIDFlag(cmd.Flags())
 	if err != nil {
-		return nil, err
+		return nil, exitcode.Wrap(err, exitcode.Config)
 	}
-	files, err := getFileFlags(cmd.Flags())
+	files, loadOpts, err := getLoadOptions(cmd)
 	if err != nil {
-		return nil, err
+		return nil, exitcode.Wrap(err, exitcode.Config)
 	}
-	cfg, err := config.New(files)
+	cfg, err := config.NewWithOptions(files, loadOpts)
 	if err != nil {
-		log.Error(err)
-		os.Exit(1)
+		exitWithError(exitcode.Wrap(err, exitcode.Config))
 	}
-	if err := setFromKubeConfig(cfg); err != nil {
-		log.Error(err)
-		os.Exit(1)
+	if kubeConfigOpts != nil {
+		if err := config.SetFromKubeConfigWithOptions(cfg, kubeConfigOpts); err != nil {
+			exitWithError(exitcode.Wrap(err, exitcode.Config))
+		}
 	}
 	cfg.EnvironmentID = envID
-	if namespace, exists := getNamespaceFlag(cmd.Flags()); exists {
+	// Resources are only scoped to a project if the project name is set explicitly, so that environments that were created without
+	// project scoping are still matched by their names and labels.
+	cfg.ProjectName = k8smeta.NormalizeProjectName(ephemeral.LookupProjectName())
+	cfg.StateFile, _ = cmd.Flags().GetString(stateFileFlagName)
+	namespace, exists := getNamespaceFlag(cmd.Flags())
+	if exists {
 		cfg.Namespace = namespace
 	}
+	if isEphemeral, _ := cmd.Flags().GetBool(ephemeralFlagName); isEphemeral {
+		if exists {
+			exitWithError(exitcode.Wrap(fmt.Errorf("the flag --%s cannot be combined with the flag --%s or the environment variable %s",
+				ephemeralFlagName, namespaceFlagName, namespaceEnvVarName), exitcode.Config))
+		}
+		cfg.Namespace, err = getEphemeralNamespace(files)
+		if err != nil {
+			exitWithError(exitcode.Wrap(err, exitcode.Config))
+		}
+	}
 	if len(args) == 0 {
 		for _, service := range cfg.Services {
 			cfg.AddToFilter(service)
@@ -111,8 +192,7 @@ func getCommandConfig(cmd *cobra.Command, args []string) (*config.Config, error)
 		for _, arg := range args {
 			service := cfg.Services[arg]
 			if service == nil {
-				log.Errorf("no service named %#v exists\n", arg)
-				os.Exit(1)
+				exitWithError(exitcode.Wrap(fmt.Errorf("no service named %#v exists", arg), exitcode.Config))
 			}
 			cfg.AddToFilter(service)
 		}
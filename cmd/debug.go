@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kube-compose/kube-compose/internal/app/debug"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/spf13/cobra"
+)
+
+func newDebugCli() *cobra.Command {
+	var debugCmd = &cobra.Command{
+		Use:   "debug SERVICE [-- COMMAND [ARGS...]]",
+		Short: "Attach an ephemeral container to the pod of a service for troubleshooting",
+		Long: "adds an ephemeral container that shares the process namespace of the container of a docker compose service to the " +
+			"service's pod, and attaches to it. This is useful for images without a shell, such as distroless images. Requires " +
+			"Kubernetes 1.23 or later",
+		Args: cobra.MinimumNArgs(1),
+		RunE: debugCommand,
+	}
+	debugCmd.PersistentFlags().StringP("image", "", debug.DefaultImage, "The image of the ephemeral container")
+	debugCmd.PersistentFlags().BoolP("stdin", "i", false, "Keep standard input open and attach it to the ephemeral container")
+	debugCmd.PersistentFlags().BoolP("tty", "t", false, "Allocate a TTY for the ephemeral container. Requires --stdin")
+	return debugCmd
+}
+
+// getDebugOptions returns the options of debug.Run from the flags and arguments of the debug command.
+func getDebugOptions(cmd *cobra.Command, args []string) (*debug.Options, error) {
+	opts := &debug.Options{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		if dash != 1 {
+			return nil, fmt.Errorf("exactly one service must be specified before --")
+		}
+		opts.Command = args[dash:]
+	} else if len(args) > 1 {
+		return nil, fmt.Errorf("the command of the ephemeral container must be specified after --")
+	}
+	opts.Image, _ = cmd.Flags().GetString("image")
+	if stdin, _ := cmd.Flags().GetBool("stdin"); stdin {
+		opts.Stdin = os.Stdin
+	}
+	opts.TTY, _ = cmd.Flags().GetBool("tty")
+	if opts.TTY && opts.Stdin == nil {
+		return nil, fmt.Errorf("the flag --tty requires the flag --stdin")
+	}
+	return opts, nil
+}
+
+func debugCommand(cmd *cobra.Command, args []string) error {
+	opts, err := getDebugOptions(cmd, args)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	cfg, err := getCommandConfig(cmd, args[:1])
+	if err != nil {
+		return err
+	}
+	err = debug.Run(cfg, cfg.Services[args[0]], opts)
+	if err != nil {
+		exitWithError(err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kube-compose/kube-compose/internal/app/debugbundle"
+	"github.com/spf13/cobra"
+)
+
+func newDebugBundleCli() *cobra.Command {
+	var debugBundleCmd = &cobra.Command{
+		Use:   "debug-bundle",
+		Short: "Collect diagnostic information into an archive",
+		Long: "writes a gzipped tarball with the effective docker compose configuration, the pods and services of the environment, " +
+			"their events and recent logs of all containers, for attaching to bug reports",
+		RunE: debugBundleCommand,
+	}
+	debugBundleCmd.PersistentFlags().StringP("output", "o", "kube-compose-debug-bundle.tar.gz", "The file to write the archive to")
+	debugBundleCmd.PersistentFlags().Int64P("tail", "", 1000, "The number of most recent log lines to include for each container. "+
+		"If not positive, all log lines are included")
+	return debugBundleCmd
+}
+
+func debugBundleCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	opts := &debugbundle.Options{}
+	opts.Output, _ = cmd.Flags().GetString("output")
+	opts.TailLines, _ = cmd.Flags().GetInt64("tail")
+	err = debugbundle.Run(cfg, opts)
+	if err != nil {
+		exitWithError(err)
+	}
+	fmt.Printf("wrote debug bundle to %s\n", opts.Output)
+	return nil
+}
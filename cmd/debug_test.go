@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/debug"
+)
+
+func TestGetDebugOptions_Defaults(t *testing.T) {
+	cmd := newDebugCli()
+	_ = cmd.ParseFlags([]string{"web"})
+	opts, err := getDebugOptions(cmd, cmd.Flags().Args())
+	if err != nil {
+		t.Error(err)
+	} else if opts.Image != debug.DefaultImage || opts.Stdin != nil || opts.TTY || len(opts.Command) != 0 {
+		t.Error(opts)
+	}
+}
+
+func TestGetDebugOptions_Success(t *testing.T) {
+	cmd := newDebugCli()
+	_ = cmd.ParseFlags([]string{"-it", "--image=alpine:3.10", "web", "--", "ps", "aux"})
+	opts, err := getDebugOptions(cmd, cmd.Flags().Args())
+	if err != nil {
+		t.Error(err)
+	} else if opts.Image != "alpine:3.10" || opts.Stdin == nil || !opts.TTY || len(opts.Command) != 2 || opts.Command[0] != "ps" ||
+		opts.Command[1] != "aux" {
+		t.Error(opts)
+	}
+}
+
+func TestGetDebugOptions_CommandWithoutDash(t *testing.T) {
+	cmd := newDebugCli()
+	_ = cmd.ParseFlags([]string{"web", "sh"})
+	_, err := getDebugOptions(cmd, cmd.Flags().Args())
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestGetDebugOptions_TTYWithoutStdin(t *testing.T) {
+	cmd := newDebugCli()
+	_ = cmd.ParseFlags([]string{"-t", "web"})
+	_, err := getDebugOptions(cmd, cmd.Flags().Args())
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -1,10 +1,10 @@
 package cmd
 
 import (
-	"os"
+	"fmt"
 
-	log "github.com/Sirupsen/logrus"
 	"github.com/kube-compose/kube-compose/internal/app/down"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
 	"github.com/spf13/cobra"
 )
 
@@ -16,18 +16,41 @@ func newDownCli() *cobra.Command {
 		Long: "destroy all pods and services",
 		RunE: downCommand,
 	}
+	addContextMapFlag(downCmd.PersistentFlags())
+	downCmd.PersistentFlags().BoolP("volumes", "v", false, "Delete the PersistentVolumeClaims of named volumes, and thereby their data. "+
+		"The PersistentVolumeClaims of external volumes are never deleted")
+	downCmd.PersistentFlags().DurationP("timeout", "", down.DefaultTimeout, "The maximum time to wait until deleted objects are gone "+
+		"(e.g. '10m'), or 0 to wait indefinitely")
 	return downCmd
 }
 
+// getDownOptions returns the options of down.RunWithOptions from the flags of the down command.
+func getDownOptions(cmd *cobra.Command) (*down.Options, error) {
+	opts := &down.Options{}
+	opts.Volumes, _ = cmd.Flags().GetBool("volumes")
+	opts.Timeout, _ = cmd.Flags().GetDuration("timeout")
+	if opts.Timeout < 0 {
+		return nil, fmt.Errorf("the flag --timeout must not be negative")
+	}
+	return opts, nil
+}
+
 func downCommand(cmd *cobra.Command, args []string) error {
-	cfg, err := getCommandConfig(cmd, args)
+	opts, err := getDownOptions(cmd)
 	if err != nil {
-		return err
+		return exitcode.Wrap(err, exitcode.Config)
 	}
-	err = down.Run(cfg)
+	cfgs, err := loadClusterConfigs(cmd, args, nil)
 	if err != nil {
-		log.Error(err)
-		os.Exit(1)
+		return err
+	}
+	// The docker compose services of a cluster can depend on the services of earlier clusters, so clusters are brought down in reverse
+	// order.
+	for i := len(cfgs) - 1; i >= 0; i-- {
+		err = down.RunWithOptions(cfgs[i], opts)
+		if err != nil {
+			exitWithError(err)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/down"
+)
+
+func TestGetDownOptions_Success(t *testing.T) {
+	cmd := newDownCli()
+	_ = cmd.ParseFlags([]string{"--volumes", "--timeout=2m"})
+	opts, err := getDownOptions(cmd)
+	if err != nil {
+		t.Error(err)
+	} else if !opts.Volumes || opts.Timeout != 2*time.Minute {
+		t.Error(opts)
+	}
+}
+
+func TestGetDownOptions_DefaultTimeout(t *testing.T) {
+	cmd := newDownCli()
+	_ = cmd.ParseFlags([]string{})
+	opts, err := getDownOptions(cmd)
+	if err != nil || opts.Timeout != down.DefaultTimeout {
+		t.Error(opts, err)
+	}
+}
+
+func TestGetDownOptions_NegativeTimeout(t *testing.T) {
+	cmd := newDownCli()
+	_ = cmd.ParseFlags([]string{"--timeout=-1s"})
+	_, err := getDownOptions(cmd)
+	if err == nil {
+		t.Fail()
+	}
+}
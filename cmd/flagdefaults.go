@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"github.com/kube-compose/kube-compose/pkg/expanduser"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// The environment variable with the path of the config file, which defaults to configFileName in the home directory.
+	configFileEnvVarName = envVarPrefix + "CONFIG_FILE"
+	configFileName       = ".kube-compose.yaml"
+)
+
+// flagEnvVarNames are the environment variables of flags that predate the KUBECOMPOSE_<FLAG> naming scheme. These are read by the
+// commands themselves, so that their error messages name the environment variable.
+var flagEnvVarNames = map[string]string{
+	envIDFlagName:     envIDEnvVarName,
+	logLevelFlagName:  logLevelEnvVarName,
+	namespaceFlagName: namespaceEnvVarName,
+}
+
+// getFlagEnvVarName returns the environment variable of a flag, which is the flag name in upper case with dashes replaced by underscores
+// and prefixed with KUBECOMPOSE_ (e.g. KUBECOMPOSE_DEPENDENCY_WAIT_MODE for --dependency-wait-mode).
+func getFlagEnvVarName(name string) string {
+	if envVarName, ok := flagEnvVarNames[name]; ok {
+		return envVarName
+	}
+	return envVarPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// isRepeatableFlag returns true if and only if each occurrence of a flag adds a value, such as --env.
+func isRepeatableFlag(flag *pflag.Flag) bool {
+	return strings.HasSuffix(flag.Value.Type(), "Array")
+}
+
+// setFlagFromEnv sets a flag from its environment variable. The values of repeatable flags are separated by newlines.
+func setFlagFromEnv(flags *pflag.FlagSet, flag *pflag.Flag, envVarName, value string) error {
+	values := []string{value}
+	if isRepeatableFlag(flag) {
+		values = strings.Split(strings.TrimSuffix(value, "\n"), "\n")
+	}
+	for _, v := range values {
+		if err := flags.Set(flag.Name, v); err != nil {
+			return fmt.Errorf("the environment variable %s is invalid: %v", envVarName, err)
+		}
+	}
+	return nil
+}
+
+// setFlagFromConfigFile sets a flag from the value of a key of the config file. A list sets a repeatable flag or a flag of a list type
+// (such as --file) to its elements.
+func setFlagFromConfigFile(flags *pflag.FlagSet, flag *pflag.Flag, file string, value interface{}) error {
+	values := []interface{}{value}
+	if list, ok := value.([]interface{}); ok {
+		if !isRepeatableFlag(flag) && !strings.HasSuffix(flag.Value.Type(), "Slice") {
+			return fmt.Errorf("the key %s of the config file %s must not be a list", flag.Name, file)
+		}
+		values = list
+	}
+	for _, v := range values {
+		if err := flags.Set(flag.Name, fmt.Sprint(v)); err != nil {
+			return fmt.Errorf("the key %s of the config file %s is invalid: %v", flag.Name, file, err)
+		}
+	}
+	return nil
+}
+
+// getConfigFile returns the path of the user's config file.
+func getConfigFile() (string, error) {
+	if file, ok := envGetter(configFileEnvVarName); ok {
+		return file, nil
+	}
+	home, err := expanduser.Home()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, configFileName), nil
+}
+
+// getProjectConfigFile returns the path of the project's config file, which is the file named configFileName in the current working
+// directory. Typically, the project's config file is committed next to the docker compose files.
+func getProjectConfigFile() (string, error) {
+	dir, err := fs.OS.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// loadConfigFile loads a config file, which maps flag names to values. A key that is the name of a command maps the flag names of that
+// command to values, which take precedence over the values of the flags at the root. Keys that start with x- are extension fields (see
+// getProjectXProperties). Nil is returned if the config file does not exist.
+func loadConfigFile(file string) (map[string]interface{}, error) {
+	fd, err := fs.OS.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(fd)
+	data, err := ioutil.ReadAll(fd)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	err = yaml.Unmarshal(data, &values)
+	if err != nil {
+		return nil, fmt.Errorf("the config file %s is invalid: %v", file, err)
+	}
+	return values, nil
+}
+
+// getKnownFlags returns the names of the flags of all commands of root.
+func getKnownFlags(root *cobra.Command) map[string]bool {
+	knownFlags := map[string]bool{}
+	visit := func(flag *pflag.Flag) {
+		knownFlags[flag.Name] = true
+	}
+	root.PersistentFlags().VisitAll(visit)
+	for _, c := range root.Commands() {
+		c.Flags().VisitAll(visit)
+		c.PersistentFlags().VisitAll(visit)
+	}
+	return knownFlags
+}
+
+// getConfigFileValues returns the values of the config file for a command, and logs a warning for keys that are not flags of any command,
+// because they are likely typos.
+func getConfigFileValues(cmd *cobra.Command, file string, values map[string]interface{}) map[string]interface{} {
+	commandValues := map[string]interface{}{}
+	if section, ok := values[cmd.Name()].(map[interface{}]interface{}); ok && cmd != cmd.Root() {
+		for key, value := range section {
+			commandValues[fmt.Sprint(key)] = value
+		}
+	}
+	knownFlags := getKnownFlags(cmd.Root())
+	var unknownKeys []string
+	for key, value := range values {
+		if _, isSection := value.(map[interface{}]interface{}); isSection || strings.HasPrefix(key, "x-") {
+			continue
+		}
+		if !knownFlags[key] {
+			unknownKeys = append(unknownKeys, key)
+		} else if _, ok := commandValues[key]; !ok {
+			commandValues[key] = value
+		}
+	}
+	if len(unknownKeys) > 0 {
+		sort.Strings(unknownKeys)
+		log.Warnf("ignoring the keys %s of the config file %s, because they are not flags", strings.Join(unknownKeys, ", "), file)
+	}
+	return commandValues
+}
+
+// configFile is a config file and its values for a command.
+type configFile struct {
+	file   string
+	values map[string]interface{}
+}
+
+// loadConfigFiles loads the project's config file and the user's config file, in order of precedence, and returns their values for a
+// command.
+func loadConfigFiles(cmd *cobra.Command) ([]*configFile, error) {
+	var files []string
+	projectFile, err := getProjectConfigFile()
+	if err != nil {
+		log.Warnf("not using a project config file: %v", err)
+	} else {
+		files = append(files, projectFile)
+	}
+	userFile, err := getConfigFile()
+	if err != nil {
+		log.Warnf("not using a config file: %v", err)
+	} else if userFile != projectFile {
+		files = append(files, userFile)
+	}
+	var configFiles []*configFile
+	for _, file := range files {
+		var values map[string]interface{}
+		values, err = loadConfigFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if values != nil {
+			configFiles = append(configFiles, &configFile{
+				file:   file,
+				values: getConfigFileValues(cmd, file, values),
+			})
+		}
+	}
+	return configFiles, nil
+}
+
+// setFlagsFromEnvAndConfigFile sets the flags of a command that are not set on the command line from environment variables and the config
+// files. A flag set on the command line takes precedence over its environment variable (see getFlagEnvVarName), which takes precedence
+// over the project's config file, which takes precedence over the user's config file (see loadConfigFile), which takes precedence over
+// the default of the flag.
+func setFlagsFromEnvAndConfigFile(cmd *cobra.Command) error {
+	configFiles, err := loadConfigFiles(cmd)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	var setErr error
+	flags := cmd.Flags()
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if setErr != nil || flag.Changed || flag.Name == "help" || flag.Name == "version" {
+			return
+		}
+		envVarName := getFlagEnvVarName(flag.Name)
+		if value, ok := envGetter(envVarName); ok {
+			if _, isRead := flagEnvVarNames[flag.Name]; !isRead {
+				setErr = setFlagFromEnv(flags, flag, envVarName, value)
+			}
+			return
+		}
+		for _, c := range configFiles {
+			if value, ok := c.values[flag.Name]; ok {
+				setErr = setFlagFromConfigFile(flags, flag, c.file, value)
+				return
+			}
+		}
+	})
+	return exitcode.Wrap(setErr, exitcode.Config)
+}
+
+// getProjectXProperties returns the extension fields of the project's config file, which are defaults of the extension fields of the
+// docker compose files (e.g. "x-kube-compose"."cluster_image_storage"), or nil if the project does not have a config file.
+func getProjectXProperties() (dockerComposeConfig.XProperties, error) {
+	file, err := getProjectConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	values, err := loadConfigFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var xProperties dockerComposeConfig.XProperties
+	for key, value := range values {
+		if strings.HasPrefix(key, "x-") {
+			if xProperties == nil {
+				xProperties = dockerComposeConfig.XProperties{}
+			}
+			xProperties[key] = value
+		}
+	}
+	return xProperties, nil
+}
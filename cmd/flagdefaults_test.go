@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	testConfigFile        = "/home/user/.kube-compose.yaml"
+	testProjectConfigFile = "/.kube-compose.yaml"
+)
+
+func withMockConfigFile(content string, cb func()) {
+	withMockConfigFiles(content, "", cb)
+}
+
+// withMockConfigFiles mocks the file system with the user's config file and the project's config file, which are omitted if their content
+// is empty.
+func withMockConfigFiles(content, projectContent string, cb func()) {
+	orig := fs.OS
+	defer func() {
+		fs.OS = orig
+	}()
+	files := map[string]fs.InMemoryFile{}
+	if content != "" {
+		files[testConfigFile] = fs.InMemoryFile{
+			Content: []byte(content),
+		}
+	}
+	if projectContent != "" {
+		files[testProjectConfigFile] = fs.InMemoryFile{
+			Content: []byte(projectContent),
+		}
+	}
+	fs.OS = fs.NewInMemoryUnixFileSystem(files)
+	cb()
+}
+
+// newTestUpCommand returns the up command of a root command, with the flags of the command line parsed from args.
+func newTestUpCommand(args ...string) *cobra.Command {
+	root := &cobra.Command{
+		Use: "kube-compose",
+	}
+	upCmd := newUpCli()
+	root.AddCommand(upCmd, newDownCli())
+	setRootCommandFlags(root)
+	_ = upCmd.ParseFlags(args)
+	return upCmd
+}
+
+func TestGetFlagEnvVarName(t *testing.T) {
+	if name := getFlagEnvVarName("dependency-wait-mode"); name != "KUBECOMPOSE_DEPENDENCY_WAIT_MODE" {
+		t.Error(name)
+	}
+	if name := getFlagEnvVarName(envIDFlagName); name != envIDEnvVarName {
+		t.Error(name)
+	}
+}
+
+func TestSetFlagsFromEnvAndConfigFile_Precedence(t *testing.T) {
+	withMockConfigFile("concurrency: 2\ndetach: true\nephemeral-ttl: 1h\nfile: [a.yml, b.yml]\nup:\n  concurrency: 3\n", func() {
+		withMockedEnv(map[string]string{
+			"KUBECOMPOSE_CONFIG_FILE":   testConfigFile,
+			"KUBECOMPOSE_DETACH":        "false",
+			"KUBECOMPOSE_EXPOSE_MODE":   "service",
+			"KUBECOMPOSE_STRICT":        "true",
+			"KUBECOMPOSE_EPHEMERAL_TTL": "2h",
+		}, func() {
+			upCmd := newTestUpCommand("--strict=false")
+			err := setFlagsFromEnvAndConfigFile(upCmd)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			flags := upCmd.Flags()
+			// The command line takes precedence over environment variables.
+			if strict, _ := flags.GetBool("strict"); strict {
+				t.Error(strict)
+			}
+			// Environment variables take precedence over the config file.
+			if detach, _ := flags.GetBool("detach"); detach {
+				t.Error(detach)
+			}
+			if ttl, _ := flags.GetDuration("ephemeral-ttl"); ttl != 2*time.Hour {
+				t.Error(ttl)
+			}
+			if exposeMode, _ := flags.GetString("expose-mode"); exposeMode != "service" {
+				t.Error(exposeMode)
+			}
+			// The section of a command takes precedence over the root of the config file.
+			if concurrency, _ := flags.GetInt("concurrency"); concurrency != 3 {
+				t.Error(concurrency)
+			}
+			if files, _ := flags.GetStringSlice(fileFlagName); !reflect.DeepEqual(files, []string{"a.yml", "b.yml"}) {
+				t.Error(files)
+			}
+		})
+	})
+}
+
+func TestSetFlagsFromEnvAndConfigFile_RepeatableFlagEnv(t *testing.T) {
+	withMockConfigFile("", func() {
+		withMockedEnv(map[string]string{
+			"KUBECOMPOSE_CONFIG_FILE": testConfigFile,
+			"KUBECOMPOSE_ENV":         "web.A=1\nweb.B=2,3\n",
+		}, func() {
+			upCmd := newTestUpCommand()
+			err := setFlagsFromEnvAndConfigFile(upCmd)
+			env, _ := upCmd.Flags().GetStringArray("env")
+			if err != nil || !reflect.DeepEqual(env, []string{"web.A=1", "web.B=2,3"}) {
+				t.Error(env, err)
+			}
+		})
+	})
+}
+
+func TestSetFlagsFromEnvAndConfigFile_LegacyEnvVarNotSet(t *testing.T) {
+	withMockConfigFile("env-id: fromfile\n", func() {
+		withMockedEnv(map[string]string{
+			"KUBECOMPOSE_CONFIG_FILE": testConfigFile,
+			"KUBECOMPOSE_ENVID":       "fromenv",
+		}, func() {
+			upCmd := newTestUpCommand()
+			err := setFlagsFromEnvAndConfigFile(upCmd)
+			if err != nil || upCmd.Flags().Changed(envIDFlagName) {
+				t.Error(err)
+			}
+			envID, err := getEnvIDFlag(upCmd.Flags())
+			if err != nil || envID != "fromenv" {
+				t.Error(envID, err)
+			}
+		})
+	})
+}
+
+func TestSetFlagsFromEnvAndConfigFile_InvalidEnv(t *testing.T) {
+	withMockConfigFile("", func() {
+		withMockedEnv(map[string]string{
+			"KUBECOMPOSE_CONFIG_FILE": testConfigFile,
+			"KUBECOMPOSE_CONCURRENCY": "many",
+		}, func() {
+			upCmd := newTestUpCommand()
+			err := setFlagsFromEnvAndConfigFile(upCmd)
+			if exitcode.FromError(err) != exitcode.Config {
+				t.Error(err)
+			}
+		})
+	})
+}
+
+func TestSetFlagsFromEnvAndConfigFile_ListOfScalarFlag(t *testing.T) {
+	withMockConfigFile("detach: [true]\n", func() {
+		withMockedEnv(map[string]string{
+			"KUBECOMPOSE_CONFIG_FILE": testConfigFile,
+		}, func() {
+			upCmd := newTestUpCommand()
+			err := setFlagsFromEnvAndConfigFile(upCmd)
+			if err == nil {
+				t.Fail()
+			}
+		})
+	})
+}
+
+func TestSetFlagsFromEnvAndConfigFile_InvalidConfigFile(t *testing.T) {
+	withMockConfigFile("detach: [", func() {
+		withMockedEnv(map[string]string{
+			"KUBECOMPOSE_CONFIG_FILE": testConfigFile,
+		}, func() {
+			upCmd := newTestUpCommand()
+			err := setFlagsFromEnvAndConfigFile(upCmd)
+			if exitcode.FromError(err) != exitcode.Config {
+				t.Error(err)
+			}
+		})
+	})
+}
+
+func TestGetConfigFileValues_UnknownKeysAndOtherSections(t *testing.T) {
+	upCmd := newTestUpCommand()
+	values := getConfigFileValues(upCmd, testConfigFile, map[string]interface{}{
+		"detach": true,
+		"down": map[interface{}]interface{}{
+			"detach": false,
+		},
+		"typo": 1,
+	})
+	if !reflect.DeepEqual(values, map[string]interface{}{"detach": true}) {
+		t.Error(values)
+	}
+}
+
+func TestSetFlagsFromEnvAndConfigFile_ProjectConfigFile(t *testing.T) {
+	withMockConfigFiles("concurrency: 2\nup:\n  detach: true\n", "concurrency: 3\nx-kube-compose:\n  presets: true\n", func() {
+		withMockedEnv(map[string]string{
+			"KUBECOMPOSE_CONFIG_FILE": testConfigFile,
+		}, func() {
+			upCmd := newTestUpCommand()
+			err := setFlagsFromEnvAndConfigFile(upCmd)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			// The project's config file takes precedence over the user's config file, even over the section of a command.
+			if concurrency, _ := upCmd.Flags().GetInt("concurrency"); concurrency != 3 {
+				t.Error(concurrency)
+			}
+			if detach, _ := upCmd.Flags().GetBool("detach"); !detach {
+				t.Error(detach)
+			}
+		})
+	})
+}
+
+func TestGetProjectXProperties_Success(t *testing.T) {
+	withMockConfigFiles("", "namespace: dev\nx-kube-compose:\n  presets: true\n", func() {
+		xProperties, err := getProjectXProperties()
+		expected := dockerComposeConfig.XProperties{
+			"x-kube-compose": map[interface{}]interface{}{
+				"presets": true,
+			},
+		}
+		if err != nil || !reflect.DeepEqual(xProperties, expected) {
+			t.Error(xProperties, err)
+		}
+	})
+}
+
+func TestGetProjectXProperties_NoFile(t *testing.T) {
+	withMockConfigFiles("", "", func() {
+		xProperties, err := getProjectXProperties()
+		if err != nil || xProperties != nil {
+			t.Error(xProperties, err)
+		}
+	})
+}
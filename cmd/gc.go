@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/gc"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/spf13/cobra"
+)
+
+func newGCCli() *cobra.Command {
+	var gcCmd = &cobra.Command{
+		Use:   "gc",
+		Short: "Delete expired ephemeral environments",
+		Long: "deletes the namespaces of ephemeral environments (see --ephemeral) whose expiry has passed, including all resources " +
+			"in them",
+		RunE: gcCommand,
+	}
+	gcCmd.PersistentFlags().BoolP("dry-run", "", false, "Only print the namespaces that would be deleted")
+	return gcCmd
+}
+
+func gcCommand(cmd *cobra.Command, args []string) error {
+	// Garbage collection is not specific to a docker compose file or environment, so only the kube config is loaded.
+	cfg := &config.Config{}
+	err := config.SetFromKubeConfigWithOptions(cfg, getKubeConfigFlags(cmd.Flags()))
+	if err != nil {
+		exitWithError(exitcode.Wrap(err, exitcode.Config))
+	}
+	opts := &gc.Options{}
+	opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	err = gc.Run(cfg, opts)
+	if err != nil {
+		exitWithError(err)
+	}
+	return nil
+}
@@ -1,15 +1,20 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"text/template"
 
-	log "github.com/Sirupsen/logrus"
 	details "github.com/kube-compose/kube-compose/internal/app/get"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
 )
 
 func newGetCli() *cobra.Command {
@@ -19,10 +24,68 @@ func newGetCli() *cobra.Command {
 		Long:  "Print a detailed description of the selected resources, including related resources such as hostname or host IP.",
 		RunE:  getCommand,
 	}
-	getCmd.PersistentFlags().StringP("output", "o", "", "Go template string")
+	getCmd.PersistentFlags().StringP("output", "o", "", "Output format, like the flag of kubectl. One of json, yaml, name, "+
+		"go-template=... and jsonpath=.... Any other value is used as a Go template")
 	return getCmd
 }
 
+// getServiceDetailsPrinter returns a function that prints service details in an output format (see newGetCli).
+func getServiceDetailsPrinter(output string) (func(io.Writer, *details.ServiceDetails) error, error) {
+	switch {
+	case output == "json":
+		return func(w io.Writer, d *details.ServiceDetails) error {
+			data, err := json.MarshalIndent(d, "", "    ")
+			if err == nil {
+				_, err = fmt.Fprintf(w, "%s\n", data)
+			}
+			return err
+		}, nil
+	case output == "yaml":
+		return func(w io.Writer, d *details.ServiceDetails) error {
+			data, err := yaml.Marshal(d)
+			if err == nil {
+				_, err = w.Write(data)
+			}
+			return err
+		}, nil
+	case output == "name":
+		return func(w io.Writer, d *details.ServiceDetails) error {
+			_, err := fmt.Fprintf(w, "service/%s\n", d.Name)
+			return err
+		}, nil
+	case strings.HasPrefix(output, "jsonpath="):
+		return getServiceDetailsJSONPathPrinter(strings.TrimPrefix(output, "jsonpath="))
+	}
+	tmpl, err := template.New("output").Parse(strings.TrimPrefix(output, "go-template="))
+	if err != nil {
+		return nil, err
+	}
+	return func(w io.Writer, d *details.ServiceDetails) error {
+		return tmpl.Execute(w, d)
+	}, nil
+}
+
+// getServiceDetailsJSONPathPrinter returns a function that prints service details with a JSONPath template of kubectl. The template is
+// evaluated against the JSON form of the details, like kubectl does.
+func getServiceDetailsJSONPathPrinter(expr string) (func(io.Writer, *details.ServiceDetails) error, error) {
+	jp := jsonpath.New("output")
+	if err := jp.Parse(expr); err != nil {
+		return nil, err
+	}
+	return func(w io.Writer, d *details.ServiceDetails) error {
+		data, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		var obj interface{}
+		err = json.Unmarshal(data, &obj)
+		if err != nil {
+			return err
+		}
+		return jp.Execute(w, obj)
+	}, nil
+}
+
 // TODO: If no service is specified then it should iterate through all services in the docker-compose
 // https://github.com/kube-compose/kube-compose/issues/126
 func getCommand(cmd *cobra.Command, args []string) error {
@@ -33,27 +96,24 @@ func getCommand(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	var tmpl *template.Template
+	var printer func(io.Writer, *details.ServiceDetails) error
 	if cmd.Flags().Changed("output") {
 		var output string
 		output, _ = cmd.Flags().GetString("output")
-		tmpl, err = template.New("test").Parse(output)
+		printer, err = getServiceDetailsPrinter(output)
 		if err != nil {
-			log.Error(err)
-			os.Exit(1)
+			exitWithError(exitcode.Wrap(err, exitcode.Config))
 		}
 	}
 	service := cfg.Services[args[0]]
 	d, err := details.GetServiceDetails(cfg, service)
 	if err != nil {
-		log.Error(err)
-		os.Exit(1)
+		exitWithError(err)
 	}
-	if tmpl != nil {
-		err = tmpl.Execute(os.Stdout, d)
+	if printer != nil {
+		err = printer(os.Stdout, d)
 		if err != nil {
-			log.Error(err)
-			os.Exit(1)
+			exitWithError(err)
 		}
 	} else {
 		output := util.FormatTable([][]string{
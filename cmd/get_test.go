@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"bytes"
 	"testing"
 
+	details "github.com/kube-compose/kube-compose/internal/app/get"
 	"github.com/spf13/cobra"
 )
 
@@ -23,3 +25,36 @@ func TestGetCommand_ConfigError(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestGetServiceDetailsPrinter(t *testing.T) {
+	d := &details.ServiceDetails{
+		Name:      "web",
+		ClusterIP: "10.0.0.1",
+		Hostname:  "web-test123",
+	}
+	for output, expected := range map[string]string{
+		"name":                      "service/web\n",
+		"yaml":                      "clusterIP: 10.0.0.1\nhostname: web-test123\nname: web\n",
+		"jsonpath={.clusterIP}":     "10.0.0.1",
+		"go-template={{.Hostname}}": "web-test123",
+		"{{.Name}}:{{.ClusterIP}}":  "web:10.0.0.1",
+	} {
+		printer, err := getServiceDetailsPrinter(output)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var buffer bytes.Buffer
+		err = printer(&buffer, d)
+		if err != nil || buffer.String() != expected {
+			t.Errorf("output %s: %#v %v", output, buffer.String(), err)
+		}
+	}
+}
+
+func TestGetServiceDetailsPrinter_InvalidJSONPath(t *testing.T) {
+	_, err := getServiceDetailsPrinter("jsonpath={.name")
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/kube-compose/kube-compose/internal/app/history"
+	"github.com/spf13/cobra"
+)
+
+func newHistoryCli() *cobra.Command {
+	var historyCmd = &cobra.Command{
+		Use:   "history [SERVICE...]",
+		Short: "List the revisions of the environment, with the pods of the specified services (or of all services)",
+		Long: "lists the revisions that up and rollback recorded, from oldest to latest, with when and by whom each revision was " +
+			"recorded, and the image, image ID and configuration hash of the pod of each service",
+		RunE: historyCommand,
+	}
+	return historyCmd
+}
+
+func historyCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	err = history.Run(cfg, os.Stdout)
+	if err != nil {
+		exitWithError(err)
+	}
+	return nil
+}
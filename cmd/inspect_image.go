@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	units "github.com/docker/go-units"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/inspectimage"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/remote"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	platformFlagName = "platform"
+	sourceFlagName   = "source"
+)
+
+func newInspectImageCli() *cobra.Command {
+	var inspectImageCmd = &cobra.Command{
+		Use:   "inspect-image SERVICE",
+		Short: "Show the configuration of the image of a service",
+		Long: "prints the manifest and configuration of the image of a docker compose service, such as its layers, platform, exposed " +
+			"ports, environment variables, entrypoint and labels, from which default values of the service's containers are derived",
+		Args: cobra.ExactArgs(1),
+		RunE: inspectImageCommand,
+	}
+	inspectImageCmd.PersistentFlags().StringP("output", "o", "", "Output format. One of json and yaml")
+	inspectImageCmd.PersistentFlags().StringP(sourceFlagName, "", inspectimage.SourceRegistry, fmt.Sprintf("Where the image is "+
+		"inspected. One of %s (without pulling it) and %s (e.g. for images that were built locally)", inspectimage.SourceRegistry,
+		inspectimage.SourceDaemon))
+	inspectImageCmd.PersistentFlags().StringP(platformFlagName, "", inspectimage.DefaultPlatform.String(),
+		"The platform of multi-platform images, of the form os/architecture[/variant]")
+	return inspectImageCmd
+}
+
+// printImageDetails prints image details in a human-readable format.
+func printImageDetails(w io.Writer, d *inspectimage.ImageDetails) error {
+	var labels []string
+	for key, value := range d.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+	rows := [][]string{
+		{"Image:", d.Image},
+		{"Source:", d.Source},
+		{"Digest:", d.Digest},
+		{"Platform:", d.Platform},
+		{"Size:", units.HumanSize(float64(d.Size))},
+		{"Entrypoint:", strings.Join(d.Entrypoint, " ")},
+		{"Cmd:", strings.Join(d.Cmd, " ")},
+		{"User:", d.User},
+		{"Working dir:", d.WorkingDir},
+		{"Exposed ports:", strings.Join(d.ExposedPorts, ", ")},
+		{"Healthcheck:", strings.Join(d.Healthcheck, " ")},
+		{"Env:", strings.Join(d.Env, "\n")},
+		{"Labels:", strings.Join(labels, "\n")},
+	}
+	sb := strings.Builder{}
+	for _, row := range rows {
+		// Multi-line values are indented on their own lines.
+		if strings.Contains(row[1], "\n") {
+			_, _ = fmt.Fprintf(&sb, "%s\n  %s\n", row[0], strings.Replace(row[1], "\n", "\n  ", -1))
+		} else {
+			_, _ = fmt.Fprintf(&sb, "%-15s %s\n", row[0], row[1])
+		}
+	}
+	layers := [][]string{{"  DIGEST", "SIZE"}}
+	for _, layer := range d.Layers {
+		size := "-"
+		if layer.Size > 0 {
+			size = units.HumanSize(float64(layer.Size))
+		}
+		layers = append(layers, []string{"  " + layer.Digest, size})
+	}
+	sb.WriteString("Layers:\n" + util.FormatTable(layers))
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// getInspectImageOptions returns the options of inspectimage.GetImageDetails of the flags of the command.
+func getInspectImageOptions(cmd *cobra.Command) (*inspectimage.Options, error) {
+	opts := &inspectimage.Options{}
+	opts.Source, _ = cmd.Flags().GetString(sourceFlagName)
+	platform, _ := cmd.Flags().GetString(platformFlagName)
+	var err error
+	opts.Platform, err = remote.ParsePlatform(platform)
+	if err != nil {
+		return nil, err
+	}
+	output, _ := cmd.Flags().GetString("output")
+	if output != "" && output != "json" && output != "yaml" {
+		return nil, fmt.Errorf("the output format %#v must be json or yaml", output)
+	}
+	return opts, nil
+}
+
+func inspectImageCommand(cmd *cobra.Command, args []string) error {
+	opts, err := getInspectImageOptions(cmd)
+	if err != nil {
+		exitWithError(exitcode.Wrap(err, exitcode.Config))
+	}
+	// Inspecting images does not require a cluster, so only the docker compose files are loaded.
+	files, loadOpts, err := getLoadOptions(cmd)
+	if err != nil {
+		exitWithError(exitcode.Wrap(err, exitcode.Config))
+	}
+	cfg, err := config.NewWithOptions(files, loadOpts)
+	if err != nil {
+		exitWithError(exitcode.Wrap(err, exitcode.Config))
+	}
+	service := cfg.Services[args[0]]
+	if service == nil {
+		exitWithError(exitcode.Wrap(fmt.Errorf("no service named %#v exists", args[0]), exitcode.Config))
+	}
+	if service.DockerComposeService.Image == "" {
+		exitWithError(exitcode.Wrap(fmt.Errorf("the docker compose service %s does not have an image", args[0]), exitcode.Config))
+	}
+	opts.DockerConfig = loadDockerCLIConfig()
+	d, err := inspectimage.GetImageDetails(service.DockerComposeService.Image, opts)
+	if err != nil {
+		exitWithError(err)
+	}
+	var data []byte
+	switch output, _ := cmd.Flags().GetString("output"); output {
+	case "json":
+		data, err = json.MarshalIndent(d, "", "    ")
+		data = append(data, '\n')
+	case "yaml":
+		data, err = yaml.Marshal(d)
+	default:
+		err = printImageDetails(os.Stdout, d)
+	}
+	if err == nil {
+		_, err = os.Stdout.Write(data)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/inspectimage"
+)
+
+func TestPrintImageDetails(t *testing.T) {
+	d := &inspectimage.ImageDetails{
+		Cmd:          []string{"nginx", "-g", "daemon off;"},
+		Env:          []string{"PATH=/bin", "NGINX_VERSION=1.17"},
+		ExposedPorts: []string{"80/tcp"},
+		Image:        "nginx:1.17",
+		Labels:       map[string]string{"maintainer": "nginx"},
+		Layers: []inspectimage.Layer{
+			{Digest: "sha256:1", Size: 2048},
+			{Digest: "sha256:22"},
+		},
+		Platform: "linux/amd64",
+		Size:     2048,
+		Source:   inspectimage.SourceRegistry,
+	}
+	var buf bytes.Buffer
+	err := printImageDetails(&buf, d)
+	expected := "Image:          nginx:1.17\n" +
+		"Source:         registry\n" +
+		"Digest:         \n" +
+		"Platform:       linux/amd64\n" +
+		"Size:           2.048kB\n" +
+		"Entrypoint:     \n" +
+		"Cmd:            nginx -g daemon off;\n" +
+		"User:           \n" +
+		"Working dir:    \n" +
+		"Exposed ports:  80/tcp\n" +
+		"Healthcheck:    \n" +
+		"Env:\n" +
+		"  PATH=/bin\n" +
+		"  NGINX_VERSION=1.17\n" +
+		"Labels:         maintainer=nginx\n" +
+		"Layers:\n" +
+		"  DIGEST     SIZE\n" +
+		"  sha256:1   2.048kB\n" +
+		"  sha256:22  -\n"
+	if err != nil || buf.String() != expected {
+		t.Error(buf.String(), err)
+	}
+}
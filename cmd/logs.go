@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/logs"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newLogsCli() *cobra.Command {
+	var logsCmd = &cobra.Command{
+		Use:   "logs",
+		Short: "View the logs of the containers of the specified docker compose services",
+		Long: "prints the logs of the containers of the specified docker compose services (or of all services), with each line prefixed " +
+			"by the name of its service",
+		RunE: logsCommand,
+	}
+	logsCmd.PersistentFlags().BoolP("follow", "f", false, "Follow log output")
+	logsCmd.PersistentFlags().BoolP("no-color", "", false, "Produce monochrome output")
+	logsCmd.PersistentFlags().StringP("since", "", "", "Show logs since a timestamp (e.g. 2013-01-02T13:23:37Z) or a relative duration "+
+		"(e.g. 42m)")
+	logsCmd.PersistentFlags().StringP("tail", "", "all", "Number of lines to show from the end of the logs of each container, or all")
+	return logsCmd
+}
+
+// setLogsSince sets the time since which logs are shown from the value of the flag --since, which is either a timestamp in RFC 3339
+// format or a relative duration like docker compose supports.
+func setLogsSince(opts *logs.Options, since string) error {
+	if since == "" {
+		return nil
+	}
+	if d, err := time.ParseDuration(since); err == nil && d > 0 {
+		// Kubernetes only supports whole seconds, so the duration is rounded up so that no logs are missed.
+		seconds := int64((d + time.Second - 1) / time.Second)
+		opts.SinceSeconds = &seconds
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return fmt.Errorf("the flag --since must be a timestamp (e.g. 2013-01-02T13:23:37Z) or a positive duration (e.g. 42m)")
+	}
+	opts.SinceTime = &metav1.Time{
+		Time: t,
+	}
+	return nil
+}
+
+// getLogsOptions returns the options of logs.Run from the flags of the logs command.
+func getLogsOptions(cmd *cobra.Command) (*logs.Options, error) {
+	opts := &logs.Options{
+		Output: os.Stdout,
+	}
+	opts.Follow, _ = cmd.Flags().GetBool("follow")
+	opts.NoColor, _ = cmd.Flags().GetBool("no-color")
+	since, _ := cmd.Flags().GetString("since")
+	err := setLogsSince(opts, since)
+	if err != nil {
+		return nil, err
+	}
+	if tail, _ := cmd.Flags().GetString("tail"); tail != "all" {
+		var tailLines int64
+		tailLines, err = strconv.ParseInt(tail, 10, 64)
+		if err != nil || tailLines < 0 {
+			return nil, fmt.Errorf("the flag --tail must be a non-negative number or all")
+		}
+		opts.TailLines = &tailLines
+	}
+	return opts, nil
+}
+
+func logsCommand(cmd *cobra.Command, args []string) error {
+	opts, err := getLogsOptions(cmd)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	err = logs.Run(cfg, opts)
+	if err != nil {
+		exitWithError(err)
+	}
+	return nil
+}
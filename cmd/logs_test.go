@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetLogsOptions_Defaults(t *testing.T) {
+	cmd := newLogsCli()
+	_ = cmd.ParseFlags(nil)
+	opts, err := getLogsOptions(cmd)
+	if err != nil {
+		t.Error(err)
+	} else if opts.Follow || opts.NoColor || opts.SinceSeconds != nil || opts.SinceTime != nil || opts.TailLines != nil {
+		t.Error(opts)
+	}
+}
+
+func TestGetLogsOptions_Success(t *testing.T) {
+	cmd := newLogsCli()
+	_ = cmd.ParseFlags([]string{"-f", "--no-color", "--since=1m30s", "--tail=20"})
+	opts, err := getLogsOptions(cmd)
+	if err != nil {
+		t.Error(err)
+	} else if !opts.Follow || !opts.NoColor || opts.SinceSeconds == nil || *opts.SinceSeconds != 90 || opts.TailLines == nil ||
+		*opts.TailLines != 20 {
+		t.Error(opts)
+	}
+}
+
+func TestGetLogsOptions_SinceTimestamp(t *testing.T) {
+	cmd := newLogsCli()
+	_ = cmd.ParseFlags([]string{"--since=2013-01-02T13:23:37Z"})
+	opts, err := getLogsOptions(cmd)
+	if err != nil {
+		t.Error(err)
+	} else if opts.SinceTime == nil || !opts.SinceTime.Time.Equal(time.Date(2013, 1, 2, 13, 23, 37, 0, time.UTC)) {
+		t.Error(opts)
+	}
+}
+
+func TestGetLogsOptions_InvalidSince(t *testing.T) {
+	cmd := newLogsCli()
+	_ = cmd.ParseFlags([]string{"--since=yesterday"})
+	_, err := getLogsOptions(cmd)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestGetLogsOptions_InvalidTail(t *testing.T) {
+	cmd := newLogsCli()
+	_ = cmd.ParseFlags([]string{"--tail=-1"})
+	_, err := getLogsOptions(cmd)
+	if err == nil {
+		t.Fail()
+	}
+}
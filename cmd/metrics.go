@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/pkg/metrics"
+	"github.com/spf13/pflag"
+)
+
+const metricsAddressFlagName = "metrics-address"
+
+// addMetricsAddressFlag adds the flag that sets the address on which Prometheus metrics are served.
+func addMetricsAddressFlag(flags *pflag.FlagSet) {
+	flags.StringP(metricsAddressFlagName, "", "", "When set, Prometheus metrics are served on this address (e.g. \":9090\") at the "+
+		"path /metrics")
+}
+
+// serveMetricsFromFlags serves a new metrics registry on the address of the flag --metrics-address, or returns nil if the flag is not
+// set.
+func serveMetricsFromFlags(flags *pflag.FlagSet) (*metrics.Registry, error) {
+	address, _ := flags.GetString(metricsAddressFlagName)
+	if address == "" {
+		return nil, nil
+	}
+	return serveMetrics(address)
+}
+
+// serveMetrics starts serving a new metrics registry on address in the background. The listener is created synchronously so that
+// errors such as the address being in use are reported before any work is done.
+func serveMetrics(address string) (*metrics.Registry, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	registry := metrics.NewRegistry()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
+	go func() {
+		err := http.Serve(listener, mux)
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+	return registry, nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestServeMetricsFromFlags_NotSet(t *testing.T) {
+	registry, err := serveMetricsFromFlags(newWatchCli().Flags())
+	if registry != nil || err != nil {
+		t.Error(registry, err)
+	}
+}
+
+func TestServeMetricsFromFlags_Watch(t *testing.T) {
+	// Find a free port.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	address := listener.Addr().String()
+	_ = listener.Close()
+	cmd := newWatchCli()
+	err = cmd.ParseFlags([]string{"--metrics-address", address})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	registry, err := serveMetricsFromFlags(cmd.Flags())
+	if registry == nil || err != nil {
+		t.Error(registry, err)
+		return
+	}
+	registry.NewCounterVec("test_total", "A test counter.", "service").Inc("web")
+	resp, err := http.Get("http://" + address + "/metrics")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil || !strings.Contains(string(body), `test_total{service="web"} 1`) {
+		t.Error(string(body), err)
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"sigs.k8s.io/yaml"
+)
+
+// appliedObjects records the objects applied by up (see up.Options.AppliedObjectHandler), so that they can be printed for tools that
+// use kube-compose as their deployer, such as Tilt. All methods are safe for concurrent use.
+type appliedObjects struct {
+	err     error
+	mutex   sync.Mutex
+	objects []map[string]interface{}
+	// The output format of write, which is json or yaml.
+	output string
+}
+
+// record records an object in its JSON decoded form. The data of Secrets is removed, so that secret values are not printed.
+func (a *appliedObjects) record(obj interface{}) {
+	data, err := json.Marshal(obj)
+	var m map[string]interface{}
+	if err == nil {
+		err = json.Unmarshal(data, &m)
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if err != nil {
+		if a.err == nil {
+			a.err = err
+		}
+		return
+	}
+	if m["kind"] == "Secret" {
+		delete(m, "data")
+		delete(m, "stringData")
+	}
+	a.objects = append(a.objects, m)
+}
+
+// write writes the recorded objects in the output format: a YAML stream (yaml) or a List (json).
+func (a *appliedObjects) write(w io.Writer) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	if a.output == "json" {
+		items := make([]interface{}, len(a.objects))
+		for i, obj := range a.objects {
+			items[i] = obj
+		}
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"apiVersion": "v1",
+			"items":      items,
+			"kind":       "List",
+		}, "", "    ")
+		if err == nil {
+			_, err = fmt.Fprintf(w, "%s\n", data)
+		}
+		return err
+	}
+	for _, obj := range a.objects {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "---\n%s", data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDir writes each recorded object to its own file in dir, in the output format, so that the objects can be committed to a
+// repository. Files are named after the kind and name of their objects (e.g. pod-web.yaml), and dir is created if it does not exist.
+func (a *appliedObjects) writeDir(vfs fs.VirtualFileSystem, dir string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	err := vfs.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	for _, obj := range a.objects {
+		var data []byte
+		if a.output == "json" {
+			data, err = json.MarshalIndent(obj, "", "    ")
+			data = append(data, '\n')
+		} else {
+			data, err = yaml.Marshal(obj)
+		}
+		if err != nil {
+			return err
+		}
+		metadata, _ := obj["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		kind, _ := obj["kind"].(string)
+		err = writeFile(vfs, filepath.Join(dir, fmt.Sprintf("%s-%s.%s", strings.ToLower(kind), name, a.output)), data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(vfs fs.VirtualFileSystem, name string, data []byte) error {
+	file, err := vfs.Create(name)
+	if err != nil {
+		return err
+	}
+	defer util.CloseAndLogError(file)
+	_, err = file.Write(data)
+	return err
+}
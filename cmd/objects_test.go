@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestAppliedObjects(output string) *appliedObjects {
+	a := &appliedObjects{
+		output: output,
+	}
+	a.record(&v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "db",
+		},
+		Data: map[string][]byte{
+			"password": []byte("hunter2"),
+		},
+	})
+	return a
+}
+
+func TestAppliedObjects_WriteYAML(t *testing.T) {
+	a := newTestAppliedObjects("yaml")
+	var buffer bytes.Buffer
+	err := a.write(&buffer)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := "---\napiVersion: v1\nkind: Secret\nmetadata:\n  creationTimestamp: null\n  name: db\n"
+	if buffer.String() != expected {
+		t.Error(buffer.String())
+	}
+}
+
+func TestAppliedObjects_WriteJSON(t *testing.T) {
+	a := newTestAppliedObjects("json")
+	var buffer bytes.Buffer
+	err := a.write(&buffer)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := `{
+    "apiVersion": "v1",
+    "items": [
+        {
+            "apiVersion": "v1",
+            "kind": "Secret",
+            "metadata": {
+                "creationTimestamp": null,
+                "name": "db"
+            }
+        }
+    ],
+    "kind": "List"
+}
+`
+	if buffer.String() != expected {
+		t.Error(buffer.String())
+	}
+}
+
+func TestAppliedObjects_WriteDir(t *testing.T) {
+	a := newTestAppliedObjects("yaml")
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{})
+	err := a.writeDir(vfs, "/manifests")
+	if err != nil {
+		t.Error(err)
+	}
+	file, err := vfs.Open("/manifests/secret-db.yaml")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	data, _ := ioutil.ReadAll(file)
+	expected := "apiVersion: v1\nkind: Secret\nmetadata:\n  creationTimestamp: null\n  name: db\n"
+	if string(data) != expected {
+		t.Error(string(data))
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+)
+
+const (
+	// The name of kube-compose as a docker CLI plugin. The docker CLI runs "docker kube-compose" by running the executable
+	// "docker-kube-compose" in a plugin directory (such as ~/.docker/cli-plugins) with the plugin name as the first argument.
+	dockerCLIPluginName = "kube-compose"
+	// The docker CLI runs plugins with only this argument to get their metadata.
+	dockerCLIPluginMetadataArg = "docker-cli-plugin-metadata"
+	// The environment variable that the docker CLI sets when it runs plugins.
+	dockerCLIPluginEnvVarName = "DOCKER_CLI_PLUGIN_ORIGINAL_CLI_COMMAND"
+)
+
+type dockerCLIPluginMetadata struct {
+	SchemaVersion    string
+	Vendor           string
+	Version          string
+	ShortDescription string
+	URL              string
+}
+
+// writeDockerCLIPluginMetadata writes the metadata that the docker CLI requires of plugins.
+func writeDockerCLIPluginMetadata(w io.Writer) error {
+	return json.NewEncoder(w).Encode(&dockerCLIPluginMetadata{
+		SchemaVersion:    "0.1.0",
+		Vendor:           "kube-compose",
+		Version:          version,
+		ShortDescription: "Run docker compose projects on Kubernetes",
+		URL:              "https://github.com/kube-compose/kube-compose",
+	})
+}
+
+// getDockerCLIPluginArgs returns the arguments of kube-compose if it was run by the docker CLI as a plugin, which are the arguments after
+// the plugin name. The second return value is false if kube-compose was not run as a plugin.
+func getDockerCLIPluginArgs(args []string) ([]string, bool) {
+	if _, ok := envGetter(dockerCLIPluginEnvVarName); !ok || len(args) == 0 || args[0] != dockerCLIPluginName {
+		return args, false
+	}
+	return args[1:], true
+}
+
+// loadDockerCLIConfig loads the configuration of the docker CLI, so that kube-compose connects to the docker daemon of the current
+// docker context and pulls images with the registry credentials of the docker CLI. The docker daemon is configured by setting the
+// environment variables of the docker client. If the configuration cannot be loaded then a warning is logged and nil is returned.
+func loadDockerCLIConfig() *docker.CLIConfig {
+	dir, err := docker.CLIConfigDir(envGetter)
+	if err != nil {
+		log.Warnf("not using the docker CLI configuration: %v", err)
+		return nil
+	}
+	cliConfig, err := docker.LoadCLIConfig(dir)
+	if err != nil {
+		log.Warnf("not using the docker CLI configuration: %v", err)
+		return nil
+	}
+	env, err := cliConfig.ContextEnv(envGetter)
+	if err != nil {
+		log.Warnf("not using the docker context: %v", err)
+	}
+	for name, value := range env {
+		err = os.Setenv(name, value)
+		if err != nil {
+			log.Warnf("not using the docker context: %v", err)
+		}
+	}
+	return cliConfig
+}
+
+// The name of the executable of kube-compose as a kubectl plugin. kubectl runs "kubectl compose" by running the executable
+// "kubectl-compose" on the PATH.
+const kubectlPluginExecutableName = "kubectl-compose"
+
+// isKubectlPlugin returns true if and only if the executable at path is kube-compose installed as a kubectl plugin.
+func isKubectlPlugin(path string) bool {
+	return strings.TrimSuffix(filepath.Base(path), ".exe") == kubectlPluginExecutableName
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_GetDockerCLIPluginArgs_Plugin(t *testing.T) {
+	withMockedEnv(map[string]string{
+		dockerCLIPluginEnvVarName: "/usr/bin/docker",
+	}, func() {
+		args, ok := getDockerCLIPluginArgs([]string{"kube-compose", "up", "-d"})
+		if !ok || !reflect.DeepEqual(args, []string{"up", "-d"}) {
+			t.Error(args, ok)
+		}
+	})
+}
+
+func Test_GetDockerCLIPluginArgs_NotPlugin(t *testing.T) {
+	withMockedEnv(map[string]string{}, func() {
+		args, ok := getDockerCLIPluginArgs([]string{"kube-compose", "up"})
+		if ok || !reflect.DeepEqual(args, []string{"kube-compose", "up"}) {
+			t.Error(args, ok)
+		}
+	})
+}
+
+func Test_WriteDockerCLIPluginMetadata(t *testing.T) {
+	var buffer bytes.Buffer
+	err := writeDockerCLIPluginMetadata(&buffer)
+	if err != nil {
+		t.Error(err)
+	}
+	var metadata map[string]string
+	err = json.Unmarshal(buffer.Bytes(), &metadata)
+	if err != nil {
+		t.Error(err)
+	}
+	if metadata["SchemaVersion"] != "0.1.0" || metadata["Version"] != version {
+		t.Error(metadata)
+	}
+}
+
+func Test_IsKubectlPlugin(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"/usr/local/bin/kubectl-compose":     true,
+		"kubectl-compose.exe":                true,
+		"/usr/local/bin/kube-compose":        false,
+		"/usr/local/bin/docker-kube-compose": false,
+	} {
+		if isKubectlPlugin(path) != expected {
+			t.Error(path)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kube-compose/kube-compose/internal/app/publish"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/remote"
+	"github.com/spf13/cobra"
+)
+
+const resolveImageDigestsFlagName = "resolve-image-digests"
+
+func newPublishCli() *cobra.Command {
+	var publishCmd = &cobra.Command{
+		Use:   "publish REPOSITORY[:TAG]",
+		Short: "Publish the docker compose project as an OCI artifact",
+		Long: "pushes the docker compose files and the env files that they refer to as an OCI artifact to a registry, so that the " +
+			"project can be run with -f oci://REPOSITORY[:TAG]",
+		Args: cobra.ExactArgs(1),
+		RunE: publishCommand,
+	}
+	publishCmd.PersistentFlags().BoolP(resolveImageDigestsFlagName, "", false,
+		"Pin the images of docker compose services to the digests of their manifests in their registries")
+	return publishCmd
+}
+
+func publishCommand(cmd *cobra.Command, args []string) error {
+	files, err := getFileFlags(cmd.Flags())
+	if err != nil {
+		exitWithError(exitcode.Wrap(err, exitcode.Config))
+	}
+	files, err = resolveRemoteFiles(files)
+	if err != nil {
+		exitWithError(exitcode.Wrap(err, exitcode.Config))
+	}
+	opts := &publish.Options{}
+	opts.DockerConfig = loadDockerCLIConfig()
+	opts.ResolveImageDigests, _ = cmd.Flags().GetBool(resolveImageDigestsFlagName)
+	opts.Template, _ = cmd.Flags().GetBool(templateFlagName)
+	digest, err := publish.Run(files, args[0], opts)
+	if err != nil {
+		exitWithError(err)
+	}
+	ref, _ := remote.ParseReference(args[0])
+	ref.Tag = digest
+	fmt.Println(ref)
+	return nil
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/kube-compose/kube-compose/internal/app/registrytokens"
+	"github.com/kube-compose/kube-compose/internal/pkg/remote"
+	"github.com/spf13/cobra"
+)
+
+func newRegistryTokensCli() *cobra.Command {
+	var registryTokensCmd = &cobra.Command{
+		Use:   "registry-tokens",
+		Short: "Manage the cached bearer tokens of registries",
+		Long: "lists, refreshes and clears the bearer tokens that registries issued to kube-compose, which are cached in the keychain of the " +
+			"operating system so that they are reused across commands",
+	}
+	registryTokensCmd.AddCommand(&cobra.Command{
+		Use:   "list [REGISTRY]",
+		Short: "List the cached tokens of a registry (or of all registries), without their values",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  registryTokensListCommand,
+	}, &cobra.Command{
+		Use:   "refresh [REGISTRY]",
+		Short: "Request new tokens for the cached tokens of a registry (or of all registries)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  registryTokensRefreshCommand,
+	}, &cobra.Command{
+		Use:   "clear [REGISTRY]",
+		Short: "Remove the cached tokens of a registry (or of all registries), but not the credentials of the docker CLI",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  registryTokensClearCommand,
+	})
+	return registryTokensCmd
+}
+
+// getRegistryArg returns the registry of the arguments of the registry-tokens commands, or the empty string for all registries.
+func getRegistryArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+func registryTokensListCommand(_ *cobra.Command, args []string) error {
+	err := registrytokens.List(remote.Tokens, getRegistryArg(args), os.Stdout)
+	if err != nil {
+		exitWithError(err)
+	}
+	return nil
+}
+
+func registryTokensRefreshCommand(_ *cobra.Command, args []string) error {
+	err := registrytokens.Refresh(remote.Tokens, loadDockerCLIConfig(), getRegistryArg(args), os.Stdout)
+	if err != nil {
+		exitWithError(err)
+	}
+	return nil
+}
+
+func registryTokensClearCommand(_ *cobra.Command, args []string) error {
+	err := registrytokens.Clear(remote.Tokens, getRegistryArg(args), os.Stdout)
+	if err != nil {
+		exitWithError(err)
+	}
+	return nil
+}
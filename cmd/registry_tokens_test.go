@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestNewRegistryTokensCli(t *testing.T) {
+	cmd := newRegistryTokensCli()
+	var names []string
+	for _, subcommand := range cmd.Commands() {
+		names = append(names, subcommand.Name())
+	}
+	if len(names) != 3 || names[0] != "clear" || names[1] != "list" || names[2] != "refresh" {
+		t.Error(names)
+	}
+}
+
+func TestGetRegistryArg(t *testing.T) {
+	if registry := getRegistryArg(nil); registry != "" {
+		t.Error(registry)
+	}
+	if registry := getRegistryArg([]string{"registry.example.com"}); registry != "registry.example.com" {
+		t.Error(registry)
+	}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/up"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/policy"
+	"github.com/spf13/cobra"
+)
+
+func newRenderCli() *cobra.Command {
+	var renderCmd = &cobra.Command{
+		Use:   "render",
+		Short: "Print the Kubernetes objects of the specified docker compose services without applying them",
+		Long: "prints the pods, PersistentVolumeClaims and Kubernetes services that up would apply, without connecting to a cluster or " +
+			"docker daemon, so that they can be reviewed or committed to a GitOps repository",
+		RunE: renderCommand,
+	}
+	renderCmd.PersistentFlags().StringP("output", "o", "yaml", "The output format, json (a List, or a file per object with "+
+		"--output-dir) or yaml")
+	renderCmd.PersistentFlags().StringP("output-dir", "", "", "When set, each object is written to its own file in this directory "+
+		"(e.g. pod-web.yaml) instead of standard output")
+	renderCmd.PersistentFlags().BoolP("run-as-user", "", false, "When set, the runAsUser/runAsGroup of each pod is set based on the "+
+		"\"user\" key of the pod's docker-compose service")
+	renderCmd.PersistentFlags().StringP("dependency-wait-mode", "", string(up.DependencyWaitModeClient), fmt.Sprintf("How depends_on "+
+		"is enforced. One of %s and %s (init containers wait for dependencies, so that the objects can be applied by other means)",
+		up.DependencyWaitModeClient, up.DependencyWaitModeInitContainer))
+	renderCmd.PersistentFlags().BoolP("host-ports", "", false, "When set, published ports are published on the nodes that run the "+
+		"pods (hostPort)")
+	renderCmd.PersistentFlags().StringP("ip-family-policy", "", "", fmt.Sprintf("The IP family policy of the Kubernetes services. One "+
+		"of %s, %s and %s", k8smeta.IPFamilyPolicySingleStack, k8smeta.IPFamilyPolicyPreferDualStack, k8smeta.IPFamilyPolicyRequireDualStack))
+	renderCmd.PersistentFlags().StringSliceP("ip-families", "", nil, fmt.Sprintf("The IP families (%s and/or %s) of the Kubernetes "+
+		"services", k8smeta.IPv4, k8smeta.IPv6))
+	renderCmd.PersistentFlags().StringP("mesh", "", "", fmt.Sprintf("The service mesh whose sidecar proxy is injected into pods. One of "+
+		"%s and %s", up.MeshIstio, up.MeshLinkerd))
+	renderCmd.PersistentFlags().BoolP("service-aliases", "", false, "When set, each docker compose service with ports also gets a "+
+		"Kubernetes service named exactly like the docker compose service")
+	renderCmd.PersistentFlags().StringSliceP("dns-search", "", nil, "Search domains added to the DNS configuration of pods, at most 6")
+	renderCmd.PersistentFlags().BoolP("strict", "", false, "When set, fails if docker compose services set fields that cannot be "+
+		"mapped to Kubernetes, instead of warning about them")
+	renderCmd.PersistentFlags().BoolP("synthesize-probes", "", false, "When set, docker compose services without a healthcheck get a "+
+		"TCP readiness probe on their first published port")
+	renderCmd.PersistentFlags().BoolP("liveness-probes", "", false, "When set, healthchecks are also converted to liveness probes")
+	renderCmd.PersistentFlags().StringArrayP("env", "", nil, "Set an environment variable of a docker compose service "+
+		"(SERVICE.KEY=VALUE) or of all docker compose services (KEY=VALUE), taking precedence over the docker compose files. Can be "+
+		"repeated")
+	for _, flag := range defaultResourceFlags {
+		renderCmd.PersistentFlags().StringP(flag.name, "", "", fmt.Sprintf("The default %s %s of containers (a Kubernetes quantity)",
+			flag.resourceName, flag.kind()))
+	}
+	renderCmd.PersistentFlags().StringP("policy", "", "", "When set, the objects are evaluated against the Rego policies in this "+
+		"directory, and render fails with the violation messages. Requires opa")
+	renderCmd.PersistentFlags().StringArrayP("mutator-exec", "", nil, "An executable that mutates each object before it is printed "+
+		"(see up). Can be repeated")
+	renderCmd.PersistentFlags().StringArrayP("mutator-webhook", "", nil, "A URL to which the JSON of each object is posted before it "+
+		"is printed (see up). Can be repeated, and runs after --mutator-exec")
+	return renderCmd
+}
+
+// getRenderOptions returns the options of up.Render from the flags of the render command.
+func getRenderOptions(cmd *cobra.Command) (*up.Options, error) {
+	opts := &up.Options{}
+	opts.RunAsUser, _ = cmd.Flags().GetBool("run-as-user")
+	opts.HostPorts, _ = cmd.Flags().GetBool("host-ports")
+	opts.ServiceAliases, _ = cmd.Flags().GetBool("service-aliases")
+	opts.DNSSearches, _ = cmd.Flags().GetStringSlice("dns-search")
+	opts.Strict, _ = cmd.Flags().GetBool("strict")
+	opts.SynthesizeProbes, _ = cmd.Flags().GetBool("synthesize-probes")
+	opts.LivenessProbes, _ = cmd.Flags().GetBool("liveness-probes")
+	dependencyWaitMode, _ := cmd.Flags().GetString("dependency-wait-mode")
+	opts.DependencyWaitMode = up.DependencyWaitMode(dependencyWaitMode)
+	if opts.DependencyWaitMode != up.DependencyWaitModeClient && opts.DependencyWaitMode != up.DependencyWaitModeInitContainer {
+		return nil, fmt.Errorf("the flag --dependency-wait-mode can only be set to one of %s and %s", up.DependencyWaitModeClient,
+			up.DependencyWaitModeInitContainer)
+	}
+	mesh, _ := cmd.Flags().GetString("mesh")
+	opts.Mesh = up.Mesh(mesh)
+	if opts.Mesh != up.MeshNone && opts.Mesh != up.MeshIstio && opts.Mesh != up.MeshLinkerd {
+		return nil, fmt.Errorf("the flag --mesh can only be set to one of %s and %s", up.MeshIstio, up.MeshLinkerd)
+	}
+	err := setIPFamiliesFromFlags(cmd, opts)
+	if err != nil {
+		return nil, err
+	}
+	if policyDir, _ := cmd.Flags().GetString("policy"); policyDir != "" {
+		opts.Policies, err = policy.Load(policyDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	setMutatorsFromFlags(cmd, opts)
+	return opts, nil
+}
+
+// getRenderOutput returns the recorder of the rendered objects in the output format of the flag --output.
+func getRenderOutput(cmd *cobra.Command) (*appliedObjects, error) {
+	output, _ := cmd.Flags().GetString("output")
+	if output != "json" && output != "yaml" {
+		return nil, fmt.Errorf("the flag --output can only be set to one of json and yaml")
+	}
+	return &appliedObjects{
+		output: output,
+	}, nil
+}
+
+func renderCommand(cmd *cobra.Command, args []string) error {
+	opts, err := getRenderOptions(cmd)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	objects, err := getRenderOutput(cmd)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	// Rendering does not connect to a cluster, so the kube config is not loaded.
+	cfg, err := loadCommandConfig(cmd, args, false)
+	if err != nil {
+		return err
+	}
+	err = setDefaultResourcesFromFlags(cmd, cfg)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	envOverrides, _ := cmd.Flags().GetStringArray("env")
+	err = config.ApplyEnvironmentOverrides(cfg, envOverrides)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	rendered, err := up.Render(cfg, opts)
+	if err != nil {
+		exitWithError(err)
+	}
+	for _, obj := range rendered {
+		objects.record(obj)
+	}
+	if outputDir, _ := cmd.Flags().GetString("output-dir"); outputDir != "" {
+		err = objects.writeDir(fs.OS, outputDir)
+	} else {
+		err = objects.write(os.Stdout)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+	return nil
+}
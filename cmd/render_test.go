@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/up"
+)
+
+func TestGetRenderOptions_Defaults(t *testing.T) {
+	cmd := newRenderCli()
+	_ = cmd.ParseFlags(nil)
+	opts, err := getRenderOptions(cmd)
+	if err != nil {
+		t.Error(err)
+	} else if opts.DependencyWaitMode != up.DependencyWaitModeClient || opts.Mesh != up.MeshNone || opts.IPFamilies != nil {
+		t.Error(opts)
+	}
+}
+
+func TestGetRenderOptions_Success(t *testing.T) {
+	cmd := newRenderCli()
+	_ = cmd.ParseFlags([]string{"--dependency-wait-mode=init-container", "--mesh=istio", "--service-aliases", "--run-as-user"})
+	opts, err := getRenderOptions(cmd)
+	if err != nil {
+		t.Error(err)
+	} else if opts.DependencyWaitMode != up.DependencyWaitModeInitContainer || opts.Mesh != up.MeshIstio || !opts.ServiceAliases ||
+		!opts.RunAsUser {
+		t.Error(opts)
+	}
+}
+
+func TestGetRenderOptions_InvalidMesh(t *testing.T) {
+	cmd := newRenderCli()
+	_ = cmd.ParseFlags([]string{"--mesh=consul"})
+	_, err := getRenderOptions(cmd)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestGetRenderOutput_Invalid(t *testing.T) {
+	cmd := newRenderCli()
+	_ = cmd.ParseFlags([]string{"-o", "xml"})
+	_, err := getRenderOutput(cmd)
+	if err == nil {
+		t.Fail()
+	}
+}
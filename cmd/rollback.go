@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kube-compose/kube-compose/internal/app/rollback"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/spf13/cobra"
+)
+
+func newRollbackCli() *cobra.Command {
+	var rollbackCmd = &cobra.Command{
+		Use:   "rollback [SERVICE...]",
+		Short: "Roll back the pods of the specified services (or of all services) to a previous revision",
+		Long: "re-applies the pods of a previous revision of the environment. Each up that creates pods records a revision, and each " +
+			"rollback records the result as a new revision. Only pods are rolled back",
+		RunE: rollbackCommand,
+	}
+	rollbackCmd.PersistentFlags().IntP("to", "", 0, "The number of the revision to roll back to. Defaults to the revision before the "+
+		"latest revision")
+	rollbackCmd.PersistentFlags().BoolP("list", "", false, "List the revisions of the environment instead of rolling back")
+	return rollbackCmd
+}
+
+// getRollbackOptions returns the options of rollback.Run from the flags of the rollback command.
+func getRollbackOptions(cmd *cobra.Command) (*rollback.Options, error) {
+	opts := &rollback.Options{}
+	opts.To, _ = cmd.Flags().GetInt("to")
+	if opts.To < 0 || (opts.To == 0 && cmd.Flags().Changed("to")) {
+		return nil, fmt.Errorf("the flag --to must be a positive revision number")
+	}
+	if list, _ := cmd.Flags().GetBool("list"); list && opts.To != 0 {
+		return nil, fmt.Errorf("the flags --list and --to cannot be combined")
+	}
+	return opts, nil
+}
+
+func rollbackCommand(cmd *cobra.Command, args []string) error {
+	opts, err := getRollbackOptions(cmd)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	if list, _ := cmd.Flags().GetBool("list"); list {
+		err = rollback.List(cfg, os.Stdout)
+	} else {
+		err = rollback.Run(cfg, opts)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+	return nil
+}
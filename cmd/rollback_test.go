@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestGetRollbackOptions_Success(t *testing.T) {
+	cmd := newRollbackCli()
+	_ = cmd.ParseFlags([]string{"--to=3", "web"})
+	opts, err := getRollbackOptions(cmd)
+	if err != nil || opts.To != 3 {
+		t.Error(opts, err)
+	}
+}
+
+func TestGetRollbackOptions_Invalid(t *testing.T) {
+	for _, flags := range [][]string{
+		{"--to=0"},
+		{"--to=-1"},
+		{"--to=2", "--list"},
+	} {
+		cmd := newRollbackCli()
+		_ = cmd.ParseFlags(flags)
+		_, err := getRollbackOptions(cmd)
+		if err == nil {
+			t.Error(flags)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/app/rollout"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/spf13/cobra"
+)
+
+// The actions of the rollout command, which are named after their flags.
+const (
+	rolloutActionAbort   = "abort"
+	rolloutActionCanary  = "canary"
+	rolloutActionPromote = "promote"
+)
+
+func newRolloutCli() *cobra.Command {
+	var rolloutCmd = &cobra.Command{
+		Use:   "rollout SERVICE",
+		Short: "Roll out a new image of a service to a canary, and promote or abort it",
+		Long: "creates a canary of a docker compose service with a new image, which receives a percentage of the connections to the " +
+			"service's k8s service (--canary and --image). The canary is finished by replacing the service's pod with a pod that runs " +
+			"the new image (--promote), or by deleting it (--abort)",
+		Args: cobra.ExactArgs(1),
+		RunE: rolloutCommand,
+	}
+	rolloutCmd.PersistentFlags().StringP(rolloutActionCanary, "", "", "Roll out --image to a canary that receives the specified "+
+		"percentage of the connections (e.g. 20%)")
+	rolloutCmd.PersistentFlags().StringP("image", "", "", "The image of the canary")
+	rolloutCmd.PersistentFlags().BoolP(rolloutActionPromote, "", false, "Replace the pod of the service with a pod that runs the image "+
+		"of the canary, and delete the canary")
+	rolloutCmd.PersistentFlags().BoolP(rolloutActionAbort, "", false, "Delete the canary")
+	return rolloutCmd
+}
+
+// parseCanaryPercentage parses the value of the flag --canary, which is a percentage between 1% and 99%. The percent sign is optional.
+func parseCanaryPercentage(value string) (int, error) {
+	percentage, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+	if err != nil || percentage < 1 || percentage > 99 {
+		return 0, fmt.Errorf("the flag --%s must be a percentage between 1%% and 99%% (e.g. 20%%), but got %#v", rolloutActionCanary, value)
+	}
+	return percentage, nil
+}
+
+// getRolloutAction returns the action of the rollout command from its flags, and the options of rollout.Start if the action is
+// rolloutActionCanary.
+func getRolloutAction(cmd *cobra.Command) (string, *rollout.Options, error) {
+	var actions []string
+	if cmd.Flags().Changed(rolloutActionCanary) {
+		actions = append(actions, rolloutActionCanary)
+	}
+	if promote, _ := cmd.Flags().GetBool(rolloutActionPromote); promote {
+		actions = append(actions, rolloutActionPromote)
+	}
+	if abort, _ := cmd.Flags().GetBool(rolloutActionAbort); abort {
+		actions = append(actions, rolloutActionAbort)
+	}
+	if len(actions) != 1 {
+		return "", nil, fmt.Errorf("exactly one of the flags --%s, --%s and --%s must be specified", rolloutActionCanary,
+			rolloutActionPromote, rolloutActionAbort)
+	}
+	image, _ := cmd.Flags().GetString("image")
+	if actions[0] != rolloutActionCanary {
+		if image != "" {
+			return "", nil, fmt.Errorf("the flag --image can only be specified with the flag --%s", rolloutActionCanary)
+		}
+		return actions[0], nil, nil
+	}
+	if image == "" {
+		return "", nil, fmt.Errorf("the flag --%s requires the flag --image", rolloutActionCanary)
+	}
+	canary, _ := cmd.Flags().GetString(rolloutActionCanary)
+	percentage, err := parseCanaryPercentage(canary)
+	if err != nil {
+		return "", nil, err
+	}
+	return rolloutActionCanary, &rollout.Options{
+		Image:      image,
+		Percentage: percentage,
+	}, nil
+}
+
+func rolloutCommand(cmd *cobra.Command, args []string) error {
+	action, opts, err := getRolloutAction(cmd)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	service := cfg.Services[args[0]]
+	switch action {
+	case rolloutActionCanary:
+		err = rollout.Start(cfg, service, opts)
+	case rolloutActionPromote:
+		err = rollout.Promote(cfg, service)
+	default:
+		err = rollout.Abort(cfg, service)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+	return nil
+}
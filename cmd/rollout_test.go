@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestGetRolloutAction_Canary(t *testing.T) {
+	cmd := newRolloutCli()
+	_ = cmd.ParseFlags([]string{"--canary=20%", "--image=web:2", "web"})
+	action, opts, err := getRolloutAction(cmd)
+	if err != nil || action != rolloutActionCanary || opts.Image != "web:2" || opts.Percentage != 20 {
+		t.Error(action, opts, err)
+	}
+}
+
+func TestGetRolloutAction_Promote(t *testing.T) {
+	cmd := newRolloutCli()
+	_ = cmd.ParseFlags([]string{"--promote", "web"})
+	action, opts, err := getRolloutAction(cmd)
+	if err != nil || action != rolloutActionPromote || opts != nil {
+		t.Error(action, opts, err)
+	}
+}
+
+func TestGetRolloutAction_Invalid(t *testing.T) {
+	for _, flags := range [][]string{
+		{},
+		{"--promote", "--abort"},
+		{"--canary=20%", "--promote", "--image=web:2"},
+		{"--canary=20%"},
+		{"--abort", "--image=web:2"},
+		{"--canary=0%", "--image=web:2"},
+		{"--canary=100", "--image=web:2"},
+		{"--canary=twenty", "--image=web:2"},
+	} {
+		cmd := newRolloutCli()
+		_ = cmd.ParseFlags(flags)
+		_, _, err := getRolloutAction(cmd)
+		if err == nil {
+			t.Error(flags)
+		}
+	}
+}
+
+func TestParseCanaryPercentage_Success(t *testing.T) {
+	for value, expected := range map[string]int{
+		"1%":  1,
+		"20":  20,
+		"99%": 99,
+	} {
+		percentage, err := parseCanaryPercentage(value)
+		if err != nil || percentage != expected {
+			t.Error(value, percentage, err)
+		}
+	}
+}
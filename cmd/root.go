@@ -2,42 +2,157 @@ package cmd
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 
 	log "github.com/Sirupsen/logrus"
+	units "github.com/docker/go-units"
+	"github.com/kube-compose/kube-compose/internal/app/ephemeral"
+	"github.com/kube-compose/kube-compose/internal/pkg/bandwidth"
+	"github.com/kube-compose/kube-compose/internal/pkg/proxy"
+	"github.com/kube-compose/kube-compose/internal/pkg/remote"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
 	"github.com/spf13/cobra"
 )
 
 const (
-	envVarPrefix        = "KUBECOMPOSE_"
-	fileFlagName        = "file"
-	namespaceEnvVarName = envVarPrefix + "NAMESPACE"
-	namespaceFlagName   = "namespace"
-	envIDEnvVarName     = envVarPrefix + "ENVID"
-	envIDFlagName       = "env-id"
+	clusterFlagName        = "cluster"
+	contextFlagName        = "context"
+	envVarPrefix           = "KUBECOMPOSE_"
+	fileFlagName           = "file"
+	kubeConfigFlagName     = "kubeconfig"
+	limitBandwidthFlagName = "limit-bandwidth"
+	namespaceEnvVarName    = envVarPrefix + "NAMESPACE"
+	namespaceFlagName      = "namespace"
+	registryProxyFlagName  = "registry-proxy"
+	envIDEnvVarName        = envVarPrefix + "ENVID"
+	envIDFlagName          = "env-id"
+	ephemeralFlagName      = "ephemeral"
+	localFileFlagName      = "local-file"
+	stateFileFlagName      = "state-file"
+	templateFlagName       = "template"
+	userFlagName           = "user"
 )
 
+const version = "0.6.1"
+
 func Execute() error {
 	log.SetOutput(os.Stdout)
+	args := os.Args[1:]
+	if len(args) == 1 && args[0] == dockerCLIPluginMetadataArg {
+		return writeDockerCLIPluginMetadata(os.Stdout)
+	}
+	args, isDockerCLIPlugin := getDockerCLIPluginArgs(args)
 	rootCmd := &cobra.Command{
 		Use:               "kube-compose",
 		Short:             "k8s",
 		Long:              "Environments on k8s made easy",
-		Version:           "0.6.1",
-		PersistentPreRunE: setupLogging,
+		Version:           version,
+		PersistentPreRunE: persistentPreRun,
+	}
+	if isDockerCLIPlugin {
+		rootCmd.Use = "docker kube-compose"
+	} else if isKubectlPlugin(os.Args[0]) {
+		rootCmd.Use = "kubectl compose"
 	}
-	rootCmd.AddCommand(newDownCli(), newUpCli(), newGetCli())
+	rootCmd.SetArgs(args)
+	rootCmd.AddCommand(newDownCli(), newUpCli(), newGetCli(), newDebugBundleCli(), newWatchCli(), newGCCli(), newTestCli(),
+		newPublishCli(), newInspectImageCli(), newLogsCli(), newRenderCli(), newDebugCli(), newRolloutCli(), newRollbackCli(),
+		newHistoryCli(), newRegistryTokensCli())
 	setRootCommandFlags(rootCmd)
 	return rootCmd.Execute()
 }
 
+// persistentPreRun sets the flags that are not set on the command line (see setFlagsFromEnvAndConfigFile), sets up logging, sets up
+// the proxies and bandwidth limit of connections with registries and sets up the cache of registry tokens.
+func persistentPreRun(cmd *cobra.Command, args []string) error {
+	if err := setFlagsFromEnvAndConfigFile(cmd); err != nil {
+		return err
+	}
+	if err := setupLogging(cmd, args); err != nil {
+		return err
+	}
+	if err := setupRegistryProxies(cmd); err != nil {
+		return err
+	}
+	setupRegistryTokenCache()
+	return setupBandwidthLimit(cmd)
+}
+
+// setupRegistryTokenCache caches the bearer tokens of registries across commands in the keychain of the operating system, so that tokens
+// are never stored in plaintext files.
+func setupRegistryTokenCache() {
+	remote.Tokens = remote.NewTokenCache()
+}
+
+// setupRegistryProxies makes http.DefaultClient, which is used by the transfers of kube-compose with registries and of remote docker
+// compose files, use the proxies of the flag --registry-proxy. Without the flag, http.DefaultClient uses the proxies of the environment
+// variables HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+func setupRegistryProxies(cmd *cobra.Command) error {
+	overrides, _ := cmd.Flags().GetStringToString(registryProxyFlagName)
+	if len(overrides) == 0 {
+		return nil
+	}
+	proxyFunc, err := proxy.Func(overrides)
+	if err != nil {
+		return fmt.Errorf("invalid --%s: %v", registryProxyFlagName, err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFunc
+	http.DefaultClient.Transport = transport
+	return nil
+}
+
+// setupBandwidthLimit throttles the transfers of http.DefaultClient, which is used by the transfers of kube-compose with registries and
+// remote docker compose files, if the flag --limit-bandwidth is set. The docker daemon transfers the layers of images itself, so pulls and
+// pushes by the daemon cannot be throttled this way.
+func setupBandwidthLimit(cmd *cobra.Command) error {
+	s, _ := cmd.Flags().GetString(limitBandwidthFlagName)
+	if s == "" {
+		return nil
+	}
+	bytesPerSecond, err := bandwidth.ParseRate(s)
+	if err != nil {
+		return fmt.Errorf("invalid --%s: %v", limitBandwidthFlagName, err)
+	}
+	base := http.DefaultClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	http.DefaultClient.Transport = bandwidth.NewLimiter(bytesPerSecond).Transport(base)
+	log.Debugf("limiting bandwidth to %s/s", units.BytesSize(float64(bytesPerSecond)))
+	return nil
+}
+
 func setRootCommandFlags(rootCmd *cobra.Command) {
-	rootCmd.PersistentFlags().StringSliceP(fileFlagName, "f", []string{}, "Specify an alternate compose file")
+	rootCmd.PersistentFlags().StringSliceP(fileFlagName, "f", []string{}, "Specify an alternate compose file, or a remote "+
+		"source of a compose file: an http(s):// URL, git::<repository>//<path>[?ref=<ref>] or oci://<registry>/<repository>[:<tag>]")
+	rootCmd.PersistentFlags().StringP(localFileFlagName, "", dockerComposeConfig.DefaultLocalFile, "A developer-specific (typically "+
+		"git-ignored) docker compose file that is merged last if it exists. Relative to the directory of the first docker compose file. "+
+		"Set to the empty string to disable")
+	rootCmd.PersistentFlags().BoolP(templateFlagName, "", false, "Render docker compose files as Go templates before parsing them. "+
+		"The template functions env, file and b64enc are available")
 	rootCmd.PersistentFlags().StringP(namespaceFlagName, "n", "", fmt.Sprintf("namespace for environment. Can also be set via "+
 		"environment variable %s. Default to the namespace of the current kube config context", namespaceEnvVarName))
 	rootCmd.PersistentFlags().StringP(envIDFlagName, "e", "", "used to isolate environments deployed to a shared namespace, "+
 		"by (1) using this value as a suffix of pod and service names and (2) using this value to isolate selectors. Either this flag or "+
 		fmt.Sprintf("the environment variable %s must be set", envIDEnvVarName))
+	rootCmd.PersistentFlags().BoolP(ephemeralFlagName, "", false, fmt.Sprintf("Use the namespace of an ephemeral environment, "+
+		"derived from the project name (the environment variable %s or the directory of the first docker compose file) and the "+
+		"current git branch (or short commit SHA if HEAD is detached). Cannot be combined with --%s", ephemeral.ProjectNameEnvVarName,
+		namespaceFlagName))
+	rootCmd.PersistentFlags().StringP(stateFileFlagName, "", "", "A local file in which up records what was deployed, and that down "+
+		"reads to delete what was deployed even if the docker compose files have since changed. Defaults to recording this in a "+
+		"ConfigMap of the namespace")
+	rootCmd.PersistentFlags().StringP(kubeConfigFlagName, "", "", "Path to the kube config file to use, like the flag of kubectl")
+	rootCmd.PersistentFlags().StringP(contextFlagName, "", "", "The name of the kube config context to use, like the flag of kubectl")
+	rootCmd.PersistentFlags().StringP(clusterFlagName, "", "", "The name of the kube config cluster to use, like the flag of kubectl")
+	rootCmd.PersistentFlags().StringP(userFlagName, "", "", "The name of the kube config user to use, like the flag of kubectl")
 	rootCmd.PersistentFlags().StringP(logLevelFlagName, "l", "", fmt.Sprintf("Set to one of %s. Can also be set via environment variable "+
 		"%s. Defaults to %s", formattedLogLevelList, logLevelEnvVarName, logLevelDefault.String()))
+	rootCmd.PersistentFlags().StringP(limitBandwidthFlagName, "", "", "Limit the combined throughput of transfers with registries and "+
+		"of remote docker compose files to a rate in bytes per second (e.g. 10MB or 512KiB). Unlimited by default")
+	rootCmd.PersistentFlags().StringToStringP(registryProxyFlagName, "", nil, fmt.Sprintf("The proxies of registries, of the form "+
+		"host=http://proxy:3128, host=socks5://proxy:1080 or host=%s. Registries without a proxy in this flag use the proxies of the "+
+		"environment variables HTTP_PROXY, HTTPS_PROXY and NO_PROXY", proxy.Direct))
 }
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func withDefaultClientTransport(cb func()) {
+	orig := http.DefaultClient.Transport
+	defer func() {
+		http.DefaultClient.Transport = orig
+	}()
+	cb()
+}
+
+func Test_SetupBandwidthLimit_Success(t *testing.T) {
+	withDefaultClientTransport(func() {
+		cmd := &cobra.Command{}
+		setRootCommandFlags(cmd)
+		_ = cmd.ParseFlags([]string{"--" + limitBandwidthFlagName, "10MB"})
+		err := setupBandwidthLimit(cmd)
+		if err != nil || http.DefaultClient.Transport == nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_SetupBandwidthLimit_Unlimited(t *testing.T) {
+	withDefaultClientTransport(func() {
+		cmd := &cobra.Command{}
+		setRootCommandFlags(cmd)
+		orig := http.DefaultClient.Transport
+		err := setupBandwidthLimit(cmd)
+		if err != nil || http.DefaultClient.Transport != orig {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_SetupBandwidthLimit_Invalid(t *testing.T) {
+	withDefaultClientTransport(func() {
+		cmd := &cobra.Command{}
+		setRootCommandFlags(cmd)
+		_ = cmd.ParseFlags([]string{"--" + limitBandwidthFlagName, "fast"})
+		err := setupBandwidthLimit(cmd)
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_SetupRegistryProxies_Success(t *testing.T) {
+	withDefaultClientTransport(func() {
+		cmd := &cobra.Command{}
+		setRootCommandFlags(cmd)
+		_ = cmd.ParseFlags([]string{"--" + registryProxyFlagName, "registry.example.com=http://proxy:3128"})
+		err := setupRegistryProxies(cmd)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		transport, ok := http.DefaultClient.Transport.(*http.Transport)
+		if !ok {
+			t.Fail()
+			return
+		}
+		req, _ := http.NewRequest(http.MethodGet, "https://registry.example.com/v2/", nil)
+		proxyURL, err := transport.Proxy(req)
+		if err != nil || proxyURL == nil || proxyURL.String() != "http://proxy:3128" {
+			t.Error(proxyURL, err)
+		}
+	})
+}
+
+func Test_SetupRegistryProxies_Invalid(t *testing.T) {
+	withDefaultClientTransport(func() {
+		cmd := &cobra.Command{}
+		setRootCommandFlags(cmd)
+		_ = cmd.ParseFlags([]string{"--" + registryProxyFlagName, "registry.example.com=proxy"})
+		err := setupRegistryProxies(cmd)
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
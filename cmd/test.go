@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/test"
+	"github.com/kube-compose/kube-compose/internal/app/up"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	"github.com/kube-compose/kube-compose/internal/pkg/timing"
+	"github.com/spf13/cobra"
+)
+
+func newTestCli() *cobra.Command {
+	var testCmd = &cobra.Command{
+		Use:   "test",
+		Short: "Run a docker compose service that runs tests against the other docker compose services",
+		Long: "creates the pods and services of the docker compose services other than the test service, runs the test service as a Job " +
+			"once its depends_on conditions are satisfied, prints its logs, and deletes all pods and services afterwards",
+		Args: cobra.NoArgs,
+		RunE: testCommand,
+	}
+	testCmd.PersistentFlags().StringP("service", "", "", "The docker compose service that runs the tests (required)")
+	return testCmd
+}
+
+func testCommand(cmd *cobra.Command, _ []string) error {
+	cfg, err := getCommandConfig(cmd, nil)
+	if err != nil {
+		return err
+	}
+	serviceName, _ := cmd.Flags().GetString("service")
+	if serviceName == "" {
+		return exitcode.Wrap(fmt.Errorf("the flag --service is required"), exitcode.Config)
+	}
+	service := cfg.Services[serviceName]
+	if service == nil {
+		return exitcode.Wrap(fmt.Errorf("no service named %#v exists", serviceName), exitcode.Config)
+	}
+	opts := &up.Options{}
+	opts.Context = context.Background()
+	opts.Detach = true
+	opts.DependencyWaitMode = up.DependencyWaitModeClient
+	opts.ExposeMode = up.ExposeModeIngress
+	opts.Reporter = reporter.New(os.Stdout)
+	opts.Timing = timing.NewRecorder()
+	opts.DockerConfig = loadDockerCLIConfig()
+	exitCode, err := test.Run(cfg, opts, service, os.Stdout)
+	timingErr := writeTimingReports(cmd, cfg, opts.Timing, os.Stdout)
+	if err != nil {
+		exitWithError(err)
+	}
+	if timingErr != nil {
+		log.Error(timingErr)
+	}
+	err = getTestExitError(service.Name(), exitCode)
+	if err != nil {
+		exitWithError(err)
+	}
+	return nil
+}
+
+// getTestExitError returns an error if the container of the test service exited with a non-zero exit code. test then exits with
+// exitcode.TestFailure, and the exit code of the container is only logged, because it would overlap with the exit codes of kube-compose.
+func getTestExitError(serviceName string, exitCode int) error {
+	if exitCode == 0 {
+		return nil
+	}
+	return exitcode.Wrap(fmt.Errorf("the test service %s exited with exit code %d", serviceName, exitCode), exitcode.TestFailure)
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+)
+
+func TestGetTestExitError_Success(t *testing.T) {
+	if err := getTestExitError("tests", 0); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetTestExitError_Failure(t *testing.T) {
+	// Exit code 2 of the container must not be mistaken for exitcode.Config.
+	err := getTestExitError("tests", 2)
+	if exitcode.FromError(err) != exitcode.TestFailure || !strings.Contains(err.Error(), "exit code 2") {
+		t.Error(err)
+	}
+}
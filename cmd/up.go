@@ -2,13 +2,28 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/ephemeral"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
 	"github.com/kube-compose/kube-compose/internal/app/up"
+	"github.com/kube-compose/kube-compose/internal/pkg/digestcache"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/mutate"
+	"github.com/kube-compose/kube-compose/internal/pkg/policy"
 	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	"github.com/kube-compose/kube-compose/internal/pkg/timing"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
 )
 
 func newUpCli() *cobra.Command {
@@ -18,23 +33,225 @@ func newUpCli() *cobra.Command {
 		Long:  "creates pods and services in an order that respects depends_on in the docker compose file",
 		RunE:  upCommand,
 	}
+	addContextMapFlag(upCmd.PersistentFlags())
+	addMetricsAddressFlag(upCmd.PersistentFlags())
 	upCmd.PersistentFlags().BoolP("detach", "d", false, "Detached mode: Run containers in the background")
 	upCmd.PersistentFlags().BoolP("run-as-user", "", false, "When set, the runAsUser/runAsGroup will be set for each pod based on the "+
 		"user of the pod's image and the \"user\" key of the pod's docker-compose service")
+	upCmd.PersistentFlags().IntP("concurrency", "", 8, "The maximum number of pods to create in parallel. Pods are created in waves "+
+		"that respect depends_on. If not positive, the number of pods created in parallel is not limited")
+	upCmd.PersistentFlags().IntP("pull-concurrency", "", 4, "The maximum number of images to pull in parallel. If not positive, the "+
+		"number of images pulled in parallel is not limited")
+	upCmd.PersistentFlags().StringP("dependency-wait-mode", "", string(up.DependencyWaitModeClient), fmt.Sprintf("How depends_on is "+
+		"enforced. One of %s (pods are created once their dependencies are satisfied) and %s (all pods are created immediately, and "+
+		"init containers wait for dependencies)", up.DependencyWaitModeClient, up.DependencyWaitModeInitContainer))
+	upCmd.PersistentFlags().StringP("expose-mode", "", string(up.ExposeModeIngress), fmt.Sprintf("How services with an "+
+		"\"x-kube-compose\".\"ingress\" are exposed. One of %s (Ingresses) and %s (HTTPRoutes of the Gateway API, and TCPRoutes for "+
+		"the other published TCP ports)", up.ExposeModeIngress, up.ExposeModeGateway))
+	upCmd.PersistentFlags().StringP("gateway", "", "", fmt.Sprintf("The Gateway that routes attach to when --expose-mode is %s, "+
+		"in the form [NAMESPACE/]NAME", up.ExposeModeGateway))
+	upCmd.PersistentFlags().BoolP("default-deny", "", false, "When set, a NetworkPolicy denies ingress traffic to the pods of the "+
+		"environment, except traffic to a docker compose service from the services that depend on it or share a network with it (other "+
+		"than the default network)")
+	upCmd.PersistentFlags().BoolP("host-ports", "", false, "When set, published ports (e.g. '8080:80') are published on the nodes "+
+		"that run the pods (hostPort), for single-node clusters without load balancers. Fails if docker compose services publish the "+
+		"same host port")
+	upCmd.PersistentFlags().StringP("ip-family-policy", "", "", fmt.Sprintf("The IP family policy of the Kubernetes services of docker "+
+		"compose services, for clusters with dual-stack networking. One of %s, %s and %s", k8smeta.IPFamilyPolicySingleStack,
+		k8smeta.IPFamilyPolicyPreferDualStack, k8smeta.IPFamilyPolicyRequireDualStack))
+	upCmd.PersistentFlags().StringSliceP("ip-families", "", nil, fmt.Sprintf("The IP families (%s and/or %s, the first is the primary "+
+		"family) of the Kubernetes services of docker compose services. Fails if published ports have a host address of another IP family",
+		k8smeta.IPv4, k8smeta.IPv6))
+	upCmd.PersistentFlags().StringP("mesh", "", "", fmt.Sprintf("The service mesh whose sidecar proxy is injected into pods. One of %s "+
+		"and %s. The application containers are started once the sidecar proxy is ready, and the readiness of pods includes the "+
+		"readiness of the sidecar proxy", up.MeshIstio, up.MeshLinkerd))
+	upCmd.PersistentFlags().BoolP("wait-for-dns", "", false, "When set, the Kubernetes service of a docker compose service must "+
+		"resolve and accept connections from a probe pod inside the cluster before the dependents of the docker compose service are "+
+		"started. Only applies when --dependency-wait-mode is client")
+	upCmd.PersistentFlags().BoolP("service-aliases", "", false, "When set, each docker compose service with ports also gets a "+
+		"Kubernetes service named exactly like the docker compose service, so that its name resolves in any pod of the namespace. Service "+
+		"aliases of different environments in the same namespace conflict")
+	upCmd.PersistentFlags().StringSliceP("dns-search", "", nil, "Search domains added to the DNS configuration of pods (e.g. the "+
+		"namespace of shared services), at most 6")
+	upCmd.PersistentFlags().BoolP("strict", "", false, "When set, fails if docker compose services set fields that cannot be mapped "+
+		"to Kubernetes (cgroup_parent, ipc sharing, oom_kill_disable, security_opt label, and oom_score_adj that disagrees with the quality of "+
+		"service class of the pod), instead of warning about them")
+	upCmd.PersistentFlags().BoolP("cascade-restart", "", false, "When set, the dependents (based on depends_on) of a docker compose "+
+		"service are also redeployed when the service is redeployed")
+	upCmd.PersistentFlags().DurationP("ephemeral-ttl", "", 24*time.Hour, fmt.Sprintf("When --%s is set, the time after which the "+
+		"namespace of the ephemeral environment expires and can be deleted by the gc command. Every up extends the expiry",
+		ephemeralFlagName))
+	upCmd.PersistentFlags().BoolP("no-debug-port-forward", "", false, "When not detached, the ports of debuggers (the port declared in "+
+		"x-kube-compose and ports 2345, 5005, 5678 and 9229) are forwarded to localhost by default. Set this flag to disable this")
+	upCmd.PersistentFlags().BoolP("no-port-forward", "", false, "When not detached, a table of URLs of the published ports of "+
+		"services is printed once pods are ready, and published ports that cannot be reached through an Ingress, host port, NodePort or "+
+		"LoadBalancer are forwarded to localhost by default. Set this flag to disable this port forwarding")
+	upCmd.PersistentFlags().BoolP("recommend-resources", "", false, "When set, the resource usage of containers is sampled with the "+
+		"metrics API while up is attached, and recommended cpus, cpu_shares, mem_limit and mem_reservation values are printed for each "+
+		"docker compose service when up exits. Requires metrics-server")
+	upCmd.PersistentFlags().BoolP("synthesize-probes", "", false, "When set, docker compose services without a healthcheck get a TCP "+
+		"readiness probe on their first published port (or else the first port exposed by their image), so that other services can "+
+		"wait for them to be healthy")
+	upCmd.PersistentFlags().BoolP("liveness-probes", "", false, "When set, healthchecks are also converted to liveness probes, so that "+
+		"containers that become unhealthy are restarted (subject to their restart policy)")
+	upCmd.PersistentFlags().StringArrayP("env", "", nil, "Set an environment variable of a docker compose service (SERVICE.KEY=VALUE) "+
+		"or of all docker compose services (KEY=VALUE), taking precedence over the docker compose files. Can be repeated")
+	for _, flag := range defaultResourceFlags {
+		upCmd.PersistentFlags().StringP(flag.name, "", "", fmt.Sprintf("The default %s %s of containers (a Kubernetes quantity), which "+
+			"applies to docker compose services that do not set it. Overrides \"x-kube-compose\".\"default_resources\"", flag.resourceName,
+			flag.kind()))
+	}
+	upCmd.PersistentFlags().StringP("policy", "", "", "When set, generated objects are evaluated against the Rego policies in this "+
+		"directory (rules named deny of the package main) before they are applied, and up fails with the violation messages. "+
+		"Requires opa")
+	upCmd.PersistentFlags().StringArrayP("mutator-exec", "", nil, "An executable that mutates each generated object before it is "+
+		"applied. The JSON of the object is written to its standard input, and it must write the JSON of the mutated object to its "+
+		"standard output. Can be repeated")
+	upCmd.PersistentFlags().StringArrayP("mutator-webhook", "", nil, "A URL to which the JSON of each generated object is posted before "+
+		"the object is applied. The response must be the JSON of the mutated object. Can be repeated, and runs after --mutator-exec")
+	upCmd.PersistentFlags().BoolP("timing", "", false, "When set, a table with the time taken by each phase of each docker compose "+
+		"service (such as pulling images and waiting for readiness) is printed when up finishes")
+	upCmd.PersistentFlags().StringP("timing-trace", "", "", "When set, the time taken by each phase of each docker compose service is "+
+		"written to this file in the Trace Event Format, which can be opened with chrome://tracing or Perfetto")
+	upCmd.PersistentFlags().StringP("output", "o", "", "When set, the applied objects are printed as json (a List) or yaml when up "+
+		"finishes, for tools that use kube-compose as their deployer (such as Tilt). Progress and logs are written to standard error "+
+		"instead. Requires --detach")
+	upCmd.PersistentFlags().BoolP("no-cache", "", false, "When set, the local cache of the digests of images pushed to the cluster's "+
+		"registry is not used, and docker compose services that did not change since the last up are not skipped")
 	return upCmd
 }
 
+type defaultResourceFlag struct {
+	isLimit      bool
+	name         string
+	resourceName v1.ResourceName
+}
+
+func (f *defaultResourceFlag) kind() string {
+	if f.isLimit {
+		return "limit"
+	}
+	return "request"
+}
+
+var defaultResourceFlags = []*defaultResourceFlag{
+	{false, "default-cpu-request", v1.ResourceCPU},
+	{true, "default-cpu-limit", v1.ResourceCPU},
+	{false, "default-memory-request", v1.ResourceMemory},
+	{true, "default-memory-limit", v1.ResourceMemory},
+}
+
+// setDefaultResourcesFromFlags sets the default resource requests and limits of the flags that are set, taking precedence over those of
+// the docker compose files.
+func setDefaultResourcesFromFlags(cmd *cobra.Command, cfg *config.Config) error {
+	for _, flag := range defaultResourceFlags {
+		value, _ := cmd.Flags().GetString(flag.name)
+		if value == "" {
+			continue
+		}
+		err := config.SetDefaultResource(&cfg.DefaultResources, flag.resourceName, flag.isLimit, value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid value of flag --%s", flag.name)
+		}
+	}
+	return config.ValidateDefaultResources(&cfg.DefaultResources)
+}
+
+// prepareUpConfig applies the flags of up that change the configuration.
+func prepareUpConfig(cmd *cobra.Command, cfg *config.Config) error {
+	err := setDefaultResourcesFromFlags(cmd, cfg)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	envOverrides, _ := cmd.Flags().GetStringArray("env")
+	return exitcode.Wrap(config.ApplyEnvironmentOverrides(cfg, envOverrides), exitcode.Config)
+}
+
 func upCommand(cmd *cobra.Command, args []string) error {
-	cfg, err := getCommandConfig(cmd, args)
+	opts := &up.Options{}
+	opts.Timing = timing.NewRecorder()
+	span := opts.Timing.Start("load config", "")
+	cfgs, err := loadClusterConfigs(cmd, args, func(cfg *config.Config) error {
+		return prepareUpConfig(cmd, cfg)
+	})
 	if err != nil {
 		return err
 	}
-	opts := &up.Options{}
+	// The last cluster is the cluster whose services up is attached to.
+	cfg := cfgs[len(cfgs)-1]
+	span.Finish()
 	opts.Context = context.Background()
 	opts.Detach, _ = cmd.Flags().GetBool("detach")
+	opts.RecommendResources, _ = cmd.Flags().GetBool("recommend-resources")
+	if opts.RecommendResources && opts.Detach {
+		return exitcode.Wrap(fmt.Errorf("the flag --recommend-resources cannot be combined with --detach"), exitcode.Config)
+	}
 	opts.RunAsUser, _ = cmd.Flags().GetBool("run-as-user")
+	opts.NoDebugPortForwarding, _ = cmd.Flags().GetBool("no-debug-port-forward")
+	opts.NoPortForwarding, _ = cmd.Flags().GetBool("no-port-forward")
+	opts.Concurrency, _ = cmd.Flags().GetInt("concurrency")
+	opts.PullConcurrency, _ = cmd.Flags().GetInt("pull-concurrency")
+	opts.CascadeRestart, _ = cmd.Flags().GetBool("cascade-restart")
+	opts.SynthesizeProbes, _ = cmd.Flags().GetBool("synthesize-probes")
+	opts.LivenessProbes, _ = cmd.Flags().GetBool("liveness-probes")
+	opts.HostPorts, _ = cmd.Flags().GetBool("host-ports")
+	opts.DefaultDeny, _ = cmd.Flags().GetBool("default-deny")
+	opts.Strict, _ = cmd.Flags().GetBool("strict")
+	opts.ServiceAliases, _ = cmd.Flags().GetBool("service-aliases")
+	opts.WaitForDNS, _ = cmd.Flags().GetBool("wait-for-dns")
+	opts.DNSSearches, _ = cmd.Flags().GetStringSlice("dns-search")
+	dependencyWaitMode, _ := cmd.Flags().GetString("dependency-wait-mode")
+	opts.DependencyWaitMode = up.DependencyWaitMode(dependencyWaitMode)
+	if opts.DependencyWaitMode != up.DependencyWaitModeClient && opts.DependencyWaitMode != up.DependencyWaitModeInitContainer {
+		return exitcode.Wrap(fmt.Errorf("the flag --dependency-wait-mode can only be set to one of %s and %s", up.DependencyWaitModeClient,
+			up.DependencyWaitModeInitContainer), exitcode.Config)
+	}
+	err = setExposeModeFromFlags(cmd, opts)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	err = setIPFamiliesFromFlags(cmd, opts)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	mesh, _ := cmd.Flags().GetString("mesh")
+	opts.Mesh = up.Mesh(mesh)
+	if opts.Mesh != up.MeshNone && opts.Mesh != up.MeshIstio && opts.Mesh != up.MeshLinkerd {
+		return exitcode.Wrap(fmt.Errorf("the flag --mesh can only be set to one of %s and %s", up.MeshIstio, up.MeshLinkerd),
+			exitcode.Config)
+	}
+	if isEphemeral, _ := cmd.Flags().GetBool(ephemeralFlagName); isEphemeral {
+		ttl, _ := cmd.Flags().GetDuration("ephemeral-ttl")
+		for _, clusterCfg := range cfgs {
+			err = ephemeral.EnsureNamespace(clusterCfg, ttl)
+			if err != nil {
+				exitWithError(err)
+			}
+		}
+	}
+	policyDir, _ := cmd.Flags().GetString("policy")
+	if policyDir != "" {
+		opts.Policies, err = policy.Load(policyDir)
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.Config)
+		}
+	}
+	setMutatorsFromFlags(cmd, opts)
+	opts.DockerConfig = loadDockerCLIConfig()
+	if noCache, _ := cmd.Flags().GetBool("no-cache"); !noCache {
+		opts.DigestCache = loadDigestCache()
+		opts.Incremental = true
+	}
+	opts.Metrics, err = serveMetricsFromFlags(cmd.Flags())
+	if err != nil {
+		return err
+	}
 
-	opts.Reporter = reporter.New(os.Stdout)
+	objects, humanOutput, err := setOutputFromFlags(cmd, opts)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	opts.Reporter = reporter.New(humanOutput)
 	if opts.Reporter.IsTerminal() {
 		log.StandardLogger().SetOutput(opts.Reporter.LogSink())
 		go func() {
@@ -45,12 +262,148 @@ func upCommand(cmd *cobra.Command, args []string) error {
 		}()
 	}
 
-	err = up.Run(cfg, opts)
+	err = runUpClusters(cfgs, opts)
+	opts.Reporter.Refresh()
+	if err == nil && objects != nil {
+		err = objects.write(os.Stdout)
+	}
+	timingErr := writeTimingReports(cmd, cfg, opts.Timing, humanOutput)
 	if err != nil {
 		log.Error(err)
-		opts.Reporter.Refresh()
-		os.Exit(1)
+		if timingErr != nil {
+			log.Error(timingErr)
+		}
+		os.Exit(int(exitcode.FromError(err)))
+	}
+	return timingErr
+}
+
+// writeTimingReports prints the timing summary and writes the trace file of a command, if the flags are set, and exports the spans with
+// OTLP if an OTLP endpoint is configured (see timing.NewOTLPExporterFromEnv). This is also done if the command fails, so that users can
+// see which phase was slow before the failure.
+func writeTimingReports(cmd *cobra.Command, cfg *config.Config, recorder *timing.Recorder, out io.Writer) error {
+	exporter, err := timing.NewOTLPExporterFromEnv(envGetter)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	if exporter != nil {
+		err = exporter.Export(recorder, "kube-compose "+cmd.Name(), map[string]string{
+			"k8s.namespace.name":          cfg.Namespace,
+			"kube_compose.environment_id": cfg.EnvironmentID,
+			"kube_compose.project":        cfg.ProjectName,
+		})
+		if err != nil {
+			// The trace is diagnostic information, so failing to export it does not fail the command.
+			log.Warn(err)
+		}
+	}
+	if printTiming, _ := cmd.Flags().GetBool("timing"); printTiming {
+		err = recorder.WriteSummary(out)
+		if err != nil {
+			return err
+		}
+	}
+	traceFile, _ := cmd.Flags().GetString("timing-trace")
+	if traceFile == "" {
+		return nil
+	}
+	file, err := fs.OS.Create(traceFile)
+	if err != nil {
+		return err
+	}
+	defer util.CloseAndLogError(file)
+	return recorder.WriteChromeTrace(file)
+}
+
+// setOutputFromFlags sets the applied object handler of the options if the flag --output is set, and returns the recorder of the applied
+// objects (or nil) and the writer to which progress is reported. When the applied objects are printed, progress and logs are written to
+// standard error so that standard output only contains the objects.
+func setOutputFromFlags(cmd *cobra.Command, opts *up.Options) (*appliedObjects, io.Writer, error) {
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		return nil, os.Stdout, nil
+	}
+	if output != "json" && output != "yaml" {
+		return nil, nil, fmt.Errorf("the flag --output can only be set to one of json and yaml")
+	}
+	if !opts.Detach {
+		return nil, nil, fmt.Errorf("the flag --output requires --detach")
+	}
+	objects := &appliedObjects{
+		output: output,
+	}
+	opts.AppliedObjectHandler = objects.record
+	log.StandardLogger().SetOutput(os.Stderr)
+	return objects, os.Stderr, nil
+}
+
+// setMutatorsFromFlags sets the mutators of the options from the flags --mutator-exec and --mutator-webhook, in that order.
+func setMutatorsFromFlags(cmd *cobra.Command, opts *up.Options) {
+	mutatorCommands, _ := cmd.Flags().GetStringArray("mutator-exec")
+	for _, command := range mutatorCommands {
+		opts.Mutators = append(opts.Mutators, &mutate.Exec{
+			Command: command,
+		})
+	}
+	mutatorURLs, _ := cmd.Flags().GetStringArray("mutator-webhook")
+	for _, url := range mutatorURLs {
+		opts.Mutators = append(opts.Mutators, mutate.NewWebhook(url))
+	}
+}
+
+// loadDigestCache loads the digest cache from the user's cache directory. The cache only makes up faster, so if it cannot be loaded then
+// a warning is logged and nil is returned.
+func loadDigestCache() *digestcache.Cache {
+	path, err := digestcache.DefaultPath()
+	if err == nil {
+		var cache *digestcache.Cache
+		cache, err = digestcache.Load(fs.OS, path, digestcache.DefaultTTL)
+		if err == nil {
+			return cache
+		}
+	}
+	log.Warnf("not using the digest cache: %v", err)
+	return nil
+}
+
+// setIPFamiliesFromFlags sets the IP family policy and IP families of Kubernetes services, if either flag is set.
+func setIPFamiliesFromFlags(cmd *cobra.Command, opts *up.Options) error {
+	ipFamilyPolicy, _ := cmd.Flags().GetString("ip-family-policy")
+	families, _ := cmd.Flags().GetStringSlice("ip-families")
+	if ipFamilyPolicy == "" && len(families) == 0 {
+		return nil
+	}
+	opts.IPFamilies = &k8smeta.IPFamilies{
+		Families: families,
+		Policy:   ipFamilyPolicy,
+	}
+	return errors.Wrap(opts.IPFamilies.Validate(), "invalid value of flag --ip-family-policy or --ip-families")
+}
+
+// setExposeModeFromFlags sets how services are exposed, and the Gateway that routes attach to.
+func setExposeModeFromFlags(cmd *cobra.Command, opts *up.Options) error {
+	exposeMode, _ := cmd.Flags().GetString("expose-mode")
+	opts.ExposeMode = up.ExposeMode(exposeMode)
+	gateway, _ := cmd.Flags().GetString("gateway")
+	switch opts.ExposeMode {
+	case up.ExposeModeIngress:
+		if gateway != "" {
+			return fmt.Errorf("the flag --gateway can only be set if --expose-mode is %s", up.ExposeModeGateway)
+		}
+	case up.ExposeModeGateway:
+		if gateway == "" {
+			return fmt.Errorf("the flag --gateway is required if --expose-mode is %s", up.ExposeModeGateway)
+		}
+		parts := strings.Split(gateway, "/")
+		if len(parts) > 2 || parts[0] == "" || parts[len(parts)-1] == "" {
+			return fmt.Errorf("the flag --gateway must be of the form [NAMESPACE/]NAME")
+		}
+		opts.GatewayName = parts[len(parts)-1]
+		if len(parts) == 2 {
+			opts.GatewayNamespace = parts[0]
+		}
+	default:
+		return fmt.Errorf("the flag --expose-mode can only be set to one of %s and %s", up.ExposeModeIngress, up.ExposeModeGateway)
 	}
-	opts.Reporter.Refresh()
 	return nil
 }
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/up"
+	"github.com/spf13/cobra"
+)
+
+// newTestUpCli returns the up command with its persistent flags merged into its flags, as cobra does when parsing the command line.
+func newTestUpCli() *cobra.Command {
+	cmd := newUpCli()
+	cmd.Flags().AddFlagSet(cmd.PersistentFlags())
+	return cmd
+}
+
+func Test_SetExposeModeFromFlags_Gateway(t *testing.T) {
+	cmd := newTestUpCli()
+	_ = cmd.Flags().Set("expose-mode", "gateway")
+	_ = cmd.Flags().Set("gateway", "infra/public")
+	opts := &up.Options{}
+	err := setExposeModeFromFlags(cmd, opts)
+	if err != nil || opts.ExposeMode != up.ExposeModeGateway || opts.GatewayNamespace != "infra" || opts.GatewayName != "public" {
+		t.Error(opts, err)
+	}
+}
+
+func Test_SetExposeModeFromFlags_GatewayWithoutNamespace(t *testing.T) {
+	cmd := newTestUpCli()
+	_ = cmd.Flags().Set("expose-mode", "gateway")
+	_ = cmd.Flags().Set("gateway", "public")
+	opts := &up.Options{}
+	err := setExposeModeFromFlags(cmd, opts)
+	if err != nil || opts.GatewayNamespace != "" || opts.GatewayName != "public" {
+		t.Error(opts, err)
+	}
+}
+
+func Test_SetExposeModeFromFlags_Invalid(t *testing.T) {
+	for _, flags := range [][2]string{
+		{"gateway", ""},
+		{"gateway", "a/b/c"},
+		{"gateway", "infra/"},
+		{"ingress", "public"},
+		{"loadbalancer", ""},
+	} {
+		cmd := newTestUpCli()
+		_ = cmd.Flags().Set("expose-mode", flags[0])
+		_ = cmd.Flags().Set("gateway", flags[1])
+		err := setExposeModeFromFlags(cmd, &up.Options{})
+		if err == nil {
+			t.Error(flags)
+		}
+	}
+}
+
+func Test_SetIPFamiliesFromFlags_Success(t *testing.T) {
+	cmd := newTestUpCli()
+	_ = cmd.Flags().Set("ip-family-policy", "PreferDualStack")
+	_ = cmd.Flags().Set("ip-families", "IPv6,IPv4")
+	opts := &up.Options{}
+	err := setIPFamiliesFromFlags(cmd, opts)
+	if err != nil || opts.IPFamilies == nil || opts.IPFamilies.Policy != "PreferDualStack" || len(opts.IPFamilies.Families) != 2 {
+		t.Error(opts.IPFamilies, err)
+	}
+}
+
+func Test_SetIPFamiliesFromFlags_NotSet(t *testing.T) {
+	opts := &up.Options{}
+	err := setIPFamiliesFromFlags(newTestUpCli(), opts)
+	if err != nil || opts.IPFamilies != nil {
+		t.Error(opts.IPFamilies, err)
+	}
+}
+
+func Test_SetIPFamiliesFromFlags_Invalid(t *testing.T) {
+	cmd := newTestUpCli()
+	_ = cmd.Flags().Set("ip-families", "IPv4,IPv6")
+	err := setIPFamiliesFromFlags(cmd, &up.Options{})
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/watch"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/spf13/cobra"
+)
+
+func newWatchCli() *cobra.Command {
+	var watchCmd = &cobra.Command{
+		Use:   "watch",
+		Short: "Sync changed files into running containers",
+		Long: "watches the paths of the develop.watch rules of the specified docker compose services, and copies changed files into " +
+			"the running containers of the services. Kubernetes cannot restart containers on demand, so the pods of services with action " +
+			"sync+restart are recreated after syncing",
+		RunE: watchCommand,
+	}
+	watchCmd.PersistentFlags().StringP("output", "o", "", "When set to json, machine-readable events about triggers, syncs and "+
+		"restarts are written to standard output as JSON Lines, for tools that drive kube-compose (such as Skaffold and Tilt). Logs are "+
+		"written to standard error instead")
+	addMetricsAddressFlag(watchCmd.PersistentFlags())
+	return watchCmd
+}
+
+func watchCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	opts := &watch.Options{}
+	switch output, _ := cmd.Flags().GetString("output"); output {
+	case "":
+	case "json":
+		opts.Events = os.Stdout
+		log.StandardLogger().SetOutput(os.Stderr)
+	default:
+		return exitcode.Wrap(fmt.Errorf("the flag --output can only be set to json"), exitcode.Config)
+	}
+	opts.Metrics, err = serveMetricsFromFlags(cmd.Flags())
+	if err != nil {
+		return err
+	}
+	err = watch.Run(cfg, opts)
+	if err != nil {
+		exitWithError(err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// GetCluster returns the name of the cluster of the pod and Kubernetes service of a docker compose service, or the empty string for the
+// cluster of the kube context of the command. The containers of sidecars run in the pod of the first service of their pod group, so
+// sidecars are in the cluster of that service.
+func (cfg *Config) GetCluster(service *Service) string {
+	return service.PodService().Cluster
+}
+
+// Clusters returns the names of the clusters of the docker compose services, ordered such that the services of a cluster only depend on
+// services of the same cluster or of preceding clusters, so that the clusters can be started one after the other. Clusters without such
+// an order between them are in lexicographical order, where the empty string is the cluster of the kube context of the command. An error
+// is returned if the depends_on of services form a cycle between clusters.
+func (cfg *Config) Clusters() ([]string, error) {
+	dependencies := map[string]map[string]bool{}
+	for _, service := range cfg.Services {
+		cluster := cfg.GetCluster(service)
+		if dependencies[cluster] == nil {
+			dependencies[cluster] = map[string]bool{}
+		}
+		for name := range service.DockerComposeService.DependsOn {
+			if dependency := cfg.GetCluster(cfg.Services[name]); dependency != cluster {
+				dependencies[cluster][dependency] = true
+			}
+		}
+	}
+	var clusters []string
+	for len(dependencies) > 0 {
+		var next []string
+		for cluster, clusterDependencies := range dependencies {
+			if len(clusterDependencies) == 0 {
+				next = append(next, cluster)
+			}
+		}
+		if len(next) == 0 {
+			var cycle []string
+			for cluster := range dependencies {
+				cycle = append(cycle, fmt.Sprintf("%#v", cluster))
+			}
+			sort.Strings(cycle)
+			return nil, fmt.Errorf("the depends_on of docker compose services form a cycle between the clusters %s", strings.Join(cycle, ", "))
+		}
+		sort.Strings(next)
+		for _, cluster := range next {
+			delete(dependencies, cluster)
+			for _, clusterDependencies := range dependencies {
+				delete(clusterDependencies, cluster)
+			}
+		}
+		clusters = append(clusters, next...)
+	}
+	return clusters, nil
+}
+
+// TargetCluster sets the cluster that commands operate on, and removes the docker compose services of other clusters from the
+// configuration along with the depends_on conditions on them, which are satisfied by starting the clusters in the order of Clusters. The
+// removed docker compose services are returned in lexicographical order, so that their addresses can be added with AddRemoteService.
+func (cfg *Config) TargetCluster(cluster string) []*Service {
+	cfg.Cluster = cluster
+	var removed []*Service
+	for name, service := range cfg.Services {
+		if cfg.GetCluster(service) != cluster {
+			removed = append(removed, service)
+			delete(cfg.Services, name)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		return removed[i].Name() < removed[j].Name()
+	})
+	for _, service := range cfg.Services {
+		dcService := service.DockerComposeService
+		for _, removedService := range removed {
+			delete(dcService.DependsOn, removedService.Name())
+			delete(dcService.DependsOnRestart, removedService.Name())
+		}
+	}
+	return removed
+}
+
+// AddRemoteService makes pods resolve the name of a docker compose service of another cluster (see TargetCluster) to host, which is the
+// address at which the service is reachable from outside its cluster. IP addresses are resolved through host aliases (see
+// RemoteServices), and host names through external services (see ExternalServices).
+func (cfg *Config) AddRemoteService(service *Service, host string) {
+	if net.ParseIP(host) != nil {
+		if cfg.RemoteServices == nil {
+			cfg.RemoteServices = map[string]string{}
+		}
+		cfg.RemoteServices[service.Name()] = host
+		return
+	}
+	if cfg.ExternalServices == nil {
+		cfg.ExternalServices = map[string]string{}
+	}
+	cfg.ExternalServices[service.Name()] = host
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+)
+
+func withClusterConfig(t *testing.T, content string, cb func(c *Config)) {
+	file := "/cluster"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(content),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		cb(c)
+	})
+}
+
+func Test_New_Cluster(t *testing.T) {
+	withClusterConfig(t, `version: '2.4'
+services:
+  app:
+    image: app
+    depends_on:
+    - db
+    - cache
+  cache:
+    image: cache
+    x-kube-compose:
+      cluster: shared
+  db:
+    image: db
+    x-kube-compose:
+      cluster: data
+  proxy:
+    image: proxy
+x-kube-compose:
+  pod_groups:
+    g: [db, proxy]
+`, func(c *Config) {
+		if c.GetCluster(c.Services["app"]) != "" || c.GetCluster(c.Services["db"]) != "data" || c.GetCluster(c.Services["proxy"]) != "data" {
+			t.Error(c.Services)
+		}
+		clusters, err := c.Clusters()
+		if err != nil || !reflect.DeepEqual(clusters, []string{"data", "shared", ""}) {
+			t.Error(clusters, err)
+		}
+		removed := c.TargetCluster("")
+		if len(removed) != 3 || removed[0].Name() != "cache" || removed[1].Name() != "db" || removed[2].Name() != "proxy" {
+			t.Error(removed)
+		}
+		if c.Cluster != "" || len(c.Services) != 1 || len(c.Services["app"].DockerComposeService.DependsOn) != 0 {
+			t.Error(c.Services)
+		}
+	})
+}
+
+func Test_Config_ClustersCycle(t *testing.T) {
+	withClusterConfig(t, `version: '2.4'
+services:
+  a:
+    image: a
+    depends_on:
+    - b
+  b:
+    image: b
+    depends_on:
+    - c
+    x-kube-compose:
+      cluster: data
+  c:
+    image: c
+`, func(c *Config) {
+		_, err := c.Clusters()
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_ClusterInvalid(t *testing.T) {
+	file := "/cluster"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+    x-kube-compose:
+      cluster: Not_Valid
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_Config_AddRemoteService(t *testing.T) {
+	c := &Config{}
+	c.AddRemoteService(&Service{
+		DockerComposeService: &dockerComposeConfig.Service{
+			Name: "db",
+		},
+	}, "10.1.2.3")
+	c.AddRemoteService(&Service{
+		DockerComposeService: &dockerComposeConfig.Service{
+			Name: "cache",
+		},
+	}, "cache.example.com")
+	if c.RemoteServices["db"] != "10.1.2.3" || c.ExternalServices["cache"] != "cache.example.com" || len(c.RemoteServices) != 1 ||
+		len(c.ExternalServices) != 1 {
+		t.Error(c.RemoteServices, c.ExternalServices)
+	}
+}
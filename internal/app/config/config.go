@@ -2,28 +2,78 @@ package config
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
 	"github.com/pkg/errors"
 	"github.com/uber-go/mapdecode"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/rest"
 )
 
 type DockerRegistryClusterImageStorage struct {
 	Host string
+	// The path of the repositories of pushed images in the docker registry, or the empty string if it is the namespace.
+	Prefix string
 }
 
 type Service struct {
-	DockerComposeService  *dockerComposeConfig.Service
+	// The name of the cluster of the service's pod and Kubernetes service, or the empty string for the cluster of the kube context of the
+	// command (see Config.GetCluster).
+	Cluster string
+	// The port of a debugger in the service's containers that is forwarded to localhost, or 0 if it was not declared.
+	DebugPort int32
+	// The extended resource limits of the devices reserved by the service (e.g. nvidia.com/gpu), or nil if it reserves no devices.
+	DeviceLimits         v1.ResourceList
+	DockerComposeService *dockerComposeConfig.Service
+	// The Ingress of the service, or nil if the service is not exposed through an Ingress.
+	Ingress *Ingress
+	// The configuration of the Kubernetes service of the service, or nil for a ClusterIP service without session affinity.
+	KubernetesService     *KubernetesService
 	matchesFilter         bool
 	matchesFilterDirectly bool
 	NameEscaped           string
-	Ports                 []Port
+	// The namespace of the service's pod and Kubernetes service, or the empty string for the namespace of the environment (see
+	// Config.GetNamespace).
+	Namespace string
+	// The node selector of the service's pod, as required by the drivers of its devices (see DeviceDriver).
+	NodeSelector map[string]string
+	// What to do when this service fails, or when this service does not satisfy the depends_on conditions of other services within
+	// WaitTimeout.
+	OnFailure FailurePolicy
+	Ports     []Port
+	// The pod group of the service, or nil if the service runs in its own pod.
+	PodGroup *PodGroup
+	// The preset of the service's image, or nil if presets are disabled or the image does not have a preset.
+	Preset *Preset
+	// The priority class of the service's pod, or the empty string for the cluster's default priority.
+	PriorityClassName string
+	// The runtime class of the service's pod (e.g. gVisor or Kata Containers), or the empty string for the cluster's default runtime.
+	RuntimeClassName string
+	// The service account configuration of the service's pod, or nil if the pod does not get a service account token.
+	ServiceAccount *ServiceAccount
+	// How the service's pod is spread over the topology of the cluster, or nil if the pod is scheduled anywhere.
+	TopologySpread *TopologySpread
+	// The maximum duration to wait for this service to satisfy the depends_on conditions of other services, measured from the creation
+	// of its pod. Nil means wait indefinitely.
+	WaitTimeout *time.Duration
 }
 
+// FailurePolicy specifies what happens when a docker compose service fails.
+type FailurePolicy string
+
+const (
+	// FailurePolicyAbort aborts the whole operation.
+	FailurePolicyAbort FailurePolicy = "abort"
+	// FailurePolicySkip skips all services that (indirectly) depend on the failed service.
+	FailurePolicySkip FailurePolicy = "skip"
+)
+
 func (s *Service) Name() string {
 	return s.DockerComposeService.Name
 }
@@ -34,16 +84,45 @@ type ClusterImageStorage struct {
 }
 
 type Config struct {
+	// The cluster whose docker compose services commands operate on, or the empty string for the cluster of the kube context of the
+	// command (see TargetCluster).
+	Cluster string
 	// All Kubernetes resources are named with "-"+EnvironmentID as a suffix,
 	// and have an additional label "env="+EnvironmentID so that namespaces can be shared.
-	EnvironmentID       string
-	EnvironmentLabel    string
-	KubeConfig          *rest.Config
-	Namespace           string
+	EnvironmentID    string
+	EnvironmentLabel string
+	KubeConfig       *rest.Config
+	Namespace        string
+	// The normalized name of the docker compose project. If not empty, resource names are prefixed with it and resources are labelled
+	// with it, so that multiple projects can share a namespace.
+	ProjectName string
+	// If not empty, the state of the environment (see package state) is recorded in this local file instead of in a ConfigMap.
+	StateFile           string
 	ClusterImageStorage ClusterImageStorage
+	// Additional labels of the Kubernetes resources of docker compose services, such as the team that owns the project.
+	Labels map[string]string
+	// The default resource requests and limits of containers, which apply to docker compose services that do not set the corresponding
+	// resource constraints.
+	DefaultResources v1.ResourceRequirements
+	// The drivers of device reservations of docker compose services, keyed by name.
+	DeviceDrivers       map[string]*DeviceDriver
 	VolumeInitBaseImage *string
+	// The image of init containers that wait for dependencies, if dependencies are waited for by init containers.
+	WaitForImage string
 
+	// The host names of services outside the docker compose project that pods resolve, keyed by alias. These come from the external_links
+	// of docker compose services and from "x-kube-compose"."external_services".
+	ExternalServices map[string]string
+	// The IP addresses of docker compose services in other clusters that pods resolve, keyed by the names of the docker compose services
+	// (see TargetCluster).
+	RemoteServices map[string]string
+	// True if and only if the presets of common stateful images are applied to docker compose services (see Preset).
+	Presets bool
+	// The external docker compose secrets that have provider configuration, keyed by the names used to refer to them from services.
+	Secrets  map[string]*Secret
 	Services map[string]*Service
+	// The named docker compose volumes that are mounted by services, keyed by the names used to refer to them from services.
+	Volumes map[string]*Volume
 }
 
 type Port struct {
@@ -53,21 +132,45 @@ type Port struct {
 }
 
 func New(files []string) (*Config, error) {
+	return NewWithLocalFile(files, "")
+}
+
+// NewWithLocalFile is like New, but additionally merges the developer-specific docker compose file localFile last, if it exists (see
+// dockerComposeConfig.NewWithLocalFile).
+func NewWithLocalFile(files []string, localFile string) (*Config, error) {
+	return NewWithOptions(files, &dockerComposeConfig.LoadOptions{
+		LocalFile: localFile,
+	})
+}
+
+// NewWithOptions is like New, but with additional options for loading the docker compose files (see dockerComposeConfig.NewWithOptions).
+func NewWithOptions(files []string, opts *dockerComposeConfig.LoadOptions) (*Config, error) {
 	cfg := &Config{
 		EnvironmentLabel: "env",
+		WaitForImage:     DefaultWaitForImage,
 	}
-	dcCfg, err := dockerComposeConfig.New(files)
+	dcCfg, err := dockerComposeConfig.NewWithOptions(files, opts)
 	if err != nil {
 		return nil, err
 	}
-	cfg.Services = map[string]*Service{}
-	for name, dcService := range dcCfg.Services {
+	names := make([]string, 0, len(dcCfg.Services))
+	for name := range dcCfg.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// The services are loaded concurrently, because large projects have many services. Errors are reported in the order of the names of
+	// services.
+	services := make([]*Service, len(names))
+	err = util.ForEachConcurrently(len(names), func(i int) error {
+		name := names[i]
 		if e := validation.IsDNS1123Subdomain(name); len(e) > 0 {
-			return nil, fmt.Errorf("sorry, we do not support the potentially valid docker compose service named %s: %s", name, e[0])
+			return fmt.Errorf("sorry, we do not support the potentially valid docker compose service named %s: %s", name, e[0])
 		}
+		dcService := dcCfg.Services[name]
 		service := &Service{
 			DockerComposeService: dcService,
 			NameEscaped:          util.EscapeName(name),
+			OnFailure:            FailurePolicyAbort,
 		}
 		for _, portBinding := range dcService.Ports {
 			service.Ports = append(service.Ports, Port{
@@ -75,27 +178,87 @@ func New(files []string) (*Config, error) {
 				Port:     portBinding.Internal,
 			})
 		}
-		cfg.Services[name] = service
+		services[i] = service
+		return loadServiceXKubeCompose(service)
+	})
+	if err != nil {
+		return nil, err
+	}
+	cfg.Services = map[string]*Service{}
+	for i, name := range names {
+		cfg.Services[name] = services[i]
+	}
+	err = loadExternalLinks(cfg)
+	if err != nil {
+		return nil, err
 	}
 	err = loadXKubeCompose(cfg, dcCfg.XProperties)
 	if err != nil {
 		return nil, err
 	}
+	if cfg.Presets {
+		applyPresets(cfg)
+	}
+	err = validateExternalServices(cfg)
+	if err != nil {
+		return nil, err
+	}
+	err = resolveDevices(cfg)
+	if err != nil {
+		return nil, err
+	}
+	err = validatePodGroups(cfg)
+	if err != nil {
+		return nil, err
+	}
+	err = loadSecrets(cfg, dcCfg.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	err = loadVolumes(cfg, dcCfg.Volumes)
+	if err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
+// DefaultWaitForImage is the default image of init containers that wait for dependencies. The image must have a shell and a version of
+// nc that supports the -z flag.
+const DefaultWaitForImage = "busybox:1.31"
+
 type clusterImageStorage struct {
-	Type string  `mapdecode:"type"`
-	Host *string `mapdecode:"host"`
+	Type   string  `mapdecode:"type"`
+	Host   *string `mapdecode:"host"`
+	Prefix *string `mapdecode:"prefix"`
+}
+
+type debug struct {
+	Port int32 `mapdecode:"port"`
+}
+
+type dependency struct {
+	OnFailure   *string `mapdecode:"on_failure"`
+	WaitTimeout *string `mapdecode:"wait_timeout"`
 }
 
 type xKubeCompose struct {
 	XKubeCompose struct {
-		ClusterImageStorage *clusterImageStorage `mapdecode:"cluster_image_storage"`
+		ClusterImageStorage *clusterImageStorage     `mapdecode:"cluster_image_storage"`
+		Debug               map[string]*debug        `mapdecode:"debug"`
+		DefaultResources    *defaultResources        `mapdecode:"default_resources"`
+		Dependencies        map[string]*dependency   `mapdecode:"dependencies"`
+		Devices             map[string]*deviceDriver `mapdecode:"devices"`
+		ExternalServices    map[string]string        `mapdecode:"external_services"`
+		Labels              map[string]string        `mapdecode:"labels"`
+		PodGroups           map[string][]string      `mapdecode:"pod_groups"`
+		Presets             *bool                    `mapdecode:"presets"`
 		PushImages          *struct {
 			DockerRegistry string `mapdecode:"docker_registry"`
 		} `mapdecode:"push_images"`
-		VolumeInitBaseImage *string `mapdecode:"volume_init_base_image"`
+		ServiceAccounts     map[string]*serviceAccount `mapdecode:"service_accounts"`
+		TopologySpread      map[string]*topologySpread `mapdecode:"topology_spread"`
+		VolumeInitBaseImage *string                    `mapdecode:"volume_init_base_image"`
+		WaitForImage        *string                    `mapdecode:"wait_for_image"`
 	} `mapdecode:"x-kube-compose"`
 }
 
@@ -124,6 +287,100 @@ func loadXKubeCompose(cfg *Config, xPropertiesSlice []dockerComposeConfig.XPrope
 			}
 		}
 		cfg.VolumeInitBaseImage = x.XKubeCompose.VolumeInitBaseImage
+		if x.XKubeCompose.WaitForImage != nil {
+			cfg.WaitForImage = *x.XKubeCompose.WaitForImage
+		}
+		if x.XKubeCompose.Presets != nil {
+			cfg.Presets = *x.XKubeCompose.Presets
+		}
+		err = loadDependencies(cfg, x.XKubeCompose.Dependencies)
+		if err != nil {
+			return err
+		}
+		err = loadDebug(cfg, x.XKubeCompose.Debug)
+		if err != nil {
+			return err
+		}
+		err = loadServiceAccounts(cfg, x.XKubeCompose.ServiceAccounts)
+		if err != nil {
+			return err
+		}
+		err = loadDefaultResources(cfg, x.XKubeCompose.DefaultResources)
+		if err != nil {
+			return err
+		}
+		err = loadTopologySpread(cfg, x.XKubeCompose.TopologySpread)
+		if err != nil {
+			return err
+		}
+		loadExternalServices(cfg, x.XKubeCompose.ExternalServices)
+		err = loadLabels(cfg, x.XKubeCompose.Labels)
+		if err != nil {
+			return err
+		}
+		err = loadPodGroups(cfg, x.XKubeCompose.PodGroups)
+		if err != nil {
+			return err
+		}
+		err = loadDeviceDrivers(cfg, x.XKubeCompose.Devices)
+		if err != nil {
+			return err
+		}
+	}
+	return ValidateDefaultResources(&cfg.DefaultResources)
+}
+
+func loadDependencies(cfg *Config, dependencies map[string]*dependency) error {
+	names := make([]string, 0, len(dependencies))
+	for name := range dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		service := cfg.Services[name]
+		if service == nil {
+			return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"dependencies\": service %s does not exist",
+				name)
+		}
+		d := dependencies[name]
+		if d == nil {
+			continue
+		}
+		if d.WaitTimeout != nil {
+			waitTimeout, err := time.ParseDuration(*d.WaitTimeout)
+			if err != nil {
+				return errors.Wrapf(err, "a docker compose file has an invalid value at \"x-kube-compose\".\"dependencies\".%q."+
+					"\"wait_timeout\"", name)
+			}
+			service.WaitTimeout = &waitTimeout
+		}
+		if d.OnFailure != nil {
+			switch onFailure := FailurePolicy(*d.OnFailure); onFailure {
+			case FailurePolicyAbort, FailurePolicySkip:
+				service.OnFailure = onFailure
+			default:
+				return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"dependencies\".%q.\"on_failure\": "+
+					"value must be one of \"abort\" and \"skip\"", name)
+			}
+		}
+	}
+	return nil
+}
+
+func loadDebug(cfg *Config, debugs map[string]*debug) error {
+	for name, d := range debugs {
+		service := cfg.Services[name]
+		if service == nil {
+			return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"debug\": service %s does not exist", name)
+		}
+		if d == nil {
+			continue
+		}
+		if d.Port < 1 || d.Port > 65535 {
+			return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"debug\".%q.\"port\": value must be "+
+				"a port number", name)
+		}
+		service.DebugPort = d.Port
 	}
 	return nil
 }
@@ -142,6 +399,9 @@ func loadClusterImageStorage(cfg *Config, v *clusterImageStorage) error {
 		cfg.ClusterImageStorage.DockerRegistry = &DockerRegistryClusterImageStorage{
 			Host: *v.Host,
 		}
+		if v.Prefix != nil {
+			cfg.ClusterImageStorage.DockerRegistry.Prefix = strings.Trim(*v.Prefix, "/")
+		}
 	default:
 		return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"cluster_image_storage\".\"type\": " +
 			"value must be one of \"docker\" and \"docker_registry\"")
@@ -149,6 +409,29 @@ func loadClusterImageStorage(cfg *Config, v *clusterImageStorage) error {
 	return nil
 }
 
+// loadLabels adds labels to cfg.Labels, replacing the values of existing keys.
+func loadLabels(cfg *Config, labels map[string]string) error {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if e := validation.IsQualifiedName(key); len(e) > 0 {
+			return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"labels\": %q is not a valid label "+
+				"name: %s", key, e[0])
+		}
+		if e := validation.IsValidLabelValue(labels[key]); len(e) > 0 {
+			return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"labels\".%q: %s", key, e[0])
+		}
+		if cfg.Labels == nil {
+			cfg.Labels = map[string]string{}
+		}
+		cfg.Labels[key] = labels[key]
+	}
+	return nil
+}
+
 // AddService adds a service to this configuration.
 func (cfg *Config) AddService(dockerComposeService *dockerComposeConfig.Service) *Service {
 	service := cfg.Services[dockerComposeService.Name]
@@ -161,6 +444,7 @@ func (cfg *Config) AddService(dockerComposeService *dockerComposeConfig.Service)
 		service = &Service{
 			DockerComposeService: dockerComposeService,
 			NameEscaped:          util.EscapeName(dockerComposeService.Name),
+			OnFailure:            FailurePolicyAbort,
 		}
 		if cfg.Services == nil {
 			cfg.Services = map[string]*Service{}
@@ -188,7 +472,7 @@ func (cfg *Config) ClearFilter() {
 	}
 }
 
-// AddToFilter adds service and its (in)direct dependencies (based on depends_on) to the set of services matched by
+// AddToFilter adds service and its (in)direct dependencies (based on depends_on and pod groups) to the set of services matched by
 // the current filter. After a AddToFilter(service), MatchesFilterDirectly(service) will return true unless ClearFilter was called.
 func (cfg *Config) AddToFilter(service *Service) {
 	queue := []*Service{
@@ -201,8 +485,15 @@ func (cfg *Config) AddToFilter(service *Service) {
 		service1 := queue[n]
 		if !service1.matchesFilter {
 			service1.matchesFilter = true
+			var services2 []*Service
 			for d := range service1.DockerComposeService.DependsOn {
-				service2 := cfg.Services[d]
+				services2 = append(services2, cfg.Services[d])
+			}
+			if service1.PodGroup != nil {
+				// The services of a pod group are started together.
+				services2 = append(services2, service1.PodGroup.Services...)
+			}
+			for _, service2 := range services2 {
 				if n < len(queue) {
 					queue[n] = service2
 				} else {
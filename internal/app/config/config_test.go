@@ -3,6 +3,7 @@ package config
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/kube-compose/kube-compose/internal/pkg/fs"
 	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
@@ -277,6 +278,198 @@ x-kube-compose:
 	})
 }
 
+func Test_New_WaitForImageDefault(t *testing.T) {
+	withMockFS(func() {
+		c, err := New([]string{dockerComposeYmlValidPushImages})
+		if err != nil {
+			t.Error(err)
+		} else if c.WaitForImage != DefaultWaitForImage {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_WaitForImageSuccess(t *testing.T) {
+	file := "/waitforimagesuccess"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  wait_for_image: my-registry.example.com/busybox:latest
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else if c.WaitForImage != "my-registry.example.com/busybox:latest" {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_DependenciesSuccess(t *testing.T) {
+	file := "/dependencies"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+  b:
+    image: b
+x-kube-compose:
+  dependencies:
+    a:
+      wait_timeout: 1m30s
+      on_failure: skip
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else {
+			a := c.Services["a"]
+			if a.WaitTimeout == nil || *a.WaitTimeout != 90*time.Second || a.OnFailure != FailurePolicySkip {
+				t.Error(a)
+			}
+			b := c.Services["b"]
+			if b.WaitTimeout != nil || b.OnFailure != FailurePolicyAbort {
+				t.Error(b)
+			}
+		}
+	})
+}
+
+func Test_New_DependenciesUnknownService(t *testing.T) {
+	file := "/dependencies"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  dependencies:
+    a:
+      on_failure: skip
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_DependenciesInvalidWaitTimeout(t *testing.T) {
+	file := "/dependencies"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+x-kube-compose:
+  dependencies:
+    a:
+      wait_timeout: soon
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_DependenciesInvalidOnFailure(t *testing.T) {
+	file := "/dependencies"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+x-kube-compose:
+  dependencies:
+    a:
+      on_failure: ignore
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_DebugSuccess(t *testing.T) {
+	file := "/debug"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+x-kube-compose:
+  debug:
+    a:
+      port: 5005
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else if c.Services["a"].DebugPort != 5005 {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_DebugInvalidPort(t *testing.T) {
+	file := "/debug"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+x-kube-compose:
+  debug:
+    a:
+      port: 0
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_DebugUnknownService(t *testing.T) {
+	file := "/debug"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  debug:
+    a:
+      port: 5005
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
 func Test_New_ClusterImageStorageInvalidType(t *testing.T) {
 	file := "/invalidtype"
 	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
@@ -360,3 +553,423 @@ x-kube-compose:
 		}
 	})
 }
+
+func Test_New_ServiceAccountsSuccess(t *testing.T) {
+	file := "/serviceaccounts"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+x-kube-compose:
+  service_accounts:
+    a:
+      automount_token: true
+      name: reader
+      tokens:
+      - audience: vault
+        expiration: 1h
+        path: /var/run/secrets/tokens/vault
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		expected := &ServiceAccount{
+			AutomountToken: true,
+			Name:           "reader",
+			Tokens: []ServiceAccountToken{
+				{
+					Audience:   "vault",
+					Expiration: time.Hour,
+					Path:       "/var/run/secrets/tokens/vault",
+				},
+			},
+		}
+		if !reflect.DeepEqual(c.Services["a"].ServiceAccount, expected) {
+			t.Error(c.Services["a"].ServiceAccount)
+		}
+	})
+}
+
+func Test_New_ServiceAccountsInvalid(t *testing.T) {
+	for _, token := range []string{
+		"{path: relative/token}",
+		"{path: /token}",
+		"{path: /run/token, expiration: 1m}",
+		"{path: /run/token, expiration: henkie}",
+	} {
+		file := "/serviceaccounts"
+		withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+			file: {
+				Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+x-kube-compose:
+  service_accounts:
+    a:
+      tokens:
+      - ` + token + `
+`),
+			},
+		}), func() {
+			_, err := New([]string{file})
+			if err == nil {
+				t.Error(token)
+			}
+		})
+	}
+}
+
+func Test_New_ServiceAccountsUnknownService(t *testing.T) {
+	file := "/serviceaccounts"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  service_accounts:
+    a:
+      automount_token: true
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_TopologySpread(t *testing.T) {
+	file := "/topologyspread"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+  b:
+    image: b
+x-kube-compose:
+  topology_spread:
+    a: {}
+    b:
+      group: db
+      required: true
+      topology: hostname
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		expectedA := &TopologySpread{
+			Group:       DefaultTopologySpreadGroup,
+			TopologyKey: TopologyKeyZone,
+		}
+		if !reflect.DeepEqual(c.Services["a"].TopologySpread, expectedA) {
+			t.Error(c.Services["a"].TopologySpread)
+		}
+		expectedB := &TopologySpread{
+			Group:       "db",
+			Required:    true,
+			TopologyKey: TopologyKeyHostname,
+		}
+		if !reflect.DeepEqual(c.Services["b"].TopologySpread, expectedB) {
+			t.Error(c.Services["b"].TopologySpread)
+		}
+	})
+}
+
+func Test_New_TopologySpreadInvalid(t *testing.T) {
+	for _, ts := range []string{"{topology: 'not a label!'}", "{group: ''}", "{group: 'a b'}"} {
+		file := "/topologyspread"
+		withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+			file: {
+				Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+x-kube-compose:
+  topology_spread:
+    a: ` + ts + `
+`),
+			},
+		}), func() {
+			_, err := New([]string{file})
+			if err == nil {
+				t.Error(ts)
+			}
+		})
+	}
+}
+
+func Test_ParseTopologyKey_NodeLabel(t *testing.T) {
+	topologyKey, err := parseTopologyKey("example.com/rack")
+	if err != nil || topologyKey != "example.com/rack" {
+		t.Error(topologyKey, err)
+	}
+}
+
+func Test_New_PriorityAndRuntimeClassName(t *testing.T) {
+	file := "/classnames"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+    x-kube-compose:
+      priority_class_name: high-priority
+      runtime_class_name: gvisor
+  b:
+    image: b
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		a := c.Services["a"]
+		if a.PriorityClassName != "high-priority" || a.RuntimeClassName != "gvisor" {
+			t.Error(a.PriorityClassName, a.RuntimeClassName)
+		}
+		b := c.Services["b"]
+		if b.PriorityClassName != "" || b.RuntimeClassName != "" {
+			t.Error(b.PriorityClassName, b.RuntimeClassName)
+		}
+	})
+}
+
+func Test_New_RuntimeClassNameInvalid(t *testing.T) {
+	file := "/classnames"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+    x-kube-compose:
+      runtime_class_name: Not_Valid
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_ExternalServices(t *testing.T) {
+	file := "/externalservices"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+    external_links:
+    - redis
+    - project_db_1:mysql
+  b:
+    image: b
+    external_links:
+    - redis
+x-kube-compose:
+  external_services:
+    mysql: mysql.example.com
+    search: elasticsearch.logging
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		expected := map[string]string{
+			"mysql":  "mysql.example.com",
+			"redis":  "redis",
+			"search": "elasticsearch.logging",
+		}
+		if !reflect.DeepEqual(c.ExternalServices, expected) {
+			t.Error(c.ExternalServices)
+		}
+	})
+}
+
+func Test_New_ExternalServicesInvalid(t *testing.T) {
+	for _, content := range []string{
+		"services:\n  a:\n    image: a\n    external_links: [project_db_1:mysql]\n",
+		"services:\n  a:\n    image: a\n    external_links: [db1:db]\n  b:\n    image: b\n    external_links: [db2:db]\n",
+		"services:\n  a:\n    image: a\nx-kube-compose:\n  external_services:\n    a: a.example.com\n",
+		"services:\n  a:\n    image: a\nx-kube-compose:\n  external_services:\n    my.db: db.example.com\n",
+	} {
+		file := "/externalservicesinvalid"
+		withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+			file: {
+				Content: []byte("version: '2.4'\n" + content),
+			},
+		}), func() {
+			_, err := New([]string{file})
+			if err == nil {
+				t.Error(content)
+			}
+		})
+	}
+}
+
+func Test_NewWithOptions_LabelsAndDefaults(t *testing.T) {
+	file := "/labels"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+x-kube-compose:
+  labels:
+    team: web
+`),
+		},
+	}), func() {
+		c, err := NewWithOptions([]string{file}, &dockerComposeConfig.LoadOptions{
+			XProperties: dockerComposeConfig.XProperties{
+				"x-kube-compose": map[interface{}]interface{}{
+					"cluster_image_storage": map[interface{}]interface{}{
+						"type":   "docker_registry",
+						"host":   "registry.example.com",
+						"prefix": "/dev/",
+					},
+					"labels": map[interface{}]interface{}{
+						"cost-center": "42",
+						"team":        "platform",
+					},
+				},
+			},
+		})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		expectedLabels := map[string]string{
+			"cost-center": "42",
+			"team":        "web",
+		}
+		if !reflect.DeepEqual(c.Labels, expectedLabels) {
+			t.Error(c.Labels)
+		}
+		expected := &DockerRegistryClusterImageStorage{
+			Host:   "registry.example.com",
+			Prefix: "dev",
+		}
+		if !reflect.DeepEqual(c.ClusterImageStorage.DockerRegistry, expected) {
+			t.Error(c.ClusterImageStorage.DockerRegistry)
+		}
+	})
+}
+
+func Test_New_LabelsInvalid(t *testing.T) {
+	for _, content := range []string{
+		"x-kube-compose:\n  labels:\n    'team name': web\n",
+		"x-kube-compose:\n  labels:\n    team: 'web team'\n",
+	} {
+		file := "/labelsinvalid"
+		withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+			file: {
+				Content: []byte("version: '2.4'\nservices:\n  a:\n    image: a\n" + content),
+			},
+		}), func() {
+			_, err := New([]string{file})
+			if err == nil {
+				t.Error(content)
+			}
+		})
+	}
+}
+
+func Test_New_Devices(t *testing.T) {
+	file := "/devices"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '3.8'
+services:
+  a:
+    image: a
+    deploy:
+      resources:
+        reservations:
+          devices:
+          - capabilities: [gpu]
+            count: 2
+          - capabilities: [gpu]
+            count: all
+  b:
+    image: b
+    deploy:
+      resources:
+        reservations:
+          devices:
+          - capabilities: [fpga]
+            driver: xilinx
+            device_ids: ['0']
+x-kube-compose:
+  devices:
+    nvidia:
+      runtime_class_name: nvidia
+      node_selector:
+        nvidia.com/gpu.present: 'true'
+    xilinx:
+      resource: xilinx.com/fpga
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		a := c.Services["a"]
+		gpus := a.DeviceLimits["nvidia.com/gpu"]
+		if gpus.Value() != 3 || a.RuntimeClassName != "nvidia" || a.NodeSelector["nvidia.com/gpu.present"] != "true" {
+			t.Error(a.DeviceLimits, a.RuntimeClassName, a.NodeSelector)
+		}
+		b := c.Services["b"]
+		fpgas := b.DeviceLimits["xilinx.com/fpga"]
+		if fpgas.Value() != 1 || b.RuntimeClassName != "" || b.NodeSelector != nil {
+			t.Error(b.DeviceLimits, b.RuntimeClassName, b.NodeSelector)
+		}
+	})
+}
+
+func Test_New_DevicesInvalid(t *testing.T) {
+	for _, content := range []string{
+		"services:\n  a:\n    image: a\n    deploy: {resources: {reservations: {devices: [{capabilities: [fpga]}]}}}\n",
+		"services:\n  a:\n    image: a\n    deploy: {resources: {reservations: {devices: [{capabilities: [gpu]}]}}}\n" +
+			"    x-kube-compose:\n      runtime_class_name: gvisor\n" +
+			"x-kube-compose:\n  devices:\n    nvidia:\n      runtime_class_name: nvidia\n",
+		"services:\n  a:\n    image: a\nx-kube-compose:\n  devices:\n    nvidia:\n      resource: 'not a resource'\n",
+	} {
+		file := "/devicesinvalid"
+		withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+			file: {
+				Content: []byte("version: '3.8'\n" + content),
+			},
+		}), func() {
+			_, err := New([]string{file})
+			if err == nil {
+				t.Error(content)
+			}
+		})
+	}
+}
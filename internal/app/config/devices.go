@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	log "github.com/Sirupsen/logrus"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DefaultDeviceDriver is the driver of device reservations with capability gpu that do not set a driver, like docker.
+const DefaultDeviceDriver = "nvidia"
+
+// DeviceDriver specifies how the devices of a driver (of the device reservations of docker compose services) are requested from
+// Kubernetes.
+type DeviceDriver struct {
+	// The extended resource of the devices, as advertised by the device plugin of the driver (e.g. nvidia.com/gpu).
+	Resource v1.ResourceName
+	// If not empty, the runtimeClassName of pods that reserve devices of the driver.
+	RuntimeClassName string
+	// The node selector of pods that reserve devices of the driver.
+	NodeSelector map[string]string
+}
+
+// newDefaultDeviceDrivers returns the device drivers whose extended resources are known.
+func newDefaultDeviceDrivers() map[string]*DeviceDriver {
+	return map[string]*DeviceDriver{
+		"amd": {
+			Resource: "amd.com/gpu",
+		},
+		"intel": {
+			Resource: "gpu.intel.com/i915",
+		},
+		"nvidia": {
+			Resource: "nvidia.com/gpu",
+		},
+	}
+}
+
+type deviceDriver struct {
+	NodeSelector     map[string]string `mapdecode:"node_selector"`
+	Resource         *string           `mapdecode:"resource"`
+	RuntimeClassName *string           `mapdecode:"runtime_class_name"`
+}
+
+func loadDeviceDrivers(cfg *Config, deviceDrivers map[string]*deviceDriver) error {
+	names := make([]string, 0, len(deviceDrivers))
+	for name := range deviceDrivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		dd := deviceDrivers[name]
+		if dd == nil {
+			continue
+		}
+		if cfg.DeviceDrivers == nil {
+			cfg.DeviceDrivers = newDefaultDeviceDrivers()
+		}
+		driver := cfg.DeviceDrivers[name]
+		if driver == nil {
+			driver = &DeviceDriver{}
+			cfg.DeviceDrivers[name] = driver
+		}
+		if dd.Resource != nil {
+			if e := validation.IsQualifiedName(*dd.Resource); len(e) > 0 {
+				return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"devices\".%q.\"resource\": %s",
+					name, e[0])
+			}
+			driver.Resource = v1.ResourceName(*dd.Resource)
+		}
+		if dd.RuntimeClassName != nil {
+			if e := validation.IsDNS1123Subdomain(*dd.RuntimeClassName); len(e) > 0 {
+				return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"devices\".%q."+
+					"\"runtime_class_name\": %s", name, e[0])
+			}
+			driver.RuntimeClassName = *dd.RuntimeClassName
+		}
+		for key, value := range dd.NodeSelector {
+			if e := validation.IsQualifiedName(key); len(e) > 0 {
+				return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"devices\".%q.\"node_selector\": "+
+					"%s", name, e[0])
+			}
+			if e := validation.IsValidLabelValue(value); len(e) > 0 {
+				return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"devices\".%q.\"node_selector\"."+
+					"%q: %s", name, key, e[0])
+			}
+			if driver.NodeSelector == nil {
+				driver.NodeSelector = map[string]string{}
+			}
+			driver.NodeSelector[key] = value
+		}
+	}
+	return nil
+}
+
+func hasCapability(deviceRequest *dockerComposeConfig.DeviceRequest, capability string) bool {
+	for _, c := range deviceRequest.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveServiceDevices maps the device reservations of a docker compose service to extended resource limits, and sets the runtime
+// class and node selector of the drivers of the devices. Kubernetes cannot reserve all devices of a node, so count: all reserves one
+// device.
+func resolveServiceDevices(cfg *Config, service *Service) error {
+	for i := range service.DockerComposeService.Devices {
+		deviceRequest := &service.DockerComposeService.Devices[i]
+		driverName := deviceRequest.Driver
+		if driverName == "" && hasCapability(deviceRequest, "gpu") {
+			driverName = DefaultDeviceDriver
+		}
+		driver := cfg.DeviceDrivers[driverName]
+		if driver == nil || driver.Resource == "" {
+			return fmt.Errorf("service %s reserves devices of driver %#v, but its extended resource is not known (set it at "+
+				"\"x-kube-compose\".\"devices\".%q.\"resource\")", service.Name(), driverName, driverName)
+		}
+		count := deviceRequest.Count
+		if count == dockerComposeConfig.DeviceRequestCountAll {
+			log.Warnf("service %s reserves all devices of driver %s, but Kubernetes can only reserve a number of devices, so one device "+
+				"is reserved", service.Name(), driverName)
+			count = 1
+		}
+		if count == 0 {
+			continue
+		}
+		if service.DeviceLimits == nil {
+			service.DeviceLimits = v1.ResourceList{}
+		}
+		quantity := service.DeviceLimits[driver.Resource]
+		quantity.Add(*resource.NewQuantity(count, resource.DecimalSI))
+		service.DeviceLimits[driver.Resource] = quantity
+		if driver.RuntimeClassName != "" && service.RuntimeClassName != driver.RuntimeClassName {
+			if service.RuntimeClassName != "" {
+				return fmt.Errorf("service %s has runtime class %s, but reserves devices of driver %s that require runtime class %s",
+					service.Name(), service.RuntimeClassName, driverName, driver.RuntimeClassName)
+			}
+			service.RuntimeClassName = driver.RuntimeClassName
+		}
+		for key, value := range driver.NodeSelector {
+			if service.NodeSelector == nil {
+				service.NodeSelector = map[string]string{}
+			}
+			service.NodeSelector[key] = value
+		}
+	}
+	return nil
+}
+
+func resolveDevices(cfg *Config) error {
+	if cfg.DeviceDrivers == nil {
+		cfg.DeviceDrivers = newDefaultDeviceDrivers()
+	}
+	names := make([]string, 0, len(cfg.Services))
+	for name := range cfg.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		err := resolveServiceDevices(cfg, cfg.Services[name])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
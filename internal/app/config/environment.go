@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ApplyEnvironmentOverrides sets environment variables of docker compose services, taking precedence over the docker compose files. Each
+// override has the form SERVICE.KEY=VALUE to set a variable of one service, or KEY=VALUE to set a variable of all services. Because
+// service names can contain dots, the service name is the part of SERVICE.KEY before the last dot.
+func ApplyEnvironmentOverrides(cfg *Config, overrides []string) error {
+	for _, override := range overrides {
+		i := strings.IndexByte(override, '=')
+		if i <= 0 {
+			return fmt.Errorf("environment override %#v must have the form SERVICE.KEY=VALUE or KEY=VALUE", override)
+		}
+		name, value := override[:i], override[i+1:]
+		services := cfg.Services
+		if j := strings.LastIndexByte(name, '.'); j >= 0 {
+			service := cfg.Services[name[:j]]
+			if service == nil {
+				return fmt.Errorf("environment override %#v refers to a docker compose service named %#v that does not exist", override,
+					name[:j])
+			}
+			services = map[string]*Service{
+				name[:j]: service,
+			}
+			name = name[j+1:]
+		}
+		if name == "" {
+			return fmt.Errorf("environment override %#v has an empty variable name", override)
+		}
+		for _, service := range services {
+			if service.DockerComposeService.Environment == nil {
+				service.DockerComposeService.Environment = map[string]string{}
+			}
+			service.DockerComposeService.Environment[name] = value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+)
+
+func newTestConfigWithServices() *Config {
+	cfg := &Config{}
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name: "web",
+		Environment: map[string]string{
+			"LOG_LEVEL": "info",
+		},
+	})
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name: "db.internal",
+	})
+	return cfg
+}
+
+func TestApplyEnvironmentOverrides_Success(t *testing.T) {
+	cfg := newTestConfigWithServices()
+	err := ApplyEnvironmentOverrides(cfg, []string{"TZ=UTC", "web.LOG_LEVEL=debug", "db.internal.MODE=a=b"})
+	if err != nil {
+		t.Error(err)
+	}
+	expectedWeb := map[string]string{
+		"LOG_LEVEL": "debug",
+		"TZ":        "UTC",
+	}
+	expectedDB := map[string]string{
+		"MODE": "a=b",
+		"TZ":   "UTC",
+	}
+	if !reflect.DeepEqual(cfg.Services["web"].DockerComposeService.Environment, expectedWeb) ||
+		!reflect.DeepEqual(cfg.Services["db.internal"].DockerComposeService.Environment, expectedDB) {
+		t.Fail()
+	}
+}
+
+func TestApplyEnvironmentOverrides_UnknownService(t *testing.T) {
+	cfg := newTestConfigWithServices()
+	err := ApplyEnvironmentOverrides(cfg, []string{"api.KEY=value"})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestApplyEnvironmentOverrides_Invalid(t *testing.T) {
+	cfg := newTestConfigWithServices()
+	for _, override := range []string{"KEY", "=value", "web.=value"} {
+		if err := ApplyEnvironmentOverrides(cfg, []string{override}); err == nil {
+			t.Error(override)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// loadExternalLinks adds the external_links of the docker compose services to cfg.ExternalServices. Docker compose services may link to
+// the same container under the same alias, but not to different containers.
+func loadExternalLinks(cfg *Config) error {
+	names := make([]string, 0, len(cfg.Services))
+	for name := range cfg.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, externalLink := range cfg.Services[name].DockerComposeService.ExternalLinks {
+			if host, ok := cfg.ExternalServices[externalLink.Alias]; ok && host != externalLink.Target {
+				return fmt.Errorf("service %s has an external link with alias %s to %s, but another service links to %s with that alias",
+					name, externalLink.Alias, externalLink.Target, host)
+			}
+			if cfg.ExternalServices == nil {
+				cfg.ExternalServices = map[string]string{}
+			}
+			cfg.ExternalServices[externalLink.Alias] = externalLink.Target
+		}
+	}
+	return nil
+}
+
+func loadExternalServices(cfg *Config, externalServices map[string]string) {
+	for alias, host := range externalServices {
+		if cfg.ExternalServices == nil {
+			cfg.ExternalServices = map[string]string{}
+		}
+		cfg.ExternalServices[alias] = host
+	}
+}
+
+// validateExternalServices validates cfg.ExternalServices after external links and "x-kube-compose"."external_services" have been
+// loaded, so that an external link to a container whose name is not a valid host name can be redirected with "external_services".
+func validateExternalServices(cfg *Config) error {
+	aliases := make([]string, 0, len(cfg.ExternalServices))
+	for alias := range cfg.ExternalServices {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		if e := validation.IsDNS1035Label(alias); len(e) > 0 {
+			return fmt.Errorf("sorry, we do not support the external service named %s: %s", alias, e[0])
+		}
+		if cfg.Services[alias] != nil {
+			return fmt.Errorf("the external service named %s has the same name as a docker compose service", alias)
+		}
+		host := cfg.ExternalServices[alias]
+		if e := validation.IsDNS1123Subdomain(host); len(e) > 0 {
+			return fmt.Errorf("the external service named %s has an invalid host %#v (map it to a valid host at \"x-kube-compose\"."+
+				"\"external_services\".%q): %s", alias, host, alias, e[0])
+		}
+	}
+	return nil
+}
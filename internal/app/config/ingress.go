@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DefaultIngressTLSIssuerKind is the kind of the cert-manager issuer of an Ingress if no kind is configured.
+const DefaultIngressTLSIssuerKind = "ClusterIssuer"
+
+// Ingress is an Ingress that routes HTTP(S) traffic for a host to a published port of a docker compose service.
+type Ingress struct {
+	// The host name of the Ingress.
+	Host string
+	// The (TCP) port of the Kubernetes service of the docker compose service that traffic is routed to.
+	Port int32
+	// If not nil then a cert-manager Certificate is created for the host, and the Ingress terminates TLS with its Secret.
+	TLS *IngressTLS
+}
+
+// IngressTLS is the cert-manager issuer of the TLS certificate of an Ingress.
+type IngressTLS struct {
+	// The name of the Issuer or ClusterIssuer.
+	Issuer string
+	// Either Issuer or ClusterIssuer.
+	IssuerKind string
+}
+
+type ingress struct {
+	Host *string     `mapdecode:"host"`
+	Port *int32      `mapdecode:"port"`
+	TLS  *ingressTLS `mapdecode:"tls"`
+}
+
+type ingressTLS struct {
+	Issuer     *string `mapdecode:"issuer"`
+	IssuerKind *string `mapdecode:"issuer_kind"`
+}
+
+// loadIngress loads the Ingress of a docker compose service. Ingresses route to Kubernetes services, so the docker compose service must
+// publish a TCP port.
+func loadIngress(service *Service, i *ingress) error {
+	if i.Host == nil {
+		return fmt.Errorf("service %s has an invalid value at \"x-kube-compose\".\"ingress\": a host is required", service.Name())
+	}
+	if e := validation.IsDNS1123Subdomain(*i.Host); len(e) > 0 {
+		return fmt.Errorf("service %s has an invalid value at \"x-kube-compose\".\"ingress\".\"host\": %s", service.Name(), e[0])
+	}
+	result := &Ingress{
+		Host: *i.Host,
+	}
+	for _, port := range service.Ports {
+		if port.Protocol == "tcp" && (i.Port == nil || *i.Port == port.Port) {
+			result.Port = port.Port
+			break
+		}
+	}
+	if result.Port == 0 {
+		if i.Port == nil {
+			return fmt.Errorf("service %s has an \"x-kube-compose\".\"ingress\", but does not publish a TCP port", service.Name())
+		}
+		return fmt.Errorf("service %s has an invalid value at \"x-kube-compose\".\"ingress\".\"port\": the service does not publish TCP "+
+			"port %d", service.Name(), *i.Port)
+	}
+	if i.TLS != nil {
+		if i.TLS.Issuer == nil || *i.TLS.Issuer == "" {
+			return fmt.Errorf("service %s has an invalid value at \"x-kube-compose\".\"ingress\".\"tls\": an issuer is required",
+				service.Name())
+		}
+		result.TLS = &IngressTLS{
+			Issuer:     *i.TLS.Issuer,
+			IssuerKind: DefaultIngressTLSIssuerKind,
+		}
+		if i.TLS.IssuerKind != nil {
+			switch *i.TLS.IssuerKind {
+			case "Issuer", "ClusterIssuer":
+				result.TLS.IssuerKind = *i.TLS.IssuerKind
+			default:
+				return fmt.Errorf("service %s has an invalid value at \"x-kube-compose\".\"ingress\".\"tls\".\"issuer_kind\": value must be "+
+					"one of \"Issuer\" and \"ClusterIssuer\"", service.Name())
+			}
+		}
+	}
+	service.Ingress = result
+	return nil
+}
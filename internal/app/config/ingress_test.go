@@ -0,0 +1,101 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+)
+
+func newTestIngressService() *Service {
+	return &Service{
+		DockerComposeService: &dockerComposeConfig.Service{
+			Name: "web",
+		},
+		Ports: []Port{
+			{
+				Port:     53,
+				Protocol: "udp",
+			},
+			{
+				Port:     8080,
+				Protocol: "tcp",
+			},
+			{
+				Port:     8443,
+				Protocol: "tcp",
+			},
+		},
+	}
+}
+
+func Test_LoadIngress_DefaultPort(t *testing.T) {
+	service := newTestIngressService()
+	err := loadIngress(service, &ingress{
+		Host: util.NewString("web.example.com"),
+	})
+	expected := &Ingress{
+		Host: "web.example.com",
+		Port: 8080,
+	}
+	if err != nil || !reflect.DeepEqual(service.Ingress, expected) {
+		t.Error(service.Ingress, err)
+	}
+}
+
+func Test_LoadIngress_PortAndTLS(t *testing.T) {
+	service := newTestIngressService()
+	port := int32(8443)
+	err := loadIngress(service, &ingress{
+		Host: util.NewString("web.example.com"),
+		Port: &port,
+		TLS: &ingressTLS{
+			Issuer: util.NewString("letsencrypt"),
+		},
+	})
+	expected := &Ingress{
+		Host: "web.example.com",
+		Port: 8443,
+		TLS: &IngressTLS{
+			Issuer:     "letsencrypt",
+			IssuerKind: DefaultIngressTLSIssuerKind,
+		},
+	}
+	if err != nil || !reflect.DeepEqual(service.Ingress, expected) {
+		t.Error(service.Ingress, err)
+	}
+}
+
+func Test_LoadIngress_UnpublishedPort(t *testing.T) {
+	service := newTestIngressService()
+	port := int32(53)
+	err := loadIngress(service, &ingress{
+		Host: util.NewString("web.example.com"),
+		Port: &port,
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_LoadIngress_NoPorts(t *testing.T) {
+	service := newTestIngressService()
+	service.Ports = nil
+	err := loadIngress(service, &ingress{
+		Host: util.NewString("web.example.com"),
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_LoadIngress_InvalidHost(t *testing.T) {
+	service := newTestIngressService()
+	err := loadIngress(service, &ingress{
+		Host: util.NewString("Not A Host"),
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
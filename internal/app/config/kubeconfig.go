@@ -0,0 +1,43 @@
+package config
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// KubeConfigOptions select the kube config, and the cluster, context and user within it, like the flags of kubectl with the same names.
+type KubeConfigOptions struct {
+	Cluster    string
+	Context    string
+	KubeConfig string
+	User       string
+}
+
+// SetFromKubeConfig sets the Kubernetes client configuration and namespace of cfg from the kube config, which is loaded like kubectl
+// loads it (from the KUBECONFIG environment variable or ~/.kube/config).
+func SetFromKubeConfig(cfg *Config) error {
+	return SetFromKubeConfigWithOptions(cfg, &KubeConfigOptions{})
+}
+
+// SetFromKubeConfigWithOptions is like SetFromKubeConfig, but with additional options.
+func SetFromKubeConfigWithOptions(cfg *Config, opts *KubeConfigOptions) error {
+	loader := clientcmd.NewDefaultClientConfigLoadingRules()
+	loader.ExplicitPath = opts.KubeConfig
+	overrides := clientcmd.ConfigOverrides{
+		CurrentContext: opts.Context,
+	}
+	overrides.Context.Cluster = opts.Cluster
+	overrides.Context.AuthInfo = opts.User
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, &overrides)
+	kubeConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return errors.Wrap(err, "could not load kube config")
+	}
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return err
+	}
+	cfg.KubeConfig = kubeConfig
+	cfg.Namespace = namespace
+	return nil
+}
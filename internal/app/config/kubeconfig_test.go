@@ -0,0 +1,13 @@
+package config
+
+import "testing"
+
+func Test_SetFromKubeConfigWithOptions_KubeConfigNotExists(t *testing.T) {
+	cfg := &Config{}
+	err := SetFromKubeConfigWithOptions(cfg, &KubeConfigOptions{
+		KubeConfig: "/kube-compose-does-not-exist/config",
+	})
+	if err == nil || cfg.KubeConfig != nil {
+		t.Error(err)
+	}
+}
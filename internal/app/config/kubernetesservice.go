@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// maxSessionAffinityTimeout is the maximum timeout of the ClientIP session affinity of Kubernetes services.
+const maxSessionAffinityTimeout = 24 * time.Hour
+
+// KubernetesService configures the Kubernetes service of a docker compose service, for services that require sticky sessions or need to
+// see the IP addresses of clients.
+type KubernetesService struct {
+	// The external traffic policy of the Kubernetes service, or the empty string for the cluster's default. Can only be set if Type is
+	// NodePort or LoadBalancer.
+	ExternalTrafficPolicy v1.ServiceExternalTrafficPolicyType
+	// The session affinity of the Kubernetes service, or the empty string for no session affinity.
+	SessionAffinity v1.ServiceAffinity
+	// The maximum session sticky time if SessionAffinity is ClientIP, or nil for the cluster's default.
+	SessionAffinityTimeout *time.Duration
+	// The type of the Kubernetes service. Defaults to ClusterIP.
+	Type v1.ServiceType
+}
+
+type kubernetesService struct {
+	ExternalTrafficPolicy  *string `mapdecode:"external_traffic_policy"`
+	SessionAffinity        *string `mapdecode:"session_affinity"`
+	SessionAffinityTimeout *string `mapdecode:"session_affinity_timeout"`
+	Type                   *string `mapdecode:"type"`
+}
+
+// loadKubernetesService loads the configuration of the Kubernetes service of a docker compose service.
+func loadKubernetesService(service *Service, ks *kubernetesService) error {
+	result := &KubernetesService{
+		Type: v1.ServiceTypeClusterIP,
+	}
+	if ks.Type != nil {
+		result.Type = v1.ServiceType(*ks.Type)
+		if result.Type != v1.ServiceTypeClusterIP && result.Type != v1.ServiceTypeNodePort && result.Type != v1.ServiceTypeLoadBalancer {
+			return fmt.Errorf("service %s has an invalid value at \"x-kube-compose\".\"kubernetes_service\".\"type\": value must be one of "+
+				"%s, %s and %s", service.Name(), v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer)
+		}
+	}
+	if ks.ExternalTrafficPolicy != nil {
+		result.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyType(*ks.ExternalTrafficPolicy)
+		if result.ExternalTrafficPolicy != v1.ServiceExternalTrafficPolicyTypeCluster &&
+			result.ExternalTrafficPolicy != v1.ServiceExternalTrafficPolicyTypeLocal {
+			return fmt.Errorf("service %s has an invalid value at \"x-kube-compose\".\"kubernetes_service\".\"external_traffic_policy\": "+
+				"value must be one of %s and %s", service.Name(), v1.ServiceExternalTrafficPolicyTypeCluster,
+				v1.ServiceExternalTrafficPolicyTypeLocal)
+		}
+		if result.Type == v1.ServiceTypeClusterIP {
+			return fmt.Errorf("service %s has an \"x-kube-compose\".\"kubernetes_service\".\"external_traffic_policy\", but the type of "+
+				"its Kubernetes service is not %s or %s", service.Name(), v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer)
+		}
+	}
+	err := loadSessionAffinity(service, ks, result)
+	if err != nil {
+		return err
+	}
+	service.KubernetesService = result
+	return nil
+}
+
+func loadSessionAffinity(service *Service, ks *kubernetesService, result *KubernetesService) error {
+	if ks.SessionAffinity != nil {
+		result.SessionAffinity = v1.ServiceAffinity(*ks.SessionAffinity)
+		if result.SessionAffinity != v1.ServiceAffinityNone && result.SessionAffinity != v1.ServiceAffinityClientIP {
+			return fmt.Errorf("service %s has an invalid value at \"x-kube-compose\".\"kubernetes_service\".\"session_affinity\": value "+
+				"must be one of %s and %s", service.Name(), v1.ServiceAffinityNone, v1.ServiceAffinityClientIP)
+		}
+	}
+	if ks.SessionAffinityTimeout == nil {
+		return nil
+	}
+	if result.SessionAffinity != v1.ServiceAffinityClientIP {
+		return fmt.Errorf("service %s has an \"x-kube-compose\".\"kubernetes_service\".\"session_affinity_timeout\", but its "+
+			"session_affinity is not %s", service.Name(), v1.ServiceAffinityClientIP)
+	}
+	timeout, err := time.ParseDuration(*ks.SessionAffinityTimeout)
+	if err != nil || timeout < time.Second || timeout > maxSessionAffinityTimeout || timeout%time.Second != 0 {
+		return fmt.Errorf("service %s has an invalid value at \"x-kube-compose\".\"kubernetes_service\".\"session_affinity_timeout\": "+
+			"value must be a whole number of seconds between 1s and %s", service.Name(), maxSessionAffinityTimeout)
+	}
+	result.SessionAffinityTimeout = &timeout
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTestKubernetesServiceService() *Service {
+	return &Service{
+		DockerComposeService: &dockerComposeConfig.Service{
+			Name: "web",
+		},
+	}
+}
+
+func Test_LoadKubernetesService_Success(t *testing.T) {
+	service := newTestKubernetesServiceService()
+	err := loadKubernetesService(service, &kubernetesService{
+		ExternalTrafficPolicy:  util.NewString("Local"),
+		SessionAffinity:        util.NewString("ClientIP"),
+		SessionAffinityTimeout: util.NewString("3h"),
+		Type:                   util.NewString("LoadBalancer"),
+	})
+	timeout := 3 * time.Hour
+	expected := &KubernetesService{
+		ExternalTrafficPolicy:  v1.ServiceExternalTrafficPolicyTypeLocal,
+		SessionAffinity:        v1.ServiceAffinityClientIP,
+		SessionAffinityTimeout: &timeout,
+		Type:                   v1.ServiceTypeLoadBalancer,
+	}
+	if err != nil || !reflect.DeepEqual(service.KubernetesService, expected) {
+		t.Error(service.KubernetesService, err)
+	}
+}
+
+func Test_LoadKubernetesService_DefaultType(t *testing.T) {
+	service := newTestKubernetesServiceService()
+	err := loadKubernetesService(service, &kubernetesService{
+		SessionAffinity: util.NewString("ClientIP"),
+	})
+	if err != nil || service.KubernetesService.Type != v1.ServiceTypeClusterIP {
+		t.Error(service.KubernetesService, err)
+	}
+}
+
+func Test_LoadKubernetesService_Invalid(t *testing.T) {
+	for _, ks := range []*kubernetesService{
+		{Type: util.NewString("ExternalName")},
+		{ExternalTrafficPolicy: util.NewString("Local")},
+		{ExternalTrafficPolicy: util.NewString("Node"), Type: util.NewString("NodePort")},
+		{SessionAffinity: util.NewString("Cookie")},
+		{SessionAffinityTimeout: util.NewString("1h")},
+		{SessionAffinity: util.NewString("ClientIP"), SessionAffinityTimeout: util.NewString("1500ms")},
+		{SessionAffinity: util.NewString("ClientIP"), SessionAffinityTimeout: util.NewString("25h")},
+	} {
+		err := loadKubernetesService(newTestKubernetesServiceService(), ks)
+		if err == nil {
+			t.Error(ks)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"sort"
+)
+
+// GetNamespace returns the namespace of the pod and Kubernetes service of a docker compose service. The containers of sidecars run in
+// the pod of the first service of their pod group, so sidecars are in the namespace of that service.
+func (cfg *Config) GetNamespace(service *Service) string {
+	if namespace := service.PodService().Namespace; namespace != "" {
+		return namespace
+	}
+	return cfg.Namespace
+}
+
+// Namespaces returns the namespaces of the docker compose services: the namespace of the environment, followed by the other namespaces
+// in lexicographical order.
+func (cfg *Config) Namespaces() []string {
+	seen := map[string]bool{
+		cfg.Namespace: true,
+	}
+	var others []string
+	for _, service := range cfg.Services {
+		if namespace := cfg.GetNamespace(service); !seen[namespace] {
+			seen[namespace] = true
+			others = append(others, namespace)
+		}
+	}
+	sort.Strings(others)
+	return append([]string{cfg.Namespace}, others...)
+}
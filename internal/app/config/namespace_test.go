@@ -0,0 +1,64 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func Test_New_Namespace(t *testing.T) {
+	file := "/namespace"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+  b:
+    image: b
+    x-kube-compose:
+      namespace: infra
+  c:
+    image: c
+x-kube-compose:
+  pod_groups:
+    g: [b, c]
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		c.Namespace = "app"
+		if c.GetNamespace(c.Services["a"]) != "app" || c.GetNamespace(c.Services["b"]) != "infra" ||
+			c.GetNamespace(c.Services["c"]) != "infra" {
+			t.Error(c.Services)
+		}
+		if namespaces := c.Namespaces(); !reflect.DeepEqual(namespaces, []string{"app", "infra"}) {
+			t.Error(namespaces)
+		}
+	})
+}
+
+func Test_New_NamespaceInvalid(t *testing.T) {
+	file := "/namespace"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+    x-kube-compose:
+      namespace: Not_Valid
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PodGroup is a group of docker compose services whose containers run in a single pod ("x-kube-compose"."pod_groups"), such as an
+// application and its sidecars. The containers of the pod share the network namespace, so that they can reach each other on localhost,
+// and containers that bind mount the same host path share the volume.
+type PodGroup struct {
+	Name string
+	// The services of the group. The pod is the pod of the first service, and the other services are its sidecars. Pod-level settings,
+	// such as the service account and the restart policy, are those of the first service.
+	Services []*Service
+}
+
+// PodService returns the docker compose service whose pod runs the container of the service. This is the service itself, unless the
+// service is a sidecar in a pod group.
+func (s *Service) PodService() *Service {
+	if s.PodGroup == nil {
+		return s
+	}
+	return s.PodGroup.Services[0]
+}
+
+// PodServices returns the docker compose services whose containers run in the pod of the service, starting with the service whose pod it
+// is.
+func (s *Service) PodServices() []*Service {
+	if s.PodGroup == nil {
+		return []*Service{s}
+	}
+	return s.PodGroup.Services
+}
+
+// IsSidecar returns true if and only if the container of the service runs in the pod of another service (see PodGroup).
+func (s *Service) IsSidecar() bool {
+	return s.PodService() != s
+}
+
+func loadPodGroups(cfg *Config, podGroups map[string][]string) error {
+	names := make([]string, 0, len(podGroups))
+	for name := range podGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if len(podGroups[name]) < 2 {
+			return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"pod_groups\".%q: a pod group must have "+
+				"at least two services", name)
+		}
+		// A pod group that is redefined replaces the earlier definition.
+		for _, service := range cfg.Services {
+			if service.PodGroup != nil && service.PodGroup.Name == name {
+				service.PodGroup = nil
+			}
+		}
+		podGroup := &PodGroup{
+			Name: name,
+		}
+		for _, serviceName := range podGroups[name] {
+			service := cfg.Services[serviceName]
+			if service == nil {
+				return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"pod_groups\".%q: service %s does not "+
+					"exist", name, serviceName)
+			}
+			if service.PodGroup != nil && service.PodGroup.Name != name {
+				return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"pod_groups\".%q: service %s is "+
+					"already in pod group %s", name, serviceName, service.PodGroup.Name)
+			}
+			for _, service2 := range podGroup.Services {
+				if service2 == service {
+					return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"pod_groups\".%q: service %s is "+
+						"listed more than once", name, serviceName)
+				}
+			}
+			podGroup.Services = append(podGroup.Services, service)
+		}
+		for _, service := range podGroup.Services {
+			service.PodGroup = podGroup
+		}
+	}
+	return nil
+}
+
+// validateSidecar returns an error if a sidecar sets configuration that applies to a whole pod, or that is not supported for sidecars.
+func validateSidecar(service *Service) error {
+	var setting string
+	switch {
+	case service.Cluster != "":
+		setting = "a cluster"
+	case service.Namespace != "":
+		setting = "a namespace"
+	case service.PriorityClassName != "":
+		setting = "a priority class"
+	case service.RuntimeClassName != "":
+		setting = "a runtime class"
+	case service.ServiceAccount != nil:
+		setting = "a service account"
+	case service.TopologySpread != nil:
+		setting = "a topology spread"
+	case len(service.DockerComposeService.Secrets) > 0:
+		setting = "secrets"
+	default:
+		return nil
+	}
+	return fmt.Errorf("service %s is a sidecar in pod group %s, but has %s (set it on service %s instead)", service.Name(),
+		service.PodGroup.Name, setting, service.PodService().Name())
+}
+
+// ensureNoPodGroupCycle returns an error if a pod group (indirectly) depends on itself, because the pod of the group can then never be
+// created. For example, this is the case if a sidecar depends on a service that depends on the first service of the group.
+func ensureNoPodGroupCycle(cfg *Config, podGroup *PodGroup) error {
+	visited := map[*Service]bool{}
+	var queue []*Service
+	for _, service := range podGroup.Services {
+		for name := range service.DockerComposeService.DependsOn {
+			if service2 := cfg.Services[name]; service2.PodGroup != podGroup {
+				queue = append(queue, service2)
+			}
+		}
+	}
+	for len(queue) > 0 {
+		service := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if visited[service] {
+			continue
+		}
+		visited[service] = true
+		if service.PodGroup == podGroup {
+			return fmt.Errorf("pod group %s depends on itself through depends_on (via service %s)", podGroup.Name, service.Name())
+		}
+		for _, member := range service.PodServices() {
+			for name := range member.DockerComposeService.DependsOn {
+				queue = append(queue, cfg.Services[name])
+			}
+		}
+	}
+	return nil
+}
+
+func validatePodGroups(cfg *Config) error {
+	names := make([]string, 0, len(cfg.Services))
+	for name := range cfg.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		service := cfg.Services[name]
+		if !service.IsSidecar() {
+			if service.PodGroup != nil {
+				err := ensureNoPodGroupCycle(cfg, service.PodGroup)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		err := validateSidecar(service)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func Test_New_PodGroups(t *testing.T) {
+	file := "/podgroups"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  app:
+    image: app
+    depends_on: [db]
+  proxy:
+    image: nginx
+    depends_on: [app]
+  db:
+    image: db
+x-kube-compose:
+  pod_groups:
+    web: [app, proxy]
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		app, proxy, db := c.Services["app"], c.Services["proxy"], c.Services["db"]
+		if app.PodGroup == nil || app.PodGroup.Name != "web" || proxy.PodGroup != app.PodGroup || db.PodGroup != nil {
+			t.Fail()
+			return
+		}
+		if app.IsSidecar() || !proxy.IsSidecar() || db.IsSidecar() || proxy.PodService() != app || db.PodService() != db {
+			t.Fail()
+		}
+		if len(proxy.PodServices()) != 2 || len(db.PodServices()) != 1 {
+			t.Fail()
+		}
+		c.AddToFilter(proxy)
+		if !c.MatchesFilter(app) || !c.MatchesFilter(db) {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_PodGroupsInvalid(t *testing.T) {
+	for _, content := range []string{
+		// Too few services.
+		"x-kube-compose:\n  pod_groups:\n    g: [a]\n",
+		// Unknown service.
+		"x-kube-compose:\n  pod_groups:\n    g: [a, c]\n",
+		// Duplicate service.
+		"x-kube-compose:\n  pod_groups:\n    g: [a, a]\n",
+		// Service in two groups.
+		"x-kube-compose:\n  pod_groups:\n    g: [a, b]\n    h: [b, a]\n",
+		// Sidecar with pod-level configuration.
+		"    x-kube-compose:\n      priority_class_name: high\nx-kube-compose:\n  pod_groups:\n    g: [a, b]\n",
+	} {
+		file := "/podgroupsinvalid"
+		withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+			file: {
+				Content: []byte("version: '2.4'\nservices:\n  a:\n    image: a\n  b:\n    image: b\n" + content),
+			},
+		}), func() {
+			_, err := New([]string{file})
+			if err == nil {
+				t.Error(content)
+			}
+		})
+	}
+}
+
+func Test_New_PodGroupCycle(t *testing.T) {
+	file := "/podgroupcycle"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+  b:
+    image: b
+    depends_on: [c]
+  c:
+    image: c
+    depends_on: [a]
+x-kube-compose:
+  pod_groups:
+    g: [a, b]
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
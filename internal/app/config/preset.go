@@ -0,0 +1,113 @@
+package config
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	dockerRef "github.com/docker/distribution/reference"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+)
+
+// Preset is built-in configuration of a common stateful image, which fills in the configuration that docker compose files of such images
+// commonly leave out ("x-kube-compose"."presets").
+type Preset struct {
+	Name string
+	// The familiar name of the repository of the image of the preset.
+	image string
+	// The healthcheck of services that do not have one.
+	healthcheck dockerComposeConfig.Healthcheck
+	// The directory in the container where the image stores its data. The directory is put on a volume, so that the data survives
+	// restarts of the container.
+	DataDir string
+	// The stop_grace_period of services that do not set it, which gives the image time to flush its data to disk.
+	stopGracePeriod time.Duration
+}
+
+var presets = []*Preset{
+	{
+		Name:  "postgres",
+		image: "postgres",
+		healthcheck: dockerComposeConfig.Healthcheck{
+			IsShell: true,
+			Test:    []string{`pg_isready -U "${POSTGRES_USER:-postgres}" -h 127.0.0.1`},
+		},
+		DataDir:         "/var/lib/postgresql/data",
+		stopGracePeriod: 30 * time.Second,
+	},
+	{
+		Name:  "mysql",
+		image: "mysql",
+		healthcheck: dockerComposeConfig.Healthcheck{
+			IsShell: true,
+			Test:    []string{"mysqladmin ping -h 127.0.0.1 --silent"},
+		},
+		DataDir:         "/var/lib/mysql",
+		stopGracePeriod: 30 * time.Second,
+	},
+	{
+		Name:  "redis",
+		image: "redis",
+		healthcheck: dockerComposeConfig.Healthcheck{
+			Test: []string{"redis-cli", "ping"},
+		},
+		DataDir:         "/data",
+		stopGracePeriod: 10 * time.Second,
+	},
+	{
+		Name:  "rabbitmq",
+		image: "rabbitmq",
+		healthcheck: dockerComposeConfig.Healthcheck{
+			Test: []string{"rabbitmq-diagnostics", "-q", "ping"},
+		},
+		DataDir:         "/var/lib/rabbitmq",
+		stopGracePeriod: 30 * time.Second,
+	},
+}
+
+// The parameters of the healthchecks of presets. The interval is shorter than docker's default, so that dependency waiting is not slowed
+// down.
+const (
+	presetHealthcheckInterval = 5 * time.Second
+	presetHealthcheckRetries  = 10
+	presetHealthcheckTimeout  = 5 * time.Second
+)
+
+// findPreset returns the preset of an image, or nil if the image does not have a preset. The tag and digest of the image are ignored.
+func findPreset(image string) *Preset {
+	named, err := dockerRef.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil
+	}
+	familiarName := dockerRef.FamiliarName(named)
+	for _, preset := range presets {
+		if familiarName == preset.image {
+			return preset
+		}
+	}
+	return nil
+}
+
+// applyPresets applies the presets of the images of docker compose services. The healthcheck and stop_grace_period of a preset are only
+// used if the service does not set them, and a disabled healthcheck stays disabled.
+func applyPresets(cfg *Config) {
+	for _, service := range cfg.Services {
+		dcService := service.DockerComposeService
+		preset := findPreset(dcService.Image)
+		if preset == nil {
+			continue
+		}
+		log.Debugf("applying preset %s to service %s", preset.Name, service.Name())
+		service.Preset = preset
+		if dcService.Healthcheck == nil && !dcService.HealthcheckDisabled {
+			healthcheck := preset.healthcheck
+			healthcheck.Interval = presetHealthcheckInterval
+			healthcheck.Retries = presetHealthcheckRetries
+			healthcheck.Timeout = presetHealthcheckTimeout
+			dcService.Healthcheck = &healthcheck
+		}
+		if dcService.StopGracePeriod == nil {
+			stopGracePeriod := preset.stopGracePeriod
+			dcService.StopGracePeriod = &stopGracePeriod
+		}
+	}
+}
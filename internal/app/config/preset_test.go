@@ -0,0 +1,94 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func TestFindPreset(t *testing.T) {
+	for image, expected := range map[string]string{
+		"postgres":                      "postgres",
+		"postgres:12-alpine":            "postgres",
+		"docker.io/library/mysql:8":     "mysql",
+		"redis@sha256:" + testDigestHex: "redis",
+		"rabbitmq:3-management":         "rabbitmq",
+		"bitnami/postgresql":            "",
+		"registry.example.com/redis":    "",
+		"":                              "",
+	} {
+		preset := findPreset(image)
+		if (preset == nil && expected != "") || (preset != nil && preset.Name != expected) {
+			t.Error(image, preset)
+		}
+	}
+}
+
+const testDigestHex = "0000000000000000000000000000000000000000000000000000000000000000"
+
+func Test_New_Presets(t *testing.T) {
+	file := "/presets"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  db:
+    image: postgres:12
+  cache:
+    image: redis
+    healthcheck:
+      test: ['CMD', 'true']
+    stop_grace_period: 1s
+  queue:
+    image: rabbitmq
+    healthcheck:
+      disable: true
+  app:
+    image: app
+x-kube-compose:
+  presets: true
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		db := c.Services["db"]
+		if db.Preset == nil || db.Preset.DataDir != "/var/lib/postgresql/data" || db.DockerComposeService.Healthcheck == nil ||
+			!db.DockerComposeService.Healthcheck.IsShell || *db.DockerComposeService.StopGracePeriod != 30*time.Second {
+			t.Error(db.Preset, db.DockerComposeService)
+		}
+		cache := c.Services["cache"].DockerComposeService
+		if cache.Healthcheck.Test[0] != "true" || *cache.StopGracePeriod != time.Second {
+			t.Error(cache)
+		}
+		queue := c.Services["queue"].DockerComposeService
+		if queue.Healthcheck != nil || !queue.HealthcheckDisabled {
+			t.Error(queue)
+		}
+		if c.Services["app"].Preset != nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_PresetsDisabledByDefault(t *testing.T) {
+	file := "/presetsdisabled"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte("version: '2.4'\nservices:\n  db:\n    image: postgres\n"),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if c.Services["db"].Preset != nil || c.Services["db"].DockerComposeService.Healthcheck != nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type defaultResources struct {
+	CPULimit      *string `mapdecode:"cpu_limit"`
+	CPURequest    *string `mapdecode:"cpu_request"`
+	MemoryLimit   *string `mapdecode:"memory_limit"`
+	MemoryRequest *string `mapdecode:"memory_request"`
+}
+
+// SetDefaultResource sets a default resource request or limit of containers, which applies to docker compose services that do not set
+// the corresponding resource constraint. The value is a Kubernetes quantity such as "100m" or "512Mi".
+func SetDefaultResource(requirements *v1.ResourceRequirements, name v1.ResourceName, isLimit bool, value string) error {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return errors.Wrapf(err, "invalid quantity %#v", value)
+	}
+	if quantity.Sign() <= 0 {
+		return fmt.Errorf("quantity %#v must be positive", value)
+	}
+	list := &requirements.Requests
+	if isLimit {
+		list = &requirements.Limits
+	}
+	if *list == nil {
+		*list = v1.ResourceList{}
+	}
+	(*list)[name] = quantity
+	return nil
+}
+
+// ValidateDefaultResources returns an error if a default request exceeds the corresponding default limit, because Kubernetes rejects
+// such containers.
+func ValidateDefaultResources(requirements *v1.ResourceRequirements) error {
+	for name, request := range requirements.Requests {
+		if limit, ok := requirements.Limits[name]; ok && request.Cmp(limit) > 0 {
+			return fmt.Errorf("the default %s request %s exceeds the default %s limit %s", name, request.String(), name, limit.String())
+		}
+	}
+	return nil
+}
+
+func loadDefaultResources(cfg *Config, d *defaultResources) error {
+	if d == nil {
+		return nil
+	}
+	for _, item := range []struct {
+		key     string
+		name    v1.ResourceName
+		isLimit bool
+		value   *string
+	}{
+		{"cpu_limit", v1.ResourceCPU, true, d.CPULimit},
+		{"cpu_request", v1.ResourceCPU, false, d.CPURequest},
+		{"memory_limit", v1.ResourceMemory, true, d.MemoryLimit},
+		{"memory_request", v1.ResourceMemory, false, d.MemoryRequest},
+	} {
+		if item.value == nil {
+			continue
+		}
+		err := SetDefaultResource(&cfg.DefaultResources, item.name, item.isLimit, *item.value)
+		if err != nil {
+			return errors.Wrapf(err, "a docker compose file has an invalid value at \"x-kube-compose\".\"default_resources\".%q", item.key)
+		}
+	}
+	return nil
+}
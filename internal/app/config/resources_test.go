@@ -0,0 +1,72 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	v1 "k8s.io/api/core/v1"
+)
+
+func Test_New_DefaultResourcesSuccess(t *testing.T) {
+	file := "/defaultresources"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    image: a
+x-kube-compose:
+  default_resources:
+    cpu_request: 100m
+    memory_limit: 512Mi
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		cpuRequest := c.DefaultResources.Requests[v1.ResourceCPU]
+		memoryLimit := c.DefaultResources.Limits[v1.ResourceMemory]
+		if cpuRequest.String() != "100m" || memoryLimit.String() != "512Mi" || len(c.DefaultResources.Requests) != 1 ||
+			len(c.DefaultResources.Limits) != 1 {
+			t.Error(c.DefaultResources)
+		}
+	})
+}
+
+func Test_New_DefaultResourcesInvalid(t *testing.T) {
+	for _, defaultResources := range []string{
+		"{cpu_request: henkie}",
+		"{memory_limit: '-1'}",
+		"{memory_request: 1Gi, memory_limit: 512Mi}",
+	} {
+		file := "/defaultresources"
+		withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+			file: {
+				Content: []byte(`version: '2.4'
+x-kube-compose:
+  default_resources: ` + defaultResources + `
+`),
+			},
+		}), func() {
+			_, err := New([]string{file})
+			if err == nil {
+				t.Error(defaultResources)
+			}
+		})
+	}
+}
+
+func TestSetDefaultResource_Success(t *testing.T) {
+	var requirements v1.ResourceRequirements
+	err := SetDefaultResource(&requirements, v1.ResourceCPU, true, "2")
+	if err != nil {
+		t.Error(err)
+	}
+	cpuLimit := requirements.Limits[v1.ResourceCPU]
+	if cpuLimit.String() != "2" || requirements.Requests != nil {
+		t.Error(requirements)
+	}
+}
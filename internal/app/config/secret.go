@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"github.com/pkg/errors"
+	"github.com/uber-go/mapdecode"
+)
+
+// ExternalSecret configures an external docker compose secret that is provided by the External Secrets Operator
+// (https://external-secrets.io/). kube-compose creates an ExternalSecret object, and the operator creates the Secret mounted by pods.
+type ExternalSecret struct {
+	// The key of the secret in the external secret store.
+	RemoteKey string
+	// The property of the secret in the external secret store, or the empty string if the whole secret is used.
+	RemoteProperty string
+	// The kind of the referenced secret store, either SecretStore or ClusterSecretStore.
+	SecretStoreKind string
+	// The name of the referenced secret store.
+	SecretStoreName string
+}
+
+// CSISecret configures an external docker compose secret that is provided by the Secrets Store CSI Driver
+// (https://secrets-store-csi-driver.sigs.k8s.io/). kube-compose creates a SecretProviderClass object, and pods mount the secret through
+// the driver without a Secret being created.
+type CSISecret struct {
+	// The name of the file within the volume mounted by the driver that contains the secret.
+	ObjectName string
+	// The provider specific parameters of the SecretProviderClass.
+	Parameters map[string]string
+	// The name of the provider of the driver (e.g. aws, azure, gcp or vault).
+	Provider string
+}
+
+// Secret is an external docker compose secret that is provided by an operator in the cluster, so that kube-compose does not need to
+// create Secrets with plaintext values. Exactly one of CSI and ExternalSecret is set.
+type Secret struct {
+	CSI            *CSISecret
+	ExternalSecret *ExternalSecret
+	// The key of the docker compose secret.
+	Name string
+}
+
+type csiSecret struct {
+	ObjectName *string           `mapdecode:"object_name"`
+	Parameters map[string]string `mapdecode:"parameters"`
+	Provider   string            `mapdecode:"provider"`
+}
+
+type externalSecret struct {
+	RemoteKey       *string `mapdecode:"remote_key"`
+	RemoteProperty  string  `mapdecode:"remote_property"`
+	SecretStore     string  `mapdecode:"secret_store"`
+	SecretStoreKind *string `mapdecode:"secret_store_kind"`
+}
+
+type xKubeComposeSecret struct {
+	XKubeCompose struct {
+		CSI            *csiSecret      `mapdecode:"csi"`
+		ExternalSecret *externalSecret `mapdecode:"external_secret"`
+	} `mapdecode:"x-kube-compose"`
+}
+
+// loadSecrets loads the provider configuration of external docker compose secrets. Secrets without provider configuration are not
+// added to cfg.
+func loadSecrets(cfg *Config, dcSecrets map[string]*dockerComposeConfig.Secret) error {
+	keys := make([]string, 0, len(dcSecrets))
+	for key := range dcSecrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		dcSecret := dcSecrets[key]
+		var x xKubeComposeSecret
+		err := mapdecode.Decode(&x, dcSecret.XProperties, mapdecode.IgnoreUnused(true))
+		if err != nil {
+			return errors.Wrapf(err, "error while parsing \"x-kube-compose\" of secret %s", key)
+		}
+		if x.XKubeCompose.CSI == nil && x.XKubeCompose.ExternalSecret == nil {
+			continue
+		}
+		if !dcSecret.External {
+			return fmt.Errorf("secret %s has \"x-kube-compose\" provider configuration, but is not external", key)
+		}
+		secret := &Secret{
+			Name: key,
+		}
+		if x.XKubeCompose.CSI != nil {
+			if x.XKubeCompose.ExternalSecret != nil {
+				return fmt.Errorf("secret %s cannot set both \"x-kube-compose\".\"csi\" and \"x-kube-compose\".\"external_secret\"", key)
+			}
+			secret.CSI, err = loadCSISecret(dcSecret, x.XKubeCompose.CSI)
+		} else {
+			secret.ExternalSecret, err = loadExternalSecret(dcSecret, x.XKubeCompose.ExternalSecret)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "secret %s has an invalid value at \"x-kube-compose\"", key)
+		}
+		if cfg.Secrets == nil {
+			cfg.Secrets = map[string]*Secret{}
+		}
+		cfg.Secrets[key] = secret
+	}
+	return nil
+}
+
+func loadCSISecret(dcSecret *dockerComposeConfig.Secret, v *csiSecret) (*CSISecret, error) {
+	if v.Provider == "" {
+		return nil, fmt.Errorf("\"csi\".\"provider\" is required")
+	}
+	s := &CSISecret{
+		ObjectName: dcSecret.Name,
+		Parameters: v.Parameters,
+		Provider:   v.Provider,
+	}
+	if v.ObjectName != nil {
+		s.ObjectName = *v.ObjectName
+	}
+	return s, nil
+}
+
+func loadExternalSecret(dcSecret *dockerComposeConfig.Secret, v *externalSecret) (*ExternalSecret, error) {
+	if v.SecretStore == "" {
+		return nil, fmt.Errorf("\"external_secret\".\"secret_store\" is required")
+	}
+	s := &ExternalSecret{
+		RemoteKey:       dcSecret.Name,
+		RemoteProperty:  v.RemoteProperty,
+		SecretStoreKind: "SecretStore",
+		SecretStoreName: v.SecretStore,
+	}
+	if v.RemoteKey != nil {
+		s.RemoteKey = *v.RemoteKey
+	}
+	if v.SecretStoreKind != nil {
+		switch *v.SecretStoreKind {
+		case "SecretStore", "ClusterSecretStore":
+			s.SecretStoreKind = *v.SecretStoreKind
+		default:
+			return nil, fmt.Errorf("\"external_secret\".\"secret_store_kind\" must be one of \"SecretStore\" and \"ClusterSecretStore\"")
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func Test_New_SecretsSuccess(t *testing.T) {
+	file := "/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '3.7'
+services:
+  service1:
+    secrets:
+    - db_password
+    - api_key
+    - tls_key
+secrets:
+  db_password:
+    external: true
+    x-kube-compose:
+      external_secret:
+        secret_store: vault-backend
+        secret_store_kind: ClusterSecretStore
+        remote_key: secret/myapp
+        remote_property: db_password
+  api_key:
+    external: true
+    x-kube-compose:
+      csi:
+        provider: aws
+        parameters:
+          region: eu-west-1
+  tls_key:
+    external: true
+`),
+		},
+	}), func() {
+		cfg, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else {
+			expected := map[string]*Secret{
+				"db_password": {
+					ExternalSecret: &ExternalSecret{
+						RemoteKey:       "secret/myapp",
+						RemoteProperty:  "db_password",
+						SecretStoreKind: "ClusterSecretStore",
+						SecretStoreName: "vault-backend",
+					},
+					Name: "db_password",
+				},
+				"api_key": {
+					CSI: &CSISecret{
+						ObjectName: "api_key",
+						Parameters: map[string]string{
+							"region": "eu-west-1",
+						},
+						Provider: "aws",
+					},
+					Name: "api_key",
+				},
+			}
+			if !reflect.DeepEqual(cfg.Secrets, expected) {
+				t.Error(cfg.Secrets)
+			}
+		}
+	})
+}
+
+func Test_New_SecretsNotExternal(t *testing.T) {
+	file := "/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '3.7'
+services: {}
+secrets:
+  db_password:
+    file: ./db_password.txt
+    x-kube-compose:
+      csi:
+        provider: aws
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_SecretsBothProviders(t *testing.T) {
+	file := "/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '3.7'
+services: {}
+secrets:
+  db_password:
+    external: true
+    x-kube-compose:
+      csi:
+        provider: aws
+      external_secret:
+        secret_store: vault-backend
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_SecretsInvalidSecretStoreKind(t *testing.T) {
+	file := "/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '3.7'
+services: {}
+secrets:
+  db_password:
+    external: true
+    x-kube-compose:
+      external_secret:
+        secret_store: vault-backend
+        secret_store_kind: Vault
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/mapdecode"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// xKubeComposeService is the "x-kube-compose" extension field of a docker compose service.
+type xKubeComposeService struct {
+	XKubeCompose struct {
+		Cluster           *string            `mapdecode:"cluster"`
+		Ingress           *ingress           `mapdecode:"ingress"`
+		KubernetesService *kubernetesService `mapdecode:"kubernetes_service"`
+		Namespace         *string            `mapdecode:"namespace"`
+		PriorityClassName *string            `mapdecode:"priority_class_name"`
+		RuntimeClassName  *string            `mapdecode:"runtime_class_name"`
+	} `mapdecode:"x-kube-compose"`
+}
+
+// loadServiceXKubeCompose loads the "x-kube-compose" extension field of a docker compose service.
+func loadServiceXKubeCompose(service *Service) error {
+	var x xKubeComposeService
+	err := mapdecode.Decode(&x, service.DockerComposeService.XProperties, mapdecode.IgnoreUnused(true))
+	if err != nil {
+		return errors.Wrapf(err, "error while parsing \"x-kube-compose\" of service %s", service.Name())
+	}
+	if x.XKubeCompose.Cluster != nil {
+		if e := validation.IsDNS1123Label(*x.XKubeCompose.Cluster); len(e) > 0 {
+			return fmt.Errorf("service %s has an invalid value at \"x-kube-compose\".\"cluster\": %s", service.Name(), e[0])
+		}
+		service.Cluster = *x.XKubeCompose.Cluster
+	}
+	if x.XKubeCompose.Ingress != nil {
+		err = loadIngress(service, x.XKubeCompose.Ingress)
+		if err != nil {
+			return err
+		}
+	}
+	if x.XKubeCompose.KubernetesService != nil {
+		err = loadKubernetesService(service, x.XKubeCompose.KubernetesService)
+		if err != nil {
+			return err
+		}
+	}
+	if x.XKubeCompose.Namespace != nil {
+		if e := validation.IsDNS1123Label(*x.XKubeCompose.Namespace); len(e) > 0 {
+			return fmt.Errorf("service %s has an invalid value at \"x-kube-compose\".\"namespace\": %s", service.Name(), e[0])
+		}
+		service.Namespace = *x.XKubeCompose.Namespace
+	}
+	if x.XKubeCompose.PriorityClassName != nil {
+		if e := validation.IsDNS1123Subdomain(*x.XKubeCompose.PriorityClassName); len(e) > 0 {
+			return fmt.Errorf("service %s has an invalid value at \"x-kube-compose\".\"priority_class_name\": %s", service.Name(), e[0])
+		}
+		service.PriorityClassName = *x.XKubeCompose.PriorityClassName
+	}
+	if x.XKubeCompose.RuntimeClassName != nil {
+		if e := validation.IsDNS1123Subdomain(*x.XKubeCompose.RuntimeClassName); len(e) > 0 {
+			return fmt.Errorf("service %s has an invalid value at \"x-kube-compose\".\"runtime_class_name\": %s", service.Name(), e[0])
+		}
+		service.RuntimeClassName = *x.XKubeCompose.RuntimeClassName
+	}
+	return nil
+}
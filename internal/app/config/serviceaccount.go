@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MinServiceAccountTokenExpiration is the minimum expiration of a projected service account token accepted by Kubernetes.
+const MinServiceAccountTokenExpiration = 10 * time.Minute
+
+// ServiceAccount is the service account configuration of the pod of a docker compose service. By default pods do not get a service
+// account token, because most docker compose services do not need access to the Kubernetes API.
+type ServiceAccount struct {
+	// Whether the token of the service account is mounted at the standard location (automountServiceAccountToken).
+	AutomountToken bool
+	// The name of the service account, or the empty string to use the default service account of the namespace.
+	Name string
+	// Projected service account tokens, which are typically used to authenticate to systems other than the Kubernetes API.
+	Tokens []ServiceAccountToken
+}
+
+// ServiceAccountToken is a projected service account token that is mounted into the container of a docker compose service.
+type ServiceAccountToken struct {
+	// The intended audience of the token, or the empty string for the audience of the Kubernetes API.
+	Audience string
+	// The requested validity of the token, or 0 for Kubernetes' default. Kubernetes rotates the token before it expires.
+	Expiration time.Duration
+	// The absolute path of the token file in the container. The directory of the file is mounted as a volume that only has tokens.
+	Path string
+}
+
+type serviceAccount struct {
+	AutomountToken *bool                  `mapdecode:"automount_token"`
+	Name           *string                `mapdecode:"name"`
+	Tokens         []*serviceAccountToken `mapdecode:"tokens"`
+}
+
+type serviceAccountToken struct {
+	Audience   string  `mapdecode:"audience"`
+	Expiration *string `mapdecode:"expiration"`
+	Path       string  `mapdecode:"path"`
+}
+
+func loadServiceAccounts(cfg *Config, serviceAccounts map[string]*serviceAccount) error {
+	names := make([]string, 0, len(serviceAccounts))
+	for name := range serviceAccounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		service := cfg.Services[name]
+		if service == nil {
+			return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"service_accounts\": service %s does "+
+				"not exist", name)
+		}
+		sa := serviceAccounts[name]
+		if sa == nil {
+			continue
+		}
+		if service.ServiceAccount == nil {
+			service.ServiceAccount = &ServiceAccount{}
+		}
+		if sa.AutomountToken != nil {
+			service.ServiceAccount.AutomountToken = *sa.AutomountToken
+		}
+		if sa.Name != nil {
+			service.ServiceAccount.Name = *sa.Name
+		}
+		if sa.Tokens != nil {
+			tokens, err := loadServiceAccountTokens(name, sa.Tokens)
+			if err != nil {
+				return err
+			}
+			service.ServiceAccount.Tokens = tokens
+		}
+	}
+	return nil
+}
+
+func loadServiceAccountTokens(name string, tokensRaw []*serviceAccountToken) ([]ServiceAccountToken, error) {
+	tokens := make([]ServiceAccountToken, 0, len(tokensRaw))
+	paths := map[string]bool{}
+	for i, tokenRaw := range tokensRaw {
+		if tokenRaw == nil {
+			continue
+		}
+		// The directory of the token is mounted as a volume, so it cannot be the root directory.
+		if !path.IsAbs(tokenRaw.Path) || path.Dir(path.Clean(tokenRaw.Path)) == "/" {
+			return nil, fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"service_accounts\".%q.\"tokens\"[%d]."+
+				"\"path\": value must be an absolute path of a file in a directory other than /", name, i)
+		}
+		token := ServiceAccountToken{
+			Audience: tokenRaw.Audience,
+			Path:     path.Clean(tokenRaw.Path),
+		}
+		if paths[token.Path] {
+			return nil, fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"service_accounts\".%q.\"tokens\": "+
+				"path %s is used more than once", name, token.Path)
+		}
+		paths[token.Path] = true
+		if tokenRaw.Expiration != nil {
+			var err error
+			token.Expiration, err = time.ParseDuration(*tokenRaw.Expiration)
+			if err != nil {
+				return nil, errors.Wrapf(err, "a docker compose file has an invalid value at \"x-kube-compose\".\"service_accounts\".%q."+
+					"\"tokens\"[%d].\"expiration\"", name, i)
+			}
+			if token.Expiration < MinServiceAccountTokenExpiration {
+				return nil, fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"service_accounts\".%q."+
+					"\"tokens\"[%d].\"expiration\": value must be at least %s", name, i, MinServiceAccountTokenExpiration)
+			}
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// TopologyKeyHostname is the node label that identifies the node, which is used to spread pods over nodes.
+	TopologyKeyHostname = "kubernetes.io/hostname"
+	// TopologyKeyZone is the node label that identifies the zone of the node, which is used to spread pods over zones.
+	TopologyKeyZone = "topology.kubernetes.io/zone"
+	// DefaultTopologySpreadGroup is the group of services that are spread if no group is configured.
+	DefaultTopologySpreadGroup = "default"
+)
+
+// TopologySpread specifies how the pod of a docker compose service is spread over the topology of the cluster, relative to the pods
+// of the other services in the same group. Since every docker compose service has a single pod, spreading applies to groups of services
+// (for example, all replicas of a database that are declared as separate services).
+type TopologySpread struct {
+	// The name of the group of services whose pods are spread.
+	Group string
+	// If true then a pod is not scheduled in a topology domain that already runs a pod of the group, otherwise this is only preferred.
+	Required bool
+	// The node label that defines the topology domains, such as TopologyKeyZone or TopologyKeyHostname.
+	TopologyKey string
+}
+
+type topologySpread struct {
+	Group    *string `mapdecode:"group"`
+	Required *bool   `mapdecode:"required"`
+	Topology *string `mapdecode:"topology"`
+}
+
+func loadTopologySpread(cfg *Config, topologySpreads map[string]*topologySpread) error {
+	names := make([]string, 0, len(topologySpreads))
+	for name := range topologySpreads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		service := cfg.Services[name]
+		if service == nil {
+			return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"topology_spread\": service %s does "+
+				"not exist", name)
+		}
+		ts := topologySpreads[name]
+		if ts == nil {
+			continue
+		}
+		if service.TopologySpread == nil {
+			service.TopologySpread = &TopologySpread{
+				Group:       DefaultTopologySpreadGroup,
+				TopologyKey: TopologyKeyZone,
+			}
+		}
+		if ts.Group != nil {
+			if e := validation.IsValidLabelValue(*ts.Group); *ts.Group == "" || len(e) > 0 {
+				return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"topology_spread\".%q.\"group\": "+
+					"value must be a non-empty label value", name)
+			}
+			service.TopologySpread.Group = *ts.Group
+		}
+		if ts.Required != nil {
+			service.TopologySpread.Required = *ts.Required
+		}
+		if ts.Topology != nil {
+			topologyKey, err := parseTopologyKey(*ts.Topology)
+			if err != nil {
+				return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"topology_spread\".%q.\"topology\": "+
+					"%v", name, err)
+			}
+			service.TopologySpread.TopologyKey = topologyKey
+		}
+	}
+	return nil
+}
+
+// parseTopologyKey returns the node label of a topology, which is either zone, hostname or the name of a node label.
+func parseTopologyKey(topology string) (string, error) {
+	switch topology {
+	case "zone":
+		return TopologyKeyZone, nil
+	case "hostname":
+		return TopologyKeyHostname, nil
+	}
+	if e := validation.IsQualifiedName(topology); len(e) > 0 {
+		return "", fmt.Errorf("value must be \"zone\", \"hostname\" or the name of a node label: %s", strings.Join(e, "; "))
+	}
+	return topology, nil
+}
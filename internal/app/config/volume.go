@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"github.com/pkg/errors"
+	"github.com/uber-go/mapdecode"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DefaultVolumeSize is the default storage request of the PersistentVolumeClaims of named docker compose volumes.
+const DefaultVolumeSize = "1Gi"
+
+// Volume is a named docker compose volume, which is backed by a PersistentVolumeClaim.
+type Volume struct {
+	AccessMode v1.PersistentVolumeAccessMode
+	// The name of the existing PersistentVolumeClaim of an external volume, or the empty string if the volume is not external.
+	ClaimName string
+	// The key of the volume in the docker compose configuration.
+	Name string
+	// The storage request of the PersistentVolumeClaim.
+	Size resource.Quantity
+	// The storage class of the PersistentVolumeClaim, or nil to use the default storage class of the cluster.
+	StorageClassName *string
+}
+
+type xKubeComposeVolume struct {
+	XKubeCompose struct {
+		AccessMode   *string `mapdecode:"access_mode"`
+		Size         *string `mapdecode:"size"`
+		StorageClass *string `mapdecode:"storage_class"`
+	} `mapdecode:"x-kube-compose"`
+}
+
+// loadVolumes loads the named docker compose volumes that are mounted by services. Volumes that no service mounts are not added to cfg.
+// Docker compose files of version 1 do not declare volumes, so their named volumes get the defaults.
+func loadVolumes(cfg *Config, dcVolumes map[string]*dockerComposeConfig.Volume) error {
+	keys := getMountedVolumeKeys(cfg)
+	for _, key := range keys {
+		dcVolume := dcVolumes[key]
+		if dcVolume == nil {
+			dcVolume = &dockerComposeConfig.Volume{
+				Name: key,
+			}
+		}
+		volume, err := loadVolume(key, dcVolume)
+		if err != nil {
+			return err
+		}
+		if cfg.Volumes == nil {
+			cfg.Volumes = map[string]*Volume{}
+		}
+		cfg.Volumes[key] = volume
+	}
+	return nil
+}
+
+func getMountedVolumeKeys(cfg *Config) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, service := range cfg.Services {
+		for _, serviceVolume := range service.DockerComposeService.Volumes {
+			if serviceVolume.Short == nil || !serviceVolume.Short.NamedVolume || seen[serviceVolume.Short.HostPath] {
+				continue
+			}
+			seen[serviceVolume.Short.HostPath] = true
+			keys = append(keys, serviceVolume.Short.HostPath)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func loadVolume(key string, dcVolume *dockerComposeConfig.Volume) (*Volume, error) {
+	var x xKubeComposeVolume
+	err := mapdecode.Decode(&x, dcVolume.XProperties, mapdecode.IgnoreUnused(true))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while parsing \"x-kube-compose\" of volume %s", key)
+	}
+	volume := &Volume{
+		AccessMode:       v1.ReadWriteOnce,
+		Name:             key,
+		Size:             resource.MustParse(DefaultVolumeSize),
+		StorageClassName: x.XKubeCompose.StorageClass,
+	}
+	if x.XKubeCompose.AccessMode != nil {
+		switch mode := v1.PersistentVolumeAccessMode(*x.XKubeCompose.AccessMode); mode {
+		case v1.ReadWriteOnce, v1.ReadOnlyMany, v1.ReadWriteMany:
+			volume.AccessMode = mode
+		default:
+			return nil, fmt.Errorf("volume %s has an invalid value at \"x-kube-compose\".\"access_mode\": must be one of %q, %q and %q",
+				key, v1.ReadWriteOnce, v1.ReadOnlyMany, v1.ReadWriteMany)
+		}
+	}
+	if dcVolume.External {
+		// The PersistentVolumeClaim of an external volume already exists, so its size and storage class cannot be configured.
+		if x.XKubeCompose.Size != nil || x.XKubeCompose.StorageClass != nil {
+			return nil, fmt.Errorf("volume %s is external, so it cannot set \"x-kube-compose\".\"size\" or \"x-kube-compose\".\"storage_class\"",
+				key)
+		}
+		if e := validation.IsDNS1123Subdomain(dcVolume.Name); len(e) > 0 {
+			return nil, fmt.Errorf("the name of external volume %s is not a valid name of a PersistentVolumeClaim: %s", key, e[0])
+		}
+		volume.ClaimName = dcVolume.Name
+		return volume, nil
+	}
+	if x.XKubeCompose.Size != nil {
+		volume.Size, err = resource.ParseQuantity(*x.XKubeCompose.Size)
+		if err != nil || volume.Size.Sign() <= 0 {
+			return nil, fmt.Errorf("volume %s has an invalid value at \"x-kube-compose\".\"size\": %#v is not a positive quantity",
+				key, *x.XKubeCompose.Size)
+		}
+	}
+	return volume, nil
+}
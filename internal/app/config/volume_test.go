@@ -0,0 +1,155 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func Test_New_VolumesSuccess(t *testing.T) {
+	file := "/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '3.7'
+services:
+  service1:
+    image: postgres
+    volumes:
+    - data:/var/lib/postgresql/data
+    - shared:/shared:ro
+  service2:
+    image: nginx
+    volumes:
+    - shared:/usr/share/nginx/html
+volumes:
+  data:
+    x-kube-compose:
+      size: 10Gi
+      storage_class: fast
+  shared:
+    external: true
+    name: shared-claim
+    x-kube-compose:
+      access_mode: ReadWriteMany
+  unused: {}
+`),
+		},
+	}), func() {
+		cfg, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else {
+			storageClass := "fast"
+			expected := map[string]*Volume{
+				"data": {
+					AccessMode:       v1.ReadWriteOnce,
+					Name:             "data",
+					Size:             resource.MustParse("10Gi"),
+					StorageClassName: &storageClass,
+				},
+				"shared": {
+					AccessMode: v1.ReadWriteMany,
+					ClaimName:  "shared-claim",
+					Name:       "shared",
+					Size:       resource.MustParse(DefaultVolumeSize),
+				},
+			}
+			if !reflect.DeepEqual(cfg.Volumes, expected) {
+				t.Error(cfg.Volumes)
+			}
+		}
+	})
+}
+
+func Test_New_VolumesVersion1(t *testing.T) {
+	file := "/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`service1:
+  image: postgres
+  volumes:
+  - data:/var/lib/postgresql/data
+`),
+		},
+	}), func() {
+		cfg, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else if v := cfg.Volumes["data"]; v == nil || v.AccessMode != v1.ReadWriteOnce || v.Size.Cmp(resource.MustParse("1Gi")) != 0 {
+			t.Error(cfg.Volumes)
+		}
+	})
+}
+
+func Test_New_VolumesInvalidSize(t *testing.T) {
+	file := "/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '3.7'
+services:
+  service1:
+    volumes:
+    - data:/data
+volumes:
+  data:
+    x-kube-compose:
+      size: -1Gi
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_VolumesInvalidAccessMode(t *testing.T) {
+	file := "/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '3.7'
+services:
+  service1:
+    volumes:
+    - data:/data
+volumes:
+  data:
+    x-kube-compose:
+      access_mode: ReadWriteSometimes
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_VolumesExternalWithStorageClass(t *testing.T) {
+	file := "/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '3.7'
+services:
+  service1:
+    volumes:
+    - data:/data
+volumes:
+  data:
+    external: true
+    x-kube-compose:
+      storage_class: fast
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,230 @@
+// Package conformance runs docker compose fixtures, such as the test fixtures of the Compose Specification, through the loader of
+// kube-compose and reports the support of each feature. The support matrix of the bundled fixtures is committed (see testdata), so that
+// regressions fail the tests.
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Status is the support of a feature.
+type Status string
+
+const (
+	// StatusSupported means that all fixtures of the feature pass.
+	StatusSupported Status = "supported"
+	// StatusPartial means that some, but not all, fixtures of the feature pass.
+	StatusPartial Status = "partial"
+	// StatusUnsupported means that no fixture of the feature passes.
+	StatusUnsupported Status = "unsupported"
+)
+
+// FeatureSupport is the support of a feature, which is a directory of fixtures.
+type FeatureSupport struct {
+	// The reasons that fixtures failed, keyed by the names of the fixtures.
+	Failures map[string]string `json:"failures,omitempty"`
+	Feature  string            `json:"feature"`
+	// The sorted names of the fixtures that passed.
+	Passed []string `json:"passed,omitempty"`
+	Status Status   `json:"status"`
+}
+
+// isFixture returns true if and only if a file is a fixture, which are the YAML files of the directory of a feature. Other files, such as
+// env files, can be referred to by fixtures.
+func isFixture(fileInfo os.FileInfo) bool {
+	ext := filepath.Ext(fileInfo.Name())
+	return !fileInfo.IsDir() && (ext == ".yml" || ext == ".yaml")
+}
+
+func readDir(dir string) ([]os.FileInfo, error) {
+	fd, err := fs.OS.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(fd)
+	fileInfos, err := fd.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(fileInfos, func(i, j int) bool {
+		return fileInfos[i].Name() < fileInfos[j].Name()
+	})
+	return fileInfos, nil
+}
+
+// findIgnoredKeys returns an error if a fixture has keys at its root or in a service that are not interpreted by kube-compose, because
+// the loader ignores these keys and the fixture would otherwise pass.
+func findIgnoredKeys(file string) error {
+	fd, err := fs.OS.Open(file)
+	if err != nil {
+		return err
+	}
+	defer util.CloseAndLogError(fd)
+	var root struct {
+		Keys     map[string]interface{}            `yaml:",inline"`
+		Services map[string]map[string]interface{} `yaml:"services"`
+	}
+	err = yaml.NewDecoder(fd).Decode(&root)
+	if err != nil {
+		return err
+	}
+	if err = checkKeys(root.Keys, dockerComposeConfig.FileKeys()); err != nil {
+		return err
+	}
+	serviceKeys := dockerComposeConfig.ServiceKeys()
+	for _, service := range root.Services {
+		if err = checkKeys(service, serviceKeys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkKeys(m map[string]interface{}, supportedKeys []string) error {
+	var ignored []string
+	for key := range m {
+		i := sort.SearchStrings(supportedKeys, key)
+		if !strings.HasPrefix(key, "x-") && (i == len(supportedKeys) || supportedKeys[i] != key) {
+			ignored = append(ignored, key)
+		}
+	}
+	if len(ignored) == 0 {
+		return nil
+	}
+	sort.Strings(ignored)
+	return fmt.Errorf("the keys %s are ignored", strings.Join(ignored, ", "))
+}
+
+// runFixture returns nil if and only if a fixture passes. A fixture whose name starts with "invalid" passes if loading fails, and any
+// other fixture passes if it is loaded without error and does not have ignored keys.
+func runFixture(file string) error {
+	_, err := config.New([]string{file})
+	if strings.HasPrefix(filepath.Base(file), "invalid") {
+		if err == nil {
+			return fmt.Errorf("the fixture is invalid, but was loaded without error")
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return findIgnoredKeys(file)
+}
+
+// runFeature runs the fixtures in the directory of a feature. Nil is returned if the directory does not contain fixtures.
+func runFeature(dir, feature string) (*FeatureSupport, error) {
+	featureDir := filepath.Join(dir, feature)
+	fileInfos, err := readDir(featureDir)
+	if err != nil {
+		return nil, err
+	}
+	f := &FeatureSupport{
+		Failures: map[string]string{},
+		Feature:  feature,
+	}
+	for _, fileInfo := range fileInfos {
+		if !isFixture(fileInfo) {
+			continue
+		}
+		err = runFixture(filepath.Join(featureDir, fileInfo.Name()))
+		if err != nil {
+			// Make the reasons independent of the directory that contains the fixtures.
+			f.Failures[fileInfo.Name()] = strings.ReplaceAll(err.Error(), featureDir+string(filepath.Separator), "")
+		} else {
+			f.Passed = append(f.Passed, fileInfo.Name())
+		}
+	}
+	switch {
+	case len(f.Failures) == 0 && len(f.Passed) == 0:
+		return nil, nil
+	case len(f.Failures) == 0:
+		f.Status = StatusSupported
+	case len(f.Passed) == 0:
+		f.Status = StatusUnsupported
+	default:
+		f.Status = StatusPartial
+	}
+	return f, nil
+}
+
+// Run runs the fixtures in dir and returns the support matrix, sorted by feature. Each directory in dir is a feature, and the YAML files
+// in the directory of a feature are its fixtures. The fixtures are loaded through the file system fs.OS.
+func Run(dir string) ([]*FeatureSupport, error) {
+	fileInfos, err := readDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var matrix []*FeatureSupport
+	for _, fileInfo := range fileInfos {
+		if !fileInfo.IsDir() {
+			continue
+		}
+		var f *FeatureSupport
+		f, err = runFeature(dir, fileInfo.Name())
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			matrix = append(matrix, f)
+		}
+	}
+	return matrix, nil
+}
+
+// Regressions compares a support matrix with a baseline, and returns a message for each fixture that passed in the baseline but does not
+// pass in the matrix. New fixtures and features are not regressions.
+func Regressions(baseline, matrix []*FeatureSupport) []string {
+	features := map[string]*FeatureSupport{}
+	for _, f := range matrix {
+		features[f.Feature] = f
+	}
+	var regressions []string
+	for _, fBaseline := range baseline {
+		f := features[fBaseline.Feature]
+		for _, name := range fBaseline.Passed {
+			if f != nil && f.Failures[name] != "" {
+				regressions = append(regressions, fmt.Sprintf("feature %s: fixture %s failed: %s", f.Feature, name, f.Failures[name]))
+			} else if f == nil || !containsString(f.Passed, name) {
+				regressions = append(regressions, fmt.Sprintf("feature %s: fixture %s no longer exists", fBaseline.Feature, name))
+			}
+		}
+	}
+	return regressions
+}
+
+func containsString(slice []string, s string) bool {
+	for _, s2 := range slice {
+		if s2 == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadMatrix reads a support matrix in JSON.
+func ReadMatrix(r io.Reader) ([]*FeatureSupport, error) {
+	var matrix []*FeatureSupport
+	err := json.NewDecoder(r).Decode(&matrix)
+	return matrix, err
+}
+
+// WriteMatrix writes a support matrix in JSON.
+func WriteMatrix(w io.Writer, matrix []*FeatureSupport) error {
+	data, err := json.MarshalIndent(matrix, "", "    ")
+	if err == nil {
+		_, err = fmt.Fprintf(w, "%s\n", data)
+	}
+	return err
+}
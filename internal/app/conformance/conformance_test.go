@@ -0,0 +1,146 @@
+package conformance
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+)
+
+// The fixtures and support matrix of TestSupportMatrix. To compute the support matrix of the fixtures of the Compose Specification, run:
+// go test ./internal/app/conformance -run TestSupportMatrix -args -fixtures /path/to/fixtures -matrix /path/to/matrix.json -update
+var fixturesFlag = flag.String("fixtures", "testdata/fixtures", "the directory of the fixtures")
+var matrixFlag = flag.String("matrix", "testdata/support-matrix.json", "the support matrix of the fixtures")
+var updateFlag = flag.Bool("update", false, "write the support matrix instead of checking it for regressions")
+
+func withMockFS(vfs fs.VirtualFileSystem, cb func()) {
+	orig := fs.OS
+	defer func() {
+		fs.OS = orig
+	}()
+	fs.OS = vfs
+	cb()
+}
+
+func TestRun_Success(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/fixtures/depends_on/a.yml": {
+			Content: []byte("version: '3'\nservices:\n  web:\n    image: nginx\n    ports: [80]\n"),
+		},
+		"/fixtures/depends_on/invalid-b.yml": {
+			Content: []byte("version: '3'\nservices:\n  web:\n    image: nginx\n    depends_on: [db]\n"),
+		},
+		"/fixtures/profiles/a.yml": {
+			Content: []byte("version: '3'\nservices:\n  web:\n    image: nginx\n    profiles: [debug]\n    x-a: 1\n"),
+		},
+		"/fixtures/profiles/b.yml": {
+			Content: []byte("version: '3'\nservices:\n  web:\n    image: nginx\n"),
+		},
+		"/fixtures/empty/README.md": {
+			Content: []byte("no fixtures"),
+		},
+	}), func() {
+		matrix, err := Run("/fixtures")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		expected := []*FeatureSupport{
+			{
+				Failures: map[string]string{},
+				Feature:  "depends_on",
+				Passed:   []string{"a.yml", "invalid-b.yml"},
+				Status:   StatusSupported,
+			},
+			{
+				Failures: map[string]string{
+					"a.yml": "the keys profiles are ignored",
+				},
+				Feature: "profiles",
+				Passed:  []string{"b.yml"},
+				Status:  StatusPartial,
+			},
+		}
+		if !reflect.DeepEqual(matrix, expected) {
+			t.Error(matrix)
+		}
+	})
+}
+
+func TestRun_NotExists(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{}), func() {
+		_, err := Run("/fixtures")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestRegressions(t *testing.T) {
+	baseline := []*FeatureSupport{
+		{
+			Feature: "a",
+			Passed:  []string{"1.yml", "2.yml", "3.yml"},
+		},
+		{
+			Feature: "b",
+			Passed:  []string{"1.yml"},
+		},
+	}
+	matrix := []*FeatureSupport{
+		{
+			Failures: map[string]string{
+				"2.yml": "error",
+			},
+			Feature: "a",
+			Passed:  []string{"1.yml", "4.yml"},
+		},
+	}
+	regressions := Regressions(baseline, matrix)
+	expected := []string{
+		"feature a: fixture 2.yml failed: error",
+		"feature a: fixture 3.yml no longer exists",
+		"feature b: fixture 1.yml no longer exists",
+	}
+	if !reflect.DeepEqual(regressions, expected) {
+		t.Error(regressions)
+	}
+}
+
+func TestSupportMatrix(t *testing.T) {
+	matrix, err := Run(*fixturesFlag)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if *updateFlag {
+		var buffer bytes.Buffer
+		err = WriteMatrix(&buffer, matrix)
+		if err == nil {
+			err = ioutil.WriteFile(*matrixFlag, buffer.Bytes(), 0644)
+		}
+		if err != nil {
+			t.Error(err)
+		}
+		return
+	}
+	fd, err := os.Open(*matrixFlag)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer util.CloseAndLogError(fd)
+	baseline, err := ReadMatrix(fd)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, regression := range Regressions(baseline, matrix) {
+		t.Error(regression)
+	}
+}
@@ -0,0 +1,291 @@
+// Package debug attaches ephemeral containers to the pods of docker compose services for troubleshooting, like kubectl debug. This is
+// useful for images without a shell, such as distroless images.
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/term"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// DefaultImage is the image of ephemeral containers if Options.Image is not set.
+const DefaultImage = "busybox:1.31"
+
+// The interval at which the pod is polled when waiting for the ephemeral container to start. It is a variable to improve testability.
+var ephemeralContainerPollInterval = time.Second
+
+// The reasons of waiting containers that will not start without user intervention.
+var ephemeralContainerFailureReasons = map[string]bool{
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+}
+
+// Options are the options of Run.
+type Options struct {
+	// The command of the ephemeral container. If empty, the entrypoint of the image is run.
+	Command []string
+	// The image of the ephemeral container. Defaults to DefaultImage.
+	Image  string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// If true, a TTY is allocated for the ephemeral container and, if Stdin is a terminal, the terminal is put in raw mode while
+	// attached.
+	TTY bool
+}
+
+// ephemeralContainer is an ephemeral container of a pod. The version of the Kubernetes API that kube-compose is built with does not have
+// ephemeral containers, so only the fields that are set by kube-compose are declared.
+type ephemeralContainer struct {
+	Command             []string `json:"command,omitempty"`
+	Image               string   `json:"image"`
+	Name                string   `json:"name"`
+	Stdin               bool     `json:"stdin"`
+	TargetContainerName string   `json:"targetContainerName,omitempty"`
+	TTY                 bool     `json:"tty"`
+}
+
+// podWithEphemeralContainerStatuses is a pod with the statuses of its ephemeral containers (see ephemeralContainer).
+type podWithEphemeralContainerStatuses struct {
+	ObjectMeta metav1.ObjectMeta `json:"metadata"`
+	Status     struct {
+		EphemeralContainerStatuses []v1.ContainerStatus `json:"ephemeralContainerStatuses"`
+	} `json:"status"`
+}
+
+// attach attaches the standard streams of opts to a running container. It is a variable to improve testability.
+var attach = func(cfg *config.Config, restClient rest.Interface, namespace, podName, containerName string, opts *Options) error {
+	req := restClient.Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("attach").
+		VersionedParams(&v1.PodAttachOptions{
+			Container: containerName,
+			Stdin:     opts.Stdin != nil,
+			Stdout:    true,
+			Stderr:    !opts.TTY,
+			TTY:       opts.TTY,
+		}, scheme.ParameterCodec)
+	exec, err := remotecommand.NewSPDYExecutor(cfg.KubeConfig, "POST", req.URL())
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	if fd, isTerminal := term.GetFdInfo(opts.Stdin); opts.TTY && isTerminal {
+		state, rawErr := term.SetRawTerminal(fd)
+		if rawErr != nil {
+			return rawErr
+		}
+		defer func() {
+			_ = term.RestoreTerminal(fd, state)
+		}()
+	}
+	streamOptions := remotecommand.StreamOptions{
+		Stdin:  opts.Stdin,
+		Stdout: opts.Stdout,
+		Tty:    opts.TTY,
+	}
+	if !opts.TTY {
+		streamOptions.Stderr = opts.Stderr
+	}
+	return exec.Stream(streamOptions)
+}
+
+type debugRunner struct {
+	cfg        *config.Config
+	opts       *Options
+	restClient rest.Interface
+	service    *config.Service
+}
+
+func (d *debugRunner) initKubernetesClientset() error {
+	clients, err := k8smeta.NewClients(d.cfg.KubeConfig)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	d.restClient = clients.Clientset.CoreV1().RESTClient()
+	return nil
+}
+
+// newEphemeralContainer returns an ephemeral container with a random name that shares the process namespace of the container of the
+// docker compose service, so that the processes of the docker compose service can be inspected.
+func newEphemeralContainer(service *config.Service, opts *Options) *ephemeralContainer {
+	image := opts.Image
+	if image == "" {
+		image = DefaultImage
+	}
+	return &ephemeralContainer{
+		Command:             opts.Command,
+		Image:               image,
+		Name:                "debugger-" + rand.String(5),
+		Stdin:               opts.Stdin != nil,
+		TargetContainerName: k8smeta.GetShortName(service),
+		TTY:                 opts.TTY,
+	}
+}
+
+// getPod returns the pod of the docker compose service, which must be owned by the environment.
+func (d *debugRunner) getPod(podName string) (*podWithEphemeralContainerStatuses, error) {
+	data, err := d.restClient.Get().
+		Namespace(d.cfg.GetNamespace(d.service)).
+		Resource("pods").
+		Name(podName).
+		Do().
+		Raw()
+	if k8sError.IsNotFound(err) {
+		return nil, exitcode.Wrap(fmt.Errorf("service %s does not have a pod, run up to create it", d.service.Name()), exitcode.Config)
+	}
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	pod := &podWithEphemeralContainerStatuses{}
+	err = json.Unmarshal(data, pod)
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	err = k8smeta.ValidateOwnership(d.cfg, "Pod", &pod.ObjectMeta)
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.Config)
+	}
+	return pod, nil
+}
+
+// addEphemeralContainer adds an ephemeral container to a pod with the ephemeralcontainers subresource.
+func (d *debugRunner) addEphemeralContainer(podName string, container *ephemeralContainer) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ephemeralContainers": []*ephemeralContainer{container},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	err = d.restClient.Patch(types.StrategicMergePatchType).
+		Namespace(d.cfg.GetNamespace(d.service)).
+		Resource("pods").
+		Name(podName).
+		SubResource("ephemeralcontainers").
+		Body(data).
+		Do().
+		Error()
+	if k8sError.IsNotFound(err) {
+		return exitcode.Wrap(fmt.Errorf("the cluster does not support ephemeral containers, which requires Kubernetes 1.23 or later"),
+			exitcode.ClusterConnectivity)
+	}
+	if err != nil {
+		return exitcode.Wrap(errors.Wrapf(err, "could not add an ephemeral container to pod %s", podName), exitcode.ClusterConnectivity)
+	}
+	return nil
+}
+
+// waitForEphemeralContainer waits until an ephemeral container of a pod is running or has terminated, and returns its status.
+func (d *debugRunner) waitForEphemeralContainer(podName, containerName string) (*v1.ContainerStatus, error) {
+	for {
+		pod, err := d.getPod(podName)
+		if err != nil {
+			return nil, err
+		}
+		for i := range pod.Status.EphemeralContainerStatuses {
+			status := &pod.Status.EphemeralContainerStatuses[i]
+			if status.Name != containerName {
+				continue
+			}
+			if status.State.Running != nil || status.State.Terminated != nil {
+				return status, nil
+			}
+			if waiting := status.State.Waiting; waiting != nil && ephemeralContainerFailureReasons[waiting.Reason] {
+				return nil, exitcode.Wrap(fmt.Errorf("ephemeral container %s could not be started (%s): %s", containerName, waiting.Reason,
+					waiting.Message), exitcode.Config)
+			}
+		}
+		time.Sleep(ephemeralContainerPollInterval)
+	}
+}
+
+// printLogs copies the logs of a container of a pod to Options.Stdout.
+func (d *debugRunner) printLogs(podName, containerName string) error {
+	stream, err := d.restClient.Get().
+		Namespace(d.cfg.GetNamespace(d.service)).
+		Resource("pods").
+		Name(podName).
+		SubResource("log").
+		Param("container", containerName).
+		Stream()
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	defer util.CloseAndLogError(stream)
+	_, err = io.Copy(d.opts.Stdout, stream)
+	return err
+}
+
+// debugPod adds an ephemeral container to the pod of the docker compose service, and attaches to it once it is running.
+func (d *debugRunner) debugPod() error {
+	podName := k8smeta.GetK8sName(d.service.PodService(), d.cfg)
+	_, err := d.getPod(podName)
+	if err != nil {
+		return err
+	}
+	container := newEphemeralContainer(d.service, d.opts)
+	err = d.addEphemeralContainer(podName, container)
+	if err != nil {
+		return err
+	}
+	log.Infof("waiting for ephemeral container %s of pod %s to start", container.Name, podName)
+	status, err := d.waitForEphemeralContainer(podName, container.Name)
+	if err != nil {
+		return err
+	}
+	if terminated := status.State.Terminated; terminated != nil {
+		// The ephemeral container completed before it could be attached to, so its output is printed instead.
+		err = d.printLogs(podName, container.Name)
+		if err != nil {
+			return err
+		}
+		if terminated.ExitCode != 0 {
+			return fmt.Errorf("ephemeral container %s exited with code %d", container.Name, terminated.ExitCode)
+		}
+		return nil
+	}
+	return attach(d.cfg, d.restClient, d.cfg.GetNamespace(d.service), podName, container.Name, d.opts)
+}
+
+func (d *debugRunner) run() error {
+	err := d.initKubernetesClientset()
+	if err != nil {
+		return err
+	}
+	return d.debugPod()
+}
+
+// Run adds an ephemeral container to the pod of a docker compose service and attaches to it. The ephemeral container shares the process
+// namespace of the container of the docker compose service. Ephemeral containers cannot be removed from pods, so the ephemeral
+// container remains in the pod (terminated) until the pod is deleted.
+func Run(cfg *config.Config, service *config.Service, opts *Options) error {
+	d := &debugRunner{
+		cfg:     cfg,
+		opts:    opts,
+		service: service,
+	}
+	return d.run()
+}
@@ -0,0 +1,213 @@
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	restFake "k8s.io/client-go/rest/fake"
+)
+
+const notFoundStatus = `{"apiVersion":"v1","code":404,"kind":"Status","reason":"NotFound","status":"Failure"}`
+
+// fakeCluster is a cluster with the pod of the docker compose service web, whose ephemeral containers get the state once they are added.
+type fakeCluster struct {
+	// The patches of the ephemeralcontainers subresource.
+	patches []string
+	// If true, the ephemeralcontainers subresource does not exist.
+	noEphemeralContainers bool
+	// If true, the pod does not exist.
+	noPod bool
+	state v1.ContainerState
+}
+
+func newResponse(code int, body string) *http.Response {
+	return &http.Response{
+		Body: ioutil.NopCloser(strings.NewReader(body)),
+		Header: http.Header{
+			"Content-Type": []string{"application/json"},
+		},
+		StatusCode: code,
+	}
+}
+
+func (c *fakeCluster) getPod() (*http.Response, error) {
+	if c.noPod {
+		return newResponse(http.StatusNotFound, notFoundStatus), nil
+	}
+	pod := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{
+				"env": "test",
+			},
+			"name": "web-test",
+		},
+	}
+	if len(c.patches) > 0 {
+		var patch map[string]map[string][]*ephemeralContainer
+		_ = json.Unmarshal([]byte(c.patches[0]), &patch)
+		pod["status"] = map[string]interface{}{
+			"ephemeralContainerStatuses": []v1.ContainerStatus{
+				{
+					Name:  patch["spec"]["ephemeralContainers"][0].Name,
+					State: c.state,
+				},
+			},
+		}
+	}
+	data, err := json.Marshal(pod)
+	return newResponse(http.StatusOK, string(data)), err
+}
+
+func (c *fakeCluster) roundTrip(req *http.Request) (*http.Response, error) {
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == "/api/v1/namespaces/default/pods/web-test":
+		return c.getPod()
+	case req.Method == http.MethodGet && req.URL.Path == "/api/v1/namespaces/default/pods/web-test/log":
+		return newResponse(http.StatusOK, "hello\n"), nil
+	case req.Method == http.MethodPatch && req.URL.Path == "/api/v1/namespaces/default/pods/web-test/ephemeralcontainers":
+		if c.noEphemeralContainers {
+			return newResponse(http.StatusNotFound, notFoundStatus), nil
+		}
+		data, _ := ioutil.ReadAll(req.Body)
+		c.patches = append(c.patches, string(data))
+		return newResponse(http.StatusOK, "{}"), nil
+	}
+	return newResponse(http.StatusNotFound, notFoundStatus), nil
+}
+
+func newTestDebugRunner(c *fakeCluster, opts *Options) *debugRunner {
+	cfg := &config.Config{
+		EnvironmentID:    "test",
+		EnvironmentLabel: "env",
+		Namespace:        "default",
+	}
+	service := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "web",
+	})
+	return &debugRunner{
+		cfg:  cfg,
+		opts: opts,
+		restClient: &restFake.RESTClient{
+			Client:               restFake.CreateHTTPClient(c.roundTrip),
+			GroupVersion:         v1.SchemeGroupVersion,
+			NegotiatedSerializer: scheme.Codecs,
+			VersionedAPIPath:     "/api/v1",
+		},
+		service: service,
+	}
+}
+
+func withMockAttach(attachedContainers *[]string, cb func()) {
+	orig := attach
+	defer func() {
+		attach = orig
+	}()
+	attach = func(_ *config.Config, _ rest.Interface, _, _, containerName string, _ *Options) error {
+		*attachedContainers = append(*attachedContainers, containerName)
+		return nil
+	}
+	cb()
+}
+
+func TestNewEphemeralContainer(t *testing.T) {
+	d := newTestDebugRunner(&fakeCluster{}, &Options{})
+	container := newEphemeralContainer(d.service, d.opts)
+	if container.Image != DefaultImage || container.TargetContainerName != "web" || !strings.HasPrefix(container.Name, "debugger-") ||
+		container.Stdin || container.TTY {
+		t.Error(container)
+	}
+}
+
+func TestDebugPod_Attach(t *testing.T) {
+	c := &fakeCluster{
+		state: v1.ContainerState{
+			Running: &v1.ContainerStateRunning{},
+		},
+	}
+	d := newTestDebugRunner(c, &Options{
+		Command: []string{"sh"},
+		Image:   "alpine:3.10",
+		Stdin:   &bytes.Buffer{},
+		TTY:     true,
+	})
+	var attachedContainers []string
+	withMockAttach(&attachedContainers, func() {
+		err := d.debugPod()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	if len(c.patches) != 1 || !strings.Contains(c.patches[0], `"image":"alpine:3.10"`) ||
+		!strings.Contains(c.patches[0], `"targetContainerName":"web"`) || !strings.Contains(c.patches[0], `"stdin":true`) {
+		t.Error(c.patches)
+	}
+	if len(attachedContainers) != 1 || !strings.HasPrefix(attachedContainers[0], "debugger-") {
+		t.Error(attachedContainers)
+	}
+}
+
+func TestDebugPod_Terminated(t *testing.T) {
+	c := &fakeCluster{
+		state: v1.ContainerState{
+			Terminated: &v1.ContainerStateTerminated{},
+		},
+	}
+	var stdout bytes.Buffer
+	d := newTestDebugRunner(c, &Options{
+		Command: []string{"ls"},
+		Stdout:  &stdout,
+	})
+	var attachedContainers []string
+	withMockAttach(&attachedContainers, func() {
+		err := d.debugPod()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	if stdout.String() != "hello\n" || len(attachedContainers) != 0 {
+		t.Error(stdout.String(), attachedContainers)
+	}
+}
+
+func TestDebugPod_ImagePullFailure(t *testing.T) {
+	c := &fakeCluster{
+		state: v1.ContainerState{
+			Waiting: &v1.ContainerStateWaiting{
+				Reason: "ErrImagePull",
+			},
+		},
+	}
+	d := newTestDebugRunner(c, &Options{
+		Image: "doesnotexist",
+	})
+	err := d.debugPod()
+	if exitcode.FromError(err) != exitcode.Config {
+		t.Error(err)
+	}
+}
+
+func TestDebugPod_NoPod(t *testing.T) {
+	d := newTestDebugRunner(&fakeCluster{noPod: true}, &Options{})
+	err := d.debugPod()
+	if exitcode.FromError(err) != exitcode.Config {
+		t.Error(err)
+	}
+}
+
+func TestDebugPod_EphemeralContainersNotSupported(t *testing.T) {
+	d := newTestDebugRunner(&fakeCluster{noEphemeralContainers: true}, &Options{})
+	err := d.debugPod()
+	if exitcode.FromError(err) != exitcode.ClusterConnectivity {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,59 @@
+package debugbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+// archive is a gzipped tarball that is written through the fs abstraction.
+type archive struct {
+	fd      fs.FileDescriptor
+	gz      *gzip.Writer
+	modTime time.Time
+	tw      *tar.Writer
+}
+
+func createArchive(name string, modTime time.Time) (*archive, error) {
+	fd, err := fs.OS.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(fd)
+	return &archive{
+		fd:      fd,
+		gz:      gz,
+		modTime: modTime,
+		tw:      tar.NewWriter(gz),
+	}, nil
+}
+
+func (a *archive) addFile(name string, data []byte) error {
+	err := a.tw.WriteHeader(&tar.Header{
+		Mode:     0644,
+		ModTime:  a.modTime,
+		Name:     name,
+		Size:     int64(len(data)),
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = a.tw.Write(data)
+	return err
+}
+
+// close flushes the archive and closes the underlying file. The file is closed even if flushing fails.
+func (a *archive) close() error {
+	err := a.tw.Close()
+	if err == nil {
+		err = a.gz.Close()
+	}
+	errClose := a.fd.Close()
+	if err == nil {
+		err = errClose
+	}
+	return err
+}
@@ -0,0 +1,245 @@
+package debugbundle
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// Options is a struct of options that can be passed to Run.
+type Options struct {
+	// The file to which the gzipped tarball is written.
+	Output string
+	// The number of most recent log lines to include for each container.
+	TailLines int64
+}
+
+// effectiveConfig is the part of config.Config that is included in a debug bundle. Notably, it excludes the kube config, which may
+// contain credentials.
+type effectiveConfig struct {
+	EnvironmentID       string
+	EnvironmentLabel    string
+	Namespace           string
+	ProjectName         string
+	StateFile           string
+	ClusterImageStorage config.ClusterImageStorage
+	DefaultResources    v1.ResourceRequirements
+	VolumeInitBaseImage *string
+	Services            map[string]*dockerComposeConfig.Service
+}
+
+type debugBundleRunner struct {
+	archive      *archive
+	cfg          *config.Config
+	k8sClientset *kubernetes.Clientset
+	// Names of all resources in the bundle, used to select relevant events.
+	objectNames map[string]bool
+	opts        *Options
+}
+
+func (d *debugBundleRunner) initKubernetesClientset() error {
+	k8sClientset, err := kubernetes.NewForConfig(d.cfg.KubeConfig)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	d.k8sClientset = k8sClientset
+	return nil
+}
+
+func (d *debugBundleRunner) listOptions() metav1.ListOptions {
+	return metav1.ListOptions{
+		LabelSelector: k8smeta.GetLabelSelector(d.cfg),
+	}
+}
+
+func (d *debugBundleRunner) matchesFilter(objectMeta *metav1.ObjectMeta) bool {
+	composeService := k8smeta.FindFromObjectMeta(d.cfg, objectMeta)
+	return composeService == nil || d.cfg.MatchesFilter(composeService)
+}
+
+func marshalEffectiveConfig(cfg *config.Config) ([]byte, error) {
+	e := &effectiveConfig{
+		EnvironmentID:       cfg.EnvironmentID,
+		EnvironmentLabel:    cfg.EnvironmentLabel,
+		Namespace:           cfg.Namespace,
+		ProjectName:         cfg.ProjectName,
+		StateFile:           cfg.StateFile,
+		ClusterImageStorage: cfg.ClusterImageStorage,
+		DefaultResources:    cfg.DefaultResources,
+		VolumeInitBaseImage: cfg.VolumeInitBaseImage,
+		Services:            map[string]*dockerComposeConfig.Service{},
+	}
+	for name, service := range cfg.Services {
+		if cfg.MatchesFilter(service) {
+			e.Services[name] = service.DockerComposeService
+		}
+	}
+	return yaml.Marshal(e)
+}
+
+func (d *debugBundleRunner) addConfig() error {
+	data, err := marshalEffectiveConfig(d.cfg)
+	if err != nil {
+		return err
+	}
+	return d.archive.addFile("config.yaml", data)
+}
+
+func (d *debugBundleRunner) addObject(name string, obj interface{}) error {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return d.archive.addFile(name, data)
+}
+
+// addServices adds the Kubernetes services of the environment in each namespace of the docker compose services.
+func (d *debugBundleRunner) addServices() error {
+	for _, namespace := range d.cfg.Namespaces() {
+		serviceList, err := d.k8sClientset.CoreV1().Services(namespace).List(d.listOptions())
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		for i := 0; i < len(serviceList.Items); i++ {
+			service := &serviceList.Items[i]
+			if !d.matchesFilter(&service.ObjectMeta) {
+				continue
+			}
+			service.Kind = "Service"
+			service.APIVersion = "v1"
+			d.objectNames[service.Name] = true
+			err = d.addObject("services/"+service.Name+".yaml", service)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addPods adds the pods of the environment and their logs in each namespace of the docker compose services.
+func (d *debugBundleRunner) addPods() error {
+	for _, namespace := range d.cfg.Namespaces() {
+		podList, err := d.k8sClientset.CoreV1().Pods(namespace).List(d.listOptions())
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		for i := 0; i < len(podList.Items); i++ {
+			pod := &podList.Items[i]
+			if !d.matchesFilter(&pod.ObjectMeta) {
+				continue
+			}
+			pod.Kind = "Pod"
+			pod.APIVersion = "v1"
+			d.objectNames[pod.Name] = true
+			err = d.addObject("pods/"+pod.Name+".yaml", pod)
+			if err != nil {
+				return err
+			}
+			err = d.addPodLogs(pod)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *debugBundleRunner) addPodLogs(pod *v1.Pod) error {
+	var containers []v1.Container
+	containers = append(containers, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+	for _, container := range containers {
+		logOptions := &v1.PodLogOptions{
+			Container: container.Name,
+		}
+		if d.opts.TailLines > 0 {
+			logOptions.TailLines = &d.opts.TailLines
+		}
+		data, err := d.k8sClientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, logOptions).DoRaw()
+		if err != nil {
+			// Logs are not available if a container has not started yet, which is exactly the kind of situation a debug bundle is
+			// created for. So record the error instead of failing.
+			log.Warnf("could not get logs of container %s of pod %s: %v", container.Name, pod.Name, err)
+			data = []byte(fmt.Sprintf("error getting logs: %v\n", err))
+		}
+		err = d.archive.addFile("logs/"+pod.Name+"/"+container.Name+".log", data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *debugBundleRunner) addEvents() error {
+	var events []v1.Event
+	for _, namespace := range d.cfg.Namespaces() {
+		eventList, err := d.k8sClientset.CoreV1().Events(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		for _, event := range eventList.Items {
+			if d.objectNames[event.InvolvedObject.Name] {
+				events = append(events, event)
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].LastTimestamp.Before(&events[j].LastTimestamp)
+	})
+	return d.addObject("events.yaml", events)
+}
+
+func (d *debugBundleRunner) run() error {
+	err := d.initKubernetesClientset()
+	if err != nil {
+		return err
+	}
+	d.archive, err = createArchive(d.opts.Output, time.Now())
+	if err != nil {
+		return err
+	}
+	err = d.addAll()
+	errClose := d.archive.close()
+	if err == nil {
+		err = errClose
+	}
+	return err
+}
+
+func (d *debugBundleRunner) addAll() error {
+	err := d.addConfig()
+	if err != nil {
+		return err
+	}
+	err = d.addServices()
+	if err != nil {
+		return err
+	}
+	err = d.addPods()
+	if err != nil {
+		return err
+	}
+	return d.addEvents()
+}
+
+// Run writes a gzipped tarball to opts.Output with the effective configuration, the pods and services of the environment, events of
+// those resources and recent logs of all containers. The archive is intended to be attached to bug reports.
+func Run(cfg *config.Config, opts *Options) error {
+	d := &debugBundleRunner{
+		cfg:         cfg,
+		objectNames: map[string]bool{},
+		opts:        opts,
+	}
+	return d.run()
+}
@@ -0,0 +1,110 @@
+package debugbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+)
+
+func withMockFS(vfs fs.VirtualFileSystem, cb func()) {
+	orig := fs.OS
+	defer func() {
+		fs.OS = orig
+	}()
+	fs.OS = vfs
+	cb()
+}
+
+func Test_Archive_Success(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/tmp": {
+			Mode: os.ModeDir,
+		},
+	})
+	withMockFS(vfs, func() {
+		a, err := createArchive("/tmp/bundle.tar.gz", time.Time{})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_ = a.addFile("config.yaml", []byte("a: b\n"))
+		_ = a.addFile("logs/pod/container.log", []byte("line\n"))
+		err = a.close()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	fd, err := vfs.Open("/tmp/bundle.tar.gz")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	gz, err := gzip.NewReader(fd)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	tr := tar.NewReader(gz)
+	expected := map[string]string{
+		"config.yaml":            "a: b\n",
+		"logs/pod/container.log": "line\n",
+	}
+	for {
+		h, err := tr.Next()
+		if err != nil {
+			break
+		}
+		data, _ := ioutil.ReadAll(tr)
+		if expected[h.Name] != string(data) {
+			t.Errorf("unexpected content of %s: %#v", h.Name, string(data))
+		}
+		delete(expected, h.Name)
+	}
+	if len(expected) > 0 {
+		t.Fail()
+	}
+}
+
+func Test_CreateArchive_Error(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{})
+	withMockFS(vfs, func() {
+		_, err := createArchive("/dirdoesnotexist/bundle.tar.gz", time.Time{})
+		if !os.IsNotExist(err) {
+			t.Fail()
+		}
+	})
+}
+
+func Test_MarshalEffectiveConfig_Success(t *testing.T) {
+	cfg := &config.Config{
+		EnvironmentID: "myenv",
+		Namespace:     "mynamespace",
+	}
+	service1 := cfg.AddService(&dockerComposeConfig.Service{
+		Name:  "service1",
+		Image: "ubuntu:latest",
+	})
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name:  "service2",
+		Image: "debian:latest",
+	})
+	cfg.AddToFilter(service1)
+	data, err := marshalEffectiveConfig(cfg)
+	if err != nil {
+		t.Error(err)
+	} else {
+		s := string(data)
+		if !strings.Contains(s, "EnvironmentID: myenv") || !strings.Contains(s, "Image: ubuntu:latest") ||
+			strings.Contains(s, "debian") || strings.Contains(s, "KubeConfig") {
+			t.Error(s)
+		}
+	}
+}
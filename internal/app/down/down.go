@@ -1,95 +1,542 @@
 package down
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
 	log "github.com/Sirupsen/logrus"
 	"github.com/kube-compose/kube-compose/internal/app/config"
 	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/state"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/pkg/errors"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
+// The interval at which objects are polled when waiting for objects to be deleted. It is a variable to improve testability.
+var deletionPollInterval = time.Second
+
+// DefaultTimeout is the default of Options.Timeout.
+const DefaultTimeout = 5 * time.Minute
+
 type deleter func(name string, options *metav1.DeleteOptions) error
 
 type lister func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error)
 
+// namespacedClient returns the lister and deleter of a kind of object in a namespace.
+type namespacedClient func(namespace string) (lister, deleter)
+
+// Options are the options of RunWithOptions.
+type Options struct {
+	// If true, the PersistentVolumeClaims of named docker compose volumes are deleted, like docker compose down --volumes. This deletes
+	// the data of the volumes, unless the reclaim policy of their PersistentVolumes is Retain.
+	Volumes bool
+	// The maximum time that is waited until deleted objects are gone, or 0 to wait indefinitely. Objects are not gone immediately if they
+	// have a grace period or finalizers (e.g. PersistentVolumeClaims that are still in use).
+	Timeout time.Duration
+}
+
+// deletedObjects are the deleted objects of a kind, which are waited for until they are gone.
+type deletedObjects struct {
+	kind   string
+	lister lister
+	names  map[string]bool
+}
+
 type downRunner struct {
 	cfg              *config.Config
-	k8sClientset     *kubernetes.Clientset
-	k8sServiceClient clientV1.ServiceInterface
-	k8sPodClient     clientV1.PodInterface
+	k8sClientset     kubernetes.Interface
+	k8sDynamicClient dynamic.Interface
+	opts             *Options
+	state            *state.State
+	stateStore       state.Store
+	// The time after which waiting for objects to be deleted fails, or the zero time if there is no such time.
+	deadline time.Time
+	// The deleted objects that have not been observed to be gone.
+	deleted []*deletedObjects
+	// The namespaces in which objects are deleted (see initNamespaces).
+	namespaces []string
 }
 
 func (d *downRunner) initKubernetesClientset() error {
-	k8sClientset, err := kubernetes.NewForConfig(d.cfg.KubeConfig)
+	clients, err := k8smeta.NewClients(d.cfg.KubeConfig)
 	if err != nil {
-		return err
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	d.k8sClientset = clients.Clientset
+	d.k8sDynamicClient = clients.Dynamic
+	return nil
+}
+
+// loadState loads the state recorded by up, which describes the docker compose services of pods even if the docker compose files have
+// since changed.
+func (d *downRunner) loadState() error {
+	d.stateStore = state.NewStore(d.cfg, d.k8sClientset.CoreV1().ConfigMaps(d.cfg.Namespace))
+	var err error
+	d.state, err = d.stateStore.Load()
+	if err != nil {
+		return exitcode.Wrap(errors.Wrap(err, "error while loading the state of the environment"), exitcode.ClusterConnectivity)
+	}
+	return nil
+}
+
+// initNamespaces initializes the namespaces in which objects are deleted, which are the namespaces of the docker compose services and the
+// namespaces of the services in the state recorded by up, so that pods are also deleted from namespaces that were since removed from the
+// docker compose files.
+func (d *downRunner) initNamespaces() {
+	d.namespaces = d.cfg.Namespaces()
+	seen := map[string]bool{}
+	for _, namespace := range d.namespaces {
+		seen[namespace] = true
+	}
+	var others []string
+	for _, service := range d.state.Services {
+		if service.Namespace != "" && !seen[service.Namespace] {
+			seen[service.Namespace] = true
+			others = append(others, service.Namespace)
+		}
+	}
+	sort.Strings(others)
+	d.namespaces = append(d.namespaces, others...)
+}
+
+// saveState removes the services of the deleted pods from the state and saves the state. If deletedAll is true then everything is removed
+// from the state.
+func (d *downRunner) saveState(deletedPods []*podToDelete, deletedAll bool) error {
+	for _, pod := range deletedPods {
+		if pod.serviceName != "" {
+			delete(d.state.Services, pod.serviceName)
+		}
+	}
+	if deletedAll {
+		d.state.Objects = nil
+		d.state.Services = map[string]*state.Service{}
+	}
+	err := d.stateStore.Save(d.state)
+	if err != nil {
+		return exitcode.Wrap(errors.Wrap(err, "error while saving the state of the environment"), exitcode.ClusterConnectivity)
 	}
-	d.k8sClientset = k8sClientset
-	d.k8sServiceClient = d.k8sClientset.CoreV1().Services(d.cfg.Namespace)
-	d.k8sPodClient = d.k8sClientset.CoreV1().Pods(d.cfg.Namespace)
 	return nil
 }
 
 func (d *downRunner) deleteCommon(kind string, lister lister, deleter deleter) (bool, error) {
 	listOptions := metav1.ListOptions{
-		LabelSelector: d.cfg.EnvironmentLabel + "=" + d.cfg.EnvironmentID,
+		LabelSelector: k8smeta.GetLabelSelector(d.cfg),
 	}
 	list, err := lister(listOptions)
 	if err != nil {
-		return false, err
+		return false, exitcode.Wrap(err, exitcode.ClusterConnectivity)
 	}
 	deleteOptions := &metav1.DeleteOptions{}
 	deletedAll := true
+	deleted := &deletedObjects{
+		kind:   kind,
+		lister: lister,
+		names:  map[string]bool{},
+	}
 	for _, item := range list {
 		composeService := k8smeta.FindFromObjectMeta(d.cfg, item)
 		if composeService == nil || d.cfg.MatchesFilter(composeService) {
 			err = deleter(item.Name, deleteOptions)
+			if k8sError.IsNotFound(err) {
+				continue
+			}
 			if err != nil {
-				return false, err
+				return false, exitcode.Wrap(err, exitcode.ClusterConnectivity)
 			}
 			log.Infof("deleted %s %s\n", kind, item.Name)
+			deleted.names[item.Name] = true
 		} else {
 			deletedAll = false
 		}
 	}
+	if len(deleted.names) > 0 {
+		d.deleted = append(d.deleted, deleted)
+	}
 	return deletedAll, nil
 }
 
-// Linter reports code duplication amongst deleteServices and deletePods. Although this is true, deduplicating would require the use of
-// generics, so we choose to nolint.
-// nolint
-func (d *downRunner) deleteServices() (bool, error) {
-	lister := func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
-		serviceList, err := d.k8sServiceClient.List(listOptions)
+// deleteInNamespaces is like deleteCommon, but deletes the objects in all namespaces (see initNamespaces).
+func (d *downRunner) deleteInNamespaces(kind string, client namespacedClient) (bool, error) {
+	deletedAll := true
+	for _, namespace := range d.namespaces {
+		lister, deleter := client(namespace)
+		deletedAllInNamespace, err := d.deleteCommon(kind, lister, deleter)
+		if err != nil {
+			return false, err
+		}
+		deletedAll = deletedAll && deletedAllInNamespace
+	}
+	return deletedAll, nil
+}
+
+// checkDeadline returns an error if the time to wait for objects to be deleted has passed (see Options.Timeout).
+func (d *downRunner) checkDeadline(description string) error {
+	if !d.deadline.IsZero() && time.Now().After(d.deadline) {
+		return fmt.Errorf("timed out after %v waiting until %s are deleted", d.opts.Timeout, description)
+	}
+	return nil
+}
+
+// waitForObjectsDeleted waits until the deleted objects are gone, by listing the objects of each kind until none of the deleted objects
+// are listed.
+func (d *downRunner) waitForObjectsDeleted() error {
+	listOptions := metav1.ListOptions{
+		LabelSelector: k8smeta.GetLabelSelector(d.cfg),
+	}
+	for len(d.deleted) > 0 {
+		var remaining []*deletedObjects
+		for _, deleted := range d.deleted {
+			list, err := deleted.lister(listOptions)
+			if err != nil {
+				return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+			}
+			for _, item := range list {
+				if deleted.names[item.Name] {
+					remaining = append(remaining, deleted)
+					break
+				}
+			}
+		}
+		d.deleted = remaining
+		if len(remaining) == 0 {
+			break
+		}
+		err := d.checkDeadline(fmt.Sprintf("the %ss", remaining[0].kind))
 		if err != nil {
-			return nil, err
+			return err
+		}
+		time.Sleep(deletionPollInterval)
+	}
+	return nil
+}
+
+func (d *downRunner) deleteServices() (bool, error) {
+	return d.deleteInNamespaces("Service", func(namespace string) (lister, deleter) {
+		client := d.k8sClientset.CoreV1().Services(namespace)
+		return func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
+			serviceList, err := client.List(listOptions)
+			if err != nil {
+				return nil, err
+			}
+			list := make([]*metav1.ObjectMeta, len(serviceList.Items))
+			for i := 0; i < len(serviceList.Items); i++ {
+				list[i] = &serviceList.Items[i].ObjectMeta
+			}
+			return list, nil
+		}, client.Delete
+	})
+}
+
+// deleteNetworkPolicies deletes the NetworkPolicies that up creates if the environment is isolated with --default-deny.
+func (d *downRunner) deleteNetworkPolicies() error {
+	_, err := d.deleteInNamespaces("NetworkPolicy", func(namespace string) (lister, deleter) {
+		client := d.k8sClientset.NetworkingV1().NetworkPolicies(namespace)
+		return func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
+			networkPolicyList, err := client.List(listOptions)
+			if err != nil {
+				return nil, err
+			}
+			list := make([]*metav1.ObjectMeta, len(networkPolicyList.Items))
+			for i := 0; i < len(networkPolicyList.Items); i++ {
+				list[i] = &networkPolicyList.Items[i].ObjectMeta
+			}
+			return list, nil
+		}, client.Delete
+	})
+	return err
+}
+
+// deleteSecrets deletes the secrets that hold the resolved secret environment variables of pods.
+func (d *downRunner) deleteSecrets() error {
+	_, err := d.deleteInNamespaces("Secret", func(namespace string) (lister, deleter) {
+		client := d.k8sClientset.CoreV1().Secrets(namespace)
+		return func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
+			secretList, err := client.List(listOptions)
+			if err != nil {
+				return nil, err
+			}
+			list := make([]*metav1.ObjectMeta, len(secretList.Items))
+			for i := 0; i < len(secretList.Items); i++ {
+				list[i] = &secretList.Items[i].ObjectMeta
+			}
+			return list, nil
+		}, client.Delete
+	})
+	return err
+}
+
+// deleteObjects deletes the objects of a resource that is accessed with the dynamic client, such as the ExternalSecret objects of external
+// docker compose secrets and Ingresses. Nothing is deleted if the resource is not installed in the cluster.
+func (d *downRunner) deleteObjects(kind string, gvr schema.GroupVersionResource) error {
+	_, err := d.deleteInNamespaces(kind, func(namespace string) (lister, deleter) {
+		client := d.k8sDynamicClient.Resource(gvr).Namespace(namespace)
+		listObjects := func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
+			objList, err := client.List(listOptions)
+			if err != nil {
+				return nil, err
+			}
+			list := make([]*metav1.ObjectMeta, len(objList.Items))
+			for i := 0; i < len(objList.Items); i++ {
+				list[i] = &metav1.ObjectMeta{
+					Annotations: objList.Items[i].GetAnnotations(),
+					Name:        objList.Items[i].GetName(),
+				}
+			}
+			return list, nil
 		}
-		list := make([]*metav1.ObjectMeta, len(serviceList.Items))
-		for i := 0; i < len(serviceList.Items); i++ {
-			list[i] = &serviceList.Items[i].ObjectMeta
+		return listObjects, func(name string, options *metav1.DeleteOptions) error {
+			return client.Delete(name, options)
 		}
-		return list, nil
+	})
+	if k8sError.IsNotFound(errors.Cause(err)) {
+		return nil
 	}
-	return d.deleteCommon("Service", lister, d.k8sServiceClient.Delete)
+	return err
+}
+
+// deleteJobs deletes the Jobs that run creates, which are left behind if run is interrupted. The pods of Jobs are deleted by the garbage
+// collector.
+func (d *downRunner) deleteJobs() error {
+	_, err := d.deleteInNamespaces("Job", func(namespace string) (lister, deleter) {
+		client := d.k8sClientset.BatchV1().Jobs(namespace)
+		listJobs := func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
+			jobList, err := client.List(listOptions)
+			if err != nil {
+				return nil, err
+			}
+			list := make([]*metav1.ObjectMeta, len(jobList.Items))
+			for i := 0; i < len(jobList.Items); i++ {
+				list[i] = &jobList.Items[i].ObjectMeta
+			}
+			return list, nil
+		}
+		return listJobs, func(name string, options *metav1.DeleteOptions) error {
+			propagationPolicy := metav1.DeletePropagationBackground
+			return client.Delete(name, &metav1.DeleteOptions{
+				PropagationPolicy: &propagationPolicy,
+			})
+		}
+	})
+	return err
+}
+
+// deleteDeployments deletes the Deployments of the canaries of docker compose services (see package rollout). The pods of Deployments are
+// deleted by the garbage collector.
+func (d *downRunner) deleteDeployments() error {
+	_, err := d.deleteInNamespaces("Deployment", func(namespace string) (lister, deleter) {
+		client := d.k8sClientset.AppsV1().Deployments(namespace)
+		listDeployments := func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
+			deploymentList, err := client.List(listOptions)
+			if err != nil {
+				return nil, err
+			}
+			list := make([]*metav1.ObjectMeta, len(deploymentList.Items))
+			for i := 0; i < len(deploymentList.Items); i++ {
+				list[i] = &deploymentList.Items[i].ObjectMeta
+			}
+			return list, nil
+		}
+		return listDeployments, func(name string, options *metav1.DeleteOptions) error {
+			propagationPolicy := metav1.DeletePropagationBackground
+			return client.Delete(name, &metav1.DeleteOptions{
+				PropagationPolicy: &propagationPolicy,
+			})
+		}
+	})
+	return err
+}
+
+// deleteConfigMaps deletes the ConfigMaps of the environment, such as the ConfigMap of the state recorded by up.
+func (d *downRunner) deleteConfigMaps() error {
+	_, err := d.deleteInNamespaces("ConfigMap", func(namespace string) (lister, deleter) {
+		client := d.k8sClientset.CoreV1().ConfigMaps(namespace)
+		return func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
+			configMapList, err := client.List(listOptions)
+			if err != nil {
+				return nil, err
+			}
+			list := make([]*metav1.ObjectMeta, len(configMapList.Items))
+			for i := 0; i < len(configMapList.Items); i++ {
+				list[i] = &configMapList.Items[i].ObjectMeta
+			}
+			return list, nil
+		}, client.Delete
+	})
+	return err
 }
 
-// Linter reports code duplication amongst deleteServices and deletePods. Although this is true, deduplicating would require the use of
-// generics, so we choose to nolint.
-// nolint
-func (d *downRunner) deletePods() (bool, error) {
-	lister := func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
-		podList, err := d.k8sPodClient.List(listOptions)
+// deletePersistentVolumeClaims deletes the PersistentVolumeClaims of named docker compose volumes. The PersistentVolumeClaims of external
+// volumes are not labelled by up, so they are never deleted.
+func (d *downRunner) deletePersistentVolumeClaims() error {
+	_, err := d.deleteInNamespaces("PersistentVolumeClaim", func(namespace string) (lister, deleter) {
+		client := d.k8sClientset.CoreV1().PersistentVolumeClaims(namespace)
+		return func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
+			pvcList, err := client.List(listOptions)
+			if err != nil {
+				return nil, err
+			}
+			list := make([]*metav1.ObjectMeta, len(pvcList.Items))
+			for i := 0; i < len(pvcList.Items); i++ {
+				list[i] = &pvcList.Items[i].ObjectMeta
+			}
+			return list, nil
+		}, client.Delete
+	})
+	return err
+}
+
+type podToDelete struct {
+	name      string
+	namespace string
+	// The name of the docker compose service of the pod, or the empty string if the pod's annotations do not name one.
+	serviceName string
+	// The docker compose services the pod's service depends on, taken from the configuration or else from the state recorded by up.
+	dependsOn          []string
+	gracePeriodSeconds *int64
+}
+
+// newPodToDelete creates a podToDelete from the pod's metadata. The dependencies and grace period of services that are no longer in the
+// docker compose files are taken from the recorded state.
+func (d *downRunner) newPodToDelete(objectMeta *metav1.ObjectMeta, composeService *config.Service) *podToDelete {
+	pod := &podToDelete{
+		name:        objectMeta.Name,
+		serviceName: objectMeta.Annotations[k8smeta.AnnotationName],
+	}
+	if composeService != nil {
+		// The pod of a pod group depends on the pods of the dependencies of all services of the group (see config.PodGroup).
+		for _, podService := range composeService.PodServices() {
+			for name := range podService.DockerComposeService.DependsOn {
+				if service := d.cfg.Services[name]; service != nil {
+					name = service.PodService().Name()
+				}
+				if name != composeService.Name() {
+					pod.dependsOn = append(pod.dependsOn, name)
+				}
+			}
+		}
+		pod.gracePeriodSeconds = k8smeta.GetGracePeriodSeconds(composeService)
+	} else if stateService := d.state.Services[pod.serviceName]; stateService != nil && pod.serviceName != "" {
+		pod.dependsOn = stateService.DependsOn
+		pod.gracePeriodSeconds = stateService.GracePeriodSeconds
+	}
+	return pod
+}
+
+// getPodDeletionWave splits pods into the pods that can be deleted now (wave) and the pods that have to be deleted later (rest). A pod can
+// be deleted once the pods of all services that depend on it (based on depends_on) have been deleted, so that dependencies are not killed
+// while dependents are still shutting down.
+func getPodDeletionWave(pods []*podToDelete) (wave, rest []*podToDelete) {
+	dependedOn := map[string]bool{}
+	for _, pod := range pods {
+		for _, name := range pod.dependsOn {
+			dependedOn[name] = true
+		}
+	}
+	for _, pod := range pods {
+		if pod.serviceName != "" && dependedOn[pod.serviceName] {
+			rest = append(rest, pod)
+		} else {
+			wave = append(wave, pod)
+		}
+	}
+	if len(wave) == 0 {
+		// This is unreachable, because depends_on has no cycles, but guarantees progress.
+		return rest, nil
+	}
+	return wave, rest
+}
+
+func (d *downRunner) deletePodsWave(wave []*podToDelete) error {
+	for _, pod := range wave {
+		deleteOptions := &metav1.DeleteOptions{
+			GracePeriodSeconds: pod.gracePeriodSeconds,
+		}
+		err := d.k8sClientset.CoreV1().Pods(pod.namespace).Delete(pod.name, deleteOptions)
+		if err != nil && !k8sError.IsNotFound(err) {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		log.Infof("deleting Pod %s\n", pod.name)
+	}
+	return d.waitForPodsDeleted(wave)
+}
+
+func (d *downRunner) waitForPodsDeleted(pods []*podToDelete) error {
+	for len(pods) > 0 {
+		var remaining []*podToDelete
+		for _, pod := range pods {
+			_, err := d.k8sClientset.CoreV1().Pods(pod.namespace).Get(pod.name, metav1.GetOptions{})
+			switch {
+			case k8sError.IsNotFound(err):
+				log.Infof("deleted Pod %s\n", pod.name)
+			case err != nil:
+				return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+			default:
+				remaining = append(remaining, pod)
+			}
+		}
+		pods = remaining
+		if len(pods) > 0 {
+			err := d.checkDeadline("the Pods")
+			if err != nil {
+				return err
+			}
+			time.Sleep(deletionPollInterval)
+		}
+	}
+	return nil
+}
+
+// listPodsToDelete lists the pods to delete in all namespaces (see initNamespaces). The returned boolean is true if and only if all pods of
+// the environment are to be deleted.
+func (d *downRunner) listPodsToDelete() ([]*podToDelete, bool, error) {
+	listOptions := metav1.ListOptions{
+		LabelSelector: k8smeta.GetLabelSelector(d.cfg),
+	}
+	var pods []*podToDelete
+	deletedAll := true
+	for _, namespace := range d.namespaces {
+		podList, err := d.k8sClientset.CoreV1().Pods(namespace).List(listOptions)
 		if err != nil {
-			return nil, err
+			return nil, false, exitcode.Wrap(err, exitcode.ClusterConnectivity)
 		}
-		list := make([]*metav1.ObjectMeta, len(podList.Items))
 		for i := 0; i < len(podList.Items); i++ {
-			list[i] = &podList.Items[i].ObjectMeta
+			objectMeta := &podList.Items[i].ObjectMeta
+			composeService := k8smeta.FindFromObjectMeta(d.cfg, objectMeta)
+			if composeService == nil || d.cfg.MatchesFilter(composeService) {
+				pod := d.newPodToDelete(objectMeta, composeService)
+				pod.namespace = namespace
+				pods = append(pods, pod)
+			} else {
+				deletedAll = false
+			}
 		}
-		return list, nil
 	}
-	return d.deleteCommon("Pod", lister, d.k8sPodClient.Delete)
+	return pods, deletedAll, nil
+}
+
+// deletePods deletes pods in reverse dependency order: the pods of a wave are deleted one after another, and are waited for before the
+// next wave is deleted.
+func (d *downRunner) deletePods() ([]*podToDelete, bool, error) {
+	pods, deletedAll, err := d.listPodsToDelete()
+	if err != nil {
+		return nil, false, err
+	}
+	deletedPods := pods
+	for len(pods) > 0 {
+		var wave []*podToDelete
+		wave, pods = getPodDeletionWave(pods)
+		err = d.deletePodsWave(wave)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	return deletedPods, deletedAll, nil
 }
 
 func (d *downRunner) run() error {
@@ -98,7 +545,47 @@ func (d *downRunner) run() error {
 		return err
 	}
 
-	deletedAllPods, err := d.deletePods()
+	err = d.loadState()
+	if err != nil {
+		return err
+	}
+	d.initNamespaces()
+	if d.opts.Timeout > 0 {
+		d.deadline = time.Now().Add(d.opts.Timeout)
+	}
+
+	err = d.deleteJobs()
+	if err != nil {
+		return err
+	}
+	err = d.deleteDeployments()
+	if err != nil {
+		return err
+	}
+	deletedPods, deletedAllPods, err := d.deletePods()
+	if err != nil {
+		return err
+	}
+
+	// Certificates are deleted before Secrets, so that cert-manager does not recreate the Secrets of Certificates.
+	err = d.deleteObjects("Ingress", k8smeta.IngressesGVR)
+	if err != nil {
+		return err
+	}
+	err = d.deleteObjects("Certificate", k8smeta.CertificatesGVR)
+	if err != nil {
+		return err
+	}
+	err = d.deleteObjects("HTTPRoute", k8smeta.HTTPRoutesGVR)
+	if err != nil {
+		return err
+	}
+	err = d.deleteObjects("TCPRoute", k8smeta.TCPRoutesGVR)
+	if err != nil {
+		return err
+	}
+
+	err = d.deleteSecrets()
 	if err != nil {
 		return err
 	}
@@ -106,18 +593,60 @@ func (d *downRunner) run() error {
 	// Only delete services if all pods are to be deleted. This is so that existing pods will not have
 	// their host aliases invalidated.
 	if deletedAllPods {
-		_, err = d.deleteServices()
+		err = d.deleteSharedObjects()
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+	err = d.waitForObjectsDeleted()
+	if err != nil {
+		return err
+	}
+	return d.saveState(deletedPods, deletedAllPods)
+}
+
+// deleteSharedObjects deletes the objects that can be shared by the pods of services, once all pods have been deleted.
+func (d *downRunner) deleteSharedObjects() error {
+	_, err := d.deleteServices()
+	if err != nil {
+		return err
+	}
+	// NetworkPolicies are deleted under the same condition as services, so that the remaining pods stay isolated.
+	err = d.deleteNetworkPolicies()
+	if err != nil {
+		return err
+	}
+	// External docker compose secrets can be shared by services, so they are deleted under the same condition as services.
+	err = d.deleteObjects("ExternalSecret", k8smeta.ExternalSecretsGVR)
+	if err != nil {
+		return err
+	}
+	err = d.deleteObjects("SecretProviderClass", k8smeta.SecretProviderClassesGVR)
+	if err != nil {
+		return err
+	}
+	// Named volumes can also be shared by services, and are only deleted on request, because deleting them deletes their data.
+	if d.opts.Volumes {
+		err = d.deletePersistentVolumeClaims()
+		if err != nil {
+			return err
+		}
+	}
+	return d.deleteConfigMaps()
 }
 
 // Run runs a docker-compose down command...
 func Run(cfg *config.Config) error {
+	return RunWithOptions(cfg, &Options{
+		Timeout: DefaultTimeout,
+	})
+}
+
+// RunWithOptions is like Run, but with additional options.
+func RunWithOptions(cfg *config.Config, opts *Options) error {
 	d := &downRunner{
-		cfg: cfg,
+		cfg:  cfg,
+		opts: opts,
 	}
 	return d.run()
 }
@@ -0,0 +1,113 @@
+package down
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/state"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestPodsToDelete() []*podToDelete {
+	return []*podToDelete{
+		{
+			name:        "c-env",
+			serviceName: "c",
+		},
+		{
+			name:        "b-env",
+			serviceName: "b",
+			dependsOn:   []string{"c"},
+		},
+		{
+			name:        "a-env",
+			serviceName: "a",
+			dependsOn:   []string{"b"},
+		},
+		{
+			name: "unknown-env",
+		},
+	}
+}
+
+func podNames(pods []*podToDelete) []string {
+	var names []string
+	for _, pod := range pods {
+		names = append(names, pod.name)
+	}
+	return names
+}
+
+func TestGetPodDeletionWave_ReverseDependencyOrder(t *testing.T) {
+	pods := newTestPodsToDelete()
+	var waves [][]string
+	for len(pods) > 0 {
+		var wave []*podToDelete
+		wave, pods = getPodDeletionWave(pods)
+		waves = append(waves, podNames(wave))
+	}
+	if len(waves) != 3 || len(waves[0]) != 2 || waves[0][0] != "a-env" || waves[0][1] != "unknown-env" ||
+		len(waves[1]) != 1 || waves[1][0] != "b-env" || len(waves[2]) != 1 || waves[2][0] != "c-env" {
+		t.Error(waves)
+	}
+}
+
+func TestGetPodDeletionWave_DependencyNotBeingDeleted(t *testing.T) {
+	pods := newTestPodsToDelete()
+	// Only delete the pod of b, its dependency c is not being deleted.
+	wave, rest := getPodDeletionWave(pods[1:2])
+	if len(wave) != 1 || len(rest) != 0 {
+		t.Fail()
+	}
+}
+
+func TestNewPodToDelete_FromConfig(t *testing.T) {
+	cfg := &config.Config{}
+	serviceA := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	serviceA.DockerComposeService.DependsOn = map[string]dockerComposeConfig.ServiceHealthiness{
+		"b": dockerComposeConfig.ServiceHealthy,
+	}
+	d := &downRunner{
+		cfg:   cfg,
+		state: &state.State{},
+	}
+	pod := d.newPodToDelete(&metav1.ObjectMeta{
+		Annotations: map[string]string{
+			k8smeta.AnnotationName: "a",
+		},
+		Name: "a-env",
+	}, serviceA)
+	if pod.name != "a-env" || pod.serviceName != "a" || !reflect.DeepEqual(pod.dependsOn, []string{"b"}) {
+		t.Error(pod)
+	}
+}
+
+func TestNewPodToDelete_FromState(t *testing.T) {
+	gracePeriodSeconds := int64(30)
+	d := &downRunner{
+		cfg: &config.Config{},
+		state: &state.State{
+			Services: map[string]*state.Service{
+				"removed": {
+					DependsOn:          []string{"db"},
+					GracePeriodSeconds: &gracePeriodSeconds,
+					PodName:            "removed-env",
+				},
+			},
+		},
+	}
+	pod := d.newPodToDelete(&metav1.ObjectMeta{
+		Annotations: map[string]string{
+			k8smeta.AnnotationName: "removed",
+		},
+		Name: "removed-env",
+	}, nil)
+	if !reflect.DeepEqual(pod.dependsOn, []string{"db"}) || pod.gracePeriodSeconds == nil || *pod.gracePeriodSeconds != 30 {
+		t.Error(pod)
+	}
+}
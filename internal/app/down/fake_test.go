@@ -0,0 +1,299 @@
+package down
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	appsV1 "k8s.io/api/apps/v1"
+	batchV1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+// withFakeClientset runs cb with down connecting to clientset instead of a cluster.
+func withFakeClientset(clientset *fake.Clientset, cb func()) {
+	orig := k8smeta.NewClients
+	defer func() {
+		k8smeta.NewClients = orig
+	}()
+	k8smeta.NewClients = func(_ *rest.Config) (*k8smeta.Clients, error) {
+		return &k8smeta.Clients{
+			Clientset: clientset,
+			Dynamic:   dynamicFake.NewSimpleDynamicClient(runtime.NewScheme()),
+		}, nil
+	}
+	cb()
+}
+
+func newFakeClientsetTestConfig() *config.Config {
+	cfg := &config.Config{
+		EnvironmentID:    "test",
+		EnvironmentLabel: "env",
+		Namespace:        "default",
+		ProjectName:      "project",
+	}
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name: "db",
+	})
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name: "web",
+	})
+	return cfg
+}
+
+// newFakeClientsetTestObjects returns the pods and Kubernetes services of cfg, as up would have created them.
+func newFakeClientsetTestObjects(cfg *config.Config) []runtime.Object {
+	var objects []runtime.Object
+	for _, composeService := range []*config.Service{cfg.Services["db"], cfg.Services["web"]} {
+		pod := &v1.Pod{}
+		k8smeta.InitObjectMeta(cfg, &pod.ObjectMeta, composeService)
+		pod.Namespace = cfg.GetNamespace(composeService)
+		service := &v1.Service{}
+		k8smeta.InitObjectMeta(cfg, &service.ObjectMeta, composeService)
+		service.Namespace = cfg.GetNamespace(composeService)
+		objects = append(objects, pod, service)
+	}
+	// A pod of another environment, which must not be deleted.
+	objects = append(objects, &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"env": "other",
+			},
+			Name:      "project-db-other",
+			Namespace: cfg.Namespace,
+		},
+	})
+	return objects
+}
+
+func TestRun_FakeClientsetSuccess(t *testing.T) {
+	cfg := newFakeClientsetTestConfig()
+	cfg.AddToFilter(cfg.Services["db"])
+	cfg.AddToFilter(cfg.Services["web"])
+	clientset := fake.NewSimpleClientset(newFakeClientsetTestObjects(cfg)...)
+	withFakeClientset(clientset, func() {
+		err := Run(cfg)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		podList, err := clientset.CoreV1().Pods("default").List(metav1.ListOptions{})
+		if err != nil || len(podList.Items) != 1 || podList.Items[0].Name != "project-db-other" {
+			t.Error(podList, err)
+		}
+		serviceList, err := clientset.CoreV1().Services("default").List(metav1.ListOptions{})
+		if err != nil || len(serviceList.Items) != 0 {
+			t.Error(serviceList, err)
+		}
+	})
+}
+
+func TestRun_FakeClientsetFilterKeepsServices(t *testing.T) {
+	cfg := newFakeClientsetTestConfig()
+	cfg.AddToFilter(cfg.Services["web"])
+	clientset := fake.NewSimpleClientset(newFakeClientsetTestObjects(cfg)...)
+	withFakeClientset(clientset, func() {
+		err := Run(cfg)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_, err = clientset.CoreV1().Pods("default").Get("project-web-test", metav1.GetOptions{})
+		if !k8sError.IsNotFound(err) {
+			t.Error(err)
+		}
+		_, err = clientset.CoreV1().Pods("default").Get("project-db-test", metav1.GetOptions{})
+		if err != nil {
+			t.Error(err)
+		}
+		serviceList, err := clientset.CoreV1().Services("default").List(metav1.ListOptions{})
+		if err != nil || len(serviceList.Items) != 2 {
+			t.Error(serviceList, err)
+		}
+	})
+}
+
+func TestRun_FakeClientsetDeletePodError(t *testing.T) {
+	cfg := newFakeClientsetTestConfig()
+	cfg.AddToFilter(cfg.Services["db"])
+	clientset := fake.NewSimpleClientset(newFakeClientsetTestObjects(cfg)...)
+	clientset.PrependReactor("delete", "pods", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8sError.NewInternalError(fmt.Errorf("etcdserver: request timed out"))
+	})
+	withFakeClientset(clientset, func() {
+		err := Run(cfg)
+		if exitcode.FromError(err) != exitcode.ClusterConnectivity {
+			t.Error(err)
+		}
+	})
+}
+
+// newFakeClientsetTestPersistentVolumeClaims returns the PersistentVolumeClaim of a named volume, as up would have created it, and the
+// PersistentVolumeClaim of an external volume.
+func newFakeClientsetTestPersistentVolumeClaims(cfg *config.Config) []runtime.Object {
+	return []runtime.Object{
+		&v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:    k8smeta.InitEnvironmentLabels(cfg, nil),
+				Name:      "project-data-test",
+				Namespace: cfg.Namespace,
+			},
+		},
+		&v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "external-data",
+				Namespace: cfg.Namespace,
+			},
+		},
+	}
+}
+
+func TestRun_FakeClientsetKeepsPersistentVolumeClaims(t *testing.T) {
+	cfg := newFakeClientsetTestConfig()
+	cfg.AddToFilter(cfg.Services["db"])
+	cfg.AddToFilter(cfg.Services["web"])
+	clientset := fake.NewSimpleClientset(append(newFakeClientsetTestObjects(cfg), newFakeClientsetTestPersistentVolumeClaims(cfg)...)...)
+	withFakeClientset(clientset, func() {
+		err := Run(cfg)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		pvcList, err := clientset.CoreV1().PersistentVolumeClaims("default").List(metav1.ListOptions{})
+		if err != nil || len(pvcList.Items) != 2 {
+			t.Error(pvcList, err)
+		}
+	})
+}
+
+func TestRunWithOptions_FakeClientsetDeletesPersistentVolumeClaims(t *testing.T) {
+	cfg := newFakeClientsetTestConfig()
+	cfg.AddToFilter(cfg.Services["db"])
+	cfg.AddToFilter(cfg.Services["web"])
+	clientset := fake.NewSimpleClientset(append(newFakeClientsetTestObjects(cfg), newFakeClientsetTestPersistentVolumeClaims(cfg)...)...)
+	withFakeClientset(clientset, func() {
+		err := RunWithOptions(cfg, &Options{
+			Volumes: true,
+		})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		pvcList, err := clientset.CoreV1().PersistentVolumeClaims("default").List(metav1.ListOptions{})
+		if err != nil || len(pvcList.Items) != 1 || pvcList.Items[0].Name != "external-data" {
+			t.Error(pvcList, err)
+		}
+	})
+}
+
+func TestRun_FakeClientsetDeletesJobsAndConfigMaps(t *testing.T) {
+	cfg := newFakeClientsetTestConfig()
+	cfg.AddToFilter(cfg.Services["db"])
+	cfg.AddToFilter(cfg.Services["web"])
+	job := &batchV1.Job{}
+	k8smeta.InitObjectMeta(cfg, &job.ObjectMeta, cfg.Services["web"])
+	job.Namespace = cfg.Namespace
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:    k8smeta.InitEnvironmentLabels(cfg, nil),
+			Name:      "project-kube-compose-state-test",
+			Namespace: cfg.Namespace,
+		},
+	}
+	clientset := fake.NewSimpleClientset(append(newFakeClientsetTestObjects(cfg), job, configMap)...)
+	withFakeClientset(clientset, func() {
+		err := Run(cfg)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		jobList, err := clientset.BatchV1().Jobs("default").List(metav1.ListOptions{})
+		if err != nil || len(jobList.Items) != 0 {
+			t.Error(jobList, err)
+		}
+		configMapList, err := clientset.CoreV1().ConfigMaps("default").List(metav1.ListOptions{})
+		if err != nil || len(configMapList.Items) != 0 {
+			t.Error(configMapList, err)
+		}
+	})
+}
+
+func TestRun_FakeClientsetDeletesDeployments(t *testing.T) {
+	cfg := newFakeClientsetTestConfig()
+	cfg.AddToFilter(cfg.Services["web"])
+	deployment := &appsV1.Deployment{}
+	k8smeta.InitObjectMeta(cfg, &deployment.ObjectMeta, cfg.Services["web"])
+	deployment.Name = "web-canary-test"
+	deployment.Namespace = cfg.Namespace
+	clientset := fake.NewSimpleClientset(append(newFakeClientsetTestObjects(cfg), deployment)...)
+	withFakeClientset(clientset, func() {
+		err := Run(cfg)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		deploymentList, err := clientset.AppsV1().Deployments("default").List(metav1.ListOptions{})
+		if err != nil || len(deploymentList.Items) != 0 {
+			t.Error(deploymentList, err)
+		}
+	})
+}
+
+func TestRun_FakeClientsetOtherNamespace(t *testing.T) {
+	cfg := newFakeClientsetTestConfig()
+	cfg.Services["db"].Namespace = "infra"
+	cfg.AddToFilter(cfg.Services["db"])
+	cfg.AddToFilter(cfg.Services["web"])
+	clientset := fake.NewSimpleClientset(newFakeClientsetTestObjects(cfg)...)
+	withFakeClientset(clientset, func() {
+		err := Run(cfg)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		podList, err := clientset.CoreV1().Pods("infra").List(metav1.ListOptions{})
+		if err != nil || len(podList.Items) != 0 {
+			t.Error(podList, err)
+		}
+		serviceList, err := clientset.CoreV1().Services("infra").List(metav1.ListOptions{})
+		if err != nil || len(serviceList.Items) != 0 {
+			t.Error(serviceList, err)
+		}
+	})
+}
+
+func TestRunWithOptions_FakeClientsetTimeout(t *testing.T) {
+	orig := deletionPollInterval
+	defer func() {
+		deletionPollInterval = orig
+	}()
+	deletionPollInterval = time.Millisecond
+	cfg := newFakeClientsetTestConfig()
+	cfg.AddToFilter(cfg.Services["db"])
+	cfg.AddToFilter(cfg.Services["web"])
+	clientset := fake.NewSimpleClientset(append(newFakeClientsetTestObjects(cfg), newFakeClientsetTestPersistentVolumeClaims(cfg)...)...)
+	// The PersistentVolumeClaim is protected by a finalizer, so it is not gone after it has been deleted.
+	clientset.PrependReactor("delete", "persistentvolumeclaims", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		return true, nil, nil
+	})
+	withFakeClientset(clientset, func() {
+		err := RunWithOptions(cfg, &Options{
+			Timeout: 10 * time.Millisecond,
+			Volumes: true,
+		})
+		if err == nil || err.Error() != "timed out after 10ms waiting until the PersistentVolumeClaims are deleted" {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package ephemeral
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ProjectNameEnvVarName is the name of the environment variable that overrides the project name, like in docker compose.
+const ProjectNameEnvVarName = "COMPOSE_PROJECT_NAME"
+
+// lookupEnv is a function used to get environment variables. It is a variable to improve testability.
+var lookupEnv = os.LookupEnv
+
+// runGit runs git with the specified arguments in the current working directory, and returns its output without surrounding
+// whitespace. It is a variable to improve testability.
+var runGit = func(args ...string) (string, error) {
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// LookupProjectName returns the value of the environment variable COMPOSE_PROJECT_NAME, or the empty string if it is not set.
+func LookupProjectName() string {
+	projectName, _ := lookupEnv(ProjectNameEnvVarName)
+	return projectName
+}
+
+// GetProjectName returns the name of the project, which is the value of the environment variable COMPOSE_PROJECT_NAME if it is set,
+// and otherwise the name of the directory of the first docker compose file (or the current working directory if files is empty).
+func GetProjectName(files []string) (string, error) {
+	if projectName := LookupProjectName(); projectName != "" {
+		return projectName, nil
+	}
+	var dir string
+	var err error
+	if len(files) > 0 {
+		dir, err = fs.OS.Abs(files[0])
+		dir = filepath.Dir(dir)
+	} else {
+		dir, err = fs.OS.Getwd()
+	}
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(dir), nil
+}
+
+// GetGitRef returns the current git branch, or the short SHA of the current commit if HEAD is detached (as is common in CI).
+func GetGitRef() (string, error) {
+	branch, err := runGit("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", errors.Wrap(err, "could not determine the current git branch")
+	}
+	if branch != "HEAD" {
+		return branch, nil
+	}
+	sha, err := runGit("rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", errors.Wrap(err, "could not determine the current git commit")
+	}
+	return sha, nil
+}
+
+// GetNamespace returns the name of the namespace of the ephemeral environment of a project and git ref. Characters that are not allowed
+// in namespace names are replaced by dashes, and names that are too long are truncated and suffixed with a hash to avoid collisions.
+func GetNamespace(projectName, gitRef string) string {
+	namespace := util.TruncateName(util.NormalizeName(projectName+"-"+gitRef), validation.DNS1123LabelMaxLength)
+	if namespace == "" {
+		namespace = "kube-compose"
+	}
+	return namespace
+}
+
+// EnsureNamespace creates the namespace of an ephemeral environment if it does not exist, and sets its expiry to ttl from now. It is an
+// error if the namespace exists but is not the namespace of an ephemeral environment, so that existing namespaces are never garbage
+// collected.
+func EnsureNamespace(cfg *config.Config, ttl time.Duration) error {
+	k8sClientset, err := kubernetes.NewForConfig(cfg.KubeConfig)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	namespaceClient := k8sClientset.CoreV1().Namespaces()
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	namespace, err := namespaceClient.Get(cfg.Namespace, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		_, err = namespaceClient.Create(&v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: cfg.Namespace,
+				Labels: map[string]string{
+					k8smeta.EphemeralLabelName: "true",
+					k8smeta.ExpiresLabelName:   expires,
+				},
+			},
+		})
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		log.Infof("created ephemeral namespace %s", cfg.Namespace)
+		return nil
+	} else if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	if namespace.ObjectMeta.Labels[k8smeta.EphemeralLabelName] != "true" {
+		return exitcode.Wrap(fmt.Errorf("namespace %s already exists, but is not the namespace of an ephemeral environment",
+			cfg.Namespace), exitcode.Config)
+	}
+	namespace.ObjectMeta.Labels[k8smeta.ExpiresLabelName] = expires
+	_, err = namespaceClient.Update(namespace)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package ephemeral
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func withMockGit(outputs map[string]string, cb func()) {
+	orig := runGit
+	defer func() {
+		runGit = orig
+	}()
+	runGit = func(args ...string) (string, error) {
+		output, ok := outputs[strings.Join(args, " ")]
+		if !ok {
+			return "", fmt.Errorf("not a git repository")
+		}
+		return output, nil
+	}
+	cb()
+}
+
+func withMockEnv(env map[string]string, cb func()) {
+	orig := lookupEnv
+	defer func() {
+		lookupEnv = orig
+	}()
+	lookupEnv = func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	cb()
+}
+
+func TestGetGitRef_Branch(t *testing.T) {
+	withMockGit(map[string]string{
+		"rev-parse --abbrev-ref HEAD": "feature/login",
+	}, func() {
+		gitRef, err := GetGitRef()
+		if err != nil || gitRef != "feature/login" {
+			t.Fail()
+		}
+	})
+}
+
+func TestGetGitRef_DetachedHead(t *testing.T) {
+	withMockGit(map[string]string{
+		"rev-parse --abbrev-ref HEAD": "HEAD",
+		"rev-parse --short HEAD":      "abc1234",
+	}, func() {
+		gitRef, err := GetGitRef()
+		if err != nil || gitRef != "abc1234" {
+			t.Fail()
+		}
+	})
+}
+
+func TestGetGitRef_Error(t *testing.T) {
+	withMockGit(map[string]string{}, func() {
+		_, err := GetGitRef()
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestGetProjectName_EnvVar(t *testing.T) {
+	withMockEnv(map[string]string{
+		ProjectNameEnvVarName: "myproject",
+	}, func() {
+		projectName, err := GetProjectName([]string{"/a/b/docker-compose.yml"})
+		if err != nil || projectName != "myproject" {
+			t.Fail()
+		}
+	})
+}
+
+func TestGetProjectName_File(t *testing.T) {
+	withMockEnv(map[string]string{}, func() {
+		projectName, err := GetProjectName([]string{"/a/b/docker-compose.yml"})
+		if err != nil || projectName != "b" {
+			t.Fail()
+		}
+	})
+}
+
+func TestLookupProjectName_NotSet(t *testing.T) {
+	withMockEnv(map[string]string{}, func() {
+		if projectName := LookupProjectName(); projectName != "" {
+			t.Error(projectName)
+		}
+	})
+}
+
+func TestGetProjectName_WorkingDir(t *testing.T) {
+	orig := fs.OS
+	defer func() {
+		fs.OS = orig
+	}()
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/a/c/docker-compose.yml": {},
+	})
+	_ = vfs.Chdir("/a/c")
+	fs.OS = vfs
+	withMockEnv(map[string]string{}, func() {
+		projectName, err := GetProjectName(nil)
+		if err != nil || projectName != "c" {
+			t.Fail()
+		}
+	})
+}
+
+func TestGetNamespace_Sanitized(t *testing.T) {
+	namespace := GetNamespace("My_Project", "feature/Login--Page")
+	if namespace != "my-project-feature-login-page" {
+		t.Error(namespace)
+	}
+}
+
+func TestGetNamespace_Truncated(t *testing.T) {
+	branch := strings.Repeat("a", 100)
+	namespace1 := GetNamespace("project", branch)
+	namespace2 := GetNamespace("project", branch+"b")
+	if len(namespace1) > validation.DNS1123LabelMaxLength || namespace1 == namespace2 {
+		t.Error(namespace1, namespace2)
+	}
+	if e := validation.IsDNS1123Label(namespace1); len(e) > 0 {
+		t.Error(e)
+	}
+}
+
+func TestGetNamespace_Empty(t *testing.T) {
+	namespace := GetNamespace("", "")
+	if namespace != "kube-compose" {
+		t.Error(namespace)
+	}
+}
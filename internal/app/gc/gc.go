@@ -0,0 +1,69 @@
+package gc
+
+import (
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Options is a struct of options for Run.
+type Options struct {
+	// True to only log the namespaces that would be deleted.
+	DryRun bool
+}
+
+// isExpired determines whether the namespace of an ephemeral environment has expired at time now. The second return value is false if
+// the namespace does not have a valid expiry.
+func isExpired(namespace *v1.Namespace, now time.Time) (expired, ok bool) {
+	expires, err := strconv.ParseInt(namespace.ObjectMeta.Labels[k8smeta.ExpiresLabelName], 10, 64)
+	if err != nil {
+		return false, false
+	}
+	return now.Unix() >= expires, true
+}
+
+// Run deletes the namespaces of ephemeral environments that have expired.
+func Run(cfg *config.Config, opts *Options) error {
+	k8sClientset, err := kubernetes.NewForConfig(cfg.KubeConfig)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	namespaceClient := k8sClientset.CoreV1().Namespaces()
+	namespaceList, err := namespaceClient.List(metav1.ListOptions{
+		LabelSelector: k8smeta.EphemeralLabelName + "=true",
+	})
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	now := time.Now()
+	for i := 0; i < len(namespaceList.Items); i++ {
+		namespace := &namespaceList.Items[i]
+		expired, ok := isExpired(namespace, now)
+		if !ok {
+			log.Warnf("ignoring namespace %s because it has an invalid value for label %s", namespace.ObjectMeta.Name,
+				k8smeta.ExpiresLabelName)
+			continue
+		}
+		if !expired {
+			continue
+		}
+		if opts.DryRun {
+			log.Infof("would delete expired namespace %s", namespace.ObjectMeta.Name)
+			continue
+		}
+		err = namespaceClient.Delete(namespace.ObjectMeta.Name, &metav1.DeleteOptions{})
+		if err != nil && !k8sError.IsNotFound(err) {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		log.Infof("deleted expired namespace %s", namespace.ObjectMeta.Name)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package gc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestNamespace(expires string) *v1.Namespace {
+	return &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				k8smeta.EphemeralLabelName: "true",
+				k8smeta.ExpiresLabelName:   expires,
+			},
+		},
+	}
+}
+
+func TestIsExpired_Expired(t *testing.T) {
+	expired, ok := isExpired(newTestNamespace("1000"), time.Unix(1000, 0))
+	if !expired || !ok {
+		t.Fail()
+	}
+}
+
+func TestIsExpired_NotExpired(t *testing.T) {
+	expired, ok := isExpired(newTestNamespace("1001"), time.Unix(1000, 0))
+	if expired || !ok {
+		t.Fail()
+	}
+}
+
+func TestIsExpired_Invalid(t *testing.T) {
+	_, ok := isExpired(newTestNamespace("tomorrow"), time.Unix(1000, 0))
+	if ok {
+		t.Fail()
+	}
+}
@@ -3,6 +3,8 @@ package details
 import (
 	"github.com/kube-compose/kube-compose/internal/app/config"
 	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -16,9 +18,9 @@ type getRunner struct {
 }
 
 type ServiceDetails struct {
-	Name      string
-	ClusterIP string
-	Hostname  string
+	Name      string `json:"name"`
+	ClusterIP string `json:"clusterIP"`
+	Hostname  string `json:"hostname"`
 }
 
 func GetServiceDetails(cfg *config.Config, service *config.Service) (*ServiceDetails, error) {
@@ -32,10 +34,10 @@ func GetServiceDetails(cfg *config.Config, service *config.Service) (*ServiceDet
 func (g *getRunner) initKubernetesClientset() error {
 	k8sClientset, err := kubernetes.NewForConfig(g.cfg.KubeConfig)
 	if err != nil {
-		return err
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
 	}
 	g.k8sClientset = k8sClientset
-	g.k8sServiceClient = g.k8sClientset.CoreV1().Services(g.cfg.Namespace)
+	g.k8sServiceClient = g.k8sClientset.CoreV1().Services(g.cfg.GetNamespace(g.service))
 	return nil
 }
 
@@ -47,6 +49,9 @@ func (g *getRunner) run() (*ServiceDetails, error) {
 	k8sName := k8smeta.GetK8sName(g.service, g.cfg)
 	result, err := g.k8sServiceClient.Get(k8sName, metav1.GetOptions{})
 	if err != nil {
+		if !k8sError.IsNotFound(err) {
+			err = exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
 		return nil, err
 	}
 	details := &ServiceDetails{
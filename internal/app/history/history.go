@@ -0,0 +1,90 @@
+// Package history lists the revisions of an environment (see package revision), which up and rollback record.
+package history
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/revision"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/pkg/errors"
+)
+
+// The number of characters of hashes and image IDs that are shown, like the short IDs of docker.
+const shortLength = 12
+
+// shorten returns the first characters of a hash or image ID (without the algorithm of the digest), or "-" if s is empty.
+func shorten(s string) string {
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		s = s[i+1:]
+	}
+	switch {
+	case s == "":
+		return "-"
+	case len(s) > shortLength:
+		return s[:shortLength]
+	}
+	return s
+}
+
+// getPodServiceNames returns the names of the docker compose services whose pods are listed, which are the pod services (see
+// config.Service.PodService) of the docker compose services in the filter.
+func getPodServiceNames(cfg *config.Config) map[string]bool {
+	names := map[string]bool{}
+	for _, service := range cfg.Services {
+		if cfg.MatchesFilter(service) {
+			names[service.PodService().Name()] = true
+		}
+	}
+	return names
+}
+
+// writeRevision writes the rows of a revision, one for each of its docker compose services in names.
+func writeRevision(w io.Writer, r *revision.Revision, names map[string]bool) {
+	var serviceNames []string
+	for name := range r.Services {
+		if names[name] {
+			serviceNames = append(serviceNames, name)
+		}
+	}
+	sort.Strings(serviceNames)
+	number := fmt.Sprintf("%d", r.Number)
+	if r.RollbackOf > 0 {
+		number = fmt.Sprintf("%d (rollback to %d)", r.Number, r.RollbackOf)
+	}
+	deployedBy := r.DeployedBy
+	if deployedBy == "" {
+		deployedBy = "-"
+	}
+	for _, name := range serviceNames {
+		service := r.Services[name]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", number, r.Time.Format(time.RFC3339), deployedBy, name, service.Image,
+			shorten(service.ImageID), shorten(service.Hash))
+	}
+}
+
+// Run writes a table of the revisions of the environment to w, from oldest to latest, with a row for each docker compose service in the
+// filter of cfg (and the docker compose services of the pod groups of their sidecars) that has a pod in the revision.
+func Run(cfg *config.Config, w io.Writer) error {
+	clients, err := k8smeta.NewClients(cfg.KubeConfig)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	revisions, err := revision.NewStore(cfg, clients.Clientset.CoreV1().ConfigMaps(cfg.Namespace)).List()
+	if err != nil {
+		return exitcode.Wrap(errors.Wrap(err, "error while listing the revisions of the environment"), exitcode.ClusterConnectivity)
+	}
+	names := getPodServiceNames(cfg)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "REVISION\tTIME\tDEPLOYED BY\tSERVICE\tIMAGE\tIMAGE ID\tCONFIG HASH")
+	for _, r := range revisions {
+		writeRevision(tw, r, names)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,115 @@
+package history
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/revision"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+// withFakeClientset runs cb with history connecting to clientset instead of a cluster.
+func withFakeClientset(clientset *fake.Clientset, cb func()) {
+	orig := k8smeta.NewClients
+	defer func() {
+		k8smeta.NewClients = orig
+	}()
+	k8smeta.NewClients = func(_ *rest.Config) (*k8smeta.Clients, error) {
+		return &k8smeta.Clients{
+			Clientset: clientset,
+		}, nil
+	}
+	cb()
+}
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{
+		EnvironmentID:    "test",
+		EnvironmentLabel: "env",
+		Namespace:        "default",
+	}
+	cfg.AddToFilter(cfg.AddService(&dockerComposeConfig.Service{
+		Name: "db",
+	}))
+	cfg.AddToFilter(cfg.AddService(&dockerComposeConfig.Service{
+		Name: "web",
+	}))
+	return cfg
+}
+
+// saveTestRevisions saves a revision recorded by up, and a rollback to that revision.
+func saveTestRevisions(t *testing.T, cfg *config.Config, clientset *fake.Clientset) {
+	store := revision.NewStore(cfg, clientset.CoreV1().ConfigMaps(cfg.Namespace))
+	r1 := &revision.Revision{
+		DeployedBy: "alice@laptop",
+		Number:     1,
+		Time:       time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Services: map[string]*revision.Service{
+			"db": {
+				Hash:    "0123456789abcdef",
+				Image:   "postgres:11.5",
+				ImageID: "sha256:fedcba9876543210",
+			},
+			"web": {
+				Image: "nginx@sha256:1111",
+			},
+		},
+	}
+	r2 := &revision.Revision{
+		Number:     2,
+		RollbackOf: 1,
+		Time:       time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		Services:   r1.Services,
+	}
+	for i, r := range []*revision.Revision{r1, r2} {
+		err := store.Save(r, []*revision.Revision{r1, r2}[:i])
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRun_Success(t *testing.T) {
+	cfg := newTestConfig()
+	clientset := fake.NewSimpleClientset()
+	saveTestRevisions(t, cfg, clientset)
+	withFakeClientset(clientset, func() {
+		var buffer bytes.Buffer
+		err := Run(cfg, &buffer)
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		if err != nil || len(lines) != 5 {
+			t.Error(buffer.String(), err)
+			return
+		}
+		if fields := strings.Fields(lines[1]); strings.Join(fields, " ") !=
+			"1 2020-01-01T00:00:00Z alice@laptop db postgres:11.5 fedcba987654 0123456789ab" {
+			t.Error(lines[1])
+		}
+		if fields := strings.Fields(lines[4]); strings.Join(fields, " ") !=
+			"2 (rollback to 1) 2020-01-02T00:00:00Z - web nginx@sha256:1111 - -" {
+			t.Error(lines[4])
+		}
+	})
+}
+
+func TestRun_Filtered(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.ClearFilter()
+	cfg.AddToFilter(cfg.Services["web"])
+	clientset := fake.NewSimpleClientset()
+	saveTestRevisions(t, cfg, clientset)
+	withFakeClientset(clientset, func() {
+		var buffer bytes.Buffer
+		err := Run(cfg, &buffer)
+		output := buffer.String()
+		if err != nil || strings.Count(output, "\n") != 3 || strings.Contains(output, "postgres") {
+			t.Error(output, err)
+		}
+	})
+}
@@ -0,0 +1,202 @@
+// Package inspectimage shows the configuration of the images of docker compose services, from which up derives defaults such as the
+// ports, command and healthcheck of containers.
+package inspectimage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	dockerContainer "github.com/docker/docker/api/types/container"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/remote"
+	"github.com/pkg/errors"
+)
+
+// The sources of images.
+const (
+	// SourceDaemon inspects images in the docker daemon, which includes images that were built locally.
+	SourceDaemon = "daemon"
+	// SourceRegistry gets the manifests and configurations of images from their registries, without pulling them.
+	SourceRegistry = "registry"
+)
+
+// DefaultPlatform is the platform of multi-platform images that is inspected by default, which is the platform of most Kubernetes nodes.
+var DefaultPlatform = &remote.Platform{
+	Architecture: "amd64",
+	OS:           "linux",
+}
+
+// Options are the options of GetImageDetails.
+type Options struct {
+	Context context.Context
+	// The docker CLI configuration, which provides the credentials of registries, or nil to not use credentials.
+	DockerConfig *docker.CLIConfig
+	// The HTTP client of registries, or nil to use http.DefaultClient.
+	HTTPClient *http.Client
+	// The platform of multi-platform images, or nil to use DefaultPlatform. Only used by SourceRegistry.
+	Platform *remote.Platform
+	// SourceDaemon or SourceRegistry.
+	Source string
+}
+
+// Layer is a layer of an image.
+type Layer struct {
+	// The digest of the compressed layer, or the digest of the uncompressed layer if the image was inspected in the docker daemon.
+	Digest    string `json:"digest"`
+	MediaType string `json:"mediaType,omitempty"`
+	// The size of the compressed layer, which is 0 if the image was inspected in the docker daemon.
+	Size int64 `json:"size,omitempty"`
+}
+
+// ImageDetails are the details of an image.
+type ImageDetails struct {
+	Cmd []string `json:"cmd,omitempty"`
+	// The digest of the manifest of the image, which is empty if the image was inspected in the docker daemon.
+	Digest       string   `json:"digest,omitempty"`
+	Entrypoint   []string `json:"entrypoint,omitempty"`
+	Env          []string `json:"env,omitempty"`
+	ExposedPorts []string `json:"exposedPorts,omitempty"`
+	// The test of the healthcheck of the image (e.g. CMD-SHELL curl -f http://localhost), or nil if the image does not have a healthcheck.
+	Healthcheck []string          `json:"healthcheck,omitempty"`
+	Image       string            `json:"image"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Layers      []Layer           `json:"layers"`
+	Platform    string            `json:"platform,omitempty"`
+	// The total size of the layers.
+	Size       int64  `json:"size"`
+	Source     string `json:"source"`
+	User       string `json:"user,omitempty"`
+	WorkingDir string `json:"workingDir,omitempty"`
+}
+
+// imageConfig is the configuration of an image. Since encoding/json matches field names case-insensitively, it can be parsed from both the
+// configuration of the OCI image spec and the output of docker inspect.
+type imageConfig struct {
+	Architecture string                 `json:"architecture"`
+	Config       dockerContainer.Config `json:"config"`
+	OS           string                 `json:"os"`
+	RootFS       struct {
+		Layers []string `json:"layers"`
+	} `json:"rootfs"`
+	Size    int64  `json:"size"`
+	Variant string `json:"variant"`
+}
+
+func newImageDetails(image, source string, data []byte) (*ImageDetails, *imageConfig, error) {
+	cfg := &imageConfig{}
+	err := json.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	d := &ImageDetails{
+		Cmd:        cfg.Config.Cmd,
+		Entrypoint: cfg.Config.Entrypoint,
+		Env:        cfg.Config.Env,
+		Image:      image,
+		Labels:     cfg.Config.Labels,
+		Layers:     []Layer{},
+		Source:     source,
+		User:       cfg.Config.User,
+		WorkingDir: cfg.Config.WorkingDir,
+	}
+	if cfg.OS != "" {
+		platform := &remote.Platform{
+			Architecture: cfg.Architecture,
+			OS:           cfg.OS,
+			Variant:      cfg.Variant,
+		}
+		d.Platform = platform.String()
+	}
+	for port := range cfg.Config.ExposedPorts {
+		d.ExposedPorts = append(d.ExposedPorts, string(port))
+	}
+	sort.Strings(d.ExposedPorts)
+	if cfg.Config.Healthcheck != nil {
+		d.Healthcheck = cfg.Config.Healthcheck.Test
+	}
+	return d, cfg, nil
+}
+
+// newDockerClient creates the docker client of SourceDaemon. It is a variable so that unit tests can use a fake docker daemon.
+var newDockerClient = docker.NewEnvClient
+
+func getImageDetailsFromDaemon(image string, opts *Options) (*ImageDetails, error) {
+	dc, err := newDockerClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, data, err := dc.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return nil, err
+	}
+	d, cfg, err := newImageDetails(image, SourceDaemon, data)
+	if err != nil {
+		return nil, err
+	}
+	for _, layer := range cfg.RootFS.Layers {
+		d.Layers = append(d.Layers, Layer{
+			Digest: layer,
+		})
+	}
+	d.Size = cfg.Size
+	return d, nil
+}
+
+func getImageDetailsFromRegistry(image string, opts *Options) (*ImageDetails, error) {
+	ref, err := remote.ParseImageReference(image)
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.Config)
+	}
+	credentials, err := remote.GetCredentials(opts.DockerConfig, image)
+	if err != nil {
+		return nil, err
+	}
+	platform := opts.Platform
+	if platform == nil {
+		platform = DefaultPlatform
+	}
+	img, err := remote.GetImage(opts.HTTPClient, credentials, ref, platform)
+	if err != nil {
+		return nil, err
+	}
+	d, _, err := newImageDetails(image, SourceRegistry, img.Config)
+	if err != nil {
+		return nil, err
+	}
+	d.Digest = img.Digest
+	for _, layer := range img.Manifest.Layers {
+		d.Layers = append(d.Layers, Layer{
+			Digest:    layer.Digest,
+			MediaType: layer.MediaType,
+			Size:      layer.Size,
+		})
+		d.Size += layer.Size
+	}
+	return d, nil
+}
+
+// GetImageDetails returns the details of an image, from the source of opts.
+func GetImageDetails(image string, opts *Options) (*ImageDetails, error) {
+	var d *ImageDetails
+	var err error
+	switch opts.Source {
+	case SourceDaemon:
+		d, err = getImageDetailsFromDaemon(image, opts)
+	case SourceRegistry:
+		d, err = getImageDetailsFromRegistry(image, opts)
+	default:
+		return nil, exitcode.Wrap(fmt.Errorf("the source %#v must be %s or %s", opts.Source, SourceDaemon, SourceRegistry), exitcode.Config)
+	}
+	if err != nil && exitcode.FromError(err) == exitcode.Generic {
+		err = exitcode.Wrap(errors.Wrapf(err, "could not inspect image %s", image), exitcode.ImageTransfer)
+	}
+	return d, err
+}
@@ -0,0 +1,148 @@
+package inspectimage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	dockerContainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/pkg/docker/dockertest"
+)
+
+func digestOf(data []byte) string {
+	h := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(h[:])
+}
+
+// newTestRegistry returns a registry with the multi-platform image library/web:1.0, and the digest of its linux/amd64 manifest.
+func newTestRegistry() (server *httptest.Server, digest string) {
+	config := []byte(`{"architecture":"amd64","os":"linux","config":{"User":"nobody","ExposedPorts":{"8080/tcp":{},"80/tcp":{}},` +
+		`"Env":["PATH=/bin"],"Entrypoint":["/entrypoint.sh"],"Cmd":["web"],"WorkingDir":"/app","Labels":{"a":"b"},` +
+		`"Healthcheck":{"Test":["CMD","true"]}},"rootfs":{"type":"layers","diff_ids":["sha256:3"]}}`)
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json",` +
+		`"config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"` + digestOf(config) + `","size":1},` +
+		`"layers":[{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"sha256:1","size":100},` +
+		`{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"sha256:2","size":20}]}`)
+	index := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[` +
+		`{"digest":"sha256:0","platform":{"architecture":"arm64","os":"linux"}},` +
+		`{"digest":"` + digestOf(manifest) + `","platform":{"architecture":"amd64","os":"linux"}}]}`)
+	blobs := map[string][]byte{
+		"/v2/library/web/manifests/1.0":                   index,
+		"/v2/library/web/manifests/" + digestOf(manifest): manifest,
+		"/v2/library/web/blobs/" + digestOf(config):       config,
+	}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := blobs[r.URL.Path]
+		if data == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	return server, digestOf(manifest)
+}
+
+func TestGetImageDetails_RegistrySuccess(t *testing.T) {
+	server, digest := newTestRegistry()
+	defer server.Close()
+	image := strings.TrimPrefix(server.URL, "http://") + "/library/web:1.0"
+	d, err := GetImageDetails(image, &Options{
+		Source: SourceRegistry,
+	})
+	expected := &ImageDetails{
+		Cmd:          []string{"web"},
+		Digest:       digest,
+		Entrypoint:   []string{"/entrypoint.sh"},
+		Env:          []string{"PATH=/bin"},
+		ExposedPorts: []string{"80/tcp", "8080/tcp"},
+		Healthcheck:  []string{"CMD", "true"},
+		Image:        image,
+		Labels:       map[string]string{"a": "b"},
+		Layers: []Layer{
+			{Digest: "sha256:1", MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Size: 100},
+			{Digest: "sha256:2", MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Size: 20},
+		},
+		Platform:   "linux/amd64",
+		Size:       120,
+		Source:     SourceRegistry,
+		User:       "nobody",
+		WorkingDir: "/app",
+	}
+	if err != nil || !reflect.DeepEqual(d, expected) {
+		data, _ := json.Marshal(d)
+		t.Error(string(data), err)
+	}
+}
+
+func TestGetImageDetails_RegistryNotFound(t *testing.T) {
+	server, _ := newTestRegistry()
+	defer server.Close()
+	_, err := GetImageDetails(strings.TrimPrefix(server.URL, "http://")+"/library/web:2.0", &Options{
+		Source: SourceRegistry,
+	})
+	if exitcode.FromError(err) != exitcode.ImageTransfer {
+		t.Error(err)
+	}
+}
+
+func withFakeDaemon(cb func(d *dockertest.Daemon)) {
+	d := dockertest.NewDaemon()
+	defer d.Close()
+	orig := newDockerClient
+	defer func() {
+		newDockerClient = orig
+	}()
+	newDockerClient = d.NewClient
+	cb(d)
+}
+
+func TestGetImageDetails_DaemonSuccess(t *testing.T) {
+	withFakeDaemon(func(daemon *dockertest.Daemon) {
+		daemon.AddImage(&dockertest.Image{
+			Config: &dockerContainer.Config{
+				Cmd:          []string{"web"},
+				ExposedPorts: nat.PortSet{"80/tcp": {}},
+			},
+			ID:       "sha256:0123456789012345678901234567890123456789012345678901234567890123",
+			RepoTags: []string{"web:latest"},
+		})
+		d, err := GetImageDetails("web:latest", &Options{
+			Source: SourceDaemon,
+		})
+		expected := &ImageDetails{
+			Cmd:          []string{"web"},
+			ExposedPorts: []string{"80/tcp"},
+			Image:        "web:latest",
+			Layers:       []Layer{},
+			Source:       SourceDaemon,
+		}
+		if err != nil || !reflect.DeepEqual(d, expected) {
+			t.Error(d, err)
+		}
+	})
+}
+
+func TestGetImageDetails_DaemonNotFound(t *testing.T) {
+	withFakeDaemon(func(daemon *dockertest.Daemon) {
+		_, err := GetImageDetails("web:latest", &Options{
+			Source: SourceDaemon,
+		})
+		if exitcode.FromError(err) != exitcode.ImageTransfer {
+			t.Error(err)
+		}
+	})
+}
+
+func TestGetImageDetails_InvalidSource(t *testing.T) {
+	_, err := GetImageDetails("web:latest", &Options{})
+	if exitcode.FromError(err) != exitcode.Config {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,30 @@
+package k8smeta
+
+import (
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Clients are the Kubernetes clients of a cluster.
+type Clients struct {
+	Clientset kubernetes.Interface
+	Dynamic   dynamic.Interface
+}
+
+// NewClients creates the Kubernetes clients of the cluster of a kube config. It is a variable so that unit tests can run the up and down
+// commands against fake clients (see k8s.io/client-go/kubernetes/fake and k8s.io/client-go/dynamic/fake).
+var NewClients = func(config *rest.Config) (*Clients, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Clients{
+		Clientset: clientset,
+		Dynamic:   dynamicClient,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package k8smeta
+
+import (
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+// CSIVolumesAnnotationName is the name of an annotation added by kube compose to pods, whose value maps names of volumes to CSI volume
+// sources that replace them when the pod is created. This is needed because the version of the Kubernetes API that kube compose is built
+// against cannot represent CSI volumes in pod specs.
+const CSIVolumesAnnotationName = "kube-compose/csi-volumes"
+
+// CSIVolumeSource is an inline CSI volume, see https://kubernetes.io/docs/concepts/storage/volumes/#csi-ephemeral-volumes.
+type CSIVolumeSource struct {
+	Driver           string            `json:"driver"`
+	ReadOnly         bool              `json:"readOnly,omitempty"`
+	VolumeAttributes map[string]string `json:"volumeAttributes,omitempty"`
+}
+
+// SetCSIVolumes records in the annotations of a pod that volumes of the pod are to be replaced by CSI volume sources when the pod is
+// created by CreatePod.
+func SetCSIVolumes(pod *v1.Pod, volumes map[string]*CSIVolumeSource) error {
+	data, err := json.Marshal(volumes)
+	if err != nil {
+		return err
+	}
+	if pod.ObjectMeta.Annotations == nil {
+		pod.ObjectMeta.Annotations = map[string]string{}
+	}
+	pod.ObjectMeta.Annotations[CSIVolumesAnnotationName] = string(data)
+	return nil
+}
+
+// marshalPodWithCSIVolumes JSON encodes a pod, replacing the sources of volumes as recorded by SetCSIVolumes.
+func marshalPodWithCSIVolumes(pod *v1.Pod) ([]byte, error) {
+	var csiVolumes map[string]*CSIVolumeSource
+	err := json.Unmarshal([]byte(pod.ObjectMeta.Annotations[CSIVolumesAnnotationName]), &csiVolumes)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(pod)
+	if err != nil {
+		return nil, err
+	}
+	var podRaw map[string]interface{}
+	err = json.Unmarshal(data, &podRaw)
+	if err != nil {
+		return nil, err
+	}
+	spec, _ := podRaw["spec"].(map[string]interface{})
+	volumes, _ := spec["volumes"].([]interface{})
+	for _, volumeRaw := range volumes {
+		volume, _ := volumeRaw.(map[string]interface{})
+		name, _ := volume["name"].(string)
+		if csiVolume := csiVolumes[name]; csiVolume != nil {
+			for key := range volume {
+				if key != "name" {
+					delete(volume, key)
+				}
+			}
+			volume["csi"] = csiVolume
+			delete(csiVolumes, name)
+		}
+	}
+	for name := range csiVolumes {
+		return nil, fmt.Errorf("pod %s does not have a volume named %s", pod.ObjectMeta.Name, name)
+	}
+	return json.Marshal(podRaw)
+}
+
+// CreatePod creates a pod in namespace. If the pod has CSI volumes (see SetCSIVolumes) then the pod is created with a raw request so
+// that the CSI volume sources can be included.
+func CreatePod(podClient clientV1.PodInterface, restClient rest.Interface, namespace string, pod *v1.Pod) (*v1.Pod, error) {
+	if _, ok := pod.ObjectMeta.Annotations[CSIVolumesAnnotationName]; !ok {
+		return podClient.Create(pod)
+	}
+	data, err := marshalPodWithCSIVolumes(pod)
+	if err != nil {
+		return nil, err
+	}
+	result := &v1.Pod{}
+	err = restClient.Post().
+		Namespace(namespace).
+		Resource("pods").
+		SetHeader("Content-Type", "application/json").
+		Body(data).
+		Do().
+		Into(result)
+	return result, err
+}
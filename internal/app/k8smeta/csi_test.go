@@ -0,0 +1,84 @@
+package k8smeta
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTestPodWithCSIVolume(volumeName string) *v1.Pod {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{
+				{
+					Name: "vol1",
+					VolumeSource: v1.VolumeSource{
+						EmptyDir: &v1.EmptyDirVolumeSource{},
+					},
+				},
+				{
+					Name: "secret1",
+					VolumeSource: v1.VolumeSource{
+						EmptyDir: &v1.EmptyDirVolumeSource{},
+					},
+				},
+			},
+		},
+	}
+	_ = SetCSIVolumes(pod, map[string]*CSIVolumeSource{
+		volumeName: {
+			Driver:   "secrets-store.csi.k8s.io",
+			ReadOnly: true,
+			VolumeAttributes: map[string]string{
+				"secretProviderClass": "api-key-env1",
+			},
+		},
+	})
+	return pod
+}
+
+func TestMarshalPodWithCSIVolumes_Success(t *testing.T) {
+	data, err := marshalPodWithCSIVolumes(newTestPodWithCSIVolume("secret1"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var podRaw struct {
+		Spec struct {
+			Volumes []map[string]interface{} `json:"volumes"`
+		} `json:"spec"`
+	}
+	err = json.Unmarshal(data, &podRaw)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := []map[string]interface{}{
+		{
+			"name":     "vol1",
+			"emptyDir": map[string]interface{}{},
+		},
+		{
+			"name": "secret1",
+			"csi": map[string]interface{}{
+				"driver":   "secrets-store.csi.k8s.io",
+				"readOnly": true,
+				"volumeAttributes": map[string]interface{}{
+					"secretProviderClass": "api-key-env1",
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(podRaw.Spec.Volumes, expected) {
+		t.Error(podRaw.Spec.Volumes)
+	}
+}
+
+func TestMarshalPodWithCSIVolumes_VolumeDoesNotExist(t *testing.T) {
+	_, err := marshalPodWithCSIVolumes(newTestPodWithCSIVolume("secret2"))
+	if err == nil {
+		t.Fail()
+	}
+}
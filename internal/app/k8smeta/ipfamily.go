@@ -0,0 +1,147 @@
+package k8smeta
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+
+	v1 "k8s.io/api/core/v1"
+	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+// IPFamiliesAnnotationName is the name of an annotation added by kube compose to services, whose value is the IP family policy and IP
+// families that are set on the service when it is created by CreateService. This is needed because the version of the Kubernetes API that
+// kube compose is built against cannot represent IP families in service specs.
+const IPFamiliesAnnotationName = "kube-compose/ip-families"
+
+// The IP families of services.
+const (
+	IPv4 = "IPv4"
+	IPv6 = "IPv6"
+)
+
+// The IP family policies of services, see https://kubernetes.io/docs/concepts/services-networking/dual-stack/#services.
+const (
+	IPFamilyPolicySingleStack      = "SingleStack"
+	IPFamilyPolicyPreferDualStack  = "PreferDualStack"
+	IPFamilyPolicyRequireDualStack = "RequireDualStack"
+)
+
+// IPFamilies are the IP family policy and IP families of a service.
+type IPFamilies struct {
+	// The IP families in order of preference (the first is the primary family), or nil for the cluster's default.
+	Families []string `json:"ipFamilies,omitempty"`
+	// The IP family policy, or the empty string for SingleStack.
+	Policy string `json:"ipFamilyPolicy,omitempty"`
+}
+
+// Validate returns an error if the policy or families are not valid.
+func (f *IPFamilies) Validate() error {
+	switch f.Policy {
+	case "", IPFamilyPolicySingleStack, IPFamilyPolicyPreferDualStack, IPFamilyPolicyRequireDualStack:
+	default:
+		return fmt.Errorf("the IP family policy %#v must be one of %s, %s and %s", f.Policy, IPFamilyPolicySingleStack,
+			IPFamilyPolicyPreferDualStack, IPFamilyPolicyRequireDualStack)
+	}
+	for i, family := range f.Families {
+		if family != IPv4 && family != IPv6 {
+			return fmt.Errorf("the IP family %#v must be %s or %s", family, IPv4, IPv6)
+		}
+		if i > 0 && family == f.Families[0] {
+			return fmt.Errorf("the IP families must not have duplicates")
+		}
+	}
+	if len(f.Families) > 2 {
+		return fmt.Errorf("at most two IP families can be set")
+	}
+	if len(f.Families) == 2 && (f.Policy == "" || f.Policy == IPFamilyPolicySingleStack) {
+		return fmt.Errorf("two IP families require the IP family policy %s or %s", IPFamilyPolicyPreferDualStack,
+			IPFamilyPolicyRequireDualStack)
+	}
+	return nil
+}
+
+// Allows returns true if services with these IP families can have addresses of the family of ip. An address of either family is allowed
+// if the families are not set, since they are then determined by the cluster.
+func (f *IPFamilies) Allows(ip net.IP) bool {
+	if len(f.Families) == 0 {
+		return true
+	}
+	family := IPv6
+	if ip.To4() != nil {
+		family = IPv4
+	}
+	for _, allowed := range f.Families {
+		if allowed == family {
+			return true
+		}
+	}
+	return false
+}
+
+// SetIPFamilies records in the annotations of a service that the service is to have the IP family policy and IP families of f when it is
+// created by CreateService.
+func SetIPFamilies(service *v1.Service, f *IPFamilies) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if service.ObjectMeta.Annotations == nil {
+		service.ObjectMeta.Annotations = map[string]string{}
+	}
+	service.ObjectMeta.Annotations[IPFamiliesAnnotationName] = string(data)
+	return nil
+}
+
+// marshalServiceWithIPFamilies JSON encodes a service, adding the IP family policy and IP families recorded by SetIPFamilies to its spec.
+func marshalServiceWithIPFamilies(service *v1.Service) ([]byte, error) {
+	var f IPFamilies
+	err := json.Unmarshal([]byte(service.ObjectMeta.Annotations[IPFamiliesAnnotationName]), &f)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(service)
+	if err != nil {
+		return nil, err
+	}
+	var serviceRaw map[string]interface{}
+	err = json.Unmarshal(data, &serviceRaw)
+	if err != nil {
+		return nil, err
+	}
+	spec, _ := serviceRaw["spec"].(map[string]interface{})
+	if spec == nil {
+		spec = map[string]interface{}{}
+		serviceRaw["spec"] = spec
+	}
+	if len(f.Families) > 0 {
+		spec["ipFamilies"] = f.Families
+	}
+	if f.Policy != "" {
+		spec["ipFamilyPolicy"] = f.Policy
+	}
+	return json.Marshal(serviceRaw)
+}
+
+// CreateService creates a service in namespace. If the service has IP families (see SetIPFamilies) then the service is created with a raw
+// request so that the IP families can be included.
+func CreateService(serviceClient clientV1.ServiceInterface, restClient rest.Interface, namespace string, service *v1.Service) (*v1.Service,
+	error) {
+	if _, ok := service.ObjectMeta.Annotations[IPFamiliesAnnotationName]; !ok {
+		return serviceClient.Create(service)
+	}
+	data, err := marshalServiceWithIPFamilies(service)
+	if err != nil {
+		return nil, err
+	}
+	result := &v1.Service{}
+	err = restClient.Post().
+		Namespace(namespace).
+		Resource("services").
+		SetHeader("Content-Type", "application/json").
+		Body(data).
+		Do().
+		Into(result)
+	return result, err
+}
@@ -0,0 +1,87 @@
+package k8smeta
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestIPFamiliesValidate_Success(t *testing.T) {
+	f := &IPFamilies{
+		Families: []string{IPv6, IPv4},
+		Policy:   IPFamilyPolicyPreferDualStack,
+	}
+	if err := f.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIPFamiliesValidate_Errors(t *testing.T) {
+	for _, f := range []*IPFamilies{
+		{Policy: "DualStack"},
+		{Families: []string{"IPv5"}},
+		{Families: []string{IPv4, IPv4}, Policy: IPFamilyPolicyRequireDualStack},
+		{Families: []string{IPv4, IPv6, IPv4}, Policy: IPFamilyPolicyRequireDualStack},
+		{Families: []string{IPv4, IPv6}},
+	} {
+		if err := f.Validate(); err == nil {
+			t.Error(f)
+		}
+	}
+}
+
+func TestIPFamiliesAllows(t *testing.T) {
+	f := &IPFamilies{
+		Families: []string{IPv6},
+	}
+	if !f.Allows(net.ParseIP("::1")) {
+		t.Fail()
+	}
+	if f.Allows(net.ParseIP("127.0.0.1")) {
+		t.Fail()
+	}
+	f.Families = nil
+	if !f.Allows(net.ParseIP("127.0.0.1")) {
+		t.Fail()
+	}
+}
+
+func TestMarshalServiceWithIPFamilies_Success(t *testing.T) {
+	service := &v1.Service{
+		Spec: v1.ServiceSpec{
+			Type: v1.ServiceTypeClusterIP,
+		},
+	}
+	err := SetIPFamilies(service, &IPFamilies{
+		Families: []string{IPv4, IPv6},
+		Policy:   IPFamilyPolicyRequireDualStack,
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	data, err := marshalServiceWithIPFamilies(service)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var serviceRaw struct {
+		Spec map[string]interface{} `json:"spec"`
+	}
+	err = json.Unmarshal(data, &serviceRaw)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := map[string]interface{}{
+		"ipFamilies":     []interface{}{IPv4, IPv6},
+		"ipFamilyPolicy": IPFamilyPolicyRequireDualStack,
+		"type":           "ClusterIP",
+	}
+	if !reflect.DeepEqual(serviceRaw.Spec, expected) {
+		t.Error(serviceRaw.Spec)
+	}
+}
@@ -2,33 +2,161 @@ package k8smeta
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // AnnotationName is the name of an annotation added by kube compose to resources, so that resources can be mapped back to their docker
 // compose service.
 const AnnotationName = "kube-compose/service"
 
+// SpecHashAnnotationName is the name of an annotation added by kube compose to pods, whose value is a hash of the pod's spec. The hash
+// is used to detect whether an existing pod needs to be redeployed.
+const SpecHashAnnotationName = "kube-compose/spec-hash"
+
+// ConfigHashAnnotationName is the name of an annotation added by kube compose to pods, whose value is a hash of configuration that pods
+// read at startup but that is not part of the pod's spec, such as the data of Secrets and the contents of bind mounted volumes. The hash
+// is used to detect whether an existing pod runs with stale configuration.
+const ConfigHashAnnotationName = "kube-compose/config-hash"
+
+// ProjectLabelName is the name of a label added by kube compose to resources, whose value is the name of the docker compose project that
+// owns the resource. This allows multiple projects to share a namespace.
+const ProjectLabelName = "kube-compose/project"
+
+// TrackLabelName is the name of a label added by kube compose to the pods of canaries (see package rollout), so that the selector of the
+// canary's Deployment does not select the pod of the docker compose service.
+const TrackLabelName = "kube-compose/track"
+
+// EphemeralLabelName is the name of a label added by kube compose to namespaces of ephemeral environments.
+const EphemeralLabelName = "kube-compose/ephemeral"
+
+// ExpiresLabelName is the name of a label added by kube compose to namespaces of ephemeral environments, whose value is the Unix time
+// (in seconds) after which the namespace can be garbage collected.
+const ExpiresLabelName = "kube-compose/expires"
+
+// SpreadGroupLabelName is the name of a label added by kube compose to pods of services with a topology spread, whose value is the
+// group of services whose pods are spread (see config.TopologySpread).
+const SpreadGroupLabelName = "kube-compose/spread-group"
+
+var (
+	// CertificatesGVR is the resource of Certificate objects of cert-manager.
+	CertificatesGVR = schema.GroupVersionResource{
+		Group:    "cert-manager.io",
+		Version:  "v1",
+		Resource: "certificates",
+	}
+	// ExternalSecretsGVR is the resource of ExternalSecret objects of the External Secrets Operator.
+	ExternalSecretsGVR = schema.GroupVersionResource{
+		Group:    "external-secrets.io",
+		Version:  "v1beta1",
+		Resource: "externalsecrets",
+	}
+	// HTTPRoutesGVR is the resource of HTTPRoute objects of the Gateway API.
+	HTTPRoutesGVR = schema.GroupVersionResource{
+		Group:    "gateway.networking.k8s.io",
+		Version:  "v1",
+		Resource: "httproutes",
+	}
+	// IngressClassesGVR is the resource of IngressClass objects, which are registered by Ingress controllers.
+	IngressClassesGVR = schema.GroupVersionResource{
+		Group:    "networking.k8s.io",
+		Version:  "v1",
+		Resource: "ingressclasses",
+	}
+	// IngressesGVR is the resource of Ingress objects. The dynamic client is used, because the client library predates version v1 of
+	// Ingress.
+	IngressesGVR = schema.GroupVersionResource{
+		Group:    "networking.k8s.io",
+		Version:  "v1",
+		Resource: "ingresses",
+	}
+	// PodMetricsGVR is the resource of PodMetrics objects of the metrics API, which is served by metrics-server.
+	PodMetricsGVR = schema.GroupVersionResource{
+		Group:    "metrics.k8s.io",
+		Version:  "v1beta1",
+		Resource: "pods",
+	}
+	// SecretProviderClassesGVR is the resource of SecretProviderClass objects of the Secrets Store CSI Driver.
+	SecretProviderClassesGVR = schema.GroupVersionResource{
+		Group:    "secrets-store.csi.x-k8s.io",
+		Version:  "v1",
+		Resource: "secretproviderclasses",
+	}
+	// TCPRoutesGVR is the resource of TCPRoute objects of the Gateway API, which are only available in the experimental channel.
+	TCPRoutesGVR = schema.GroupVersionResource{
+		Group:    "gateway.networking.k8s.io",
+		Version:  "v1alpha2",
+		Resource: "tcproutes",
+	}
+)
+
 // ErrorResourcesModifiedExternally returns an error indicating that resources managed by kube-compose have been modified externally.
 func ErrorResourcesModifiedExternally() error {
 	return fmt.Errorf("one or more resources appear to have been modified by an external process, aborting")
 }
 
-// InitCommonLabels adds the labels for the specified docker compose service to the string map.
-func InitCommonLabels(cfg *config.Config, composeService *config.Service, labels map[string]string) map[string]string {
+// NormalizeProjectName converts the name of a docker compose project to a name that can be used as a label value and as a prefix of
+// resource names.
+func NormalizeProjectName(projectName string) string {
+	return util.TruncateName(util.NormalizeName(projectName), validation.DNS1123LabelMaxLength)
+}
+
+// InitEnvironmentLabels adds the labels that identify the environment and project of resources to the string map.
+func InitEnvironmentLabels(cfg *config.Config, labels map[string]string) map[string]string {
 	if labels == nil {
 		labels = map[string]string{}
 	}
-	labels["app"] = composeService.NameEscaped
 	labels[cfg.EnvironmentLabel] = cfg.EnvironmentID
+	if cfg.ProjectName != "" {
+		labels[ProjectLabelName] = cfg.ProjectName
+	}
+	return labels
+}
+
+// InitCommonLabels adds the labels for the specified docker compose service to the string map.
+func InitCommonLabels(cfg *config.Config, composeService *config.Service, labels map[string]string) map[string]string {
+	labels = InitEnvironmentLabels(cfg, labels)
+	labels["app"] = GetShortName(composeService)
 	return labels
 }
 
-// InitObjectMeta sets the name, labels and annotations of a resource for the specified docker compose service.
+// GetLabelSelector returns the label selector of the resources of the environment and project.
+func GetLabelSelector(cfg *config.Config) string {
+	selector := cfg.EnvironmentLabel + "=" + cfg.EnvironmentID
+	if cfg.ProjectName != "" {
+		selector += "," + ProjectLabelName + "=" + cfg.ProjectName
+	}
+	return selector
+}
+
+// ValidateOwnership returns an error if an existing resource does not belong to the environment and project, so that resources of other
+// projects that share the namespace are never updated or deleted.
+func ValidateOwnership(cfg *config.Config, kind string, objectMeta *metav1.ObjectMeta) error {
+	if objectMeta.Labels[cfg.EnvironmentLabel] != cfg.EnvironmentID ||
+		(cfg.ProjectName != "" && objectMeta.Labels[ProjectLabelName] != cfg.ProjectName) {
+		return fmt.Errorf("%s %s already exists but is not owned by project %s and environment %s, refusing to modify it", kind,
+			objectMeta.Name, cfg.ProjectName, cfg.EnvironmentID)
+	}
+	return nil
+}
+
+// InitObjectMeta sets the name, namespace, labels and annotations of a resource for the specified docker compose service. The labels
+// include cfg.Labels, but the common labels take precedence over them. The namespace is only set if the docker compose service overrides
+// the namespace of the environment (see config.Config.GetNamespace), so that clients create resources in their own namespace by default.
 func InitObjectMeta(cfg *config.Config, objectMeta *metav1.ObjectMeta, composeService *config.Service) {
 	objectMeta.Name = GetK8sName(composeService, cfg)
+	objectMeta.Namespace = composeService.PodService().Namespace
+	if len(cfg.Labels) > 0 && objectMeta.Labels == nil {
+		objectMeta.Labels = map[string]string{}
+	}
+	for key, value := range cfg.Labels {
+		objectMeta.Labels[key] = value
+	}
 	objectMeta.Labels = InitCommonLabels(cfg, composeService, objectMeta.Labels)
 	if objectMeta.Annotations == nil {
 		objectMeta.Annotations = map[string]string{}
@@ -47,6 +175,48 @@ func FindFromObjectMeta(cfg *config.Config, objectMeta *metav1.ObjectMeta) *conf
 	return nil
 }
 
+// GetGracePeriodSeconds returns the grace period in seconds of pods of a docker compose service, based on stop_grace_period, rounding
+// up to whole seconds. Nil is returned if stop_grace_period is not set, so that Kubernetes' default is used. The grace period is at least
+// one second, because Kubernetes force deletes pods with a grace period of zero without waiting for their containers to terminate.
+func GetGracePeriodSeconds(composeService *config.Service) *int64 {
+	stopGracePeriod := composeService.DockerComposeService.StopGracePeriod
+	if stopGracePeriod == nil {
+		return nil
+	}
+	seconds := int64((*stopGracePeriod + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return &seconds
+}
+
+// GetResourceName returns the name of a resource of the environment and project, which is name prefixed with the project name and
+// suffixed with the environment ID. Names longer than maxLength are truncated deterministically (see util.TruncateName).
+func GetResourceName(cfg *config.Config, name string, maxLength int) string {
+	if cfg.ProjectName != "" {
+		name = cfg.ProjectName + "-" + name
+	}
+	return util.TruncateName(name+"-"+cfg.EnvironmentID, maxLength)
+}
+
+// GetK8sName returns the name of the pod and Kubernetes service of a docker compose service. Names that are too long for a Kubernetes
+// service are truncated deterministically (see util.TruncateName).
 func GetK8sName(service *config.Service, cfg *config.Config) string {
-	return service.NameEscaped + "-" + cfg.EnvironmentID
+	return GetResourceName(cfg, service.NameEscaped, validation.DNS1123LabelMaxLength)
+}
+
+// GetK8sHost returns the host name through which the pod of docker compose service from connects to the Kubernetes service of docker
+// compose service to. The host name is qualified with the namespace of to if the services are in different namespaces.
+func GetK8sHost(cfg *config.Config, from, to *config.Service) string {
+	host := GetK8sName(to, cfg)
+	if namespace := cfg.GetNamespace(to); namespace != cfg.GetNamespace(from) {
+		host += "." + namespace
+	}
+	return host
+}
+
+// GetShortName returns the escaped name of a docker compose service, truncated deterministically so that it can be used as a label
+// value, container name or image repository name.
+func GetShortName(service *config.Service) string {
+	return util.TruncateName(service.NameEscaped, validation.DNS1123LabelMaxLength)
 }
@@ -1,11 +1,14 @@
 package k8smeta
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kube-compose/kube-compose/internal/app/config"
 	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func newTestConfig() *config.Config {
@@ -39,6 +42,23 @@ func TestGetK8sName(t *testing.T) {
 	}
 }
 
+func TestGetK8sName_Long(t *testing.T) {
+	service := &config.Service{NameEscaped: strings.Repeat("a", 70)}
+	cfg := &config.Config{EnvironmentID: "123"}
+	serviceName := GetK8sName(service, cfg)
+	if len(serviceName) != validation.DNS1123LabelMaxLength || !strings.HasPrefix(serviceName, strings.Repeat("a", 54)+"-") {
+		t.Error(serviceName)
+	}
+}
+
+func TestGetShortName_Long(t *testing.T) {
+	service := &config.Service{NameEscaped: strings.Repeat("a", 70)}
+	shortName := GetShortName(service)
+	if len(shortName) != validation.DNS1123LabelMaxLength || shortName == GetShortName(&config.Service{NameEscaped: strings.Repeat("a", 71)}) {
+		t.Error(shortName)
+	}
+}
+
 func TestFindFromObjectMeta_NotFound(t *testing.T) {
 	cfg := config.Config{}
 	objectMeta := metav1.ObjectMeta{}
@@ -59,9 +79,137 @@ func TestInitObjectMeta_Success(t *testing.T) {
 	InitObjectMeta(cfg, &objectMeta, serviceA)
 }
 
+func TestInitObjectMeta_Labels(t *testing.T) {
+	cfg := &config.Config{
+		EnvironmentID:    "myenv",
+		EnvironmentLabel: "env",
+		Labels: map[string]string{
+			"env":  "other",
+			"team": "payments",
+		},
+	}
+	serviceA := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	objectMeta := metav1.ObjectMeta{}
+	InitObjectMeta(cfg, &objectMeta, serviceA)
+	if objectMeta.Labels["team"] != "payments" || objectMeta.Labels["env"] != "myenv" {
+		t.Error(objectMeta.Labels)
+	}
+}
+
 func Test_ErrorResourcesModifiedExternally(t *testing.T) {
 	err := ErrorResourcesModifiedExternally()
 	if err == nil {
 		t.Fail()
 	}
 }
+
+func TestGetGracePeriodSeconds_NotSet(t *testing.T) {
+	cfg := newTestConfig()
+	if GetGracePeriodSeconds(cfg.Services["a"]) != nil {
+		t.Fail()
+	}
+}
+
+func TestGetGracePeriodSeconds_RoundsUp(t *testing.T) {
+	cfg := newTestConfig()
+	stopGracePeriod := 1500 * time.Millisecond
+	cfg.Services["a"].DockerComposeService.StopGracePeriod = &stopGracePeriod
+	seconds := GetGracePeriodSeconds(cfg.Services["a"])
+	if seconds == nil || *seconds != 2 {
+		t.Fail()
+	}
+}
+
+func TestGetGracePeriodSeconds_Zero(t *testing.T) {
+	cfg := newTestConfig()
+	var stopGracePeriod time.Duration
+	cfg.Services["a"].DockerComposeService.StopGracePeriod = &stopGracePeriod
+	seconds := GetGracePeriodSeconds(cfg.Services["a"])
+	if seconds == nil || *seconds != 1 {
+		t.Fail()
+	}
+}
+
+func TestNormalizeProjectName_Success(t *testing.T) {
+	projectName := NormalizeProjectName("My_Project..Name-")
+	if projectName != "my-project-name" {
+		t.Error(projectName)
+	}
+}
+
+func TestGetK8sName_Project(t *testing.T) {
+	service := &config.Service{NameEscaped: "web"}
+	cfg := &config.Config{EnvironmentID: "123", ProjectName: "shop"}
+	serviceName := GetK8sName(service, cfg)
+	if serviceName != "shop-web-123" {
+		t.Error(serviceName)
+	}
+}
+
+func TestGetK8sHost_OtherNamespace(t *testing.T) {
+	cfg := &config.Config{EnvironmentID: "123", Namespace: "app"}
+	from := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "web",
+	})
+	to := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "db",
+	})
+	if host := GetK8sHost(cfg, from, to); host != "db-123" {
+		t.Error(host)
+	}
+	to.Namespace = "infra"
+	if host := GetK8sHost(cfg, from, to); host != "db-123.infra" {
+		t.Error(host)
+	}
+}
+
+func TestInitObjectMeta_Namespace(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Services["a"].Namespace = "infra"
+	objectMeta := &metav1.ObjectMeta{}
+	InitObjectMeta(cfg, objectMeta, cfg.Services["a"])
+	if objectMeta.Namespace != "infra" {
+		t.Error(objectMeta)
+	}
+}
+
+func TestGetLabelSelector_Success(t *testing.T) {
+	cfg := &config.Config{EnvironmentID: "123", EnvironmentLabel: "env"}
+	if GetLabelSelector(cfg) != "env=123" {
+		t.Fail()
+	}
+	cfg.ProjectName = "shop"
+	if GetLabelSelector(cfg) != "env=123,kube-compose/project=shop" {
+		t.Fail()
+	}
+}
+
+func TestValidateOwnership_Success(t *testing.T) {
+	cfg := &config.Config{EnvironmentID: "123", EnvironmentLabel: "env", ProjectName: "shop"}
+	objectMeta := &metav1.ObjectMeta{
+		Name: "shop-web-123",
+		Labels: map[string]string{
+			"env":            "123",
+			ProjectLabelName: "shop",
+		},
+	}
+	if err := ValidateOwnership(cfg, "Pod", objectMeta); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateOwnership_OtherProject(t *testing.T) {
+	cfg := &config.Config{EnvironmentID: "123", EnvironmentLabel: "env", ProjectName: "shop"}
+	objectMeta := &metav1.ObjectMeta{
+		Name: "shop-web-123",
+		Labels: map[string]string{
+			"env":            "123",
+			ProjectLabelName: "blog",
+		},
+	}
+	if err := ValidateOwnership(cfg, "Pod", objectMeta); err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,190 @@
+// Package logs prints the logs of the containers of docker compose services, like docker compose logs.
+package logs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// Options are the options of Run.
+type Options struct {
+	// If true, the logs are streamed until the containers terminate, like docker compose logs --follow.
+	Follow bool
+	// If true, the prefixes of log lines are not colored.
+	NoColor bool
+	// The writer of the log lines.
+	Output io.Writer
+	// If not nil, only log lines that are newer than this number of seconds are printed.
+	SinceSeconds *int64
+	// If not nil, only log lines that are newer than this time are printed. At most one of SinceSeconds and SinceTime is set.
+	SinceTime *metav1.Time
+	// If not nil, only this number of lines from the end of the logs of each container are printed.
+	TailLines *int64
+}
+
+// openLogStream opens the logs of a container of a pod. It is a variable to improve testability, because the fake clientset does not
+// support the logs of pods.
+var openLogStream = func(podClient clientV1.PodInterface, podName string, podLogOptions *v1.PodLogOptions) (io.ReadCloser, error) {
+	return podClient.GetLogs(podName, podLogOptions).Stream()
+}
+
+type loggedService struct {
+	color     int
+	podClient clientV1.PodInterface
+	podName   string
+	service   *config.Service
+}
+
+type logsRunner struct {
+	cfg          *config.Config
+	k8sClientset kubernetes.Interface
+	// The width of the prefixes of log lines, which is based on the longest name of a service.
+	maxServiceNameLength int
+	mutex                sync.Mutex
+	opts                 *Options
+	services             []*loggedService
+}
+
+func (l *logsRunner) initKubernetesClientset() error {
+	clients, err := k8smeta.NewClients(l.cfg.KubeConfig)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	l.k8sClientset = clients.Clientset
+	return nil
+}
+
+// initServices determines the services whose logs are printed, which are the services that match the filter directly and have a pod. The
+// colors of services are assigned in the order of their names, so that they are the same every time.
+func (l *logsRunner) initServices() error {
+	names := make([]string, 0, len(l.cfg.Services))
+	for name, service := range l.cfg.Services {
+		if l.cfg.MatchesFilterDirectly(service) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		service := l.cfg.Services[name]
+		podClient := l.k8sClientset.CoreV1().Pods(l.cfg.GetNamespace(service))
+		podName := k8smeta.GetK8sName(service.PodService(), l.cfg)
+		_, err := podClient.Get(podName, metav1.GetOptions{})
+		if k8sError.IsNotFound(err) {
+			log.Warnf("service %s does not have a pod, run up to create it", name)
+			continue
+		}
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		l.services = append(l.services, &loggedService{
+			color:     util.ServiceColor(i),
+			podClient: podClient,
+			podName:   podName,
+			service:   service,
+		})
+		if len(name) > l.maxServiceNameLength {
+			l.maxServiceNameLength = len(name)
+		}
+	}
+	return nil
+}
+
+// writeLine writes a log line of a service with the prefix of the service. Lines are written at once, so that the lines of services that
+// are streamed concurrently are not interleaved.
+func (l *logsRunner) writeLine(ls *loggedService, line string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	var err error
+	if l.opts.NoColor {
+		_, err = fmt.Fprintf(l.opts.Output, "%-*s| %s\n", l.maxServiceNameLength+3, ls.service.Name(), line)
+	} else {
+		_, err = fmt.Fprintf(l.opts.Output, "\x1b[%dm%-*s|\x1b[0m %s\n", ls.color, l.maxServiceNameLength+3, ls.service.Name(), line)
+	}
+	return err
+}
+
+func (l *logsRunner) streamLogs(ls *loggedService) error {
+	podLogOptions := &v1.PodLogOptions{
+		Container:    k8smeta.GetShortName(ls.service),
+		Follow:       l.opts.Follow,
+		SinceSeconds: l.opts.SinceSeconds,
+		SinceTime:    l.opts.SinceTime,
+		TailLines:    l.opts.TailLines,
+	}
+	stream, err := openLogStream(ls.podClient, ls.podName, podLogOptions)
+	if err != nil {
+		return exitcode.Wrap(errors.Wrapf(err, "error while streaming the logs of service %s", ls.service.Name()),
+			exitcode.ClusterConnectivity)
+	}
+	defer util.CloseAndLogError(stream)
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		err = l.writeLine(ls, scanner.Text())
+		if err != nil {
+			return err
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return exitcode.Wrap(errors.Wrapf(err, "error while streaming the logs of service %s", ls.service.Name()),
+			exitcode.ClusterConnectivity)
+	}
+	return nil
+}
+
+// streamAllLogs streams the logs of all services concurrently, and returns the error of the first service (by name) whose logs could not
+// be streamed. The logs of all services are streamed at the same time, because streams do not end in follow mode.
+func (l *logsRunner) streamAllLogs() error {
+	errs := make([]error, len(l.services))
+	var wg sync.WaitGroup
+	for i, ls := range l.services {
+		wg.Add(1)
+		go func(i int, ls *loggedService) {
+			defer wg.Done()
+			errs[i] = l.streamLogs(ls)
+		}(i, ls)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *logsRunner) run() error {
+	err := l.initKubernetesClientset()
+	if err != nil {
+		return err
+	}
+	err = l.initServices()
+	if err != nil {
+		return err
+	}
+	return l.streamAllLogs()
+}
+
+// Run prints the logs of the containers of the docker compose services that match the filter of cfg directly. Each line is prefixed with
+// the name of its service.
+func Run(cfg *config.Config, opts *Options) error {
+	l := &logsRunner{
+		cfg:  cfg,
+		opts: opts,
+	}
+	return l.run()
+}
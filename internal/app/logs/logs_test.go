@@ -0,0 +1,147 @@
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+// withFakeCluster runs cb with logs connecting to a fake clientset with the pods of the services db and web, and with the logs of the
+// containers given by logs (keyed by pod name). The options of the opened streams are recorded in podLogOptions.
+func withFakeCluster(cfg *config.Config, logs map[string]string, podLogOptions map[string]*v1.PodLogOptions, cb func()) {
+	var objects []runtime.Object
+	for _, name := range []string{"db", "web"} {
+		pod := &v1.Pod{}
+		k8smeta.InitObjectMeta(cfg, &pod.ObjectMeta, cfg.Services[name])
+		pod.Namespace = cfg.Namespace
+		objects = append(objects, pod)
+	}
+	clientset := fake.NewSimpleClientset(objects...)
+	origNewClients := k8smeta.NewClients
+	origOpenLogStream := openLogStream
+	defer func() {
+		k8smeta.NewClients = origNewClients
+		openLogStream = origOpenLogStream
+	}()
+	k8smeta.NewClients = func(_ *rest.Config) (*k8smeta.Clients, error) {
+		return &k8smeta.Clients{
+			Clientset: clientset,
+		}, nil
+	}
+	var mutex sync.Mutex
+	openLogStream = func(_ clientV1.PodInterface, podName string, opts *v1.PodLogOptions) (io.ReadCloser, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		podLogOptions[podName] = opts
+		data, ok := logs[podName]
+		if !ok {
+			return nil, fmt.Errorf("container %s is waiting to start", opts.Container)
+		}
+		return ioutil.NopCloser(strings.NewReader(data)), nil
+	}
+	cb()
+}
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{
+		EnvironmentID:    "test",
+		EnvironmentLabel: "env",
+		Namespace:        "default",
+	}
+	for _, name := range []string{"db", "web", "worker"} {
+		cfg.AddToFilter(cfg.AddService(&dockerComposeConfig.Service{
+			Name: name,
+		}))
+	}
+	return cfg
+}
+
+func TestRun_Success(t *testing.T) {
+	cfg := newTestConfig()
+	podLogOptions := map[string]*v1.PodLogOptions{}
+	logs := map[string]string{
+		"db-test":  "ready\n",
+		"web-test": "listening\nGET /\n",
+	}
+	tailLines := int64(10)
+	withFakeCluster(cfg, logs, podLogOptions, func() {
+		var buffer bytes.Buffer
+		err := Run(cfg, &Options{
+			Follow:    true,
+			NoColor:   true,
+			Output:    &buffer,
+			TailLines: &tailLines,
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+		expected := map[string]bool{
+			"db    | ready":     true,
+			"web   | listening": true,
+			"web   | GET /":     true,
+		}
+		if len(lines) != len(expected) {
+			t.Error(lines)
+		}
+		for _, line := range lines {
+			if !expected[line] {
+				t.Error(line)
+			}
+		}
+	})
+	opts := podLogOptions["web-test"]
+	if opts == nil || opts.Container != "web" || !opts.Follow || opts.TailLines != &tailLines {
+		t.Error(opts)
+	}
+}
+
+func TestRun_Color(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.ClearFilter()
+	cfg.AddToFilter(cfg.Services["web"])
+	logs := map[string]string{
+		"web-test": "listening\n",
+	}
+	withFakeCluster(cfg, logs, map[string]*v1.PodLogOptions{}, func() {
+		var buffer bytes.Buffer
+		err := Run(cfg, &Options{
+			Output: &buffer,
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		if buffer.String() != "\x1b[37mweb   |\x1b[0m listening\n" {
+			t.Errorf("%q", buffer.String())
+		}
+	})
+}
+
+func TestRun_StreamError(t *testing.T) {
+	cfg := newTestConfig()
+	logs := map[string]string{
+		"web-test": "listening\n",
+	}
+	withFakeCluster(cfg, logs, map[string]*v1.PodLogOptions{}, func() {
+		err := Run(cfg, &Options{
+			Output: ioutil.Discard,
+		})
+		if exitcode.FromError(err) != exitcode.ClusterConnectivity {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,209 @@
+// Package publish publishes docker compose projects as OCI artifacts, like docker compose publish. A published project can be used by
+// passing an oci:// source to the flag -f (see remote.OCIFetcher).
+package publish
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	dockerRef "github.com/docker/distribution/reference"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/remote"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ImageDigestsFile is the name of the docker compose file of a published project that pins the images of docker compose services to
+// digests (see Options.ResolveImageDigests). It is merged after the other docker compose files.
+const ImageDigestsFile = "image-digests.yml"
+
+// Options are the options of Run.
+type Options struct {
+	// The docker CLI configuration, which provides the credentials of registries, or nil to not use credentials.
+	DockerConfig *docker.CLIConfig
+	// The HTTP client of registries, or nil to use http.DefaultClient.
+	HTTPClient *http.Client
+	// If true, the images of docker compose services are pinned to the digests of their manifests in their registries, so that the
+	// published project always runs the same images.
+	ResolveImageDigests bool
+	// If true, the docker compose files are rendered as Go templates before they are validated. The files are published as is.
+	Template bool
+}
+
+// readFile reads a file of the project, and returns its layer. The name of the file in the artifact is relative to dir.
+func readFile(dir, file, mediaType, annotation string) (*remote.Layer, error) {
+	name, err := filepath.Rel(dir, file)
+	if err != nil || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("cannot publish the file %s, because it is not in the directory %s of the first docker compose file", file, dir)
+	}
+	fd, err := fs.OS.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(fd)
+	data, err := ioutil.ReadAll(fd)
+	if err != nil {
+		return nil, err
+	}
+	return &remote.Layer{
+		Annotations: map[string]string{
+			annotation: filepath.ToSlash(name),
+		},
+		Data:      data,
+		MediaType: mediaType,
+	}, nil
+}
+
+// getLayers returns the layers of the docker compose files and the layers of the env files that they refer to.
+func getLayers(files []string) (composeFileLayers, envFileLayers []*remote.Layer, err error) {
+	dir := filepath.Dir(files[0])
+	seen := map[string]bool{}
+	for _, file := range files {
+		var layer *remote.Layer
+		layer, err = readFile(dir, file, remote.ComposeFileMediaType, remote.ComposeFileAnnotation)
+		if err != nil {
+			return nil, nil, err
+		}
+		composeFileLayers = append(composeFileLayers, layer)
+		var envFiles []string
+		envFiles, err = remote.GetEnvFiles(layer.Data)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, envFile := range envFiles {
+			envFile = filepath.Join(filepath.Dir(file), filepath.FromSlash(envFile))
+			if seen[envFile] {
+				continue
+			}
+			seen[envFile] = true
+			layer, err = readFile(dir, envFile, remote.EnvFileMediaType, remote.EnvFileAnnotation)
+			if err != nil {
+				return nil, nil, err
+			}
+			envFileLayers = append(envFileLayers, layer)
+		}
+	}
+	return composeFileLayers, envFileLayers, nil
+}
+
+// resolveImageDigest returns an image reference that pins image to the digest of its manifest in its registry.
+func resolveImageDigest(opts *Options, image string) (string, error) {
+	named, err := dockerRef.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+	if canonical, ok := named.(dockerRef.Canonical); ok {
+		return dockerRef.FamiliarString(canonical), nil
+	}
+	ref, err := remote.ParseImageReference(image)
+	if err != nil {
+		return "", err
+	}
+	credentials, err := remote.GetCredentials(opts.DockerConfig, image)
+	if err != nil {
+		return "", err
+	}
+	digest, err := remote.ResolveDigest(opts.HTTPClient, credentials, ref)
+	if err != nil {
+		return "", err
+	}
+	return dockerRef.FamiliarName(named) + "@" + digest, nil
+}
+
+// getImageDigestsLayer returns the layer of the docker compose file that pins the images of docker compose services to digests (see
+// ImageDigestsFile). The docker compose file has the same version as firstFile (the data of the first docker compose file), because
+// docker compose files of different versions cannot be merged.
+func getImageDigestsLayer(opts *Options, services map[string]*dockerComposeConfig.Service, firstFile []byte) (*remote.Layer, error) {
+	var versioned struct {
+		Version *string `yaml:"version"`
+	}
+	err := yaml.Unmarshal(firstFile, &versioned)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pinned := map[string]interface{}{}
+	for _, name := range names {
+		image := services[name].Image
+		if image == "" {
+			continue
+		}
+		var pinnedImage string
+		pinnedImage, err = resolveImageDigest(opts, image)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not resolve the digest of the image %s of docker compose service %s", image, name)
+		}
+		pinned[name] = map[string]string{
+			"image": pinnedImage,
+		}
+	}
+	// Docker compose files without a version have the format of version 1, which does not have the key services.
+	var dcFile interface{} = pinned
+	if versioned.Version != nil {
+		dcFile = map[string]interface{}{
+			"services": pinned,
+			"version":  *versioned.Version,
+		}
+	}
+	data, err := yaml.Marshal(dcFile)
+	if err != nil {
+		return nil, err
+	}
+	return &remote.Layer{
+		Annotations: map[string]string{
+			remote.ComposeFileAnnotation: ImageDigestsFile,
+		},
+		Data:      data,
+		MediaType: remote.ComposeFileMediaType,
+	}, nil
+}
+
+// Run publishes the docker compose files, and the env files that they refer to, as an OCI artifact to ref (of the form
+// <registry>/<repository>[:<tag>]), and returns the digest of the artifact. The developer-specific docker compose file is not published.
+func Run(files []string, ref string, opts *Options) (string, error) {
+	r, err := remote.ParseReference(ref)
+	if err != nil {
+		return "", exitcode.Wrap(err, exitcode.Config)
+	}
+	dcCfg, err := dockerComposeConfig.NewWithOptions(files, &dockerComposeConfig.LoadOptions{
+		Template: opts.Template,
+	})
+	if err != nil {
+		return "", exitcode.Wrap(err, exitcode.Config)
+	}
+	composeFileLayers, envFileLayers, err := getLayers(dcCfg.Files)
+	if err != nil {
+		return "", exitcode.Wrap(err, exitcode.Config)
+	}
+	if opts.ResolveImageDigests {
+		var layer *remote.Layer
+		layer, err = getImageDigestsLayer(opts, dcCfg.Services, composeFileLayers[0].Data)
+		if err != nil {
+			return "", exitcode.Wrap(err, exitcode.ImageTransfer)
+		}
+		// The docker compose files are merged in the order of their layers, so the docker compose file that pins images is merged last.
+		composeFileLayers = append(composeFileLayers, layer)
+	}
+	credentials, err := remote.GetCredentials(opts.DockerConfig, r.Host+"/"+r.Repository)
+	if err != nil {
+		return "", exitcode.Wrap(err, exitcode.Config)
+	}
+	digest, err := remote.PushArtifact(opts.HTTPClient, credentials, r, remote.ArtifactType,
+		append(composeFileLayers, envFileLayers...))
+	if err != nil {
+		return "", exitcode.Wrap(errors.Wrapf(err, "could not publish %s", ref), exitcode.ImageTransfer)
+	}
+	return digest, nil
+}
@@ -0,0 +1,146 @@
+package publish
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/remote"
+)
+
+const testNginxDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+
+func digestOf(data []byte) string {
+	h := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(h[:])
+}
+
+// newTestRegistry returns a registry that stores pushed blobs and manifests in memory, and has the image library/nginx:1.17.
+func newTestRegistry() *httptest.Server {
+	blobs := map[string][]byte{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case r.URL.Path == "/v2/library/nginx/manifests/1.17":
+			w.Header().Set("Docker-Content-Digest", testNginxDigest)
+		case strings.HasSuffix(r.URL.Path, "/blobs/uploads/"):
+			w.Header().Set("Location", "/upload")
+			w.WriteHeader(http.StatusAccepted)
+		case r.URL.Path == "/upload":
+			blobs[r.URL.Query().Get("digest")] = data
+			w.WriteHeader(http.StatusCreated)
+		case strings.Contains(r.URL.Path, "/blobs/") && blobs[r.URL.Path[strings.LastIndexByte(r.URL.Path, '/')+1:]] != nil:
+			_, _ = w.Write(blobs[r.URL.Path[strings.LastIndexByte(r.URL.Path, '/')+1:]])
+		case strings.Contains(r.URL.Path, "/manifests/") && r.Method == http.MethodPut:
+			blobs[r.URL.Path] = data
+			blobs[r.URL.Path[:strings.LastIndexByte(r.URL.Path, '/')+1]+digestOf(data)] = data
+			w.WriteHeader(http.StatusCreated)
+		case blobs[r.URL.Path] != nil:
+			_, _ = w.Write(blobs[r.URL.Path])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func withMockFS(files map[string]string, cb func()) {
+	orig := fs.OS
+	defer func() {
+		fs.OS = orig
+	}()
+	inMemoryFiles := map[string]fs.InMemoryFile{}
+	for name, content := range files {
+		inMemoryFiles[name] = fs.InMemoryFile{
+			Content: []byte(content),
+		}
+	}
+	fs.OS = fs.NewInMemoryUnixFileSystem(inMemoryFiles)
+	cb()
+}
+
+func TestRun_Success(t *testing.T) {
+	server := newTestRegistry()
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	withMockFS(map[string]string{
+		"/project/docker-compose.yml": "version: '2.4'\nservices:\n  web:\n    image: " + host + "/library/nginx:1.17\n" +
+			"    env_file: env/web.env\n",
+		"/project/docker-compose.override.yml": "version: '2.4'\nservices:\n  web:\n    environment:\n      B: '2'\n",
+		"/project/env/web.env":                 "A=1\n",
+	}, func() {
+		digest, err := Run([]string{"/project/docker-compose.yml", "/project/docker-compose.override.yml"}, host+"/org/app:1.0", &Options{
+			ResolveImageDigests: true,
+		})
+		if err != nil || !strings.HasPrefix(digest, "sha256:") {
+			t.Error(digest, err)
+			return
+		}
+		// The published project is fetched like up fetches it.
+		files, err := (&remote.OCIFetcher{}).Fetch("oci://"+host+"/org/app@"+digest, "/cache")
+		expected := []string{"/cache/app/docker-compose.yml", "/cache/app/docker-compose.override.yml", "/cache/app/image-digests.yml"}
+		if err != nil || !reflect.DeepEqual(files, expected) {
+			t.Error(files, err)
+			return
+		}
+		if content := readTestFile(t, "/cache/app/image-digests.yml"); content !=
+			"services:\n  web:\n    image: "+host+"/library/nginx@"+testNginxDigest+"\nversion: \"2.4\"\n" {
+			t.Error(content)
+		}
+		if content := readTestFile(t, "/cache/app/env/web.env"); content != "A=1\n" {
+			t.Error(content)
+		}
+	})
+}
+
+func readTestFile(t *testing.T, name string) string {
+	fd, err := fs.OS.Open(name)
+	if err != nil {
+		t.Error(err)
+		return ""
+	}
+	defer fd.Close()
+	data, _ := ioutil.ReadAll(fd)
+	return string(data)
+}
+
+func TestRun_FileOutsideProject(t *testing.T) {
+	withMockFS(map[string]string{
+		"/project/docker-compose.yml": "version: '2.4'\nservices:\n  web:\n    image: nginx\n    env_file: ../web.env\n",
+		"/web.env":                    "A=1\n",
+	}, func() {
+		_, err := Run([]string{"/project/docker-compose.yml"}, "localhost:5000/org/app:1.0", &Options{})
+		if exitcode.FromError(err) != exitcode.Config {
+			t.Error(err)
+		}
+	})
+}
+
+func TestRun_ResolveImageDigestError(t *testing.T) {
+	server := newTestRegistry()
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	withMockFS(map[string]string{
+		"/project/docker-compose.yml": "version: '2.4'\nservices:\n  web:\n    image: " + host + "/library/nginx:1.16\n",
+	}, func() {
+		_, err := Run([]string{"/project/docker-compose.yml"}, host+"/org/app:1.0", &Options{
+			ResolveImageDigests: true,
+		})
+		if exitcode.FromError(err) != exitcode.ImageTransfer {
+			t.Error(err)
+		}
+	})
+}
+
+func TestResolveImageDigest_AlreadyPinned(t *testing.T) {
+	image, err := resolveImageDigest(&Options{}, "nginx@"+testNginxDigest)
+	if err != nil || image != "nginx@"+testNginxDigest {
+		t.Error(image, err)
+	}
+}
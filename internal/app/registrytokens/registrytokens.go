@@ -0,0 +1,66 @@
+// Package registrytokens lists, refreshes and clears the bearer tokens of registries that kube-compose caches (see remote.TokenCache).
+package registrytokens
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	"github.com/kube-compose/kube-compose/internal/pkg/remote"
+	"github.com/pkg/errors"
+)
+
+// orDash returns s, or "-" if s is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// writeTokens writes a table of tokens to w. The values of the tokens are not written.
+func writeTokens(w io.Writer, tokens []*remote.Token) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "REGISTRY\tUSERNAME\tSCOPE\tEXPIRES")
+	for _, t := range tokens {
+		expires := t.ExpiresAt.Format(time.RFC3339)
+		if !t.ExpiresAt.After(time.Now()) {
+			expires = "expired"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", t.Registry, orDash(t.Username), orDash(t.Scope()), expires)
+	}
+	return tw.Flush()
+}
+
+// List writes a table of the cached tokens of a registry, or of all registries if registry is the empty string, to w.
+func List(cache *remote.TokenCache, registry string, w io.Writer) error {
+	tokens, err := cache.List(registry)
+	if err != nil {
+		return errors.Wrap(err, "error while listing the cached registry tokens")
+	}
+	return writeTokens(w, tokens)
+}
+
+// Refresh requests new tokens for the cached tokens of a registry, or of all registries if registry is the empty string, with the
+// credentials of the docker CLI configuration, and writes a table of the refreshed tokens to w.
+func Refresh(cache *remote.TokenCache, cliConfig *docker.CLIConfig, registry string, w io.Writer) error {
+	tokens, err := cache.Refresh(cliConfig, http.DefaultClient, registry)
+	if err != nil {
+		return errors.Wrap(err, "error while refreshing the cached registry tokens")
+	}
+	return writeTokens(w, tokens)
+}
+
+// Clear removes the cached tokens of a registry, or of all registries if registry is the empty string, and writes the number of removed
+// tokens to w. The credentials of the docker CLI are not removed.
+func Clear(cache *remote.TokenCache, registry string, w io.Writer) error {
+	n, err := cache.Clear(registry)
+	if err != nil {
+		return errors.Wrap(err, "error while clearing the cached registry tokens")
+	}
+	_, err = fmt.Fprintf(w, "cleared %d registry token(s)\n", n)
+	return err
+}
@@ -0,0 +1,41 @@
+package registrytokens
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/remote"
+)
+
+func TestWriteTokens(t *testing.T) {
+	var buffer bytes.Buffer
+	err := writeTokens(&buffer, []*remote.Token{
+		{
+			ExpiresAt: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC),
+			Registry:  "registry.example.com",
+			URL:       "https://auth.example.com/token?scope=repository%3Aorg%2Fapp%3Apull&service=registry",
+			Username:  "alice",
+			Value:     "secret",
+		},
+		{
+			ExpiresAt: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+			Registry:  "localhost:5000",
+			URL:       "http://localhost:5000/token",
+			Value:     "secret",
+		},
+	})
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if err != nil || len(lines) != 3 || strings.Contains(buffer.String(), "secret") {
+		t.Error(buffer.String(), err)
+		return
+	}
+	if fields := strings.Join(strings.Fields(lines[1]), " "); fields !=
+		"registry.example.com alice repository:org/app:pull 2100-01-01T00:00:00Z" {
+		t.Error(lines[1])
+	}
+	if fields := strings.Join(strings.Fields(lines[2]), " "); fields != "localhost:5000 - - expired" {
+		t.Error(lines[2])
+	}
+}
@@ -0,0 +1,184 @@
+// Package revision records the pods that up deployed for an environment of a docker compose project as numbered revisions, so that
+// rollback can re-apply the pods of a previous revision. Each revision is stored in its own ConfigMap.
+package revision
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// LabelName is the name of a label added by kube compose to the ConfigMaps of revisions, whose value is the number of the revision.
+const LabelName = "kube-compose/revision"
+
+// configMapKey is the key of the data of the ConfigMap that holds the JSON encoded revision.
+const configMapKey = "revision.json"
+
+// HistoryLimit is the number of revisions that are kept. When a revision is saved, the oldest revisions beyond this limit are deleted.
+var HistoryLimit = 10
+
+// getDeployer returns who records a revision, as the name of the user of the operating system and the name of the host (user@host). It is
+// a variable to improve testability.
+var getDeployer = func() string {
+	name := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return name + "@" + host
+	}
+	return name
+}
+
+// Service is the pod of a docker compose service in a revision. The containers of sidecars are part of the pod of the first docker
+// compose service of their pod group, so sidecars do not have their own Service.
+type Service struct {
+	// The hash of the effective configuration of the pod, or the empty string if the revision was recorded by an older version of
+	// kube-compose.
+	Hash string `json:"hash,omitempty"`
+	// The image of the pod, which is resolved to a digest if the image was pushed to a registry.
+	Image string `json:"image"`
+	// The ID of the local image of the pod, or the empty string if the revision was recorded by an older version of kube-compose.
+	ImageID string `json:"imageID,omitempty"`
+	// The pod as it was created by up, including the annotations that up uses to detect changes.
+	Pod *v1.Pod `json:"pod"`
+}
+
+// Revision is a record of the pods of an environment after an up or a rollback.
+type Revision struct {
+	// Who recorded the revision (see getDeployer), or the empty string if the revision was recorded by an older version of kube-compose.
+	DeployedBy string `json:"deployedBy,omitempty"`
+	// The number of the revision, which is one more than the number of the previous revision.
+	Number int `json:"number"`
+	// The number of the revision that was rolled back to, or 0 if the revision was recorded by up.
+	RollbackOf int       `json:"rollbackOf,omitempty"`
+	Time       time.Time `json:"time"`
+	// The pods of the docker compose services, keyed by the name of the docker compose service.
+	Services map[string]*Service `json:"services"`
+}
+
+// Next returns the revision that follows the revision, with the services of the revision except those whose docker compose service is
+// no longer in cfg, and with the specified services. The revision can be nil if there are no revisions yet.
+func (r *Revision) Next(cfg *config.Config, services map[string]*Service) *Revision {
+	next := &Revision{
+		DeployedBy: getDeployer(),
+		Number:     1,
+		Time:       time.Now(),
+		Services:   map[string]*Service{},
+	}
+	if r != nil {
+		next.Number = r.Number + 1
+		for name, service := range r.Services {
+			if cfg.Services[name] != nil {
+				next.Services[name] = service
+			}
+		}
+	}
+	for name, service := range services {
+		next.Services[name] = service
+	}
+	return next
+}
+
+// Store loads and saves the revisions of an environment.
+type Store struct {
+	cfg    *config.Config
+	client clientV1.ConfigMapInterface
+}
+
+// NewStore returns a store that saves the revisions in ConfigMaps of the namespace of the environment. The ConfigMaps are named after
+// the project, environment and number of the revision, and are labelled like the other resources of the environment.
+func NewStore(cfg *config.Config, client clientV1.ConfigMapInterface) *Store {
+	return &Store{
+		cfg:    cfg,
+		client: client,
+	}
+}
+
+func (s *Store) getConfigMapName(number int) string {
+	return k8smeta.GetResourceName(s.cfg, fmt.Sprintf("kube-compose-revision-%d", number), validation.DNS1123SubdomainMaxLength)
+}
+
+// List returns the revisions, in ascending order of their numbers.
+func (s *Store) List() ([]*Revision, error) {
+	configMapList, err := s.client.List(metav1.ListOptions{
+		LabelSelector: k8smeta.GetLabelSelector(s.cfg) + "," + LabelName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var revisions []*Revision
+	for i := 0; i < len(configMapList.Items); i++ {
+		configMap := &configMapList.Items[i]
+		r := &Revision{}
+		err = json.Unmarshal([]byte(configMap.Data[configMapKey]), r)
+		if err != nil {
+			return nil, fmt.Errorf("ConfigMap %s does not hold a valid revision: %v", configMap.ObjectMeta.Name, err)
+		}
+		revisions = append(revisions, r)
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Number < revisions[j].Number
+	})
+	return revisions, nil
+}
+
+// Save saves a revision, and then deletes the oldest of the revisions beyond HistoryLimit. revisions must be the revisions returned by
+// List before the revision was created.
+func (s *Store) Save(r *Revision, revisions []*Revision) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	labels := k8smeta.InitEnvironmentLabels(s.cfg, nil)
+	labels[LabelName] = strconv.Itoa(r.Number)
+	_, err = s.client.Create(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: labels,
+			Name:   s.getConfigMapName(r.Number),
+		},
+		Data: map[string]string{
+			configMapKey: string(data),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(revisions)+1-HistoryLimit; i++ {
+		err = s.client.Delete(s.getConfigMapName(revisions[i].Number), &metav1.DeleteOptions{})
+		if err != nil && !k8sError.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Latest returns the revision with the highest number, or nil if there are no revisions.
+func Latest(revisions []*Revision) *Revision {
+	if len(revisions) == 0 {
+		return nil
+	}
+	return revisions[len(revisions)-1]
+}
+
+// Find returns the revision with the specified number, or nil if there is no such revision.
+func Find(revisions []*Revision, number int) *Revision {
+	for _, r := range revisions {
+		if r.Number == number {
+			return r
+		}
+	}
+	return nil
+}
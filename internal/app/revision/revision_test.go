@@ -0,0 +1,81 @@
+package revision
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{
+		EnvironmentID:    "test",
+		EnvironmentLabel: "env",
+		Namespace:        "default",
+	}
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name: "web",
+	})
+	return cfg
+}
+
+func TestRevision_Next(t *testing.T) {
+	orig := getDeployer
+	defer func() {
+		getDeployer = orig
+	}()
+	getDeployer = func() string {
+		return "alice@laptop"
+	}
+	cfg := newTestConfig()
+	first := (*Revision)(nil).Next(cfg, map[string]*Service{
+		"removed": {Image: "removed:1"},
+		"web":     {Image: "web:1"},
+	})
+	if first.Number != 1 || len(first.Services) != 2 || first.DeployedBy != "alice@laptop" {
+		t.Error(first)
+	}
+	second := first.Next(cfg, nil)
+	if second.Number != 2 || len(second.Services) != 1 || second.Services["web"].Image != "web:1" {
+		t.Error(second)
+	}
+}
+
+func TestStore_SaveAndList(t *testing.T) {
+	orig := HistoryLimit
+	defer func() {
+		HistoryLimit = orig
+	}()
+	HistoryLimit = 2
+	cfg := newTestConfig()
+	clientset := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"env": "test",
+			},
+			Name:      "kube-compose-state-test",
+			Namespace: "default",
+		},
+	})
+	store := NewStore(cfg, clientset.CoreV1().ConfigMaps("default"))
+	for i := 0; i < 3; i++ {
+		revisions, err := store.List()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		err = store.Save(Latest(revisions).Next(cfg, map[string]*Service{
+			"web": {Image: "web:1"},
+		}), revisions)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	revisions, err := store.List()
+	if err != nil || len(revisions) != 2 || revisions[0].Number != 2 || revisions[1].Number != 3 || Find(revisions, 1) != nil {
+		t.Error(revisions, err)
+	}
+}
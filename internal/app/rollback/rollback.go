@@ -0,0 +1,239 @@
+// Package rollback re-applies the pods of a previous revision of an environment (see package revision), for all docker compose services
+// or for the docker compose services in the filter of the configuration.
+package rollback
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/revision"
+	"github.com/kube-compose/kube-compose/internal/app/state"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The interval at which a deleted pod is polled until it is gone. It is a variable to improve testability.
+var deletionPollInterval = time.Second
+
+// Options are the options of Run.
+type Options struct {
+	// The number of the revision to roll back to, or 0 to roll back to the revision before the latest revision.
+	To int
+}
+
+type rollbackRunner struct {
+	cfg          *config.Config
+	k8sClientset kubernetes.Interface
+	store        *revision.Store
+}
+
+func newRollbackRunner(cfg *config.Config) (*rollbackRunner, error) {
+	clients, err := k8smeta.NewClients(cfg.KubeConfig)
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	return &rollbackRunner{
+		cfg:          cfg,
+		k8sClientset: clients.Clientset,
+		store:        revision.NewStore(cfg, clients.Clientset.CoreV1().ConfigMaps(cfg.Namespace)),
+	}, nil
+}
+
+func (r *rollbackRunner) listRevisions() ([]*revision.Revision, error) {
+	revisions, err := r.store.List()
+	if err != nil {
+		return nil, exitcode.Wrap(errors.Wrap(err, "error while listing the revisions of the environment"), exitcode.ClusterConnectivity)
+	}
+	return revisions, nil
+}
+
+// getTargetRevision returns the revision to roll back to.
+func getTargetRevision(revisions []*revision.Revision, opts *Options) (*revision.Revision, error) {
+	if opts.To == 0 {
+		if len(revisions) < 2 {
+			return nil, fmt.Errorf("the environment has no revision before the latest revision to roll back to")
+		}
+		return revisions[len(revisions)-2], nil
+	}
+	target := revision.Find(revisions, opts.To)
+	if target == nil {
+		return nil, fmt.Errorf("the environment has no revision %d, see rollback --list for the revisions that are kept", opts.To)
+	}
+	return target, nil
+}
+
+// getPodServiceNames returns the names of the docker compose services whose pods are rolled back, which are the pod services (see
+// config.Service.PodService) of the docker compose services in the filter.
+func (r *rollbackRunner) getPodServiceNames() []string {
+	set := map[string]bool{}
+	for _, service := range r.cfg.Services {
+		if r.cfg.MatchesFilter(service) {
+			set[service.PodService().Name()] = true
+		}
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// recreatePod deletes the existing pod of a docker compose service (if any), waits until it is gone, and creates the pod of the revision.
+func (r *rollbackRunner) recreatePod(service *config.Service, pod *v1.Pod) error {
+	namespace := r.cfg.GetNamespace(service)
+	client := r.k8sClientset.CoreV1().Pods(namespace)
+	existing, err := client.Get(pod.ObjectMeta.Name, metav1.GetOptions{})
+	switch {
+	case k8sError.IsNotFound(err):
+	case err != nil:
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	default:
+		err = k8smeta.ValidateOwnership(r.cfg, "Pod", &existing.ObjectMeta)
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.Config)
+		}
+		err = client.Delete(pod.ObjectMeta.Name, &metav1.DeleteOptions{
+			GracePeriodSeconds: k8smeta.GetGracePeriodSeconds(service),
+		})
+		if err != nil && !k8sError.IsNotFound(err) {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		for {
+			_, err = client.Get(pod.ObjectMeta.Name, metav1.GetOptions{})
+			if k8sError.IsNotFound(err) {
+				break
+			}
+			if err != nil {
+				return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+			}
+			time.Sleep(deletionPollInterval)
+		}
+	}
+	_, err = k8smeta.CreatePod(client, r.k8sClientset.CoreV1().RESTClient(), namespace, pod.DeepCopy())
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	return nil
+}
+
+// isSamePod returns true if and only if two pods of a docker compose service in revisions have the same specification and configuration.
+func isSamePod(a, b *revision.Service) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	for _, name := range []string{k8smeta.SpecHashAnnotationName, k8smeta.ConfigHashAnnotationName} {
+		if a.Pod.ObjectMeta.Annotations[name] != b.Pod.ObjectMeta.Annotations[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// forgetHashes removes the hashes of the rolled back docker compose services from the state of the environment, so that the next
+// incremental up (see up.Options.Incremental) redeploys their pods from the docker compose files.
+func (r *rollbackRunner) forgetHashes(rolledBack map[string]*revision.Service) error {
+	store := state.NewStore(r.cfg, r.k8sClientset.CoreV1().ConfigMaps(r.cfg.Namespace))
+	s, err := store.Load()
+	if err == nil {
+		for name, service := range rolledBack {
+			if stateService := s.Services[name]; stateService != nil {
+				stateService.Hash = ""
+				stateService.Image = service.Image
+			}
+		}
+		err = store.Save(s)
+	}
+	if err != nil {
+		return exitcode.Wrap(errors.Wrap(err, "error while saving the state of the environment"), exitcode.ClusterConnectivity)
+	}
+	return nil
+}
+
+func (r *rollbackRunner) run(opts *Options) error {
+	revisions, err := r.listRevisions()
+	if err != nil {
+		return err
+	}
+	target, err := getTargetRevision(revisions, opts)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	latest := revision.Latest(revisions)
+	rolledBack := map[string]*revision.Service{}
+	for _, name := range r.getPodServiceNames() {
+		targetService := target.Services[name]
+		switch {
+		case targetService == nil:
+			log.Warnf("docker compose service %s has no pod in revision %d, so it is not rolled back", name, target.Number)
+		case isSamePod(targetService, latest.Services[name]):
+			log.Infof("the pod of docker compose service %s is the same in revision %d", name, target.Number)
+		default:
+			err = r.recreatePod(r.cfg.Services[name], targetService.Pod)
+			if err != nil {
+				return err
+			}
+			log.Infof("rolled back pod %s to revision %d (image %s)", targetService.Pod.ObjectMeta.Name, target.Number, targetService.Image)
+			rolledBack[name] = targetService
+		}
+	}
+	if len(rolledBack) == 0 {
+		return nil
+	}
+	next := latest.Next(r.cfg, rolledBack)
+	next.RollbackOf = target.Number
+	err = r.store.Save(next, revisions)
+	if err != nil {
+		return exitcode.Wrap(errors.Wrap(err, "error while recording the revision of the environment"), exitcode.ClusterConnectivity)
+	}
+	return r.forgetHashes(rolledBack)
+}
+
+// Run rolls back the pods of the docker compose services in the filter of cfg (and the pods of their sidecars) to a previous revision,
+// by deleting their pods and creating the pods of the revision. The result is recorded as a new revision, so that the rollback can itself
+// be rolled back. Only pods are rolled back: Kubernetes services, Secrets and other objects keep their current configuration.
+func Run(cfg *config.Config, opts *Options) error {
+	r, err := newRollbackRunner(cfg)
+	if err != nil {
+		return err
+	}
+	return r.run(opts)
+}
+
+// List writes a table of the revisions of the environment to w, from oldest to latest.
+func List(cfg *config.Config, w io.Writer) error {
+	r, err := newRollbackRunner(cfg)
+	if err != nil {
+		return err
+	}
+	revisions, err := r.listRevisions()
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "REVISION\tTIME\tSERVICES")
+	for _, rev := range revisions {
+		var services []string
+		for name, service := range rev.Services {
+			services = append(services, name+"="+service.Image)
+		}
+		sort.Strings(services)
+		description := strings.Join(services, " ")
+		if rev.RollbackOf > 0 {
+			description = fmt.Sprintf("rollback to %d: %s", rev.RollbackOf, description)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", rev.Number, rev.Time.Format(time.RFC3339), description)
+	}
+	return tw.Flush()
+}
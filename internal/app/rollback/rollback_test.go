@@ -0,0 +1,186 @@
+package rollback
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/revision"
+	"github.com/kube-compose/kube-compose/internal/app/state"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+// withFakeClientset runs cb with rollback connecting to clientset instead of a cluster.
+func withFakeClientset(clientset *fake.Clientset, cb func()) {
+	orig := k8smeta.NewClients
+	defer func() {
+		k8smeta.NewClients = orig
+	}()
+	k8smeta.NewClients = func(_ *rest.Config) (*k8smeta.Clients, error) {
+		return &k8smeta.Clients{
+			Clientset: clientset,
+		}, nil
+	}
+	cb()
+}
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{
+		EnvironmentID:    "test",
+		EnvironmentLabel: "env",
+		Namespace:        "default",
+	}
+	cfg.AddToFilter(cfg.AddService(&dockerComposeConfig.Service{
+		Name: "db",
+	}))
+	cfg.AddToFilter(cfg.AddService(&dockerComposeConfig.Service{
+		Name: "web",
+	}))
+	return cfg
+}
+
+// newTestRevisionService returns the pod of a docker compose service with an image, as up would have created it.
+func newTestRevisionService(cfg *config.Config, name, image string) *revision.Service {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Image: image,
+					Name:  name,
+				},
+			},
+		},
+	}
+	k8smeta.InitObjectMeta(cfg, &pod.ObjectMeta, cfg.Services[name])
+	pod.ObjectMeta.Annotations[k8smeta.SpecHashAnnotationName] = image
+	return &revision.Service{
+		Image: image,
+		Pod:   pod,
+	}
+}
+
+// saveTestRevisions saves a revision with db:1 and web:1, and a revision with db:1 and web:2, and creates the pods of the latest revision.
+func saveTestRevisions(t *testing.T, cfg *config.Config, clientset *fake.Clientset) {
+	store := revision.NewStore(cfg, clientset.CoreV1().ConfigMaps(cfg.Namespace))
+	var revisions []*revision.Revision
+	for _, webImage := range []string{"web:1", "web:2"} {
+		r := revision.Latest(revisions).Next(cfg, map[string]*revision.Service{
+			"db":  newTestRevisionService(cfg, "db", "db:1"),
+			"web": newTestRevisionService(cfg, "web", webImage),
+		})
+		err := store.Save(r, revisions)
+		if err != nil {
+			t.Error(err)
+		}
+		revisions = append(revisions, r)
+	}
+	for _, service := range revisions[1].Services {
+		pod := service.Pod.DeepCopy()
+		pod.ObjectMeta.Namespace = cfg.Namespace
+		_, err := clientset.CoreV1().Pods(cfg.Namespace).Create(pod)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func getTestPodImage(clientset *fake.Clientset, cfg *config.Config, name string) string {
+	pod, err := clientset.CoreV1().Pods(cfg.Namespace).Get(k8smeta.GetK8sName(cfg.Services[name], cfg), metav1.GetOptions{})
+	if err != nil {
+		return err.Error()
+	}
+	return pod.Spec.Containers[0].Image
+}
+
+func TestRun_Previous(t *testing.T) {
+	cfg := newTestConfig()
+	clientset := fake.NewSimpleClientset()
+	saveTestRevisions(t, cfg, clientset)
+	err := state.NewStore(cfg, clientset.CoreV1().ConfigMaps(cfg.Namespace)).Save(&state.State{
+		Services: map[string]*state.Service{
+			"web": {Hash: "hash", Image: "web:2"},
+		},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	withFakeClientset(clientset, func() {
+		err = Run(cfg, &Options{})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if image := getTestPodImage(clientset, cfg, "web"); image != "web:1" {
+			t.Error(image)
+		}
+		revisions, err := revision.NewStore(cfg, clientset.CoreV1().ConfigMaps(cfg.Namespace)).List()
+		if err != nil || len(revisions) != 3 || revisions[2].RollbackOf != 1 || revisions[2].Services["web"].Image != "web:1" ||
+			revisions[2].Services["db"].Image != "db:1" {
+			t.Error(revisions, err)
+		}
+		s, err := state.NewStore(cfg, clientset.CoreV1().ConfigMaps(cfg.Namespace)).Load()
+		if err != nil || s.Services["web"].Hash != "" || s.Services["web"].Image != "web:1" {
+			t.Error(s, err)
+		}
+	})
+}
+
+func TestRun_ToRevisionFiltered(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.ClearFilter()
+	cfg.AddToFilter(cfg.Services["db"])
+	clientset := fake.NewSimpleClientset()
+	saveTestRevisions(t, cfg, clientset)
+	withFakeClientset(clientset, func() {
+		err := Run(cfg, &Options{To: 1})
+		if err != nil {
+			t.Error(err)
+		}
+		// The pod of db is the same in both revisions, and web is not in the filter, so nothing is rolled back.
+		if image := getTestPodImage(clientset, cfg, "web"); image != "web:2" {
+			t.Error(image)
+		}
+		revisions, err := revision.NewStore(cfg, clientset.CoreV1().ConfigMaps(cfg.Namespace)).List()
+		if err != nil || len(revisions) != 2 {
+			t.Error(revisions, err)
+		}
+	})
+}
+
+func TestRun_NoRevision(t *testing.T) {
+	cfg := newTestConfig()
+	clientset := fake.NewSimpleClientset()
+	saveTestRevisions(t, cfg, clientset)
+	withFakeClientset(clientset, func() {
+		err := Run(cfg, &Options{To: 5})
+		if err == nil {
+			t.Fail()
+		}
+	})
+	withFakeClientset(fake.NewSimpleClientset(), func() {
+		err := Run(cfg, &Options{})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestList_Success(t *testing.T) {
+	cfg := newTestConfig()
+	clientset := fake.NewSimpleClientset()
+	saveTestRevisions(t, cfg, clientset)
+	withFakeClientset(clientset, func() {
+		var buffer bytes.Buffer
+		err := List(cfg, &buffer)
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		if err != nil || len(lines) != 3 || !strings.HasPrefix(lines[2], "2 ") || !strings.HasSuffix(lines[2], "db=db:1 web=web:2") {
+			t.Error(buffer.String(), err)
+		}
+	})
+}
@@ -0,0 +1,364 @@
+package rollout
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The value of k8smeta.TrackLabelName of the pods of canaries.
+const trackCanary = "canary"
+
+var (
+	// pollInterval is the interval at which promote checks whether the pod of a docker compose service is deleted or ready.
+	pollInterval = time.Second
+	// readyTimeout is the maximum time that promote waits until the new pod of a docker compose service is ready.
+	readyTimeout = 5 * time.Minute
+)
+
+// Options is a struct of options for Start.
+type Options struct {
+	// The image of the canary.
+	Image string
+	// The requested percentage of the connections to the Kubernetes service of the docker compose service that the canary receives,
+	// between 1 and 99.
+	Percentage int
+}
+
+type rolloutRunner struct {
+	cfg          *config.Config
+	k8sClientset kubernetes.Interface
+	namespace    string
+	service      *config.Service
+}
+
+func newRolloutRunner(cfg *config.Config, service *config.Service) (*rolloutRunner, error) {
+	if service.IsSidecar() {
+		return nil, exitcode.Wrap(fmt.Errorf("docker compose service %s is a sidecar of the pod of docker compose service %s, which can be "+
+			"rolled out instead", service.Name(), service.PodService().Name()), exitcode.Config)
+	}
+	if len(service.Ports) == 0 {
+		return nil, exitcode.Wrap(fmt.Errorf("docker compose service %s has no ports, so it has no k8s service that a canary can receive "+
+			"connections from", service.Name()), exitcode.Config)
+	}
+	clients, err := k8smeta.NewClients(cfg.KubeConfig)
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	return &rolloutRunner{
+		cfg:          cfg,
+		k8sClientset: clients.Clientset,
+		namespace:    cfg.GetNamespace(service),
+		service:      service,
+	}, nil
+}
+
+// getCanaryName returns the name of the Deployment of the canary of a docker compose service.
+func getCanaryName(cfg *config.Config, service *config.Service) string {
+	return k8smeta.GetResourceName(cfg, service.NameEscaped+"-canary", validation.DNS1123LabelMaxLength)
+}
+
+// getCanaryReplicas returns the number of pods of a canary, such that the fraction of the pods of the docker compose service that run the
+// canary is closest to the requested percentage. The docker compose service has a single pod besides the canary, so the canary has at
+// least one pod. The percentage of the connections that the canary receives is also returned.
+func getCanaryReplicas(percentage int) (int32, int) {
+	replicas := int32(math.Round(float64(percentage) / float64(100-percentage)))
+	if replicas < 1 {
+		replicas = 1
+	}
+	return replicas, int(math.Round(100 * float64(replicas) / float64(replicas+1)))
+}
+
+// getStablePod returns the pod of the docker compose service, which up created.
+func (r *rolloutRunner) getStablePod() (*v1.Pod, error) {
+	name := k8smeta.GetK8sName(r.service, r.cfg)
+	pod, err := r.k8sClientset.CoreV1().Pods(r.namespace).Get(name, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		return nil, exitcode.Wrap(fmt.Errorf("docker compose service %s has no pod, run up first", r.service.Name()), exitcode.Config)
+	}
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	return pod, exitcode.Wrap(k8smeta.ValidateOwnership(r.cfg, "Pod", &pod.ObjectMeta), exitcode.Config)
+}
+
+// setContainerImage sets the image of the container of the docker compose service in a pod spec.
+func (r *rolloutRunner) setContainerImage(podSpec *v1.PodSpec, image string) {
+	containerName := k8smeta.GetShortName(r.service)
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == containerName {
+			podSpec.Containers[i].Image = image
+			// The image is pulled by the cluster, so an image in the docker daemon of the cluster is not required.
+			podSpec.Containers[i].ImagePullPolicy = v1.PullIfNotPresent
+		}
+	}
+}
+
+// newCanary returns the Deployment of the canary of the docker compose service. The pods of the canary have the spec of the stable pod,
+// except for the image of the docker compose service's container, and have the labels that the k8s service of the docker compose service
+// selects. The pods of the canary do not have the annotation of the docker compose service, so that up ignores them.
+func (r *rolloutRunner) newCanary(stablePod *v1.Pod, image string, replicas int32) *appsV1.Deployment {
+	deployment := &appsV1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+	}
+	k8smeta.InitObjectMeta(r.cfg, &deployment.ObjectMeta, r.service)
+	deployment.ObjectMeta.Name = getCanaryName(r.cfg, r.service)
+	podLabels := map[string]string{}
+	for key, value := range stablePod.ObjectMeta.Labels {
+		podLabels[key] = value
+	}
+	podLabels[k8smeta.TrackLabelName] = trackCanary
+	podAnnotations := map[string]string{}
+	for key, value := range stablePod.ObjectMeta.Annotations {
+		podAnnotations[key] = value
+	}
+	delete(podAnnotations, k8smeta.AnnotationName)
+	delete(podAnnotations, k8smeta.ConfigHashAnnotationName)
+	delete(podAnnotations, k8smeta.SpecHashAnnotationName)
+	podSpec := *stablePod.Spec.DeepCopy()
+	podSpec.NodeName = ""
+	// Pods of Deployments must always be restarted.
+	podSpec.RestartPolicy = v1.RestartPolicyAlways
+	r.setContainerImage(&podSpec, image)
+	deployment.Spec = appsV1.DeploymentSpec{
+		Replicas: &replicas,
+		Selector: &metav1.LabelSelector{
+			MatchLabels: k8smeta.InitCommonLabels(r.cfg, r.service, map[string]string{
+				k8smeta.TrackLabelName: trackCanary,
+			}),
+		},
+		Template: v1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: podAnnotations,
+				Labels:      podLabels,
+			},
+			Spec: podSpec,
+		},
+	}
+	return deployment
+}
+
+// start creates or updates the canary of the docker compose service.
+func (r *rolloutRunner) start(opts *Options) error {
+	stablePod, err := r.getStablePod()
+	if err != nil {
+		return err
+	}
+	if _, ok := stablePod.ObjectMeta.Annotations[k8smeta.CSIVolumesAnnotationName]; ok {
+		// The typed clients cannot represent the sources of CSI volumes (see k8smeta.SetCSIVolumes), so the pods of the canary would lack them.
+		return exitcode.Wrap(fmt.Errorf("docker compose service %s has CSI volumes, which canaries do not support", r.service.Name()),
+			exitcode.Config)
+	}
+	replicas, percentage := getCanaryReplicas(opts.Percentage)
+	canary := r.newCanary(stablePod, opts.Image, replicas)
+	client := r.k8sClientset.AppsV1().Deployments(r.namespace)
+	_, err = client.Create(canary)
+	if k8sError.IsAlreadyExists(err) {
+		var existing *appsV1.Deployment
+		existing, err = client.Get(canary.ObjectMeta.Name, metav1.GetOptions{})
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		err = k8smeta.ValidateOwnership(r.cfg, "Deployment", &existing.ObjectMeta)
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.Config)
+		}
+		existing.Spec.Replicas = canary.Spec.Replicas
+		existing.Spec.Template = canary.Spec.Template
+		_, err = client.Update(existing)
+	}
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	log.Infof("rolled out image %s to canary Deployment %s with %d pods", opts.Image, canary.ObjectMeta.Name, replicas)
+	if percentage != opts.Percentage {
+		log.Warnf("the canary receives about %d%% instead of %d%% of the connections, because docker compose service %s has a single "+
+			"pod besides the canary", percentage, opts.Percentage, r.service.Name())
+	}
+	return nil
+}
+
+// getCanary returns the Deployment of the canary of the docker compose service, or nil if there is no canary.
+func (r *rolloutRunner) getCanary() (*appsV1.Deployment, error) {
+	canary, err := r.k8sClientset.AppsV1().Deployments(r.namespace).Get(getCanaryName(r.cfg, r.service), metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	return canary, exitcode.Wrap(k8smeta.ValidateOwnership(r.cfg, "Deployment", &canary.ObjectMeta), exitcode.Config)
+}
+
+// deleteCanary deletes the Deployment of the canary of the docker compose service, and thereby its pods.
+func (r *rolloutRunner) deleteCanary(canary *appsV1.Deployment) error {
+	propagationPolicy := metav1.DeletePropagationBackground
+	err := r.k8sClientset.AppsV1().Deployments(r.namespace).Delete(canary.ObjectMeta.Name, &metav1.DeleteOptions{
+		PropagationPolicy: &propagationPolicy,
+	})
+	if err != nil && !k8sError.IsNotFound(err) {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	log.Infof("deleted canary Deployment %s", canary.ObjectMeta.Name)
+	return nil
+}
+
+// abort deletes the canary of the docker compose service.
+func (r *rolloutRunner) abort() error {
+	canary, err := r.getCanary()
+	if err != nil {
+		return err
+	}
+	if canary == nil {
+		log.Infof("docker compose service %s has no canary", r.service.Name())
+		return nil
+	}
+	return r.deleteCanary(canary)
+}
+
+// getCanaryImage returns the image of the container of the docker compose service in the pods of a canary.
+func (r *rolloutRunner) getCanaryImage(canary *appsV1.Deployment) (string, error) {
+	containerName := k8smeta.GetShortName(r.service)
+	for _, container := range canary.Spec.Template.Spec.Containers {
+		if container.Name == containerName {
+			return container.Image, nil
+		}
+	}
+	return "", k8smeta.ErrorResourcesModifiedExternally()
+}
+
+// recreatePod replaces the pod of the docker compose service with a pod that has the same metadata and spec, except for the image of the
+// docker compose service's container. The annotations of the pod are kept, so that up only redeploys the pod once the docker compose
+// service is changed (e.g. to the promoted image).
+func (r *rolloutRunner) recreatePod(stablePod *v1.Pod, image string) error {
+	client := r.k8sClientset.CoreV1().Pods(r.namespace)
+	pod := &v1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: stablePod.ObjectMeta.Annotations,
+			Labels:      stablePod.ObjectMeta.Labels,
+			Name:        stablePod.ObjectMeta.Name,
+			Namespace:   stablePod.ObjectMeta.Namespace,
+		},
+		Spec: *stablePod.Spec.DeepCopy(),
+	}
+	r.setContainerImage(&pod.Spec, image)
+	err := client.Delete(pod.ObjectMeta.Name, &metav1.DeleteOptions{})
+	if err != nil && !k8sError.IsNotFound(err) {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	for {
+		_, err = client.Get(pod.ObjectMeta.Name, metav1.GetOptions{})
+		if k8sError.IsNotFound(err) {
+			break
+		}
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		time.Sleep(pollInterval)
+	}
+	// The sources of CSI volumes are only recorded in an annotation of the pod (see k8smeta.SetCSIVolumes).
+	_, err = k8smeta.CreatePod(client, r.k8sClientset.CoreV1().RESTClient(), r.namespace, pod)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	log.Infof("recreated pod %s with image %s", pod.ObjectMeta.Name, image)
+	return nil
+}
+
+// waitForPodReady waits until the pod of the docker compose service is ready.
+func (r *rolloutRunner) waitForPodReady(name string) error {
+	deadline := time.Now().Add(readyTimeout)
+	for {
+		pod, err := r.k8sClientset.CoreV1().Pods(r.namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == v1.PodReady && condition.Status == v1.ConditionTrue {
+				return nil
+			}
+		}
+		if !time.Now().Before(deadline) {
+			return exitcode.Wrap(fmt.Errorf("timed out after %v waiting until pod %s is ready, the canary was not deleted", readyTimeout,
+				name), exitcode.ReadinessTimeout)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// promote replaces the pod of the docker compose service with a pod that runs the image of the canary, and then deletes the canary. The
+// canary keeps receiving connections until the new pod is ready.
+func (r *rolloutRunner) promote() error {
+	canary, err := r.getCanary()
+	if err != nil {
+		return err
+	}
+	if canary == nil {
+		return exitcode.Wrap(fmt.Errorf("docker compose service %s has no canary to promote", r.service.Name()), exitcode.Config)
+	}
+	image, err := r.getCanaryImage(canary)
+	if err != nil {
+		return err
+	}
+	stablePod, err := r.getStablePod()
+	if err != nil {
+		return err
+	}
+	err = r.recreatePod(stablePod, image)
+	if err != nil {
+		return err
+	}
+	err = r.waitForPodReady(stablePod.ObjectMeta.Name)
+	if err != nil {
+		return err
+	}
+	return r.deleteCanary(canary)
+}
+
+// Start rolls out an image of a docker compose service to a canary: a Deployment whose pods receive a percentage of the connections to
+// the k8s service of the docker compose service. If the docker compose service already has a canary then its image and percentage are
+// updated.
+func Start(cfg *config.Config, service *config.Service, opts *Options) error {
+	r, err := newRolloutRunner(cfg, service)
+	if err != nil {
+		return err
+	}
+	return r.start(opts)
+}
+
+// Promote finishes the rollout of the canary of a docker compose service, by replacing the pod of the docker compose service with a pod
+// that runs the image of the canary and deleting the canary.
+func Promote(cfg *config.Config, service *config.Service) error {
+	r, err := newRolloutRunner(cfg, service)
+	if err != nil {
+		return err
+	}
+	return r.promote()
+}
+
+// Abort deletes the canary of a docker compose service, if any.
+func Abort(cfg *config.Config, service *config.Service) error {
+	r, err := newRolloutRunner(cfg, service)
+	if err != nil {
+		return err
+	}
+	return r.abort()
+}
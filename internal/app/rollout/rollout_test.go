@@ -0,0 +1,243 @@
+package rollout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	appsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+// withFakeClientset runs cb with rollout connecting to clientset instead of a cluster.
+func withFakeClientset(clientset *fake.Clientset, cb func()) {
+	orig := k8smeta.NewClients
+	origPollInterval := pollInterval
+	defer func() {
+		k8smeta.NewClients = orig
+		pollInterval = origPollInterval
+	}()
+	k8smeta.NewClients = func(_ *rest.Config) (*k8smeta.Clients, error) {
+		return &k8smeta.Clients{
+			Clientset: clientset,
+		}, nil
+	}
+	pollInterval = time.Millisecond
+	cb()
+}
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{
+		EnvironmentID:    "test",
+		EnvironmentLabel: "env",
+		Namespace:        "default",
+	}
+	service := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "web",
+	})
+	service.Ports = []config.Port{
+		{Port: 8080, Protocol: "tcp"},
+	}
+	return cfg
+}
+
+// newTestPod returns the pod of the docker compose service web, as up would have created it.
+func newTestPod(cfg *config.Config) *v1.Pod {
+	service := cfg.Services["web"]
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Image: "web:1",
+					Name:  k8smeta.GetShortName(service),
+				},
+			},
+			NodeName:      "node1",
+			RestartPolicy: v1.RestartPolicyNever,
+		},
+	}
+	k8smeta.InitObjectMeta(cfg, &pod.ObjectMeta, service)
+	pod.ObjectMeta.Namespace = cfg.Namespace
+	pod.ObjectMeta.Annotations[k8smeta.SpecHashAnnotationName] = "hash"
+	return pod
+}
+
+func getTestCanary(t *testing.T, clientset *fake.Clientset, cfg *config.Config) *appsV1.Deployment {
+	canary, err := clientset.AppsV1().Deployments(cfg.Namespace).Get(getCanaryName(cfg, cfg.Services["web"]), metav1.GetOptions{})
+	if err != nil {
+		t.Error(err)
+		return nil
+	}
+	return canary
+}
+
+func TestGetCanaryReplicas(t *testing.T) {
+	for _, testCase := range []struct {
+		percentage         int
+		replicas           int32
+		expectedPercentage int
+	}{
+		{1, 1, 50},
+		{50, 1, 50},
+		{70, 2, 67},
+		{80, 4, 80},
+		{99, 99, 99},
+	} {
+		replicas, percentage := getCanaryReplicas(testCase.percentage)
+		if replicas != testCase.replicas || percentage != testCase.expectedPercentage {
+			t.Error(testCase, replicas, percentage)
+		}
+	}
+}
+
+func TestStart_Success(t *testing.T) {
+	cfg := newTestConfig()
+	clientset := fake.NewSimpleClientset(newTestPod(cfg))
+	withFakeClientset(clientset, func() {
+		err := Start(cfg, cfg.Services["web"], &Options{Image: "web:2", Percentage: 80})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		canary := getTestCanary(t, clientset, cfg)
+		if canary == nil {
+			return
+		}
+		template := canary.Spec.Template
+		if *canary.Spec.Replicas != 4 || canary.Spec.Selector.MatchLabels[k8smeta.TrackLabelName] != trackCanary ||
+			template.ObjectMeta.Labels[k8smeta.TrackLabelName] != trackCanary || template.ObjectMeta.Labels["app"] != "web" {
+			t.Error(canary)
+		}
+		if _, ok := template.ObjectMeta.Annotations[k8smeta.AnnotationName]; ok {
+			t.Error(template.ObjectMeta.Annotations)
+		}
+		if template.Spec.Containers[0].Image != "web:2" || template.Spec.NodeName != "" || template.Spec.RestartPolicy != v1.RestartPolicyAlways {
+			t.Error(template.Spec)
+		}
+	})
+}
+
+func TestStart_UpdatesCanary(t *testing.T) {
+	cfg := newTestConfig()
+	clientset := fake.NewSimpleClientset(newTestPod(cfg))
+	withFakeClientset(clientset, func() {
+		_ = Start(cfg, cfg.Services["web"], &Options{Image: "web:2", Percentage: 50})
+		err := Start(cfg, cfg.Services["web"], &Options{Image: "web:3", Percentage: 80})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		canary := getTestCanary(t, clientset, cfg)
+		if canary != nil && (*canary.Spec.Replicas != 4 || canary.Spec.Template.Spec.Containers[0].Image != "web:3") {
+			t.Error(canary)
+		}
+	})
+}
+
+func TestStart_NoPod(t *testing.T) {
+	cfg := newTestConfig()
+	withFakeClientset(fake.NewSimpleClientset(), func() {
+		err := Start(cfg, cfg.Services["web"], &Options{Image: "web:2", Percentage: 20})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestStart_NoPorts(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Services["web"].Ports = nil
+	err := Start(cfg, cfg.Services["web"], &Options{Image: "web:2", Percentage: 20})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestPromote_Success(t *testing.T) {
+	cfg := newTestConfig()
+	tracker := k8sTesting.NewObjectTracker(scheme.Scheme, scheme.Codecs.UniversalDecoder())
+	_ = tracker.Add(newTestPod(cfg))
+	clientset := &fake.Clientset{}
+	clientset.AddReactor("*", "*", k8sTesting.ObjectReaction(tracker))
+	// The fake clientset does not run pods, so pods are ready as soon as they exist.
+	clientset.PrependReactor("get", "pods", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		getAction := action.(k8sTesting.GetAction)
+		obj, err := tracker.Get(getAction.GetResource(), getAction.GetNamespace(), getAction.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		pod := obj.(*v1.Pod)
+		pod.Status.Conditions = []v1.PodCondition{
+			{Type: v1.PodReady, Status: v1.ConditionTrue},
+		}
+		return true, pod, nil
+	})
+	withFakeClientset(clientset, func() {
+		err := Start(cfg, cfg.Services["web"], &Options{Image: "web:2", Percentage: 20})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		err = Promote(cfg, cfg.Services["web"])
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		pod, err := clientset.CoreV1().Pods(cfg.Namespace).Get(k8smeta.GetK8sName(cfg.Services["web"], cfg), metav1.GetOptions{})
+		if err != nil || pod.Spec.Containers[0].Image != "web:2" || pod.ObjectMeta.Annotations[k8smeta.SpecHashAnnotationName] != "hash" {
+			t.Error(pod, err)
+		}
+		deploymentList, err := clientset.AppsV1().Deployments(cfg.Namespace).List(metav1.ListOptions{})
+		if err != nil || len(deploymentList.Items) != 0 {
+			t.Error(deploymentList, err)
+		}
+	})
+}
+
+func TestPromote_NoCanary(t *testing.T) {
+	cfg := newTestConfig()
+	withFakeClientset(fake.NewSimpleClientset(newTestPod(cfg)), func() {
+		err := Promote(cfg, cfg.Services["web"])
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestAbort_Success(t *testing.T) {
+	cfg := newTestConfig()
+	clientset := fake.NewSimpleClientset(newTestPod(cfg))
+	withFakeClientset(clientset, func() {
+		_ = Start(cfg, cfg.Services["web"], &Options{Image: "web:2", Percentage: 20})
+		err := Abort(cfg, cfg.Services["web"])
+		if err != nil {
+			t.Error(err)
+		}
+		deploymentList, err := clientset.AppsV1().Deployments(cfg.Namespace).List(metav1.ListOptions{})
+		if err != nil || len(deploymentList.Items) != 0 {
+			t.Error(deploymentList, err)
+		}
+		pod, err := clientset.CoreV1().Pods(cfg.Namespace).Get(k8smeta.GetK8sName(cfg.Services["web"], cfg), metav1.GetOptions{})
+		if err != nil || pod.Spec.Containers[0].Image != "web:1" {
+			t.Error(pod, err)
+		}
+	})
+}
+
+func TestAbort_NoCanary(t *testing.T) {
+	cfg := newTestConfig()
+	withFakeClientset(fake.NewSimpleClientset(), func() {
+		err := Abort(cfg, cfg.Services["web"])
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
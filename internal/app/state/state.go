@@ -0,0 +1,231 @@
+// Package state records what up deployed for an environment of a docker compose project, so that down can act on the deployed objects
+// even when the docker compose files have since changed. The state is stored in a ConfigMap, or in a local file.
+package state
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// configMapKey is the key of the data of the ConfigMap that holds the JSON encoded state.
+const configMapKey = "state.json"
+
+// Service is the state of a docker compose service that was deployed.
+type Service struct {
+	// The names of the docker compose services the service depends on, so that pods can be deleted in reverse dependency order.
+	DependsOn          []string `json:"dependsOn,omitempty"`
+	GracePeriodSeconds *int64   `json:"gracePeriodSeconds,omitempty"`
+	// The hash of the effective configuration of the pod, so that up can skip docker compose services that did not change.
+	Hash string `json:"hash,omitempty"`
+	// The image of the pod, which is resolved to a digest if the image was pushed to a registry.
+	Image string `json:"image"`
+	// The ID of the local docker image that the image of the pod was created from, if any.
+	ImageID string `json:"imageID,omitempty"`
+	// The namespace of the pod and Kubernetes service, if the docker compose service overrides the namespace of the environment.
+	Namespace string `json:"namespace,omitempty"`
+	PodName   string `json:"podName"`
+	// The name of the Secret that holds the resolved secret environment variables of the pod, if any.
+	SecretName string `json:"secretName,omitempty"`
+	// The name of the Kubernetes service, if any.
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+// Object identifies an object other than a pod, Kubernetes service or Secret that was deployed.
+type Object struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// State is a record of what was deployed for an environment of a docker compose project.
+type State struct {
+	// The objects of external docker compose secrets (such as ExternalSecret objects), Ingresses, Certificates and the ExternalName
+	// services of external services.
+	Objects []Object `json:"objects,omitempty"`
+	// The deployed docker compose services, keyed by name.
+	Services map[string]*Service `json:"services"`
+}
+
+// AddObject records that an object was deployed, unless it was already recorded.
+func (s *State) AddObject(kind, name string) {
+	for _, obj := range s.Objects {
+		if obj.Kind == kind && obj.Name == name {
+			return
+		}
+	}
+	s.Objects = append(s.Objects, Object{
+		Kind: kind,
+		Name: name,
+	})
+	sort.Slice(s.Objects, func(i, j int) bool {
+		if s.Objects[i].Kind != s.Objects[j].Kind {
+			return s.Objects[i].Kind < s.Objects[j].Kind
+		}
+		return s.Objects[i].Name < s.Objects[j].Name
+	})
+}
+
+// RemoveObject removes an object from the recorded objects, if it is recorded.
+func (s *State) RemoveObject(kind, name string) {
+	for i, obj := range s.Objects {
+		if obj.Kind == kind && obj.Name == name {
+			s.Objects = append(s.Objects[:i], s.Objects[i+1:]...)
+			return
+		}
+	}
+}
+
+// IsEmpty returns true if and only if nothing is recorded as deployed.
+func (s *State) IsEmpty() bool {
+	return len(s.Services) == 0 && len(s.Objects) == 0
+}
+
+// Store loads and saves the state.
+type Store interface {
+	// Load loads the state. If no state was saved then an empty state is returned.
+	Load() (*State, error)
+	// Save saves the state. Saving an empty state removes the state, so that down leaves nothing behind.
+	Save(s *State) error
+}
+
+func decode(data []byte) (*State, error) {
+	s := &State{}
+	if len(data) > 0 {
+		err := json.Unmarshal(data, s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s.Services == nil {
+		s.Services = map[string]*Service{}
+	}
+	return s, nil
+}
+
+type configMapStore struct {
+	cfg    *config.Config
+	client clientV1.ConfigMapInterface
+	name   string
+}
+
+// NewConfigMapStore returns a store that saves the state in a ConfigMap of the namespace of the environment. The ConfigMap is named
+// after the project and environment, and is labelled like the other resources of the environment.
+func NewConfigMapStore(cfg *config.Config, client clientV1.ConfigMapInterface) Store {
+	return &configMapStore{
+		cfg:    cfg,
+		client: client,
+		name:   k8smeta.GetResourceName(cfg, "kube-compose-state", validation.DNS1123SubdomainMaxLength),
+	}
+}
+
+func (c *configMapStore) Load() (*State, error) {
+	configMap, err := c.client.Get(c.name, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		return decode(nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = k8smeta.ValidateOwnership(c.cfg, "ConfigMap", &configMap.ObjectMeta)
+	if err != nil {
+		return nil, err
+	}
+	return decode([]byte(configMap.Data[configMapKey]))
+}
+
+func (c *configMapStore) Save(s *State) error {
+	if s.IsEmpty() {
+		err := c.client.Delete(c.name, &metav1.DeleteOptions{})
+		if k8sError.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: k8smeta.InitEnvironmentLabels(c.cfg, nil),
+			Name:   c.name,
+		},
+		Data: map[string]string{
+			configMapKey: string(data),
+		},
+	}
+	_, err = c.client.Create(configMap)
+	if k8sError.IsAlreadyExists(err) {
+		var existing *v1.ConfigMap
+		existing, err = c.client.Get(c.name, metav1.GetOptions{})
+		if err == nil {
+			existing.Data = configMap.Data
+			_, err = c.client.Update(existing)
+		}
+	}
+	return err
+}
+
+type fileStore struct {
+	file string
+}
+
+// NewFileStore returns a store that saves the state in a local file. Since files cannot be removed through package fs, an empty state
+// is saved as a file without services.
+func NewFileStore(file string) Store {
+	return &fileStore{
+		file: file,
+	}
+}
+
+func (f *fileStore) Load() (*State, error) {
+	fd, err := fs.OS.Open(f.file)
+	if os.IsNotExist(err) {
+		return decode(nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(fd)
+	util.CloseAndLogError(fd)
+	if err != nil {
+		return nil, err
+	}
+	return decode(data)
+}
+
+func (f *fileStore) Save(s *State) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	fd, err := fs.OS.Create(f.file)
+	if err != nil {
+		return err
+	}
+	_, err = fd.Write(append(data, '\n'))
+	if err != nil {
+		util.CloseAndLogError(fd)
+		return err
+	}
+	return fd.Close()
+}
+
+// NewStore returns a file store if cfg.StateFile is set, and a ConfigMap store otherwise.
+func NewStore(cfg *config.Config, client clientV1.ConfigMapInterface) Store {
+	if cfg.StateFile != "" {
+		return NewFileStore(cfg.StateFile)
+	}
+	return NewConfigMapStore(cfg, client)
+}
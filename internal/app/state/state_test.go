@@ -0,0 +1,83 @@
+package state
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func withMockFS(vfs fs.VirtualFileSystem, cb func()) {
+	orig := fs.OS
+	defer func() {
+		fs.OS = orig
+	}()
+	fs.OS = vfs
+	cb()
+}
+
+func TestFileStore_LoadNotExists(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project": {
+			Mode: 0755 | os.ModeDir,
+		},
+	}), func() {
+		s, err := NewFileStore("/project/state.json").Load()
+		if err != nil || !s.IsEmpty() || s.Services == nil {
+			t.Error(s, err)
+		}
+	})
+}
+
+func TestFileStore_SaveAndLoad(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project": {
+			Mode: 0755 | os.ModeDir,
+		},
+	}), func() {
+		store := NewFileStore("/project/state.json")
+		s := &State{
+			Services: map[string]*Service{
+				"web": {
+					DependsOn: []string{"db"},
+					Image:     "registry/web@sha256:123",
+					PodName:   "web-env",
+				},
+			},
+		}
+		err := store.Save(s)
+		if err != nil {
+			t.Error(err)
+		}
+		loaded, err := store.Load()
+		if err != nil || !reflect.DeepEqual(loaded, s) {
+			t.Error(loaded, err)
+		}
+	})
+}
+
+func TestState_AddObject(t *testing.T) {
+	s := &State{}
+	s.AddObject("SecretProviderClass", "b")
+	s.AddObject("ExternalSecret", "a")
+	s.AddObject("SecretProviderClass", "b")
+	expected := []Object{
+		{Kind: "ExternalSecret", Name: "a"},
+		{Kind: "SecretProviderClass", Name: "b"},
+	}
+	if !reflect.DeepEqual(s.Objects, expected) {
+		t.Error(s.Objects)
+	}
+}
+
+func TestState_RemoveObject(t *testing.T) {
+	s := &State{}
+	s.AddObject("Service", "b")
+	s.AddObject("Service", "a")
+	s.RemoveObject("Service", "a")
+	s.RemoveObject("Ingress", "b")
+	if len(s.Objects) != 1 || s.Objects[0].Name != "b" {
+		t.Error(s.Objects)
+	}
+}
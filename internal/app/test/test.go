@@ -0,0 +1,74 @@
+package test
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/down"
+	"github.com/kube-compose/kube-compose/internal/app/up"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+)
+
+// validateService returns an error if a docker compose service cannot be run as the test service. The test service runs to completion
+// in a pod of its own, so it cannot be in a pod group, and no other service can depend on it.
+func validateService(cfg *config.Config, service *config.Service) error {
+	if service.PodGroup != nil {
+		return fmt.Errorf("service %s is in pod group %s, and therefore cannot be run as the test service", service.Name(),
+			service.PodGroup.Name)
+	}
+	names := make([]string, 0, len(cfg.Services))
+	for name := range cfg.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := cfg.Services[name].DockerComposeService.DependsOn[service.Name()]; ok {
+			return fmt.Errorf("service %s depends on service %s, and therefore %s cannot be run as the test service", name,
+				service.Name(), service.Name())
+		}
+	}
+	return nil
+}
+
+func run(cfg *config.Config, opts *up.Options, service *config.Service, out io.Writer) (int, error) {
+	cfg.ClearFilter()
+	for _, service2 := range cfg.Services {
+		if service2 != service {
+			cfg.AddToFilter(service2)
+		}
+	}
+	if len(cfg.Services) > 1 {
+		err := up.Run(cfg, opts)
+		if err != nil {
+			return 0, err
+		}
+	}
+	cfg.ClearFilter()
+	cfg.AddToFilter(service)
+	return up.RunJob(cfg, opts, service, out)
+}
+
+// Run deploys the docker compose services other than the test service, runs the test service as a Kubernetes Job once the services
+// that it depends on are running (see up.RunJob), and then deletes the environment, also if deploying or running the test service
+// failed. The logs of the test service are copied to out, and the exit code of its container is returned.
+func Run(cfg *config.Config, opts *up.Options, service *config.Service, out io.Writer) (int, error) {
+	err := validateService(cfg, service)
+	if err != nil {
+		return 0, exitcode.Wrap(err, exitcode.Config)
+	}
+	exitCode, err := run(cfg, opts, service, out)
+	cfg.ClearFilter()
+	for _, service2 := range cfg.Services {
+		cfg.AddToFilter(service2)
+	}
+	downErr := down.Run(cfg)
+	if err == nil {
+		err = downErr
+	} else if downErr != nil {
+		log.Error(downErr)
+	}
+	return exitCode, err
+}
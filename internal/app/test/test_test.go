@@ -0,0 +1,59 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+)
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name: "db",
+	})
+	app := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "app",
+	})
+	tests := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "tests",
+	})
+	app.DockerComposeService.DependsOn = map[string]dockerComposeConfig.ServiceHealthiness{
+		"db": dockerComposeConfig.ServiceHealthy,
+	}
+	tests.DockerComposeService.DependsOn = map[string]dockerComposeConfig.ServiceHealthiness{
+		"app": dockerComposeConfig.ServiceHealthy,
+	}
+	return cfg
+}
+
+func TestValidateService_Success(t *testing.T) {
+	cfg := newTestConfig()
+	err := validateService(cfg, cfg.Services["tests"])
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateService_HasDependents(t *testing.T) {
+	cfg := newTestConfig()
+	err := validateService(cfg, cfg.Services["db"])
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestValidateService_PodGroup(t *testing.T) {
+	cfg := newTestConfig()
+	tests, db := cfg.Services["tests"], cfg.Services["db"]
+	podGroup := &config.PodGroup{
+		Name:     "g",
+		Services: []*config.Service{db, tests},
+	}
+	tests.PodGroup = podGroup
+	db.PodGroup = podGroup
+	err := validateService(cfg, tests)
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,164 @@
+package up
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/builder/dockerignore"
+	"github.com/docker/docker/pkg/fileutils"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"github.com/pkg/errors"
+)
+
+const defaultDockerfile = "Dockerfile"
+
+// getBuildImageTag returns the tag of the image that is built for an app whose docker compose service has a build section. Like docker
+// compose, this is the image of the docker compose service, and otherwise a name that is unique to the service and the environment.
+func (u *upRunner) getBuildImageTag(a *app) string {
+	if a.composeService.DockerComposeService.Image != "" {
+		return a.composeService.DockerComposeService.Image
+	}
+	return k8smeta.GetK8sName(a.composeService, u.cfg)
+}
+
+// getBuildDockerfile returns the slash separated path of the Dockerfile of a build section relative to its build context, which is the
+// path of the Dockerfile in the build context that is sent to the docker daemon.
+func getBuildDockerfile(b *dockerComposeConfig.Build) (string, error) {
+	if b.Dockerfile == "" {
+		return defaultDockerfile, nil
+	}
+	if !filepath.IsAbs(b.Dockerfile) {
+		return filepath.ToSlash(filepath.Clean(b.Dockerfile)), nil
+	}
+	rel, err := filepath.Rel(b.Context, b.Dockerfile)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("the Dockerfile %#v is not within the build context %#v", b.Dockerfile, b.Context)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// readDockerignore returns the patterns of the .dockerignore file of a build context, or nil if it does not have one.
+func readDockerignore(contextDir string) ([]string, error) {
+	fd, err := fs.OS.Open(filepath.Join(contextDir, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(fd)
+	return dockerignore.ReadAll(fd)
+}
+
+// getBuildContext returns a tar archive of the files of a build context, excluding the files that match the patterns of its .dockerignore
+// file. Like docker, the Dockerfile and the .dockerignore file are never excluded.
+func getBuildContext(contextDir, dockerfile string) ([]byte, error) {
+	contextDir = filepath.Clean(contextDir)
+	excludes, err := readDockerignore(contextDir)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := fs.OS.Open(contextDir)
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(fd)
+	entries, err := fd.Readdir(0)
+	if err != nil {
+		return nil, err
+	}
+	var tarBuffer bytes.Buffer
+	tw := tar.NewWriter(&tarBuffer)
+	vol := filepath.VolumeName(contextDir)
+	h := &bindMountHostFileToTarHelper{
+		tw:                     tw,
+		renameTo:               ".",
+		rootHostFile:           contextDir,
+		rootHostFileVol:        vol,
+		rootHostFileWithoutVol: contextDir[len(vol):],
+		exclude: func(fileNameInTar string) (bool, error) {
+			if fileNameInTar == dockerfile || fileNameInTar == ".dockerignore" || len(excludes) == 0 {
+				return false, nil
+			}
+			return fileutils.Matches(filepath.FromSlash(fileNameInTar), excludes)
+		},
+	}
+	for _, entry := range entries {
+		err = h.runRecursive(entry, filepath.Join(contextDir, entry.Name()), entry.Name())
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = tw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return tarBuffer.Bytes(), nil
+}
+
+// buildImage builds the image of an app whose docker compose service has a build section, and returns the ID of the image.
+func (u *upRunner) buildImage(tag string, a *app) (string, error) {
+	b := a.composeService.DockerComposeService.Build
+	if b.Target != "" {
+		return "", exitcode.Wrap(fmt.Errorf("docker compose service %s has a build target, which is not supported", a.name()),
+			exitcode.Config)
+	}
+	dockerfile, err := getBuildDockerfile(b)
+	if err != nil {
+		return "", exitcode.Wrap(errors.Wrapf(err, "docker compose service %s", a.name()), exitcode.Config)
+	}
+	buildContext, err := getBuildContext(b.Context, dockerfile)
+	if err != nil {
+		return "", errors.Wrapf(err, "error while reading the build context of docker compose service %s", a.name())
+	}
+	pt := a.reporterRow.AddProgressTask("building image")
+	defer pt.Done()
+	defer u.opts.Timing.Start(phaseBuildImage, a.name()).Finish()
+	a.reporterRow.AddStatus(reporter.StatusDockerBuild)
+	defer a.reporterRow.RemoveStatus(reporter.StatusDockerBuild)
+	buildArgs := make(map[string]*string, len(b.Args))
+	for name, value := range b.Args {
+		buildArgs[name] = util.NewString(value)
+	}
+	imageID, err := docker.BuildImage(u.opts.Context, u.dockerClient, bytes.NewReader(buildContext), dockerTypes.ImageBuildOptions{
+		BuildArgs:  buildArgs,
+		Dockerfile: dockerfile,
+		Remove:     true,
+		Tags:       []string{tag},
+	}, func(build *docker.Build) {
+		pt.Update(build.Progress())
+	})
+	if err != nil {
+		return "", exitcode.Wrap(errors.Wrapf(err, "error while building the image of docker compose service %s", a.name()),
+			exitcode.ImageTransfer)
+	}
+	a.newLogEntry().Debugf("built image %s (%s)", tag, imageID)
+	// The docker daemon may only output a short ID, so the tag is inspected to get the full ID.
+	inspect, _, err := u.inspectImage(tag)
+	if err != nil {
+		return "", err
+	}
+	return inspect.ID, nil
+}
+
+// buildImageOnce builds an image once per tag, so that services with the same image and build section do not build it concurrently. The
+// progress of the build is only reported for the first app that builds the image. Returns the tag and the ID of the image.
+func (u *upRunner) buildImageOnce(a *app) (string, string, error) {
+	tag := u.getBuildImageTag(a)
+	item := u.imageBuilds.get(tag)
+	item.once.Do(func() {
+		item.digest, item.err = u.buildImage(tag, a)
+	})
+	return tag, item.digest, item.err
+}
@@ -0,0 +1,149 @@
+package up
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"github.com/kube-compose/kube-compose/pkg/docker/dockertest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestBuildContextFS() fs.VirtualFileSystem {
+	return fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project/web/.dockerignore": {
+			Content: []byte("node_modules\n*.log\nDockerfile.dev\n"),
+		},
+		"/project/web/Dockerfile.dev": {
+			Content: []byte("FROM nginx:1.17\nCOPY . /app\n"),
+		},
+		"/project/web/debug.log": {
+			Content: []byte("debug"),
+		},
+		"/project/web/node_modules/module/index.js": {
+			Content: []byte("module"),
+		},
+		"/project/web/src/index.html": {
+			Content: []byte("<html></html>"),
+		},
+	})
+}
+
+// getTarFileNames returns the sorted names of the files of a tar archive.
+func getTarFileNames(b []byte) ([]string, error) {
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(b))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func TestGetBuildDockerfile(t *testing.T) {
+	testCases := []struct {
+		build    dockerComposeConfig.Build
+		expected string
+		err      bool
+	}{
+		{build: dockerComposeConfig.Build{Context: "/project"}, expected: "Dockerfile"},
+		{build: dockerComposeConfig.Build{Context: "/project", Dockerfile: "./docker/Dockerfile.dev"}, expected: "docker/Dockerfile.dev"},
+		{build: dockerComposeConfig.Build{Context: "/project", Dockerfile: "/project/Dockerfile.dev"}, expected: "Dockerfile.dev"},
+		{build: dockerComposeConfig.Build{Context: "/project", Dockerfile: "/other/Dockerfile"}, err: true},
+	}
+	for _, testCase := range testCases {
+		dockerfile, err := getBuildDockerfile(&testCase.build)
+		if dockerfile != testCase.expected || (err != nil) != testCase.err {
+			t.Error(testCase.build, dockerfile, err)
+		}
+	}
+}
+
+func TestGetBuildContext_Dockerignore(t *testing.T) {
+	withMockFS(newTestBuildContextFS(), func() {
+		buildContext, err := getBuildContext("/project/web", "Dockerfile.dev")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		names, err := getTarFileNames(buildContext)
+		expected := []string{".dockerignore", "Dockerfile.dev", "src/", "src/index.html"}
+		if err != nil || !reflect.DeepEqual(names, expected) {
+			t.Error(names, err)
+		}
+	})
+}
+
+func TestGetBuildContext_OpenError(t *testing.T) {
+	withMockFS(newTestBuildContextFS(), func() {
+		_, err := getBuildContext("/project/api", "Dockerfile")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestRun_FakeClusterBuild(t *testing.T) {
+	clientset := newFakeClientset()
+	withFakeCluster(clientset, func(d *dockertest.Daemon) {
+		withMockFS(newTestBuildContextFS(), func() {
+			cfg := newFakeClusterTestConfig()
+			web := cfg.Services["web"].DockerComposeService
+			web.Image = ""
+			web.Build = &dockerComposeConfig.Build{
+				Args: map[string]string{
+					"VERSION": "1",
+				},
+				Context:    "/project/web",
+				Dockerfile: "Dockerfile.dev",
+			}
+			err := Run(cfg, newFakeClusterTestOptions())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			builds := d.Builds()
+			if len(builds) != 1 || builds[0].Dockerfile != "Dockerfile.dev" || *builds[0].BuildArgs["VERSION"] != "1" ||
+				!reflect.DeepEqual(builds[0].Tags, []string{"project-web-test"}) {
+				t.Error(builds)
+				return
+			}
+			if len(d.Pulls()) != 0 {
+				t.Error(d.Pulls())
+			}
+			pod, err := clientset.CoreV1().Pods("default").Get("project-web-test", metav1.GetOptions{})
+			if err != nil || pod.Spec.Containers[0].Image != "project-web-test" {
+				t.Error(pod, err)
+			}
+		})
+	})
+}
+
+func TestRun_FakeClusterBuildTarget(t *testing.T) {
+	clientset := newFakeClientset()
+	withFakeCluster(clientset, func(d *dockertest.Daemon) {
+		withMockFS(newTestBuildContextFS(), func() {
+			cfg := newFakeClusterTestConfig()
+			cfg.Services["web"].DockerComposeService.Build = &dockerComposeConfig.Build{
+				Context: "/project/web",
+				Target:  "dev",
+			}
+			err := Run(cfg, newFakeClusterTestOptions())
+			if err == nil || len(d.Builds()) != 0 {
+				t.Error(err)
+			}
+		})
+	})
+}
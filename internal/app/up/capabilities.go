@@ -0,0 +1,240 @@
+package up
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// podSecurityEnforceLabelName is the name of the label of namespaces that sets the Pod Security Standard that Pod Security Admission
+// enforces in the namespace.
+const podSecurityEnforceLabelName = "pod-security.kubernetes.io/enforce"
+
+const (
+	podSecurityLevelBaseline   = "baseline"
+	podSecurityLevelRestricted = "restricted"
+)
+
+// clusterCapabilities are the optional capabilities that the cluster lacks (see detectClusterCapabilities). The zero value assumes that
+// the cluster has all capabilities, so that up only degrades if a capability was detected to be missing.
+type clusterCapabilities struct {
+	// True if and only if the cluster has no IngressClasses, in which case Ingresses are not created because no Ingress controller would
+	// route them.
+	noIngressController bool
+	// True if and only if the cluster does not appear to provision load balancers, in which case Kubernetes services of type
+	// LoadBalancer are created with type NodePort instead.
+	noLoadBalancers bool
+	// True if and only if the cluster does not serve the metrics API, in which case resources are not recommended.
+	noMetricsAPI bool
+}
+
+// detectClusterCapabilities detects capabilities that the cluster lacks but the environment needs, and adjusts the defaults of up with a
+// warning, so that up does not fail mid-deploy.
+func (u *upRunner) detectClusterCapabilities() error {
+	err := u.checkPodSecurity()
+	if err != nil {
+		return err
+	}
+	err = u.detectIngressController()
+	if err != nil {
+		return err
+	}
+	err = u.detectLoadBalancers()
+	if err != nil {
+		return err
+	}
+	return u.detectMetricsAPI()
+}
+
+// getPodSecurityBaselineViolation returns the reason why the pod of an app violates the baseline Pod Security Standard, or the empty
+// string if it does not.
+func (u *upRunner) getPodSecurityBaselineViolation(a *app) string {
+	for _, a2 := range a.podApps {
+		dcService := a2.composeService.DockerComposeService
+		switch {
+		case dcService.Privileged:
+			return "privileged"
+		case dcService.SecurityOptions.Seccomp == dockerComposeConfig.SecurityOptUnconfined:
+			return "security_opt seccomp:unconfined"
+		case dcService.SecurityOptions.AppArmor == dockerComposeConfig.SecurityOptUnconfined:
+			return "security_opt apparmor:unconfined"
+		case len(a2.hostPorts) > 0:
+			return "host ports"
+		}
+	}
+	return ""
+}
+
+// checkPodSecurity verifies that the pods of the apps to be started are admitted by the Pod Security Standards that are enforced in their
+// namespaces, so that violations are reported before anything is applied.
+func (u *upRunner) checkPodSecurity() error {
+	for _, namespace := range u.cfg.Namespaces() {
+		err := u.checkNamespacePodSecurity(namespace)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNamespacePodSecurity verifies that the pods of the apps to be started in a namespace are admitted by the Pod Security Standard
+// that is enforced in the namespace. The restricted standard requires seccomp profiles to be set in the security contexts of containers,
+// which this version of the Kubernetes API does not support, so pods are never admitted by it.
+func (u *upRunner) checkNamespacePodSecurity(namespaceName string) error {
+	namespace, err := u.k8sClientset.CoreV1().Namespaces().Get(namespaceName, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) || k8sError.IsForbidden(err) {
+		log.Debugf("could not get namespace %s to detect its Pod Security Standard: %v", namespaceName, err)
+		return nil
+	}
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	level := namespace.Labels[podSecurityEnforceLabelName]
+	if level == podSecurityLevelRestricted {
+		return exitcode.Wrap(fmt.Errorf("namespace %s enforces the restricted Pod Security Standard, which pods of kube-compose cannot "+
+			"satisfy; use a namespace that enforces the baseline or privileged Pod Security Standard instead", namespaceName),
+			exitcode.Config)
+	}
+	if level != podSecurityLevelBaseline {
+		return nil
+	}
+	var violations []string
+	for a := range u.appsToBeStarted {
+		if u.cfg.GetNamespace(a.composeService) != namespaceName {
+			continue
+		}
+		if violation := u.getPodSecurityBaselineViolation(a); violation != "" {
+			violations = append(violations, fmt.Sprintf("%s (%s)", a.name(), violation))
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	sort.Strings(violations)
+	return exitcode.Wrap(fmt.Errorf("the pods of services %s would be rejected, because namespace %s enforces the baseline Pod "+
+		"Security Standard", strings.Join(violations, ", "), namespaceName), exitcode.Config)
+}
+
+// detectIngressController detects whether the cluster has an Ingress controller, if services are exposed through Ingresses. Ingress
+// controllers register IngressClasses, so the cluster is assumed to have no Ingress controller if it has no IngressClasses.
+func (u *upRunner) detectIngressController() error {
+	if u.opts.ExposeMode == ExposeModeGateway || !u.hasApp(func(a *app) bool {
+		return a.composeService.Ingress != nil
+	}) {
+		return nil
+	}
+	ok, err := u.hasResource(k8smeta.IngressClassesGVR)
+	if err != nil || !ok {
+		return err
+	}
+	list, err := u.k8sDynamicClient.Resource(k8smeta.IngressClassesGVR).List(metav1.ListOptions{})
+	if k8sError.IsForbidden(err) {
+		log.Debugf("could not list IngressClasses to detect an Ingress controller: %v", err)
+		return nil
+	}
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	if len(list.Items) == 0 {
+		u.capabilities.noIngressController = true
+		log.Warnf("services are not exposed through Ingresses, because the cluster has no IngressClasses (is an Ingress controller " +
+			"installed?)")
+	}
+	return nil
+}
+
+// clusterProvisionsLoadBalancers returns true if the cluster appears to provision load balancers, which is the case if a Kubernetes
+// service of type LoadBalancer in any namespace has an external address, or if the nodes are managed by a cloud provider. If up is not
+// allowed to find out then the cluster is assumed to provision load balancers.
+func (u *upRunner) clusterProvisionsLoadBalancers() (bool, error) {
+	services, err := u.k8sClientset.CoreV1().Services(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if k8sError.IsForbidden(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	for i := range services.Items {
+		service := &services.Items[i]
+		if service.Spec.Type == v1.ServiceTypeLoadBalancer && len(service.Status.LoadBalancer.Ingress) > 0 {
+			return true, nil
+		}
+	}
+	nodes, err := u.k8sClientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if k8sError.IsForbidden(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	for i := range nodes.Items {
+		if nodes.Items[i].Spec.ProviderID != "" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// detectLoadBalancers detects whether the cluster provisions load balancers, if an app has a Kubernetes service of type
+// LoadBalancer.
+func (u *upRunner) detectLoadBalancers() error {
+	if !u.hasApp(func(a *app) bool {
+		ks := a.composeService.KubernetesService
+		return ks != nil && ks.Type == v1.ServiceTypeLoadBalancer
+	}) {
+		return nil
+	}
+	ok, err := u.clusterProvisionsLoadBalancers()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		u.capabilities.noLoadBalancers = true
+		log.Warnf("Kubernetes services of type %s are created with type %s, because the cluster does not appear to provision load "+
+			"balancers", v1.ServiceTypeLoadBalancer, v1.ServiceTypeNodePort)
+	}
+	return nil
+}
+
+// detectMetricsAPI detects whether the cluster serves the metrics API, if resources are recommended.
+func (u *upRunner) detectMetricsAPI() error {
+	if !u.opts.RecommendResources {
+		return nil
+	}
+	ok, err := u.hasResource(k8smeta.PodMetricsGVR)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		u.capabilities.noMetricsAPI = true
+		log.Warnf("resources are not recommended, because the cluster does not serve the metrics API (is metrics-server installed?)")
+	}
+	return nil
+}
+
+// hasApp returns true if and only if an app satisfies f. Kubernetes services and Ingresses are created for all apps, not only the apps to
+// be started.
+func (u *upRunner) hasApp(f func(a *app) bool) bool {
+	for _, a := range u.apps {
+		if f(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// adjustServiceType changes the type of a Kubernetes service of type LoadBalancer to NodePort if the cluster does not provision load
+// balancers.
+func (u *upRunner) adjustServiceType(service *v1.Service) {
+	if u.capabilities.noLoadBalancers && service.Spec.Type == v1.ServiceTypeLoadBalancer {
+		service.Spec.Type = v1.ServiceTypeNodePort
+	}
+}
@@ -0,0 +1,180 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestUpRunnerCapabilities(objects ...runtime.Object) *upRunner {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.cfg.Namespace = "default"
+	clientset := fake.NewSimpleClientset(objects...)
+	clientset.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: k8smeta.IngressClassesGVR.GroupVersion().String(),
+			APIResources: []metav1.APIResource{
+				{Name: k8smeta.IngressClassesGVR.Resource},
+			},
+		},
+	}
+	u.k8sClientset = clientset
+	u.k8sDynamicClient = dynamicFake.NewSimpleDynamicClient(runtime.NewScheme())
+	return u
+}
+
+func newTestNamespace(podSecurityLevel string) *v1.Namespace {
+	return &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				podSecurityEnforceLabelName: podSecurityLevel,
+			},
+			Name: "default",
+		},
+	}
+}
+
+func TestCheckPodSecurity_Baseline(t *testing.T) {
+	u := newTestUpRunnerCapabilities(newTestNamespace(podSecurityLevelBaseline))
+	err := u.checkPodSecurity()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckPodSecurity_BaselineViolation(t *testing.T) {
+	u := newTestUpRunnerCapabilities(newTestNamespace(podSecurityLevelBaseline))
+	u.apps["b"].composeService.DockerComposeService.Privileged = true
+	err := u.checkPodSecurity()
+	if exitcode.FromError(err) != exitcode.Config || err.Error() != "the pods of services b (privileged) would be rejected, because "+
+		"namespace default enforces the baseline Pod Security Standard" {
+		t.Error(err)
+	}
+}
+
+func TestCheckPodSecurity_Restricted(t *testing.T) {
+	u := newTestUpRunnerCapabilities(newTestNamespace(podSecurityLevelRestricted))
+	err := u.checkPodSecurity()
+	if exitcode.FromError(err) != exitcode.Config {
+		t.Error(err)
+	}
+}
+
+func TestCheckPodSecurity_NamespaceNotFound(t *testing.T) {
+	u := newTestUpRunnerCapabilities()
+	err := u.checkPodSecurity()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDetectIngressController_NoIngressClasses(t *testing.T) {
+	u := newTestUpRunnerCapabilities()
+	u.apps["a"].composeService.Ingress = &config.Ingress{
+		Host: "a.example.com",
+		Port: 8080,
+	}
+	err := u.detectIngressController()
+	if err != nil || !u.capabilities.noIngressController {
+		t.Error(err)
+	}
+	if url := u.getAppURL(u.apps["a"], 8080, nil); url != nil {
+		t.Error(url)
+	}
+}
+
+func TestDetectIngressController_WithoutIngress(t *testing.T) {
+	u := newTestUpRunnerCapabilities()
+	err := u.detectIngressController()
+	if err != nil || u.capabilities.noIngressController {
+		t.Error(err)
+	}
+}
+
+func newTestUpRunnerLoadBalancer(objects ...runtime.Object) *upRunner {
+	u := newTestUpRunnerCapabilities(objects...)
+	u.apps["a"].composeService.KubernetesService = &config.KubernetesService{
+		Type: v1.ServiceTypeLoadBalancer,
+	}
+	return u
+}
+
+func TestDetectLoadBalancers_NotProvisioned(t *testing.T) {
+	u := newTestUpRunnerLoadBalancer(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+		},
+	})
+	err := u.detectLoadBalancers()
+	if err != nil || !u.capabilities.noLoadBalancers {
+		t.Error(err)
+	}
+	service := newService(u.cfg, u.apps["a"])
+	u.adjustServiceType(service)
+	if service.Spec.Type != v1.ServiceTypeNodePort {
+		t.Error(service.Spec.Type)
+	}
+}
+
+func TestDetectLoadBalancers_ExternalAddress(t *testing.T) {
+	u := newTestUpRunnerLoadBalancer(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress-nginx",
+			Namespace: "ingress-nginx",
+		},
+		Spec: v1.ServiceSpec{
+			Type: v1.ServiceTypeLoadBalancer,
+		},
+		Status: v1.ServiceStatus{
+			LoadBalancer: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{
+					{IP: "203.0.113.10"},
+				},
+			},
+		},
+	})
+	err := u.detectLoadBalancers()
+	if err != nil || u.capabilities.noLoadBalancers {
+		t.Error(err)
+	}
+}
+
+func TestDetectLoadBalancers_CloudProvider(t *testing.T) {
+	u := newTestUpRunnerLoadBalancer(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+		},
+		Spec: v1.NodeSpec{
+			ProviderID: "aws:///eu-west-1a/i-0123456789abcdef0",
+		},
+	})
+	err := u.detectLoadBalancers()
+	if err != nil || u.capabilities.noLoadBalancers {
+		t.Error(err)
+	}
+}
+
+func TestDetectMetricsAPI_NotServed(t *testing.T) {
+	u := newTestUpRunnerCapabilities()
+	u.opts.RecommendResources = true
+	// The fake discovery client does not report unknown groups as not found, so the group is served without PodMetrics.
+	clientset := u.k8sClientset.(*fake.Clientset)
+	clientset.Resources = append(clientset.Resources, &metav1.APIResourceList{
+		GroupVersion: k8smeta.PodMetricsGVR.GroupVersion().String(),
+	})
+	err := u.detectMetricsAPI()
+	if err != nil || !u.capabilities.noMetricsAPI {
+		t.Error(err)
+	}
+	u.startResourceRecorder()
+	if u.resourceRecorder != nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,125 @@
+package up
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// knownDebugPorts maps the default ports of common debuggers to a description of the debugger.
+var knownDebugPorts = map[int32]string{
+	2345: "Go (Delve)",
+	5005: "Java (JDWP)",
+	5678: "Python (debugpy)",
+	9229: "Node.js inspector",
+}
+
+type debugPort struct {
+	description string
+	port        int32
+}
+
+// getDebugPorts returns the ports of debuggers of an app, sorted by port: the port declared in x-kube-compose (if any) and the TCP
+// ports of the docker compose service that are the default ports of common debuggers.
+func getDebugPorts(a *app) []debugPort {
+	var debugPorts []debugPort
+	if a.composeService.DebugPort != 0 {
+		description, ok := knownDebugPorts[a.composeService.DebugPort]
+		if !ok {
+			description = "debugger"
+		}
+		debugPorts = append(debugPorts, debugPort{
+			description: description,
+			port:        a.composeService.DebugPort,
+		})
+	}
+	for _, port := range a.composeService.Ports {
+		if port.Protocol != "tcp" || port.Port == a.composeService.DebugPort {
+			continue
+		}
+		if description, ok := knownDebugPorts[port.Port]; ok {
+			debugPorts = append(debugPorts, debugPort{
+				description: description,
+				port:        port.Port,
+			})
+		}
+	}
+	sort.Slice(debugPorts, func(i, j int) bool {
+		return debugPorts[i].port < debugPorts[j].port
+	})
+	return debugPorts
+}
+
+// getPortForwardSpec returns a port specification of package portforward that forwards port to the same local port if it is available,
+// and to a random local port otherwise.
+func getPortForwardSpec(port int32) string {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return fmt.Sprintf(":%d", port)
+	}
+	util.CloseAndLogError(listener)
+	return fmt.Sprintf("%d:%d", port, port)
+}
+
+// forwardDebugPorts forwards the debug ports of the pod of an app to localhost, and prints connection instructions once the ports are
+// forwarded. It returns when port forwarding fails.
+func (u *upRunner) forwardDebugPorts(a *app, podName string, debugPorts []debugPort) {
+	ports := make([]int32, len(debugPorts))
+	for i, debugPort := range debugPorts {
+		ports[i] = debugPort.port
+	}
+	u.forwardPorts(a, podName, "debug ports", ports, func(forwardedPorts []portforward.ForwardedPort) {
+		for i, forwardedPort := range forwardedPorts {
+			a.newLogEntry().Infof("%s of service %s is forwarded, connect your debugger to localhost:%d", debugPorts[i].description,
+				a.name(), forwardedPort.Local)
+		}
+	})
+}
+
+// forwardPorts forwards ports of the pod of an app to localhost, to the same local ports if they are available. onReady is called with
+// the forwarded ports once the ports are forwarded. It returns when port forwarding fails. what describes the ports in warnings.
+func (u *upRunner) forwardPorts(a *app, podName, what string, ports []int32, onReady func([]portforward.ForwardedPort)) {
+	transport, upgrader, err := spdy.RoundTripperFor(u.cfg.KubeConfig)
+	if err != nil {
+		a.newLogEntry().Warnf("could not forward %s: %v", what, err)
+		return
+	}
+	url := u.k8sClientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(u.cfg.GetNamespace(a.composeService)).
+		Name(podName).
+		SubResource("portforward").
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", url)
+	portSpecs := make([]string, len(ports))
+	for i, port := range ports {
+		portSpecs[i] = getPortForwardSpec(port)
+	}
+	// The stop channel is never closed, because ports are forwarded until kube-compose exits.
+	stopChannel := make(chan struct{})
+	readyChannel := make(chan struct{})
+	forwarder, err := portforward.New(dialer, portSpecs, stopChannel, readyChannel, ioutil.Discard, ioutil.Discard)
+	if err != nil {
+		a.newLogEntry().Warnf("could not forward %s: %v", what, err)
+		return
+	}
+	go func() {
+		<-readyChannel
+		forwardedPorts, err := forwarder.GetPorts()
+		if err != nil {
+			return
+		}
+		onReady(forwardedPorts)
+	}()
+	err = forwarder.ForwardPorts()
+	if err != nil {
+		a.newLogEntry().Warnf("stopped forwarding %s: %v", what, strings.TrimSpace(err.Error()))
+	}
+}
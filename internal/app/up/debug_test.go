@@ -0,0 +1,76 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+)
+
+func TestGetDebugPorts_Success(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	a := u.apps["a"]
+	a.composeService.DebugPort = 4000
+	a.composeService.Ports = []config.Port{
+		{
+			Port:     9229,
+			Protocol: "tcp",
+		},
+		{
+			Port:     5005,
+			Protocol: "udp",
+		},
+		{
+			Port:     8080,
+			Protocol: "tcp",
+		},
+	}
+	debugPorts := getDebugPorts(a)
+	expected := []debugPort{
+		{
+			description: "debugger",
+			port:        4000,
+		},
+		{
+			description: "Node.js inspector",
+			port:        9229,
+		},
+	}
+	if !reflect.DeepEqual(debugPorts, expected) {
+		t.Error(debugPorts)
+	}
+}
+
+func TestGetDebugPorts_DeclaredKnownPort(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	a := u.apps["a"]
+	a.composeService.DebugPort = 5005
+	a.composeService.Ports = []config.Port{
+		{
+			Port:     5005,
+			Protocol: "tcp",
+		},
+	}
+	debugPorts := getDebugPorts(a)
+	if len(debugPorts) != 1 || debugPorts[0].description != "Java (JDWP)" {
+		t.Error(debugPorts)
+	}
+}
+
+func TestShouldForwardDebugPorts_Success(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	a := u.apps["a"]
+	u.cfg.AddToFilter(a.composeService)
+	if !u.shouldForwardDebugPorts(a) {
+		t.Fail()
+	}
+	u.opts.Detach = true
+	if u.shouldForwardDebugPorts(a) {
+		t.Fail()
+	}
+	u.opts.Detach = false
+	a.debugPortsForwarded = true
+	if u.shouldForwardDebugPorts(a) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,64 @@
+package up
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// maxDNSSearches is the maximum number of search domains of the DNS configuration of a pod that Kubernetes accepts.
+const maxDNSSearches = 6
+
+// checkDNS validates Options.DNSSearches, and reports the docker compose services whose names cannot be the names of service aliases
+// (see Options.ServiceAliases).
+func (u *upRunner) checkDNS() error {
+	if len(u.opts.DNSSearches) > maxDNSSearches {
+		return exitcode.Wrap(fmt.Errorf("pods can have at most %d DNS search domains", maxDNSSearches), exitcode.Config)
+	}
+	for _, search := range u.opts.DNSSearches {
+		if e := validation.IsDNS1123Subdomain(search); len(e) > 0 {
+			return exitcode.Wrap(fmt.Errorf("the DNS search domain %s is invalid: %s", search, e[0]), exitcode.Config)
+		}
+	}
+	if !u.opts.ServiceAliases {
+		return nil
+	}
+	names := make([]string, 0, len(u.apps))
+	for name, app := range u.apps {
+		if app.hasService() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if e := validation.IsDNS1035Label(name); len(e) > 0 {
+			return exitcode.Wrap(fmt.Errorf("the name of service %s cannot be the name of a Kubernetes service, so it cannot have a "+
+				"service alias: %s", name, e[0]), exitcode.Config)
+		}
+	}
+	return nil
+}
+
+// newServiceAlias returns the service alias of a docker compose service (see Options.ServiceAliases), which is like the Kubernetes
+// service of the docker compose service, but is named exactly like the docker compose service. The service alias is labelled and
+// annotated like the Kubernetes service, so that down deletes it. Because service aliases are not unique in shared namespaces, up reports
+// an existing service alias of another environment like any other resource that it does not own.
+func newServiceAlias(cfg *config.Config, app *app) *v1.Service {
+	service := newService(cfg, app)
+	service.ObjectMeta.Name = app.name()
+	return service
+}
+
+// setPodDNSSearches adds the search domains of Options.DNSSearches to the DNS configuration of a pod.
+func setPodDNSSearches(opts *Options, pod *v1.Pod) {
+	if len(opts.DNSSearches) == 0 {
+		return
+	}
+	pod.Spec.DNSConfig = &v1.PodDNSConfig{
+		Searches: append([]string(nil), opts.DNSSearches...),
+	}
+}
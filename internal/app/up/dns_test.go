@@ -0,0 +1,134 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"github.com/kube-compose/kube-compose/pkg/docker/dockertest"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestUpRunnerServiceAliases(name string) *upRunner {
+	cfg := newFakeClusterTestConfig()
+	service := cfg.AddService(&dockerComposeConfig.Service{
+		Name: name,
+	})
+	service.Ports = []config.Port{
+		{
+			Port:     80,
+			Protocol: "tcp",
+		},
+	}
+	u := &upRunner{
+		cfg: cfg,
+		opts: &Options{
+			ServiceAliases: true,
+		},
+	}
+	u.initApps()
+	return u
+}
+
+func TestCheckDNS_Success(t *testing.T) {
+	u := newTestUpRunnerServiceAliases("api")
+	u.opts.DNSSearches = []string{"shared.svc.cluster.local"}
+	if err := u.checkDNS(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckDNS_InvalidSearch(t *testing.T) {
+	u := newTestUpRunnerServiceAliases("api")
+	u.opts.DNSSearches = []string{"Shared_Services"}
+	if err := u.checkDNS(); exitcode.FromError(err) != exitcode.Config {
+		t.Error(err)
+	}
+}
+
+func TestCheckDNS_TooManySearches(t *testing.T) {
+	u := newTestUpRunnerServiceAliases("api")
+	u.opts.DNSSearches = []string{"a", "b", "c", "d", "e", "f", "g"}
+	if err := u.checkDNS(); exitcode.FromError(err) != exitcode.Config {
+		t.Error(err)
+	}
+}
+
+func TestCheckDNS_InvalidAliasName(t *testing.T) {
+	u := newTestUpRunnerServiceAliases("my_api")
+	if err := u.checkDNS(); exitcode.FromError(err) != exitcode.Config {
+		t.Error(err)
+	}
+	// Without service aliases the name of a docker compose service is escaped, so it is valid.
+	u.opts.ServiceAliases = false
+	if err := u.checkDNS(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewServiceAlias(t *testing.T) {
+	u := newTestUpRunnerServiceAliases("api")
+	alias := newServiceAlias(u.cfg, u.apps["db"])
+	service := newService(u.cfg, u.apps["db"])
+	if alias.ObjectMeta.Name != "db" || !reflect.DeepEqual(alias.Spec.Selector, service.Spec.Selector) {
+		t.Error(alias)
+	}
+}
+
+func TestSetPodDNSSearches(t *testing.T) {
+	pod := &v1.Pod{}
+	setPodDNSSearches(&Options{}, pod)
+	if pod.Spec.DNSConfig != nil {
+		t.Error(pod.Spec.DNSConfig)
+	}
+	setPodDNSSearches(&Options{DNSSearches: []string{"shared.svc.cluster.local"}}, pod)
+	if pod.Spec.DNSConfig == nil || len(pod.Spec.DNSConfig.Searches) != 1 || pod.Spec.DNSConfig.Searches[0] != "shared.svc.cluster.local" {
+		t.Error(pod.Spec.DNSConfig)
+	}
+}
+
+func TestRun_FakeClusterServiceAliases(t *testing.T) {
+	clientset := newFakeClientset()
+	withFakeCluster(clientset, func(_ *dockertest.Daemon) {
+		opts := newFakeClusterTestOptions()
+		opts.ServiceAliases = true
+		err := Run(newFakeClusterTestConfig(), opts)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		alias, err := clientset.CoreV1().Services("default").Get("db", metav1.GetOptions{})
+		if err != nil || alias.Spec.Ports[0].Port != 5432 {
+			t.Error(alias, err)
+		}
+	})
+}
+
+func TestRun_FakeClusterServiceAliasOwnedByOtherEnvironment(t *testing.T) {
+	clientset := newFakeClientset(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db",
+			Namespace: "default",
+		},
+	})
+	withFakeCluster(clientset, func(_ *dockertest.Daemon) {
+		opts := newFakeClusterTestOptions()
+		opts.ServiceAliases = true
+		err := Run(newFakeClusterTestConfig(), opts)
+		if exitcode.FromError(err) != exitcode.Config {
+			t.Error(err)
+		}
+	})
+}
+
+func TestRender_InvalidDNSSearch(t *testing.T) {
+	_, err := Render(newFakeClusterTestConfig(), &Options{
+		DNSSearches: []string{"Shared_Services"},
+	})
+	if exitcode.FromError(err) != exitcode.Config {
+		t.Error(err)
+	}
+}
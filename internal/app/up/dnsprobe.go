@@ -0,0 +1,178 @@
+package up
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// The number of times a DNS probe tries to resolve and connect to a Kubernetes service, once per second, before it fails.
+const dnsProbeAttempts = 60
+
+// The name of the container of DNS probe pods.
+const dnsProbeContainerName = "dns-probe"
+
+// The interval at which a DNS probe pod is polled when waiting for it to complete. It is a variable to improve testability.
+var dnsProbePollInterval = time.Second
+
+// dnsProbeStatus is the status of the DNS probe of an app (see Options.WaitForDNS).
+type dnsProbeStatus int
+
+const (
+	dnsProbeNotStarted dnsProbeStatus = iota
+	dnsProbeRunning
+	dnsProbeSucceeded
+	dnsProbeFailed
+)
+
+// dnsProbeResult is the result of the DNS probe of an app. Results are sent to the goroutine that watches pods, so that the state of apps
+// is only modified by that goroutine.
+type dnsProbeResult struct {
+	app *app
+	err error
+}
+
+// getDNSProbePodName returns the name of the DNS probe pod of a docker compose service.
+func getDNSProbePodName(cfg *config.Config, composeService *config.Service) string {
+	return k8smeta.GetResourceName(cfg, composeService.NameEscaped+"-dns-probe", validation.DNS1123SubdomainMaxLength)
+}
+
+// newDNSProbePod returns a pod that completes successfully once the name of the Kubernetes service of an app resolves and, if the app has
+// a TCP port, the Kubernetes service accepts connections on that port. The pod is labelled like the other resources of the environment so
+// that down deletes it, but it is not annotated with a docker compose service so that its events are ignored while watching pods.
+func newDNSProbePod(cfg *config.Config, opts *Options, a *app) *v1.Pod {
+	host := k8smeta.GetK8sName(a.composeService, cfg)
+	check := fmt.Sprintf("nslookup %s >/dev/null 2>&1", host)
+	for _, p := range a.composeService.Ports {
+		if p.Protocol == "tcp" {
+			check = fmt.Sprintf("%s && nc -z -w 2 %s %d", check, host, p.Port)
+			break
+		}
+	}
+	return &v1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:    k8smeta.InitEnvironmentLabels(cfg, nil),
+			Name:      getDNSProbePodName(cfg, a.composeService),
+			Namespace: a.composeService.PodService().Namespace,
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name:            dnsProbeContainerName,
+					Image:           cfg.WaitForImage,
+					SecurityContext: getMeshInitContainerSecurityContext(opts.Mesh),
+					Command: []string{
+						"sh",
+						"-c",
+						fmt.Sprintf("i=0; until %s; do i=$((i+1)); if [ $i -ge %d ]; then exit 1; fi; sleep 1; done", check,
+							dnsProbeAttempts),
+					},
+				},
+			},
+			RestartPolicy: v1.RestartPolicyNever,
+		},
+	}
+}
+
+// deleteDNSProbePod deletes the DNS probe pod of a previous run, if any.
+func (u *upRunner) deleteDNSProbePod(namespace, name string) error {
+	existing, err := u.podClient(namespace).Get(name, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	err = k8smeta.ValidateOwnership(u.cfg, "Pod", &existing.ObjectMeta)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	gracePeriodSeconds := int64(0)
+	return u.deletePodAndWait(existing, &gracePeriodSeconds)
+}
+
+// runDNSProbe runs the DNS probe pod of an app until it completes, and deletes it afterwards.
+func (u *upRunner) runDNSProbe(a *app) error {
+	pod := newDNSProbePod(u.cfg, u.opts, a)
+	err := u.admitObject(pod)
+	if err != nil {
+		return err
+	}
+	client := u.podClient(pod.ObjectMeta.Namespace)
+	err = u.deleteDNSProbePod(pod.ObjectMeta.Namespace, pod.ObjectMeta.Name)
+	if err != nil {
+		return err
+	}
+	pod, err = client.Create(pod)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	defer func() {
+		deleteErr := client.Delete(pod.ObjectMeta.Name, &metav1.DeleteOptions{})
+		if deleteErr != nil && !k8sError.IsNotFound(deleteErr) {
+			a.newLogEntry().Warnf("could not delete DNS probe pod %s: %v", pod.ObjectMeta.Name, deleteErr)
+		}
+	}()
+	for pod.Status.Phase != v1.PodSucceeded {
+		if pod.Status.Phase == v1.PodFailed {
+			return exitcode.Wrap(fmt.Errorf("the Kubernetes service %s of docker compose service %s did not resolve or accept connections "+
+				"from inside the cluster", k8smeta.GetK8sName(a.composeService, u.cfg), a.name()), exitcode.ReadinessTimeout)
+		}
+		time.Sleep(dnsProbePollInterval)
+		pod, err = client.Get(pod.ObjectMeta.Name, metav1.GetOptions{})
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+	}
+	return nil
+}
+
+// isAppReachable returns true if and only if the DNS probe of an app succeeded (see Options.WaitForDNS). The DNS probe is started if it
+// has not been started yet, and its result is handled by handleDNSProbeResult.
+func (u *upRunner) isAppReachable(a *app) bool {
+	if !u.opts.WaitForDNS || !a.hasService() {
+		return true
+	}
+	if a.dnsProbeStatus == dnsProbeNotStarted {
+		if u.dnsProbeResults == nil {
+			u.dnsProbeResults = make(chan *dnsProbeResult, len(u.apps))
+		}
+		a.dnsProbeStatus = dnsProbeRunning
+		u.dnsProbesRunning++
+		a.newLogEntry().Debugf("waiting for the Kubernetes service to resolve and accept connections from inside the cluster")
+		go func() {
+			u.dnsProbeResults <- &dnsProbeResult{
+				app: a,
+				err: u.runDNSProbe(a),
+			}
+		}()
+	}
+	return a.dnsProbeStatus == dnsProbeSucceeded
+}
+
+// handleDNSProbeResult records the result of the DNS probe of an app, and creates the pods of the dependents of the app that can be
+// started. If the DNS probe failed then the failure policy of the app is applied.
+func (u *upRunner) handleDNSProbeResult(result *dnsProbeResult) error {
+	u.dnsProbesRunning--
+	if result.err != nil {
+		result.app.dnsProbeStatus = dnsProbeFailed
+		err := u.handleAppFailure(result.app, result.err)
+		if err != nil {
+			return err
+		}
+	} else {
+		result.app.dnsProbeStatus = dnsProbeSucceeded
+	}
+	return u.createPodsIfNeeded()
+}
@@ -0,0 +1,156 @@
+package up
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+func newTestUpRunnerWaitForDNS() *upRunner {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.cfg.EnvironmentID = "test"
+	u.cfg.EnvironmentLabel = "env"
+	u.opts.WaitForDNS = true
+	for _, name := range []string{"b", "c", "d"} {
+		delete(u.appsToBeStarted, u.apps[name])
+	}
+	setTestAppHealthy(u.apps["c"])
+	u.apps["d"].maxObservedPodStatus = podStatusStarted
+	u.apps["d"].composeService.Ports = []config.Port{
+		{
+			Port:     8080,
+			Protocol: "tcp",
+		},
+	}
+	return u
+}
+
+// withTestDNSProbePodPhase makes up connect to a fake clientset where the DNS probe pod of the app d has the phase once it has been
+// created.
+func withTestDNSProbePodPhase(u *upRunner, phase v1.PodPhase) *fake.Clientset {
+	clientset := fake.NewSimpleClientset()
+	created := false
+	clientset.PrependReactor("create", "pods", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		created = true
+		return false, nil, nil
+	})
+	clientset.PrependReactor("get", "pods", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		name := action.(k8sTesting.GetAction).GetName()
+		if !created || name != getDNSProbePodName(u.cfg, u.apps["d"].composeService) {
+			return false, nil, nil
+		}
+		return true, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Status: v1.PodStatus{
+				Phase: phase,
+			},
+		}, nil
+	})
+	u.k8sPodClient = clientset.CoreV1().Pods(u.cfg.Namespace)
+	return clientset
+}
+
+func TestNewDNSProbePod(t *testing.T) {
+	u := newTestUpRunnerWaitForDNS()
+	u.cfg.WaitForImage = "busybox"
+	pod := newDNSProbePod(u.cfg, u.opts, u.apps["d"])
+	if pod.ObjectMeta.Name != "d-dns-probe-test" || pod.ObjectMeta.Labels["env"] != "test" || len(pod.ObjectMeta.Annotations) != 0 {
+		t.Error(pod.ObjectMeta)
+	}
+	container := pod.Spec.Containers[0]
+	if container.Image != "busybox" || !strings.Contains(container.Command[2], "nslookup d-test >/dev/null 2>&1 && nc -z -w 2 d-test 8080") {
+		t.Error(container)
+	}
+	if pod.Spec.RestartPolicy != v1.RestartPolicyNever {
+		t.Error(pod.Spec.RestartPolicy)
+	}
+}
+
+func TestGetAppsThatCanBeStarted_WaitForDNSRunning(t *testing.T) {
+	u := newTestUpRunnerWaitForDNS()
+	u.apps["d"].dnsProbeStatus = dnsProbeRunning
+	wave, err := u.getAppsThatCanBeStarted()
+	if err != nil || len(wave) != 0 {
+		t.Error(wave, err)
+	}
+}
+
+func TestGetAppsThatCanBeStarted_WaitForDNSSucceeded(t *testing.T) {
+	u := newTestUpRunnerWaitForDNS()
+	u.apps["d"].dnsProbeStatus = dnsProbeSucceeded
+	wave, err := u.getAppsThatCanBeStarted()
+	names := waveToNameSet(wave)
+	if err != nil || len(names) != 1 || !names["a"] {
+		t.Error(names, err)
+	}
+}
+
+func TestRunDNSProbe_Success(t *testing.T) {
+	origDNSProbePollInterval := dnsProbePollInterval
+	defer func() {
+		dnsProbePollInterval = origDNSProbePollInterval
+	}()
+	dnsProbePollInterval = 0
+	u := newTestUpRunnerWaitForDNS()
+	clientset := withTestDNSProbePodPhase(u, v1.PodSucceeded)
+	err := u.runDNSProbe(u.apps["d"])
+	if err != nil {
+		t.Error(err)
+	}
+	// The DNS probe pod is deleted once it has completed.
+	actions := clientset.Actions()
+	if last := actions[len(actions)-1]; !last.Matches("delete", "pods") || last.(k8sTesting.DeleteAction).GetName() != "d-dns-probe-test" {
+		t.Error(last)
+	}
+}
+
+func TestRunDNSProbe_Failed(t *testing.T) {
+	origDNSProbePollInterval := dnsProbePollInterval
+	defer func() {
+		dnsProbePollInterval = origDNSProbePollInterval
+	}()
+	dnsProbePollInterval = 0
+	u := newTestUpRunnerWaitForDNS()
+	withTestDNSProbePodPhase(u, v1.PodFailed)
+	err := u.runDNSProbe(u.apps["d"])
+	if exitcode.FromError(err) != exitcode.ReadinessTimeout {
+		t.Error(err)
+	}
+}
+
+func TestRunDNSProbe_OwnedByOtherEnvironment(t *testing.T) {
+	u := newTestUpRunnerWaitForDNS()
+	clientset := fake.NewSimpleClientset(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "d-dns-probe-test",
+		},
+	})
+	u.k8sPodClient = clientset.CoreV1().Pods(u.cfg.Namespace)
+	err := u.runDNSProbe(u.apps["d"])
+	if exitcode.FromError(err) != exitcode.Config {
+		t.Error(err)
+	}
+}
+
+func TestHandleDNSProbeResult_Failed(t *testing.T) {
+	u := newTestUpRunnerWaitForDNS()
+	u.apps["d"].dnsProbeStatus = dnsProbeRunning
+	u.dnsProbesRunning = 1
+	err := u.handleDNSProbeResult(&dnsProbeResult{
+		app: u.apps["d"],
+		err: k8smeta.ErrorResourcesModifiedExternally(),
+	})
+	if err == nil || u.apps["d"].dnsProbeStatus != dnsProbeFailed || u.dnsProbesRunning != 0 {
+		t.Error(err)
+	}
+}
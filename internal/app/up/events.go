@@ -0,0 +1,67 @@
+package up
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType is the type of an Event.
+type EventType string
+
+const (
+	// EventPulled means that the image of a docker compose service was resolved, and pulled and pushed if needed.
+	EventPulled EventType = "pulled"
+	// EventApplied means that the pod of a docker compose service was created, or that its existing pod was kept.
+	EventApplied EventType = "applied"
+	// EventReady means that the pod of a docker compose service became ready, or completed.
+	EventReady EventType = "ready"
+	// EventFailed means that a docker compose service failed. This is reported at most once per service.
+	EventFailed EventType = "failed"
+)
+
+// Event is a lifecycle event of a docker compose service during up (see Options.EventHandler).
+type Event struct {
+	// The error that caused the failure if Type is EventFailed, or nil otherwise.
+	Err     error
+	Service string
+	Time    time.Time
+	Type    EventType
+}
+
+type eventEmitter struct {
+	// The apps whose failure has been reported.
+	failed map[*app]bool
+	mutex  sync.Mutex
+}
+
+// emitEvent passes an event of an app to the event handler, if there is one. Calls of the event handler are serialized, because the
+// events of apps are emitted by multiple goroutines.
+func (u *upRunner) emitEvent(a *app, t EventType, err error) {
+	if u.opts.EventHandler == nil {
+		return
+	}
+	u.events.mutex.Lock()
+	defer u.events.mutex.Unlock()
+	if t == EventFailed {
+		if u.events.failed[a] {
+			return
+		}
+		if u.events.failed == nil {
+			u.events.failed = map[*app]bool{}
+		}
+		u.events.failed[a] = true
+	}
+	u.opts.EventHandler(&Event{
+		Err:     err,
+		Service: a.name(),
+		Time:    time.Now(),
+		Type:    t,
+	})
+}
+
+// emitPodEvent emits an event for each app whose container runs in the pod of an app.
+func (u *upRunner) emitPodEvent(a *app, t EventType) {
+	for _, app2 := range a.podApps {
+		u.emitEvent(app2, t, nil)
+	}
+}
@@ -0,0 +1,38 @@
+package up
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHandleAppFailure_EmitsFailedEventOnce(t *testing.T) {
+	u := newTestUpRunnerWithFailedDependency()
+	var events []*Event
+	u.opts.EventHandler = func(event *Event) {
+		events = append(events, event)
+	}
+	err := fmt.Errorf("test error")
+	_ = u.handleAppFailure(u.apps["c"], err)
+	_ = u.handleAppFailure(u.apps["c"], err)
+	if len(events) != 1 || events[0].Type != EventFailed || events[0].Service != "c" || events[0].Err != err {
+		t.Fail()
+	}
+}
+
+func TestEmitPodEvent(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	a := u.apps["a"]
+	a.podApps = []*app{a, u.apps["b"]}
+	var services []string
+	u.opts.EventHandler = func(event *Event) {
+		if event.Type != EventReady || event.Err != nil {
+			t.Fail()
+		}
+		services = append(services, event.Service)
+	}
+	u.setAppMaxObservedPodStatus(a, podStatusReady)
+	u.setAppMaxObservedPodStatus(a, podStatusCompleted)
+	if len(services) != 2 || services[0] != "a" || services[1] != "b" {
+		t.Error(services)
+	}
+}
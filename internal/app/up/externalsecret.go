@@ -0,0 +1,217 @@
+package up
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// The name of the CSI driver of the Secrets Store CSI Driver.
+	secretsStoreCSIDriver = "secrets-store.csi.k8s.io"
+	// The key of the Secret created by the External Secrets Operator that holds the value of a docker compose secret.
+	externalSecretKey = "value"
+)
+
+type secretObjects struct {
+	errs  map[string]error
+	mutex sync.Mutex
+}
+
+// getSecretObjectName returns the name of the ExternalSecret or SecretProviderClass of a docker compose secret. The Secret created by the
+// External Secrets Operator has the same name.
+func getSecretObjectName(cfg *config.Config, secret *config.Secret) string {
+	return k8smeta.GetResourceName(cfg, util.EscapeName(secret.Name), validation.DNS1123SubdomainMaxLength)
+}
+
+func newExternalSecretObject(cfg *config.Config, secret *config.Secret) *unstructured.Unstructured {
+	remoteRef := map[string]interface{}{
+		"key": secret.ExternalSecret.RemoteKey,
+	}
+	if secret.ExternalSecret.RemoteProperty != "" {
+		remoteRef["property"] = secret.ExternalSecret.RemoteProperty
+	}
+	name := getSecretObjectName(cfg, secret)
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": k8smeta.ExternalSecretsGVR.GroupVersion().String(),
+			"kind":       "ExternalSecret",
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"spec": map[string]interface{}{
+				"data": []interface{}{
+					map[string]interface{}{
+						"remoteRef": remoteRef,
+						"secretKey": externalSecretKey,
+					},
+				},
+				"secretStoreRef": map[string]interface{}{
+					"kind": secret.ExternalSecret.SecretStoreKind,
+					"name": secret.ExternalSecret.SecretStoreName,
+				},
+				"target": map[string]interface{}{
+					"creationPolicy": "Owner",
+					"name":           name,
+				},
+			},
+		},
+	}
+	obj.SetLabels(k8smeta.InitEnvironmentLabels(cfg, nil))
+	return obj
+}
+
+func newSecretProviderClassObject(cfg *config.Config, secret *config.Secret) *unstructured.Unstructured {
+	parameters := map[string]interface{}{}
+	for key, value := range secret.CSI.Parameters {
+		parameters[key] = value
+	}
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": k8smeta.SecretProviderClassesGVR.GroupVersion().String(),
+			"kind":       "SecretProviderClass",
+			"metadata": map[string]interface{}{
+				"name": getSecretObjectName(cfg, secret),
+			},
+			"spec": map[string]interface{}{
+				"parameters": parameters,
+				"provider":   secret.CSI.Provider,
+			},
+		},
+	}
+	obj.SetLabels(k8smeta.InitEnvironmentLabels(cfg, nil))
+	return obj
+}
+
+// newSecretObject returns the ExternalSecret or SecretProviderClass of a docker compose secret, and the resource of the object.
+func newSecretObject(cfg *config.Config, secret *config.Secret) (schema.GroupVersionResource, *unstructured.Unstructured) {
+	if secret.ExternalSecret != nil {
+		return k8smeta.ExternalSecretsGVR, newExternalSecretObject(cfg, secret)
+	}
+	return k8smeta.SecretProviderClassesGVR, newSecretProviderClassObject(cfg, secret)
+}
+
+// createOrUpdateSecretObject creates the ExternalSecret or SecretProviderClass of a docker compose secret, or updates its spec if it
+// already exists.
+func (u *upRunner) createOrUpdateSecretObject(secret *config.Secret) error {
+	gvr, obj := newSecretObject(u.cfg, secret)
+	return u.createOrUpdateObject(gvr, obj)
+}
+
+// createOrUpdateObject creates an object with the dynamic client, or updates its spec if it already exists and is owned by the
+// environment. The object is recorded in the state.
+func (u *upRunner) createOrUpdateObject(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	err := u.admitObject(obj.Object)
+	if err != nil {
+		return err
+	}
+	client := u.k8sDynamicClient.Resource(gvr).Namespace(u.getNamespace(obj.GetNamespace()))
+	_, err = client.Create(obj, metav1.CreateOptions{})
+	if k8sError.IsAlreadyExists(err) {
+		var existing *unstructured.Unstructured
+		existing, err = client.Get(obj.GetName(), metav1.GetOptions{})
+		if err == nil {
+			err = k8smeta.ValidateOwnership(u.cfg, obj.GetKind(), &metav1.ObjectMeta{
+				Labels: existing.GetLabels(),
+				Name:   existing.GetName(),
+			})
+			if err != nil {
+				return exitcode.Wrap(err, exitcode.Config)
+			}
+			existing.Object["spec"] = obj.Object["spec"]
+			_, err = client.Update(existing, metav1.UpdateOptions{})
+		}
+	}
+	if err != nil {
+		return exitcode.Wrap(fmt.Errorf("error while creating %s %s: %v", obj.GetKind(), obj.GetName(), err), exitcode.ClusterConnectivity)
+	}
+	return u.recordObject(obj.GetKind(), obj.GetName())
+}
+
+// createOrUpdateSecretObjectOnce is like createOrUpdateSecretObject, but only creates or updates each object once, because docker compose
+// secrets can be shared by services.
+func (u *upRunner) createOrUpdateSecretObjectOnce(secret *config.Secret) error {
+	u.secretObjects.mutex.Lock()
+	defer u.secretObjects.mutex.Unlock()
+	if u.secretObjects.errs == nil {
+		u.secretObjects.errs = map[string]error{}
+	}
+	err, ok := u.secretObjects.errs[secret.Name]
+	if !ok {
+		err = u.createOrUpdateSecretObject(secret)
+		u.secretObjects.errs[secret.Name] = err
+	}
+	return err
+}
+
+// createPodSecretObjects creates or updates the ExternalSecrets and SecretProviderClasses of the external docker compose secrets of an
+// app. Other docker compose secrets are ignored.
+func (u *upRunner) createPodSecretObjects(app *app) error {
+	for _, serviceSecret := range app.composeService.DockerComposeService.Secrets {
+		secret := u.cfg.Secrets[serviceSecret.Source]
+		if secret == nil {
+			app.newLogEntry().Warnf("ignoring secret %s because it is not an external secret with \"x-kube-compose\" provider configuration",
+				serviceSecret.Source)
+			continue
+		}
+		err := u.createOrUpdateSecretObjectOnce(secret)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPodSecretVolumes mounts the external docker compose secrets of an app into the container of its pod (see createPodSecretObjects).
+// Secrets provided by the External Secrets Operator are mounted from the Secret created by the operator, and secrets provided by the
+// Secrets Store CSI Driver are mounted through the driver.
+func (u *upRunner) setPodSecretVolumes(app *app, pod *v1.Pod) error {
+	csiVolumes := map[string]*k8smeta.CSIVolumeSource{}
+	for i, serviceSecret := range app.composeService.DockerComposeService.Secrets {
+		secret := u.cfg.Secrets[serviceSecret.Source]
+		if secret == nil {
+			continue
+		}
+		volume := v1.Volume{
+			Name: fmt.Sprintf("secret%d", i+1),
+		}
+		volumeMount := v1.VolumeMount{
+			MountPath: serviceSecret.Target,
+			Name:      volume.Name,
+			ReadOnly:  true,
+		}
+		if secret.ExternalSecret != nil {
+			volume.VolumeSource.Secret = &v1.SecretVolumeSource{
+				SecretName: getSecretObjectName(u.cfg, secret),
+			}
+			volumeMount.SubPath = externalSecretKey
+		} else {
+			// The CSI volume source replaces this placeholder when the pod is created, see k8smeta.CreatePod.
+			volume.VolumeSource.EmptyDir = &v1.EmptyDirVolumeSource{}
+			csiVolumes[volume.Name] = &k8smeta.CSIVolumeSource{
+				Driver:   secretsStoreCSIDriver,
+				ReadOnly: true,
+				VolumeAttributes: map[string]string{
+					"secretProviderClass": getSecretObjectName(u.cfg, secret),
+				},
+			}
+			volumeMount.SubPath = secret.CSI.ObjectName
+		}
+		pod.Spec.Volumes = append(pod.Spec.Volumes, volume)
+		pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, volumeMount)
+	}
+	if len(csiVolumes) == 0 {
+		return nil
+	}
+	return k8smeta.SetCSIVolumes(pod, csiVolumes)
+}
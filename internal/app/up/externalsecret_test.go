@@ -0,0 +1,176 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+var testExternalSecret = &config.Secret{
+	ExternalSecret: &config.ExternalSecret{
+		RemoteKey:       "secret/myapp",
+		RemoteProperty:  "db_password",
+		SecretStoreKind: "ClusterSecretStore",
+		SecretStoreName: "vault-backend",
+	},
+	Name: "db_password",
+}
+
+var testCSISecret = &config.Secret{
+	CSI: &config.CSISecret{
+		ObjectName: "api-key",
+		Parameters: map[string]string{
+			"region": "eu-west-1",
+		},
+		Provider: "aws",
+	},
+	Name: "api_key",
+}
+
+func newTestUpRunnerWithSecrets() *upRunner {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.cfg.EnvironmentID = "env1"
+	u.cfg.EnvironmentLabel = "env"
+	u.cfg.Secrets = map[string]*config.Secret{
+		"db_password": testExternalSecret,
+		"api_key":     testCSISecret,
+	}
+	// Mark the objects of the secrets as created, so that no Kubernetes API is needed.
+	u.secretObjects.errs = map[string]error{
+		"db_password": nil,
+		"api_key":     nil,
+	}
+	return u
+}
+
+func TestNewExternalSecretObject(t *testing.T) {
+	u := newTestUpRunnerWithSecrets()
+	obj := newExternalSecretObject(u.cfg, testExternalSecret)
+	if obj.GetName() != "db9cxpassword-env1" || obj.GetKind() != "ExternalSecret" || obj.GetAPIVersion() != "external-secrets.io/v1beta1" {
+		t.Error(obj)
+	}
+	if !reflect.DeepEqual(obj.GetLabels(), map[string]string{"env": "env1"}) {
+		t.Error(obj.GetLabels())
+	}
+	spec := obj.Object["spec"].(map[string]interface{})
+	expectedData := []interface{}{
+		map[string]interface{}{
+			"remoteRef": map[string]interface{}{
+				"key":      "secret/myapp",
+				"property": "db_password",
+			},
+			"secretKey": externalSecretKey,
+		},
+	}
+	if !reflect.DeepEqual(spec["data"], expectedData) {
+		t.Error(spec["data"])
+	}
+	if !reflect.DeepEqual(spec["target"], map[string]interface{}{"creationPolicy": "Owner", "name": "db9cxpassword-env1"}) {
+		t.Error(spec["target"])
+	}
+}
+
+func TestNewSecretProviderClassObject(t *testing.T) {
+	u := newTestUpRunnerWithSecrets()
+	obj := newSecretProviderClassObject(u.cfg, testCSISecret)
+	if obj.GetName() != "api9cxkey-env1" || obj.GetKind() != "SecretProviderClass" {
+		t.Error(obj)
+	}
+	expectedSpec := map[string]interface{}{
+		"parameters": map[string]interface{}{
+			"region": "eu-west-1",
+		},
+		"provider": "aws",
+	}
+	if !reflect.DeepEqual(obj.Object["spec"], expectedSpec) {
+		t.Error(obj.Object["spec"])
+	}
+}
+
+func TestSetPodSecretVolumes_Success(t *testing.T) {
+	u := newTestUpRunnerWithSecrets()
+	a := u.apps["a"]
+	a.composeService.DockerComposeService.Secrets = []dockerComposeConfig.ServiceSecret{
+		{
+			Source: "db_password",
+			Target: "/run/secrets/db_password",
+		},
+		{
+			Source: "tls_key",
+			Target: "/run/secrets/tls_key",
+		},
+		{
+			Source: "api_key",
+			Target: "/etc/api-key",
+		},
+	}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{},
+			},
+		},
+	}
+	err := u.setPodSecretVolumes(a, pod)
+	if err != nil {
+		t.Error(err)
+	}
+	expectedVolumes := []v1.Volume{
+		{
+			Name: "secret1",
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: "db9cxpassword-env1",
+				},
+			},
+		},
+		{
+			Name: "secret3",
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+	if !reflect.DeepEqual(pod.Spec.Volumes, expectedVolumes) {
+		t.Error(pod.Spec.Volumes)
+	}
+	expectedVolumeMounts := []v1.VolumeMount{
+		{
+			MountPath: "/run/secrets/db_password",
+			Name:      "secret1",
+			ReadOnly:  true,
+			SubPath:   externalSecretKey,
+		},
+		{
+			MountPath: "/etc/api-key",
+			Name:      "secret3",
+			ReadOnly:  true,
+			SubPath:   "api-key",
+		},
+	}
+	if !reflect.DeepEqual(pod.Spec.Containers[0].VolumeMounts, expectedVolumeMounts) {
+		t.Error(pod.Spec.Containers[0].VolumeMounts)
+	}
+	if pod.ObjectMeta.Annotations[k8smeta.CSIVolumesAnnotationName] == "" {
+		t.Error(pod.ObjectMeta.Annotations)
+	}
+}
+
+func TestSetPodSecretVolumes_NoSecrets(t *testing.T) {
+	u := newTestUpRunnerWithSecrets()
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{},
+			},
+		},
+	}
+	err := u.setPodSecretVolumes(u.apps["b"], pod)
+	if err != nil || pod.Spec.Volumes != nil || pod.ObjectMeta.Annotations != nil {
+		t.Fail()
+	}
+}
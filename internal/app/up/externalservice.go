@@ -0,0 +1,109 @@
+package up
+
+import (
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getExternalServiceHost returns the fully qualified host name of an external service. A host without dots, such as the container name
+// of an external link, is assumed to be the name of a Kubernetes service in the namespace of the environment.
+func getExternalServiceHost(cfg *config.Config, host string) string {
+	if strings.Contains(host, ".") {
+		return host
+	}
+	return host + "." + cfg.Namespace + ".svc.cluster.local"
+}
+
+// newExternalNameService returns an ExternalName service for an external service. Pods resolve external services through DNS, so unlike
+// the Kubernetes services of docker compose services the service is named after the alias, without project prefix and environment suffix.
+// The service is labelled with the environment but has no service annotation, so that down always deletes it.
+func newExternalNameService(cfg *config.Config, alias, host string) *v1.Service {
+	return &v1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: k8smeta.InitEnvironmentLabels(cfg, nil),
+			Name:   alias,
+		},
+		Spec: v1.ServiceSpec{
+			ExternalName: getExternalServiceHost(cfg, host),
+			Type:         v1.ServiceTypeExternalName,
+		},
+	}
+}
+
+// createExternalServices creates or updates the ExternalName services of the external links and external services of the configuration.
+// Pods resolve external services in their own namespace, so the services are created in each namespace of the docker compose services.
+func (u *upRunner) createExternalServices() error {
+	aliases := make([]string, 0, len(u.cfg.ExternalServices))
+	for alias := range u.cfg.ExternalServices {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		service := newExternalNameService(u.cfg, alias, u.cfg.ExternalServices[alias])
+		err := u.admitObject(service)
+		if err != nil {
+			return err
+		}
+		for _, namespace := range u.cfg.Namespaces() {
+			err = u.createExternalService(namespace, service)
+			if err != nil {
+				return err
+			}
+		}
+		err = u.recordObject("Service", alias)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createExternalService creates or updates the ExternalName service of an external service in a namespace.
+func (u *upRunner) createExternalService(namespace string, service *v1.Service) error {
+	_, err := u.serviceClient(namespace).Create(service)
+	switch {
+	case k8sError.IsAlreadyExists(err):
+		return u.updateExternalService(namespace, service)
+	case err != nil:
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	log.Infof("created k8s service %s for external host %s", service.Name, service.Spec.ExternalName)
+	return nil
+}
+
+// updateExternalService updates the host of an existing ExternalName service in a namespace, after verifying that the service is owned by
+// the environment and project.
+func (u *upRunner) updateExternalService(namespace string, service *v1.Service) error {
+	client := u.serviceClient(namespace)
+	existing, err := client.Get(service.Name, metav1.GetOptions{})
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	err = k8smeta.ValidateOwnership(u.cfg, "Service", &existing.ObjectMeta)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	if existing.Spec.Type == service.Spec.Type && existing.Spec.ExternalName == service.Spec.ExternalName {
+		log.Debugf("k8s service %s already exists", service.Name)
+		return nil
+	}
+	existing.Spec = service.Spec
+	_, err = client.Update(existing)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	log.Infof("updated k8s service %s for external host %s", service.Name, service.Spec.ExternalName)
+	return nil
+}
@@ -0,0 +1,41 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestGetExternalServiceHost_Qualified(t *testing.T) {
+	cfg := &config.Config{
+		Namespace: "ns",
+	}
+	host := getExternalServiceHost(cfg, "mysql.example.com")
+	if host != "mysql.example.com" {
+		t.Error(host)
+	}
+}
+
+func TestGetExternalServiceHost_ServiceName(t *testing.T) {
+	cfg := &config.Config{
+		Namespace: "ns",
+	}
+	host := getExternalServiceHost(cfg, "redis")
+	if host != "redis.ns.svc.cluster.local" {
+		t.Error(host)
+	}
+}
+
+func TestNewExternalNameService_Success(t *testing.T) {
+	cfg := &config.Config{
+		EnvironmentID:    "123",
+		EnvironmentLabel: "env",
+		Namespace:        "ns",
+	}
+	service := newExternalNameService(cfg, "mysql", "mysql.example.com")
+	if service.Name != "mysql" || service.Labels["env"] != "123" || len(service.Annotations) != 0 ||
+		service.Spec.Type != v1.ServiceTypeExternalName || service.Spec.ExternalName != "mysql.example.com" {
+		t.Error(service)
+	}
+}
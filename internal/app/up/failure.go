@@ -0,0 +1,85 @@
+package up
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+)
+
+const dependencyWaitTimeoutCheckInterval = time.Second
+
+// handleAppFailure applies the failure policy of an app that failed with err. If the policy is to abort then err is returned. Otherwise
+// the app and its (indirect) dependents that are yet to be started are skipped, and nil is returned.
+func (u *upRunner) handleAppFailure(app *app, err error) error {
+	u.emitEvent(app, EventFailed, err)
+	if app.composeService.OnFailure != config.FailurePolicySkip {
+		return err
+	}
+	if app.failed {
+		return nil
+	}
+	app.newLogEntry().Warnf("%v, skipping services that depend on %s", err, app.name())
+	u.skipApp(app)
+	return nil
+}
+
+// skipApp marks an app as failed so that it is no longer waited for, and skips its (indirect) dependents that are yet to be started. The
+// sidecars of skipped apps are skipped too, because their containers run in the pods of the skipped apps (see config.PodGroup).
+func (u *upRunner) skipApp(app *app) {
+	app.failed = true
+	delete(u.appsThatNeedToBeReady, app)
+	for app1 := range u.appsToBeStarted {
+		dependsOn, _ := app1.getPodDependsOn()
+		if _, ok := dependsOn[app.name()]; ok {
+			app1.newLogEntry().Warnf("skipping service %s because its dependency %s failed", app1.name(), app.name())
+			delete(u.appsToBeStarted, app1)
+			for _, app2 := range app1.podApps {
+				u.skipApp(app2)
+			}
+		}
+	}
+}
+
+// hasDependencyWaitTimeouts returns true if and only if any app has a wait timeout.
+func (u *upRunner) hasDependencyWaitTimeouts() bool {
+	for _, app := range u.apps {
+		if app.composeService.WaitTimeout != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDependencyWaitTimeouts applies the failure policies of apps that have not satisfied the depends_on conditions of apps that are
+// yet to be started within their wait timeouts.
+func (u *upRunner) checkDependencyWaitTimeouts() error {
+	for app1 := range u.appsToBeStarted {
+		dependsOn, _ := app1.getPodDependsOn()
+		for name, healthiness := range dependsOn {
+			app2 := u.apps[u.cfg.Services[name].Name()]
+			waitTimeout := app2.composeService.WaitTimeout
+			if waitTimeout == nil || app2.failed || app2.podCreationTime.IsZero() || time.Since(app2.podCreationTime) < *waitTimeout {
+				continue
+			}
+			satisfied, err := u.isDependsOnConditionSatisfied(app1, app2, healthiness)
+			if err == nil && !satisfied {
+				condition := "started"
+				if healthiness == dockerComposeConfig.ServiceHealthy {
+					condition = "healthy"
+				}
+				err = exitcode.Wrap(fmt.Errorf("docker compose service %s did not become %s within %v", app2.name(), condition,
+					*waitTimeout), exitcode.ReadinessTimeout)
+			}
+			if err != nil {
+				err = u.handleAppFailure(app2, err)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
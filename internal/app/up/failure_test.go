@@ -0,0 +1,100 @@
+package up
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+)
+
+func newTestUpRunnerWithFailedDependency() *upRunner {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.appsThatNeedToBeReady = map[*app]bool{}
+	for _, name := range []string{"b", "c", "d"} {
+		delete(u.appsToBeStarted, u.apps[name])
+		u.appsThatNeedToBeReady[u.apps[name]] = true
+	}
+	return u
+}
+
+func TestHandleAppFailure_Abort(t *testing.T) {
+	u := newTestUpRunnerWithFailedDependency()
+	err := fmt.Errorf("test error")
+	if u.handleAppFailure(u.apps["c"], err) != err || u.apps["c"].failed || !u.appsToBeStarted[u.apps["a"]] {
+		t.Fail()
+	}
+}
+
+func TestHandleAppFailure_Skip(t *testing.T) {
+	u := newTestUpRunnerWithFailedDependency()
+	u.apps["c"].composeService.OnFailure = config.FailurePolicySkip
+	err := u.handleAppFailure(u.apps["c"], fmt.Errorf("test error"))
+	if err != nil {
+		t.Error(err)
+	}
+	if !u.apps["c"].failed || u.appsThatNeedToBeReady[u.apps["c"]] {
+		t.Fail()
+	}
+	if !u.apps["a"].failed || len(u.appsToBeStarted) != 0 {
+		t.Fail()
+	}
+	if !u.appsThatNeedToBeReady[u.apps["d"]] {
+		t.Fail()
+	}
+}
+
+func TestCheckDependencyWaitTimeouts_NotExpired(t *testing.T) {
+	u := newTestUpRunnerWithFailedDependency()
+	waitTimeout := time.Hour
+	u.apps["c"].composeService.WaitTimeout = &waitTimeout
+	u.apps["c"].podCreationTime = time.Now()
+	err := u.checkDependencyWaitTimeouts()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckDependencyWaitTimeouts_ExpiredAbort(t *testing.T) {
+	u := newTestUpRunnerWithFailedDependency()
+	waitTimeout := time.Minute
+	u.apps["c"].composeService.WaitTimeout = &waitTimeout
+	u.apps["c"].podCreationTime = time.Now().Add(-time.Hour)
+	err := u.checkDependencyWaitTimeouts()
+	if err == nil {
+		t.Fail()
+	} else {
+		if err.Error() != "docker compose service c did not become healthy within 1m0s" {
+			t.Error(err)
+		}
+		if exitcode.FromError(err) != exitcode.ReadinessTimeout {
+			t.Fail()
+		}
+	}
+}
+
+func TestCheckDependencyWaitTimeouts_ExpiredSkip(t *testing.T) {
+	u := newTestUpRunnerWithFailedDependency()
+	waitTimeout := time.Minute
+	u.apps["c"].composeService.WaitTimeout = &waitTimeout
+	u.apps["c"].composeService.OnFailure = config.FailurePolicySkip
+	u.apps["c"].podCreationTime = time.Now().Add(-time.Hour)
+	err := u.checkDependencyWaitTimeouts()
+	if err != nil {
+		t.Error(err)
+	}
+	if !u.apps["c"].failed || len(u.appsToBeStarted) != 0 {
+		t.Fail()
+	}
+}
+
+func TestGetAppsThatCanBeStarted_DependencyCompletedWithoutBecomingHealthySkip(t *testing.T) {
+	u := newTestUpRunnerWithFailedDependency()
+	u.apps["c"].composeService.OnFailure = config.FailurePolicySkip
+	u.apps["c"].maxObservedPodStatus = podStatusCompleted
+	wave, err := u.getAppsThatCanBeStarted()
+	if err != nil || len(wave) != 0 || !u.apps["a"].failed {
+		t.Fail()
+	}
+}
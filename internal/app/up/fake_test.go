@@ -0,0 +1,221 @@
+package up
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/revision"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"github.com/kube-compose/kube-compose/pkg/docker/dockertest"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sWatch "k8s.io/apimachinery/pkg/watch"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+const (
+	testNginxImageID    = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	testPostgresImageID = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+// newFakeClientset returns a fake clientset that behaves like a cluster where services are assigned cluster IPs and pods become ready as
+// soon as they are created. The reactors of the fake clientset act on copies of actions, so the reactors that modify created objects add
+// them to the object tracker themselves.
+func newFakeClientset(objects ...runtime.Object) *fake.Clientset {
+	tracker := k8sTesting.NewObjectTracker(scheme.Scheme, scheme.Codecs.UniversalDecoder())
+	for _, obj := range objects {
+		if err := tracker.Add(obj); err != nil {
+			panic(err)
+		}
+	}
+	clientset := fake.NewSimpleClientset()
+	clientset.ReactionChain = nil
+	clientset.WatchReactionChain = nil
+	objectReaction := k8sTesting.ObjectReaction(tracker)
+	clientset.AddReactor("*", "*", objectReaction)
+	clientset.AddWatchReactor("*", func(action k8sTesting.Action) (bool, k8sWatch.Interface, error) {
+		w, err := tracker.Watch(action.GetResource(), action.GetNamespace())
+		return err == nil, w, err
+	})
+	clusterIPCount := 0
+	clientset.PrependReactor("create", "services", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		service := action.(k8sTesting.CreateAction).GetObject().(*v1.Service)
+		clusterIPCount++
+		service.Spec.ClusterIP = fmt.Sprintf("10.0.0.%d", clusterIPCount)
+		return objectReaction(action)
+	})
+	clientset.PrependReactor("create", "pods", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		pod := action.(k8sTesting.CreateAction).GetObject().(*v1.Pod)
+		pod.Status.Phase = v1.PodRunning
+		pod.Status.Conditions = []v1.PodCondition{
+			{
+				Status: v1.ConditionTrue,
+				Type:   v1.PodReady,
+			},
+		}
+		return objectReaction(action)
+	})
+	return clientset
+}
+
+// withFakeCluster runs cb with up connecting to clientset and a fake docker daemon, instead of a cluster and docker daemon. The daemon
+// has the image of the docker compose service db, and the image of the docker compose service web can be pulled.
+func withFakeCluster(clientset *fake.Clientset, cb func(d *dockertest.Daemon)) {
+	d := dockertest.NewDaemon()
+	defer d.Close()
+	d.AddImage(&dockertest.Image{
+		ID:       testPostgresImageID,
+		RepoTags: []string{"postgres:11.5"},
+	})
+	_, _ = d.Registry.AddImage("nginx:1.17", &dockertest.Image{
+		ID: testNginxImageID,
+	})
+	origNewClients := k8smeta.NewClients
+	origNewDockerClient := newDockerClient
+	defer func() {
+		k8smeta.NewClients = origNewClients
+		newDockerClient = origNewDockerClient
+	}()
+	k8smeta.NewClients = func(_ *rest.Config) (*k8smeta.Clients, error) {
+		return &k8smeta.Clients{
+			Clientset: clientset,
+			Dynamic:   dynamicFake.NewSimpleDynamicClient(runtime.NewScheme()),
+		}, nil
+	}
+	newDockerClient = d.NewClient
+	cb(d)
+}
+
+func newFakeClusterTestConfig() *config.Config {
+	cfg := &config.Config{
+		EnvironmentID:    "test",
+		EnvironmentLabel: "env",
+		Namespace:        "default",
+		ProjectName:      "project",
+	}
+	db := cfg.AddService(&dockerComposeConfig.Service{
+		Image: "postgres:11.5",
+		Name:  "db",
+		Ports: []dockerComposeConfig.PortBinding{
+			{
+				Internal: 5432,
+				Protocol: "tcp",
+			},
+		},
+	})
+	db.Ports = []config.Port{
+		{
+			Port:     5432,
+			Protocol: "tcp",
+		},
+	}
+	web := cfg.AddService(&dockerComposeConfig.Service{
+		Image: "nginx:1.17",
+		Name:  "web",
+	})
+	cfg.AddToFilter(db)
+	cfg.AddToFilter(web)
+	return cfg
+}
+
+func newFakeClusterTestOptions() *Options {
+	return &Options{
+		Context:  context.Background(),
+		Detach:   true,
+		Reporter: reporter.New(ioutil.Discard),
+	}
+}
+
+func TestRun_FakeClusterSuccess(t *testing.T) {
+	clientset := newFakeClientset()
+	withFakeCluster(clientset, func(d *dockertest.Daemon) {
+		cfg := newFakeClusterTestConfig()
+		err := Run(cfg, newFakeClusterTestOptions())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !reflect.DeepEqual(d.Pulls(), []string{"nginx:1.17"}) {
+			t.Error(d.Pulls())
+		}
+		podList, err := clientset.CoreV1().Pods("default").List(metav1.ListOptions{})
+		if err != nil || len(podList.Items) != 2 {
+			t.Error(podList, err)
+		}
+		// The pod of a pulled image refers to the image by digest.
+		nginxImage := "nginx@" + d.Registry.Digest("nginx:1.17")
+		for _, pod := range podList.Items {
+			if pod.Spec.Containers[0].Image != "postgres:11.5" && pod.Spec.Containers[0].Image != nginxImage {
+				t.Error(pod.Spec.Containers[0].Image)
+			}
+			if len(pod.Spec.HostAliases) != 1 || pod.Spec.HostAliases[0].IP != "10.0.0.1" {
+				t.Error(pod.Spec.HostAliases)
+			}
+		}
+		service, err := clientset.CoreV1().Services("default").Get("project-db-test", metav1.GetOptions{})
+		if err != nil || service.Spec.Ports[0].Port != 5432 {
+			t.Error(service, err)
+		}
+		revisions, err := revision.NewStore(cfg, clientset.CoreV1().ConfigMaps("default")).List()
+		if err != nil || len(revisions) != 1 || revisions[0].Number != 1 || len(revisions[0].Services) != 2 ||
+			revisions[0].Services["web"].Image != nginxImage || revisions[0].Services["web"].ImageID != testNginxImageID ||
+			revisions[0].Services["web"].Hash == "" {
+			t.Error(revisions, err)
+		}
+	})
+}
+
+func TestRun_FakeClusterPodOwnedByOtherEnvironment(t *testing.T) {
+	clientset := newFakeClientset(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "project-db-test",
+			Namespace: "default",
+		},
+	})
+	withFakeCluster(clientset, func(_ *dockertest.Daemon) {
+		err := Run(newFakeClusterTestConfig(), newFakeClusterTestOptions())
+		if exitcode.FromError(err) != exitcode.Config {
+			t.Error(err)
+		}
+	})
+}
+
+func TestRun_FakeClusterCreatePodError(t *testing.T) {
+	clientset := newFakeClientset()
+	clientset.PrependReactor("create", "pods", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8sError.NewInternalError(fmt.Errorf("etcdserver: request timed out"))
+	})
+	withFakeCluster(clientset, func(_ *dockertest.Daemon) {
+		err := Run(newFakeClusterTestConfig(), newFakeClusterTestOptions())
+		if exitcode.FromError(err) != exitcode.ClusterConnectivity {
+			t.Error(err)
+		}
+	})
+}
+
+func TestRun_FakeClusterResourceQuotasForbidden(t *testing.T) {
+	clientset := newFakeClientset()
+	clientset.PrependReactor("list", "resourcequotas", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8sError.NewForbidden(schema.GroupResource{Resource: "resourcequotas"}, "", fmt.Errorf("forbidden"))
+	})
+	withFakeCluster(clientset, func(_ *dockertest.Daemon) {
+		err := Run(newFakeClusterTestConfig(), newFakeClusterTestOptions())
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package up
+
+import (
+	"fmt"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// hasResource returns true if and only if the cluster serves the resource.
+func (u *upRunner) hasResource(gvr schema.GroupVersionResource) (bool, error) {
+	resourceList, err := u.k8sClientset.Discovery().ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if k8sError.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	for _, resource := range resourceList.APIResources {
+		if resource.Name == gvr.Resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkExposeMode verifies that the cluster has the Gateway API installed if services are exposed through the Gateway API. TCPRoutes are
+// only part of the experimental channel of the Gateway API, so TCP ports are not exposed if the cluster does not serve TCPRoutes.
+func (u *upRunner) checkExposeMode() error {
+	if u.opts.ExposeMode != ExposeModeGateway {
+		return nil
+	}
+	ok, err := u.hasResource(k8smeta.HTTPRoutesGVR)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return exitcode.Wrap(fmt.Errorf("services cannot be exposed through the Gateway API, because the cluster does not serve %s",
+			k8smeta.HTTPRoutesGVR.GroupResource()), exitcode.Config)
+	}
+	u.tcpRoutesSupported, err = u.hasResource(k8smeta.TCPRoutesGVR)
+	if err != nil {
+		return err
+	}
+	if !u.tcpRoutesSupported {
+		log.Warnf("published TCP ports are not exposed through the Gateway API, because the cluster does not serve %s",
+			k8smeta.TCPRoutesGVR.GroupResource())
+	}
+	return nil
+}
+
+// newGatewayParentRef returns the reference to the Gateway of the routes of a docker compose service. A Gateway without namespace is in
+// the namespace of the environment, which has to be set explicitly if the service is in another namespace.
+func newGatewayParentRef(cfg *config.Config, opts *Options, composeService *config.Service) map[string]interface{} {
+	parentRef := map[string]interface{}{
+		"group": k8smeta.HTTPRoutesGVR.Group,
+		"kind":  "Gateway",
+		"name":  opts.GatewayName,
+	}
+	if opts.GatewayNamespace != "" {
+		parentRef["namespace"] = opts.GatewayNamespace
+	} else if cfg.GetNamespace(composeService) != cfg.Namespace {
+		parentRef["namespace"] = cfg.Namespace
+	}
+	return parentRef
+}
+
+func newServiceBackendRef(cfg *config.Config, composeService *config.Service, port int32) map[string]interface{} {
+	return map[string]interface{}{
+		"name": k8smeta.GetK8sName(composeService, cfg),
+		"port": int64(port),
+	}
+}
+
+// newHTTPRouteObject returns the HTTPRoute that routes the host of the "x-kube-compose"."ingress" of a docker compose service. TLS is
+// terminated by the listeners of the Gateway.
+func newHTTPRouteObject(cfg *config.Config, opts *Options, composeService *config.Service) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": k8smeta.HTTPRoutesGVR.GroupVersion().String(),
+			"kind":       "HTTPRoute",
+			"spec": map[string]interface{}{
+				"hostnames":  []interface{}{composeService.Ingress.Host},
+				"parentRefs": []interface{}{newGatewayParentRef(cfg, opts, composeService)},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"backendRefs": []interface{}{newServiceBackendRef(cfg, composeService, composeService.Ingress.Port)},
+					},
+				},
+			},
+		},
+	}
+	setServiceObjectMeta(cfg, obj, composeService)
+	return obj
+}
+
+// newTCPRouteObjects returns a TCPRoute for each published TCP port of a docker compose service, except the port of its HTTPRoute. Each
+// TCPRoute attaches to the listener of the Gateway whose port is the published (host) port, so that the port can be reached like with
+// docker compose. Ports that are published on a random host port are not routed.
+func newTCPRouteObjects(cfg *config.Config, opts *Options, composeService *config.Service) []*unstructured.Unstructured {
+	var objs []*unstructured.Unstructured
+	for _, port := range composeService.DockerComposeService.Ports {
+		if port.Protocol != "tcp" || port.ExternalMin <= 0 || port.ExternalMin != port.ExternalMax ||
+			(composeService.Ingress != nil && composeService.Ingress.Port == port.Internal) {
+			continue
+		}
+		parentRef := newGatewayParentRef(cfg, opts, composeService)
+		parentRef["port"] = int64(port.ExternalMin)
+		obj := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": k8smeta.TCPRoutesGVR.GroupVersion().String(),
+				"kind":       "TCPRoute",
+				"spec": map[string]interface{}{
+					"parentRefs": []interface{}{parentRef},
+					"rules": []interface{}{
+						map[string]interface{}{
+							"backendRefs": []interface{}{newServiceBackendRef(cfg, composeService, port.Internal)},
+						},
+					},
+				},
+			},
+		}
+		setServiceObjectMeta(cfg, obj, composeService)
+		obj.SetName(k8smeta.GetResourceName(cfg, composeService.NameEscaped+"-tcp"+strconv.Itoa(int(port.ExternalMin)),
+			validation.DNS1123SubdomainMaxLength))
+		objs = append(objs, obj)
+	}
+	return objs
+}
+
+// createOrUpdateRoutes creates the HTTPRoute and TCPRoutes of an app.
+func (u *upRunner) createOrUpdateRoutes(app *app) error {
+	if ingress := app.composeService.Ingress; ingress != nil {
+		if ingress.TLS != nil {
+			app.newLogEntry().Warnf("ignoring \"x-kube-compose\".\"ingress\".\"tls\", because TLS is terminated by the listeners of the " +
+				"Gateway")
+		}
+		obj := newHTTPRouteObject(u.cfg, u.opts, app.composeService)
+		err := u.createOrUpdateObject(k8smeta.HTTPRoutesGVR, obj)
+		if err != nil {
+			return err
+		}
+		app.newLogEntry().Infof("created or updated HTTPRoute %s for host %s", obj.GetName(), ingress.Host)
+	}
+	if !u.tcpRoutesSupported {
+		return nil
+	}
+	for _, obj := range newTCPRouteObjects(u.cfg, u.opts, app.composeService) {
+		err := u.createOrUpdateObject(k8smeta.TCPRoutesGVR, obj)
+		if err != nil {
+			return err
+		}
+		app.newLogEntry().Infof("created or updated TCPRoute %s", obj.GetName())
+	}
+	return nil
+}
+
+// exposeApp exposes an app outside of the cluster, depending on the expose mode.
+func (u *upRunner) exposeApp(app *app) error {
+	if u.opts.ExposeMode == ExposeModeGateway {
+		return u.createOrUpdateRoutes(app)
+	}
+	if u.capabilities.noIngressController {
+		return nil
+	}
+	return u.createOrUpdateIngress(app)
+}
@@ -0,0 +1,86 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestGatewayOptions() *Options {
+	return &Options{
+		ExposeMode:       ExposeModeGateway,
+		GatewayName:      "public",
+		GatewayNamespace: "infra",
+	}
+}
+
+func TestNewHTTPRouteObject(t *testing.T) {
+	cfg := newTestIngressConfig(nil)
+	obj := newHTTPRouteObject(cfg, newTestGatewayOptions(), cfg.Services["a"])
+	hostnames, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "hostnames")
+	parentRefs, _, _ := unstructured.NestedSlice(obj.Object, "spec", "parentRefs")
+	expectedParentRefs := []interface{}{
+		map[string]interface{}{
+			"group":     "gateway.networking.k8s.io",
+			"kind":      "Gateway",
+			"name":      "public",
+			"namespace": "infra",
+		},
+	}
+	if obj.GetName() != "a-myenv" || !reflect.DeepEqual(hostnames, []string{"a.example.com"}) ||
+		!reflect.DeepEqual(parentRefs, expectedParentRefs) {
+		t.Error(obj)
+	}
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	expectedRules := []interface{}{
+		map[string]interface{}{
+			"backendRefs": []interface{}{
+				map[string]interface{}{
+					"name": "a-myenv",
+					"port": int64(8080),
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(rules, expectedRules) {
+		t.Error(rules)
+	}
+}
+
+func TestNewTCPRouteObjects(t *testing.T) {
+	cfg := newTestIngressConfig(nil)
+	cfg.Services["a"].DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		// The port of the HTTPRoute.
+		{Internal: 8080, ExternalMin: 80, ExternalMax: 80, Protocol: "tcp"},
+		{Internal: 5432, ExternalMin: 15432, ExternalMax: 15432, Protocol: "tcp"},
+		// Not published.
+		{Internal: 6379, ExternalMin: -1, Protocol: "tcp"},
+		// Published on a random host port.
+		{Internal: 9000, ExternalMin: 9000, ExternalMax: 9010, Protocol: "tcp"},
+		{Internal: 53, ExternalMin: 53, ExternalMax: 53, Protocol: "udp"},
+	}
+	objs := newTCPRouteObjects(cfg, newTestGatewayOptions(), cfg.Services["a"])
+	if len(objs) != 1 {
+		t.Error(objs)
+		return
+	}
+	obj := objs[0]
+	parentRefs, _, _ := unstructured.NestedSlice(obj.Object, "spec", "parentRefs")
+	port, _, _ := unstructured.NestedInt64(parentRefs[0].(map[string]interface{}), "port")
+	if obj.GetName() != "a-tcp15432-myenv" || obj.GetKind() != "TCPRoute" || port != 15432 {
+		t.Error(obj)
+	}
+}
+
+func TestNewTCPRouteObjects_WithoutIngress(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Services["a"].DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 8080, ExternalMin: 80, ExternalMax: 80, Protocol: "tcp"},
+	}
+	objs := newTCPRouteObjects(cfg, newTestGatewayOptions(), cfg.Services["a"])
+	if len(objs) != 1 {
+		t.Error(objs)
+	}
+}
@@ -0,0 +1,53 @@
+package up
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+// splitGroupAdd splits the groups of group_add into GIDs and names of groups.
+func splitGroupAdd(groups []string) (gids []int64, names []string) {
+	for _, group := range groups {
+		gid, err := strconv.ParseInt(group, 10, 64)
+		if err == nil && gid >= 0 {
+			gids = append(gids, gid)
+		} else {
+			names = append(names, group)
+		}
+	}
+	return gids, names
+}
+
+// getAppImageInfoSupplementalGroups resolves the groups of group_add of an app to GIDs. Like docker, names of groups are resolved using the
+// /etc/group file of the app's image.
+func (u *upRunner) getAppImageInfoSupplementalGroups(a *app, sourceImage string) error {
+	gids, names := splitGroupAdd(a.composeService.DockerComposeService.GroupAdd)
+	if len(names) > 0 {
+		namedGIDs, err := getGIDsFromImage(u.opts.Context, u.dockerClient, a.imageInfo.sourceImageID, names)
+		if err != nil {
+			return errors.Wrapf(err, "error getting the gids of group_add of docker compose service %s from image %#v", a.name(),
+				sourceImage)
+		}
+		gids = append(gids, namedGIDs...)
+	}
+	a.imageInfo.supplementalGroups = gids
+	return nil
+}
+
+// setPodSupplementalGroups makes the containers of a pod members of the groups of group_add. Supplemental groups apply to all containers of
+// a pod, so the containers of a pod group are members of the groups of all containers of the group.
+func setPodSupplementalGroups(a *app, pod *v1.Pod) {
+	var supplementalGroups []int64
+	for _, a2 := range a.podApps {
+		supplementalGroups = append(supplementalGroups, a2.imageInfo.supplementalGroups...)
+	}
+	if len(supplementalGroups) == 0 {
+		return
+	}
+	if pod.Spec.SecurityContext == nil {
+		pod.Spec.SecurityContext = &v1.PodSecurityContext{}
+	}
+	pod.Spec.SecurityContext.SupplementalGroups = supplementalGroups
+}
@@ -0,0 +1,29 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestSplitGroupAdd(t *testing.T) {
+	gids, names := splitGroupAdd([]string{"1000", "video", "-1", "44"})
+	if !reflect.DeepEqual(gids, []int64{1000, 44}) || !reflect.DeepEqual(names, []string{"video", "-1"}) {
+		t.Error(gids, names)
+	}
+}
+
+func TestSetPodSupplementalGroups(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	pod := &v1.Pod{}
+	setPodSupplementalGroups(u.apps["a"], pod)
+	if pod.Spec.SecurityContext != nil {
+		t.Error(pod.Spec.SecurityContext)
+	}
+	u.apps["a"].imageInfo.supplementalGroups = []int64{44, 1000}
+	setPodSupplementalGroups(u.apps["a"], pod)
+	if pod.Spec.SecurityContext == nil || !reflect.DeepEqual(pod.Spec.SecurityContext.SupplementalGroups, []int64{44, 1000}) {
+		t.Error(pod.Spec.SecurityContext)
+	}
+}
@@ -0,0 +1,85 @@
+package up
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+)
+
+// hostPortKey identifies a container port of a pod, or a port of a node.
+type hostPortKey struct {
+	port     int32
+	protocol string
+}
+
+// hostPort is the port of the node that a container port is published on (see Options.HostPorts).
+type hostPort struct {
+	hostIP string
+	port   int32
+}
+
+// allocateHostPorts chooses the host ports of the published ports of all apps, so that conflicts between docker compose services are
+// reported before anything is applied instead of leaving pods pending. Fixed host ports are allocated first, and a range of host ports
+// (e.g. '9000-9010:80') gets the lowest port of the range that is not taken. Like docker compose, only the first binding of a container
+// port is published, and ports without a host port (e.g. '80') are not published.
+func (u *upRunner) allocateHostPorts() error {
+	if !u.opts.HostPorts {
+		return nil
+	}
+	names := make([]string, 0, len(u.apps))
+	for name := range u.apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	owners := map[hostPortKey]string{}
+	for _, fixed := range []bool{true, false} {
+		for _, name := range names {
+			err := allocateAppHostPorts(u.apps[name], owners, fixed)
+			if err != nil {
+				return exitcode.Wrap(err, exitcode.Config)
+			}
+		}
+	}
+	return nil
+}
+
+func allocateAppHostPorts(a *app, owners map[hostPortKey]string, fixed bool) error {
+	for _, port := range a.composeService.DockerComposeService.Ports {
+		if port.ExternalMin <= 0 || (port.ExternalMin == port.ExternalMax) != fixed {
+			continue
+		}
+		containerPort := hostPortKey{
+			port:     port.Internal,
+			protocol: port.Protocol,
+		}
+		if _, ok := a.hostPorts[containerPort]; ok {
+			continue
+		}
+		allocated := int32(0)
+		for p := port.ExternalMin; p <= port.ExternalMax; p++ {
+			if _, ok := owners[hostPortKey{port: p, protocol: port.Protocol}]; !ok {
+				allocated = p
+				break
+			}
+		}
+		if allocated == 0 {
+			if fixed {
+				owner := owners[hostPortKey{port: port.ExternalMin, protocol: port.Protocol}]
+				return fmt.Errorf("services %s and %s both publish %s port %d on the host", owner, a.name(), port.Protocol,
+					port.ExternalMin)
+			}
+			return fmt.Errorf("service %s publishes %s port %d on a host port in the range %d-%d, but all ports of the range are "+
+				"taken by other services", a.name(), port.Protocol, port.Internal, port.ExternalMin, port.ExternalMax)
+		}
+		owners[hostPortKey{port: allocated, protocol: port.Protocol}] = a.name()
+		if a.hostPorts == nil {
+			a.hostPorts = map[hostPortKey]hostPort{}
+		}
+		a.hostPorts[containerPort] = hostPort{
+			hostIP: port.Host,
+			port:   allocated,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTestUpRunnerHostPorts() *upRunner {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.opts.HostPorts = true
+	return u
+}
+
+func TestAllocateHostPorts_Success(t *testing.T) {
+	u := newTestUpRunnerHostPorts()
+	u.apps["a"].composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: 8080, ExternalMax: 8081, Protocol: "tcp"},
+		{Internal: 6379, ExternalMin: -1, Protocol: "tcp"},
+	}
+	u.apps["b"].composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: 8080, ExternalMax: 8080, Protocol: "tcp", Host: "127.0.0.1"},
+		{Internal: 53, ExternalMin: 8080, ExternalMax: 8080, Protocol: "udp"},
+	}
+	err := u.allocateHostPorts()
+	if err != nil {
+		t.Error(err)
+	}
+	if hp := u.apps["a"].hostPorts[hostPortKey{port: 80, protocol: "tcp"}]; hp.port != 8081 {
+		t.Error(hp)
+	}
+	if _, ok := u.apps["a"].hostPorts[hostPortKey{port: 6379, protocol: "tcp"}]; ok {
+		t.Fail()
+	}
+	if hp := u.apps["b"].hostPorts[hostPortKey{port: 80, protocol: "tcp"}]; hp.port != 8080 || hp.hostIP != "127.0.0.1" {
+		t.Error(hp)
+	}
+	if hp := u.apps["b"].hostPorts[hostPortKey{port: 53, protocol: "udp"}]; hp.port != 8080 {
+		t.Error(hp)
+	}
+}
+
+func TestAllocateHostPorts_Conflict(t *testing.T) {
+	u := newTestUpRunnerHostPorts()
+	for _, name := range []string{"a", "b"} {
+		u.apps[name].composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+			{Internal: 80, ExternalMin: 8080, ExternalMax: 8080, Protocol: "tcp"},
+		}
+	}
+	err := u.allocateHostPorts()
+	if err == nil || err.Error() != "services a and b both publish tcp port 8080 on the host" {
+		t.Error(err)
+	}
+}
+
+func TestAllocateHostPorts_RangeExhausted(t *testing.T) {
+	u := newTestUpRunnerHostPorts()
+	u.apps["a"].composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: 8080, ExternalMax: 8080, Protocol: "tcp"},
+	}
+	u.apps["b"].composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: 8079, ExternalMax: 8080, Protocol: "tcp"},
+	}
+	u.apps["c"].composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: 8079, ExternalMax: 8080, Protocol: "tcp"},
+	}
+	err := u.allocateHostPorts()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestAllocateHostPorts_Disabled(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.apps["a"].composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: 8080, ExternalMax: 8080, Protocol: "tcp"},
+	}
+	err := u.allocateHostPorts()
+	if err != nil || u.apps["a"].hostPorts != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetContainerPorts_HostPort(t *testing.T) {
+	u := newTestUpRunnerHostPorts()
+	a := u.apps["a"]
+	a.composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: 8080, ExternalMax: 8080, Protocol: "tcp"},
+		{Internal: 80, ExternalMin: 8081, ExternalMax: 8081, Protocol: "tcp"},
+	}
+	a.composeService.Ports = nil
+	for _, port := range a.composeService.DockerComposeService.Ports {
+		a.composeService.Ports = append(a.composeService.Ports, config.Port{
+			Port:     port.Internal,
+			Protocol: port.Protocol,
+		})
+	}
+	err := u.allocateHostPorts()
+	if err != nil {
+		t.Error(err)
+	}
+	for i := 0; i < 2; i++ {
+		containerPorts := getContainerPorts(a)
+		if len(containerPorts) != 2 || containerPorts[0].HostPort != 8080 || containerPorts[1].HostPort != 0 ||
+			containerPorts[0].Protocol != v1.ProtocolTCP {
+			t.Error(containerPorts)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package up
+
+import (
+	"sync"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+)
+
+// imagePullProgressRowName is the name of the row of the reporter that shows the aggregate progress of image pulls.
+const imagePullProgressRowName = "all images"
+
+// imageCacheItem is the result of a docker operation on an image, which is computed at most once.
+type imageCacheItem struct {
+	digest     string
+	inspect    dockerTypes.ImageInspect
+	inspectRaw []byte
+	err        error
+	once       sync.Once
+}
+
+// imageCache caches the results of docker operations on images, because the services of large projects commonly share images. For
+// example, pulling an image once per service is slow and inspecting an image once per service puts unnecessary load on the docker daemon.
+// The zero value is an empty cache, and all methods are safe for concurrent use.
+type imageCache struct {
+	items map[string]*imageCacheItem
+	mutex sync.Mutex
+}
+
+func (c *imageCache) get(key string) *imageCacheItem {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.items == nil {
+		c.items = map[string]*imageCacheItem{}
+	}
+	item := c.items[key]
+	if item == nil {
+		item = &imageCacheItem{}
+		c.items[key] = item
+	}
+	return item
+}
+
+// inspectImage inspects an image once per image ID.
+func (u *upRunner) inspectImage(imageID string) (*dockerTypes.ImageInspect, []byte, error) {
+	item := u.imageInspects.get(imageID)
+	item.once.Do(func() {
+		item.inspect, item.inspectRaw, item.err = u.dockerClient.ImageInspectWithRaw(u.opts.Context, imageID)
+	})
+	return &item.inspect, item.inspectRaw, item.err
+}
+
+// pullImageOnce pulls an image once per image reference, so that services with the same image do not pull it concurrently. The progress
+// of the pull is only reported for the first app that pulls the image.
+func (u *upRunner) pullImageOnce(sourceImage string, a *app) (string, error) {
+	item := u.imagePulls.get(sourceImage)
+	item.once.Do(func() {
+		item.digest, item.err = u.getAppImageInfoPullImage(sourceImage, a)
+	})
+	return item.digest, item.err
+}
+
+// imagePullProgress is the row of the reporter that shows the aggregate progress of the image pulls of up, which only exists while images
+// are being pulled. The zero value has no row.
+type imagePullProgress struct {
+	mutex sync.Mutex
+	row   *reporter.Row
+	task  *reporter.ProgressTask
+}
+
+// initImagePullManager creates the manager of the image pulls of up, which limits the number of concurrent pulls to
+// u.opts.PullConcurrency and reports the aggregate progress of the pulls.
+func (u *upRunner) initImagePullManager() {
+	u.imagePullManager = docker.NewPullManager(u.dockerClient, u.opts.PullConcurrency)
+	u.imagePullManager.OnUpdate = u.reportImagePullProgress
+}
+
+// reportImagePullProgress adds the row of the aggregate progress of image pulls to the reporter while images are being pulled, and removes
+// the row once all pulls have completed.
+func (u *upRunner) reportImagePullProgress(m *docker.PullManager) {
+	p := &u.imagePullProgress
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if m.Pending() == 0 {
+		if p.row != nil {
+			p.task.Done()
+			u.opts.Reporter.DeleteRow(p.row)
+			p.row = nil
+			p.task = nil
+		}
+		return
+	}
+	if p.row == nil {
+		p.row = u.opts.Reporter.AddRow(imagePullProgressRowName)
+		p.task = p.row.AddProgressTask("pulling images")
+	}
+	p.task.Update(m.Progress())
+}
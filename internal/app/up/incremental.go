@@ -0,0 +1,261 @@
+package up
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	dockerRef "github.com/docker/distribution/reference"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/mutate"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// serviceHashInput is the effective configuration of the pod of an app, that is hashed to detect whether the app changed since the
+// last up (see computeServiceHash).
+type serviceHashInput struct {
+	ClusterImageStorage config.ClusterImageStorage
+	ImagePullSecretData []byte
+	Objects             []interface{}
+	Pod                 *v1.Pod
+}
+
+// newHashApp returns a copy of an app and of the apps of its pod, whose image information is stubbed (see stubAppImageInfo), so that
+// the pod of the app can be built without pulling images. Whether the images changed is detected separately (see
+// isSourceImageUnchanged).
+func (u *upRunner) newHashApp(a *app) *app {
+	podApps := make([]*app, len(a.podApps))
+	for i, a2 := range a.podApps {
+		hashApp := *a2
+		hashApp.imageInfo = appImageInfo{
+			once: &sync.Once{},
+		}
+		hashApp.volumeInitImage = appVolumesInitImage{
+			once: &sync.Once{},
+		}
+		u.stubAppImageInfo(&hashApp)
+		podApps[i] = &hashApp
+	}
+	for _, hashApp := range podApps {
+		hashApp.podApp = podApps[0]
+		hashApp.podApps = nil
+	}
+	podApps[0].podApps = podApps
+	return podApps[0]
+}
+
+// computeServiceHash returns a hash of the effective configuration of the pod of an app: the pod as it would be applied (including its
+// config hash annotation, see computePodConfigHash), the credentials of its image pull Secret, and the Kubernetes services and external
+// secrets that are owned by the apps of the pod. The pod and the objects are mutated like they are when they are applied (see
+// admitObject), so that changes to mutators are detected.
+func (u *upRunner) computeServiceHash(app *app) (string, error) {
+	hashApp := u.newHashApp(app)
+	pod, secretData, err := u.buildPod(hashApp)
+	if err != nil {
+		return "", err
+	}
+	configHash, err := u.computePodConfigHash(hashApp, secretData)
+	if err != nil {
+		return "", err
+	}
+	pod.ObjectMeta.Annotations[k8smeta.ConfigHashAnnotationName] = configHash
+	input := &serviceHashInput{
+		ClusterImageStorage: u.cfg.ClusterImageStorage,
+		Objects:             u.newSecretObjects(app.podApps),
+		Pod:                 pod,
+	}
+	input.ImagePullSecretData, err = u.getImagePullSecretData(pod)
+	if err != nil {
+		return "", err
+	}
+	for _, app2 := range app.podApps {
+		if !app2.hasService() {
+			continue
+		}
+		service := newService(u.cfg, app2)
+		u.adjustServiceType(service)
+		input.Objects = append(input.Objects, service)
+		if u.opts.ServiceAliases {
+			input.Objects = append(input.Objects, newServiceAlias(u.cfg, app2))
+		}
+	}
+	for _, obj := range append(input.Objects, pod) {
+		err = mutate.Apply(u.opts.Mutators, obj)
+		if err != nil {
+			return "", exitcode.Wrap(err, exitcode.Config)
+		}
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// getUnchangedPod returns the existing pod of an app if the app can be skipped, or nil otherwise. An app can be skipped if its hash and
+// pod name equal those recorded by the last up, and its pod is owned by the environment, is not being deleted and is ready. Apps with
+// volumes are never skipped, because changes to the contents of bind mounted files cannot be detected cheaply.
+func (u *upRunner) getUnchangedPod(app *app, pods map[string]*v1.Pod) *v1.Pod {
+	if len(app.volumes) > 0 {
+		return nil
+	}
+	var podName string
+	for _, app2 := range app.podApps {
+		s := u.state.v.Services[app2.name()]
+		if s == nil || s.Hash == "" || s.Hash != app2.hash || (podName != "" && s.PodName != podName) {
+			return nil
+		}
+		podName = s.PodName
+	}
+	pod := pods[podName]
+	if pod == nil || pod.ObjectMeta.DeletionTimestamp != nil || k8smeta.ValidateOwnership(u.cfg, "Pod", &pod.ObjectMeta) != nil {
+		return nil
+	}
+	if s, err := parsePodStatus(pod, u.opts.Mesh); err != nil || s != podStatusReady {
+		return nil
+	}
+	return pod
+}
+
+// isSourceImageUnchanged returns true if and only if the images of the containers of an app's pod resolve to the same local images as
+// during the last up, so that rebuilding an image without changing its tag is detected. Images that are not available locally and images
+// of docker compose services with a build section (whose build context may have changed) are considered changed.
+func (u *upRunner) isSourceImageUnchanged(app *app) (bool, error) {
+	localImageIDSet, err := u.getLocalImageIDSet()
+	if err != nil {
+		return false, err
+	}
+	for _, app2 := range app.podApps {
+		if app2.composeService.DockerComposeService.Build != nil {
+			return false, nil
+		}
+		var sourceImageRef dockerRef.Reference
+		sourceImageRef, err = dockerRef.ParseAnyReferenceWithSet(app2.composeService.DockerComposeService.Image, localImageIDSet)
+		if err != nil {
+			return false, nil
+		}
+		sourceImageID := resolveLocalImageID(sourceImageRef, localImageIDSet, u.localImagesCache.images)
+		if sourceImageID == "" || sourceImageID != u.state.v.Services[app2.name()].ImageID {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// removeAppsWithChangedDependencies removes apps from unchangedApps that depend on apps that will be (re)deployed, if a redeployment of
+// the dependency would redeploy the app (see getRecreatePodReason). Apps are removed until no more apps can be removed, because removing
+// an app can cause its dependents to be removed.
+func (u *upRunner) removeAppsWithChangedDependencies(unchangedApps map[*app]*v1.Pod) {
+	for removed := true; removed; {
+		removed = false
+		for app := range unchangedApps {
+			dependsOn, dependsOnRestart := app.getPodDependsOn()
+			for name := range dependsOn {
+				podApp := u.apps[name].podApp
+				changed := u.appsToBeStarted[podApp] && unchangedApps[podApp] == nil
+				if changed && (u.opts.CascadeRestart || dependsOnRestart[name]) {
+					delete(unchangedApps, app)
+					removed = true
+					break
+				}
+			}
+		}
+	}
+}
+
+// initAppHashes computes the hashes of the apps that are to be started and of their sidecars.
+func (u *upRunner) initAppHashes() error {
+	for app := range u.appsToBeStarted {
+		hash, err := u.computeServiceHash(app)
+		if err != nil {
+			return err
+		}
+		for _, app2 := range app.podApps {
+			app2.hash = hash
+		}
+	}
+	return nil
+}
+
+// listPodsByName lists the pods of the environment in each namespace of the docker compose services, keyed by name.
+func (u *upRunner) listPodsByName() (map[string]*v1.Pod, error) {
+	pods := map[string]*v1.Pod{}
+	for _, namespace := range u.cfg.Namespaces() {
+		podList, err := u.podClient(namespace).List(metav1.ListOptions{
+			LabelSelector: k8smeta.GetLabelSelector(u.cfg),
+		})
+		if err != nil {
+			return nil, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		for i := 0; i < len(podList.Items); i++ {
+			pods[podList.Items[i].ObjectMeta.Name] = &podList.Items[i]
+		}
+	}
+	return pods, nil
+}
+
+// skipUnchangedApps computes the hashes of the apps, and does not start apps that did not change since the last up and whose pods are
+// ready (see Options.Incremental). Images of skipped apps are neither pulled nor pushed, and their pods are not applied again. The docker
+// client must have been initialized.
+func (u *upRunner) skipUnchangedApps() error {
+	err := u.initAppHashes()
+	if err != nil || !u.opts.Incremental || len(u.state.v.Services) == 0 {
+		return err
+	}
+	pods, err := u.listPodsByName()
+	if err != nil {
+		return err
+	}
+	unchangedApps := map[*app]*v1.Pod{}
+	for app := range u.appsToBeStarted {
+		pod := u.getUnchangedPod(app, pods)
+		if pod == nil {
+			continue
+		}
+		ok, err := u.isSourceImageUnchanged(app)
+		if err != nil {
+			return err
+		}
+		if ok {
+			unchangedApps[app] = pod
+		}
+	}
+	u.removeAppsWithChangedDependencies(unchangedApps)
+	for app, pod := range unchangedApps {
+		err = u.skipUnchangedApp(app, pod)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipUnchangedApp does not start an app whose existing pod is kept (see skipUnchangedApps), and records the app as if it was started.
+func (u *upRunner) skipUnchangedApp(app *app, pod *v1.Pod) error {
+	app.newLogEntry().Infof("skipping pod %s, because the service did not change since the last up", pod.ObjectMeta.Name)
+	delete(u.appsToBeStarted, app)
+	for _, app2 := range app.podApps {
+		s := u.state.v.Services[app2.name()]
+		app2.imageInfo.once.Do(func() {
+			app2.imageInfo.podImage = s.Image
+			app2.imageInfo.sourceImageID = s.ImageID
+		})
+		app2.podUID = pod.UID
+		u.appsThatNeedToBeReady[app2] = true
+		u.metrics.reconciles.Inc(app2.name(), reconcileResultUnchanged)
+	}
+	if u.opts.AppliedObjectHandler != nil {
+		// Pods that are listed do not have their apiVersion and kind set.
+		pod.TypeMeta = metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		}
+		u.opts.AppliedObjectHandler(pod)
+	}
+	return u.updateAppMaxObservedPodStatus(pod)
+}
@@ -0,0 +1,153 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/state"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestUpRunnerIncremental(t *testing.T) *upRunner {
+	u := &upRunner{
+		cfg: newTestConfig(),
+		opts: &Options{
+			Incremental: true,
+		},
+	}
+	u.initApps()
+	u.appsToBeStarted = map[*app]bool{}
+	for _, a := range u.apps {
+		u.appsToBeStarted[a] = true
+	}
+	err := u.initAppHashes()
+	if err != nil {
+		t.Error(err)
+	}
+	u.state.v = &state.State{
+		Services: map[string]*state.Service{},
+	}
+	for name, a := range u.apps {
+		u.state.v.Services[name] = &state.Service{
+			Hash:    a.hash,
+			PodName: name,
+		}
+	}
+	return u
+}
+
+func newTestReadyPod(name string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Status: v1.PodStatus{
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func TestComputeServiceHash_Changed(t *testing.T) {
+	u := newTestUpRunnerIncremental(t)
+	a := u.apps["a"]
+	hash1, _ := u.computeServiceHash(a)
+	a.composeService.DockerComposeService.Environment = map[string]string{
+		"KEY": "value",
+	}
+	hash2, _ := u.computeServiceHash(a)
+	u.opts.RunAsUser = true
+	hash3, _ := u.computeServiceHash(a)
+	if hash1 == "" || hash1 != a.hash || hash1 == hash2 || hash2 == hash3 {
+		t.Error(hash1, hash2, hash3)
+	}
+}
+
+func TestComputeServiceHash_XKubeComposeChanged(t *testing.T) {
+	u := newTestUpRunnerIncremental(t)
+	a := u.apps["a"]
+	hash1, _ := u.computeServiceHash(a)
+	a.composeService.PriorityClassName = "high"
+	hash2, _ := u.computeServiceHash(a)
+	a.composeService.NodeSelector = map[string]string{
+		"disktype": "ssd",
+	}
+	hash3, _ := u.computeServiceHash(a)
+	if hash1 == "" || hash1 == hash2 || hash2 == hash3 {
+		t.Error(hash1, hash2, hash3)
+	}
+}
+
+func TestComputeServiceHash_Deterministic(t *testing.T) {
+	u := newTestUpRunnerIncremental(t)
+	for _, a := range u.apps {
+		hash, err := u.computeServiceHash(a)
+		if err != nil || hash != a.hash {
+			t.Error(a.name(), hash, err)
+		}
+	}
+}
+
+func TestGetUnchangedPod_Success(t *testing.T) {
+	u := newTestUpRunnerIncremental(t)
+	pods := map[string]*v1.Pod{
+		"c": newTestReadyPod("c"),
+	}
+	if pod := u.getUnchangedPod(u.apps["c"], pods); pod != pods["c"] {
+		t.Error(pod)
+	}
+}
+
+func TestGetUnchangedPod_HashChanged(t *testing.T) {
+	u := newTestUpRunnerIncremental(t)
+	u.state.v.Services["c"].Hash = "old"
+	pods := map[string]*v1.Pod{
+		"c": newTestReadyPod("c"),
+	}
+	if pod := u.getUnchangedPod(u.apps["c"], pods); pod != nil {
+		t.Error(pod)
+	}
+}
+
+func TestGetUnchangedPod_NotReady(t *testing.T) {
+	u := newTestUpRunnerIncremental(t)
+	pod := newTestReadyPod("c")
+	pod.Status.Conditions = nil
+	if pod := u.getUnchangedPod(u.apps["c"], map[string]*v1.Pod{"c": pod}); pod != nil {
+		t.Error(pod)
+	}
+}
+
+func TestGetUnchangedPod_PodNotFound(t *testing.T) {
+	u := newTestUpRunnerIncremental(t)
+	if pod := u.getUnchangedPod(u.apps["c"], map[string]*v1.Pod{}); pod != nil {
+		t.Error(pod)
+	}
+}
+
+func TestRemoveAppsWithChangedDependencies_CascadeRestart(t *testing.T) {
+	u := newTestUpRunnerIncremental(t)
+	u.opts.CascadeRestart = true
+	unchangedApps := map[*app]*v1.Pod{
+		u.apps["a"]: newTestReadyPod("a"),
+		u.apps["d"]: newTestReadyPod("d"),
+	}
+	// a depends on c, which changed.
+	u.removeAppsWithChangedDependencies(unchangedApps)
+	if len(unchangedApps) != 1 || unchangedApps[u.apps["d"]] == nil {
+		t.Error(unchangedApps)
+	}
+}
+
+func TestRemoveAppsWithChangedDependencies_NoRestart(t *testing.T) {
+	u := newTestUpRunnerIncremental(t)
+	unchangedApps := map[*app]*v1.Pod{
+		u.apps["a"]: newTestReadyPod("a"),
+	}
+	// Without --cascade-restart or restart: true a change of c does not redeploy a.
+	u.removeAppsWithChangedDependencies(unchangedApps)
+	if len(unchangedApps) != 1 {
+		t.Error(unchangedApps)
+	}
+}
@@ -0,0 +1,126 @@
+package up
+
+import (
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// getIngressTLSSecretName returns the name of the Secret in which cert-manager stores the TLS certificate of the Ingress of a docker
+// compose service.
+func getIngressTLSSecretName(cfg *config.Config, composeService *config.Service) string {
+	return k8smeta.GetResourceName(cfg, composeService.NameEscaped+"-tls", validation.DNS1123SubdomainMaxLength)
+}
+
+// setServiceObjectMeta sets the name, namespace, labels and annotations of an object of a docker compose service that is created with the
+// dynamic client, so that down can find the object and map it back to its docker compose service.
+func setServiceObjectMeta(cfg *config.Config, obj *unstructured.Unstructured, composeService *config.Service) {
+	objectMeta := &metav1.ObjectMeta{}
+	k8smeta.InitObjectMeta(cfg, objectMeta, composeService)
+	obj.SetAnnotations(objectMeta.Annotations)
+	obj.SetLabels(objectMeta.Labels)
+	obj.SetName(objectMeta.Name)
+	obj.SetNamespace(objectMeta.Namespace)
+}
+
+func newIngressObject(cfg *config.Config, composeService *config.Service) *unstructured.Unstructured {
+	ingress := composeService.Ingress
+	spec := map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{
+				"host": ingress.Host,
+				"http": map[string]interface{}{
+					"paths": []interface{}{
+						map[string]interface{}{
+							"backend": map[string]interface{}{
+								"service": map[string]interface{}{
+									"name": k8smeta.GetK8sName(composeService, cfg),
+									"port": map[string]interface{}{
+										"number": int64(ingress.Port),
+									},
+								},
+							},
+							"path":     "/",
+							"pathType": "Prefix",
+						},
+					},
+				},
+			},
+		},
+	}
+	if ingress.TLS != nil {
+		spec["tls"] = []interface{}{
+			map[string]interface{}{
+				"hosts":      []interface{}{ingress.Host},
+				"secretName": getIngressTLSSecretName(cfg, composeService),
+			},
+		}
+	}
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": k8smeta.IngressesGVR.GroupVersion().String(),
+			"kind":       "Ingress",
+			"spec":       spec,
+		},
+	}
+	setServiceObjectMeta(cfg, obj, composeService)
+	return obj
+}
+
+// newCertificateObject returns the cert-manager Certificate of the Ingress of a docker compose service. The Secret of the certificate is
+// labelled and annotated like the other resources of the docker compose service, so that down deletes it.
+func newCertificateObject(cfg *config.Config, composeService *config.Service) *unstructured.Unstructured {
+	ingress := composeService.Ingress
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": k8smeta.CertificatesGVR.GroupVersion().String(),
+			"kind":       "Certificate",
+		},
+	}
+	setServiceObjectMeta(cfg, obj, composeService)
+	obj.Object["spec"] = map[string]interface{}{
+		"dnsNames": []interface{}{ingress.Host},
+		"issuerRef": map[string]interface{}{
+			"group": k8smeta.CertificatesGVR.Group,
+			"kind":  ingress.TLS.IssuerKind,
+			"name":  ingress.TLS.Issuer,
+		},
+		"secretName": getIngressTLSSecretName(cfg, composeService),
+		"secretTemplate": map[string]interface{}{
+			"annotations": toInterfaceMap(obj.GetAnnotations()),
+			"labels":      toInterfaceMap(obj.GetLabels()),
+		},
+	}
+	return obj
+}
+
+// toInterfaceMap converts a string map to the representation of maps of unstructured objects.
+func toInterfaceMap(m map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+// createOrUpdateIngress creates the Ingress of an app, and the cert-manager Certificate of the Ingress if it terminates TLS.
+func (u *upRunner) createOrUpdateIngress(app *app) error {
+	if app.composeService.Ingress == nil {
+		return nil
+	}
+	if app.composeService.Ingress.TLS != nil {
+		err := u.createOrUpdateObject(k8smeta.CertificatesGVR, newCertificateObject(u.cfg, app.composeService))
+		if err != nil {
+			return err
+		}
+	}
+	obj := newIngressObject(u.cfg, app.composeService)
+	err := u.createOrUpdateObject(k8smeta.IngressesGVR, obj)
+	if err != nil {
+		return err
+	}
+	app.newLogEntry().Infof("created or updated Ingress %s for host %s", obj.GetName(), app.composeService.Ingress.Host)
+	return nil
+}
@@ -0,0 +1,95 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestIngressConfig(tls *config.IngressTLS) *config.Config {
+	cfg := newTestConfig()
+	cfg.EnvironmentID = "myenv"
+	cfg.EnvironmentLabel = "env"
+	cfg.Services["a"].Ingress = &config.Ingress{
+		Host: "a.example.com",
+		Port: 8080,
+		TLS:  tls,
+	}
+	return cfg
+}
+
+func TestNewIngressObject_WithoutTLS(t *testing.T) {
+	cfg := newTestIngressConfig(nil)
+	obj := newIngressObject(cfg, cfg.Services["a"])
+	if obj.GetName() != "a-myenv" || obj.GetAnnotations()[k8smeta.AnnotationName] != "a" || obj.GetLabels()["env"] != "myenv" {
+		t.Error(obj)
+	}
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	if len(rules) != 1 {
+		t.Error(rules)
+		return
+	}
+	host, _, _ := unstructured.NestedString(rules[0].(map[string]interface{}), "host")
+	paths, _, _ := unstructured.NestedSlice(rules[0].(map[string]interface{}), "http", "paths")
+	if host != "a.example.com" || len(paths) != 1 {
+		t.Error(rules)
+		return
+	}
+	service, _, _ := unstructured.NestedMap(paths[0].(map[string]interface{}), "backend", "service")
+	expected := map[string]interface{}{
+		"name": "a-myenv",
+		"port": map[string]interface{}{
+			"number": int64(8080),
+		},
+	}
+	if !reflect.DeepEqual(service, expected) {
+		t.Error(service)
+	}
+	if _, ok, _ := unstructured.NestedSlice(obj.Object, "spec", "tls"); ok {
+		t.Fail()
+	}
+}
+
+func TestNewIngressObject_WithTLS(t *testing.T) {
+	cfg := newTestIngressConfig(&config.IngressTLS{
+		Issuer:     "letsencrypt",
+		IssuerKind: "ClusterIssuer",
+	})
+	obj := newIngressObject(cfg, cfg.Services["a"])
+	tls, _, _ := unstructured.NestedSlice(obj.Object, "spec", "tls")
+	expected := []interface{}{
+		map[string]interface{}{
+			"hosts":      []interface{}{"a.example.com"},
+			"secretName": "a-tls-myenv",
+		},
+	}
+	if !reflect.DeepEqual(tls, expected) {
+		t.Error(tls)
+	}
+}
+
+func TestNewCertificateObject(t *testing.T) {
+	cfg := newTestIngressConfig(&config.IngressTLS{
+		Issuer:     "letsencrypt",
+		IssuerKind: "Issuer",
+	})
+	obj := newCertificateObject(cfg, cfg.Services["a"])
+	secretName, _, _ := unstructured.NestedString(obj.Object, "spec", "secretName")
+	issuerRef, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "issuerRef")
+	expectedIssuerRef := map[string]string{
+		"group": "cert-manager.io",
+		"kind":  "Issuer",
+		"name":  "letsencrypt",
+	}
+	if secretName != "a-tls-myenv" || !reflect.DeepEqual(issuerRef, expectedIssuerRef) {
+		t.Error(secretName, issuerRef)
+	}
+	labels, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "secretTemplate", "labels")
+	annotations, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "secretTemplate", "annotations")
+	if labels["env"] != "myenv" || annotations[k8smeta.AnnotationName] != "a" {
+		t.Error(labels, annotations)
+	}
+}
@@ -0,0 +1,49 @@
+package up
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	v1 "k8s.io/api/core/v1"
+)
+
+// checkIPFamilies validates Options.IPFamilies, and reports published ports whose host is an address of an IP family that the Kubernetes
+// services of docker compose services do not have.
+func (u *upRunner) checkIPFamilies() error {
+	ipFamilies := u.opts.IPFamilies
+	if ipFamilies == nil {
+		return nil
+	}
+	err := ipFamilies.Validate()
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	names := make([]string, 0, len(u.apps))
+	for name := range u.apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, port := range u.apps[name].composeService.DockerComposeService.Ports {
+			ip := net.ParseIP(port.Host)
+			if ip == nil || ip.IsUnspecified() || ipFamilies.Allows(ip) {
+				continue
+			}
+			return exitcode.Wrap(fmt.Errorf("service %s publishes %s port %d on the address %s, but services only have the IP families %s",
+				name, port.Protocol, port.Internal, port.Host, strings.Join(ipFamilies.Families, ", ")), exitcode.Config)
+		}
+	}
+	return nil
+}
+
+// setServiceIPFamilies records Options.IPFamilies on a Kubernetes service, so that it is created with them (see k8smeta.CreateService).
+func (u *upRunner) setServiceIPFamilies(service *v1.Service) error {
+	if u.opts.IPFamilies == nil {
+		return nil
+	}
+	return k8smeta.SetIPFamilies(service, u.opts.IPFamilies)
+}
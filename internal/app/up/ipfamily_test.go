@@ -0,0 +1,49 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTestUpRunnerIPFamilies(host string) *upRunner {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.opts.IPFamilies = &k8smeta.IPFamilies{
+		Families: []string{k8smeta.IPv6},
+	}
+	u.apps["a"].composeService.DockerComposeService.Ports = []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: 8080, ExternalMax: 8080, Protocol: "tcp", Host: host},
+	}
+	return u
+}
+
+func TestCheckIPFamilies_Success(t *testing.T) {
+	for _, host := range []string{"", "::", "0.0.0.0", "::1"} {
+		u := newTestUpRunnerIPFamilies(host)
+		if err := u.checkIPFamilies(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCheckIPFamilies_WrongFamily(t *testing.T) {
+	u := newTestUpRunnerIPFamilies("127.0.0.1")
+	err := u.checkIPFamilies()
+	if err == nil || err.Error() != "service a publishes tcp port 80 on the address 127.0.0.1, but services only have the IP families IPv6" {
+		t.Error(err)
+	}
+}
+
+func TestSetServiceIPFamilies(t *testing.T) {
+	u := newTestUpRunnerIPFamilies("")
+	service := &v1.Service{}
+	err := u.setServiceIPFamilies(service)
+	if err != nil {
+		t.Error(err)
+	}
+	if service.ObjectMeta.Annotations[k8smeta.IPFamiliesAnnotationName] != `{"ipFamilies":["IPv6"]}` {
+		t.Error(service.ObjectMeta.Annotations)
+	}
+}
@@ -0,0 +1,249 @@
+package up
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	batchV1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+)
+
+// The interval at which a Job is polled when waiting for it to be deleted.
+const jobDeletionPollInterval = time.Second
+
+// The label that the Job controller sets on the pods of a Job.
+const jobNameLabelName = "job-name"
+
+// newJob returns a Job that runs a pod to completion once. The pod is neither restarted nor retried, so that the exit code of its container
+// is the result of the Job.
+func newJob(pod *v1.Pod) *batchV1.Job {
+	backoffLimit := int32(0)
+	spec := pod.Spec
+	spec.RestartPolicy = v1.RestartPolicyNever
+	return &batchV1.Job{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "batch/v1",
+			Kind:       "Job",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: pod.ObjectMeta.Annotations,
+			Labels:      pod.ObjectMeta.Labels,
+			Name:        pod.ObjectMeta.Name,
+			Namespace:   pod.ObjectMeta.Namespace,
+		},
+		Spec: batchV1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: pod.ObjectMeta.Annotations,
+					Labels:      pod.ObjectMeta.Labels,
+				},
+				Spec: spec,
+			},
+		},
+	}
+}
+
+// getJobPodExitCode returns the exit code of a container of a pod of a Job, and whether the container has terminated.
+func getJobPodExitCode(pod *v1.Pod, containerName string) (int, bool, error) {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.Name != containerName {
+			continue
+		}
+		if t := containerStatus.State.Terminated; t != nil {
+			return int(t.ExitCode), true, nil
+		}
+		if w := containerStatus.State.Waiting; w != nil && w.Reason == "ErrImagePull" {
+			return 0, false, exitcode.Wrap(fmt.Errorf("container %s of pod %s could not pull image: %s",
+				containerStatus.Name,
+				pod.ObjectMeta.Name,
+				w.Message,
+			), exitcode.ImageTransfer)
+		}
+	}
+	if pod.Status.Phase == v1.PodFailed {
+		// For example, the pod was evicted before its container terminated.
+		return 0, false, exitcode.Wrap(fmt.Errorf("pod %s failed (reason=%s): %s", pod.ObjectMeta.Name, pod.Status.Reason,
+			pod.Status.Message), exitcode.ContainerFailure)
+	}
+	return 0, false, nil
+}
+
+// deleteJob deletes a Job and its pods, and waits until the Job has been deleted. It is not an error if the Job does not exist.
+func (u *upRunner) deleteJob(namespace, name string) error {
+	jobClient := u.k8sClientset.BatchV1().Jobs(namespace)
+	existing, err := jobClient.Get(name, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	err = k8smeta.ValidateOwnership(u.cfg, "Job", &existing.ObjectMeta)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	propagationPolicy := metav1.DeletePropagationForeground
+	err = jobClient.Delete(name, &metav1.DeleteOptions{
+		PropagationPolicy: &propagationPolicy,
+	})
+	for err == nil {
+		time.Sleep(jobDeletionPollInterval)
+		_, err = jobClient.Get(name, metav1.GetOptions{})
+	}
+	if k8sError.IsNotFound(err) {
+		return nil
+	}
+	return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+}
+
+// streamJobLogs copies the logs of a container of a pod of a Job to out, and closes completedChannel when the container has terminated.
+func (u *upRunner) streamJobLogs(pod *v1.Pod, containerName string, out io.Writer, completedChannel chan interface{}) {
+	defer close(completedChannel)
+	bodyReader, err := u.podClient(pod.ObjectMeta.Namespace).GetLogs(pod.ObjectMeta.Name, &v1.PodLogOptions{
+		Container: containerName,
+		Follow:    true,
+	}).Stream()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	defer util.CloseAndLogError(bodyReader)
+	_, err = io.Copy(out, bodyReader)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// updateJobPod starts streaming the logs of the container of an app in a pod of the app's Job once the container has started, and
+// returns the exit code of the container once it has terminated.
+func (u *upRunner) updateJobPod(a *app, pod *v1.Pod, out io.Writer) (int, bool, error) {
+	containerName := k8smeta.GetShortName(a.composeService)
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		started := containerStatus.State.Running != nil || containerStatus.State.Terminated != nil
+		if containerStatus.Name == containerName && started && !a.containersForWhichWeAreStreamingLogs[pod.ObjectMeta.Name] {
+			a.containersForWhichWeAreStreamingLogs[pod.ObjectMeta.Name] = true
+			completedChannel := make(chan interface{})
+			u.completedChannels = append(u.completedChannels, completedChannel)
+			go u.streamJobLogs(pod, containerName, out, completedChannel)
+		}
+	}
+	return getJobPodExitCode(pod, containerName)
+}
+
+// waitForJob waits until the container of an app in the pod of a Job has terminated, and returns its exit code.
+func (u *upRunner) waitForJob(a *app, jobName string, out io.Writer) (int, error) {
+	listOptions := metav1.ListOptions{
+		LabelSelector: jobNameLabelName + "=" + jobName,
+	}
+	client := u.podClient(u.cfg.GetNamespace(a.composeService))
+	podList, err := client.List(listOptions)
+	if err != nil {
+		return 0, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	var exitCode int
+	var done bool
+	for i := 0; i < len(podList.Items); i++ {
+		exitCode, done, err = u.updateJobPod(a, &podList.Items[i], out)
+		if err != nil || done {
+			return exitCode, err
+		}
+	}
+	listOptions.ResourceVersion = podList.ResourceVersion
+	listOptions.Watch = true
+	watch, err := client.Watch(listOptions)
+	if err != nil {
+		return 0, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	defer watch.Stop()
+	for event := range watch.ResultChan() {
+		switch event.Type {
+		case k8swatch.Added, k8swatch.Modified:
+			exitCode, done, err = u.updateJobPod(a, event.Object.(*v1.Pod), out)
+			if err != nil || done {
+				return exitCode, err
+			}
+		case k8swatch.Deleted:
+			return 0, k8smeta.ErrorResourcesModifiedExternally()
+		default:
+			return 0, fmt.Errorf("got unexpected error event from channel: %+v", event.Object)
+		}
+	}
+	return 0, exitcode.Wrap(fmt.Errorf("channel unexpectedly closed"), exitcode.ClusterConnectivity)
+}
+
+// runJob runs the pod of an app as a Job, copies the logs of the app's container to out, and returns the exit code of the container. The
+// Job is deleted before returning.
+func (u *upRunner) runJob(service *config.Service, out io.Writer) (int, error) {
+	u.initApps()
+	u.initAppsToBeStarted()
+	u.initVolumeInfo()
+	err := u.initKubernetesClientset()
+	if err != nil {
+		return 0, err
+	}
+	u.dockerClient, err = newDockerClient()
+	if err != nil {
+		return 0, err
+	}
+	a := u.apps[service.Name()]
+	pod, secretData, err := u.newPod(a)
+	if err != nil {
+		return 0, err
+	}
+	if len(secretData) > 0 {
+		err = u.createOrUpdateSecret(a, pod.ObjectMeta.Name, secretData)
+		if err != nil {
+			return 0, err
+		}
+	}
+	err = u.createImagePullSecret(a, pod)
+	if err != nil {
+		return 0, err
+	}
+	job := newJob(pod)
+	err = u.admitObject(job)
+	if err != nil {
+		return 0, err
+	}
+	// Delete the Job of an earlier run, which may not have been cleaned up.
+	namespace := u.cfg.GetNamespace(service)
+	err = u.deleteJob(namespace, job.ObjectMeta.Name)
+	if err != nil {
+		return 0, err
+	}
+	_, err = u.k8sClientset.BatchV1().Jobs(namespace).Create(job)
+	if err != nil {
+		return 0, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	a.newLogEntry().Infof("created Job %s", job.ObjectMeta.Name)
+	exitCode, err := u.waitForJob(a, job.ObjectMeta.Name, out)
+	if err == nil {
+		for _, completedChannel := range u.completedChannels {
+			<-completedChannel
+		}
+	}
+	deleteErr := u.deleteJob(namespace, job.ObjectMeta.Name)
+	if err == nil {
+		err = deleteErr
+	} else if deleteErr != nil {
+		log.Error(deleteErr)
+	}
+	return exitCode, err
+}
+
+// RunJob runs a docker compose service as a Kubernetes Job, and returns the exit code of its container. The pod of the Job is configured
+// like up configures pods, except that its container is not restarted. The logs of the container are copied to out. The docker compose
+// services that the service depends on must already be running (see Run).
+func RunJob(cfg *config.Config, opts *Options, service *config.Service, out io.Writer) (int, error) {
+	return newUpRunner(cfg, opts).runJob(service, out)
+}
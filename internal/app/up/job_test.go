@@ -0,0 +1,90 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewJob(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "tests",
+			Labels: map[string]string{
+				"env": "123",
+			},
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyAlways,
+		},
+	}
+	job := newJob(pod)
+	if job.ObjectMeta.Name != "tests" || job.Spec.Template.ObjectMeta.Name != "" || job.Spec.Template.ObjectMeta.Labels["env"] != "123" {
+		t.Error(job.ObjectMeta, job.Spec.Template.ObjectMeta)
+	}
+	if job.Spec.BackoffLimit == nil || *job.Spec.BackoffLimit != 0 || job.Spec.Template.Spec.RestartPolicy != v1.RestartPolicyNever {
+		t.Error(job.Spec)
+	}
+	if pod.Spec.RestartPolicy != v1.RestartPolicyAlways {
+		t.Fail()
+	}
+}
+
+func newTestJobPod(state v1.ContainerState) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "tests-abcde",
+		},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:  "tests",
+					State: state,
+				},
+			},
+		},
+	}
+}
+
+func TestGetJobPodExitCode_Running(t *testing.T) {
+	pod := newTestJobPod(v1.ContainerState{
+		Running: &v1.ContainerStateRunning{},
+	})
+	if _, done, err := getJobPodExitCode(pod, "tests"); done || err != nil {
+		t.Error(done, err)
+	}
+}
+
+func TestGetJobPodExitCode_Terminated(t *testing.T) {
+	pod := newTestJobPod(v1.ContainerState{
+		Terminated: &v1.ContainerStateTerminated{
+			ExitCode: 3,
+			Reason:   "Error",
+		},
+	})
+	if exitCode, done, err := getJobPodExitCode(pod, "tests"); exitCode != 3 || !done || err != nil {
+		t.Error(exitCode, done, err)
+	}
+}
+
+func TestGetJobPodExitCode_ErrImagePull(t *testing.T) {
+	pod := newTestJobPod(v1.ContainerState{
+		Waiting: &v1.ContainerStateWaiting{
+			Reason: "ErrImagePull",
+		},
+	})
+	if _, _, err := getJobPodExitCode(pod, "tests"); exitcode.FromError(err) != exitcode.ImageTransfer {
+		t.Error(err)
+	}
+}
+
+func TestGetJobPodExitCode_PodFailed(t *testing.T) {
+	pod := newTestJobPod(v1.ContainerState{})
+	pod.Status.Phase = v1.PodFailed
+	pod.Status.Reason = "Evicted"
+	if _, _, err := getJobPodExitCode(pod, "tests"); exitcode.FromError(err) != exitcode.ContainerFailure {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,70 @@
+package up
+
+import (
+	"sync"
+)
+
+// layerPushes coordinates the pushes of images that share layers. The docker daemon uploads every layer of an image to the repository of
+// the image, unless the repository already has the layer, or the daemon knows that another repository of the same registry has the layer
+// (in which case the layer is mounted from that repository). Because the image of each service is pushed to its own repository, the layers
+// that images share (such as base layers) would be uploaded once per service, and concurrently if the pushes overlap. layerPushes lets a
+// push wait for the pushes that are uploading layers of its image, so that those layers are uploaded once and mounted by the other pushes.
+// The zero value has no pushes, and all methods are safe for concurrent use.
+type layerPushes struct {
+	// The channel of each layer (by diff ID) that is being pushed, which is closed when the push completes.
+	inFlight map[string]chan struct{}
+	mutex    sync.Mutex
+}
+
+// start waits for the pushes of the layers (diff IDs) of an image that are in flight to complete, and then marks the layers as in flight.
+// The returned function must be called when the push of the image completes. Pushes of images without layers in common run concurrently.
+func (l *layerPushes) start(layers []string) (done func()) {
+	for {
+		wait := l.tryStart(layers)
+		if wait == nil {
+			break
+		}
+		for _, ch := range wait {
+			<-ch
+		}
+	}
+	return func() {
+		l.finish(layers)
+	}
+}
+
+// tryStart marks the layers as in flight and returns nil, or returns the channels of the layers that are in flight.
+func (l *layerPushes) tryStart(layers []string) []chan struct{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	var wait []chan struct{}
+	for _, layer := range layers {
+		if ch := l.inFlight[layer]; ch != nil {
+			wait = append(wait, ch)
+		}
+	}
+	if len(wait) > 0 {
+		return wait
+	}
+	if l.inFlight == nil {
+		l.inFlight = map[string]chan struct{}{}
+	}
+	ch := make(chan struct{})
+	for _, layer := range layers {
+		l.inFlight[layer] = ch
+	}
+	return nil
+}
+
+func (l *layerPushes) finish(layers []string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	var ch chan struct{}
+	for _, layer := range layers {
+		ch = l.inFlight[layer]
+		delete(l.inFlight, layer)
+	}
+	if ch != nil {
+		close(ch)
+	}
+}
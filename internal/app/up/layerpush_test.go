@@ -0,0 +1,41 @@
+package up
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLayerPushes_SharedLayersWait(t *testing.T) {
+	l := &layerPushes{}
+	done1 := l.start([]string{"sha256:base", "sha256:a"})
+	started := make(chan struct{})
+	go func() {
+		done2 := l.start([]string{"sha256:base", "sha256:b"})
+		close(started)
+		done2()
+	}()
+	select {
+	case <-started:
+		t.Error("the push of an image with a layer in flight started")
+	case <-time.After(20 * time.Millisecond):
+	}
+	done1()
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Error("the push did not start after the push of the shared layer completed")
+	}
+}
+
+func TestLayerPushes_DisjointLayersConcurrent(t *testing.T) {
+	l := &layerPushes{}
+	done1 := l.start([]string{"sha256:a"})
+	done2 := l.start([]string{"sha256:b"})
+	done3 := l.start(nil)
+	done1()
+	done2()
+	done3()
+	if len(l.inFlight) != 0 {
+		t.Error(l.inFlight)
+	}
+}
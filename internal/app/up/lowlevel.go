@@ -0,0 +1,123 @@
+package up
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+// getQOSClass returns the quality of service class of a pod whose only container has the given resource requirements, as computed by
+// Kubernetes. Requests that are not set default to limits.
+func getQOSClass(requirements *v1.ResourceRequirements) v1.PodQOSClass {
+	guaranteed := true
+	bestEffort := true
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		limit, hasLimit := requirements.Limits[name]
+		request, hasRequest := requirements.Requests[name]
+		if hasLimit || hasRequest {
+			bestEffort = false
+		}
+		if !hasLimit || (hasRequest && request.Cmp(limit) != 0) {
+			guaranteed = false
+		}
+	}
+	switch {
+	case bestEffort:
+		return v1.PodQOSBestEffort
+	case guaranteed:
+		return v1.PodQOSGuaranteed
+	}
+	return v1.PodQOSBurstable
+}
+
+// isInPodGroup returns true if and only if the service with the given name runs in the pod of a docker compose service. The containers of
+// a pod share its IPC namespace.
+func isInPodGroup(composeService *config.Service, name string) bool {
+	for _, podService := range composeService.PodServices() {
+		if podService.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getLowLevelFieldDiagnostics returns diagnostics of the fields of a docker compose service that control containers at a level that
+// Kubernetes does not expose (cgroup_parent, ipc, oom_kill_disable, oom_score_adj and some security_opt options). The kubelet derives
+// the OOM score adjustment of containers from the quality of service class of their pod, so oom_score_adj is approximated if the quality
+// of service class of the pod agrees with it.
+func getLowLevelFieldDiagnostics(composeService *config.Service, defaults *v1.ResourceRequirements) []string {
+	var diagnostics []string
+	r := &composeService.DockerComposeService.Resources
+	if r.CgroupParent != "" {
+		diagnostics = append(diagnostics, fmt.Sprintf("service %s sets cgroup_parent, but the cgroups of containers are managed by the "+
+			"kubelet", composeService.Name()))
+	}
+	securityOptions := &composeService.DockerComposeService.SecurityOptions
+	if len(securityOptions.Labels) > 0 {
+		diagnostics = append(diagnostics, fmt.Sprintf("service %s sets the security_opt label, but SELinux labels are not supported",
+			composeService.Name()))
+	}
+	if securityOptions.NoNewPrivileges && composeService.DockerComposeService.Privileged {
+		diagnostics = append(diagnostics, fmt.Sprintf("service %s sets the security_opt no-new-privileges, but Kubernetes does not "+
+			"support it for privileged containers", composeService.Name()))
+	}
+	if ipc := composeService.DockerComposeService.IPC; (strings.HasPrefix(ipc, dockerComposeConfig.IPCModeServicePrefix) &&
+		!isInPodGroup(composeService, strings.TrimPrefix(ipc, dockerComposeConfig.IPCModeServicePrefix))) ||
+		strings.HasPrefix(ipc, dockerComposeConfig.IPCModeContainerPrefix) {
+		diagnostics = append(diagnostics, fmt.Sprintf("service %s has ipc %s, but the IPC namespace of a pod cannot be shared with other "+
+			"pods (put both services in a pod group to share it)", composeService.Name(), ipc))
+	}
+	if r.OOMKillDisable {
+		diagnostics = append(diagnostics, fmt.Sprintf("service %s sets oom_kill_disable, but Kubernetes cannot disable the OOM killer "+
+			"(set mem_limit and cpus, and set mem_reservation equal to mem_limit, to make containers the least likely to be killed)",
+			composeService.Name()))
+	}
+	if r.OOMScoreAdj != nil && *r.OOMScoreAdj != 0 {
+		requirements := getContainerResources(composeService, defaults)
+		qosClass := getQOSClass(&requirements)
+		if *r.OOMScoreAdj < 0 && qosClass != v1.PodQOSGuaranteed {
+			diagnostics = append(diagnostics, fmt.Sprintf("service %s has a negative oom_score_adj, but its pod has quality of service class "+
+				"%s (set mem_limit and cpus, and set mem_reservation equal to mem_limit, for class %s)", composeService.Name(), qosClass,
+				v1.PodQOSGuaranteed))
+		} else if *r.OOMScoreAdj > 0 && qosClass == v1.PodQOSGuaranteed {
+			diagnostics = append(diagnostics, fmt.Sprintf("service %s has a positive oom_score_adj, but its pod has quality of service class "+
+				"%s (set mem_reservation less than mem_limit for class %s)", composeService.Name(), qosClass, v1.PodQOSBurstable))
+		}
+	}
+	return diagnostics
+}
+
+// checkLowLevelFields reports the fields of docker compose services that cannot be mapped to Kubernetes (see
+// getLowLevelFieldDiagnostics). The fields are reported as warnings, unless Options.Strict is set in which case up fails before anything
+// is applied.
+func (u *upRunner) checkLowLevelFields() error {
+	apps := make([]*app, 0, len(u.appsToBeStarted))
+	for app := range u.appsToBeStarted {
+		apps = append(apps, app)
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].name() < apps[j].name()
+	})
+	var diagnostics []string
+	for _, app := range apps {
+		for _, app2 := range app.podApps {
+			diagnostics = append(diagnostics, getLowLevelFieldDiagnostics(app2.composeService, &u.cfg.DefaultResources)...)
+		}
+	}
+	if len(diagnostics) == 0 {
+		return nil
+	}
+	if u.opts.Strict {
+		return exitcode.Wrap(fmt.Errorf("%s", strings.Join(diagnostics, "\n")), exitcode.Config)
+	}
+	for _, diagnostic := range diagnostics {
+		log.Warn(diagnostic)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestGetQOSClass(t *testing.T) {
+	guaranteed := &v1.ResourceRequirements{
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("1"),
+			v1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+	burstable := &v1.ResourceRequirements{
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("1"),
+			v1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+		Requests: v1.ResourceList{
+			v1.ResourceMemory: resource.MustParse("512Mi"),
+		},
+	}
+	if qosClass := getQOSClass(guaranteed); qosClass != v1.PodQOSGuaranteed {
+		t.Error(qosClass)
+	}
+	if qosClass := getQOSClass(burstable); qosClass != v1.PodQOSBurstable {
+		t.Error(qosClass)
+	}
+	if qosClass := getQOSClass(&v1.ResourceRequirements{}); qosClass != v1.PodQOSBestEffort {
+		t.Error(qosClass)
+	}
+}
+
+func TestGetLowLevelFieldDiagnostics_Approximated(t *testing.T) {
+	cfg := &config.Config{}
+	oomScoreAdj := int64(-500)
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+		Resources: dockerComposeConfig.Resources{
+			CPULimit:          1,
+			MemoryLimit:       1 << 30,
+			MemoryReservation: 1 << 30,
+			OOMScoreAdj:       &oomScoreAdj,
+		},
+	})
+	diagnostics := getLowLevelFieldDiagnostics(composeService, &v1.ResourceRequirements{})
+	if len(diagnostics) != 0 {
+		t.Error(diagnostics)
+	}
+}
+
+func TestGetLowLevelFieldDiagnostics_NotMapped(t *testing.T) {
+	cfg := &config.Config{}
+	oomScoreAdj := int64(-500)
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+		Resources: dockerComposeConfig.Resources{
+			CgroupParent:   "m-executor-abcd",
+			OOMKillDisable: true,
+			OOMScoreAdj:    &oomScoreAdj,
+		},
+	})
+	diagnostics := getLowLevelFieldDiagnostics(composeService, &v1.ResourceRequirements{})
+	if len(diagnostics) != 3 {
+		t.Error(diagnostics)
+	}
+}
+
+func TestGetLowLevelFieldDiagnostics_IPC(t *testing.T) {
+	cfg := &config.Config{}
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+		IPC:  "service:b",
+	})
+	diagnostics := getLowLevelFieldDiagnostics(composeService, &v1.ResourceRequirements{})
+	if len(diagnostics) != 1 {
+		t.Error(diagnostics)
+	}
+	composeService.DockerComposeService.IPC = dockerComposeConfig.IPCModeHost
+	diagnostics = getLowLevelFieldDiagnostics(composeService, &v1.ResourceRequirements{})
+	if len(diagnostics) != 0 {
+		t.Error(diagnostics)
+	}
+}
+
+func TestGetLowLevelFieldDiagnostics_IPCPodGroup(t *testing.T) {
+	cfg := &config.Config{}
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+		IPC:  "service:b",
+	})
+	podGroup := &config.PodGroup{
+		Name: "g",
+		Services: []*config.Service{
+			composeService,
+			cfg.AddService(&dockerComposeConfig.Service{
+				Name: "b",
+			}),
+		},
+	}
+	for _, service := range podGroup.Services {
+		service.PodGroup = podGroup
+	}
+	diagnostics := getLowLevelFieldDiagnostics(composeService, &v1.ResourceRequirements{})
+	if len(diagnostics) != 0 {
+		t.Error(diagnostics)
+	}
+}
+
+func TestCheckLowLevelFields_Strict(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.apps["a"].composeService.DockerComposeService.Resources.CgroupParent = "m-executor-abcd"
+	err := u.checkLowLevelFields()
+	if err != nil {
+		t.Error(err)
+	}
+	u.opts.Strict = true
+	err = u.checkLowLevelFields()
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,75 @@
+package up
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// meshProxy describes the sidecar proxy of a service mesh.
+type meshProxy struct {
+	// The annotations that request injection of the sidecar proxy, and that hold the start of the application container until the
+	// sidecar proxy is ready.
+	annotations map[string]string
+	// The name of the sidecar proxy container.
+	containerName string
+	// The labels that request injection of the sidecar proxy.
+	labels map[string]string
+	// The user ID of the sidecar proxy. The traffic of this user is not redirected to the sidecar proxy.
+	uid int64
+}
+
+var meshProxies = map[Mesh]*meshProxy{
+	MeshIstio: {
+		annotations: map[string]string{
+			"proxy.istio.io/config": `{"holdApplicationUntilProxyStarts":true}`,
+		},
+		containerName: "istio-proxy",
+		labels: map[string]string{
+			"sidecar.istio.io/inject": "true",
+		},
+		uid: 1337,
+	},
+	MeshLinkerd: {
+		annotations: map[string]string{
+			"config.linkerd.io/proxy-await": "enabled",
+			"linkerd.io/inject":             "enabled",
+		},
+		containerName: "linkerd-proxy",
+		uid:           2102,
+	},
+}
+
+// setPodMesh requests injection of the sidecar proxy of a service mesh into a pod.
+func setPodMesh(mesh Mesh, pod *v1.Pod) {
+	proxy := meshProxies[mesh]
+	if proxy == nil {
+		return
+	}
+	for k, v := range proxy.labels {
+		pod.ObjectMeta.Labels[k] = v
+	}
+	for k, v := range proxy.annotations {
+		pod.ObjectMeta.Annotations[k] = v
+	}
+}
+
+// getMeshInitContainerSecurityContext returns the security context of init containers that connect to other services, or nil if pods
+// are not part of a service mesh. Init containers run before the sidecar proxy has started, but the iptables rules of the mesh already
+// redirect their traffic to the sidecar proxy. Therefore, these init containers are run as the user of the sidecar proxy, whose traffic
+// is not redirected.
+func getMeshInitContainerSecurityContext(mesh Mesh) *v1.SecurityContext {
+	proxy := meshProxies[mesh]
+	if proxy == nil {
+		return nil
+	}
+	uid := proxy.uid
+	return &v1.SecurityContext{
+		RunAsUser: &uid,
+	}
+}
+
+// isMeshProxyContainer returns true if and only if the container is the sidecar proxy of the service mesh. The sidecar proxy is not
+// part of the docker compose service, so its logs are not streamed and its termination is not reported as a failure of the service.
+func isMeshProxyContainer(mesh Mesh, containerName string) bool {
+	proxy := meshProxies[mesh]
+	return proxy != nil && proxy.containerName == containerName
+}
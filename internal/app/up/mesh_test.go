@@ -0,0 +1,99 @@
+package up
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestMeshPod() *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{},
+			Labels:      map[string]string{},
+		},
+	}
+}
+
+func TestSetPodMesh_None(t *testing.T) {
+	pod := newTestMeshPod()
+	setPodMesh(MeshNone, pod)
+	if len(pod.ObjectMeta.Annotations) != 0 || len(pod.ObjectMeta.Labels) != 0 {
+		t.Error(pod.ObjectMeta)
+	}
+}
+
+func TestSetPodMesh_Istio(t *testing.T) {
+	pod := newTestMeshPod()
+	setPodMesh(MeshIstio, pod)
+	if pod.ObjectMeta.Labels["sidecar.istio.io/inject"] != "true" ||
+		pod.ObjectMeta.Annotations["proxy.istio.io/config"] != `{"holdApplicationUntilProxyStarts":true}` {
+		t.Error(pod.ObjectMeta)
+	}
+}
+
+func TestSetPodMesh_Linkerd(t *testing.T) {
+	pod := newTestMeshPod()
+	setPodMesh(MeshLinkerd, pod)
+	if pod.ObjectMeta.Annotations["linkerd.io/inject"] != "enabled" ||
+		pod.ObjectMeta.Annotations["config.linkerd.io/proxy-await"] != "enabled" {
+		t.Error(pod.ObjectMeta)
+	}
+}
+
+func TestCreateDependencyWaitInitContainers_Mesh(t *testing.T) {
+	u := newTestUpRunnerDependencyWaitModeInitContainer()
+	u.opts.Mesh = MeshIstio
+	initContainers := u.createDependencyWaitInitContainers(u.apps["a"])
+	if len(initContainers) != 1 {
+		t.Fail()
+	} else if sc := initContainers[0].SecurityContext; sc == nil || sc.RunAsUser == nil || *sc.RunAsUser != 1337 {
+		t.Error(sc)
+	}
+}
+
+func newTestMeshContainerStatuses(proxyState v1.ContainerState) *v1.Pod {
+	return &v1.Pod{
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name: "a",
+					State: v1.ContainerState{
+						Running: &v1.ContainerStateRunning{},
+					},
+				},
+				{
+					Name:  "istio-proxy",
+					State: proxyState,
+				},
+			},
+		},
+	}
+}
+
+func TestParsePodStatus_MeshProxyTerminated(t *testing.T) {
+	pod := newTestMeshContainerStatuses(v1.ContainerState{
+		Terminated: &v1.ContainerStateTerminated{
+			Reason: "Error",
+		},
+	})
+	s, err := parsePodStatus(pod, MeshIstio)
+	if err != nil || s != podStatusOther {
+		t.Error(s, err)
+	}
+	_, err = parsePodStatus(pod, MeshNone)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestParsePodStatus_MeshProxyNotRunning(t *testing.T) {
+	pod := newTestMeshContainerStatuses(v1.ContainerState{
+		Waiting: &v1.ContainerStateWaiting{},
+	})
+	s, err := parsePodStatus(pod, MeshIstio)
+	if err != nil || s != podStatusOther {
+		t.Error(s, err)
+	}
+}
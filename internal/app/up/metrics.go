@@ -0,0 +1,52 @@
+package up
+
+import (
+	"github.com/kube-compose/kube-compose/internal/pkg/metrics"
+)
+
+// The names of the phases of up whose timing is recorded (see Options.Timing).
+const (
+	phaseBuildImage       = "build image"
+	phaseCreatePod        = "create pod"
+	phaseCreateServices   = "create services"
+	phasePullImage        = "pull image"
+	phasePushImage        = "push image"
+	phaseResolveImage     = "resolve image"
+	phaseWaitForReadiness = "wait for readiness"
+)
+
+const (
+	reconcileResultCreated   = "created"
+	reconcileResultExists    = "exists"
+	reconcileResultRecreated = "recreated"
+	reconcileResultUnchanged = "unchanged"
+)
+
+type upMetrics struct {
+	imagePullSeconds *metrics.HistogramVec
+	readinessSeconds *metrics.HistogramVec
+	reconciles       *metrics.CounterVec
+}
+
+func newUpMetrics(r *metrics.Registry) *upMetrics {
+	return &upMetrics{
+		imagePullSeconds: r.NewHistogramVec(
+			"kube_compose_image_pull_duration_seconds",
+			"Time taken to pull the image of a docker compose service.",
+			metrics.DefaultDurationBuckets,
+			"service",
+		),
+		readinessSeconds: r.NewHistogramVec(
+			"kube_compose_readiness_latency_seconds",
+			"Time between creating the pod of a docker compose service and observing that the pod is ready.",
+			metrics.DefaultDurationBuckets,
+			"service",
+		),
+		reconciles: r.NewCounterVec(
+			"kube_compose_reconciles_total",
+			"Number of times the pod of a docker compose service was reconciled, partitioned by result.",
+			"service",
+			"result",
+		),
+	}
+}
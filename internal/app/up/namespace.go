@@ -0,0 +1,157 @@
+package up
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// getNamespace returns the namespace of an object, where the empty string is the namespace of the environment (see
+// k8smeta.InitObjectMeta).
+func (u *upRunner) getNamespace(namespace string) string {
+	if namespace == "" {
+		return u.cfg.Namespace
+	}
+	return namespace
+}
+
+// podClient returns the client of pods in a namespace, where the empty string is the namespace of the environment.
+func (u *upRunner) podClient(namespace string) clientV1.PodInterface {
+	if namespace = u.getNamespace(namespace); namespace == u.cfg.Namespace {
+		return u.k8sPodClient
+	}
+	return u.k8sClientset.CoreV1().Pods(namespace)
+}
+
+// serviceClient returns the client of Kubernetes services in a namespace, where the empty string is the namespace of the environment.
+func (u *upRunner) serviceClient(namespace string) clientV1.ServiceInterface {
+	if namespace = u.getNamespace(namespace); namespace == u.cfg.Namespace {
+		return u.k8sServiceClient
+	}
+	return u.k8sClientset.CoreV1().Services(namespace)
+}
+
+// secretClient returns the client of Secrets in a namespace, where the empty string is the namespace of the environment.
+func (u *upRunner) secretClient(namespace string) clientV1.SecretInterface {
+	if namespace = u.getNamespace(namespace); namespace == u.cfg.Namespace {
+		return u.k8sSecretClient
+	}
+	return u.k8sClientset.CoreV1().Secrets(namespace)
+}
+
+// mountsSharedObjects returns true if and only if a docker compose service mounts named volumes or external docker compose secrets, whose
+// PersistentVolumeClaims and Secrets are shared by services.
+func (u *upRunner) mountsSharedObjects(composeService *config.Service) bool {
+	dcService := composeService.DockerComposeService
+	for _, serviceVolume := range dcService.Volumes {
+		if serviceVolume.Short != nil && serviceVolume.Short.NamedVolume {
+			return true
+		}
+	}
+	for _, serviceSecret := range dcService.Secrets {
+		if u.cfg.Secrets[serviceSecret.Source] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNamespaces returns an error if a docker compose service in another namespace than the namespace of the environment mounts named
+// volumes or external docker compose secrets, because pods can only mount PersistentVolumeClaims and Secrets of their own namespace.
+func (u *upRunner) checkNamespaces() error {
+	var names []string
+	for _, a := range u.apps {
+		if u.cfg.GetNamespace(a.composeService) != u.cfg.Namespace && u.mountsSharedObjects(a.composeService) {
+			names = append(names, a.name())
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return exitcode.Wrap(fmt.Errorf("services %s mount named volumes or docker compose secrets, which is only supported in namespace %s "+
+		"of the environment", strings.Join(names, ", "), u.cfg.Namespace), exitcode.Config)
+}
+
+// namespacedWatch merges the watches of multiple namespaces into a single watch.
+type namespacedWatch struct {
+	done     chan struct{}
+	result   chan k8swatch.Event
+	stopOnce sync.Once
+	watches  []k8swatch.Interface
+}
+
+func (w *namespacedWatch) ResultChan() <-chan k8swatch.Event {
+	return w.result
+}
+
+func (w *namespacedWatch) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.done)
+		for _, watch := range w.watches {
+			watch.Stop()
+		}
+	})
+}
+
+// newNamespacedWatch returns a watch whose result channel receives the events of watches, and is closed once the result channels of all
+// watches are closed.
+func newNamespacedWatch(watches []k8swatch.Interface) k8swatch.Interface {
+	if len(watches) == 1 {
+		return watches[0]
+	}
+	w := &namespacedWatch{
+		done:    make(chan struct{}),
+		result:  make(chan k8swatch.Event),
+		watches: watches,
+	}
+	var wg sync.WaitGroup
+	wg.Add(len(watches))
+	for _, watch := range watches {
+		go func(watch k8swatch.Interface) {
+			defer wg.Done()
+			for event := range watch.ResultChan() {
+				select {
+				case w.result <- event:
+				case <-w.done:
+					return
+				}
+			}
+		}(watch)
+	}
+	go func() {
+		wg.Wait()
+		close(w.result)
+	}()
+	return w
+}
+
+// namespacedWatcher starts a watch in a namespace.
+type namespacedWatcher func(namespace string, listOptions metav1.ListOptions) (k8swatch.Interface, error)
+
+// watchNamespaces starts a watch in each namespace of the docker compose services, from the resource version of the list of each
+// namespace, and merges them into a single watch.
+func (u *upRunner) watchNamespaces(listOptions metav1.ListOptions, resourceVersions map[string]string,
+	watcher namespacedWatcher) (k8swatch.Interface, error) {
+	var watches []k8swatch.Interface
+	for _, namespace := range u.cfg.Namespaces() {
+		listOptions.ResourceVersion = resourceVersions[namespace]
+		listOptions.Watch = true
+		watch, err := watcher(namespace, listOptions)
+		if err != nil {
+			for _, watch2 := range watches {
+				watch2.Stop()
+			}
+			return nil, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		watches = append(watches, watch)
+	}
+	return newNamespacedWatch(watches), nil
+}
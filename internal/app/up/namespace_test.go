@@ -0,0 +1,77 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckNamespaces_NamedVolume(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.cfg.Namespace = "app"
+	u.cfg.Services["b"].Namespace = "infra"
+	u.cfg.Services["b"].DockerComposeService.Volumes = []dockerComposeConfig.ServiceVolume{
+		{
+			Short: &dockerComposeConfig.PathMapping{
+				ContainerPath: "/var/lib/data",
+				HasHostPath:   true,
+				HostPath:      "data",
+				NamedVolume:   true,
+			},
+		},
+	}
+	err := u.checkNamespaces()
+	if exitcode.FromError(err) != exitcode.Config {
+		t.Error(err)
+	}
+	u.cfg.Services["b"].Namespace = ""
+	err = u.checkNamespaces()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPodClient_OtherNamespace(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.cfg.Namespace = "app"
+	clientset := fake.NewSimpleClientset(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "b",
+			Namespace: "infra",
+		},
+	})
+	u.k8sClientset = clientset
+	u.k8sPodClient = clientset.CoreV1().Pods(u.cfg.Namespace)
+	if u.podClient("") != u.k8sPodClient || u.podClient("app") != u.k8sPodClient {
+		t.Fail()
+	}
+	_, err := u.podClient("infra").Get("b", metav1.GetOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewNamespacedWatch_MergesEvents(t *testing.T) {
+	watch1 := k8swatch.NewFake()
+	watch2 := k8swatch.NewFake()
+	w := newNamespacedWatch([]k8swatch.Interface{watch1, watch2})
+	go watch1.Add(&v1.Pod{})
+	event := <-w.ResultChan()
+	if event.Type != k8swatch.Added {
+		t.Error(event)
+	}
+	go watch2.Delete(&v1.Pod{})
+	event = <-w.ResultChan()
+	if event.Type != k8swatch.Deleted {
+		t.Error(event)
+	}
+	w.Stop()
+	// The result channel is closed once the channels of both watches are closed.
+	for range w.ResultChan() {
+	}
+}
@@ -0,0 +1,191 @@
+package up
+
+import (
+	"sort"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	networkingV1 "k8s.io/api/networking/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// namespaceNameLabelName is the name of the label that Kubernetes sets on each namespace to the name of the namespace.
+const namespaceNameLabelName = "kubernetes.io/metadata.name"
+
+// newDefaultDenyNetworkPolicy returns a NetworkPolicy that denies all ingress traffic to the pods of the environment, except traffic that
+// is allowed by the NetworkPolicies of docker compose services (see newServiceNetworkPolicy). Only the pods of the environment are
+// selected, because namespaces can be shared by environments and projects.
+func newDefaultDenyNetworkPolicy(cfg *config.Config) *networkingV1.NetworkPolicy {
+	return &networkingV1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "networking.k8s.io/v1",
+			Kind:       "NetworkPolicy",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: k8smeta.InitEnvironmentLabels(cfg, nil),
+			Name:   k8smeta.GetResourceName(cfg, "default-deny", validation.DNS1123SubdomainMaxLength),
+		},
+		Spec: networkingV1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: k8smeta.InitEnvironmentLabels(cfg, nil),
+			},
+			PolicyTypes: []networkingV1.PolicyType{
+				networkingV1.PolicyTypeIngress,
+			},
+		},
+	}
+}
+
+// sharesNetwork returns true if and only if two docker compose services are connected to a common network other than the default
+// network. Since all services are connected to the default network by default, the default network does not allow traffic.
+func sharesNetwork(service1, service2 *config.Service) bool {
+	for _, network1 := range service1.DockerComposeService.Networks {
+		if network1 == dockerComposeConfig.DefaultNetwork {
+			continue
+		}
+		for _, network2 := range service2.DockerComposeService.Networks {
+			if network1 == network2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getNetworkPeers returns the docker compose services that are allowed to connect to a docker compose service, sorted by name: the
+// services that depend on it (depends_on), and the services that share a network with it other than the default network.
+func getNetworkPeers(cfg *config.Config, composeService *config.Service) []*config.Service {
+	var peers []*config.Service
+	for _, peer := range cfg.Services {
+		if peer == composeService {
+			continue
+		}
+		if _, ok := peer.DockerComposeService.DependsOn[composeService.Name()]; ok || sharesNetwork(peer, composeService) {
+			peers = append(peers, peer)
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Name() < peers[j].Name()
+	})
+	return peers
+}
+
+func newNetworkPolicyPorts(ports []int32) []networkingV1.NetworkPolicyPort {
+	policyPorts := make([]networkingV1.NetworkPolicyPort, len(ports))
+	for i, port := range ports {
+		p := intstr.FromInt(int(port))
+		policyPorts[i].Port = &p
+	}
+	return policyPorts
+}
+
+// newServiceNetworkPolicy returns the NetworkPolicy that allows traffic to the pod of an app from the pods of its network peers (see
+// getNetworkPeers), where peers in other namespaces are selected by the name of their namespace. The pods of sidecars and peers are the
+// pods of their pod groups (see config.PodGroup). Traffic to the port of the app's "x-kube-compose"."ingress" is allowed from all
+// namespaces, so that ingress controllers and gateways can reach the pod, and traffic to host ports is allowed from anywhere (see
+// Options.HostPorts).
+func newServiceNetworkPolicy(cfg *config.Config, a *app) *networkingV1.NetworkPolicy {
+	networkPolicy := &networkingV1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "networking.k8s.io/v1",
+			Kind:       "NetworkPolicy",
+		},
+		Spec: networkingV1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: k8smeta.InitCommonLabels(cfg, a.composeService.PodService(), nil),
+			},
+			PolicyTypes: []networkingV1.PolicyType{
+				networkingV1.PolicyTypeIngress,
+			},
+		},
+	}
+	k8smeta.InitObjectMeta(cfg, &networkPolicy.ObjectMeta, a.composeService)
+	if peers := getNetworkPeers(cfg, a.composeService); len(peers) > 0 {
+		rule := networkingV1.NetworkPolicyIngressRule{}
+		for _, peer := range peers {
+			networkPolicyPeer := networkingV1.NetworkPolicyPeer{
+				PodSelector: &metav1.LabelSelector{
+					MatchLabels: k8smeta.InitCommonLabels(cfg, peer.PodService(), nil),
+				},
+			}
+			// A pod selector without namespace selector only selects pods in the namespace of the NetworkPolicy.
+			if namespace := cfg.GetNamespace(peer); namespace != cfg.GetNamespace(a.composeService) {
+				networkPolicyPeer.NamespaceSelector = &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						namespaceNameLabelName: namespace,
+					},
+				}
+			}
+			rule.From = append(rule.From, networkPolicyPeer)
+		}
+		networkPolicy.Spec.Ingress = append(networkPolicy.Spec.Ingress, rule)
+	}
+	if ingress := a.composeService.Ingress; ingress != nil {
+		networkPolicy.Spec.Ingress = append(networkPolicy.Spec.Ingress, networkingV1.NetworkPolicyIngressRule{
+			From: []networkingV1.NetworkPolicyPeer{
+				{
+					NamespaceSelector: &metav1.LabelSelector{},
+				},
+			},
+			Ports: newNetworkPolicyPorts([]int32{ingress.Port}),
+		})
+	}
+	if len(a.hostPorts) > 0 {
+		hostPortContainerPorts := make([]int32, 0, len(a.hostPorts))
+		for key := range a.hostPorts {
+			hostPortContainerPorts = append(hostPortContainerPorts, key.port)
+		}
+		sort.Slice(hostPortContainerPorts, func(i, j int) bool {
+			return hostPortContainerPorts[i] < hostPortContainerPorts[j]
+		})
+		networkPolicy.Spec.Ingress = append(networkPolicy.Spec.Ingress, networkingV1.NetworkPolicyIngressRule{
+			Ports: newNetworkPolicyPorts(hostPortContainerPorts),
+		})
+	}
+	return networkPolicy
+}
+
+// createDefaultDenyNetworkPolicies creates or updates the default deny NetworkPolicy in each namespace of the docker compose services.
+func (u *upRunner) createDefaultDenyNetworkPolicies() error {
+	for _, namespace := range u.cfg.Namespaces() {
+		networkPolicy := newDefaultDenyNetworkPolicy(u.cfg)
+		networkPolicy.Namespace = namespace
+		err := u.createOrUpdateNetworkPolicy(networkPolicy)
+		if err != nil {
+			return err
+		}
+		log.Infof("created or updated NetworkPolicy %s in namespace %s", networkPolicy.Name, namespace)
+	}
+	return nil
+}
+
+// createOrUpdateNetworkPolicy creates a NetworkPolicy, or updates its specification if it already exists and is owned by the environment
+// and project.
+func (u *upRunner) createOrUpdateNetworkPolicy(networkPolicy *networkingV1.NetworkPolicy) error {
+	err := u.admitObject(networkPolicy)
+	if err != nil {
+		return err
+	}
+	client := u.k8sClientset.NetworkingV1().NetworkPolicies(u.getNamespace(networkPolicy.Namespace))
+	_, err = client.Create(networkPolicy)
+	if !k8sError.IsAlreadyExists(err) {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	existing, err := client.Get(networkPolicy.Name, metav1.GetOptions{})
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	err = k8smeta.ValidateOwnership(u.cfg, "NetworkPolicy", &existing.ObjectMeta)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	existing.Spec = networkPolicy.Spec
+	_, err = client.Update(existing)
+	return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+}
@@ -0,0 +1,85 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	networkingV1 "k8s.io/api/networking/v1"
+)
+
+func TestNewDefaultDenyNetworkPolicy_Success(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.EnvironmentID = "123"
+	cfg.EnvironmentLabel = "env"
+	networkPolicy := newDefaultDenyNetworkPolicy(cfg)
+	if networkPolicy.Spec.PodSelector.MatchLabels["env"] != "123" || len(networkPolicy.Spec.Ingress) != 0 ||
+		len(networkPolicy.Spec.PolicyTypes) != 1 || networkPolicy.Spec.PolicyTypes[0] != networkingV1.PolicyTypeIngress {
+		t.Error(networkPolicy)
+	}
+}
+
+func TestGetNetworkPeers_DependsOnAndNetworks(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Services["b"].DockerComposeService.Networks = []string{"back", "default"}
+	cfg.Services["c"].DockerComposeService.Networks = []string{"default"}
+	cfg.Services["d"].DockerComposeService.Networks = []string{"back"}
+	peers := getNetworkPeers(cfg, cfg.Services["d"])
+	if len(peers) != 2 || peers[0].Name() != "a" || peers[1].Name() != "b" {
+		t.Error(peers)
+	}
+	peers = getNetworkPeers(cfg, cfg.Services["b"])
+	if len(peers) != 1 || peers[0].Name() != "d" {
+		t.Error(peers)
+	}
+}
+
+func TestNewServiceNetworkPolicy_Success(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.EnvironmentID = "123"
+	a := &app{
+		composeService: cfg.Services["c"],
+		hostPorts: map[hostPortKey]hostPort{
+			{port: 5432, protocol: "tcp"}: {port: 15432},
+		},
+	}
+	a.composeService.Ingress = &config.Ingress{
+		Host: "c.example.com",
+		Port: 8080,
+	}
+	networkPolicy := newServiceNetworkPolicy(cfg, a)
+	if networkPolicy.Name != "c-123" || networkPolicy.Spec.PodSelector.MatchLabels["app"] != "c" || len(networkPolicy.Spec.Ingress) != 3 {
+		t.Error(networkPolicy)
+		return
+	}
+	peersRule := networkPolicy.Spec.Ingress[0]
+	if len(peersRule.From) != 1 || peersRule.From[0].PodSelector.MatchLabels["app"] != "a" || len(peersRule.Ports) != 0 {
+		t.Error(peersRule)
+	}
+	ingressRule := networkPolicy.Spec.Ingress[1]
+	if len(ingressRule.From) != 1 || ingressRule.From[0].NamespaceSelector == nil || ingressRule.Ports[0].Port.IntValue() != 8080 {
+		t.Error(ingressRule)
+	}
+	hostPortRule := networkPolicy.Spec.Ingress[2]
+	if len(hostPortRule.From) != 0 || hostPortRule.Ports[0].Port.IntValue() != 5432 {
+		t.Error(hostPortRule)
+	}
+}
+
+func TestNewServiceNetworkPolicy_PeerInOtherNamespace(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Namespace = "app"
+	cfg.Services["c"].Namespace = "infra"
+	a := &app{
+		composeService: cfg.Services["c"],
+	}
+	networkPolicy := newServiceNetworkPolicy(cfg, a)
+	if networkPolicy.Namespace != "infra" || len(networkPolicy.Spec.Ingress) != 1 {
+		t.Error(networkPolicy)
+		return
+	}
+	peer := networkPolicy.Spec.Ingress[0].From[0]
+	if peer.PodSelector.MatchLabels["app"] != "a" || peer.NamespaceSelector == nil ||
+		peer.NamespaceSelector.MatchLabels[namespaceNameLabelName] != "app" {
+		t.Error(peer)
+	}
+}
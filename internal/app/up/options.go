@@ -3,13 +3,139 @@ package up
 import (
 	"context"
 
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/digestcache"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	"github.com/kube-compose/kube-compose/internal/pkg/metrics"
+	"github.com/kube-compose/kube-compose/internal/pkg/mutate"
+	"github.com/kube-compose/kube-compose/internal/pkg/policy"
 	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	"github.com/kube-compose/kube-compose/internal/pkg/timing"
+)
+
+// DependencyWaitMode determines how depends_on conditions are enforced.
+type DependencyWaitMode string
+
+const (
+	// DependencyWaitModeClient enforces depends_on by only creating the pod of a docker compose service once kube-compose has observed
+	// that the depends_on conditions of that service are satisfied.
+	DependencyWaitModeClient DependencyWaitMode = "client"
+	// DependencyWaitModeInitContainer enforces depends_on by adding init containers to pods that wait until dependencies accept
+	// connections. All pods are created immediately, and the pods remain correct when applied without kube-compose.
+	DependencyWaitModeInitContainer DependencyWaitMode = "init-container"
+)
+
+// ExposeMode determines how services with an "x-kube-compose"."ingress" are exposed outside of the cluster.
+type ExposeMode string
+
+const (
+	// ExposeModeIngress exposes services through Ingresses.
+	ExposeModeIngress ExposeMode = "ingress"
+	// ExposeModeGateway exposes services through HTTPRoutes of the Gateway API, and also exposes the other published TCP ports of services
+	// through TCPRoutes.
+	ExposeModeGateway ExposeMode = "gateway"
+)
+
+// Mesh is a service mesh whose sidecar proxies are injected into pods.
+type Mesh string
+
+const (
+	// MeshNone means that pods are not part of a service mesh.
+	MeshNone Mesh = ""
+	// MeshIstio injects Istio's sidecar proxy into pods.
+	MeshIstio Mesh = "istio"
+	// MeshLinkerd injects Linkerd's sidecar proxy into pods.
+	MeshLinkerd Mesh = "linkerd"
 )
 
 type Options struct {
-	Context  context.Context
-	Detach   bool
+	// If not nil, this function is called with each generated object right before it is applied (after mutators), and with the existing
+	// pods of docker compose services that are skipped (see Incremental), so that tools can track the objects of the environment. The
+	// objects are pointers to Kubernetes objects that JSON encode with their apiVersion and kind set, or the map[string]interface{} of
+	// unstructured objects, and must not be modified. The function may be called concurrently.
+	AppliedObjectHandler func(obj interface{})
+	// True to also redeploy the (indirect) dependents of a docker compose service (based on depends_on) when the service is redeployed,
+	// because many applications only read connection information at startup.
+	CascadeRestart bool
+	// The maximum number of pods that are created concurrently. Pods are created in waves that respect depends_on, and all pods of a
+	// wave are created in parallel. If not positive then the number of concurrently created pods is not limited.
+	Concurrency int
+	Context     context.Context
+	// How depends_on conditions are enforced. Defaults to DependencyWaitModeClient.
+	DependencyWaitMode DependencyWaitMode
+	// True to create a NetworkPolicy that denies ingress traffic to the pods of the environment, and a NetworkPolicy for each docker
+	// compose service that allows traffic from the services that depend on it or share a network with it.
+	DefaultDeny bool
+	Detach      bool
+	// If not nil, this function is called with the lifecycle events of docker compose services (see EventType). Calls are serialized,
+	// and should return quickly because up waits for them.
+	EventHandler func(*Event)
+	// How services are exposed outside of the cluster. Defaults to ExposeModeIngress.
+	ExposeMode ExposeMode
+	// The name and namespace of the Gateway that routes attach to if ExposeMode is ExposeModeGateway. If GatewayNamespace is empty then
+	// the Gateway is in the namespace of the environment.
+	GatewayName      string
+	GatewayNamespace string
+	// True to publish the published ports of docker compose services on the nodes that run their pods (hostPort), for single-node
+	// clusters without load balancers. Conflicting host ports of docker compose services are reported before anything is applied.
+	HostPorts bool
+	// The service mesh whose sidecar proxies are injected into pods. Defaults to MeshNone.
+	Mesh Mesh
+	// If not nil, images are pulled and pushed with the registry credentials of this docker CLI configuration, and pods get image pull
+	// secrets with these credentials (see createImagePullSecret).
+	DockerConfig *docker.CLIConfig
+	// If not nil, the digests of images pushed to the cluster's registry are cached in this cache, and images whose digest is cached are
+	// not pushed again.
+	DigestCache *digestcache.Cache
+	// The search domains that are added to the DNS configuration of pods, so that pods resolve short host names in other domains (e.g.
+	// the namespace of services that are shared by environments).
+	DNSSearches []string
+	// True to skip docker compose services whose effective configuration did not change since the last up and whose pods are ready.
+	// The images of skipped services are neither pulled nor pushed, and their pods are not applied again.
+	Incremental bool
+	// If not nil, the Kubernetes services of docker compose services are created with this IP family policy and these IP families, for
+	// clusters with dual-stack networking. The hosts of published ports must then be addresses of one of the IP families.
+	IPFamilies *k8smeta.IPFamilies
+	// True to not forward the ports of debuggers to localhost when not detached.
+	NoDebugPortForwarding bool
+	// True to not forward published ports that are not reachable otherwise to localhost when not detached (see printURLSummary).
+	NoPortForwarding bool
+	// The mutators that are applied in order to each generated object before it is applied (and before it is evaluated against
+	// policies).
+	Mutators []mutate.Mutator
+	// If not nil, metrics about reconciles, image pulls and readiness latencies are recorded in this registry.
+	Metrics *metrics.Registry
+	// The maximum number of images that are pulled concurrently. The images of all docker compose services are pulled as soon as up
+	// starts. If not positive then the number of concurrently pulled images is not limited.
+	PullConcurrency int
+	// If not nil, generated objects are evaluated against these policies before they are applied, and up fails if an object violates
+	// policies.
+	Policies *policy.Policies
 	Reporter *reporter.Reporter
+	// True to verify from inside the cluster that the Kubernetes service of a docker compose service resolves and accepts connections
+	// before its dependents are started, using a short-lived probe pod. This catches races between services becoming ready and their
+	// names being resolvable (e.g. caused by the propagation delay of the cluster's DNS). Only applies to DependencyWaitModeClient, because
+	// the init containers of DependencyWaitModeInitContainer already connect from inside the cluster.
+	WaitForDNS bool
+	// True to also create a Kubernetes service named exactly like each docker compose service with ports (a service alias), without
+	// project prefix and environment suffix, so that the names of docker compose services also resolve through DNS like with docker
+	// compose networking, including in pods that are not created by up. Unlike the other resources, service aliases are not unique in
+	// shared namespaces.
+	ServiceAliases bool
+	// If not nil, the timing of the phases of up (such as resolving images and waiting for readiness) is recorded in this recorder.
+	Timing *timing.Recorder
+	// True to fail if docker compose services set fields that cannot be mapped to Kubernetes, such as cgroup_parent, instead of
+	// warning about them.
+	Strict bool
+	// True to synthesize a TCP readiness probe on the first published port (or else the first port exposed by the image) of docker
+	// compose services without a healthcheck, so that depends_on condition service_healthy can be used for these services.
+	SynthesizeProbes bool
+	// True to also convert the healthchecks of docker compose services (and their images) to liveness probes, so that containers that
+	// become unhealthy are restarted. Docker only reports the health of containers, so this is disabled by default.
+	LivenessProbes bool
+	// True to sample the resource usage of containers with the metrics API while up is attached, and to print recommended resource
+	// constraints for each docker compose service once up exits (also when it is interrupted). Requires metrics-server.
+	RecommendResources bool
 	// True to set runAsUser/runAsGroup for each pod based on the user of the pod's image and the "user" key of the pod's docker-compose
 	// service.
 	RunAsUser bool
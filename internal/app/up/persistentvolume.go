@@ -0,0 +1,169 @@
+package up
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+type volumeClaims struct {
+	errs  map[string]error
+	mutex sync.Mutex
+}
+
+// getPersistentVolumeClaimName returns the name of the PersistentVolumeClaim of a named docker compose volume. External volumes refer to
+// an existing PersistentVolumeClaim.
+func getPersistentVolumeClaimName(cfg *config.Config, volume *config.Volume) string {
+	if volume.ClaimName != "" {
+		return volume.ClaimName
+	}
+	return k8smeta.GetResourceName(cfg, util.EscapeName(volume.Name), validation.DNS1123SubdomainMaxLength)
+}
+
+func newPersistentVolumeClaim(cfg *config.Config, volume *config.Volume) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: k8smeta.InitEnvironmentLabels(cfg, nil),
+			Name:   getPersistentVolumeClaimName(cfg, volume),
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{
+				volume.AccessMode,
+			},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceStorage: volume.Size,
+				},
+			},
+			StorageClassName: volume.StorageClassName,
+		},
+	}
+}
+
+// createPersistentVolumeClaim creates the PersistentVolumeClaim of a named docker compose volume. An existing PersistentVolumeClaim is
+// kept as is, so that the data of the volume survives restarts of up, and because the spec of a bound claim cannot be changed.
+func (u *upRunner) createPersistentVolumeClaim(volume *config.Volume) error {
+	pvc := newPersistentVolumeClaim(u.cfg, volume)
+	err := u.admitObject(pvc)
+	if err != nil {
+		return err
+	}
+	client := u.k8sClientset.CoreV1().PersistentVolumeClaims(u.cfg.Namespace)
+	_, err = client.Create(pvc)
+	if k8sError.IsAlreadyExists(err) {
+		var existing *v1.PersistentVolumeClaim
+		existing, err = client.Get(pvc.Name, metav1.GetOptions{})
+		if err == nil {
+			err = k8smeta.ValidateOwnership(u.cfg, pvc.Kind, &existing.ObjectMeta)
+			if err != nil {
+				return exitcode.Wrap(err, exitcode.Config)
+			}
+		}
+	}
+	if err != nil {
+		return exitcode.Wrap(fmt.Errorf("error while creating PersistentVolumeClaim %s: %v", pvc.Name, err), exitcode.ClusterConnectivity)
+	}
+	return u.recordObject(pvc.Kind, pvc.Name)
+}
+
+// createPersistentVolumeClaimOnce is like createPersistentVolumeClaim, but only creates each PersistentVolumeClaim once, because named
+// volumes can be shared by services.
+func (u *upRunner) createPersistentVolumeClaimOnce(volume *config.Volume) error {
+	u.volumeClaims.mutex.Lock()
+	defer u.volumeClaims.mutex.Unlock()
+	if u.volumeClaims.errs == nil {
+		u.volumeClaims.errs = map[string]error{}
+	}
+	err, ok := u.volumeClaims.errs[volume.Name]
+	if !ok {
+		err = u.createPersistentVolumeClaim(volume)
+		u.volumeClaims.errs[volume.Name] = err
+	}
+	return err
+}
+
+// getAppNamedVolumes returns the keys of the named docker compose volumes that are mounted by the containers of the pod of an app, sorted.
+func getAppNamedVolumes(app *app) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, app2 := range app.podApps {
+		for _, serviceVolume := range app2.composeService.DockerComposeService.Volumes {
+			if serviceVolume.Short != nil && serviceVolume.Short.NamedVolume && !seen[serviceVolume.Short.HostPath] {
+				seen[serviceVolume.Short.HostPath] = true
+				keys = append(keys, serviceVolume.Short.HostPath)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// createPersistentVolumeClaims creates the PersistentVolumeClaims of the named docker compose volumes of the pod of an app, except those
+// of external volumes.
+func (u *upRunner) createPersistentVolumeClaims(app *app) error {
+	for _, key := range getAppNamedVolumes(app) {
+		volume := u.cfg.Volumes[key]
+		if volume.ClaimName != "" {
+			continue
+		}
+		err := u.createPersistentVolumeClaimOnce(volume)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isReadOnlyMode returns true if and only if the mode of a volume of a docker compose service (e.g. "ro" or "ro,z") is read-only.
+func isReadOnlyMode(mode string) bool {
+	for _, option := range strings.Split(mode, ",") {
+		if option == "ro" {
+			return true
+		}
+	}
+	return false
+}
+
+// setPodPersistentVolumes mounts the named docker compose volumes of the containers of the pod of an app from their
+// PersistentVolumeClaims. Containers of a pod group that mount the same named volume share a volume of the pod.
+func setPodPersistentVolumes(cfg *config.Config, app *app, pod *v1.Pod) {
+	volumeNames := map[string]string{}
+	for i, key := range getAppNamedVolumes(app) {
+		volumeNames[key] = fmt.Sprintf("pvc%d", i+1)
+		pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+			Name: volumeNames[key],
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+					ClaimName: getPersistentVolumeClaimName(cfg, cfg.Volumes[key]),
+				},
+			},
+		})
+	}
+	// The containers of the pod are in the order of app.podApps.
+	for i, app2 := range app.podApps {
+		for _, serviceVolume := range app2.composeService.DockerComposeService.Volumes {
+			if serviceVolume.Short == nil || !serviceVolume.Short.NamedVolume {
+				continue
+			}
+			pod.Spec.Containers[i].VolumeMounts = append(pod.Spec.Containers[i].VolumeMounts, v1.VolumeMount{
+				MountPath: serviceVolume.Short.ContainerPath,
+				Name:      volumeNames[serviceVolume.Short.HostPath],
+				ReadOnly:  isReadOnlyMode(serviceVolume.Short.Mode),
+			})
+		}
+	}
+}
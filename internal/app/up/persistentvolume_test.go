@@ -0,0 +1,157 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var testStorageClass = "fast"
+
+func newTestUpRunnerWithVolumes() *upRunner {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.cfg.EnvironmentID = "env1"
+	u.cfg.EnvironmentLabel = "env"
+	u.cfg.Volumes = map[string]*config.Volume{
+		"data": {
+			AccessMode:       v1.ReadWriteOnce,
+			Name:             "data",
+			Size:             resource.MustParse("10Gi"),
+			StorageClassName: &testStorageClass,
+		},
+		"shared": {
+			AccessMode: v1.ReadWriteMany,
+			ClaimName:  "shared-claim",
+			Name:       "shared",
+			Size:       resource.MustParse(config.DefaultVolumeSize),
+		},
+	}
+	u.apps["a"].composeService.DockerComposeService.Volumes = []dockerComposeConfig.ServiceVolume{
+		{
+			Short: &dockerComposeConfig.PathMapping{
+				ContainerPath: "/var/lib/data",
+				HasHostPath:   true,
+				HostPath:      "data",
+				NamedVolume:   true,
+			},
+		},
+		{
+			Short: &dockerComposeConfig.PathMapping{
+				ContainerPath: "/shared",
+				HasHostPath:   true,
+				HasMode:       true,
+				HostPath:      "shared",
+				Mode:          "ro,z",
+				NamedVolume:   true,
+			},
+		},
+		{
+			Short: &dockerComposeConfig.PathMapping{
+				ContainerPath: "/cache",
+			},
+		},
+	}
+	return u
+}
+
+func TestNewPersistentVolumeClaim(t *testing.T) {
+	u := newTestUpRunnerWithVolumes()
+	pvc := newPersistentVolumeClaim(u.cfg, u.cfg.Volumes["data"])
+	if pvc.Name != "data-env1" || pvc.Kind != "PersistentVolumeClaim" {
+		t.Error(pvc)
+	}
+	if !reflect.DeepEqual(pvc.Labels, map[string]string{"env": "env1"}) {
+		t.Error(pvc.Labels)
+	}
+	expectedSpec := v1.PersistentVolumeClaimSpec{
+		AccessModes: []v1.PersistentVolumeAccessMode{
+			v1.ReadWriteOnce,
+		},
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{
+				v1.ResourceStorage: resource.MustParse("10Gi"),
+			},
+		},
+		StorageClassName: &testStorageClass,
+	}
+	if !reflect.DeepEqual(pvc.Spec, expectedSpec) {
+		t.Error(pvc.Spec)
+	}
+}
+
+func TestGetPersistentVolumeClaimName_External(t *testing.T) {
+	u := newTestUpRunnerWithVolumes()
+	if name := getPersistentVolumeClaimName(u.cfg, u.cfg.Volumes["shared"]); name != "shared-claim" {
+		t.Error(name)
+	}
+}
+
+func TestSetPodPersistentVolumes(t *testing.T) {
+	u := newTestUpRunnerWithVolumes()
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{},
+			},
+		},
+	}
+	setPodPersistentVolumes(u.cfg, u.apps["a"], pod)
+	expectedVolumes := []v1.Volume{
+		{
+			Name: "pvc1",
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "data-env1",
+				},
+			},
+		},
+		{
+			Name: "pvc2",
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "shared-claim",
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(pod.Spec.Volumes, expectedVolumes) {
+		t.Error(pod.Spec.Volumes)
+	}
+	expectedVolumeMounts := []v1.VolumeMount{
+		{
+			MountPath: "/var/lib/data",
+			Name:      "pvc1",
+		},
+		{
+			MountPath: "/shared",
+			Name:      "pvc2",
+			ReadOnly:  true,
+		},
+	}
+	if !reflect.DeepEqual(pod.Spec.Containers[0].VolumeMounts, expectedVolumeMounts) {
+		t.Error(pod.Spec.Containers[0].VolumeMounts)
+	}
+}
+
+func TestRenderPersistentVolumeClaims_SkipsExternal(t *testing.T) {
+	u := newTestUpRunnerWithVolumes()
+	objects, err := renderPersistentVolumeClaims(u, []*app{u.apps["a"], u.apps["b"]})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(objects) != 1 || objects[0].(*v1.PersistentVolumeClaim).Name != "data-env1" {
+		t.Error(objects)
+	}
+}
+
+func TestInitVolumeInfoGetAppVolume_NamedVolume(t *testing.T) {
+	u := newTestUpRunnerWithVolumes()
+	a := u.apps["a"]
+	if appVolume := initVolumeInfoGetAppVolume(a, a.composeService.DockerComposeService.Volumes[0]); appVolume != nil {
+		t.Error(appVolume)
+	}
+}
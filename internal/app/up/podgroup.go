@@ -0,0 +1,85 @@
+package up
+
+import (
+	"fmt"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+// getPodDependsOn returns the depends_on conditions and restart options of the pod of an app: the combined depends_on of the apps whose
+// containers run in the pod, excluding dependencies between them (see config.PodGroup). If several containers depend on the same service
+// then service_healthy takes precedence over service_started, and restart is set if any of them sets it.
+func (a *app) getPodDependsOn() (map[string]dockerComposeConfig.ServiceHealthiness, map[string]bool) {
+	dcService := a.composeService.DockerComposeService
+	if len(a.podApps) <= 1 {
+		return dcService.DependsOn, dcService.DependsOnRestart
+	}
+	dependsOn := map[string]dockerComposeConfig.ServiceHealthiness{}
+	restart := map[string]bool{}
+	for _, a2 := range a.podApps {
+		for name, healthiness := range a2.composeService.DockerComposeService.DependsOn {
+			if a.getPodApp(name) != nil {
+				continue
+			}
+			if _, ok := dependsOn[name]; !ok || healthiness == dockerComposeConfig.ServiceHealthy {
+				dependsOn[name] = healthiness
+			}
+			if a2.composeService.DockerComposeService.DependsOnRestart[name] {
+				restart[name] = true
+			}
+		}
+	}
+	return dependsOn, restart
+}
+
+// getPodApp returns the app with the given name whose container runs in the pod of an app, or nil if there is no such app.
+func (a *app) getPodApp(name string) *app {
+	for _, a2 := range a.podApps {
+		if a2.name() == name {
+			return a2
+		}
+	}
+	return nil
+}
+
+// getContainerApp returns the app whose container has the given name in the pod of an app, or nil if there is no such app (e.g. for the
+// sidecar proxy of a service mesh).
+func (a *app) getContainerApp(containerName string) *app {
+	for _, a2 := range a.podApps {
+		if k8smeta.GetShortName(a2.composeService) == containerName {
+			return a2
+		}
+	}
+	return nil
+}
+
+// parseContainerStatus returns the status of a container of a pod, for apps whose containers run in the pod of another app. Unlike
+// parsePodStatus, a container is ready when its own readiness probe succeeds, regardless of the readiness of the other containers of the
+// pod.
+func parseContainerStatus(pod *v1.Pod, containerName string) (podStatus, error) {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.Name != containerName {
+			continue
+		}
+		if t := containerStatus.State.Terminated; t != nil {
+			return parsePodStatusTerminatedContainer(pod.ObjectMeta.Name, containerStatus.Name, t)
+		}
+		if w := containerStatus.State.Waiting; w != nil && w.Reason == "ErrImagePull" {
+			return podStatusOther, exitcode.Wrap(fmt.Errorf("container %s of pod %s could not pull image: %s",
+				containerStatus.Name,
+				pod.ObjectMeta.Name,
+				w.Message,
+			), exitcode.ImageTransfer)
+		}
+		if containerStatus.Ready {
+			return podStatusReady, nil
+		}
+		if containerStatus.State.Running != nil {
+			return podStatusStarted, nil
+		}
+	}
+	return podStatusOther, nil
+}
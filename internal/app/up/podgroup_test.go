@@ -0,0 +1,173 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newTestUpRunnerWithPodGroup returns an upRunner whose service b is a sidecar of service a.
+func newTestUpRunnerWithPodGroup() *upRunner {
+	cfg := newTestConfig()
+	serviceA, serviceB := cfg.Services["a"], cfg.Services["b"]
+	serviceB.DockerComposeService.DependsOn = map[string]dockerComposeConfig.ServiceHealthiness{
+		"a": dockerComposeConfig.ServiceStarted,
+		"d": dockerComposeConfig.ServiceHealthy,
+	}
+	serviceB.DockerComposeService.DependsOnRestart = map[string]bool{
+		"d": true,
+	}
+	podGroup := &config.PodGroup{
+		Name:     "g",
+		Services: []*config.Service{serviceA, serviceB},
+	}
+	serviceA.PodGroup = podGroup
+	serviceB.PodGroup = podGroup
+	u := &upRunner{
+		cfg:  cfg,
+		opts: &Options{},
+	}
+	u.initApps()
+	u.appsToBeStarted = map[*app]bool{}
+	for _, a := range u.apps {
+		if !a.composeService.IsSidecar() {
+			u.appsToBeStarted[a] = true
+		}
+	}
+	return u
+}
+
+func TestInitApps_PodGroup(t *testing.T) {
+	u := newTestUpRunnerWithPodGroup()
+	a, b, c := u.apps["a"], u.apps["b"], u.apps["c"]
+	if a.podApp != a || b.podApp != a || c.podApp != c {
+		t.Fail()
+	}
+	if !reflect.DeepEqual(a.podApps, []*app{a, b}) || b.podApps != nil || !reflect.DeepEqual(c.podApps, []*app{c}) {
+		t.Fail()
+	}
+}
+
+func TestGetPodDependsOn_PodGroup(t *testing.T) {
+	u := newTestUpRunnerWithPodGroup()
+	dependsOn, restart := u.apps["a"].getPodDependsOn()
+	expected := map[string]dockerComposeConfig.ServiceHealthiness{
+		"c": dockerComposeConfig.ServiceHealthy,
+		"d": dockerComposeConfig.ServiceHealthy,
+	}
+	if !reflect.DeepEqual(dependsOn, expected) || !reflect.DeepEqual(restart, map[string]bool{"d": true}) {
+		t.Error(dependsOn, restart)
+	}
+}
+
+func TestGetAppsThatCanBeStarted_PodGroup(t *testing.T) {
+	u := newTestUpRunnerWithPodGroup()
+	for _, name := range []string{"c", "d"} {
+		delete(u.appsToBeStarted, u.apps[name])
+	}
+	setTestAppHealthy(u.apps["c"])
+	// The sidecar b depends on d being healthy, so the pod of a cannot be created yet.
+	u.apps["d"].maxObservedPodStatus = podStatusStarted
+	wave, err := u.getAppsThatCanBeStarted()
+	if err != nil || len(wave) != 0 {
+		t.Error(wave, err)
+	}
+	setTestAppHealthy(u.apps["d"])
+	wave, err = u.getAppsThatCanBeStarted()
+	if err != nil {
+		t.Error(err)
+	}
+	names := waveToNameSet(wave)
+	if len(names) != 1 || !names["a"] {
+		t.Error(names)
+	}
+}
+
+func TestParseContainerStatus(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+		},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:  "a",
+					Ready: true,
+					State: v1.ContainerState{
+						Running: &v1.ContainerStateRunning{},
+					},
+				},
+				{
+					Name: "b",
+					State: v1.ContainerState{
+						Running: &v1.ContainerStateRunning{},
+					},
+				},
+				{
+					Name: "c",
+					State: v1.ContainerState{
+						Terminated: &v1.ContainerStateTerminated{
+							ExitCode: 1,
+							Reason:   "Error",
+						},
+					},
+				},
+			},
+		},
+	}
+	if s, err := parseContainerStatus(pod, "a"); s != podStatusReady || err != nil {
+		t.Error(s, err)
+	}
+	if s, err := parseContainerStatus(pod, "b"); s != podStatusStarted || err != nil {
+		t.Error(s, err)
+	}
+	if _, err := parseContainerStatus(pod, "c"); err == nil {
+		t.Fail()
+	}
+	if s, err := parseContainerStatus(pod, "d"); s != podStatusOther || err != nil {
+		t.Error(s, err)
+	}
+}
+
+func TestCreatePodVolumes_PodGroup(t *testing.T) {
+	u := newTestUpRunnerWithPodGroup()
+	a, b := u.apps["a"], u.apps["b"]
+	a.volumes = []*appVolume{
+		{app: a, resolvedHostPath: "/data", containerPath: "/a"},
+		{app: b, resolvedHostPath: "/data", containerPath: "/b", readOnly: true},
+		{app: b, resolvedHostPath: "/conf", containerPath: "/etc/conf"},
+	}
+	// Pretend that the volume init image has been built.
+	a.volumeInitImage.once.Do(func() {})
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Name: "a"},
+				{Name: "b"},
+			},
+		},
+	}
+	err := u.createPodVolumes(a, pod)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pod.Spec.Volumes) != 2 || len(pod.Spec.InitContainers) != 1 || len(pod.Spec.InitContainers[0].VolumeMounts) != 2 {
+		t.Error(pod.Spec)
+	}
+	expectedA := []v1.VolumeMount{
+		{Name: "vol1", MountPath: "/a", SubPath: "root"},
+	}
+	expectedB := []v1.VolumeMount{
+		{Name: "vol1", MountPath: "/b", SubPath: "root", ReadOnly: true},
+		{Name: "vol2", MountPath: "/etc/conf", SubPath: "root"},
+	}
+	if !reflect.DeepEqual(pod.Spec.Containers[0].VolumeMounts, expectedA) ||
+		!reflect.DeepEqual(pod.Spec.Containers[1].VolumeMounts, expectedB) {
+		t.Error(pod.Spec.Containers)
+	}
+}
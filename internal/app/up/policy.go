@@ -0,0 +1,213 @@
+package up
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/mutate"
+	"github.com/kube-compose/kube-compose/internal/pkg/policy"
+	v1 "k8s.io/api/core/v1"
+)
+
+// evaluateAllPolicies evaluates objects against policies (see policy.Policies.EvaluateAll). It is a variable to improve testability.
+var evaluateAllPolicies = func(policies *policy.Policies, objs []interface{}) error {
+	return policies.EvaluateAll(objs)
+}
+
+// admitObject prepares a generated object to be applied, like the admission of Kubernetes: the mutators of the options are applied to
+// the object, and then the mutated object is evaluated against the policies of the options. obj must be a pointer to a Kubernetes object
+// that JSON encodes with its apiVersion and kind set, or the map[string]interface{} of an unstructured object. Admitted objects are
+// passed to the applied object handler of the options.
+func (u *upRunner) admitObject(obj interface{}) error {
+	err := mutate.Apply(u.opts.Mutators, obj)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	err = u.evaluatePolicies(obj)
+	if err == nil && u.opts.AppliedObjectHandler != nil {
+		u.opts.AppliedObjectHandler(obj)
+	}
+	return err
+}
+
+// evaluatePolicies evaluates a generated object against the policies of the options, if any, before the object is applied. obj must
+// JSON encode to a Kubernetes object with its apiVersion and kind set. Objects that checkPolicies admitted are not evaluated again.
+func (u *upRunner) evaluatePolicies(obj interface{}) error {
+	if u.opts.Policies == nil {
+		return nil
+	}
+	hash, err := computeObjectHash(obj)
+	if err == nil && u.admittedObjectHashes[hash] {
+		return nil
+	}
+	return exitcode.Wrap(evaluateAllPolicies(u.opts.Policies, []interface{}{obj}), exitcode.Config)
+}
+
+// checkPolicies evaluates all objects that up applies against the policies of the options in one pass, before anything is applied, so
+// that a violation does not leave the environment partially deployed. The objects are built like they are applied, so the images of the
+// apps to be started must be resolved. Pods have no host aliases, because the cluster IPs of services are not known yet, and the objects
+// are mutated (see admitObject). Apps whose pods cannot be built are skipped, because the error is handled when the pod is created. The
+// hashes of the admitted objects are recorded, so that admitObject does not evaluate them again.
+func (u *upRunner) checkPolicies() error {
+	if u.opts.Policies == nil {
+		return nil
+	}
+	apps := u.getSortedAppsToBeStarted()
+	objects := u.newServiceObjects()
+	objects = append(objects, u.newSecretObjects(apps)...)
+	for _, a := range apps {
+		objects = append(objects, u.newPodObjects(a)...)
+	}
+	for _, key := range getPersistentVolumeClaimKeys(u.cfg, apps) {
+		objects = append(objects, newPersistentVolumeClaim(u.cfg, u.cfg.Volumes[key]))
+	}
+	for _, obj := range objects {
+		err := mutate.Apply(u.opts.Mutators, obj)
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.Config)
+		}
+	}
+	err := evaluateAllPolicies(u.opts.Policies, objects)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	u.admittedObjectHashes = map[string]bool{}
+	for _, obj := range objects {
+		// Objects that cannot be hashed are evaluated again when they are admitted.
+		hash, err := computeObjectHash(obj)
+		if err == nil {
+			u.admittedObjectHashes[hash] = true
+		}
+	}
+	return nil
+}
+
+// computeObjectHash returns a hash of the JSON encoding of an object, so that an object that is admitted by checkPolicies is recognized
+// when it is applied. The host aliases of pods are ignored, because checkPolicies evaluates pods before their host aliases are known.
+func computeObjectHash(obj interface{}) (string, error) {
+	if pod, ok := obj.(*v1.Pod); ok && len(pod.Spec.HostAliases) > 0 {
+		podCopy := *pod
+		podCopy.Spec.HostAliases = nil
+		obj = &podCopy
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// getSortedAppsToBeStarted returns the apps to be started, sorted by name.
+func (u *upRunner) getSortedAppsToBeStarted() []*app {
+	apps := make([]*app, 0, len(u.appsToBeStarted))
+	for a := range u.appsToBeStarted {
+		apps = append(apps, a)
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].name() < apps[j].name()
+	})
+	return apps
+}
+
+// newServiceObjects returns the objects that createServicesAndGetPodHostAliases applies: the ExternalName services of external services,
+// the Kubernetes services of apps with ports and, with Options.DefaultDeny, the NetworkPolicies of the environment.
+func (u *upRunner) newServiceObjects() []interface{} {
+	var objects []interface{}
+	aliases := make([]string, 0, len(u.cfg.ExternalServices))
+	for alias := range u.cfg.ExternalServices {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		objects = append(objects, newExternalNameService(u.cfg, alias, u.cfg.ExternalServices[alias]))
+	}
+	if u.opts.DefaultDeny {
+		for _, namespace := range u.cfg.Namespaces() {
+			networkPolicy := newDefaultDenyNetworkPolicy(u.cfg)
+			networkPolicy.Namespace = namespace
+			objects = append(objects, networkPolicy)
+		}
+	}
+	names := make([]string, 0, len(u.apps))
+	for name := range u.apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		a := u.apps[name]
+		if !a.hasService() {
+			continue
+		}
+		service := newService(u.cfg, a)
+		u.adjustServiceType(service)
+		services := []*v1.Service{service}
+		if u.opts.ServiceAliases {
+			services = append(services, newServiceAlias(u.cfg, a))
+		}
+		for _, service := range services {
+			// Invalid IP families are reported by checkOptions.
+			_ = u.setServiceIPFamilies(service)
+			objects = append(objects, service)
+		}
+		if u.opts.DefaultDeny {
+			objects = append(objects, newServiceNetworkPolicy(u.cfg, a))
+		}
+	}
+	return objects
+}
+
+// newSecretObjects returns the ExternalSecrets and SecretProviderClasses of the external docker compose secrets of apps (see
+// createPodSecretObjects). Each object is returned once, because docker compose secrets can be shared by services.
+func (u *upRunner) newSecretObjects(apps []*app) []interface{} {
+	seen := map[string]bool{}
+	var objects []interface{}
+	for _, a := range apps {
+		for _, serviceSecret := range a.composeService.DockerComposeService.Secrets {
+			secret := u.cfg.Secrets[serviceSecret.Source]
+			if secret != nil && !seen[secret.Name] {
+				seen[secret.Name] = true
+				_, obj := newSecretObject(u.cfg, secret)
+				objects = append(objects, obj.Object)
+			}
+		}
+	}
+	return objects
+}
+
+// newPodObjects returns the pod of an app (see buildPod), the Secrets of its secret environment variables and registry credentials and,
+// with Options.WaitForDNS, its DNS probe pod. Nil is returned if the pod cannot be built. Errors while getting registry credentials are
+// handled when the pod is created, like the errors of building the pod.
+func (u *upRunner) newPodObjects(a *app) []interface{} {
+	for _, a2 := range a.podApps {
+		if u.getAppImageInfoOnce(a2) != nil {
+			return nil
+		}
+	}
+	pod, secretData, err := u.buildPod(a)
+	if err != nil {
+		return nil
+	}
+	var objects []interface{}
+	if len(secretData) > 0 {
+		objects = append(objects, newSecret(u.cfg, a, getSecretName(pod.ObjectMeta.Name), v1.SecretTypeOpaque, secretData))
+	}
+	pullSecretData, err := u.getImagePullSecretData(pod)
+	if err == nil && pullSecretData != nil {
+		name := getImagePullSecretName(pod.ObjectMeta.Name)
+		objects = append(objects, newSecret(u.cfg, a, name, v1.SecretTypeDockerConfigJson, map[string][]byte{
+			v1.DockerConfigJsonKey: pullSecretData,
+		}))
+		pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, v1.LocalObjectReference{
+			Name: name,
+		})
+	}
+	objects = append(objects, pod)
+	if u.opts.WaitForDNS && a.hasService() {
+		objects = append(objects, newDNSProbePod(u.cfg, u.opts, a))
+	}
+	return objects
+}
@@ -0,0 +1,112 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/policy"
+	"github.com/kube-compose/kube-compose/pkg/docker/dockertest"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAdmitObject_AppliedObjectHandler(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	var objects []interface{}
+	u.opts.AppliedObjectHandler = func(obj interface{}) {
+		objects = append(objects, obj)
+	}
+	pod := &v1.Pod{}
+	err := u.admitObject(pod)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(objects) != 1 || objects[0] != pod {
+		t.Fail()
+	}
+}
+
+// withMockPolicies runs cb with policies evaluated by mock instead of opa.
+func withMockPolicies(mock func(policies *policy.Policies, objs []interface{}) error, cb func()) {
+	orig := evaluateAllPolicies
+	defer func() {
+		evaluateAllPolicies = orig
+	}()
+	evaluateAllPolicies = mock
+	cb()
+}
+
+func TestRun_FakeClusterPolicyViolation(t *testing.T) {
+	clientset := newFakeClientset()
+	var kinds []string
+	withMockPolicies(func(_ *policy.Policies, objs []interface{}) error {
+		var violations policy.Violations
+		for _, obj := range objs {
+			kinds = append(kinds, reflect.TypeOf(obj).Elem().Name())
+			if pod, ok := obj.(*v1.Pod); ok && pod.ObjectMeta.Name == "project-web-test" {
+				violations = append(violations, &policy.ViolationError{
+					Kind: "Pod",
+					Name: pod.ObjectMeta.Name,
+				})
+			}
+		}
+		if len(violations) > 0 {
+			return violations
+		}
+		return nil
+	}, func() {
+		withFakeCluster(clientset, func(_ *dockertest.Daemon) {
+			opts := newFakeClusterTestOptions()
+			opts.Policies = &policy.Policies{}
+			err := Run(newFakeClusterTestConfig(), opts)
+			if exitcode.FromError(err) != exitcode.Config {
+				t.Error(err)
+			}
+		})
+	})
+	// All objects are evaluated in one pass before anything is applied.
+	if !reflect.DeepEqual(kinds, []string{"Service", "Pod", "Pod"}) {
+		t.Error(kinds)
+	}
+	serviceList, err := clientset.CoreV1().Services("default").List(metav1.ListOptions{})
+	if err != nil || len(serviceList.Items) != 0 {
+		t.Error(serviceList, err)
+	}
+	podList, err := clientset.CoreV1().Pods("default").List(metav1.ListOptions{})
+	if err != nil || len(podList.Items) != 0 {
+		t.Error(podList, err)
+	}
+}
+
+func TestRun_FakeClusterPoliciesSatisfied(t *testing.T) {
+	clientset := newFakeClientset()
+	var reevaluated []string
+	calls := 0
+	withMockPolicies(func(_ *policy.Policies, objs []interface{}) error {
+		calls++
+		if calls > 1 {
+			for _, obj := range objs {
+				reevaluated = append(reevaluated, reflect.TypeOf(obj).Elem().Name())
+			}
+		}
+		return nil
+	}, func() {
+		withFakeCluster(clientset, func(_ *dockertest.Daemon) {
+			opts := newFakeClusterTestOptions()
+			opts.Policies = &policy.Policies{}
+			err := Run(newFakeClusterTestConfig(), opts)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	})
+	// One pass before anything is applied, whose decisions are reused when the objects are applied.
+	if calls != 1 || len(reevaluated) != 0 {
+		t.Error(calls, reevaluated)
+	}
+	podList, err := clientset.CoreV1().Pods("default").List(metav1.ListOptions{})
+	if err != nil || len(podList.Items) != 2 {
+		t.Error(podList, err)
+	}
+}
@@ -0,0 +1,41 @@
+package up
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// setPodPresetVolumes puts the data directories of the presets of the containers of a pod on emptyDir volumes, so that the data survives
+// restarts of the containers (see config.Preset). Data directories that are bind mounted or mounted from named volumes are skipped, so
+// createPodVolumes and setPodPersistentVolumes must be called first.
+func setPodPresetVolumes(a *app, pod *v1.Pod) {
+	// The containers of the pod are in the order of a.podApps.
+	for i, a2 := range a.podApps {
+		preset := a2.composeService.Preset
+		if preset == nil || hasVolumeMount(&pod.Spec.Containers[i], preset.DataDir) {
+			continue
+		}
+		volumeName := fmt.Sprintf("data%d", i+1)
+		pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+			Name: volumeName,
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		})
+		pod.Spec.Containers[i].VolumeMounts = append(pod.Spec.Containers[i].VolumeMounts, v1.VolumeMount{
+			Name:      volumeName,
+			MountPath: preset.DataDir,
+		})
+	}
+}
+
+// hasVolumeMount returns true if and only if a volume is mounted at the given path in a container.
+func hasVolumeMount(container *v1.Container, mountPath string) bool {
+	for _, volumeMount := range container.VolumeMounts {
+		if volumeMount.MountPath == mountPath {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestSetPodPresetVolumes(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	a := u.apps["a"]
+	a.composeService.Preset = &config.Preset{
+		Name:    "postgres",
+		DataDir: "/var/lib/postgresql/data",
+	}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Name: "a"},
+			},
+		},
+	}
+	setPodPresetVolumes(a, pod)
+	if len(pod.Spec.Volumes) != 1 || pod.Spec.Volumes[0].Name != "data1" || pod.Spec.Volumes[0].EmptyDir == nil {
+		t.Error(pod.Spec.Volumes)
+	}
+	expected := []v1.VolumeMount{
+		{Name: "data1", MountPath: "/var/lib/postgresql/data"},
+	}
+	if !reflect.DeepEqual(pod.Spec.Containers[0].VolumeMounts, expected) {
+		t.Error(pod.Spec.Containers[0].VolumeMounts)
+	}
+}
+
+func TestSetPodPresetVolumes_BindMounted(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	a := u.apps["a"]
+	a.composeService.Preset = &config.Preset{
+		Name:    "redis",
+		DataDir: "/data",
+	}
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name: "a",
+					VolumeMounts: []v1.VolumeMount{
+						{Name: "vol1", MountPath: "/data", SubPath: "root"},
+					},
+				},
+			},
+		},
+	}
+	setPodPresetVolumes(a, pod)
+	if len(pod.Spec.Volumes) != 0 || len(pod.Spec.Containers[0].VolumeMounts) != 1 {
+		t.Error(pod.Spec)
+	}
+}
@@ -0,0 +1,54 @@
+package up
+
+import (
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	v1 "k8s.io/api/core/v1"
+)
+
+// getImagePullSecretName returns the name of the k8s secret with the registry credentials of the images of a pod.
+func getImagePullSecretName(podName string) string {
+	return podName + "-pull"
+}
+
+// getImagePullSecretData returns the .dockerconfigjson of the k8s secret with the credentials of the docker CLI configuration for the
+// registries of the images of a pod, or nil if there are no credentials. Images that are not pulled by the cluster (e.g. images in the
+// docker daemon of the cluster) are ignored.
+func (u *upRunner) getImagePullSecretData(pod *v1.Pod) ([]byte, error) {
+	if u.opts.DockerConfig == nil {
+		return nil, nil
+	}
+	var images []string
+	for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, container := range containers {
+			if container.ImagePullPolicy != v1.PullNever {
+				images = append(images, container.Image)
+			}
+		}
+	}
+	data, err := u.opts.DockerConfig.GetDockerConfigJSON(images)
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.ImageTransfer)
+	}
+	return data, nil
+}
+
+// createImagePullSecret creates or updates a k8s secret with the credentials of the docker CLI configuration for the registries of the
+// images of the pod of an app (see getImagePullSecretData), and adds it to the imagePullSecrets of the pod, so that the cluster pulls
+// images from the same private registries as the docker daemon. Nothing is done if there are no credentials.
+func (u *upRunner) createImagePullSecret(app *app, pod *v1.Pod) error {
+	data, err := u.getImagePullSecretData(pod)
+	if err != nil || data == nil {
+		return err
+	}
+	name := getImagePullSecretName(pod.ObjectMeta.Name)
+	err = u.applySecret(app, name, v1.SecretTypeDockerConfigJson, map[string][]byte{
+		v1.DockerConfigJsonKey: data,
+	})
+	if err != nil {
+		return err
+	}
+	pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, v1.LocalObjectReference{
+		Name: name,
+	})
+	return nil
+}
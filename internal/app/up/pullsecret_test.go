@@ -0,0 +1,79 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestUpRunnerImagePullSecret(t *testing.T) *upRunner {
+	orig := fs.OS
+	defer func() {
+		fs.OS = orig
+	}()
+	fs.OS = fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker/config.json": {
+			Content: []byte(`{"auths":{"registry.example.com":{"auth":"dXNlcjpwYXNzd29yZA=="}}}`),
+		},
+	})
+	dockerConfig, err := docker.LoadCLIConfig("/docker")
+	if err != nil {
+		t.Error(err)
+	}
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.opts.DockerConfig = dockerConfig
+	clientset := fake.NewSimpleClientset()
+	u.k8sClientset = clientset
+	u.k8sSecretClient = clientset.CoreV1().Secrets(u.cfg.Namespace)
+	return u
+}
+
+func TestCreateImagePullSecret_Success(t *testing.T) {
+	u := newTestUpRunnerImagePullSecret(t)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Image: "registry.example.com/app:1"},
+				{Image: "local", ImagePullPolicy: v1.PullNever},
+			},
+		},
+	}
+	err := u.createImagePullSecret(u.apps["a"], pod)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(pod.Spec.ImagePullSecrets) != 1 || pod.Spec.ImagePullSecrets[0].Name != "a-pull" {
+		t.Error(pod.Spec.ImagePullSecrets)
+	}
+	secret, err := u.k8sSecretClient.Get("a-pull", metav1.GetOptions{})
+	if err != nil {
+		t.Error(err)
+	} else if secret.Type != v1.SecretTypeDockerConfigJson || len(secret.Data[v1.DockerConfigJsonKey]) == 0 {
+		t.Error(secret)
+	}
+}
+
+func TestCreateImagePullSecret_NoCredentials(t *testing.T) {
+	u := newTestUpRunnerImagePullSecret(t)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Image: "ubuntu:latest"},
+			},
+		},
+	}
+	err := u.createImagePullSecret(u.apps["a"], pod)
+	if err != nil || len(pod.Spec.ImagePullSecrets) != 0 {
+		t.Error(pod.Spec.ImagePullSecrets, err)
+	}
+}
@@ -0,0 +1,174 @@
+package up
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// quotaDemand is the usage of quota by the pod of a docker compose service.
+type quotaDemand struct {
+	name  string
+	usage v1.ResourceList
+}
+
+// getPodQuotaUsage returns the usage of the quota resources pods, cpu, memory, requests.* and limits.* by a pod with the given resource
+// requirements.
+func getPodQuotaUsage(requirements *v1.ResourceRequirements) v1.ResourceList {
+	return v1.ResourceList{
+		v1.ResourcePods:               resource.MustParse("1"),
+		v1.ResourceName("count/pods"): resource.MustParse("1"),
+		v1.ResourceRequestsCPU:        requirements.Requests[v1.ResourceCPU],
+		v1.ResourceCPU:                requirements.Requests[v1.ResourceCPU],
+		v1.ResourceRequestsMemory:     requirements.Requests[v1.ResourceMemory],
+		v1.ResourceMemory:             requirements.Requests[v1.ResourceMemory],
+		v1.ResourceLimitsCPU:          requirements.Limits[v1.ResourceCPU],
+		v1.ResourceLimitsMemory:       requirements.Limits[v1.ResourceMemory],
+	}
+}
+
+// getExistingPodQuotaUsage returns the quota usage of an existing pod, which is freed when the pod is redeployed. Kubernetes charges the
+// sum of the requirements of the containers, or the requirements of the largest init container if that is larger.
+func getExistingPodQuotaUsage(pod *v1.Pod) v1.ResourceList {
+	var requirements v1.ResourceRequirements
+	requirements.Requests = v1.ResourceList{}
+	requirements.Limits = v1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		addResourceLists(requirements.Requests, container.Resources.Requests)
+		addResourceLists(requirements.Limits, container.Resources.Limits)
+	}
+	for _, container := range pod.Spec.InitContainers {
+		maxResourceLists(requirements.Requests, container.Resources.Requests)
+		maxResourceLists(requirements.Limits, container.Resources.Limits)
+	}
+	return getPodQuotaUsage(&requirements)
+}
+
+func addResourceLists(into, from v1.ResourceList) {
+	for name, quantity := range from {
+		sum := into[name]
+		sum.Add(quantity)
+		into[name] = sum
+	}
+}
+
+func maxResourceLists(into, from v1.ResourceList) {
+	for name, quantity := range from {
+		if current, ok := into[name]; !ok || quantity.Cmp(current) > 0 {
+			into[name] = quantity
+		}
+	}
+}
+
+// validateResourceQuotas returns an error if the demands exceed the available amount of a resource of a quota, with a per service
+// breakdown of the demand. The available amount is the hard limit minus the used amount, plus the usage that is freed by redeploying
+// existing pods. Quotas with scopes are skipped, because whether they apply depends on details of the pods.
+func validateResourceQuotas(quotas []v1.ResourceQuota, demands []*quotaDemand, freed v1.ResourceList) error {
+	var violations []string
+	for i := range quotas {
+		quota := &quotas[i]
+		if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+			log.Debugf("skipping pre-flight validation of ResourceQuota %s, because it has scopes", quota.ObjectMeta.Name)
+			continue
+		}
+		names := make([]string, 0, len(quota.Status.Hard))
+		for name := range quota.Status.Hard {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+		for _, nameString := range names {
+			name := v1.ResourceName(nameString)
+			var needed resource.Quantity
+			var breakdown []string
+			for _, demand := range demands {
+				quantity, ok := demand.usage[name]
+				if !ok || quantity.IsZero() {
+					continue
+				}
+				needed.Add(quantity)
+				breakdown = append(breakdown, fmt.Sprintf("%s: %s", demand.name, quantity.String()))
+			}
+			if len(breakdown) == 0 {
+				continue
+			}
+			hard := quota.Status.Hard[name]
+			available := hard.DeepCopy()
+			available.Sub(quota.Status.Used[name])
+			available.Add(freed[name])
+			if needed.Cmp(available) > 0 {
+				violations = append(violations, fmt.Sprintf("%s of ResourceQuota %s: %s is needed (%s), but only %s of %s is available",
+					name, quota.ObjectMeta.Name, needed.String(), strings.Join(breakdown, ", "), available.String(),
+					hard.String()))
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("the pods would exceed the resource quotas of the namespace:\n  %s", strings.Join(violations, "\n  "))
+	}
+	return nil
+}
+
+// checkResourceQuotas validates that the pods of the apps to be started fit in the resource quotas of the namespace, so that up fails
+// early instead of pods being rejected. If the resource quotas cannot be listed (e.g. for lack of permissions) then the check is skipped.
+func (u *upRunner) checkResourceQuotas() error {
+	quotaList, err := u.k8sClientset.CoreV1().ResourceQuotas(u.cfg.Namespace).List(metav1.ListOptions{})
+	if k8sError.IsForbidden(err) {
+		log.Debugf("skipping pre-flight validation of resource quotas: %v", err)
+		return nil
+	} else if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	if len(quotaList.Items) == 0 {
+		return nil
+	}
+	podList, err := u.k8sPodClient.List(metav1.ListOptions{
+		LabelSelector: k8smeta.GetLabelSelector(u.cfg),
+	})
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	existingPods := map[string]*v1.Pod{}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		// Pods that terminated do not use quota.
+		if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+			existingPods[pod.ObjectMeta.Name] = pod
+		}
+	}
+	var demands []*quotaDemand
+	freed := v1.ResourceList{}
+	for app := range u.appsToBeStarted {
+		// Only the resource quotas of the namespace of the environment are validated.
+		if u.cfg.GetNamespace(app.composeService) != u.cfg.Namespace {
+			continue
+		}
+		// The requirements of a pod are the sum of the requirements of its containers.
+		var requirements v1.ResourceRequirements
+		requirements.Requests = v1.ResourceList{}
+		requirements.Limits = v1.ResourceList{}
+		for _, app2 := range app.podApps {
+			containerRequirements := getContainerResources(app2.composeService, &u.cfg.DefaultResources)
+			addResourceLists(requirements.Requests, containerRequirements.Requests)
+			addResourceLists(requirements.Limits, containerRequirements.Limits)
+		}
+		demands = append(demands, &quotaDemand{
+			name:  app.name(),
+			usage: getPodQuotaUsage(&requirements),
+		})
+		if pod := existingPods[k8smeta.GetK8sName(app.composeService, u.cfg)]; pod != nil {
+			addResourceLists(freed, getExistingPodQuotaUsage(pod))
+		}
+	}
+	sort.Slice(demands, func(i, j int) bool {
+		return demands[i].name < demands[j].name
+	})
+	return exitcode.Wrap(validateResourceQuotas(quotaList.Items, demands, freed), exitcode.Config)
+}
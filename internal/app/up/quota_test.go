@@ -0,0 +1,140 @@
+package up
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestResourceQuota(hard, used v1.ResourceList) v1.ResourceQuota {
+	return v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "quota1",
+		},
+		Status: v1.ResourceQuotaStatus{
+			Hard: hard,
+			Used: used,
+		},
+	}
+}
+
+func newTestQuotaDemands() []*quotaDemand {
+	return []*quotaDemand{
+		{
+			name: "a",
+			usage: getPodQuotaUsage(&v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceCPU: resource.MustParse("500m"),
+				},
+			}),
+		},
+		{
+			name: "b",
+			usage: getPodQuotaUsage(&v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceCPU: resource.MustParse("1"),
+				},
+			}),
+		},
+	}
+}
+
+func TestValidateResourceQuotas_Success(t *testing.T) {
+	quotas := []v1.ResourceQuota{
+		newTestResourceQuota(v1.ResourceList{
+			v1.ResourcePods:        resource.MustParse("10"),
+			v1.ResourceRequestsCPU: resource.MustParse("2"),
+		}, v1.ResourceList{
+			v1.ResourcePods:        resource.MustParse("1"),
+			v1.ResourceRequestsCPU: resource.MustParse("500m"),
+		}),
+	}
+	err := validateResourceQuotas(quotas, newTestQuotaDemands(), v1.ResourceList{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateResourceQuotas_Exceeded(t *testing.T) {
+	quotas := []v1.ResourceQuota{
+		newTestResourceQuota(v1.ResourceList{
+			v1.ResourceRequestsCPU: resource.MustParse("2"),
+		}, v1.ResourceList{
+			v1.ResourceRequestsCPU: resource.MustParse("1"),
+		}),
+	}
+	err := validateResourceQuotas(quotas, newTestQuotaDemands(), v1.ResourceList{})
+	if err == nil || !strings.Contains(err.Error(), "requests.cpu of ResourceQuota quota1: 1500m is needed (a: 500m, b: 1), but only 1 of 2 "+
+		"is available") {
+		t.Error(err)
+	}
+}
+
+func TestValidateResourceQuotas_Freed(t *testing.T) {
+	quotas := []v1.ResourceQuota{
+		newTestResourceQuota(v1.ResourceList{
+			v1.ResourcePods: resource.MustParse("2"),
+		}, v1.ResourceList{
+			v1.ResourcePods: resource.MustParse("2"),
+		}),
+	}
+	freed := getExistingPodQuotaUsage(&v1.Pod{})
+	freed[v1.ResourcePods] = resource.MustParse("2")
+	err := validateResourceQuotas(quotas, newTestQuotaDemands(), freed)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateResourceQuotas_Scoped(t *testing.T) {
+	quota := newTestResourceQuota(v1.ResourceList{
+		v1.ResourcePods: resource.MustParse("0"),
+	}, v1.ResourceList{})
+	quota.Spec.Scopes = []v1.ResourceQuotaScope{
+		v1.ResourceQuotaScopeBestEffort,
+	}
+	err := validateResourceQuotas([]v1.ResourceQuota{quota}, newTestQuotaDemands(), v1.ResourceList{})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetExistingPodQuotaUsage_InitContainers(t *testing.T) {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							v1.ResourceMemory: resource.MustParse("128Mi"),
+						},
+					},
+				},
+				{
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							v1.ResourceMemory: resource.MustParse("128Mi"),
+						},
+					},
+				},
+			},
+			InitContainers: []v1.Container{
+				{
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							v1.ResourceMemory: resource.MustParse("512Mi"),
+						},
+					},
+				},
+			},
+		},
+	}
+	usage := getExistingPodQuotaUsage(pod)
+	memory := usage[v1.ResourceRequestsMemory]
+	if memory.String() != "512Mi" {
+		t.Error(memory.String())
+	}
+}
@@ -0,0 +1,278 @@
+package up
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The interval at which the resource usage of pods is sampled (see Options.RecommendResources). metrics-server collects metrics every
+// 15 seconds by default, so sampling more often yields no extra information. It is a variable to improve testability.
+var resourceSampleInterval = 15 * time.Second
+
+// The percentile of the sampled usage of a resource that is recommended as the reservation of the resource, and the headroom that is
+// added to the maximum sampled usage to recommend a limit.
+const (
+	resourceReservationPercentile = 0.9
+	resourceLimitHeadroom         = 1.25
+)
+
+// The minimum values of cpu_shares and mem_limit/mem_reservation (in MiB) that docker accepts.
+const (
+	minRecommendedCPUShares = 2
+	minRecommendedMemoryMiB = 6
+)
+
+// containerMetrics is the ContainerMetrics of the metrics.k8s.io API. The version of client-go that kube-compose is built with does not
+// include the types of this API, so only the fields that are used are declared.
+type containerMetrics struct {
+	Name  string          `json:"name"`
+	Usage v1.ResourceList `json:"usage"`
+}
+
+// podMetrics is the PodMetrics of the metrics.k8s.io API (see containerMetrics).
+type podMetrics struct {
+	Metadata   metav1.ObjectMeta  `json:"metadata"`
+	Containers []containerMetrics `json:"containers"`
+}
+
+// podMetricsList is the PodMetricsList of the metrics.k8s.io API (see containerMetrics).
+type podMetricsList struct {
+	Items []podMetrics `json:"items"`
+}
+
+// getPodMetrics returns the metrics of the pods of the environment in each namespace of the docker compose services. It is a variable to
+// improve testability.
+var getPodMetrics = func(u *upRunner) (*podMetricsList, error) {
+	list := &podMetricsList{}
+	for _, namespace := range u.cfg.Namespaces() {
+		data, err := u.k8sClientset.Discovery().RESTClient().Get().
+			AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", namespace, "pods").
+			Param("labelSelector", k8smeta.GetLabelSelector(u.cfg)).
+			Do().
+			Raw()
+		if err != nil {
+			return nil, err
+		}
+		namespaceList := &podMetricsList{}
+		err = json.Unmarshal(data, namespaceList)
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, namespaceList.Items...)
+	}
+	return list, nil
+}
+
+// resourceUsage is the sampled resource usage of the container of an app.
+type resourceUsage struct {
+	// The CPU usage in millicores.
+	cpu []int64
+	// The memory usage in bytes.
+	memory []int64
+}
+
+// resourceRecorder samples the resource usage of the containers of apps while up is attached (see Options.RecommendResources).
+type resourceRecorder struct {
+	done  chan struct{}
+	mutex sync.Mutex
+	// The number of times the usage of pods was sampled.
+	samples int
+	start   time.Time
+	stop    chan struct{}
+	usage   map[*app]*resourceUsage
+}
+
+// resourceRecommendation is the recommended resource constraints of a docker compose service, as values of the version 2 keys that are
+// mapped to resource requirements of containers.
+type resourceRecommendation struct {
+	cpuShares         int64
+	cpus              float64
+	memLimitMiB       int64
+	memReservationMiB int64
+}
+
+// percentile returns the nearest-rank percentile p (between 0 and 1) of a non-empty slice of values.
+func percentile(values []int64, p float64) int64 {
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+func roundUpMiB(bytes float64) int64 {
+	mib := int64(math.Ceil(bytes / (1 << 20)))
+	if mib < minRecommendedMemoryMiB {
+		mib = minRecommendedMemoryMiB
+	}
+	return mib
+}
+
+// recommend returns the recommended resource constraints for sampled resource usage. The reservations are a high percentile of the
+// usage, so that the scheduler reserves what the container typically needs, and the limits are the maximum usage with headroom.
+func (r *resourceUsage) recommend() *resourceRecommendation {
+	cpuReservation := percentile(r.cpu, resourceReservationPercentile)
+	cpuShares := int64(math.Ceil(float64(cpuReservation) * 1024 / 1000))
+	if cpuShares < minRecommendedCPUShares {
+		cpuShares = minRecommendedCPUShares
+	}
+	// cpus is rounded up to hundredths of a CPU.
+	cpus := math.Ceil(float64(percentile(r.cpu, 1))*resourceLimitHeadroom/10) / 100
+	if cpus < 0.01 {
+		cpus = 0.01
+	}
+	return &resourceRecommendation{
+		cpuShares:         cpuShares,
+		cpus:              cpus,
+		memLimitMiB:       roundUpMiB(float64(percentile(r.memory, 1)) * resourceLimitHeadroom),
+		memReservationMiB: roundUpMiB(float64(percentile(r.memory, resourceReservationPercentile))),
+	}
+}
+
+// sampleResourceUsage records the current resource usage of the containers of apps. Containers that are not the container of an app,
+// such as the sidecar proxies of service meshes, are ignored.
+func (u *upRunner) sampleResourceUsage() error {
+	list, err := getPodMetrics(u)
+	if err != nil {
+		return err
+	}
+	podApps := map[string]*app{}
+	for _, a := range u.apps {
+		if a.podApp == a {
+			podApps[k8smeta.GetK8sName(a.composeService, u.cfg)] = a
+		}
+	}
+	r := u.resourceRecorder
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.samples++
+	for _, item := range list.Items {
+		a := podApps[item.Metadata.Name]
+		if a == nil {
+			continue
+		}
+		for _, container := range item.Containers {
+			containerApp := a.getContainerApp(container.Name)
+			if containerApp == nil {
+				continue
+			}
+			usage := r.usage[containerApp]
+			if usage == nil {
+				usage = &resourceUsage{}
+				r.usage[containerApp] = usage
+			}
+			usage.cpu = append(usage.cpu, container.Usage.Cpu().MilliValue())
+			usage.memory = append(usage.memory, container.Usage.Memory().Value())
+		}
+	}
+	return nil
+}
+
+// startResourceRecorder starts sampling the resource usage of the containers of apps, if resources are recommended and the cluster
+// serves the metrics API.
+func (u *upRunner) startResourceRecorder() {
+	if !u.opts.RecommendResources || u.capabilities.noMetricsAPI {
+		return
+	}
+	r := &resourceRecorder{
+		done:  make(chan struct{}),
+		start: time.Now(),
+		stop:  make(chan struct{}),
+		usage: map[*app]*resourceUsage{},
+	}
+	u.resourceRecorder = r
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(resourceSampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+			err := u.sampleResourceUsage()
+			if k8sError.IsNotFound(err) {
+				log.Warnf("cannot recommend resources because the metrics API is not available (is metrics-server installed?)")
+				return
+			}
+			if err != nil {
+				log.Debugf("could not sample the resource usage of pods: %v", err)
+			}
+		}
+	}()
+}
+
+// waitForCompletedChannels waits until the logs of all containers have been streamed. If resources are recommended then it also returns
+// when up is interrupted, so that the recommendations can be printed.
+func (u *upRunner) waitForCompletedChannels() {
+	completed := make(chan struct{})
+	go func() {
+		for _, completedChannel := range u.completedChannels {
+			<-completedChannel
+		}
+		close(completed)
+	}()
+	if u.resourceRecorder == nil {
+		<-completed
+		return
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	select {
+	case <-completed:
+	case <-interrupt:
+	}
+}
+
+// writeResourceRecommendations stops sampling resource usage, and writes the recommended resource constraints of each docker compose
+// service with samples as a docker compose file that can be merged into the user's docker compose file.
+func (u *upRunner) writeResourceRecommendations(w io.Writer) error {
+	r := u.resourceRecorder
+	close(r.stop)
+	<-r.done
+	names := make([]string, 0, len(r.usage))
+	recommendations := map[string]*resourceRecommendation{}
+	for a, usage := range r.usage {
+		names = append(names, a.name())
+		recommendations[a.name()] = usage.recommend()
+	}
+	if len(names) == 0 {
+		log.Info("no resources are recommended, because the resource usage of pods was not sampled")
+		return nil
+	}
+	sort.Strings(names)
+	_, err := fmt.Fprintf(w, "# recommended resources based on %d samples over %v\nservices:\n", r.samples,
+		time.Since(r.start).Round(time.Second))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		rec := recommendations[name]
+		_, err = fmt.Fprintf(w, "  %s:\n    cpu_shares: %d\n    cpus: '%.2f'\n    mem_limit: %dm\n    mem_reservation: %dm\n", name,
+			rec.cpuShares, rec.cpus, rec.memLimitMiB, rec.memReservationMiB)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package up
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestUpRunnerRecommendResources() *upRunner {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.cfg.EnvironmentID = "test"
+	u.opts.RecommendResources = true
+	u.resourceRecorder = &resourceRecorder{
+		done:  make(chan struct{}),
+		stop:  make(chan struct{}),
+		usage: map[*app]*resourceUsage{},
+	}
+	close(u.resourceRecorder.done)
+	return u
+}
+
+// withTestPodMetrics makes up sample a pod of the app a whose container uses cpu and memory, and that has a container that is not the
+// container of an app, as well as a pod that is not a pod of an app.
+func withTestPodMetrics(cpu, memory string, cb func()) {
+	orig := getPodMetrics
+	defer func() {
+		getPodMetrics = orig
+	}()
+	getPodMetrics = func(_ *upRunner) (*podMetricsList, error) {
+		return &podMetricsList{
+			Items: []podMetrics{
+				{
+					Metadata: metav1.ObjectMeta{
+						Name: "a-test",
+					},
+					Containers: []containerMetrics{
+						{
+							Name: "a",
+							Usage: v1.ResourceList{
+								v1.ResourceCPU:    resource.MustParse(cpu),
+								v1.ResourceMemory: resource.MustParse(memory),
+							},
+						},
+						{
+							Name: "istio-proxy",
+							Usage: v1.ResourceList{
+								v1.ResourceCPU:    resource.MustParse("1"),
+								v1.ResourceMemory: resource.MustParse("1Gi"),
+							},
+						},
+					},
+				},
+				{
+					Metadata: metav1.ObjectMeta{
+						Name: "a-test-dns-probe",
+					},
+				},
+			},
+		}, nil
+	}
+	cb()
+}
+
+func TestSampleResourceUsage_Success(t *testing.T) {
+	u := newTestUpRunnerRecommendResources()
+	for _, sample := range [][]string{{"10m", "10Mi"}, {"100m", "20Mi"}} {
+		withTestPodMetrics(sample[0], sample[1], func() {
+			err := u.sampleResourceUsage()
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	r := u.resourceRecorder
+	usage := r.usage[u.apps["a"]]
+	if r.samples != 2 || len(r.usage) != 1 || usage == nil || fmt.Sprint(usage.cpu) != "[10 100]" ||
+		fmt.Sprint(usage.memory) != "[10485760 20971520]" {
+		t.Error(r.samples, r.usage)
+	}
+}
+
+func TestSampleResourceUsage_Error(t *testing.T) {
+	u := newTestUpRunnerRecommendResources()
+	orig := getPodMetrics
+	defer func() {
+		getPodMetrics = orig
+	}()
+	getPodMetrics = func(_ *upRunner) (*podMetricsList, error) {
+		return nil, fmt.Errorf("sampleResourceUsageError")
+	}
+	err := u.sampleResourceUsage()
+	if err == nil || u.resourceRecorder.samples != 0 {
+		t.Error(err)
+	}
+}
+
+func TestResourceUsageRecommend_Success(t *testing.T) {
+	usage := &resourceUsage{}
+	for i := int64(1); i <= 10; i++ {
+		usage.cpu = append(usage.cpu, i*100)
+		usage.memory = append(usage.memory, i*10<<20)
+	}
+	rec := usage.recommend()
+	// The reservations are the 90th percentiles (900m and 90Mi), and the limits are the maxima with 25% headroom (1250m and 125Mi).
+	if rec.cpuShares != 922 || rec.cpus != 1.25 || rec.memReservationMiB != 90 || rec.memLimitMiB != 125 {
+		t.Error(rec)
+	}
+}
+
+func TestResourceUsageRecommend_Minimum(t *testing.T) {
+	usage := &resourceUsage{
+		cpu:    []int64{0},
+		memory: []int64{1 << 20},
+	}
+	rec := usage.recommend()
+	if rec.cpuShares != minRecommendedCPUShares || rec.cpus != 0.01 || rec.memReservationMiB != minRecommendedMemoryMiB ||
+		rec.memLimitMiB != minRecommendedMemoryMiB {
+		t.Error(rec)
+	}
+}
+
+func TestWriteResourceRecommendations_Success(t *testing.T) {
+	u := newTestUpRunnerRecommendResources()
+	u.resourceRecorder.samples = 1
+	u.resourceRecorder.usage[u.apps["a"]] = &resourceUsage{
+		cpu:    []int64{100},
+		memory: []int64{64 << 20},
+	}
+	var buffer bytes.Buffer
+	err := u.writeResourceRecommendations(&buffer)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := "services:\n  a:\n    cpu_shares: 103\n    cpus: '0.13'\n    mem_limit: 80m\n    mem_reservation: 64m\n"
+	if output := buffer.String(); !strings.HasSuffix(output, expected) {
+		t.Error(output)
+	}
+}
+
+func TestWriteResourceRecommendations_NoSamples(t *testing.T) {
+	u := newTestUpRunnerRecommendResources()
+	var buffer bytes.Buffer
+	err := u.writeResourceRecommendations(&buffer)
+	if err != nil || buffer.Len() != 0 {
+		t.Error(err, buffer.String())
+	}
+}
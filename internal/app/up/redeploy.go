@@ -0,0 +1,176 @@
+package up
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The interval at which a deleted pod is polled until it is gone. It is a variable to improve testability.
+var podDeletionPollInterval = time.Second
+
+// The time that is waited until a deleted pod is gone, in addition to its grace period. Pods with finalizers are not gone when their grace
+// period ends. It is a variable to improve testability.
+var podDeletionTimeoutMargin = time.Minute
+
+// The grace period of pods that do not set one, which is the default of Kubernetes.
+const defaultGracePeriod = 30 * time.Second
+
+// computePodSpecHash returns a hash of a pod spec, which is stored in an annotation so that changes to a docker compose service can be
+// detected when its pod already exists.
+func computePodSpecHash(spec *v1.PodSpec) (string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// computePodConfigHash returns a hash of the configuration of an app's pod that is not part of the pod's spec: the data of the Secret
+// with the pod's secret environment variables, the external docker compose secrets mounted into the pod and the image with the contents
+// of bind mounted volumes (whose tag does not change when the contents change).
+func (u *upRunner) computePodConfigHash(app *app, secretData map[string][]byte) (string, error) {
+	hash := sha256.New()
+	encoder := json.NewEncoder(hash)
+	// encoding/json sorts map keys, so the hash does not depend on the iteration order of maps.
+	err := encoder.Encode(secretData)
+	if err != nil {
+		return "", err
+	}
+	for _, serviceSecret := range app.composeService.DockerComposeService.Secrets {
+		err = encoder.Encode(u.cfg.Secrets[serviceSecret.Source])
+		if err != nil {
+			return "", err
+		}
+	}
+	err = encoder.Encode(app.volumeInitImage.sourceImageID)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// getRecreatePodReason returns a human readable reason why the existing pod of an app needs to be redeployed, or the empty string if
+// the existing pod can be kept. The annotations of pod are compared with those of the existing pod. The pod is also redeployed if a
+// dependency was redeployed, and either --cascade-restart is set or the dependency has restart: true.
+func (u *upRunner) getRecreatePodReason(app *app, existing, pod *v1.Pod) string {
+	if existing.ObjectMeta.Annotations[k8smeta.SpecHashAnnotationName] != pod.ObjectMeta.Annotations[k8smeta.SpecHashAnnotationName] {
+		return "its specification changed"
+	}
+	if existing.ObjectMeta.Annotations[k8smeta.ConfigHashAnnotationName] != pod.ObjectMeta.Annotations[k8smeta.ConfigHashAnnotationName] {
+		return "its configuration changed"
+	}
+	dependsOn, dependsOnRestart := app.getPodDependsOn()
+	for name := range dependsOn {
+		if !u.opts.CascadeRestart && !dependsOnRestart[name] {
+			continue
+		}
+		app2 := u.apps[u.cfg.Services[name].Name()]
+		if app2.redeployed {
+			return fmt.Sprintf("its dependency %s was redeployed", app2.name())
+		}
+	}
+	return ""
+}
+
+// createOrRecreatePod creates a pod. If the pod already exists then the existing pod is kept, unless getRecreatePodReason returns a
+// reason to redeploy it, in which case the existing pod is deleted and the pod is created again.
+func (u *upRunner) createOrRecreatePod(app *app, pod *v1.Pod) (*v1.Pod, error) {
+	specHash, err := computePodSpecHash(&pod.Spec)
+	if err != nil {
+		return nil, err
+	}
+	pod.ObjectMeta.Annotations[k8smeta.SpecHashAnnotationName] = specHash
+	app.podCreationTime = time.Now()
+	namespace := u.getNamespace(pod.ObjectMeta.Namespace)
+	podServer, err := k8smeta.CreatePod(u.podClient(namespace), u.k8sCoreRESTClient, namespace, pod)
+	if err == nil {
+		u.metrics.reconciles.Inc(app.name(), reconcileResultCreated)
+		u.addToRevision(app, pod)
+		app.newLogEntry().Debugf("created pod %s", pod.ObjectMeta.Name)
+		app.podUID = podServer.UID
+		return podServer, nil
+	}
+	if !k8sError.IsAlreadyExists(err) {
+		return nil, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	existing, err := u.podClient(namespace).Get(pod.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	err = k8smeta.ValidateOwnership(u.cfg, "Pod", &existing.ObjectMeta)
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.Config)
+	}
+	reason := u.getRecreatePodReason(app, existing, pod)
+	if reason == "" {
+		app.newLogEntry().Debugf("pod %s already exists", pod.ObjectMeta.Name)
+		u.metrics.reconciles.Inc(app.name(), reconcileResultExists)
+		app.podUID = existing.UID
+		return existing, nil
+	}
+	app.newLogEntry().Infof("redeploying pod %s because %s", pod.ObjectMeta.Name, reason)
+	err = u.deletePodAndWait(existing, k8smeta.GetGracePeriodSeconds(app.composeService))
+	if err != nil {
+		return nil, err
+	}
+	app.podCreationTime = time.Now()
+	podServer, err = k8smeta.CreatePod(u.podClient(namespace), u.k8sCoreRESTClient, namespace, pod)
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	u.metrics.reconciles.Inc(app.name(), reconcileResultRecreated)
+	u.addToRevision(app, pod)
+	app.newLogEntry().Debugf("recreated pod %s", pod.ObjectMeta.Name)
+	app.podUID = podServer.UID
+	app.redeployed = true
+	return podServer, nil
+}
+
+// deletePodAndWait deletes a pod and waits until it no longer exists. Waiting fails if the pod is not gone within its grace period plus
+// podDeletionTimeoutMargin (e.g. because a finalizer blocks its deletion), or if the context of up is done.
+func (u *upRunner) deletePodAndWait(pod *v1.Pod, gracePeriodSeconds *int64) error {
+	client := u.podClient(pod.ObjectMeta.Namespace)
+	err := client.Delete(pod.ObjectMeta.Name, &metav1.DeleteOptions{
+		GracePeriodSeconds: gracePeriodSeconds,
+		Preconditions: &metav1.Preconditions{
+			UID: &pod.UID,
+		},
+	})
+	if err != nil && !k8sError.IsNotFound(err) {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	timeout := defaultGracePeriod + podDeletionTimeoutMargin
+	if gracePeriodSeconds != nil {
+		timeout = time.Duration(*gracePeriodSeconds)*time.Second + podDeletionTimeoutMargin
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		podServer, err := client.Get(pod.ObjectMeta.Name, metav1.GetOptions{})
+		if k8sError.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		} else if podServer.UID != pod.UID {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return exitcode.Wrap(fmt.Errorf("timed out after %v waiting until pod %s is deleted", timeout, pod.ObjectMeta.Name),
+				exitcode.ReadinessTimeout)
+		}
+		select {
+		case <-u.opts.Context.Done():
+			return u.opts.Context.Err()
+		case <-time.After(podDeletionPollInterval):
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package up
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+func TestComputePodSpecHash_Success(t *testing.T) {
+	spec1 := &v1.PodSpec{
+		Containers: []v1.Container{
+			{
+				Image: "image1",
+			},
+		},
+	}
+	spec2 := &v1.PodSpec{
+		Containers: []v1.Container{
+			{
+				Image: "image2",
+			},
+		},
+	}
+	hash1, err := computePodSpecHash(spec1)
+	if err != nil {
+		t.Error(err)
+	}
+	hash1Again, _ := computePodSpecHash(spec1)
+	hash2, _ := computePodSpecHash(spec2)
+	if hash1 != hash1Again || hash1 == hash2 {
+		t.Fail()
+	}
+}
+
+func newTestExistingPod(specHash string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				k8smeta.SpecHashAnnotationName: specHash,
+			},
+		},
+	}
+}
+
+func TestComputePodSpecHash_HostAliasesDeterministic(t *testing.T) {
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	u.initApps()
+	for i, name := range []string{"a", "b", "c", "d"} {
+		u.apps[name].composeService.Ports = []config.Port{
+			{
+				Port:     8080,
+				Protocol: "tcp",
+			},
+		}
+		u.apps[name].serviceClusterIP = fmt.Sprintf("10.0.0.%d", i+1)
+	}
+	hostAliases := u.getServiceHostAliases()
+	hash, _ := computePodSpecHash(&v1.PodSpec{
+		HostAliases: hostAliases,
+	})
+	if len(hostAliases) != 4 || hostAliases[0].Hostnames[0] != "a" || hostAliases[3].Hostnames[0] != "d" {
+		t.Error(hostAliases)
+	}
+	// Map iteration order is random, so compare the hashes of several runs.
+	for i := 0; i < 10; i++ {
+		hash2, _ := computePodSpecHash(&v1.PodSpec{
+			HostAliases: u.getServiceHostAliases(),
+		})
+		if hash2 != hash {
+			t.Error(hash, hash2)
+		}
+	}
+}
+
+func TestGetRecreatePodReason_Unchanged(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.opts.CascadeRestart = true
+	reason := u.getRecreatePodReason(u.apps["a"], newTestExistingPod("hash"), newTestExistingPod("hash"))
+	if reason != "" {
+		t.Error(reason)
+	}
+}
+
+func TestGetRecreatePodReason_SpecChanged(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	reason := u.getRecreatePodReason(u.apps["a"], newTestExistingPod("hash1"), newTestExistingPod("hash2"))
+	if reason != "its specification changed" {
+		t.Error(reason)
+	}
+}
+
+func TestGetRecreatePodReason_DependencyRedeployed(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.opts.CascadeRestart = true
+	u.apps["c"].redeployed = true
+	reason := u.getRecreatePodReason(u.apps["a"], newTestExistingPod("hash"), newTestExistingPod("hash"))
+	if reason != "its dependency c was redeployed" {
+		t.Error(reason)
+	}
+}
+
+func TestGetRecreatePodReason_DependencyRedeployedWithoutCascadeRestart(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.apps["c"].redeployed = true
+	reason := u.getRecreatePodReason(u.apps["a"], newTestExistingPod("hash"), newTestExistingPod("hash"))
+	if reason != "" {
+		t.Error(reason)
+	}
+}
+
+func TestGetRecreatePodReason_DependencyRedeployedWithRestart(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.apps["a"].composeService.DockerComposeService.DependsOnRestart = map[string]bool{
+		"d": true,
+	}
+	u.apps["c"].redeployed = true
+	u.apps["d"].redeployed = true
+	reason := u.getRecreatePodReason(u.apps["a"], newTestExistingPod("hash"), newTestExistingPod("hash"))
+	if reason != "its dependency d was redeployed" {
+		t.Error(reason)
+	}
+}
+
+func TestGetRecreatePodReason_ConfigChanged(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	existing := newTestExistingPod("hash")
+	existing.ObjectMeta.Annotations[k8smeta.ConfigHashAnnotationName] = "config1"
+	pod := newTestExistingPod("hash")
+	pod.ObjectMeta.Annotations[k8smeta.ConfigHashAnnotationName] = "config2"
+	reason := u.getRecreatePodReason(u.apps["a"], existing, pod)
+	if reason != "its configuration changed" {
+		t.Error(reason)
+	}
+}
+
+func TestComputePodConfigHash_SecretDataChanged(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	hash1, err := u.computePodConfigHash(u.apps["a"], map[string][]byte{
+		"PASSWORD": []byte("secret1"),
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	hash1Again, _ := u.computePodConfigHash(u.apps["a"], map[string][]byte{
+		"PASSWORD": []byte("secret1"),
+	})
+	hash2, _ := u.computePodConfigHash(u.apps["a"], map[string][]byte{
+		"PASSWORD": []byte("secret2"),
+	})
+	if hash1 != hash1Again || hash1 == hash2 {
+		t.Fail()
+	}
+}
+
+func TestComputePodConfigHash_VolumesChanged(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	app := u.apps["a"]
+	hash1, _ := u.computePodConfigHash(app, nil)
+	app.volumeInitImage.sourceImageID = "sha256:123"
+	hash2, _ := u.computePodConfigHash(app, nil)
+	if hash1 == hash2 {
+		t.Fail()
+	}
+}
+
+// newTestStuckPodRunner returns an upRunner of a cluster with a pod that is not gone after it is deleted, like a pod with a finalizer.
+func newTestStuckPodRunner(ctx context.Context) (*upRunner, *v1.Pod) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "default",
+			UID:       "1",
+		},
+	}
+	clientset := fake.NewSimpleClientset(pod)
+	clientset.PrependReactor("delete", "pods", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		return true, nil, nil
+	})
+	u := &upRunner{
+		cfg: &config.Config{
+			Namespace: "default",
+		},
+		k8sPodClient: clientset.CoreV1().Pods("default"),
+		opts: &Options{
+			Context: ctx,
+		},
+	}
+	return u, pod
+}
+
+func withShortPodDeletionTimeout(cb func()) {
+	origPollInterval, origTimeoutMargin := podDeletionPollInterval, podDeletionTimeoutMargin
+	defer func() {
+		podDeletionPollInterval, podDeletionTimeoutMargin = origPollInterval, origTimeoutMargin
+	}()
+	podDeletionPollInterval, podDeletionTimeoutMargin = time.Millisecond, 10*time.Millisecond
+	cb()
+}
+
+func TestDeletePodAndWait_Timeout(t *testing.T) {
+	withShortPodDeletionTimeout(func() {
+		u, pod := newTestStuckPodRunner(context.Background())
+		gracePeriodSeconds := int64(0)
+		err := u.deletePodAndWait(pod, &gracePeriodSeconds)
+		if exitcode.FromError(err) != exitcode.ReadinessTimeout {
+			t.Error(err)
+		}
+	})
+}
+
+func TestDeletePodAndWait_ContextCanceled(t *testing.T) {
+	withShortPodDeletionTimeout(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		u, pod := newTestStuckPodRunner(ctx)
+		err := u.deletePodAndWait(pod, nil)
+		if err != context.Canceled {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package up
+
+import (
+	"io/ioutil"
+	"sort"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	v1 "k8s.io/api/core/v1"
+)
+
+// The image of the init containers that populate bind mounted volumes in rendered pods, because the image is built by a docker daemon.
+const renderVolumeInitImage = "kube-compose-volume-init"
+
+// stubAppImageInfo sets the image information of an app without a docker daemon: the image of the pod is the image of the docker compose
+// service (or the tag of the image that would be built, see getBuildImageTag), and the image is assumed to have no healthcheck, exposed
+// ports or user.
+func (u *upRunner) stubAppImageInfo(a *app) {
+	a.imageInfo.once.Do(func() {
+		a.imageInfo.podImage = a.composeService.DockerComposeService.Image
+		if a.composeService.DockerComposeService.Build != nil {
+			a.imageInfo.podImage = u.getBuildImageTag(a)
+		}
+	})
+	a.volumeInitImage.once.Do(func() {
+		a.volumeInitImage.podImage = renderVolumeInitImage
+	})
+}
+
+// Render returns the pods, PersistentVolumeClaims and Kubernetes services that up would apply for the docker compose services that match
+// the filter of cfg, each sorted by name, without connecting to a cluster or docker daemon. This makes the conversion of docker compose
+// files reviewable in golden files. Images are not pulled or pushed (see stubAppImageInfo), pods have no host aliases because the cluster
+// IPs of services are not known, and the Secrets of secret environment variables are not returned.
+func Render(cfg *config.Config, opts *Options) ([]interface{}, error) {
+	if opts.Reporter == nil {
+		opts.Reporter = reporter.New(ioutil.Discard)
+	}
+	u := newUpRunner(cfg, opts)
+	u.initApps()
+	u.initAppsToBeStarted()
+	u.initVolumeInfo()
+	err := u.checkOptions()
+	if err != nil {
+		return nil, err
+	}
+	u.hostAliases.once.Do(func() {})
+	apps := make([]*app, 0, len(u.appsToBeStarted))
+	for a := range u.appsToBeStarted {
+		apps = append(apps, a)
+		for _, a2 := range a.podApps {
+			u.stubAppImageInfo(a2)
+		}
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].name() < apps[j].name()
+	})
+	var objects []interface{}
+	for _, a := range apps {
+		pod, _, podErr := u.newPod(a)
+		if podErr != nil {
+			return nil, podErr
+		}
+		objects = append(objects, pod)
+	}
+	pvcs, err := renderPersistentVolumeClaims(u, apps)
+	if err != nil {
+		return nil, err
+	}
+	objects = append(objects, pvcs...)
+	services, err := renderServices(u)
+	if err != nil {
+		return nil, err
+	}
+	return append(objects, services...), nil
+}
+
+// getPersistentVolumeClaimKeys returns the sorted keys of the named docker compose volumes of the pods of apps. External volumes are
+// skipped, because their PersistentVolumeClaims are not created by up.
+func getPersistentVolumeClaimKeys(cfg *config.Config, apps []*app) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, a := range apps {
+		for _, key := range getAppNamedVolumes(a) {
+			if !seen[key] && cfg.Volumes[key].ClaimName == "" {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// renderPersistentVolumeClaims returns the PersistentVolumeClaims of the named docker compose volumes of the pods of apps, sorted by the
+// keys of the volumes (see getPersistentVolumeClaimKeys).
+func renderPersistentVolumeClaims(u *upRunner, apps []*app) ([]interface{}, error) {
+	var objects []interface{}
+	for _, key := range getPersistentVolumeClaimKeys(u.cfg, apps) {
+		pvc := newPersistentVolumeClaim(u.cfg, u.cfg.Volumes[key])
+		err := u.admitObject(pvc)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, pvc)
+	}
+	return objects, nil
+}
+
+// renderServices returns the Kubernetes services of all apps with ports like up, sorted by name, each followed by its service alias if
+// Options.ServiceAliases is set.
+func renderServices(u *upRunner) ([]interface{}, error) {
+	names := make([]string, 0, len(u.apps))
+	for name := range u.apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var objects []interface{}
+	for _, name := range names {
+		a := u.apps[name]
+		if !a.hasService() {
+			continue
+		}
+		services := []*v1.Service{newService(u.cfg, a)}
+		if u.opts.ServiceAliases {
+			services = append(services, newServiceAlias(u.cfg, a))
+		}
+		for _, service := range services {
+			err := u.setServiceIPFamilies(service)
+			if err != nil {
+				return nil, err
+			}
+			err = u.admitObject(service)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, service)
+		}
+	}
+	return objects, nil
+}
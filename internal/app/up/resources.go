@@ -0,0 +1,85 @@
+package up
+
+import (
+	"math"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// dockerDefaultCPUShares is the CPU weight of containers that do not set cpu_shares. The kubelet gives containers the same weight per
+// CPU that they request, so cpu_shares / dockerDefaultCPUShares CPUs preserves the weight of a container relative to other containers.
+const dockerDefaultCPUShares = 1024
+
+// getContainerResources maps the resource constraints of a docker compose service to the resource requirements of its container. Limits
+// map to limits, and mem_reservation maps to a memory request. Relative to docker's default weight, cpu_shares maps to a CPU request,
+// which is capped at the CPU limit because Kubernetes rejects requests that exceed limits. Reserved devices map to limits of extended
+// resources (see config.Service.DeviceLimits). Default requests and limits (see config.Config.DefaultResources) are applied with
+// applyDefaultResources.
+func getContainerResources(composeService *config.Service, defaults *v1.ResourceRequirements) v1.ResourceRequirements {
+	r := composeService.DockerComposeService.Resources
+	var requirements v1.ResourceRequirements
+	if r.CPULimit > 0 {
+		requirements.Limits = v1.ResourceList{}
+		requirements.Limits[v1.ResourceCPU] = *resource.NewMilliQuantity(int64(math.Ceil(r.CPULimit*1000)), resource.DecimalSI)
+	}
+	if r.MemoryLimit > 0 {
+		if requirements.Limits == nil {
+			requirements.Limits = v1.ResourceList{}
+		}
+		requirements.Limits[v1.ResourceMemory] = *resource.NewQuantity(r.MemoryLimit, resource.BinarySI)
+	}
+	if r.CPUShares > 0 {
+		milliCPU := int64(math.Ceil(float64(r.CPUShares) * 1000 / dockerDefaultCPUShares))
+		if r.CPULimit > 0 {
+			milliCPU = int64(math.Min(float64(milliCPU), math.Ceil(r.CPULimit*1000)))
+		}
+		requirements.Requests = v1.ResourceList{}
+		requirements.Requests[v1.ResourceCPU] = *resource.NewMilliQuantity(milliCPU, resource.DecimalSI)
+	}
+	if r.MemoryReservation > 0 {
+		if requirements.Requests == nil {
+			requirements.Requests = v1.ResourceList{}
+		}
+		requirements.Requests[v1.ResourceMemory] = *resource.NewQuantity(r.MemoryReservation, resource.BinarySI)
+	}
+	for name, quantity := range composeService.DeviceLimits {
+		if requirements.Limits == nil {
+			requirements.Limits = v1.ResourceList{}
+		}
+		requirements.Limits[name] = quantity
+	}
+	applyDefaultResources(&requirements, defaults)
+	return requirements
+}
+
+// applyDefaultResources sets the default requests and limits of resources for which requirements has no request or limit, respectively,
+// so that pods are not rejected in namespaces with a LimitRange or ResourceQuota that requires them. Defaults are adjusted so that a
+// request never exceeds the limit of the same resource.
+func applyDefaultResources(requirements *v1.ResourceRequirements, defaults *v1.ResourceRequirements) {
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		limit, hasLimit := requirements.Limits[name]
+		request, hasRequest := requirements.Requests[name]
+		if defaultRequest, ok := defaults.Requests[name]; ok && !hasRequest {
+			request, hasRequest = defaultRequest, true
+			if hasLimit && request.Cmp(limit) > 0 {
+				request = limit
+			}
+			if requirements.Requests == nil {
+				requirements.Requests = v1.ResourceList{}
+			}
+			requirements.Requests[name] = request
+		}
+		if defaultLimit, ok := defaults.Limits[name]; ok && !hasLimit {
+			limit = defaultLimit
+			if hasRequest && request.Cmp(limit) > 0 {
+				limit = request
+			}
+			if requirements.Limits == nil {
+				requirements.Limits = v1.ResourceList{}
+			}
+			requirements.Limits[name] = limit
+		}
+	}
+}
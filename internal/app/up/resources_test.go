@@ -0,0 +1,101 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestGetContainerResources_Success(t *testing.T) {
+	cfg := &config.Config{}
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+		Resources: dockerComposeConfig.Resources{
+			CPULimit:          0.25,
+			CPUShares:         512,
+			MemoryLimit:       512 << 20,
+			MemoryReservation: 256 << 20,
+		},
+	})
+	requirements := getContainerResources(composeService, &v1.ResourceRequirements{})
+	cpuLimit := requirements.Limits[v1.ResourceCPU]
+	memoryLimit := requirements.Limits[v1.ResourceMemory]
+	cpuRequest := requirements.Requests[v1.ResourceCPU]
+	memoryRequest := requirements.Requests[v1.ResourceMemory]
+	if cpuLimit.String() != "250m" || memoryLimit.String() != "512Mi" || cpuRequest.String() != "250m" || memoryRequest.String() != "256Mi" {
+		t.Error(requirements)
+	}
+}
+
+func TestGetContainerResources_None(t *testing.T) {
+	cfg := &config.Config{}
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	requirements := getContainerResources(composeService, &v1.ResourceRequirements{})
+	if requirements.Limits != nil || requirements.Requests != nil {
+		t.Error(requirements)
+	}
+}
+
+func newTestDefaultResources() *v1.ResourceRequirements {
+	return &v1.ResourceRequirements{
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("1"),
+			v1.ResourceMemory: resource.MustParse("512Mi"),
+		},
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("100m"),
+			v1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+	}
+}
+
+func TestGetContainerResources_Defaults(t *testing.T) {
+	cfg := &config.Config{}
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	requirements := getContainerResources(composeService, newTestDefaultResources())
+	if !reflect.DeepEqual(requirements, *newTestDefaultResources()) {
+		t.Error(requirements)
+	}
+}
+
+func TestGetContainerResources_DefaultsAdjusted(t *testing.T) {
+	cfg := &config.Config{}
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+		Resources: dockerComposeConfig.Resources{
+			CPULimit:          0.05,
+			MemoryReservation: 1 << 30,
+		},
+	})
+	requirements := getContainerResources(composeService, newTestDefaultResources())
+	cpuLimit := requirements.Limits[v1.ResourceCPU]
+	memoryLimit := requirements.Limits[v1.ResourceMemory]
+	cpuRequest := requirements.Requests[v1.ResourceCPU]
+	memoryRequest := requirements.Requests[v1.ResourceMemory]
+	if cpuLimit.String() != "50m" || memoryLimit.String() != "1Gi" || cpuRequest.String() != "50m" || memoryRequest.String() != "1Gi" {
+		t.Error(requirements)
+	}
+}
+
+func TestGetContainerResources_DeviceLimits(t *testing.T) {
+	cfg := &config.Config{}
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	composeService.DeviceLimits = v1.ResourceList{
+		"nvidia.com/gpu": *resource.NewQuantity(2, resource.DecimalSI),
+	}
+	requirements := getContainerResources(composeService, &v1.ResourceRequirements{})
+	gpuLimit := requirements.Limits["nvidia.com/gpu"]
+	if gpuLimit.String() != "2" || requirements.Requests != nil {
+		t.Error(requirements)
+	}
+}
@@ -0,0 +1,52 @@
+package up
+
+import (
+	"sync"
+
+	"github.com/kube-compose/kube-compose/internal/app/revision"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+// deployedRevision collects the pods that up creates, which are recorded as a revision of the environment once all pods are ready (see
+// recordRevision). A mutex is needed because pods are created concurrently.
+type deployedRevision struct {
+	mutex    sync.Mutex
+	services map[string]*revision.Service
+}
+
+// addToRevision adds the pod of an app, as it was created, to the revision that up records.
+func (u *upRunner) addToRevision(app *app, pod *v1.Pod) {
+	u.revision.mutex.Lock()
+	defer u.revision.mutex.Unlock()
+	if u.revision.services == nil {
+		u.revision.services = map[string]*revision.Service{}
+	}
+	u.revision.services[app.name()] = &revision.Service{
+		Hash:    app.hash,
+		Image:   app.imageInfo.podImage,
+		ImageID: app.imageInfo.sourceImageID,
+		Pod:     pod.DeepCopy(),
+	}
+}
+
+// recordRevision records the pods that up created as the next revision of the environment, together with the pods of the previous
+// revision that up kept. No revision is recorded if up did not create pods.
+func (u *upRunner) recordRevision() error {
+	u.revision.mutex.Lock()
+	defer u.revision.mutex.Unlock()
+	if len(u.revision.services) == 0 {
+		return nil
+	}
+	store := revision.NewStore(u.cfg, u.k8sClientset.CoreV1().ConfigMaps(u.cfg.Namespace))
+	revisions, err := store.List()
+	if err == nil {
+		r := revision.Latest(revisions).Next(u.cfg, u.revision.services)
+		err = store.Save(r, revisions)
+	}
+	if err != nil {
+		return exitcode.Wrap(errors.Wrap(err, "error while recording the revision of the environment"), exitcode.ClusterConnectivity)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package up
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// schedulingHint maps a fragment of a message of the Kubernetes scheduler to an explanation and a suggested fix.
+type schedulingHint struct {
+	fragment    string
+	explanation string
+	fix         string
+}
+
+var schedulingHints = []schedulingHint{
+	{
+		fragment:    "insufficient cpu",
+		explanation: "no node has enough unreserved CPU for the CPU request of the pod",
+		fix:         "lower cpus or cpu_shares of the service, lower --default-cpu-request, or free up CPU in the cluster",
+	},
+	{
+		fragment:    "insufficient memory",
+		explanation: "no node has enough unreserved memory for the memory request of the pod",
+		fix:         "lower mem_reservation or mem_limit of the service, lower --default-memory-request, or free up memory in the cluster",
+	},
+	{
+		fragment:    "insufficient ephemeral-storage",
+		explanation: "no node has enough unreserved ephemeral storage for the pod",
+		fix:         "free up disk space on the nodes of the cluster",
+	},
+	{
+		fragment:    "taint",
+		explanation: "the nodes have taints that the pod does not tolerate",
+		fix:         "schedule the pod on nodes without taints, or remove the taints with kubectl taint nodes",
+	},
+	{
+		fragment:    "unbound immediate persistentvolumeclaims",
+		explanation: "a PersistentVolumeClaim of the pod is not bound to a PersistentVolume",
+		fix:         "check that the cluster has a (default) StorageClass that can provision volumes, see kubectl describe pvc",
+	},
+	{
+		fragment:    "volume node affinity conflict",
+		explanation: "a PersistentVolume of the pod can only be attached to nodes that cannot run the pod",
+		fix:         "delete the PersistentVolumeClaim so that it is provisioned in a zone that has schedulable nodes",
+	},
+	{
+		fragment:    "didn't match node selector",
+		explanation: "no node matches the node selector or node affinity of the pod",
+		fix:         "label a node so that it matches, or change the node selector of the pod",
+	},
+	{
+		fragment:    "didn't match pod affinity rules",
+		explanation: "the pod affinity rules of the pod cannot be satisfied",
+		fix:         "relax the pod affinity rules of the pod",
+	},
+	{
+		fragment:    "anti-affinity",
+		explanation: "every node already runs a pod that the pod must not run next to",
+		fix:         "add nodes (or zones) to the cluster, or set \"required\" of the service's \"x-kube-compose\".\"topology_spread\" to false",
+	},
+	{
+		fragment:    "too many pods",
+		explanation: "the nodes have reached their maximum number of pods",
+		fix:         "delete unused pods or add nodes to the cluster",
+	},
+	{
+		fragment:    "didn't have free ports",
+		explanation: "the host ports requested by the pod are already in use on every node",
+		fix:         "remove the host port from the pod, or stop whatever uses the port",
+	},
+}
+
+// getUnschedulableMessage returns the message of the Kubernetes scheduler if the pod is pending because it cannot be scheduled, and an
+// empty string otherwise. The message is the same as the message of the FailedScheduling event of the pod.
+func getUnschedulableMessage(pod *v1.Pod) string {
+	if pod.Status.Phase != v1.PodPending {
+		return ""
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse && condition.Reason == v1.PodReasonUnschedulable {
+			return condition.Message
+		}
+	}
+	return ""
+}
+
+// explainSchedulingFailure returns a human-readable explanation of a message of the Kubernetes scheduler, with suggested fixes. The
+// message itself is always included, because the hints only cover common causes.
+func explainSchedulingFailure(message string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "pod cannot be scheduled: %s", message)
+	lower := strings.ToLower(message)
+	for _, hint := range schedulingHints {
+		if strings.Contains(lower, hint.fragment) {
+			fmt.Fprintf(&b, "\n  - %s; %s", hint.explanation, hint.fix)
+		}
+	}
+	return b.String()
+}
+
+// reportSchedulingFailure logs an explanation if the pod of an app cannot be scheduled. Each distinct message of the scheduler is
+// logged once, so that the app does not seem to be waiting silently while the pod stays pending.
+func reportSchedulingFailure(a *app, pod *v1.Pod) {
+	message := getUnschedulableMessage(pod)
+	if message == "" || message == a.lastSchedulingMessage {
+		return
+	}
+	a.lastSchedulingMessage = message
+	a.newLogEntry().Warn(explainSchedulingFailure(message))
+}
@@ -0,0 +1,71 @@
+package up
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTestUnschedulablePod(message string) *v1.Pod {
+	return &v1.Pod{
+		Status: v1.PodStatus{
+			Phase: v1.PodPending,
+			Conditions: []v1.PodCondition{
+				{
+					Type:    v1.PodScheduled,
+					Status:  v1.ConditionFalse,
+					Reason:  v1.PodReasonUnschedulable,
+					Message: message,
+				},
+			},
+		},
+	}
+}
+
+func TestGetUnschedulableMessage_Success(t *testing.T) {
+	pod := newTestUnschedulablePod("0/1 nodes are available: 1 Insufficient cpu.")
+	if msg := getUnschedulableMessage(pod); msg != "0/1 nodes are available: 1 Insufficient cpu." {
+		t.Error(msg)
+	}
+}
+
+func TestGetUnschedulableMessage_Running(t *testing.T) {
+	pod := newTestUnschedulablePod("0/1 nodes are available: 1 Insufficient cpu.")
+	pod.Status.Phase = v1.PodRunning
+	if msg := getUnschedulableMessage(pod); msg != "" {
+		t.Error(msg)
+	}
+}
+
+func TestExplainSchedulingFailure_Multiple(t *testing.T) {
+	s := explainSchedulingFailure("0/3 nodes are available: 1 Insufficient memory, 2 node(s) had taint {dedicated: infra}, " +
+		"that the pod didn't tolerate.")
+	if !strings.Contains(s, "enough unreserved memory") || !strings.Contains(s, "taints that the pod does not tolerate") ||
+		strings.Contains(s, "CPU") {
+		t.Error(s)
+	}
+}
+
+func TestExplainSchedulingFailure_UnboundPVC(t *testing.T) {
+	s := explainSchedulingFailure("pod has unbound immediate PersistentVolumeClaims (repeated 2 times)")
+	if !strings.Contains(s, "StorageClass") {
+		t.Error(s)
+	}
+}
+
+func TestExplainSchedulingFailure_Unknown(t *testing.T) {
+	s := explainSchedulingFailure("something else")
+	if s != "pod cannot be scheduled: something else" {
+		t.Error(s)
+	}
+}
+
+func TestReportSchedulingFailure_OncePerMessage(t *testing.T) {
+	a := newTestApp("a")
+	pod := newTestUnschedulablePod("0/1 nodes are available: 1 Too many pods.")
+	reportSchedulingFailure(a, pod)
+	if a.lastSchedulingMessage != "0/1 nodes are available: 1 Too many pods." {
+		t.Error(a.lastSchedulingMessage)
+	}
+}
@@ -0,0 +1,160 @@
+package up
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/vault"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// secretResolver resolves the value of a key of a secret stored outside of the docker compose file.
+type secretResolver interface {
+	Resolve(path, key string) (string, error)
+}
+
+type secretResolverCache struct {
+	v    secretResolver
+	once *sync.Once
+	err  error
+}
+
+// newSecretResolver creates the secret resolver used to resolve vault:// references. It is a variable to improve testability.
+var newSecretResolver = func() (secretResolver, error) {
+	client, err := vault.NewClientFromEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (u *upRunner) getSecretResolverOnce() (secretResolver, error) {
+	u.secretResolver.once.Do(func() {
+		v, err := newSecretResolver()
+		u.secretResolver.v = v
+		u.secretResolver.err = exitcode.Wrap(err, exitcode.Config)
+	})
+	return u.secretResolver.v, u.secretResolver.err
+}
+
+// getSecretName returns the name of the k8s secret that holds the resolved secret environment variables of an app.
+func getSecretName(podName string) string {
+	return podName + "-env"
+}
+
+// getEnvVars converts the environment of a docker compose service to environment variables of a container. Values that are references to
+// secrets in Vault (of the form vault://path#key) are resolved, returned as the data of a k8s secret and referenced by the environment
+// variables, so that plaintext credentials are neither needed in docker compose files nor visible in pod specs. The keys of sidecars are
+// prefixed with the name of their container, because the containers of a pod group share the Secret of the pod (see config.PodGroup).
+func (u *upRunner) getEnvVars(app *app, podName string) ([]v1.EnvVar, map[string][]byte, error) {
+	environment := app.composeService.DockerComposeService.Environment
+	if len(environment) == 0 {
+		return nil, nil, nil
+	}
+	envVars := make([]v1.EnvVar, 0, len(environment))
+	var secretData map[string][]byte
+	for name, value := range environment {
+		path, key, ok := vault.ParseReference(value)
+		if !ok {
+			envVars = append(envVars, v1.EnvVar{
+				Name:  name,
+				Value: value,
+			})
+			continue
+		}
+		secretKey := name
+		if app.composeService.IsSidecar() {
+			secretKey = k8smeta.GetShortName(app.composeService) + "." + name
+		}
+		if errs := validation.IsConfigMapKey(secretKey); len(errs) > 0 {
+			return nil, nil, exitcode.Wrap(fmt.Errorf("environment variable %s of docker compose service %s cannot reference a secret: %s",
+				name, app.name(), strings.Join(errs, "; ")), exitcode.Config)
+		}
+		resolver, err := u.getSecretResolverOnce()
+		if err != nil {
+			return nil, nil, err
+		}
+		resolvedValue, err := resolver.Resolve(path, key)
+		if err != nil {
+			return nil, nil, exitcode.Wrap(err, exitcode.Config)
+		}
+		if secretData == nil {
+			secretData = map[string][]byte{}
+		}
+		secretData[secretKey] = []byte(resolvedValue)
+		envVars = append(envVars, v1.EnvVar{
+			Name: name,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: getSecretName(podName),
+					},
+					Key: secretKey,
+				},
+			},
+		})
+	}
+	// Sort environment variables so that the pod spec (and therefore its hash) does not depend on map iteration order.
+	sort.Slice(envVars, func(i, j int) bool {
+		return envVars[i].Name < envVars[j].Name
+	})
+	return envVars, secretData, nil
+}
+
+// createOrUpdateSecret creates the k8s secret that holds the resolved secret environment variables of an app, or updates it if it
+// already exists so that it reflects the current values in Vault.
+func (u *upRunner) createOrUpdateSecret(app *app, podName string, data map[string][]byte) error {
+	return u.applySecret(app, getSecretName(podName), v1.SecretTypeOpaque, data)
+}
+
+// newSecret returns a k8s secret of the pod of an app.
+func newSecret(cfg *config.Config, app *app, name string, secretType v1.SecretType, data map[string][]byte) *v1.Secret {
+	secret := &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		Data: data,
+		Type: secretType,
+	}
+	k8smeta.InitObjectMeta(cfg, &secret.ObjectMeta, app.composeService)
+	secret.ObjectMeta.Name = name
+	return secret
+}
+
+// applySecret creates a k8s secret of the pod of an app, or updates its data if it already exists.
+func (u *upRunner) applySecret(app *app, name string, secretType v1.SecretType, data map[string][]byte) error {
+	secret := newSecret(u.cfg, app, name, secretType, data)
+	err := u.admitObject(secret)
+	if err != nil {
+		return err
+	}
+	client := u.secretClient(secret.ObjectMeta.Namespace)
+	_, err = client.Create(secret)
+	if k8sError.IsAlreadyExists(err) {
+		var existing *v1.Secret
+		existing, err = client.Get(secret.ObjectMeta.Name, metav1.GetOptions{})
+		if err == nil {
+			err = k8smeta.ValidateOwnership(u.cfg, "Secret", &existing.ObjectMeta)
+			if err != nil {
+				return exitcode.Wrap(err, exitcode.Config)
+			}
+			existing.Data = data
+			_, err = client.Update(existing)
+		}
+		if err == nil {
+			app.newLogEntry().Debugf("updated k8s secret %s", secret.ObjectMeta.Name)
+		}
+	} else if err == nil {
+		app.newLogEntry().Debugf("created k8s secret %s", secret.ObjectMeta.Name)
+	}
+	return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+}
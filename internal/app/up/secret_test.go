@@ -0,0 +1,121 @@
+package up
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	v1 "k8s.io/api/core/v1"
+)
+
+type mockSecretResolver map[string]string
+
+func (m mockSecretResolver) Resolve(path, key string) (string, error) {
+	value, ok := m[path+"#"+key]
+	if !ok {
+		return "", fmt.Errorf("secret %s does not have key %s", path, key)
+	}
+	return value, nil
+}
+
+func newTestUpRunnerWithSecretResolver(resolver secretResolver, err error) *upRunner {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.secretResolver.once = &sync.Once{}
+	u.secretResolver.once.Do(func() {})
+	u.secretResolver.v = resolver
+	u.secretResolver.err = err
+	return u
+}
+
+func TestGetEnvVars_Success(t *testing.T) {
+	u := newTestUpRunnerWithSecretResolver(mockSecretResolver{
+		"secret/myapp#password": "hunter2",
+	}, nil)
+	a := u.apps["a"]
+	a.composeService.DockerComposeService.Environment = map[string]string{
+		"USER":     "admin",
+		"PASSWORD": "vault://secret/myapp#password",
+	}
+	envVars, secretData, err := u.getEnvVars(a, "a")
+	if err != nil {
+		t.Error(err)
+	}
+	expectedEnvVars := []v1.EnvVar{
+		{
+			Name: "PASSWORD",
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: "a-env",
+					},
+					Key: "PASSWORD",
+				},
+			},
+		},
+		{
+			Name:  "USER",
+			Value: "admin",
+		},
+	}
+	if !reflect.DeepEqual(envVars, expectedEnvVars) {
+		t.Error(envVars)
+	}
+	if !reflect.DeepEqual(secretData, map[string][]byte{"PASSWORD": []byte("hunter2")}) {
+		t.Error(secretData)
+	}
+}
+
+func TestGetEnvVars_NoSecrets(t *testing.T) {
+	u := newTestUpRunnerWithSecretResolver(nil, fmt.Errorf("resolver should not be used"))
+	a := u.apps["a"]
+	a.composeService.DockerComposeService.Environment = map[string]string{
+		"USER": "admin",
+	}
+	envVars, secretData, err := u.getEnvVars(a, "a")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(envVars) != 1 || secretData != nil {
+		t.Error(envVars, secretData)
+	}
+}
+
+func TestGetEnvVars_ResolverError(t *testing.T) {
+	u := newTestUpRunnerWithSecretResolver(nil, exitcode.Wrap(fmt.Errorf("VAULT_ADDR not set"), exitcode.Config))
+	a := u.apps["a"]
+	a.composeService.DockerComposeService.Environment = map[string]string{
+		"PASSWORD": "vault://secret/myapp#password",
+	}
+	_, _, err := u.getEnvVars(a, "a")
+	if exitcode.FromError(err) != exitcode.Config {
+		t.Error(err)
+	}
+}
+
+func TestGetEnvVars_MissingKey(t *testing.T) {
+	u := newTestUpRunnerWithSecretResolver(mockSecretResolver{}, nil)
+	a := u.apps["a"]
+	a.composeService.DockerComposeService.Environment = map[string]string{
+		"PASSWORD": "vault://secret/myapp#password",
+	}
+	_, _, err := u.getEnvVars(a, "a")
+	if exitcode.FromError(err) != exitcode.Config {
+		t.Error(err)
+	}
+}
+
+func TestGetEnvVars_InvalidSecretKey(t *testing.T) {
+	u := newTestUpRunnerWithSecretResolver(mockSecretResolver{
+		"secret/myapp#password": "hunter2",
+	}, nil)
+	a := u.apps["a"]
+	a.composeService.DockerComposeService.Environment = map[string]string{
+		"MY PASSWORD": "vault://secret/myapp#password",
+	}
+	_, _, err := u.getEnvVars(a, "a")
+	if exitcode.FromError(err) != exitcode.Config {
+		t.Error(err)
+	}
+}
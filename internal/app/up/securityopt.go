@@ -0,0 +1,57 @@
+package up
+
+import (
+	"path"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+// appArmorContainerAnnotationKeyPrefix is the prefix of the key of the annotation that sets the AppArmor profile of a container.
+const appArmorContainerAnnotationKeyPrefix = "container.apparmor.security.beta.kubernetes.io/"
+
+// dockerDefaultAppArmorProfile is the name of the AppArmor profile that docker applies to containers by default.
+const dockerDefaultAppArmorProfile = "docker-default"
+
+// getSeccompProfile maps the seccomp option of a docker compose service to the seccomp profile of a container. Docker reads profiles from
+// the client's file system, whereas Kubernetes reads profiles from the seccomp profile root of the kubelet, so the profile is assumed to
+// be installed there under the same file name.
+func getSeccompProfile(seccomp string) string {
+	switch seccomp {
+	case "":
+		return ""
+	case dockerComposeConfig.SecurityOptUnconfined:
+		return seccomp
+	}
+	return "localhost/" + path.Base(seccomp)
+}
+
+// getAppArmorProfile maps the AppArmor option of a docker compose service to the AppArmor profile of a container. Profiles other than
+// docker's default profile must be loaded on the nodes.
+func getAppArmorProfile(appArmor string) string {
+	switch appArmor {
+	case "":
+		return ""
+	case dockerComposeConfig.SecurityOptUnconfined:
+		return appArmor
+	case dockerDefaultAppArmorProfile:
+		return "runtime/default"
+	}
+	return "localhost/" + appArmor
+}
+
+// setPodSecurityOptions sets the seccomp and AppArmor profiles of the containers of a pod, according to security_opt. This version of the
+// Kubernetes API sets these profiles with annotations.
+func setPodSecurityOptions(a *app, pod *v1.Pod) {
+	// The containers of the pod are in the order of a.podApps.
+	for i, a2 := range a.podApps {
+		containerName := pod.Spec.Containers[i].Name
+		securityOptions := &a2.composeService.DockerComposeService.SecurityOptions
+		if seccompProfile := getSeccompProfile(securityOptions.Seccomp); seccompProfile != "" {
+			pod.ObjectMeta.Annotations[v1.SeccompContainerAnnotationKeyPrefix+containerName] = seccompProfile
+		}
+		if appArmorProfile := getAppArmorProfile(securityOptions.AppArmor); appArmorProfile != "" {
+			pod.ObjectMeta.Annotations[appArmorContainerAnnotationKeyPrefix+containerName] = appArmorProfile
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package up
+
+import (
+	"testing"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetSeccompProfile(t *testing.T) {
+	testCases := map[string]string{
+		"":                      "",
+		"unconfined":            "unconfined",
+		"/profiles/strict.json": "localhost/strict.json",
+	}
+	for seccomp, expected := range testCases {
+		if actual := getSeccompProfile(seccomp); actual != expected {
+			t.Error(seccomp, actual)
+		}
+	}
+}
+
+func TestGetAppArmorProfile(t *testing.T) {
+	testCases := map[string]string{
+		"":               "",
+		"unconfined":     "unconfined",
+		"docker-default": "runtime/default",
+		"custom":         "localhost/custom",
+	}
+	for appArmor, expected := range testCases {
+		if actual := getAppArmorProfile(appArmor); actual != expected {
+			t.Error(appArmor, actual)
+		}
+	}
+}
+
+func TestSetPodSecurityOptions(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	a := u.apps["a"]
+	a.composeService.DockerComposeService.SecurityOptions = dockerComposeConfig.SecurityOptions{
+		Seccomp:  "strict.json",
+		AppArmor: "custom",
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Name: "a"},
+			},
+		},
+	}
+	setPodSecurityOptions(a, pod)
+	if pod.ObjectMeta.Annotations["container.seccomp.security.alpha.kubernetes.io/a"] != "localhost/strict.json" ||
+		pod.ObjectMeta.Annotations["container.apparmor.security.beta.kubernetes.io/a"] != "localhost/custom" {
+		t.Error(pod.ObjectMeta.Annotations)
+	}
+}
+
+func TestCreateSecurityContext_NoNewPrivileges(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	a := u.apps["a"]
+	a.composeService.DockerComposeService.SecurityOptions.NoNewPrivileges = true
+	securityContext := u.createSecurityContext(a)
+	if securityContext == nil || securityContext.AllowPrivilegeEscalation == nil || *securityContext.AllowPrivilegeEscalation {
+		t.Error(securityContext)
+	}
+	a.composeService.DockerComposeService.Privileged = true
+	securityContext = u.createSecurityContext(a)
+	if securityContext == nil || securityContext.AllowPrivilegeEscalation != nil {
+		t.Error(securityContext)
+	}
+}
@@ -0,0 +1,56 @@
+package up
+
+import (
+	"fmt"
+	"path"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// setPodServiceAccount applies the service account configuration of an app to its pod. Projected service account tokens in the same
+// directory share a volume, because a volume is mounted at a directory. Tokens are not mounted with a subPath, so that Kubernetes can
+// rotate them.
+func setPodServiceAccount(app *app, pod *v1.Pod) {
+	sa := app.composeService.ServiceAccount
+	if sa == nil {
+		return
+	}
+	pod.Spec.AutomountServiceAccountToken = &sa.AutomountToken
+	pod.Spec.ServiceAccountName = sa.Name
+	// The directories are kept in order of first use, so that the pod spec (and its hash) is deterministic.
+	var dirs []string
+	volumes := map[string]*v1.Volume{}
+	for _, token := range sa.Tokens {
+		dir := path.Dir(token.Path)
+		volume := volumes[dir]
+		if volume == nil {
+			volume = &v1.Volume{
+				Name: fmt.Sprintf("token%d", len(dirs)+1),
+				VolumeSource: v1.VolumeSource{
+					Projected: &v1.ProjectedVolumeSource{},
+				},
+			}
+			dirs = append(dirs, dir)
+			volumes[dir] = volume
+			pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, v1.VolumeMount{
+				MountPath: dir,
+				Name:      volume.Name,
+				ReadOnly:  true,
+			})
+		}
+		projection := &v1.ServiceAccountTokenProjection{
+			Audience: token.Audience,
+			Path:     path.Base(token.Path),
+		}
+		if token.Expiration > 0 {
+			expirationSeconds := int64(token.Expiration.Seconds())
+			projection.ExpirationSeconds = &expirationSeconds
+		}
+		volume.VolumeSource.Projected.Sources = append(volume.VolumeSource.Projected.Sources, v1.VolumeProjection{
+			ServiceAccountToken: projection,
+		})
+	}
+	for _, dir := range dirs {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, *volumes[dir])
+	}
+}
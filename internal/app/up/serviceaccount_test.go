@@ -0,0 +1,114 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTestPodWithContainer() *v1.Pod {
+	return &v1.Pod{
+		Spec: v1.PodSpec{
+			AutomountServiceAccountToken: new(bool),
+			Containers: []v1.Container{
+				{},
+			},
+		},
+	}
+}
+
+func TestSetPodServiceAccount_None(t *testing.T) {
+	app := newTestApp("a")
+	pod := newTestPodWithContainer()
+	setPodServiceAccount(app, pod)
+	if *pod.Spec.AutomountServiceAccountToken || len(pod.Spec.Volumes) != 0 {
+		t.Fail()
+	}
+}
+
+func TestSetPodServiceAccount_Tokens(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.ServiceAccount = &config.ServiceAccount{
+		AutomountToken: true,
+		Name:           "reader",
+		Tokens: []config.ServiceAccountToken{
+			{
+				Audience:   "vault",
+				Expiration: time.Hour,
+				Path:       "/var/run/secrets/tokens/vault",
+			},
+			{
+				Path: "/run/token",
+			},
+			{
+				Audience: "sts.amazonaws.com",
+				Path:     "/var/run/secrets/tokens/aws",
+			},
+		},
+	}
+	pod := newTestPodWithContainer()
+	setPodServiceAccount(app, pod)
+	if !*pod.Spec.AutomountServiceAccountToken || pod.Spec.ServiceAccountName != "reader" {
+		t.Fail()
+	}
+	expirationSeconds := int64(3600)
+	expectedVolumes := []v1.Volume{
+		{
+			Name: "token1",
+			VolumeSource: v1.VolumeSource{
+				Projected: &v1.ProjectedVolumeSource{
+					Sources: []v1.VolumeProjection{
+						{
+							ServiceAccountToken: &v1.ServiceAccountTokenProjection{
+								Audience:          "vault",
+								ExpirationSeconds: &expirationSeconds,
+								Path:              "vault",
+							},
+						},
+						{
+							ServiceAccountToken: &v1.ServiceAccountTokenProjection{
+								Audience: "sts.amazonaws.com",
+								Path:     "aws",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "token2",
+			VolumeSource: v1.VolumeSource{
+				Projected: &v1.ProjectedVolumeSource{
+					Sources: []v1.VolumeProjection{
+						{
+							ServiceAccountToken: &v1.ServiceAccountTokenProjection{
+								Path: "token",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(pod.Spec.Volumes, expectedVolumes) {
+		t.Error(pod.Spec.Volumes)
+	}
+	expectedVolumeMounts := []v1.VolumeMount{
+		{
+			MountPath: "/var/run/secrets/tokens",
+			Name:      "token1",
+			ReadOnly:  true,
+		},
+		{
+			MountPath: "/run",
+			Name:      "token2",
+			ReadOnly:  true,
+		},
+	}
+	if !reflect.DeepEqual(pod.Spec.Containers[0].VolumeMounts, expectedVolumeMounts) {
+		t.Error(pod.Spec.Containers[0].VolumeMounts)
+	}
+}
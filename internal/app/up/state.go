@@ -0,0 +1,277 @@
+package up
+
+import (
+	"sort"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/state"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// prunedObjectResources are the resources of the recorded objects that are accessed with the dynamic client, keyed by kind (see
+// pruneObject).
+var prunedObjectResources = map[string]schema.GroupVersionResource{
+	"Certificate":         k8smeta.CertificatesGVR,
+	"ExternalSecret":      k8smeta.ExternalSecretsGVR,
+	"HTTPRoute":           k8smeta.HTTPRoutesGVR,
+	"Ingress":             k8smeta.IngressesGVR,
+	"SecretProviderClass": k8smeta.SecretProviderClassesGVR,
+	"TCPRoute":            k8smeta.TCPRoutesGVR,
+}
+
+// deployedState is the state of the environment, which is updated as objects are created and saved once when up finishes. A mutex is
+// needed because pods are created concurrently.
+type deployedState struct {
+	mutex *sync.Mutex
+	store state.Store
+	v     *state.State
+	// The objects that were recorded by this up (see recordObject).
+	recordedObjects map[state.Object]bool
+}
+
+// loadState loads the state of the environment.
+func (u *upRunner) loadState() error {
+	u.state.mutex = &sync.Mutex{}
+	u.state.recordedObjects = map[state.Object]bool{}
+	u.state.store = state.NewStore(u.cfg, u.k8sClientset.CoreV1().ConfigMaps(u.cfg.Namespace))
+	var err error
+	u.state.v, err = u.state.store.Load()
+	if err != nil {
+		return exitcode.Wrap(errors.Wrap(err, "error while loading the state of the environment"), exitcode.ClusterConnectivity)
+	}
+	return nil
+}
+
+// saveState saves the state. It is called once when up finishes, also if up fails, so that down and the next up know which pods were
+// created.
+func (u *upRunner) saveState() error {
+	u.state.mutex.Lock()
+	defer u.state.mutex.Unlock()
+	err := u.state.store.Save(u.state.v)
+	if err != nil {
+		return exitcode.Wrap(errors.Wrap(err, "error while saving the state of the environment"), exitcode.ClusterConnectivity)
+	}
+	return nil
+}
+
+// recordPod records that the pod of an app was created or found.
+func (u *upRunner) recordPod(app *app, pod *v1.Pod, hasSecret bool) error {
+	service := &state.Service{
+		GracePeriodSeconds: k8smeta.GetGracePeriodSeconds(app.composeService),
+		Hash:               app.hash,
+		Image:              app.imageInfo.podImage,
+		ImageID:            app.imageInfo.sourceImageID,
+		Namespace:          app.composeService.PodService().Namespace,
+		PodName:            pod.ObjectMeta.Name,
+	}
+	dependsOn, _ := app.getPodDependsOn()
+	for name := range dependsOn {
+		service.DependsOn = append(service.DependsOn, name)
+	}
+	sort.Strings(service.DependsOn)
+	if hasSecret {
+		service.SecretName = getSecretName(pod.ObjectMeta.Name)
+	}
+	if app.hasService() {
+		service.ServiceName = k8smeta.GetK8sName(app.composeService, u.cfg)
+	}
+	u.state.mutex.Lock()
+	defer u.state.mutex.Unlock()
+	u.state.v.Services[app.name()] = service
+	return nil
+}
+
+// recordObject records that an object other than a pod, Kubernetes service or Secret was created or updated, such as the objects of
+// external docker compose secrets and Ingresses.
+func (u *upRunner) recordObject(kind, name string) error {
+	u.state.mutex.Lock()
+	defer u.state.mutex.Unlock()
+	u.state.v.AddObject(kind, name)
+	u.state.recordedObjects[state.Object{
+		Kind: kind,
+		Name: name,
+	}] = true
+	return nil
+}
+
+// getSecretObjectsOfConfig returns the ExternalSecrets and SecretProviderClasses of all external docker compose secrets of the
+// configuration. Unlike other recorded objects, they are only created for the apps that are started and did not change, so they cannot
+// be pruned just because this up did not record them.
+func (u *upRunner) getSecretObjectsOfConfig() map[state.Object]bool {
+	objects := map[state.Object]bool{}
+	for _, secret := range u.cfg.Secrets {
+		if secret.ExternalSecret == nil && secret.CSI == nil {
+			continue
+		}
+		_, obj := newSecretObject(u.cfg, secret)
+		objects[state.Object{
+			Kind: obj.GetKind(),
+			Name: obj.GetName(),
+		}] = true
+	}
+	return objects
+}
+
+// pruneState deletes the objects that the last up deployed but that are no longer part of the docker compose files, and removes them from
+// the state: the pods, Kubernetes services and Secrets of docker compose services that were removed, and the recorded objects that this
+// up did not deploy. PersistentVolumeClaims are never pruned, because that would delete the data of volumes (see down --volumes).
+func (u *upRunner) pruneState() error {
+	var names []string
+	for name := range u.state.v.Services {
+		if u.cfg.Services[name] == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		err := u.pruneService(u.state.v.Services[name])
+		if err != nil {
+			return err
+		}
+		delete(u.state.v.Services, name)
+	}
+	secretObjects := u.getSecretObjectsOfConfig()
+	for _, obj := range append([]state.Object{}, u.state.v.Objects...) {
+		if obj.Kind == "PersistentVolumeClaim" || u.state.recordedObjects[obj] || secretObjects[obj] {
+			continue
+		}
+		err := u.pruneObject(obj)
+		if err != nil {
+			return err
+		}
+		u.state.v.RemoveObject(obj.Kind, obj.Name)
+	}
+	return nil
+}
+
+// pruneService deletes the pod, Kubernetes service and Secrets of a docker compose service that was removed from the docker compose files.
+// The pod is not waited for.
+func (u *upRunner) pruneService(s *state.Service) error {
+	podClient := u.podClient(s.Namespace)
+	err := u.deleteOwnedObject("Pod", s.PodName, func() (*metav1.ObjectMeta, error) {
+		pod, err := podClient.Get(s.PodName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &pod.ObjectMeta, nil
+	}, func() error {
+		return podClient.Delete(s.PodName, &metav1.DeleteOptions{
+			GracePeriodSeconds: s.GracePeriodSeconds,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if s.ServiceName != "" {
+		serviceClient := u.serviceClient(s.Namespace)
+		err = u.deleteOwnedObject("Service", s.ServiceName, func() (*metav1.ObjectMeta, error) {
+			service, err := serviceClient.Get(s.ServiceName, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return &service.ObjectMeta, nil
+		}, func() error {
+			return serviceClient.Delete(s.ServiceName, &metav1.DeleteOptions{})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	secretNames := []string{
+		getImagePullSecretName(s.PodName),
+	}
+	if s.SecretName != "" {
+		secretNames = append(secretNames, s.SecretName)
+	}
+	secretClient := u.secretClient(s.Namespace)
+	for _, name := range secretNames {
+		name := name
+		err = u.deleteOwnedObject("Secret", name, func() (*metav1.ObjectMeta, error) {
+			secret, err := secretClient.Get(name, metav1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return &secret.ObjectMeta, nil
+		}, func() error {
+			return secretClient.Delete(name, &metav1.DeleteOptions{})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneObject deletes a recorded object that this up did not deploy from each namespace of the docker compose services. Objects of
+// resources that are not installed in the cluster are ignored.
+func (u *upRunner) pruneObject(obj state.Object) error {
+	gvr, ok := prunedObjectResources[obj.Kind]
+	for _, namespace := range u.cfg.Namespaces() {
+		var get func() (*metav1.ObjectMeta, error)
+		var del func() error
+		switch {
+		case obj.Kind == "Service":
+			client := u.serviceClient(namespace)
+			get = func() (*metav1.ObjectMeta, error) {
+				service, err := client.Get(obj.Name, metav1.GetOptions{})
+				if err != nil {
+					return nil, err
+				}
+				return &service.ObjectMeta, nil
+			}
+			del = func() error {
+				return client.Delete(obj.Name, &metav1.DeleteOptions{})
+			}
+		case ok:
+			client := u.k8sDynamicClient.Resource(gvr).Namespace(u.getNamespace(namespace))
+			get = func() (*metav1.ObjectMeta, error) {
+				existing, err := client.Get(obj.Name, metav1.GetOptions{})
+				if err != nil {
+					return nil, err
+				}
+				return &metav1.ObjectMeta{
+					Labels: existing.GetLabels(),
+					Name:   existing.GetName(),
+				}, nil
+			}
+			del = func() error {
+				return client.Delete(obj.Name, &metav1.DeleteOptions{})
+			}
+		default:
+			return nil
+		}
+		err := u.deleteOwnedObject(obj.Kind, obj.Name, get, del)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteOwnedObject deletes an object that is no longer part of the docker compose files, if it exists and is owned by the environment.
+func (u *upRunner) deleteOwnedObject(kind, name string, get func() (*metav1.ObjectMeta, error), del func() error) error {
+	objectMeta, err := get()
+	if k8sError.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	if k8smeta.ValidateOwnership(u.cfg, kind, objectMeta) != nil {
+		log.Debugf("not pruning %s %s, because it is not owned by the environment", kind, name)
+		return nil
+	}
+	err = del()
+	if err != nil && !k8sError.IsNotFound(err) {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	log.Infof("pruned %s %s, because it is no longer part of the docker compose files", kind, name)
+	return nil
+}
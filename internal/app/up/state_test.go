@@ -0,0 +1,90 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/state"
+	"github.com/kube-compose/kube-compose/pkg/docker/dockertest"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+func TestRun_FakeClusterSavesStateOnce(t *testing.T) {
+	clientset := newFakeClientset()
+	withFakeCluster(clientset, func(_ *dockertest.Daemon) {
+		err := Run(newFakeClusterTestConfig(), newFakeClusterTestOptions())
+		if err != nil {
+			t.Error(err)
+		}
+		writes := 0
+		for _, action := range clientset.Actions() {
+			if action.GetResource().Resource != "configmaps" || (action.GetVerb() != "create" && action.GetVerb() != "update") {
+				continue
+			}
+			if obj, ok := action.(k8sTesting.CreateAction); ok && obj.GetObject().(metav1.Object).GetName() == "project-kube-compose-state-test" {
+				writes++
+			}
+		}
+		if writes != 1 {
+			t.Error(writes)
+		}
+	})
+}
+
+func TestRun_FakeClusterPrunesRemovedService(t *testing.T) {
+	clientset := newFakeClientset()
+	withFakeCluster(clientset, func(_ *dockertest.Daemon) {
+		err := Run(newFakeClusterTestConfig(), newFakeClusterTestOptions())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		// Remove the docker compose service web.
+		cfg := newFakeClusterTestConfig()
+		delete(cfg.Services, "web")
+		err = Run(cfg, newFakeClusterTestOptions())
+		if err != nil {
+			t.Error(err)
+		}
+		_, err = clientset.CoreV1().Pods("default").Get("project-web-test", metav1.GetOptions{})
+		if !k8sError.IsNotFound(err) {
+			t.Error(err)
+		}
+		_, err = clientset.CoreV1().Pods("default").Get("project-db-test", metav1.GetOptions{})
+		if err != nil {
+			t.Error(err)
+		}
+		s, err := state.NewStore(cfg, clientset.CoreV1().ConfigMaps("default")).Load()
+		if err != nil || len(s.Services) != 1 || s.Services["db"] == nil {
+			t.Error(s, err)
+		}
+	})
+}
+
+func TestRun_FakeClusterPrunesRemovedExternalService(t *testing.T) {
+	clientset := newFakeClientset()
+	withFakeCluster(clientset, func(_ *dockertest.Daemon) {
+		cfg := newFakeClusterTestConfig()
+		cfg.ExternalServices = map[string]string{
+			"mysql": "mysql.example.com",
+		}
+		err := Run(cfg, newFakeClusterTestOptions())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_, err = clientset.CoreV1().Services("default").Get("mysql", metav1.GetOptions{})
+		if err != nil {
+			t.Error(err)
+		}
+		err = Run(newFakeClusterTestConfig(), newFakeClusterTestOptions())
+		if err != nil {
+			t.Error(err)
+		}
+		_, err = clientset.CoreV1().Services("default").Get("mysql", metav1.GetOptions{})
+		if !k8sError.IsNotFound(err) {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package up
+
+import (
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// topologySpreadWeight is the weight of the preferred pod anti-affinity term of a topology spread that is not required.
+const topologySpreadWeight = 100
+
+// setPodTopologySpread spreads the pod of an app over the topology of the cluster, relative to the pods of the other services in the
+// same group. Pod anti-affinity is used instead of topologySpreadConstraints, so that this works with older clusters. This has the same
+// effect as a maximum skew of 1, because each service has a single pod. The pod is labelled with its group, and the label selector of
+// the anti-affinity term is restricted to pods of the environment, so that environments that share a namespace do not repel each other.
+func setPodTopologySpread(cfg *config.Config, app *app, pod *v1.Pod) {
+	ts := app.composeService.TopologySpread
+	if ts == nil {
+		return
+	}
+	pod.ObjectMeta.Labels[k8smeta.SpreadGroupLabelName] = ts.Group
+	term := v1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: k8smeta.InitEnvironmentLabels(cfg, map[string]string{
+				k8smeta.SpreadGroupLabelName: ts.Group,
+			}),
+		},
+		TopologyKey: ts.TopologyKey,
+	}
+	antiAffinity := &v1.PodAntiAffinity{}
+	if ts.Required {
+		antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = []v1.PodAffinityTerm{term}
+	} else {
+		antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = []v1.WeightedPodAffinityTerm{
+			{
+				PodAffinityTerm: term,
+				Weight:          topologySpreadWeight,
+			},
+		}
+	}
+	pod.Spec.Affinity = &v1.Affinity{
+		PodAntiAffinity: antiAffinity,
+	}
+}
@@ -0,0 +1,75 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestSetPodTopologySpread_None(t *testing.T) {
+	cfg := newTestConfig()
+	app := &app{
+		composeService: cfg.Services["a"],
+	}
+	pod := &v1.Pod{}
+	setPodTopologySpread(cfg, app, pod)
+	if pod.Spec.Affinity != nil {
+		t.Error(pod.Spec.Affinity)
+	}
+}
+
+func TestSetPodTopologySpread_Preferred(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.EnvironmentID = "env1"
+	cfg.EnvironmentLabel = "env"
+	app := &app{
+		composeService: cfg.Services["a"],
+	}
+	app.composeService.TopologySpread = &config.TopologySpread{
+		Group:       "db",
+		TopologyKey: config.TopologyKeyZone,
+	}
+	pod := &v1.Pod{}
+	k8smeta.InitObjectMeta(cfg, &pod.ObjectMeta, app.composeService)
+	setPodTopologySpread(cfg, app, pod)
+	if pod.ObjectMeta.Labels[k8smeta.SpreadGroupLabelName] != "db" {
+		t.Error(pod.ObjectMeta.Labels)
+	}
+	antiAffinity := pod.Spec.Affinity.PodAntiAffinity
+	if len(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) != 0 ||
+		len(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) != 1 {
+		t.Error(antiAffinity)
+		return
+	}
+	term := antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm
+	expected := map[string]string{
+		"env":                        "env1",
+		k8smeta.SpreadGroupLabelName: "db",
+	}
+	if term.TopologyKey != config.TopologyKeyZone || !reflect.DeepEqual(term.LabelSelector.MatchLabels, expected) {
+		t.Error(term)
+	}
+}
+
+func TestSetPodTopologySpread_Required(t *testing.T) {
+	cfg := newTestConfig()
+	app := &app{
+		composeService: cfg.Services["a"],
+	}
+	app.composeService.TopologySpread = &config.TopologySpread{
+		Group:       "db",
+		Required:    true,
+		TopologyKey: config.TopologyKeyHostname,
+	}
+	pod := &v1.Pod{}
+	k8smeta.InitObjectMeta(cfg, &pod.ObjectMeta, app.composeService)
+	setPodTopologySpread(cfg, app, pod)
+	antiAffinity := pod.Spec.Affinity.PodAntiAffinity
+	if len(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) != 1 ||
+		antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey != config.TopologyKeyHostname {
+		t.Error(antiAffinity)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/distribution/digestset"
@@ -15,7 +17,10 @@ import (
 	"github.com/kube-compose/kube-compose/internal/app/config"
 	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
 	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/metrics"
 	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	"github.com/kube-compose/kube-compose/internal/pkg/timing"
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
 	goDigest "github.com/opencontainers/go-digest"
@@ -23,21 +28,16 @@ import (
 	v1 "k8s.io/api/core/v1"
 	k8sError "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	k8swatch "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
 )
 
-// This doesn't deserve the name palette.
-var appColorPalette = []int{
-	37, // gray
-	36, // blue
-	35, // magenta
-	33, // yellow
-	32, // green
-}
-
 type appImageInfo struct {
 	err                error
 	imageHealthcheck   *dockerComposeConfig.Healthcheck
@@ -46,10 +46,16 @@ type appImageInfo struct {
 	podImagePullPolicy v1.PullPolicy
 	sourceImageID      string
 	cmd                []string
+	// The TCP ports exposed by the image (EXPOSE), in ascending order.
+	exposedPorts []int32
+	// The GIDs of the groups of group_add, in order.
+	supplementalGroups []int64
 	user               *docker.Userinfo
 }
 
 type appVolume struct {
+	// The app whose container mounts the volume. This differs from the app of the pod for sidecars (see config.PodGroup).
+	app              *app
 	resolvedHostPath string
 	readOnly         bool
 	containerPath    string
@@ -64,10 +70,40 @@ type appVolumesInitImage struct {
 }
 
 type app struct {
-	composeService                       *config.Service
-	serviceClusterIP                     string
-	imageInfo                            appImageInfo
-	maxObservedPodStatus                 podStatus
+	composeService       *config.Service
+	serviceClusterIP     string
+	imageInfo            appImageInfo
+	maxObservedPodStatus podStatus
+	// True if and only if the pod has been observed to be ready. This is tracked separately from maxObservedPodStatus, because a pod
+	// that completes without ever becoming ready does not satisfy the condition service_healthy.
+	observedReady   bool
+	podCreationTime time.Time
+	// The UID of the pod that was created or found during this run. Events of other pods (e.g. pods that were redeployed) are ignored.
+	podUID types.UID
+	// The IP of the node that runs the app's pod, once the pod is scheduled.
+	nodeIP string
+	// True if and only if debug ports of the app's pod are (being) forwarded.
+	debugPortsForwarded bool
+	// True if and only if the app failed (or one of its dependencies failed) and the app's failure policy is to skip its dependents.
+	failed bool
+	// True if and only if the pod was redeployed during this run.
+	redeployed bool
+	// The hash of the effective configuration of the app's pod (see computeServiceHash).
+	hash string
+	// The status of the DNS probe of the app's Kubernetes service (see Options.WaitForDNS).
+	dnsProbeStatus dnsProbeStatus
+	// The last message of the Kubernetes scheduler that explained why the pod cannot be scheduled, so that it is logged only once.
+	lastSchedulingMessage string
+	// True if and only if a TCP readiness probe is synthesized when the app has no healthcheck (see Options.SynthesizeProbes).
+	synthesizeReadinessProbe bool
+	// True if and only if the healthcheck of the app is also converted to a liveness probe (see Options.LivenessProbes).
+	livenessProbe bool
+	// The host ports of the container ports of the pod, if published ports are published on nodes (see Options.HostPorts).
+	hostPorts map[hostPortKey]hostPort
+	// The app whose pod runs the container of this app. This is the app itself, unless the app is a sidecar (see config.PodGroup).
+	podApp *app
+	// The apps whose containers run in the pod of this app, starting with this app, or nil if this app is a sidecar.
+	podApps                              []*app
 	containersForWhichWeAreStreamingLogs map[string]bool
 	color                                int
 	reporterRow                          *reporter.Row
@@ -103,30 +139,60 @@ type localImagesCache struct {
 }
 
 type upRunner struct {
+	// The hashes of the objects that checkPolicies admitted (see computeObjectHash). It is only written before anything is applied, so
+	// it can be read concurrently.
+	admittedObjectHashes  map[string]bool
 	apps                  map[string]*app
 	appsThatNeedToBeReady map[*app]bool
 	appsToBeStarted       map[*app]bool
 	cfg                   *config.Config
 	completedChannels     []chan interface{}
 	dockerClient          *dockerClient.Client
-	k8sClientset          *kubernetes.Clientset
+	dnsProbeResults       chan *dnsProbeResult
+	dnsProbesRunning      int
+	events                eventEmitter
+	k8sClientset          kubernetes.Interface
+	k8sCoreRESTClient     rest.Interface
+	k8sDynamicClient      dynamic.Interface
 	k8sServiceClient      clientV1.ServiceInterface
 	k8sPodClient          clientV1.PodInterface
+	k8sSecretClient       clientV1.SecretInterface
 	hostAliases           hostAliases
+	imageBuilds           imageCache
+	imageInspects         imageCache
+	imagePulls            imageCache
+	imagePullManager      *docker.PullManager
+	imagePullProgress     imagePullProgress
+	revision              deployedRevision
+	layerPushes           layerPushes
 	localImagesCache      localImagesCache
 	maxServiceNameLength  int
+	metrics               *upMetrics
 	opts                  *Options
-	totalVolumeCount      int
+	volumeClaims          volumeClaims
+	secretObjects         secretObjects
+	secretResolver        secretResolverCache
+	state                 deployedState
+	// True if and only if the cluster serves TCPRoutes of the Gateway API (see checkExposeMode).
+	tcpRoutesSupported bool
+	totalVolumeCount   int
+	// Samples the resource usage of containers if resources are recommended (see Options.RecommendResources), or nil.
+	resourceRecorder *resourceRecorder
+	// The optional capabilities that the cluster lacks (see detectClusterCapabilities).
+	capabilities clusterCapabilities
 }
 
 func (u *upRunner) initKubernetesClientset() error {
-	k8sClientset, err := kubernetes.NewForConfig(u.cfg.KubeConfig)
+	clients, err := k8smeta.NewClients(u.cfg.KubeConfig)
 	if err != nil {
-		return err
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
 	}
-	u.k8sClientset = k8sClientset
+	u.k8sClientset = clients.Clientset
 	u.k8sServiceClient = u.k8sClientset.CoreV1().Services(u.cfg.Namespace)
 	u.k8sPodClient = u.k8sClientset.CoreV1().Pods(u.cfg.Namespace)
+	u.k8sSecretClient = u.k8sClientset.CoreV1().Secrets(u.cfg.Namespace)
+	u.k8sCoreRESTClient = u.k8sClientset.CoreV1().RESTClient()
+	u.k8sDynamicClient = clients.Dynamic
 	return nil
 }
 
@@ -138,13 +204,12 @@ func (u *upRunner) initAppsToBeStarted() {
 			continue
 		}
 		a.reporterRow = u.opts.Reporter.AddRow(a.name())
-		u.appsToBeStarted[a] = true
-		a.color = appColorPalette[colorIndex]
-		if colorIndex < len(appColorPalette) {
-			colorIndex++
-		} else {
-			colorIndex = 0
+		// The containers of sidecars are started by creating the pods of their pod groups.
+		if !a.composeService.IsSidecar() {
+			u.appsToBeStarted[a] = true
 		}
+		a.color = util.ServiceColor(colorIndex)
+		colorIndex++
 		if len(a.name()) > u.maxServiceNameLength {
 			u.maxServiceNameLength = len(a.name())
 		}
@@ -157,40 +222,50 @@ func (u *upRunner) initVolumeInfoWarnOnce(s string) {
 	}
 }
 
+// initVolumeInfo determines the bind mounted volumes of the pods of the apps to be started. The volumes of the containers of a pod group
+// are volumes of the pod of the group.
 func (u *upRunner) initVolumeInfo() {
 	for a := range u.appsToBeStarted {
-		for _, serviceVolume := range a.composeService.DockerComposeService.Volumes {
-			appVolume := initVolumeInfoGetAppVolume(a, serviceVolume)
-			if appVolume == nil {
-				continue
-			}
-			u.totalVolumeCount++
-			u.initVolumeInfoWarnOnce("bind mounted volumes are not synced between containers and the host (see " +
-				"https://github.com/kube-compose/kube-compose#limitations)")
-			flag := false
-			if u.cfg.ClusterImageStorage.Docker == nil && u.cfg.ClusterImageStorage.DockerRegistry == nil {
-				u.initVolumeInfoWarnOnce("disabling bind mounted volumes: cluster_image_storage is missing (see " +
-					"https://github.com/kube-compose/kube-compose#volumes)")
-				flag = true
-			}
-			if u.cfg.VolumeInitBaseImage == nil {
-				u.initVolumeInfoWarnOnce("disabling bind mounted volumes: volumes_init_base_image is missing (see " +
-					"https://github.com/kube-compose/kube-compose#volumes)")
-				flag = true
-			}
-			if flag {
-				return
+		for _, a2 := range a.podApps {
+			for _, serviceVolume := range a2.composeService.DockerComposeService.Volumes {
+				appVolume := initVolumeInfoGetAppVolume(a2, serviceVolume)
+				if appVolume == nil {
+					continue
+				}
+				u.totalVolumeCount++
+				u.initVolumeInfoWarnOnce("bind mounted volumes are not synced between containers and the host (see " +
+					"https://github.com/kube-compose/kube-compose#limitations)")
+				flag := false
+				if u.cfg.ClusterImageStorage.Docker == nil && u.cfg.ClusterImageStorage.DockerRegistry == nil {
+					u.initVolumeInfoWarnOnce("disabling bind mounted volumes: cluster_image_storage is missing (see " +
+						"https://github.com/kube-compose/kube-compose#volumes)")
+					flag = true
+				}
+				if u.cfg.VolumeInitBaseImage == nil {
+					u.initVolumeInfoWarnOnce("disabling bind mounted volumes: volumes_init_base_image is missing (see " +
+						"https://github.com/kube-compose/kube-compose#volumes)")
+					flag = true
+				}
+				if flag {
+					return
+				}
+				// TODO https://github.com/kube-compose/kube-compose/issues/171 overlapping bind mounted volumes do not work..
+				// For now we assume that there is no overlap...
+				a.volumes = append(a.volumes, appVolume)
 			}
-			// TODO https://github.com/kube-compose/kube-compose/issues/171 overlapping bind mounted volumes do not work..
-			// For now we assume that there is no overlap...
-			a.volumes = append(a.volumes, appVolume)
 		}
 	}
 }
 
 func initVolumeInfoGetAppVolume(a *app, serviceVolume dockerComposeConfig.ServiceVolume) *appVolume {
-	r := &appVolume{}
+	r := &appVolume{
+		app: a,
+	}
 	if serviceVolume.Short != nil {
+		if serviceVolume.Short.NamedVolume {
+			// Named volumes are mounted from PersistentVolumeClaims (see setPodPersistentVolumes).
+			return nil
+		}
 		r.containerPath = serviceVolume.Short.ContainerPath
 		if serviceVolume.Short.HasMode {
 			switch serviceVolume.Short.Mode {
@@ -203,6 +278,11 @@ func initVolumeInfoGetAppVolume(a *app, serviceVolume dockerComposeConfig.Servic
 				return nil
 			}
 		}
+		if a.composeService.DockerComposeService.VolumeDriver != "" {
+			// The host path is the name of a volume of the volume driver, which cannot be simulated.
+			log.Warnf("service %s has a volume_driver, ignoring volume %s\n", a.name(), serviceVolume.Short.ContainerPath)
+			return nil
+		}
 		if serviceVolume.Short.HasHostPath {
 			var err error
 			r.resolvedHostPath, err = resolveBindVolumeHostPath(serviceVolume.Short.HostPath)
@@ -230,19 +310,29 @@ func initVolumeInfoGetAppVolume(a *app, serviceVolume dockerComposeConfig.Servic
 	return r
 }
 
-func (u *upRunner) getAppVolumeInitImage(a *app) error {
-	var bindMountHostFiles []string
+// getAppVolumeHostPaths returns the distinct host paths of the volumes of the pod of an app, in order. Containers of a pod group that bind
+// mount the same host path share a volume, so that they see each other's changes.
+func getAppVolumeHostPaths(a *app) []string {
+	var hostPaths []string
+	seen := map[string]bool{}
 	for _, volume := range a.volumes {
-		bindMountHostFiles = append(bindMountHostFiles, volume.resolvedHostPath)
+		if !seen[volume.resolvedHostPath] {
+			seen[volume.resolvedHostPath] = true
+			hostPaths = append(hostPaths, volume.resolvedHostPath)
+		}
 	}
-	r, err := buildVolumeInitImage(u.opts.Context, u.dockerClient, bindMountHostFiles, *u.cfg.VolumeInitBaseImage)
+	return hostPaths
+}
+
+func (u *upRunner) getAppVolumeInitImage(a *app) error {
+	r, err := buildVolumeInitImage(u.opts.Context, u.dockerClient, getAppVolumeHostPaths(a), *u.cfg.VolumeInitBaseImage)
 	if err != nil {
 		return err
 	}
 	a.volumeInitImage.sourceImageID = r.imageID
 	tag := u.cfg.EnvironmentID + "-volumeinit"
 	if u.cfg.ClusterImageStorage.Docker != nil {
-		imageRef := fmt.Sprintf("%s/%s/%s:%s", docker.DefaultDomain, docker.OfficialRepoName, a.composeService.NameEscaped, tag)
+		imageRef := fmt.Sprintf("%s/%s/%s:%s", docker.DefaultDomain, docker.OfficialRepoName, k8smeta.GetShortName(a.composeService), tag)
 		err = u.dockerClient.ImageTag(u.opts.Context, a.volumeInitImage.sourceImageID, imageRef)
 		if err != nil {
 			return err
@@ -250,7 +340,7 @@ func (u *upRunner) getAppVolumeInitImage(a *app) error {
 		a.volumeInitImage.podImage = imageRef
 		a.volumeInitImage.podImagePullPolicy = v1.PullNever
 	} else {
-		a.volumeInitImage.podImage, err = u.pushImage(a.volumeInitImage.sourceImageID, a.composeService.NameEscaped,
+		a.volumeInitImage.podImage, err = u.pushImage(a.volumeInitImage.sourceImageID, k8smeta.GetShortName(a.composeService),
 			u.cfg.EnvironmentID+"-volumeinit", "volume init image", a)
 		if err != nil {
 			return err
@@ -260,26 +350,62 @@ func (u *upRunner) getAppVolumeInitImage(a *app) error {
 	return nil
 }
 
-func (u *upRunner) pushImage(sourceImageID, name, tag, imageDescr string, a *app) (podImage string, err error) {
+// pushImage pushes a local image to the cluster's registry, unless its digest in the registry is cached (see Options.DigestCache).
+func (u *upRunner) pushImage(sourceImageID, name, tag, imageDescr string, a *app) (string, error) {
+	prefix := u.cfg.ClusterImageStorage.DockerRegistry.Prefix
+	if prefix == "" {
+		prefix = u.cfg.Namespace
+	}
+	imagePush := fmt.Sprintf("%s/%s/%s:%s", u.cfg.ClusterImageStorage.DockerRegistry.Host, prefix, name, tag)
+	digest, ok := "", false
+	if u.opts.DigestCache != nil {
+		digest, ok = u.opts.DigestCache.Get(sourceImageID, imagePush)
+	}
+	if ok {
+		a.newLogEntry().Debugf("not pushing %s, because its digest %s is cached", imagePush, digest)
+	} else {
+		var err error
+		digest, err = u.pushImageUncached(sourceImageID, imagePush, imageDescr, a)
+		if err != nil {
+			return "", err
+		}
+		if u.opts.DigestCache != nil {
+			err = u.opts.DigestCache.Put(sourceImageID, imagePush, digest)
+			if err != nil {
+				a.newLogEntry().Warnf("error while caching the digest of %s: %v", imagePush, err)
+			}
+		}
+	}
+	return fmt.Sprintf("docker-registry.default.svc:5000/%s/%s@%s", u.cfg.Namespace, name, digest), nil
+}
+
+func (u *upRunner) pushImageUncached(sourceImageID, imagePush, imageDescr string, a *app) (string, error) {
 	pt := a.reporterRow.AddProgressTask("pushing " + imageDescr)
 	defer pt.Done()
+	defer u.opts.Timing.Start(phasePushImage, a.name()).Finish()
 	a.reporterRow.AddStatus(reporter.StatusDockerPush)
 	defer a.reporterRow.RemoveStatus(reporter.StatusDockerPush)
-	imagePush := fmt.Sprintf("%s/%s/%s:%s", u.cfg.ClusterImageStorage.DockerRegistry.Host, u.cfg.Namespace, name, tag)
+	inspect, _, err := u.inspectImage(sourceImageID)
+	if err != nil {
+		return "", err
+	}
+	// The layers that the image shares with the images of other services are uploaded once (see layerPushes).
+	defer u.layerPushes.start(inspect.RootFS.Layers)()
 	err = u.dockerClient.ImageTag(u.opts.Context, sourceImageID, imagePush)
 	if err != nil {
-		return
+		return "", err
 	}
-	var digest string
-	registryAuth := docker.EncodeRegistryAuth("unused", u.cfg.KubeConfig.BearerToken)
-	digest, err = docker.PushImage(u.opts.Context, u.dockerClient, imagePush, registryAuth, func(push *docker.PullOrPush) {
+	registryAuth, err := u.getRegistryAuth(imagePush, docker.EncodeRegistryAuth("unused", u.cfg.KubeConfig.BearerToken))
+	if err != nil {
+		return "", err
+	}
+	digest, err := docker.PushImage(u.opts.Context, u.dockerClient, imagePush, registryAuth, func(push *docker.PullOrPush) {
 		pt.Update(push.Progress())
 	})
 	if err != nil {
-		return
+		return "", exitcode.Wrap(err, exitcode.ImageTransfer)
 	}
-	podImage = fmt.Sprintf("docker-registry.default.svc:5000/%s/%s@%s", u.cfg.Namespace, name, digest)
-	return
+	return digest, nil
 }
 
 func (u *upRunner) getAppVolumeInitImageOnce(a *app) error {
@@ -296,37 +422,70 @@ func (u *upRunner) initApps() {
 		app := &app{
 			composeService:                       composeService,
 			containersForWhichWeAreStreamingLogs: make(map[string]bool),
+			synthesizeReadinessProbe:             u.opts.SynthesizeProbes,
+			livenessProbe:                        u.opts.LivenessProbes,
 		}
 		app.imageInfo.once = &sync.Once{}
 		app.volumeInitImage.once = &sync.Once{}
 		u.apps[app.name()] = app
 	}
+	for _, a := range u.apps {
+		a.podApp = u.apps[a.composeService.PodService().Name()]
+		if a.podApp != a {
+			continue
+		}
+		for _, podService := range a.composeService.PodServices() {
+			a.podApps = append(a.podApps, u.apps[podService.Name()])
+		}
+	}
 }
 
-func (u *upRunner) getAppImageInfo(app *app) error {
+// getAppSourceImage returns the source image of an app and its parsed reference, and ensures the source image is present locally. The
+// source image is built if the docker compose service has a build section, and is otherwise pulled if it is not present locally.
+func (u *upRunner) getAppSourceImage(app *app) (string, dockerRef.Reference, error) {
+	if app.composeService.DockerComposeService.Build != nil {
+		sourceImage, sourceImageID, err := u.buildImageOnce(app)
+		if err != nil {
+			return "", nil, err
+		}
+		app.imageInfo.sourceImageID = sourceImageID
+		sourceImageRef, err := dockerRef.ParseNormalizedNamed(sourceImage)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "error while parsing image %#v", sourceImage)
+		}
+		return sourceImage, sourceImageRef, nil
+	}
 	sourceImage := app.composeService.DockerComposeService.Image
 	if sourceImage == "" {
-		return fmt.Errorf("docker compose service %s has no image or its image is the empty string, and building images is not supported",
-			app.name())
+		return "", nil, fmt.Errorf("docker compose service %s has neither an image nor a build section", app.name())
 	}
 	localImageIDSet, err := u.getLocalImageIDSet()
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 	// Use the same interpretation of images as docker-compose (use ParseAnyReferenceWithSet)
 	sourceImageRef, err := dockerRef.ParseAnyReferenceWithSet(sourceImage, localImageIDSet)
 	if err != nil {
-		return errors.Wrapf(err, "error while parsing image %#v", sourceImage)
+		return "", nil, errors.Wrapf(err, "error while parsing image %#v", sourceImage)
 	}
 	err = u.getAppImageInfoEnsureSourceImageID(sourceImage, sourceImageRef, app, localImageIDSet)
+	if err != nil {
+		return "", nil, err
+	}
+	return sourceImage, sourceImageRef, nil
+}
+
+func (u *upRunner) getAppImageInfo(app *app) error {
+	sourceImage, sourceImageRef, err := u.getAppSourceImage(app)
 	if err != nil {
 		return err
 	}
-	inspect, inspectRaw, err := u.dockerClient.ImageInspectWithRaw(u.opts.Context, app.imageInfo.sourceImageID)
+	inspect, inspectRaw, err := u.inspectImage(app.imageInfo.sourceImageID)
 	if err != nil {
 		return err
 	}
 	app.imageInfo.cmd = inspect.Config.Cmd
+	app.imageInfo.exposedPorts = getExposedTCPPorts(inspect.Config)
 	err = u.getAppImageEnsureCorrectPodImage(app, sourceImageRef, sourceImage)
 	if err != nil {
 		return err
@@ -337,16 +496,19 @@ func (u *upRunner) getAppImageInfo(app *app) error {
 	}
 	app.imageInfo.imageHealthcheck = imageHealthcheck
 	if u.opts.RunAsUser {
-		err = u.getAppImageInfoUser(app, &inspect, sourceImage)
+		err = u.getAppImageInfoUser(app, inspect, sourceImage)
+		if err != nil {
+			return err
+		}
 	}
-	return err
+	return u.getAppImageInfoSupplementalGroups(app, sourceImage)
 }
 
 func (u *upRunner) getAppImageEnsureCorrectPodImage(a *app, sourceImageRef dockerRef.Reference, sourceImage string) error {
 	tag := u.cfg.EnvironmentID + "-main"
 	switch {
 	case u.cfg.ClusterImageStorage.Docker != nil:
-		imageRef := fmt.Sprintf("%s/%s/%s:%s", docker.DefaultDomain, docker.OfficialRepoName, a.composeService.NameEscaped, tag)
+		imageRef := fmt.Sprintf("%s/%s/%s:%s", docker.DefaultDomain, docker.OfficialRepoName, k8smeta.GetShortName(a.composeService), tag)
 		err := u.dockerClient.ImageTag(u.opts.Context, a.imageInfo.sourceImageID, imageRef)
 		if err != nil {
 			return err
@@ -355,7 +517,7 @@ func (u *upRunner) getAppImageEnsureCorrectPodImage(a *app, sourceImageRef docke
 		a.imageInfo.podImagePullPolicy = v1.PullNever
 	case u.cfg.ClusterImageStorage.DockerRegistry != nil:
 		var err error
-		a.imageInfo.podImage, err = u.pushImage(a.imageInfo.sourceImageID, a.composeService.NameEscaped, tag, "image", a)
+		a.imageInfo.podImage, err = u.pushImage(a.imageInfo.sourceImageID, k8smeta.GetShortName(a.composeService), tag, "image", a)
 		if err != nil {
 			return err
 		}
@@ -379,9 +541,9 @@ func (u *upRunner) getAppImageInfoEnsureSourceImageID(sourceImage string, source
 	a.imageInfo.sourceImageID = resolveLocalImageID(sourceImageRef, localImageIDSet, u.localImagesCache.images)
 	if a.imageInfo.sourceImageID == "" {
 		if !sourceImageIsNamed {
-			return fmt.Errorf("could not find image %#v locally, and building images is not supported", sourceImage)
+			return fmt.Errorf("could not find image %#v locally, and the docker compose service has no build section", sourceImage)
 		}
-		digest, err := u.getAppImageInfoPullImage(sourceImageRef, a)
+		digest, err := u.pullImageOnce(sourceImageRef.String(), a)
 		if err != nil {
 			return err
 		}
@@ -399,14 +561,42 @@ func (u *upRunner) getAppImageInfoEnsureSourceImageID(sourceImage string, source
 	return nil
 }
 
-func (u *upRunner) getAppImageInfoPullImage(sourceImageRef dockerRef.Reference, a *app) (string, error) {
+// getRegistryAuth returns the encoded credentials of the docker CLI configuration for the registry of an image, or fallback if there are
+// none.
+func (u *upRunner) getRegistryAuth(image, fallback string) (string, error) {
+	if u.opts.DockerConfig == nil {
+		return fallback, nil
+	}
+	auth, err := u.opts.DockerConfig.GetRegistryAuth(image)
+	if err != nil {
+		return "", exitcode.Wrap(err, exitcode.ImageTransfer)
+	}
+	if auth == "" {
+		return fallback, nil
+	}
+	return auth, nil
+}
+
+func (u *upRunner) getAppImageInfoPullImage(sourceImage string, a *app) (string, error) {
 	pt := a.reporterRow.AddProgressTask("pulling image")
 	defer pt.Done()
 	a.reporterRow.AddStatus(reporter.StatusDockerPull)
 	defer a.reporterRow.RemoveStatus(reporter.StatusDockerPull)
-	return docker.PullImage(u.opts.Context, u.dockerClient, sourceImageRef.String(), "123", func(pull *docker.PullOrPush) {
+	start := time.Now()
+	span := u.opts.Timing.Start(phasePullImage, a.name())
+	registryAuth, err := u.getRegistryAuth(sourceImage, "123")
+	if err != nil {
+		return "", err
+	}
+	digest, err := u.imagePullManager.Pull(u.opts.Context, sourceImage, registryAuth, func(pull *docker.PullOrPush) {
 		pt.Update(pull.Progress())
 	})
+	span.Finish()
+	if err != nil {
+		return "", exitcode.Wrap(err, exitcode.ImageTransfer)
+	}
+	u.metrics.imagePullSeconds.Observe(time.Since(start).Seconds(), a.name())
+	return digest, nil
 }
 
 func (u *upRunner) getAppImageInfoUser(a *app, inspect *dockerTypes.ImageInspect, sourceImage string) error {
@@ -438,7 +628,14 @@ func (u *upRunner) getAppImageInfoUser(a *app, inspect *dockerTypes.ImageInspect
 
 func (u *upRunner) getAppImageInfoOnce(app *app) error {
 	app.imageInfo.once.Do(func() {
+		span := u.opts.Timing.Start(phaseResolveImage, app.name())
 		app.imageInfo.err = u.getAppImageInfo(app)
+		span.Finish()
+		if app.imageInfo.err != nil {
+			u.emitEvent(app, EventFailed, app.imageInfo.err)
+		} else {
+			u.emitEvent(app, EventPulled, nil)
+		}
 	})
 	return app.imageInfo.err
 }
@@ -473,21 +670,29 @@ func (u *upRunner) waitForServiceClusterIPCountRemaining() int {
 	return remaining
 }
 
-func (u *upRunner) waitForServiceClusterIPList(expected int, listOptions *metav1.ListOptions) (string, error) {
-	serviceList, err := u.k8sServiceClient.List(*listOptions)
-	if err != nil {
-		return "", err
+// waitForServiceClusterIPList lists the Kubernetes services in each namespace of the docker compose services, and returns the resource
+// version of the list of each namespace.
+func (u *upRunner) waitForServiceClusterIPList(expected int, listOptions *metav1.ListOptions) (map[string]string, error) {
+	resourceVersions := map[string]string{}
+	var services []v1.Service
+	for _, namespace := range u.cfg.Namespaces() {
+		serviceList, err := u.serviceClient(namespace).List(*listOptions)
+		if err != nil {
+			return nil, exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		resourceVersions[namespace] = serviceList.ResourceVersion
+		services = append(services, serviceList.Items...)
 	}
-	if len(serviceList.Items) < expected {
-		return "", k8smeta.ErrorResourcesModifiedExternally()
+	if len(services) < expected {
+		return nil, k8smeta.ErrorResourcesModifiedExternally()
 	}
-	for i := 0; i < len(serviceList.Items); i++ {
-		_, err = u.waitForServiceClusterIPUpdate(&serviceList.Items[i])
+	for i := 0; i < len(services); i++ {
+		_, err := u.waitForServiceClusterIPUpdate(&services[i])
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 	}
-	return serviceList.ResourceVersion, nil
+	return resourceVersions, nil
 }
 
 func (u *upRunner) waitForServiceClusterIPWatchEvent(event *k8swatch.Event) error {
@@ -514,7 +719,7 @@ func (u *upRunner) waitForServiceClusterIPWatch(expected, remaining int, eventCh
 	for {
 		event, ok := <-eventChannel
 		if !ok {
-			return fmt.Errorf("channel unexpectedly closed")
+			return exitcode.Wrap(fmt.Errorf("channel unexpectedly closed"), exitcode.ClusterConnectivity)
 		}
 		err := u.waitForServiceClusterIPWatchEvent(&event)
 		if err != nil {
@@ -534,9 +739,9 @@ func (u *upRunner) waitForServiceClusterIPWatch(expected, remaining int, eventCh
 
 func (u *upRunner) waitForServiceClusterIP(expected int) error {
 	listOptions := metav1.ListOptions{
-		LabelSelector: u.cfg.EnvironmentLabel + "=" + u.cfg.EnvironmentID,
+		LabelSelector: k8smeta.GetLabelSelector(u.cfg),
 	}
-	resourceVersion, err := u.waitForServiceClusterIPList(expected, &listOptions)
+	resourceVersions, err := u.waitForServiceClusterIPList(expected, &listOptions)
 	if err != nil {
 		return err
 	}
@@ -545,9 +750,10 @@ func (u *upRunner) waitForServiceClusterIP(expected int) error {
 	if remaining == 0 {
 		return nil
 	}
-	listOptions.ResourceVersion = resourceVersion
-	listOptions.Watch = true
-	watch, err := u.k8sServiceClient.Watch(listOptions)
+	watch, err := u.watchNamespaces(listOptions, resourceVersions, func(namespace string, listOptions metav1.ListOptions) (
+		k8swatch.Interface, error) {
+		return u.serviceClient(namespace).Watch(listOptions)
+	})
 	if err != nil {
 		return err
 	}
@@ -555,65 +761,181 @@ func (u *upRunner) waitForServiceClusterIP(expected int) error {
 	return u.waitForServiceClusterIPWatch(expected, remaining, watch.ResultChan())
 }
 
+// newService returns the Kubernetes service of an app, which has the ports of the app's docker compose service and is configured by its
+// "x-kube-compose"."kubernetes_service".
+func newService(cfg *config.Config, app *app) *v1.Service {
+	servicePorts := make([]v1.ServicePort, len(app.composeService.DockerComposeService.Ports))
+	for i, port := range app.composeService.DockerComposeService.Ports {
+		servicePorts[i] = v1.ServicePort{
+			Name:       fmt.Sprintf("%s%d", port.Protocol, port.Internal),
+			Port:       port.Internal,
+			Protocol:   v1.Protocol(strings.ToUpper(port.Protocol)),
+			TargetPort: intstr.FromInt(int(port.Internal)),
+		}
+	}
+	service := &v1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		Spec: v1.ServiceSpec{
+			Ports:    servicePorts,
+			Selector: k8smeta.InitCommonLabels(cfg, app.composeService.PodService(), nil),
+			Type:     v1.ServiceType("ClusterIP"),
+		},
+	}
+	if ks := app.composeService.KubernetesService; ks != nil {
+		service.Spec.Type = ks.Type
+		service.Spec.ExternalTrafficPolicy = ks.ExternalTrafficPolicy
+		service.Spec.SessionAffinity = ks.SessionAffinity
+		if ks.SessionAffinityTimeout != nil {
+			timeoutSeconds := int32(*ks.SessionAffinityTimeout / time.Second)
+			service.Spec.SessionAffinityConfig = &v1.SessionAffinityConfig{
+				ClientIP: &v1.ClientIPConfig{
+					TimeoutSeconds: &timeoutSeconds,
+				},
+			}
+		}
+	}
+	k8smeta.InitObjectMeta(cfg, &service.ObjectMeta, app.composeService)
+	return service
+}
+
 func (u *upRunner) createServicesAndGetPodHostAliases() ([]v1.HostAlias, error) {
+	err := u.createExternalServices()
+	if err != nil {
+		return nil, err
+	}
+	if u.opts.DefaultDeny {
+		err = u.createDefaultDenyNetworkPolicies()
+		if err != nil {
+			return nil, err
+		}
+	}
 	expectedServiceCount := 0
 	for _, app := range u.apps {
 		if !app.hasService() {
 			continue
 		}
 		expectedServiceCount++
-		servicePorts := make([]v1.ServicePort, len(app.composeService.DockerComposeService.Ports))
-		for i, port := range app.composeService.DockerComposeService.Ports {
-			servicePorts[i] = v1.ServicePort{
-				Name:       fmt.Sprintf("%s%d", port.Protocol, port.Internal),
-				Port:       port.Internal,
-				Protocol:   v1.Protocol(strings.ToUpper(port.Protocol)),
-				TargetPort: intstr.FromInt(int(port.Internal)),
-			}
+		service := newService(u.cfg, app)
+		u.adjustServiceType(service)
+		err = u.createService(app, service, "k8s service")
+		if err != nil {
+			return nil, err
 		}
-		service := &v1.Service{
-			Spec: v1.ServiceSpec{
-				Ports:    servicePorts,
-				Selector: k8smeta.InitCommonLabels(u.cfg, app.composeService, nil),
-				Type:     v1.ServiceType("ClusterIP"),
-			},
+		if u.opts.ServiceAliases {
+			err = u.createService(app, newServiceAlias(u.cfg, app), "k8s service alias")
+			if err != nil {
+				return nil, err
+			}
 		}
-		k8smeta.InitObjectMeta(u.cfg, &service.ObjectMeta, app.composeService)
-		_, err := u.k8sServiceClient.Create(service)
-		switch {
-		case k8sError.IsAlreadyExists(err):
-			app.newLogEntry().Debugf("k8s service %s already exists", service.ObjectMeta.Name)
-		case err != nil:
+		err = u.exposeApp(app)
+		if err != nil {
 			return nil, err
-		default:
-			app.newLogEntry().Infof("created k8s service %s", service.ObjectMeta.Name)
+		}
+		if u.opts.DefaultDeny {
+			networkPolicy := newServiceNetworkPolicy(u.cfg, app)
+			err = u.createOrUpdateNetworkPolicy(networkPolicy)
+			if err != nil {
+				return nil, err
+			}
+			app.newLogEntry().Infof("created or updated NetworkPolicy %s", networkPolicy.Name)
 		}
 	}
 	if expectedServiceCount == 0 {
-		return nil, nil
+		return u.getRemoteServiceHostAliases(), nil
 	}
 	return u.getPodHostAliasesCore(expectedServiceCount)
 }
 
+// createService creates a Kubernetes service of an app, or validates the ownership of the Kubernetes service if it already exists. The
+// description of the service is used in log messages.
+func (u *upRunner) createService(app *app, service *v1.Service, description string) error {
+	err := u.setServiceIPFamilies(service)
+	if err != nil {
+		return err
+	}
+	err = u.admitObject(service)
+	if err != nil {
+		return err
+	}
+	namespace := u.getNamespace(service.ObjectMeta.Namespace)
+	_, err = k8smeta.CreateService(u.serviceClient(namespace), u.k8sCoreRESTClient, namespace, service)
+	switch {
+	case k8sError.IsAlreadyExists(err):
+		err = u.validateServiceOwnership(namespace, service.ObjectMeta.Name)
+		if err != nil {
+			return err
+		}
+		app.newLogEntry().Debugf("%s %s already exists", description, service.ObjectMeta.Name)
+	case err != nil:
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	default:
+		app.newLogEntry().Infof("created %s %s", description, service.ObjectMeta.Name)
+	}
+	return nil
+}
+
+// validateServiceOwnership returns an error if an existing Kubernetes service is not owned by the environment and project, because then
+// the host aliases of pods would route to pods of another project.
+func (u *upRunner) validateServiceOwnership(namespace, name string) error {
+	existing, err := u.serviceClient(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	return exitcode.Wrap(k8smeta.ValidateOwnership(u.cfg, "Service", &existing.ObjectMeta), exitcode.Config)
+}
+
 func (u *upRunner) getPodHostAliasesCore(expectedServiceCount int) ([]v1.HostAlias, error) {
 	err := u.waitForServiceClusterIP(expectedServiceCount)
 	if err != nil {
 		return nil, err
 	}
-	hostAliases := make([]v1.HostAlias, expectedServiceCount)
-	i := 0
-	for _, app := range u.apps {
+	return append(u.getServiceHostAliases(), u.getRemoteServiceHostAliases()...), nil
+}
+
+// getServiceHostAliases returns the host aliases of the apps with Kubernetes services, which resolve to the cluster IPs of the services.
+// The host aliases are sorted by hostname, so that the spec hash of pods does not depend on the iteration order of maps (see
+// computePodSpecHash).
+func (u *upRunner) getServiceHostAliases() []v1.HostAlias {
+	names := make([]string, 0, len(u.apps))
+	for name, app := range u.apps {
 		if app.hasService() {
-			hostAliases[i] = v1.HostAlias{
-				IP: app.serviceClusterIP,
-				Hostnames: []string{
-					app.name(),
-				},
-			}
-			i++
+			names = append(names, name)
 		}
 	}
-	return hostAliases, nil
+	sort.Strings(names)
+	hostAliases := make([]v1.HostAlias, len(names))
+	for i, name := range names {
+		hostAliases[i] = v1.HostAlias{
+			IP: u.apps[name].serviceClusterIP,
+			Hostnames: []string{
+				name,
+			},
+		}
+	}
+	return hostAliases
+}
+
+// getRemoteServiceHostAliases returns the host aliases of the docker compose services of other clusters, which resolve to the addresses
+// at which the services are reachable from outside their clusters (see config.TargetCluster).
+func (u *upRunner) getRemoteServiceHostAliases() []v1.HostAlias {
+	names := make([]string, 0, len(u.cfg.RemoteServices))
+	for name := range u.cfg.RemoteServices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var hostAliases []v1.HostAlias
+	for _, name := range names {
+		hostAliases = append(hostAliases, v1.HostAlias{
+			IP: u.cfg.RemoteServices[name],
+			Hostnames: []string{
+				name,
+			},
+		})
+	}
+	return hostAliases
 }
 
 func (u *upRunner) initLocalImages() error {
@@ -628,11 +950,10 @@ func (u *upRunner) initLocalImages() error {
 				_ = imageIDSet.Add(goDigest.Digest(imageSummarySlice[i].ID))
 			}
 		}
-		u.localImagesCache = localImagesCache{
-			imageIDSet: imageIDSet,
-			images:     imageSummarySlice,
-			err:        err,
-		}
+		// Only the results are assigned, because other goroutines read once concurrently.
+		u.localImagesCache.imageIDSet = imageIDSet
+		u.localImagesCache.images = imageSummarySlice
+		u.localImagesCache.err = err
 	})
 	return u.localImagesCache.err
 }
@@ -647,7 +968,9 @@ func (u *upRunner) getLocalImageIDSet() (*digestset.Set, error) {
 
 func (u *upRunner) createServicesAndGetPodHostAliasesOnce() ([]v1.HostAlias, error) {
 	u.hostAliases.once.Do(func() {
+		span := u.opts.Timing.Start(phaseCreateServices, "")
 		v, err := u.createServicesAndGetPodHostAliases()
+		span.Finish()
 		u.hostAliases.v = v
 		u.hostAliases.err = err
 	})
@@ -670,21 +993,78 @@ func getRestartPolicyforService(app *app) v1.RestartPolicy {
 }
 
 // GetReadinessProbe converts the image/docker-compose healthcheck to a readiness probe to implement depends_on condition: service_healthy
-// in docker compose files. Kubernetes does not appear to have disabled the healthcheck of docker images:
+// in docker compose files. If the docker compose healthcheck is disabled (disable: true or test: ["NONE"]) then no probe is returned,
+// even if the image has a healthcheck. Kubernetes does not appear to have disabled the healthcheck of docker images:
 // https://stackoverflow.com/questions/41475088/when-to-use-docker-healthcheck-vs-livenessprobe-readinessprobe
 // ... so we're not doubling up on healthchecks. We accept that this may lead to calls failing due to removal backend pods from load
 // balancers.
 func (a *app) GetReadinessProbe() *v1.Probe {
 	if !a.composeService.DockerComposeService.HealthcheckDisabled {
-		if a.composeService.DockerComposeService.Healthcheck != nil {
-			return createReadinessProbeFromDockerHealthcheck(a.composeService.DockerComposeService.Healthcheck)
-		} else if a.imageInfo.imageHealthcheck != nil {
-			return createReadinessProbeFromDockerHealthcheck(a.imageInfo.imageHealthcheck)
+		if healthcheck := a.getHealthcheck(); healthcheck != nil {
+			return createReadinessProbeFromDockerHealthcheck(healthcheck)
+		}
+		if a.synthesizeReadinessProbe {
+			return createTCPReadinessProbe(a.getProbePort())
 		}
 	}
 	return nil
 }
 
+// GetLivenessProbe converts the image/docker-compose healthcheck to a liveness probe if liveness probes are enabled (see
+// Options.LivenessProbes), so that the container is restarted (subject to its restart policy) once it becomes unhealthy. Synthesized
+// readiness probes have no liveness counterpart.
+func (a *app) GetLivenessProbe() *v1.Probe {
+	if a.livenessProbe && !a.composeService.DockerComposeService.HealthcheckDisabled {
+		return createLivenessProbeFromDockerHealthcheck(a.getHealthcheck())
+	}
+	return nil
+}
+
+// getHealthcheck returns the healthcheck of the docker compose service, or else the healthcheck of the image. Like docker, a docker
+// compose healthcheck without test inherits the test of the image's healthcheck, as well as any of interval, timeout, retries and
+// start_period that it does not set. If neither has a test then nil is returned.
+func (a *app) getHealthcheck() *dockerComposeConfig.Healthcheck {
+	healthcheck := a.composeService.DockerComposeService.Healthcheck
+	imageHealthcheck := a.imageInfo.imageHealthcheck
+	if healthcheck == nil || healthcheck.Test != nil {
+		if healthcheck == nil {
+			return imageHealthcheck
+		}
+		return healthcheck
+	}
+	if imageHealthcheck == nil {
+		return nil
+	}
+	merged := *imageHealthcheck
+	if healthcheck.Interval != 0 {
+		merged.Interval = healthcheck.Interval
+	}
+	if healthcheck.Timeout != 0 {
+		merged.Timeout = healthcheck.Timeout
+	}
+	if healthcheck.Retries != 0 {
+		merged.Retries = healthcheck.Retries
+	}
+	if healthcheck.StartPeriod != 0 {
+		merged.StartPeriod = healthcheck.StartPeriod
+	}
+	return &merged
+}
+
+// getProbePort returns the port of a synthesized readiness probe: the first published TCP port of the docker compose service, or else the
+// lowest TCP port exposed by the image. Zero is returned if there is no such port.
+func (a *app) getProbePort() int32 {
+	for _, port := range a.composeService.Ports {
+		if port.Protocol == "tcp" {
+			return port.Port
+		}
+	}
+	if len(a.imageInfo.exposedPorts) > 0 {
+		return a.imageInfo.exposedPorts[0]
+	}
+	return 0
+}
+
 func (a *app) GetArgsAndCommand(c *v1.Container) error {
 	// docker-compose does not ignore the entrypoint if it is an empty array. For example: if the entrypoint is empty but the command is not
 	// empty then the entrypoint becomes the command. But the Kubernetes client treats an empty entrypoint array as an unset entrypoint,
@@ -705,9 +1085,14 @@ func (a *app) GetArgsAndCommand(c *v1.Container) error {
 }
 
 func (u *upRunner) createSecurityContext(a *app) *v1.SecurityContext {
-	if u.opts.RunAsUser || a.composeService.DockerComposeService.Privileged {
+	// Kubernetes rejects privileged containers that do not allow privilege escalation, so no-new-privileges is ignored for privileged
+	// containers (see getLowLevelFieldDiagnostics).
+	noNewPrivileges := a.composeService.DockerComposeService.SecurityOptions.NoNewPrivileges &&
+		!a.composeService.DockerComposeService.Privileged
+	if u.opts.RunAsUser || a.composeService.DockerComposeService.Privileged || noNewPrivileges {
 		securityContext := &v1.SecurityContext{}
-		if u.opts.RunAsUser {
+		// The user of stubbed image information is unknown (see stubAppImageInfo).
+		if u.opts.RunAsUser && a.imageInfo.user != nil {
 			securityContext.RunAsUser = a.imageInfo.user.UID
 			if a.imageInfo.user.GID != nil {
 				securityContext.RunAsGroup = a.imageInfo.user.GID
@@ -716,6 +1101,9 @@ func (u *upRunner) createSecurityContext(a *app) *v1.SecurityContext {
 		if a.composeService.DockerComposeService.Privileged {
 			securityContext.Privileged = util.NewBool(true)
 		}
+		if noNewPrivileges {
+			securityContext.AllowPrivilegeEscalation = new(bool)
+		}
 		return securityContext
 	}
 	return nil
@@ -730,10 +1118,11 @@ func (u *upRunner) createPodVolumes(a *app, pod *v1.Pod) error {
 		return err
 	}
 	var volumes []v1.Volume
-	var volumeMounts []v1.VolumeMount
 	var initVolumeMounts []v1.VolumeMount
-	for i, volume := range a.volumes {
+	volumeNames := map[string]string{}
+	for i, hostPath := range getAppVolumeHostPaths(a) {
 		volumeName := fmt.Sprintf("vol%d", i+1)
+		volumeNames[hostPath] = volumeName
 		volumes = append(volumes, v1.Volume{
 			Name: volumeName,
 			VolumeSource: v1.VolumeSource{
@@ -744,97 +1133,197 @@ func (u *upRunner) createPodVolumes(a *app, pod *v1.Pod) error {
 			Name:      volumeName,
 			MountPath: fmt.Sprintf("/mnt/vol%d", i+1),
 		})
-		volumeMounts = append(volumeMounts, v1.VolumeMount{
-			ReadOnly:  volume.readOnly,
-			Name:      volumeName,
-			MountPath: volume.containerPath,
-			SubPath:   "root",
-		})
+	}
+	for _, volume := range a.volumes {
+		// The containers of the pod are in the order of a.podApps.
+		for i, a2 := range a.podApps {
+			if a2 == volume.app {
+				pod.Spec.Containers[i].VolumeMounts = append(pod.Spec.Containers[i].VolumeMounts, v1.VolumeMount{
+					ReadOnly:  volume.readOnly,
+					Name:      volumeNames[volume.resolvedHostPath],
+					MountPath: volume.containerPath,
+					SubPath:   "root",
+				})
+			}
+		}
 	}
 	initContainer := v1.Container{
-		Name:            a.composeService.NameEscaped + "-init",
+		Name:            util.TruncateName(a.composeService.NameEscaped+"-init", validation.DNS1123LabelMaxLength),
 		Image:           a.volumeInitImage.podImage,
 		ImagePullPolicy: a.volumeInitImage.podImagePullPolicy,
 		VolumeMounts:    initVolumeMounts,
 	}
 	pod.Spec.InitContainers = append(pod.Spec.InitContainers, initContainer)
-	pod.Spec.Containers[0].VolumeMounts = volumeMounts
 	pod.Spec.Volumes = volumes
 	return nil
 }
 
-func (u *upRunner) createPod(app *app) (*v1.Pod, error) {
-	err := u.getAppImageInfoOnce(app)
+// createContainer creates the container of an app in the pod with the given name. The data of the Secret with the container's secret
+// environment variables is also returned (see getEnvVars).
+func (u *upRunner) createContainer(a *app, podName string) (v1.Container, map[string][]byte, error) {
+	err := u.getAppImageInfoOnce(a)
 	if err != nil {
-		return nil, err
+		return v1.Container{}, nil, err
 	}
-	readinessProbe := app.GetReadinessProbe()
+	envVars, secretData, err := u.getEnvVars(a, podName)
+	if err != nil {
+		return v1.Container{}, nil, err
+	}
+	container := v1.Container{
+		Env:             envVars,
+		Image:           a.imageInfo.podImage,
+		ImagePullPolicy: a.imageInfo.podImagePullPolicy,
+		LivenessProbe:   a.GetLivenessProbe(),
+		Name:            k8smeta.GetShortName(a.composeService),
+		Ports:           getContainerPorts(a),
+		ReadinessProbe:  a.GetReadinessProbe(),
+		Resources:       getContainerResources(a.composeService, &u.cfg.DefaultResources),
+		SecurityContext: u.createSecurityContext(a),
+		WorkingDir:      a.composeService.DockerComposeService.WorkingDir,
+	}
+	err = a.GetArgsAndCommand(&container)
+	return container, secretData, err
+}
 
-	containerPorts := make([]v1.ContainerPort, len(app.composeService.Ports))
-	for i, port := range app.composeService.Ports {
-		containerPorts[i] = v1.ContainerPort{
-			ContainerPort: port.Port,
-			Protocol:      v1.Protocol(strings.ToUpper(port.Protocol)),
-		}
+// newPod returns the admitted pod of an app (see buildPod), after creating the Kubernetes services and the external secrets that the pod
+// depends on. The data of the Secret with the secret environment variables of the containers is also returned.
+func (u *upRunner) newPod(app *app) (*v1.Pod, map[string][]byte, error) {
+	pod, secretData, err := u.buildPod(app)
+	if err != nil {
+		return nil, nil, err
 	}
-	var envVars []v1.EnvVar
-	envVarCount := len(app.composeService.DockerComposeService.Environment)
-	if envVarCount > 0 {
-		envVars = make([]v1.EnvVar, envVarCount)
-		i := 0
-		for key, value := range app.composeService.DockerComposeService.Environment {
-			envVars[i] = v1.EnvVar{
-				Name:  key,
-				Value: value,
-			}
-			i++
-		}
+	pod.Spec.HostAliases, err = u.createServicesAndGetPodHostAliasesOnce()
+	if err != nil {
+		return nil, nil, err
 	}
-	hostAliases, err := u.createServicesAndGetPodHostAliasesOnce()
+	err = u.createPodSecretObjects(app)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	err = u.admitObject(pod)
+	if err != nil {
+		return nil, nil, err
 	}
+	return pod, secretData, nil
+}
 
+// buildPod returns the pod of an app without host aliases, with a container for each app of its pod group (see config.PodGroup). Pod-level
+// settings are those of the app, except that the pod uses the host's IPC namespace if any of its containers does. No objects are created,
+// so that the pod can be evaluated against policies before anything is applied (see checkPolicies). The data of the Secret with the
+// secret environment variables of the containers is also returned.
+func (u *upRunner) buildPod(app *app) (*v1.Pod, map[string][]byte, error) {
+	podName := k8smeta.GetK8sName(app.composeService, u.cfg)
+	containers := make([]v1.Container, len(app.podApps))
+	var secretData map[string][]byte
+	hostIPC := false
+	var nodeSelector map[string]string
+	for i, app2 := range app.podApps {
+		var containerSecretData map[string][]byte
+		var err error
+		containers[i], containerSecretData, err = u.createContainer(app2, podName)
+		if err != nil {
+			return nil, nil, err
+		}
+		for key, value := range containerSecretData {
+			if secretData == nil {
+				secretData = map[string][]byte{}
+			}
+			secretData[key] = value
+		}
+		hostIPC = hostIPC || app2.composeService.DockerComposeService.IPC == dockerComposeConfig.IPCModeHost
+		for key, value := range app2.composeService.NodeSelector {
+			if nodeSelector == nil {
+				nodeSelector = map[string]string{}
+			}
+			nodeSelector[key] = value
+		}
+	}
 	pod := &v1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
 		Spec: v1.PodSpec{
 			// new(bool) allocates a bool, sets it to false, and returns a pointer to it.
-			AutomountServiceAccountToken: new(bool),
-			Containers: []v1.Container{
-				{
-					Env:             envVars,
-					Image:           app.imageInfo.podImage,
-					ImagePullPolicy: app.imageInfo.podImagePullPolicy,
-					Name:            app.composeService.NameEscaped,
-					Ports:           containerPorts,
-					ReadinessProbe:  readinessProbe,
-					SecurityContext: u.createSecurityContext(app),
-					WorkingDir:      app.composeService.DockerComposeService.WorkingDir,
-				},
-			},
-			HostAliases:   hostAliases,
-			RestartPolicy: getRestartPolicyforService(app),
+			AutomountServiceAccountToken:  new(bool),
+			Containers:                    containers,
+			HostIPC:                       hostIPC,
+			InitContainers:                u.createDependencyWaitInitContainers(app),
+			NodeSelector:                  nodeSelector,
+			PriorityClassName:             app.composeService.PriorityClassName,
+			RestartPolicy:                 getRestartPolicyforService(app),
+			TerminationGracePeriodSeconds: k8smeta.GetGracePeriodSeconds(app.composeService),
 		},
 	}
-	err = app.GetArgsAndCommand(&pod.Spec.Containers[0])
+	k8smeta.InitObjectMeta(u.cfg, &pod.ObjectMeta, app.composeService)
+
+	err := u.createPodVolumes(app, pod)
+	if err != nil {
+		return nil, nil, err
+	}
+	setPodPersistentVolumes(u.cfg, app, pod)
+	setPodPresetVolumes(app, pod)
+	err = u.setPodSecretVolumes(app, pod)
+	if err != nil {
+		return nil, nil, err
+	}
+	setPodServiceAccount(app, pod)
+	setPodTopologySpread(u.cfg, app, pod)
+	setPodMesh(u.opts.Mesh, pod)
+	setPodDNSSearches(u.opts, pod)
+	setPodSecurityOptions(app, pod)
+	setPodSupplementalGroups(app, pod)
+	if app.composeService.RuntimeClassName != "" {
+		pod.Spec.RuntimeClassName = &app.composeService.RuntimeClassName
+	}
+	return pod, secretData, nil
+}
+
+// createPod creates the pod of an app (see newPod).
+func (u *upRunner) createPod(app *app) (*v1.Pod, error) {
+	pod, secretData, err := u.newPod(app)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.createPersistentVolumeClaims(app)
+	if err != nil {
+		return nil, err
+	}
+	if len(secretData) > 0 {
+		err = u.createOrUpdateSecret(app, pod.ObjectMeta.Name, secretData)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = u.createImagePullSecret(app, pod)
 	if err != nil {
 		return nil, err
 	}
-	k8smeta.InitObjectMeta(u.cfg, &pod.ObjectMeta, app.composeService)
 
-	err = u.createPodVolumes(app, pod)
+	configHash, err := u.computePodConfigHash(app, secretData)
 	if err != nil {
 		return nil, err
 	}
+	pod.ObjectMeta.Annotations[k8smeta.ConfigHashAnnotationName] = configHash
 
-	podServer, err := u.k8sPodClient.Create(pod)
-	if k8sError.IsAlreadyExists(err) {
-		app.newLogEntry().Debugf("pod %s already exists", pod.ObjectMeta.Name)
-	} else if err != nil {
+	span := u.opts.Timing.Start(phaseCreatePod, app.name())
+	pod, err = u.createOrRecreatePod(app, pod)
+	span.Finish()
+	if err != nil {
 		return nil, err
 	}
-	app.newLogEntry().Debugf("created pod %s", pod.ObjectMeta.Name)
-	u.appsThatNeedToBeReady[app] = true
-	return podServer, nil
+	u.emitPodEvent(app, EventApplied)
+	for _, app2 := range app.podApps[1:] {
+		app2.podCreationTime = app.podCreationTime
+		app2.podUID = app.podUID
+		app2.redeployed = app.redeployed
+		err = u.recordPod(app2, pod, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pod, u.recordPod(app, pod, len(secretData) > 0)
 }
 
 func isPodReady(pod *v1.Pod) bool {
@@ -846,28 +1335,30 @@ func isPodReady(pod *v1.Pod) bool {
 	return false
 }
 
-func parsePodStatus(pod *v1.Pod) (podStatus, error) {
+// parsePodStatus returns the status of a pod. The readiness of the pod includes the readiness of the sidecar proxy of the service mesh
+// (if any), because traffic to the pod goes through the sidecar proxy. A terminated sidecar proxy is not reported as a failure.
+func parsePodStatus(pod *v1.Pod, mesh Mesh) (podStatus, error) {
 	if isPodReady(pod) {
 		return podStatusReady, nil
 	}
 	runningCount := 0
 	for _, containerStatus := range pod.Status.ContainerStatuses {
 		t := containerStatus.State.Terminated
-		if t != nil {
+		if t != nil && !isMeshProxyContainer(mesh, containerStatus.Name) {
 			return parsePodStatusTerminatedContainer(pod.ObjectMeta.Name, containerStatus.Name, t)
 		}
 		if w := containerStatus.State.Waiting; w != nil && w.Reason == "ErrImagePull" {
-			return podStatusOther, fmt.Errorf("container %s of pod %s could not pull image: %s",
+			return podStatusOther, exitcode.Wrap(fmt.Errorf("container %s of pod %s could not pull image: %s",
 				containerStatus.Name,
 				pod.ObjectMeta.Name,
 				w.Message,
-			)
+			), exitcode.ImageTransfer)
 		}
 		if containerStatus.State.Running != nil {
 			runningCount++
 		}
 	}
-	if runningCount == len(pod.Status.ContainerStatuses) {
+	if runningCount > 0 && runningCount == len(pod.Status.ContainerStatuses) {
 		return podStatusStarted, nil
 	}
 	return podStatusOther, nil
@@ -875,14 +1366,14 @@ func parsePodStatus(pod *v1.Pod) (podStatus, error) {
 
 func parsePodStatusTerminatedContainer(podName, containerName string, t *v1.ContainerStateTerminated) (podStatus, error) {
 	if t.Reason != "Completed" {
-		return podStatusOther, fmt.Errorf("container %s of pod %s terminated abnormally (code=%d,signal=%d,reason=%s): %s",
+		return podStatusOther, exitcode.Wrap(fmt.Errorf("container %s of pod %s terminated abnormally (code=%d,signal=%d,reason=%s): %s",
 			containerName,
 			podName,
 			t.ExitCode,
 			t.Signal,
 			t.Reason,
 			t.Message,
-		)
+		), exitcode.ContainerFailure)
 	}
 	return podStatusCompleted, nil
 }
@@ -890,7 +1381,7 @@ func parsePodStatusTerminatedContainer(podName, containerName string, t *v1.Cont
 func (u *upRunner) updateAppMaxObservedPodStatus(pod *v1.Pod) error {
 
 	app := u.findAppFromObjectMeta(&pod.ObjectMeta)
-	if app == nil {
+	if app == nil || pod.UID != app.podUID {
 		return nil
 	}
 	// For each container of the pod:
@@ -898,22 +1389,51 @@ func (u *upRunner) updateAppMaxObservedPodStatus(pod *v1.Pod) error {
 	//			// use app.containersForWhichWeAreStreamingLogs to determine the following condition
 	// 			if we are not already streaming logs for the container
 	//				start streaming logs for the container
-	if !u.opts.Detach && u.cfg.MatchesFilterDirectly(app.composeService) {
+	if !u.opts.Detach {
 		for _, containerStatus := range pod.Status.ContainerStatuses {
-			_, ok := app.containersForWhichWeAreStreamingLogs[containerStatus.Name]
-			if !ok && containerStatus.State.Running != nil {
-				app.containersForWhichWeAreStreamingLogs[containerStatus.Name] = true
+			// The logs of the containers of a pod group are prefixed with the names of their services.
+			containerApp := app.getContainerApp(containerStatus.Name)
+			if containerApp == nil {
+				containerApp = app
+			}
+			if containerApp.failed || !u.cfg.MatchesFilterDirectly(containerApp.composeService) {
+				continue
+			}
+			_, ok := containerApp.containersForWhichWeAreStreamingLogs[containerStatus.Name]
+			if !ok && containerStatus.State.Running != nil && !isMeshProxyContainer(u.opts.Mesh, containerStatus.Name) {
+				containerApp.containersForWhichWeAreStreamingLogs[containerStatus.Name] = true
 				getPodLogOptions := &v1.PodLogOptions{
 					Follow:    true,
 					Container: containerStatus.Name,
 				}
 				completedChannel := make(chan interface{})
 				u.completedChannels = append(u.completedChannels, completedChannel)
-				go u.streamPodLogs(pod, completedChannel, getPodLogOptions, app)
+				go u.streamPodLogs(pod, completedChannel, getPodLogOptions, containerApp)
 			}
 		}
 	}
-	s, err := parsePodStatus(pod)
+	for _, app2 := range app.podApps {
+		if app2.failed {
+			continue
+		}
+		err := u.updateAppMaxObservedPodStatusCore(app2, pod)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateAppMaxObservedPodStatusCore updates the status of an app whose container runs in a pod. The status of an app in a pod group is
+// the status of its container (see parseContainerStatus).
+func (u *upRunner) updateAppMaxObservedPodStatusCore(app *app, pod *v1.Pod) error {
+	var s podStatus
+	var err error
+	if len(app.podApp.podApps) > 1 {
+		s, err = parseContainerStatus(pod, k8smeta.GetShortName(app.composeService))
+	} else {
+		s, err = parsePodStatus(pod, u.opts.Mesh)
+	}
 	if err != nil {
 		if app.reporterRow != nil {
 			app.reporterRow.AddStatus(&reporter.Status{
@@ -922,17 +1442,41 @@ func (u *upRunner) updateAppMaxObservedPodStatus(pod *v1.Pod) error {
 				Priority:  4,
 			})
 		}
-		return err
+		return u.handleAppFailure(app, err)
+	}
+	reportSchedulingFailure(app, pod)
+	if pod.Status.HostIP != "" {
+		app.nodeIP = pod.Status.HostIP
 	}
 
 	if s > app.maxObservedPodStatus {
 		u.setAppMaxObservedPodStatus(app, s)
 	}
+	if (s == podStatusStarted || s == podStatusReady) && u.shouldForwardDebugPorts(app) {
+		app.debugPortsForwarded = true
+		if debugPorts := getDebugPorts(app); len(debugPorts) > 0 {
+			go u.forwardDebugPorts(app, pod.ObjectMeta.Name, debugPorts)
+		}
+	}
 	return nil
 }
 
+func (u *upRunner) shouldForwardDebugPorts(app *app) bool {
+	return !u.opts.Detach && !u.opts.NoDebugPortForwarding && !app.debugPortsForwarded && u.cfg.MatchesFilterDirectly(app.composeService)
+}
+
 func (u *upRunner) setAppMaxObservedPodStatus(app *app, s podStatus) {
+	if s >= podStatusReady && app.maxObservedPodStatus < podStatusReady && !app.podCreationTime.IsZero() {
+		u.metrics.readinessSeconds.Observe(time.Since(app.podCreationTime).Seconds(), app.name())
+		u.opts.Timing.Record(phaseWaitForReadiness, app.name(), app.podCreationTime)
+	}
+	if s >= podStatusReady && app.maxObservedPodStatus < podStatusReady {
+		u.emitPodEvent(app, EventReady)
+	}
 	app.maxObservedPodStatus = s
+	if s == podStatusReady {
+		app.observedReady = true
+	}
 	if app.reporterRow != nil {
 		switch {
 		case s == podStatusStarted:
@@ -946,7 +1490,7 @@ func (u *upRunner) setAppMaxObservedPodStatus(app *app, s podStatus) {
 }
 
 func (u *upRunner) streamPodLogs(pod *v1.Pod, completedChannel chan interface{}, getPodLogOptions *v1.PodLogOptions, a *app) {
-	getLogsRequest := u.k8sPodClient.GetLogs(pod.ObjectMeta.Name, getPodLogOptions)
+	getLogsRequest := u.podClient(pod.ObjectMeta.Namespace).GetLogs(pod.ObjectMeta.Name, getPodLogOptions)
 	var bodyReader io.ReadCloser
 	bodyReader, err := getLogsRequest.Stream()
 	if err != nil {
@@ -963,39 +1507,143 @@ func (u *upRunner) streamPodLogs(pod *v1.Pod, completedChannel chan interface{},
 	close(completedChannel)
 }
 
-func (u *upRunner) createPodsIfNeeded() error {
+// isDependsOnConditionSatisfied determines whether the depends_on condition of app1 on app2 is satisfied, following docker compose
+// semantics. The condition service_started is satisfied once all containers of the pod of app2 have been running (including if they
+// have completed since). The condition service_healthy is satisfied once the pod of app2 has been ready. It is an error if app2
+// completes before becoming ready, or if app2 has no healthcheck (because Kubernetes considers pods without readiness probes ready as
+// soon as they are running).
+func (u *upRunner) isDependsOnConditionSatisfied(app1, app2 *app, healthiness dockerComposeConfig.ServiceHealthiness) (bool, error) {
+	if healthiness != dockerComposeConfig.ServiceHealthy {
+		return app2.maxObservedPodStatus >= podStatusStarted, nil
+	}
+	if !app2.observedReady {
+		if app2.maxObservedPodStatus == podStatusCompleted {
+			return false, fmt.Errorf("docker compose service %s depends on service %s being healthy, but %s completed without becoming "+
+				"healthy", app1.name(), app2.name(), app2.name())
+		}
+		return false, nil
+	}
+	err := u.getAppImageInfoOnce(app2)
+	if err != nil {
+		return false, err
+	}
+	if app2.composeService.DockerComposeService.HealthcheckDisabled {
+		return false, fmt.Errorf("docker compose service %s depends on service %s being healthy, but %s has its healthcheck disabled",
+			app1.name(), app2.name(), app2.name())
+	}
+	if app2.GetReadinessProbe() == nil {
+		return false, fmt.Errorf("docker compose service %s depends on service %s being healthy, but %s has no healthcheck",
+			app1.name(), app2.name(), app2.name())
+	}
+	return true, nil
+}
+
+// getAppsThatCanBeStarted returns the apps that are yet to be started and whose depends_on conditions are all satisfied. Together these
+// apps form the next wave of the dependency graph, and their pods can be created in parallel.
+func (u *upRunner) getAppsThatCanBeStarted() ([]*app, error) {
+	var wave []*app
 	for app1 := range u.appsToBeStarted {
 		createPod := true
-		for name, healthiness := range app1.composeService.DockerComposeService.DependsOn {
+		dependsOn, _ := app1.getPodDependsOn()
+		for name, healthiness := range dependsOn {
 			composeService := u.cfg.Services[name]
 			app2 := u.apps[composeService.Name()]
-			if healthiness == dockerComposeConfig.ServiceHealthy {
-				if app2.maxObservedPodStatus != podStatusReady {
+			if u.opts.DependencyWaitMode == DependencyWaitModeInitContainer {
+				// depends_on is enforced by init containers, so pods can be created as soon as the pods of their dependencies have
+				// been created. Creating pods in dependency order ensures that redeployments cascade deterministically.
+				if u.appsToBeStarted[app2.podApp] {
 					createPod = false
 				}
-			} else {
-				if app2.maxObservedPodStatus != podStatusStarted && app2.maxObservedPodStatus != podStatusReady {
-					createPod = false
+				continue
+			}
+			satisfied, err := u.isDependsOnConditionSatisfied(app1, app2, healthiness)
+			if err != nil {
+				err = u.handleAppFailure(app2, err)
+				if err != nil {
+					return nil, err
 				}
+				// The dependents of app2 (including app1) have been skipped, so compute the wave again.
+				return u.getAppsThatCanBeStarted()
+			}
+			if !satisfied || !u.isAppReachable(app2) {
+				createPod = false
 			}
 		}
 		if createPod {
+			wave = append(wave, app1)
+		}
+	}
+	return wave, nil
+}
+
+// createPodsConcurrently creates the pods of the apps, creating at most u.opts.Concurrency pods at the same time (or all pods at the
+// same time if u.opts.Concurrency is not positive). The first error encountered is returned.
+func (u *upRunner) createPodsConcurrently(apps []*app) ([]*v1.Pod, error) {
+	concurrency := u.opts.Concurrency
+	if concurrency <= 0 || concurrency > len(apps) {
+		concurrency = len(apps)
+	}
+	semaphore := make(chan struct{}, concurrency)
+	pods := make([]*v1.Pod, len(apps))
+	errs := make([]error, len(apps))
+	var wg sync.WaitGroup
+	for i, app1 := range apps {
+		wg.Add(1)
+		go func(i int, app1 *app) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() {
+				<-semaphore
+			}()
 			app1.newLogEntry().Debugf(u.formatCreatePodReason(app1))
-			_, err := u.createPod(app1)
+			pods[i], errs[i] = u.createPod(app1)
+		}(i, app1)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pods, nil
+}
+
+// createPodsIfNeeded creates waves of pods until no more pods can be created. Pods that already existed may already satisfy the
+// depends_on conditions of other apps, so the statuses of created pods are processed after each wave.
+func (u *upRunner) createPodsIfNeeded() error {
+	for {
+		wave, err := u.getAppsThatCanBeStarted()
+		if err != nil || len(wave) == 0 {
+			return err
+		}
+		for _, app1 := range wave {
+			delete(u.appsToBeStarted, app1)
+		}
+		pods, err := u.createPodsConcurrently(wave)
+		if err != nil {
+			return err
+		}
+		for i, app1 := range wave {
+			for _, app2 := range app1.podApps {
+				u.appsThatNeedToBeReady[app2] = true
+			}
+			err = u.updateAppMaxObservedPodStatus(pods[i])
 			if err != nil {
 				return err
 			}
-			delete(u.appsToBeStarted, app1)
 		}
 	}
-	return nil
 }
 
 func (u *upRunner) formatCreatePodReason(app1 *app) string {
+	dependsOn, _ := app1.getPodDependsOn()
+	if len(dependsOn) == 0 {
+		return "all depends_on conditions satisfied"
+	}
 	reason := strings.Builder{}
 	reason.WriteString("all depends_on conditions satisfied (")
 	comma := false
-	for name, healthiness := range app1.composeService.DockerComposeService.DependsOn {
+	for name, healthiness := range dependsOn {
 		if comma {
 			reason.WriteString(", ")
 		}
@@ -1011,63 +1659,112 @@ func (u *upRunner) formatCreatePodReason(app1 *app) string {
 	return reason.String()
 }
 
-func (u *upRunner) runStartInitialPods() error {
-	for app := range u.appsToBeStarted {
-		if len(app.composeService.DockerComposeService.DependsOn) != 0 {
-			continue
-		}
-		app.newLogEntry().Debug("all depends_on conditions satisfied")
-		_, err := u.createPod(app)
+// runListPodsAndCreateThemIfNeeded lists the pods in each namespace of the docker compose services, and returns the resource version of
+// the list of each namespace.
+func (u *upRunner) runListPodsAndCreateThemIfNeeded() (map[string]string, error) {
+	listOptions := metav1.ListOptions{
+		LabelSelector: k8smeta.GetLabelSelector(u.cfg),
+	}
+	resourceVersions := map[string]string{}
+	for _, namespace := range u.cfg.Namespaces() {
+		podList, err := u.podClient(namespace).List(listOptions)
 		if err != nil {
-			return err
+			return nil, exitcode.Wrap(err, exitcode.ClusterConnectivity)
 		}
-		delete(u.appsToBeStarted, app)
+		for i := 0; i < len(podList.Items); i++ {
+			err = u.updateAppMaxObservedPodStatus(&podList.Items[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+		resourceVersions[namespace] = podList.ResourceVersion
 	}
-	return nil
+	err := u.createPodsIfNeeded()
+	if err != nil {
+		return nil, err
+	}
+	return resourceVersions, nil
 }
 
-func (u *upRunner) runListPodsAndCreateThemIfNeeded() (string, error) {
-	listOptions := metav1.ListOptions{
-		LabelSelector: u.cfg.EnvironmentLabel + "=" + u.cfg.EnvironmentID,
+// checkOptions validates the docker compose services against the options, and allocates host ports, before anything is applied. It
+// does not connect to a cluster, so that Render fails like up.
+func (u *upRunner) checkOptions() error {
+	err := u.checkLowLevelFields()
+	if err != nil {
+		return err
 	}
-	podList, err := u.k8sPodClient.List(listOptions)
+	err = u.checkIPFamilies()
 	if err != nil {
-		return "", err
+		return err
 	}
-	for i := 0; i < len(podList.Items); i++ {
-		err = u.updateAppMaxObservedPodStatus(&podList.Items[i])
-		if err != nil {
-			return "", err
-		}
+	err = u.checkDNS()
+	if err != nil {
+		return err
 	}
-	err = u.createPodsIfNeeded()
+	err = u.checkNamespaces()
 	if err != nil {
-		return "", err
+		return err
 	}
-	return podList.ResourceVersion, nil
+	return u.allocateHostPorts()
 }
 
-func (u *upRunner) run() error {
+// newDockerClient creates the docker client of up. It is a variable so that unit tests can use a fake docker daemon.
+var newDockerClient = docker.NewEnvClient
+
+func (u *upRunner) run() (err error) {
 	u.initApps()
 	u.initAppsToBeStarted()
 	u.initVolumeInfo()
-	err := u.initKubernetesClientset()
+	err = u.checkOptions()
+	if err != nil {
+		return err
+	}
+	err = u.initKubernetesClientset()
+	if err != nil {
+		return err
+	}
+	err = u.loadState()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		saveErr := u.saveState()
+		if err == nil {
+			err = saveErr
+		}
+	}()
+	err = u.checkResourceQuotas()
+	if err != nil {
+		return err
+	}
+	err = u.checkExposeMode()
+	if err != nil {
+		return err
+	}
+	err = u.detectClusterCapabilities()
 	if err != nil {
 		return err
 	}
 	// Initialize docker client
 	var dc *dockerClient.Client
-	dc, err = dockerClient.NewEnvClient()
+	dc, err = newDockerClient()
 	if err != nil {
 		return err
 	}
 	u.dockerClient = dc
+	u.initImagePullManager()
+	err = u.skipUnchangedApps()
+	if err != nil {
+		return err
+	}
 
 	for app := range u.appsToBeStarted {
 		// Begin pulling and pushing images immediately...
 		// The error returned by getAppImageInfoOnce will be handled later, hence the nolint.
-		// nolint
-		go u.getAppImageInfoOnce(app)
+		for _, app2 := range app.podApps {
+			// nolint
+			go u.getAppImageInfoOnce(app2)
+		}
 
 		// Start building the volume init image, if needed.
 		if len(app.volumes) > 0 {
@@ -1076,29 +1773,48 @@ func (u *upRunner) run() error {
 			go u.getAppVolumeInitImageOnce(app)
 		}
 	}
+	// Evaluate policies before anything is applied, which waits for the images.
+	err = u.checkPolicies()
+	if err != nil {
+		return err
+	}
 	// Begin creating services and collecting their cluster IPs (we'll need this to
 	// set the hostAliases of each pod).
 	// The error returned by getAppImageInfoOnce will be handled later, hence the nolint.
 	// nolint
 	go u.createServicesAndGetPodHostAliasesOnce()
 
-	err = u.runStartInitialPods()
+	u.startResourceRecorder()
+
+	// Create the pods of the first wave, i.e. of apps without dependencies.
+	err = u.createPodsIfNeeded()
 	if err != nil {
 		return err
 	}
 
-	var resourceVersion string
-	resourceVersion, err = u.runListPodsAndCreateThemIfNeeded()
+	var resourceVersions map[string]string
+	resourceVersions, err = u.runListPodsAndCreateThemIfNeeded()
 	if err != nil {
 		return err
 	}
-	err = u.runWatchPods(resourceVersion)
+	err = u.runWatchPods(resourceVersions)
 	if err != nil {
 		return err
 	}
-	// Wait for completed channels
-	for _, completedChannel := range u.completedChannels {
-		<-completedChannel
+	err = u.pruneState()
+	if err != nil {
+		return err
+	}
+	err = u.recordRevision()
+	if err != nil {
+		return err
+	}
+	if !u.opts.Detach {
+		u.printURLSummary()
+	}
+	u.waitForCompletedChannels()
+	if u.resourceRecorder != nil {
+		return u.writeResourceRecommendations(log.StandardLogger().Out)
 	}
 	return nil
 }
@@ -1114,7 +1830,7 @@ func (u *upRunner) runWatchPodsEvent(event *k8swatch.Event) error {
 	case k8swatch.Deleted:
 		pod := event.Object.(*v1.Pod)
 		app := u.findAppFromObjectMeta(&pod.ObjectMeta)
-		if app != nil {
+		if app != nil && pod.UID == app.podUID {
 			return k8smeta.ErrorResourcesModifiedExternally()
 		}
 	default:
@@ -1123,28 +1839,42 @@ func (u *upRunner) runWatchPodsEvent(event *k8swatch.Event) error {
 	return u.createPodsIfNeeded()
 }
 
-func (u *upRunner) runWatchPods(resourceVersion string) error {
+func (u *upRunner) runWatchPods(resourceVersions map[string]string) error {
 	if u.checkIfPodsReady() {
 		log.Infof("pods ready (%d/%d)\n", len(u.appsThatNeedToBeReady), len(u.appsThatNeedToBeReady))
 		return nil
 	}
 	listOptions := metav1.ListOptions{
-		LabelSelector: u.cfg.EnvironmentLabel + "=" + u.cfg.EnvironmentID,
+		LabelSelector: k8smeta.GetLabelSelector(u.cfg),
 	}
-	listOptions.ResourceVersion = resourceVersion
-	listOptions.Watch = true
-	watch, err := u.k8sPodClient.Watch(listOptions)
+	watch, err := u.watchNamespaces(listOptions, resourceVersions, func(namespace string, listOptions metav1.ListOptions) (
+		k8swatch.Interface, error) {
+		return u.podClient(namespace).Watch(listOptions)
+	})
 	if err != nil {
 		return err
 	}
 	defer watch.Stop()
 	eventChannel := watch.ResultChan()
+	// A nil channel blocks forever, so timeouts are only checked if any app has a wait timeout.
+	var timeoutChannel <-chan time.Time
+	if u.hasDependencyWaitTimeouts() {
+		ticker := time.NewTicker(dependencyWaitTimeoutCheckInterval)
+		defer ticker.Stop()
+		timeoutChannel = ticker.C
+	}
 	for {
-		event, ok := <-eventChannel
-		if !ok {
-			return fmt.Errorf("channel unexpectedly closed")
+		select {
+		case event, ok := <-eventChannel:
+			if !ok {
+				return exitcode.Wrap(fmt.Errorf("channel unexpectedly closed"), exitcode.ClusterConnectivity)
+			}
+			err = u.runWatchPodsEvent(&event)
+		case <-timeoutChannel:
+			err = u.checkDependencyWaitTimeouts()
+		case result := <-u.dnsProbeResults:
+			err = u.handleDNSProbeResult(result)
 		}
-		err = u.runWatchPodsEvent(&event)
 		if err != nil {
 			return err
 		}
@@ -1157,6 +1887,10 @@ func (u *upRunner) runWatchPods(resourceVersion string) error {
 }
 
 func (u *upRunner) checkIfPodsReady() bool {
+	// The dependents of apps whose DNS probes are running are yet to be started.
+	if u.dnsProbesRunning > 0 {
+		return false
+	}
 	allPodsReady := true
 	for app := range u.appsThatNeedToBeReady {
 		if app.maxObservedPodStatus < podStatusReady {
@@ -1166,14 +1900,26 @@ func (u *upRunner) checkIfPodsReady() bool {
 	return allPodsReady
 }
 
-// Run runs an operation similar docker-compose up against a Kubernetes cluster.
-func Run(cfg *config.Config, opts *Options) error {
-	// TODO https://github.com/kube-compose/kube-compose/issues/2 accept context as a parameter
+func newUpRunner(cfg *config.Config, opts *Options) *upRunner {
 	u := &upRunner{
 		cfg:  cfg,
 		opts: opts,
 	}
+	if opts.Metrics == nil {
+		opts.Metrics = metrics.NewRegistry()
+	}
+	u.metrics = newUpMetrics(opts.Metrics)
+	if opts.Timing == nil {
+		opts.Timing = timing.NewRecorder()
+	}
 	u.hostAliases.once = &sync.Once{}
 	u.localImagesCache.once = &sync.Once{}
-	return u.run()
+	u.secretResolver.once = &sync.Once{}
+	return u
+}
+
+// Run runs an operation similar docker-compose up against a Kubernetes cluster.
+func Run(cfg *config.Config, opts *Options) error {
+	// TODO https://github.com/kube-compose/kube-compose/issues/2 accept context as a parameter
+	return newUpRunner(cfg, opts).run()
 }
@@ -1,10 +1,14 @@
 package up
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kube-compose/kube-compose/internal/app/config"
 	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/rest"
 )
 
@@ -120,7 +124,8 @@ func TestUpRunnerInitKubernetesClientset(t *testing.T) {
 func TestFormatCreatePodReason(t *testing.T) {
 	cfg := newTestConfig()
 	u := &upRunner{
-		cfg: cfg,
+		cfg:  cfg,
+		opts: &Options{},
 	}
 	u.initApps()
 	appA := u.apps["a"]
@@ -130,3 +135,330 @@ func TestFormatCreatePodReason(t *testing.T) {
 		t.Error(s)
 	}
 }
+
+func TestFormatCreatePodReason_NoDependencies(t *testing.T) {
+	cfg := newTestConfig()
+	u := &upRunner{
+		cfg:  cfg,
+		opts: &Options{},
+	}
+	u.initApps()
+	s := u.formatCreatePodReason(u.apps["b"])
+	if s != "all depends_on conditions satisfied" {
+		t.Error(s)
+	}
+}
+
+func newTestUpRunnerWithAppsToBeStarted() *upRunner {
+	u := &upRunner{
+		cfg:  newTestConfig(),
+		opts: &Options{},
+	}
+	u.initApps()
+	u.appsToBeStarted = map[*app]bool{}
+	for _, a := range u.apps {
+		u.appsToBeStarted[a] = true
+	}
+	return u
+}
+
+func waveToNameSet(wave []*app) map[string]bool {
+	names := map[string]bool{}
+	for _, a := range wave {
+		names[a.name()] = true
+	}
+	return names
+}
+
+func TestGetAppsThatCanBeStarted_FirstWave(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	wave, err := u.getAppsThatCanBeStarted()
+	if err != nil {
+		t.Error(err)
+	}
+	names := waveToNameSet(wave)
+	if len(names) != 3 || !names["b"] || !names["c"] || !names["d"] {
+		t.Error(names)
+	}
+}
+
+func TestGetAppsThatCanBeStarted_DependencyNotReady(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	for _, name := range []string{"b", "c", "d"} {
+		delete(u.appsToBeStarted, u.apps[name])
+		u.apps[name].maxObservedPodStatus = podStatusStarted
+	}
+	wave, err := u.getAppsThatCanBeStarted()
+	if err != nil || len(wave) != 0 {
+		t.Fail()
+	}
+}
+
+func TestGetAppsThatCanBeStarted_SecondWave(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	for _, name := range []string{"b", "c", "d"} {
+		delete(u.appsToBeStarted, u.apps[name])
+	}
+	setTestAppHealthy(u.apps["c"])
+	u.apps["d"].maxObservedPodStatus = podStatusCompleted
+	wave, err := u.getAppsThatCanBeStarted()
+	if err != nil {
+		t.Error(err)
+	}
+	names := waveToNameSet(wave)
+	if len(names) != 1 || !names["a"] {
+		t.Error(names)
+	}
+}
+
+// setTestAppHealthy marks the app as ready, and gives it a healthcheck without loading image information.
+func setTestAppHealthy(a *app) {
+	a.maxObservedPodStatus = podStatusReady
+	a.observedReady = true
+	a.composeService.DockerComposeService.Healthcheck = &dockerComposeConfig.Healthcheck{
+		IsShell: true,
+		Test:    []string{"true"},
+	}
+	a.imageInfo.once.Do(func() {})
+}
+
+func TestGetAppsThatCanBeStarted_HealthyDependencyCompletedWithoutBecomingReady(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	delete(u.appsToBeStarted, u.apps["c"])
+	u.apps["c"].maxObservedPodStatus = podStatusCompleted
+	_, err := u.getAppsThatCanBeStarted()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestGetAppsThatCanBeStarted_HealthyDependencyWithoutHealthcheck(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	delete(u.appsToBeStarted, u.apps["c"])
+	setTestAppHealthy(u.apps["c"])
+	u.apps["c"].composeService.DockerComposeService.Healthcheck = nil
+	_, err := u.getAppsThatCanBeStarted()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestGetAppsThatCanBeStarted_HealthyDependencyWithSynthesizedProbe(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	delete(u.appsToBeStarted, u.apps["c"])
+	setTestAppHealthy(u.apps["c"])
+	u.apps["c"].composeService.DockerComposeService.Healthcheck = nil
+	u.apps["c"].synthesizeReadinessProbe = true
+	u.apps["c"].imageInfo.exposedPorts = []int32{5432}
+	_, err := u.getAppsThatCanBeStarted()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetAppsThatCanBeStarted_HealthyDependencyWithDisabledHealthcheck(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	delete(u.appsToBeStarted, u.apps["c"])
+	setTestAppHealthy(u.apps["c"])
+	u.apps["c"].composeService.DockerComposeService.HealthcheckDisabled = true
+	_, err := u.getAppsThatCanBeStarted()
+	if err == nil || !strings.Contains(err.Error(), "healthcheck disabled") {
+		t.Error(err)
+	}
+}
+
+func TestGetReadinessProbe_Disabled(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.DockerComposeService.HealthcheckDisabled = true
+	app.imageInfo.imageHealthcheck = &dockerComposeConfig.Healthcheck{
+		Test: []string{"true"},
+	}
+	app.synthesizeReadinessProbe = true
+	app.imageInfo.exposedPorts = []int32{80}
+	if probe := app.GetReadinessProbe(); probe != nil {
+		t.Error(probe)
+	}
+}
+
+func TestGetReadinessProbe_InheritsImageTest(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.DockerComposeService.Healthcheck = &dockerComposeConfig.Healthcheck{
+		Interval: 5 * time.Second,
+	}
+	app.imageInfo.imageHealthcheck = &dockerComposeConfig.Healthcheck{
+		Interval: 30 * time.Second,
+		Retries:  3,
+		Test:     []string{"pg_isready"},
+		Timeout:  10 * time.Second,
+	}
+	probe := app.GetReadinessProbe()
+	if probe == nil || probe.PeriodSeconds != 5 || probe.TimeoutSeconds != 10 || probe.FailureThreshold != 3 ||
+		!reflect.DeepEqual(probe.Exec.Command, []string{"pg_isready"}) {
+		t.Error(probe)
+	}
+}
+
+func TestGetReadinessProbe_WithoutTestAndImageHealthcheck(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.DockerComposeService.Healthcheck = &dockerComposeConfig.Healthcheck{
+		Interval: 5 * time.Second,
+	}
+	if probe := app.GetReadinessProbe(); probe != nil {
+		t.Error(probe)
+	}
+}
+
+func TestGetContainerPorts_ExposedPorts(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.Ports = []config.Port{
+		{
+			Port:     8080,
+			Protocol: "tcp",
+		},
+	}
+	app.imageInfo.exposedPorts = []int32{80, 8080}
+	containerPorts := getContainerPorts(app)
+	expected := []v1.ContainerPort{
+		{
+			ContainerPort: 8080,
+			Protocol:      v1.ProtocolTCP,
+		},
+		{
+			ContainerPort: 80,
+			Protocol:      v1.ProtocolTCP,
+		},
+	}
+	if !reflect.DeepEqual(containerPorts, expected) {
+		t.Error(containerPorts)
+	}
+}
+
+func TestGetReadinessProbe_Synthesized(t *testing.T) {
+	app := newTestApp("a")
+	app.synthesizeReadinessProbe = true
+	app.imageInfo.exposedPorts = []int32{80}
+	app.composeService.Ports = []config.Port{
+		{
+			Port:     53,
+			Protocol: "udp",
+		},
+		{
+			Port:     8080,
+			Protocol: "tcp",
+		},
+	}
+	probe := app.GetReadinessProbe()
+	if probe == nil || probe.TCPSocket == nil || probe.TCPSocket.Port.IntValue() != 8080 {
+		t.Error(probe)
+	}
+}
+
+func TestGetReadinessProbe_SynthesizedWithoutPorts(t *testing.T) {
+	app := newTestApp("a")
+	app.synthesizeReadinessProbe = true
+	if probe := app.GetReadinessProbe(); probe != nil {
+		t.Error(probe)
+	}
+}
+
+func TestGetLivenessProbe_Success(t *testing.T) {
+	app := newTestApp("a")
+	app.livenessProbe = true
+	app.composeService.DockerComposeService.Healthcheck = &dockerComposeConfig.Healthcheck{
+		IsShell:     true,
+		Interval:    10 * time.Second,
+		Retries:     3,
+		StartPeriod: 1500 * time.Millisecond,
+		Test:        []string{"curl -f http://localhost"},
+		Timeout:     time.Second,
+	}
+	probe := app.GetLivenessProbe()
+	if probe == nil || probe.InitialDelaySeconds != 2 || probe.PeriodSeconds != 10 || probe.FailureThreshold != 3 ||
+		!reflect.DeepEqual(probe.Exec.Command, []string{"/bin/sh", "-c", "curl -f http://localhost"}) {
+		t.Error(probe)
+	}
+	// Readiness probes do not wait for the start period.
+	if probe := app.GetReadinessProbe(); probe == nil || probe.InitialDelaySeconds != 0 {
+		t.Error(probe)
+	}
+}
+
+func TestGetLivenessProbe_InheritsImageStartPeriod(t *testing.T) {
+	app := newTestApp("a")
+	app.livenessProbe = true
+	app.composeService.DockerComposeService.Healthcheck = &dockerComposeConfig.Healthcheck{
+		Interval: 5 * time.Second,
+	}
+	app.imageInfo.imageHealthcheck = &dockerComposeConfig.Healthcheck{
+		StartPeriod: 30 * time.Second,
+		Test:        []string{"pg_isready"},
+	}
+	probe := app.GetLivenessProbe()
+	if probe == nil || probe.InitialDelaySeconds != 30 || probe.PeriodSeconds != 5 {
+		t.Error(probe)
+	}
+}
+
+func TestGetLivenessProbe_NotEnabled(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.DockerComposeService.Healthcheck = &dockerComposeConfig.Healthcheck{
+		Test: []string{"true"},
+	}
+	if probe := app.GetLivenessProbe(); probe != nil {
+		t.Error(probe)
+	}
+}
+
+func TestGetLivenessProbe_Disabled(t *testing.T) {
+	app := newTestApp("a")
+	app.livenessProbe = true
+	app.composeService.DockerComposeService.HealthcheckDisabled = true
+	app.imageInfo.imageHealthcheck = &dockerComposeConfig.Healthcheck{
+		Test: []string{"true"},
+	}
+	if probe := app.GetLivenessProbe(); probe != nil {
+		t.Error(probe)
+	}
+}
+
+func TestCreatePodsIfNeeded_EmptyWave(t *testing.T) {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.appsToBeStarted = map[*app]bool{}
+	err := u.createPodsIfNeeded()
+	if err != nil || len(u.appsThatNeedToBeReady) != 0 {
+		t.Fail()
+	}
+}
+
+func TestNewService_KubernetesService(t *testing.T) {
+	a := newTestAppWithPublishedPorts(8080)
+	timeout := 3 * time.Hour
+	a.composeService.KubernetesService = &config.KubernetesService{
+		ExternalTrafficPolicy:  v1.ServiceExternalTrafficPolicyTypeLocal,
+		SessionAffinity:        v1.ServiceAffinityClientIP,
+		SessionAffinityTimeout: &timeout,
+		Type:                   v1.ServiceTypeNodePort,
+	}
+	service := newService(newTestConfig(), a)
+	spec := service.Spec
+	if spec.Type != v1.ServiceTypeNodePort || spec.ExternalTrafficPolicy != v1.ServiceExternalTrafficPolicyTypeLocal ||
+		spec.SessionAffinity != v1.ServiceAffinityClientIP || *spec.SessionAffinityConfig.ClientIP.TimeoutSeconds != 10800 {
+		t.Error(spec)
+	}
+}
+
+func TestGetRemoteServiceHostAliases_Success(t *testing.T) {
+	u := &upRunner{
+		cfg: &config.Config{
+			RemoteServices: map[string]string{
+				"db":    "10.1.2.3",
+				"cache": "10.1.2.4",
+			},
+		},
+	}
+	hostAliases := u.getRemoteServiceHostAliases()
+	if len(hostAliases) != 2 || hostAliases[0].IP != "10.1.2.4" || hostAliases[0].Hostnames[0] != "cache" ||
+		hostAliases[1].IP != "10.1.2.3" || hostAliases[1].Hostnames[0] != "db" {
+		t.Error(hostAliases)
+	}
+}
@@ -0,0 +1,184 @@
+package up
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/portforward"
+)
+
+// urlSummaryPortForwardTimeout is how long printURLSummary waits for published ports to be forwarded.
+const urlSummaryPortForwardTimeout = 10 * time.Second
+
+// appURL is a URL at which a published port of a docker compose service can be reached.
+type appURL struct {
+	port int32
+	url  string
+	// How the port is reached, such as "Ingress" or "port-forward".
+	via string
+}
+
+func formatURL(scheme, host string, port int32) string {
+	return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(int(port)))
+}
+
+// getPublishedTCPPorts returns the container ports of the published TCP ports of an app, without duplicates.
+func getPublishedTCPPorts(a *app) []int32 {
+	var ports []int32
+	seen := map[int32]bool{}
+	for _, port := range a.composeService.DockerComposeService.Ports {
+		if port.Protocol == "tcp" && !seen[port.Internal] {
+			seen[port.Internal] = true
+			ports = append(ports, port.Internal)
+		}
+	}
+	return ports
+}
+
+// getAppURL returns the URL at which a published TCP port of an app can be reached from outside of the cluster without port
+// forwarding, or nil if there is no such URL. In order of preference, ports are reached through the Ingress or HTTPRoute of the app,
+// the host port of the pod (see Options.HostPorts), or the Kubernetes service if it has type LoadBalancer or NodePort. service is the
+// Kubernetes service of the app, or nil if it is not known.
+func (u *upRunner) getAppURL(a *app, port int32, service *v1.Service) *appURL {
+	if ingress := a.composeService.Ingress; ingress != nil && ingress.Port == port && !u.capabilities.noIngressController {
+		if u.opts.ExposeMode == ExposeModeGateway {
+			return &appURL{port: port, url: "http://" + ingress.Host + "/", via: "HTTPRoute"}
+		}
+		scheme := "http"
+		if ingress.TLS != nil {
+			scheme = "https"
+		}
+		return &appURL{port: port, url: scheme + "://" + ingress.Host + "/", via: "Ingress"}
+	}
+	if hostPort, ok := a.hostPorts[hostPortKey{port: port, protocol: "tcp"}]; ok {
+		host := hostPort.hostIP
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = a.nodeIP
+		}
+		if host == "" {
+			host = "localhost"
+		}
+		return &appURL{port: port, url: formatURL("http", host, hostPort.port), via: "hostPort"}
+	}
+	if service == nil {
+		return nil
+	}
+	for _, servicePort := range service.Spec.Ports {
+		if servicePort.Port != port || servicePort.Protocol != v1.ProtocolTCP {
+			continue
+		}
+		if service.Spec.Type == v1.ServiceTypeLoadBalancer {
+			for _, ingress := range service.Status.LoadBalancer.Ingress {
+				host := ingress.IP
+				if host == "" {
+					host = ingress.Hostname
+				}
+				if host != "" {
+					return &appURL{port: port, url: formatURL("http", host, port), via: "LoadBalancer"}
+				}
+			}
+		}
+		if servicePort.NodePort != 0 && a.nodeIP != "" {
+			return &appURL{port: port, url: formatURL("http", a.nodeIP, servicePort.NodePort), via: "NodePort"}
+		}
+	}
+	return nil
+}
+
+// getAppURLs returns the URLs of the published TCP ports of an app (see getAppURL), and the published TCP ports without URL. The ports
+// of debuggers are omitted if they are already forwarded.
+func (u *upRunner) getAppURLs(a *app, service *v1.Service) (urls []appURL, unreachable []int32) {
+	debugPorts := map[int32]bool{}
+	if a.debugPortsForwarded {
+		for _, debugPort := range getDebugPorts(a) {
+			debugPorts[debugPort.port] = true
+		}
+	}
+	for _, port := range getPublishedTCPPorts(a) {
+		if url := u.getAppURL(a, port, service); url != nil {
+			urls = append(urls, *url)
+		} else if !debugPorts[port] {
+			unreachable = append(unreachable, port)
+		}
+	}
+	return urls, unreachable
+}
+
+// forwardPortsAndWait forwards ports of the pod of an app to localhost, and returns the URLs of the forwarded ports once they are
+// forwarded. No URLs are returned if the ports are not forwarded in time.
+func (u *upRunner) forwardPortsAndWait(a *app, ports []int32) []appURL {
+	readyChannel := make(chan []portforward.ForwardedPort, 1)
+	onReady := func(forwardedPorts []portforward.ForwardedPort) {
+		readyChannel <- forwardedPorts
+	}
+	go u.forwardPorts(a, k8smeta.GetK8sName(a.composeService.PodService(), u.cfg), "published ports", ports, onReady)
+	select {
+	case forwardedPorts := <-readyChannel:
+		urls := make([]appURL, len(forwardedPorts))
+		for i, forwardedPort := range forwardedPorts {
+			urls[i] = appURL{
+				port: int32(forwardedPort.Remote),
+				url:  formatURL("http", "localhost", int32(forwardedPort.Local)),
+				via:  "port-forward",
+			}
+		}
+		return urls
+	case <-time.After(urlSummaryPortForwardTimeout):
+		a.newLogEntry().Warnf("published ports were not forwarded within %v", urlSummaryPortForwardTimeout)
+		return nil
+	}
+}
+
+// printURLSummary prints a table of the URLs at which the published TCP ports of the running pods can be reached, so that users know
+// where to point their browser. Published ports that cannot be reached otherwise are forwarded to localhost, unless
+// Options.NoPortForwarding is set.
+func (u *upRunner) printURLSummary() {
+	names := make([]string, 0, len(u.apps))
+	for name, a := range u.apps {
+		if u.cfg.MatchesFilterDirectly(a.composeService) && !a.failed &&
+			(a.maxObservedPodStatus == podStatusStarted || a.maxObservedPodStatus == podStatusReady) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var buffer bytes.Buffer
+	w := tabwriter.NewWriter(&buffer, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tPORT\tURL\tVIA")
+	n := 0
+	for _, name := range names {
+		a := u.apps[name]
+		if !a.hasService() {
+			continue
+		}
+		serviceClient := u.serviceClient(u.cfg.GetNamespace(a.composeService))
+		service, err := serviceClient.Get(k8smeta.GetK8sName(a.composeService, u.cfg), metav1.GetOptions{})
+		if err != nil {
+			service = nil
+		}
+		urls, unreachable := u.getAppURLs(a, service)
+		if len(unreachable) > 0 && !u.opts.NoPortForwarding {
+			urls = append(urls, u.forwardPortsAndWait(a, unreachable)...)
+		}
+		sort.Slice(urls, func(i, j int) bool {
+			return urls[i].port < urls[j].port
+		})
+		for _, url := range urls {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", name, url.port, url.url, url.via)
+			n++
+		}
+	}
+	if n == 0 {
+		return
+	}
+	_ = w.Flush()
+	_, _ = log.StandardLogger().Out.Write(buffer.Bytes())
+}
@@ -0,0 +1,111 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTestAppWithPublishedPorts(ports ...int32) *app {
+	a := newTestApp("a")
+	for _, port := range ports {
+		a.composeService.DockerComposeService.Ports = append(a.composeService.DockerComposeService.Ports,
+			dockerComposeConfig.PortBinding{Internal: port, ExternalMin: -1, Protocol: "tcp"})
+	}
+	return a
+}
+
+func TestGetAppURLs_IngressAndHostPort(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{},
+	}
+	a := newTestAppWithPublishedPorts(8080, 5432, 6379)
+	a.composeService.Ingress = &config.Ingress{
+		Host: "web.example.com",
+		Port: 8080,
+		TLS:  &config.IngressTLS{},
+	}
+	a.nodeIP = "10.0.0.1"
+	a.hostPorts = map[hostPortKey]hostPort{
+		{port: 5432, protocol: "tcp"}: {port: 15432},
+	}
+	urls, unreachable := u.getAppURLs(a, nil)
+	expected := []appURL{
+		{port: 8080, url: "https://web.example.com/", via: "Ingress"},
+		{port: 5432, url: "http://10.0.0.1:15432", via: "hostPort"},
+	}
+	if !reflect.DeepEqual(urls, expected) || !reflect.DeepEqual(unreachable, []int32{6379}) {
+		t.Error(urls, unreachable)
+	}
+}
+
+func TestGetAppURLs_GatewayMode(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{
+			ExposeMode: ExposeModeGateway,
+		},
+	}
+	a := newTestAppWithPublishedPorts(8080)
+	a.composeService.Ingress = &config.Ingress{
+		Host: "web.example.com",
+		Port: 8080,
+	}
+	urls, _ := u.getAppURLs(a, nil)
+	if len(urls) != 1 || urls[0].url != "http://web.example.com/" || urls[0].via != "HTTPRoute" {
+		t.Error(urls)
+	}
+}
+
+func TestGetAppURLs_Service(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{},
+	}
+	a := newTestAppWithPublishedPorts(80, 443)
+	a.nodeIP = "10.0.0.1"
+	service := &v1.Service{
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Port: 80, Protocol: v1.ProtocolTCP, NodePort: 30080},
+				{Port: 443, Protocol: v1.ProtocolTCP, NodePort: 30443},
+			},
+			Type: v1.ServiceTypeLoadBalancer,
+		},
+	}
+	urls, unreachable := u.getAppURLs(a, service)
+	if len(urls) != 2 || urls[0].url != "http://10.0.0.1:30080" || urls[0].via != "NodePort" || len(unreachable) != 0 {
+		t.Error(urls, unreachable)
+	}
+	service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{
+		{Hostname: "lb.example.com"},
+	}
+	urls, _ = u.getAppURLs(a, service)
+	if len(urls) != 2 || urls[1].url != "http://lb.example.com:443" || urls[1].via != "LoadBalancer" {
+		t.Error(urls)
+	}
+}
+
+func TestGetAppURLs_DebugPortsForwarded(t *testing.T) {
+	u := &upRunner{
+		opts: &Options{},
+	}
+	a := newTestAppWithPublishedPorts(8080, 9229)
+	a.composeService.Ports = []config.Port{
+		{Port: 8080, Protocol: "tcp"},
+		{Port: 9229, Protocol: "tcp"},
+	}
+	a.debugPortsForwarded = true
+	urls, unreachable := u.getAppURLs(a, nil)
+	if len(urls) != 0 || !reflect.DeepEqual(unreachable, []int32{8080}) {
+		t.Error(urls, unreachable)
+	}
+}
+
+func TestFormatURL_IPv6(t *testing.T) {
+	url := formatURL("http", "fd00::1", 8080)
+	if url != "http://[fd00::1]:8080" {
+		t.Error(url)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"math"
 	"os"
 	"path"
+	"sort"
+	"strings"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -24,6 +26,7 @@ import (
 	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // https://docs.docker.com/engine/reference/builder/#healthcheck
@@ -63,8 +66,8 @@ func createReadinessProbeFromDockerHealthcheck(healthcheck *dockerComposeConfig.
 			},
 		},
 		// InitialDelaySeconds must always be zero so we start the healthcheck immediately.
-		// Irrespective of Docker's StartPeriod we should set this to zero.
-		// If this was a liveness probe we would have to set InitialDelaySeconds to StartPeriod.
+		// Irrespective of Docker's StartPeriod we should set this to zero, because failures of a readiness probe only delay readiness.
+		// See createLivenessProbeFromDockerHealthcheck.
 		InitialDelaySeconds: 0,
 
 		PeriodSeconds:  int32(math.RoundToEven(healthcheck.Interval.Seconds())),
@@ -75,6 +78,95 @@ func createReadinessProbeFromDockerHealthcheck(healthcheck *dockerComposeConfig.
 	return probe
 }
 
+// createLivenessProbeFromDockerHealthcheck creates a liveness probe that runs the test of a docker healthcheck. Like docker, failures
+// during the start period do not count, so the start period (rounded up to whole seconds) is the initial delay of the probe.
+func createLivenessProbeFromDockerHealthcheck(healthcheck *dockerComposeConfig.Healthcheck) *v1.Probe {
+	probe := createReadinessProbeFromDockerHealthcheck(healthcheck)
+	if probe != nil {
+		probe.InitialDelaySeconds = int32((healthcheck.StartPeriod + time.Second - 1) / time.Second)
+	}
+	return probe
+}
+
+// The parameters of synthesized readiness probes, which are more frequent than docker's default healthcheck interval so that dependency
+// waiting is not slowed down.
+const (
+	synthesizedProbeFailureThreshold = 3
+	synthesizedProbePeriodSeconds    = 2
+	synthesizedProbeTimeoutSeconds   = 1
+)
+
+// createTCPReadinessProbe creates a readiness probe that checks whether port accepts TCP connections, or returns nil if port is zero.
+func createTCPReadinessProbe(port int32) *v1.Probe {
+	if port == 0 {
+		return nil
+	}
+	return &v1.Probe{
+		FailureThreshold: synthesizedProbeFailureThreshold,
+		Handler: v1.Handler{
+			TCPSocket: &v1.TCPSocketAction{
+				Port: intstr.FromInt(int(port)),
+			},
+		},
+		PeriodSeconds:  synthesizedProbePeriodSeconds,
+		TimeoutSeconds: synthesizedProbeTimeoutSeconds,
+	}
+}
+
+// getExposedTCPPorts returns the TCP ports exposed by an image, in ascending order.
+func getExposedTCPPorts(config *dockerContainers.Config) []int32 {
+	if config == nil {
+		return nil
+	}
+	var ports []int32
+	for port := range config.ExposedPorts {
+		if port.Proto() == "tcp" {
+			ports = append(ports, int32(port.Int()))
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i] < ports[j]
+	})
+	return ports
+}
+
+// getContainerPorts returns the ports of the container of an app: the published ports of the docker compose service, followed by the
+// TCP ports exposed by the image (EXPOSE) that are not published. Published ports get the host port allocated by allocateHostPorts, if any.
+func getContainerPorts(a *app) []v1.ContainerPort {
+	containerPorts := make([]v1.ContainerPort, 0, len(a.composeService.Ports)+len(a.imageInfo.exposedPorts))
+	published := map[int32]bool{}
+	hostPortsUsed := map[hostPortKey]bool{}
+	for _, port := range a.composeService.Ports {
+		containerPort := v1.ContainerPort{
+			ContainerPort: port.Port,
+			Protocol:      v1.Protocol(strings.ToUpper(port.Protocol)),
+		}
+		key := hostPortKey{
+			port:     port.Port,
+			protocol: port.Protocol,
+		}
+		// Only the first binding of a container port is published on the host.
+		if hostPort, ok := a.hostPorts[key]; ok && !hostPortsUsed[key] {
+			containerPort.HostIP = hostPort.hostIP
+			containerPort.HostPort = hostPort.port
+			hostPortsUsed[key] = true
+		}
+		containerPorts = append(containerPorts, containerPort)
+		if port.Protocol == "tcp" {
+			published[port.Port] = true
+		}
+	}
+	for _, port := range a.imageInfo.exposedPorts {
+		if !published[port] {
+			containerPorts = append(containerPorts, v1.ContainerPort{
+				ContainerPort: port,
+				Protocol:      v1.ProtocolTCP,
+			})
+		}
+	}
+	return containerPorts
+}
+
 type hasTag interface {
 	Tag() string
 }
@@ -84,10 +176,11 @@ func inspectImageRawParseHealthcheck(inspectRaw []byte) (*dockerComposeConfig.He
 	var inspectInfo struct {
 		Config struct {
 			Healthcheck struct {
-				Test     []string `json:"Test"`
-				Timeout  *int64   `json:"Timeout"`
-				Interval *int64   `json:"Interval"`
-				Retries  *uint    `json:"Retries"`
+				Test        []string `json:"Test"`
+				Timeout     *int64   `json:"Timeout"`
+				Interval    *int64   `json:"Interval"`
+				Retries     *uint    `json:"Retries"`
+				StartPeriod *int64   `json:"StartPeriod"`
 			} `json:"Healthcheck"`
 		} `json:"Config"`
 	}
@@ -116,6 +209,9 @@ func inspectImageRawParseHealthcheck(inspectRaw []byte) (*dockerComposeConfig.He
 	if inspectInfo.Config.Healthcheck.Retries != nil {
 		healthcheck.Retries = *inspectInfo.Config.Healthcheck.Retries
 	}
+	if inspectInfo.Config.Healthcheck.StartPeriod != nil {
+		healthcheck.StartPeriod = time.Duration(*inspectInfo.Config.Healthcheck.StartPeriod)
+	}
 	return healthcheck, nil
 }
 
@@ -142,7 +238,9 @@ func copyFileFromContainer(ctx context.Context, dc *dockerClient.Client, contain
 	return nil
 }
 
-func getUserinfoFromImage(ctx context.Context, dc *dockerClient.Client, image string, user *docker.Userinfo) error {
+// withImageContainer creates a container of an image that is not started, and calls f with the container's ID and a temporary directory
+// into which files of the container can be copied. The container and temporary directory are removed afterwards.
+func withImageContainer(ctx context.Context, dc *dockerClient.Client, image string, f func(containerID, tmpDir string) error) error {
 	containerConfig := &dockerContainers.Config{
 		Entrypoint: []string{"sh"},
 		Image:      image,
@@ -168,11 +266,42 @@ func getUserinfoFromImage(ctx context.Context, dc *dockerClient.Client, image st
 			log.Error(err)
 		}
 	}()
-	err = getUserinfoFromImageUID(ctx, dc, resp.ID, tmpDir, user)
-	if err != nil {
-		return err
-	}
-	return getUserinfoFromImageGID(ctx, dc, resp.ID, tmpDir, user)
+	return f(resp.ID, tmpDir)
+}
+
+func getUserinfoFromImage(ctx context.Context, dc *dockerClient.Client, image string, user *docker.Userinfo) error {
+	return withImageContainer(ctx, dc, image, func(containerID, tmpDir string) error {
+		err := getUserinfoFromImageUID(ctx, dc, containerID, tmpDir, user)
+		if err != nil {
+			return err
+		}
+		return getUserinfoFromImageGID(ctx, dc, containerID, tmpDir, user)
+	})
+}
+
+// getGIDsFromImage resolves the names of groups to GIDs using the /etc/group file of an image, like docker resolves group_add.
+func getGIDsFromImage(ctx context.Context, dc *dockerClient.Client, image string, groups []string) ([]int64, error) {
+	var gids []int64
+	err := withImageContainer(ctx, dc, image, func(containerID, tmpDir string) error {
+		// TODO https://github.com/kube-compose/kube-compose/issues/70 this is not correct for non-Linux containers
+		err := copyFileFromContainer(ctx, dc, containerID, "/etc/group", tmpDir)
+		if err != nil {
+			return err
+		}
+		for _, group := range groups {
+			var gid *int64
+			gid, err = unix.FindUIDByNameInPasswd(path.Join(tmpDir, "group"), group)
+			if err != nil {
+				return err
+			}
+			if gid == nil {
+				return fmt.Errorf("unable to find group %s: no matching entries in group file", group)
+			}
+			gids = append(gids, *gid)
+		}
+		return nil
+	})
+	return gids, err
 }
 
 func getUserinfoFromImageUID(ctx context.Context, dc *dockerClient.Client, containerID, tmpDir string, user *docker.Userinfo) error {
@@ -57,6 +57,8 @@ type bindMountHostFileToTarHelper struct {
 	rootHostFile           string
 	rootHostFileVol        string
 	rootHostFileWithoutVol string
+	// If not nil, files for which exclude returns true are not written to the tar (including the files of excluded directories).
+	exclude func(fileNameInTar string) (bool, error)
 }
 
 func (h *bindMountHostFileToTarHelper) runRegular(fileInfo os.FileInfo, hostFile, fileNameInTar string) error {
@@ -159,8 +161,10 @@ func (h *bindMountHostFileToTarHelper) runSymlink(fileInfo os.FileInfo, hostFile
 		// Convert the target to an absolute path within the tar, normalising slashes.
 		linkResolvedInTar := filepath.ToSlash(h.renameTo + linkResolved[len(h.rootHostFile):])
 		// Convert the target to a relative path within the tar. This can be done a bit more efficiently since we know the paths are
-		// relative, cleaned and slashed. We assign the error to underscore because it should never happen.
+		// relative, cleaned and slashed. We assign the error to underscore because it should never happen. On Windows filepath.Rel
+		// returns a path with backslashes, but link targets in the tar must be slash separated.
 		linkResolvedInTarRel, _ := filepath.Rel(filepath.Dir(fileNameInTar), linkResolvedInTar)
+		linkResolvedInTarRel = filepath.ToSlash(linkResolvedInTarRel)
 		header, err := tarFileInfoHeader(fileInfo, linkResolvedInTarRel)
 		if err != nil {
 			return err
@@ -172,6 +176,12 @@ func (h *bindMountHostFileToTarHelper) runSymlink(fileInfo os.FileInfo, hostFile
 }
 
 func (h *bindMountHostFileToTarHelper) runRecursive(fileInfo os.FileInfo, hostFile, fileNameInTar string) error {
+	if h.exclude != nil {
+		excluded, err := h.exclude(fileNameInTar)
+		if err != nil || excluded {
+			return err
+		}
+	}
 	switch {
 	case (fileInfo.Mode() & os.ModeSymlink) != 0:
 		// Symlink...
@@ -3,12 +3,14 @@ package up
 import (
 	"archive/tar"
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"reflect"
 	"testing"
 
 	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/pkg/docker/dockertest"
 	"github.com/pkg/errors"
 )
 
@@ -515,3 +517,25 @@ func Test_BuildVolumeInitImageGetBuildContext_BindMouseHostFileToTarError(t *tes
 		}
 	})
 }
+
+func Test_BuildVolumeInitImage_Success(t *testing.T) {
+	d := dockertest.NewDaemon()
+	defer d.Close()
+	dc, err := d.NewClient()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	withMockFS(vfs, func() {
+		var result *buildVolumeInitImageResult
+		result, err = buildVolumeInitImage(context.Background(), dc, []string{"orig"}, "ubuntu:latest")
+		builds := d.Builds()
+		if err != nil || len(builds) != 1 || result.imageID != builds[0].ImageID {
+			t.Error(result, err)
+			return
+		}
+		if *builds[0].BuildArgs["BASE_IMAGE"] != "ubuntu:latest" {
+			t.Error(builds[0].BuildArgs)
+		}
+	})
+}
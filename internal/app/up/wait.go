@@ -0,0 +1,54 @@
+package up
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// createDependencyWaitInitContainers creates an init container for each dependency of the app, if depends_on is enforced by init
+// containers. Each init container waits until the Kubernetes service of the dependency accepts TCP connections. Since Kubernetes
+// services only route to ready pods, this waits for the dependency to be healthy, and for dependencies without healthchecks this waits
+// for the dependency to be started. Dependencies without TCP ports cannot be waited for.
+func (u *upRunner) createDependencyWaitInitContainers(a *app) []v1.Container {
+	if u.opts.DependencyWaitMode != DependencyWaitModeInitContainer {
+		return nil
+	}
+	dependsOn, _ := a.getPodDependsOn()
+	names := make([]string, 0, len(dependsOn))
+	for name := range dependsOn {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var initContainers []v1.Container
+	for _, name := range names {
+		composeService := u.cfg.Services[name]
+		port := int32(-1)
+		for _, p := range composeService.Ports {
+			if p.Protocol == "tcp" {
+				port = p.Port
+				break
+			}
+		}
+		if port < 0 {
+			a.newLogEntry().Warnf("cannot wait for depends_on service %s in an init container, because it has no TCP ports", name)
+			continue
+		}
+		host := k8smeta.GetK8sHost(u.cfg, a.composeService, composeService)
+		initContainers = append(initContainers, v1.Container{
+			Name:            util.TruncateName("wait-for-"+composeService.NameEscaped, validation.DNS1123LabelMaxLength),
+			Image:           u.cfg.WaitForImage,
+			SecurityContext: getMeshInitContainerSecurityContext(u.opts.Mesh),
+			Command: []string{
+				"sh",
+				"-c",
+				fmt.Sprintf("until nc -z %s %d; do echo waiting for %s; sleep 1; done", host, port, name),
+			},
+		})
+	}
+	return initContainers
+}
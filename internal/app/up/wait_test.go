@@ -0,0 +1,73 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+)
+
+func newTestUpRunnerDependencyWaitModeInitContainer() *upRunner {
+	u := newTestUpRunnerWithAppsToBeStarted()
+	u.opts.DependencyWaitMode = DependencyWaitModeInitContainer
+	u.cfg.EnvironmentID = "myenv"
+	u.cfg.WaitForImage = "busybox:latest"
+	u.cfg.Services["c"].Ports = []config.Port{
+		{
+			Port:     53,
+			Protocol: "udp",
+		},
+		{
+			Port:     8080,
+			Protocol: "tcp",
+		},
+	}
+	return u
+}
+
+func TestCreateDependencyWaitInitContainers_Success(t *testing.T) {
+	u := newTestUpRunnerDependencyWaitModeInitContainer()
+	initContainers := u.createDependencyWaitInitContainers(u.apps["a"])
+	// d has no ports, so it cannot be waited for.
+	if len(initContainers) != 1 {
+		t.Fail()
+	} else {
+		c := initContainers[0]
+		expectedCommand := []string{"sh", "-c", "until nc -z c-myenv 8080; do echo waiting for c; sleep 1; done"}
+		if c.Name != "wait-for-c" || c.Image != "busybox:latest" || !reflect.DeepEqual(c.Command, expectedCommand) {
+			t.Error(c)
+		}
+	}
+}
+
+func TestCreateDependencyWaitInitContainers_ClientMode(t *testing.T) {
+	u := newTestUpRunnerDependencyWaitModeInitContainer()
+	u.opts.DependencyWaitMode = DependencyWaitModeClient
+	initContainers := u.createDependencyWaitInitContainers(u.apps["a"])
+	if len(initContainers) != 0 {
+		t.Fail()
+	}
+}
+
+func TestGetAppsThatCanBeStarted_InitContainerMode(t *testing.T) {
+	u := newTestUpRunnerDependencyWaitModeInitContainer()
+	wave, err := u.getAppsThatCanBeStarted()
+	if err != nil {
+		t.Error(err)
+	}
+	names := waveToNameSet(wave)
+	if len(names) != 3 || !names["b"] || !names["c"] || !names["d"] {
+		t.Error(names)
+	}
+}
+
+func TestGetAppsThatCanBeStarted_InitContainerModeDoesNotWaitForStatus(t *testing.T) {
+	u := newTestUpRunnerDependencyWaitModeInitContainer()
+	for _, name := range []string{"b", "c", "d"} {
+		delete(u.appsToBeStarted, u.apps[name])
+	}
+	wave, err := u.getAppsThatCanBeStarted()
+	if err != nil || len(wave) != 1 || wave[0].name() != "a" {
+		t.Fail()
+	}
+}
@@ -0,0 +1,52 @@
+package watch
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// EventType is the type of an Event.
+type EventType string
+
+const (
+	// EventWatching means that the watched paths have been snapshotted and are being watched for changes.
+	EventWatching EventType = "watching"
+	// EventTriggered means that files of a watched path of a service changed.
+	EventTriggered EventType = "triggered"
+	// EventSynced means that the changed files of a watched path were synced into the container of a service.
+	EventSynced EventType = "synced"
+	// EventRestarting means that the container of a service is being restarted.
+	EventRestarting EventType = "restarting"
+	// EventRestarted means that the container of a service was restarted and is running.
+	EventRestarted EventType = "restarted"
+	// EventError means that syncing or restarting a service failed. Watching continues.
+	EventError EventType = "error"
+)
+
+// Event is a machine-readable event of watch (see Options.Events).
+type Event struct {
+	Changed []string `json:"changed,omitempty"`
+	Error   string   `json:"error,omitempty"`
+	// The watched path of the develop.watch rule, if any.
+	Path    string   `json:"path,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Service string   `json:"service,omitempty"`
+	// The watched services, if Type is EventWatching.
+	Services []string  `json:"services,omitempty"`
+	Time     time.Time `json:"time"`
+	Type     EventType `json:"type"`
+}
+
+// emitEvent writes an event to the events writer of the options, if there is one. Events are written as JSON Lines.
+func (w *watchRunner) emitEvent(event *Event) {
+	if w.opts.Events == nil {
+		return
+	}
+	event.Time = time.Now()
+	err := json.NewEncoder(w.opts.Events).Encode(event)
+	if err != nil {
+		log.Error(err)
+	}
+}
@@ -0,0 +1,54 @@
+package watch
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// executor executes commands in containers, to improve testability of code.
+type executor interface {
+	exec(namespace, podName, containerName string, command []string, stdin io.Reader) error
+}
+
+type k8sExecutor struct {
+	cfg          *config.Config
+	k8sClientset *kubernetes.Clientset
+}
+
+func (e *k8sExecutor) exec(namespace, podName, containerName string, command []string, stdin io.Reader) error {
+	req := e.k8sClientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Command:   command,
+			Container: containerName,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	exec, err := remotecommand.NewSPDYExecutor(e.cfg.KubeConfig, "POST", req.URL())
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	var output bytes.Buffer
+	err = exec.Stream(remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: &output,
+		Stderr: &output,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error while executing %q in container %s of pod %s: %s", command, containerName, podName,
+			bytes.TrimSpace(output.Bytes()))
+	}
+	return nil
+}
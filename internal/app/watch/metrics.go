@@ -0,0 +1,33 @@
+package watch
+
+import (
+	"github.com/kube-compose/kube-compose/internal/pkg/metrics"
+)
+
+const (
+	reconcileResultRestarted = "restarted"
+	reconcileResultSynced    = "synced"
+)
+
+// watchMetrics are the metrics of watch. They have the names of the metrics of up, so that dashboards work for both commands.
+type watchMetrics struct {
+	readinessSeconds *metrics.HistogramVec
+	reconciles       *metrics.CounterVec
+}
+
+func newWatchMetrics(r *metrics.Registry) *watchMetrics {
+	return &watchMetrics{
+		readinessSeconds: r.NewHistogramVec(
+			"kube_compose_readiness_latency_seconds",
+			"Time between recreating the pod of a restarted docker compose service and observing that its container is running.",
+			metrics.DefaultDurationBuckets,
+			"service",
+		),
+		reconciles: r.NewCounterVec(
+			"kube_compose_reconciles_total",
+			"Number of times the container of a docker compose service was reconciled with the watched files, partitioned by result.",
+			"service",
+			"result",
+		),
+	}
+}
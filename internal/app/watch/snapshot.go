@@ -0,0 +1,121 @@
+package watch
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+// rootKey is the key of a snapshot if the watched path is a regular file instead of a directory.
+const rootKey = "."
+
+type fileState struct {
+	mode    os.FileMode
+	modTime time.Time
+	size    int64
+}
+
+// snapshot maps slash separated paths relative to the watched path to the state of regular files.
+type snapshot map[string]fileState
+
+// isIgnored determines whether the slash separated relative path rel matches any of the ignore patterns. A pattern matches a path if
+// it matches the path or any of its parent directories, so that a pattern such as "node_modules/" ignores all files in node_modules.
+// Like in .gitignore files, a pattern without slashes is matched against base names, so that "*.tmp" ignores temporary files in all
+// directories. On Windows backslashes in patterns are separators, so that patterns written for Windows match slash separated paths.
+func isIgnored(rel string, ignore []string) bool {
+	for _, pattern := range ignore {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		matchBase := !strings.Contains(pattern, "/")
+		for p := rel; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+			name := p
+			if matchBase {
+				name = path.Base(p)
+			}
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// takeSnapshot takes a snapshot of the regular files in root that are not ignored. If root does not exist then the snapshot is empty.
+func takeSnapshot(root string, ignore []string) (snapshot, error) {
+	s := snapshot{}
+	fileInfo, err := fs.OS.Lstat(root)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if !fileInfo.IsDir() {
+		if fileInfo.Mode().IsRegular() {
+			s[rootKey] = newFileState(fileInfo)
+		}
+		return s, nil
+	}
+	err = takeSnapshotDir(s, root, "", ignore)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func takeSnapshotDir(s snapshot, dir, rel string, ignore []string) error {
+	fd, err := fs.OS.Open(dir)
+	if err != nil {
+		return err
+	}
+	fileInfos, err := fd.Readdir(-1)
+	_ = fd.Close()
+	if err != nil && err != io.EOF {
+		return err
+	}
+	for _, fileInfo := range fileInfos {
+		childRel := path.Join(rel, fileInfo.Name())
+		if isIgnored(childRel, ignore) {
+			continue
+		}
+		switch {
+		case fileInfo.IsDir():
+			err = takeSnapshotDir(s, filepath.Join(dir, fileInfo.Name()), childRel, ignore)
+			if err != nil {
+				return err
+			}
+		case fileInfo.Mode().IsRegular():
+			s[childRel] = newFileState(fileInfo)
+		}
+	}
+	return nil
+}
+
+func newFileState(fileInfo os.FileInfo) fileState {
+	return fileState{
+		mode:    fileInfo.Mode(),
+		modTime: fileInfo.ModTime(),
+		size:    fileInfo.Size(),
+	}
+}
+
+// diffSnapshots returns the sorted paths of files that were created or changed, and the sorted paths of files that were removed.
+func diffSnapshots(old, current snapshot) (changed, removed []string) {
+	for rel, state := range current {
+		if oldState, ok := old[rel]; !ok || oldState != state {
+			changed = append(changed, rel)
+		}
+	}
+	for rel := range old {
+		if _, ok := current[rel]; !ok {
+			removed = append(removed, rel)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return
+}
@@ -0,0 +1,89 @@
+package watch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func withMockFS(vfs fs.VirtualFileSystem, cb func()) {
+	orig := fs.OS
+	defer func() {
+		fs.OS = orig
+	}()
+	fs.OS = vfs
+	cb()
+}
+
+func TestIsIgnored_Success(t *testing.T) {
+	ignore := []string{"node_modules/", "*.tmp"}
+	if !isIgnored("node_modules", ignore) || !isIgnored("node_modules/a/b.js", ignore) || !isIgnored("a/b.tmp", ignore) {
+		t.Fail()
+	}
+	if isIgnored("src/index.js", ignore) {
+		t.Fail()
+	}
+}
+
+func TestTakeSnapshot_Dir(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/src/a.js": {
+			Content: []byte("a"),
+		},
+		"/src/lib/b.js": {
+			Content: []byte("bb"),
+		},
+		"/src/node_modules/c.js": {
+			Content: []byte("ccc"),
+		},
+	}), func() {
+		s, err := takeSnapshot("/src", []string{"node_modules/"})
+		if err != nil {
+			t.Error(err)
+		} else if len(s) != 2 || s["a.js"].size != 1 || s["lib/b.js"].size != 2 {
+			t.Error(s)
+		}
+	})
+}
+
+func TestTakeSnapshot_File(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/package.json": {
+			Content: []byte("{}"),
+		},
+	}), func() {
+		s, err := takeSnapshot("/package.json", nil)
+		if err != nil {
+			t.Error(err)
+		} else if len(s) != 1 || s[rootKey].size != 2 {
+			t.Error(s)
+		}
+	})
+}
+
+func TestTakeSnapshot_DoesNotExist(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{}), func() {
+		s, err := takeSnapshot("/src", nil)
+		if err != nil || len(s) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestDiffSnapshots_Success(t *testing.T) {
+	old := snapshot{
+		"a": {size: 1},
+		"b": {size: 1},
+		"c": {size: 1},
+	}
+	current := snapshot{
+		"a": {size: 1},
+		"b": {size: 2},
+		"d": {size: 1},
+	}
+	changed, removed := diffSnapshots(old, current)
+	if !reflect.DeepEqual(changed, []string{"b", "d"}) || !reflect.DeepEqual(removed, []string{"c"}) {
+		t.Error(changed, removed)
+	}
+}
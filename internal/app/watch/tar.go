@@ -0,0 +1,72 @@
+package watch
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+// getContainerPath returns the absolute path in the container of the file with slash separated path rel relative to the watched path.
+func getContainerPath(target, rel string) string {
+	if rel == rootKey {
+		return path.Join("/", target)
+	}
+	return path.Join("/", target, rel)
+}
+
+// getHostPath returns the path on the host of the file with slash separated path rel relative to the watched path root.
+func getHostPath(root, rel string) string {
+	if rel == rootKey {
+		return root
+	}
+	return filepath.Join(root, filepath.FromSlash(rel))
+}
+
+// createTarArchive creates a tar archive of the files with slash separated paths rels relative to root. Names of members are the paths
+// in the container relative to "/", so that the archive can be extracted by running tar in "/".
+func createTarArchive(root, target string, rels []string) (*bytes.Buffer, error) {
+	buffer := &bytes.Buffer{}
+	tarWriter := tar.NewWriter(buffer)
+	for _, rel := range rels {
+		hostPath := getHostPath(root, rel)
+		fileInfo, err := fs.OS.Lstat(hostPath)
+		if err != nil {
+			return nil, err
+		}
+		fd, err := fs.OS.Open(hostPath)
+		if err != nil {
+			return nil, err
+		}
+		// The file is read entirely before writing the header, so that the header has the correct size even if the file is being
+		// written to.
+		data, err := ioutil.ReadAll(fd)
+		_ = fd.Close()
+		if err != nil {
+			return nil, err
+		}
+		err = tarWriter.WriteHeader(&tar.Header{
+			Name:     strings.TrimPrefix(getContainerPath(target, rel), "/"),
+			Mode:     int64(fileInfo.Mode().Perm()),
+			ModTime:  fileInfo.ModTime(),
+			Size:     int64(len(data)),
+			Typeflag: tar.TypeReg,
+		})
+		if err != nil {
+			return nil, err
+		}
+		_, err = tarWriter.Write(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := tarWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buffer, nil
+}
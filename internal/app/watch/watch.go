@@ -0,0 +1,455 @@
+package watch
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/metrics"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+// The interval at which watched paths are polled for changes.
+const pollInterval = 250 * time.Millisecond
+
+// The interval at which pods are polled when waiting for a pod to be recreated. It is a variable to improve testability.
+var podPollInterval = 500 * time.Millisecond
+
+// The maximum time that is waited until the pod of a restarted service is deleted (in addition to its grace period), and until its
+// container is running again. It is a variable to improve testability.
+var restartTimeout = 5 * time.Minute
+
+type watchedRule struct {
+	rule     *dockerComposeConfig.WatchRule
+	snapshot snapshot
+}
+
+type watchedService struct {
+	namespace string
+	podName   string
+	rules     []*watchedRule
+	service   *config.Service
+}
+
+// Options are the options of Run.
+type Options struct {
+	// If not nil, machine-readable events about triggers, syncs and restarts are written to this writer as JSON Lines (see Event), so
+	// that tools can drive watch.
+	Events io.Writer
+	// If not nil, metrics about syncs and restarts are registered in this registry.
+	Metrics *metrics.Registry
+}
+
+type watchRunner struct {
+	cfg               *config.Config
+	executor          executor
+	k8sClientset      kubernetes.Interface
+	k8sCoreRESTClient rest.Interface
+	metrics           *watchMetrics
+	opts              *Options
+	services          []*watchedService
+}
+
+func (w *watchRunner) initKubernetesClientset() error {
+	k8sClientset, err := kubernetes.NewForConfig(w.cfg.KubeConfig)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	w.executor = &k8sExecutor{
+		cfg:          w.cfg,
+		k8sClientset: k8sClientset,
+	}
+	w.k8sClientset = k8sClientset
+	w.k8sCoreRESTClient = k8sClientset.CoreV1().RESTClient()
+	return nil
+}
+
+// podClient returns the client of pods in a namespace.
+func (w *watchRunner) podClient(namespace string) clientV1.PodInterface {
+	return w.k8sClientset.CoreV1().Pods(namespace)
+}
+
+// initServices determines the services and rules to watch, and takes the initial snapshots of the watched paths.
+func (w *watchRunner) initServices() error {
+	names := make([]string, 0, len(w.cfg.Services))
+	for name := range w.cfg.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		service := w.cfg.Services[name]
+		if !w.cfg.MatchesFilterDirectly(service) {
+			continue
+		}
+		ws := &watchedService{
+			namespace: w.cfg.GetNamespace(service),
+			podName:   k8smeta.GetK8sName(service.PodService(), w.cfg),
+			service:   service,
+		}
+		for i := range service.DockerComposeService.Watch {
+			rule := &service.DockerComposeService.Watch[i]
+			if rule.Action == dockerComposeConfig.WatchActionRebuild {
+				log.Warnf("ignoring develop.watch rule of service %s for path %s, because the action rebuild is not supported (run up "+
+					"again to rebuild images)", name, rule.Path)
+				continue
+			}
+			s, err := takeSnapshot(rule.Path, rule.Ignore)
+			if err != nil {
+				return err
+			}
+			ws.rules = append(ws.rules, &watchedRule{
+				rule:     rule,
+				snapshot: s,
+			})
+		}
+		if len(ws.rules) > 0 {
+			w.services = append(w.services, ws)
+		}
+	}
+	if len(w.services) == 0 {
+		return exitcode.Wrap(fmt.Errorf("none of the services have a develop.watch rule with action %s or %s",
+			dockerComposeConfig.WatchActionSync, dockerComposeConfig.WatchActionSyncRestart), exitcode.Config)
+	}
+	return nil
+}
+
+// syncFiles copies the changed files into the container of a service, and removes the removed files from the container.
+func (w *watchRunner) syncFiles(ws *watchedService, rule *dockerComposeConfig.WatchRule, changed, removed []string) error {
+	if len(changed) > 0 {
+		archive, err := createTarArchive(rule.Path, rule.Target, changed)
+		if err != nil {
+			return err
+		}
+		err = w.executor.exec(ws.namespace, ws.podName, k8smeta.GetShortName(ws.service), []string{"tar", "-xmf", "-", "-C", "/"}, archive)
+		if err != nil {
+			return err
+		}
+	}
+	if len(removed) > 0 {
+		command := []string{"rm", "-f", "--"}
+		for _, rel := range removed {
+			command = append(command, getContainerPath(rule.Target, rel))
+		}
+		err := w.executor.exec(ws.namespace, ws.podName, k8smeta.GetShortName(ws.service), command, nil)
+		if err != nil {
+			return err
+		}
+	}
+	log.Infof("synced %d changed and %d removed file(s) of %s into service %s", len(changed), len(removed), rule.Path,
+		ws.service.Name())
+	w.metrics.reconciles.Inc(ws.service.Name(), reconcileResultSynced)
+	w.emitEvent(&Event{
+		Changed: changed,
+		Path:    rule.Path,
+		Removed: removed,
+		Service: ws.service.Name(),
+		Type:    EventSynced,
+	})
+	return nil
+}
+
+// pollService synchronizes the changes to the watched paths of a service, and restarts the service if required.
+func (w *watchRunner) pollService(ws *watchedService) error {
+	restart := false
+	for _, wr := range ws.rules {
+		current, err := takeSnapshot(wr.rule.Path, wr.rule.Ignore)
+		if err != nil {
+			return err
+		}
+		changed, removed := diffSnapshots(wr.snapshot, current)
+		if len(changed) == 0 && len(removed) == 0 {
+			continue
+		}
+		// The snapshot is updated even if syncing fails, so that a failure is not retried until the files change again.
+		wr.snapshot = current
+		w.emitEvent(&Event{
+			Changed: changed,
+			Path:    wr.rule.Path,
+			Removed: removed,
+			Service: ws.service.Name(),
+			Type:    EventTriggered,
+		})
+		err = w.syncFiles(ws, wr.rule, changed, removed)
+		if err != nil {
+			return err
+		}
+		if wr.rule.Action == dockerComposeConfig.WatchActionSyncRestart {
+			restart = true
+		}
+	}
+	if restart {
+		err := w.restartService(ws)
+		if err != nil {
+			return err
+		}
+		return w.restartDependents(ws.service)
+	}
+	return nil
+}
+
+// getRestartDependents returns the services that (indirectly) depend on a service with restart: true, in an order in which each
+// service comes after the dependencies through which it was found.
+func (w *watchRunner) getRestartDependents(service *config.Service) []*config.Service {
+	names := make([]string, 0, len(w.cfg.Services))
+	for name := range w.cfg.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var dependents []*config.Service
+	queue := []*config.Service{service}
+	for len(queue) > 0 {
+		service1 := queue[0]
+		queue = queue[1:]
+		for _, name := range names {
+			service2 := w.cfg.Services[name]
+			if !service2.DockerComposeService.DependsOnRestart[service1.Name()] {
+				continue
+			}
+			// Move service2 to the end, so that it is restarted after all dependencies through which it was found.
+			for i, dependent := range dependents {
+				if dependent == service2 {
+					dependents = append(dependents[:i], dependents[i+1:]...)
+					break
+				}
+			}
+			dependents = append(dependents, service2)
+			queue = append(queue, service2)
+		}
+	}
+	return dependents
+}
+
+// restartDependents restarts the services that (indirectly) depend on a restarted service with restart: true. Dependents that were not
+// deployed are skipped.
+func (w *watchRunner) restartDependents(service *config.Service) error {
+	for _, dependent := range w.getRestartDependents(service) {
+		ws := w.getWatchedService(dependent)
+		_, err := w.podClient(ws.namespace).Get(ws.podName, metav1.GetOptions{})
+		if k8sError.IsNotFound(err) {
+			log.Debugf("not restarting service %s, because it has no pod", dependent.Name())
+			continue
+		} else if err != nil {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		err = w.restartService(ws)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getWatchedService returns the watched service of a service, so that watched files are synced into a restarted container. If the
+// service is not watched then a watched service without rules is returned.
+func (w *watchRunner) getWatchedService(service *config.Service) *watchedService {
+	for _, ws := range w.services {
+		if ws.service == service {
+			return ws
+		}
+	}
+	return &watchedService{
+		namespace: w.cfg.GetNamespace(service),
+		podName:   k8smeta.GetK8sName(service.PodService(), w.cfg),
+		service:   service,
+	}
+}
+
+// restartService restarts the container of a service. Kubernetes cannot restart containers on demand, so the service's pod is
+// recreated from its spec. Files synced into the old container are lost, so all watched files are synced into the new container.
+func (w *watchRunner) restartService(ws *watchedService) error {
+	pod, err := w.podClient(ws.namespace).Get(ws.podName, metav1.GetOptions{})
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	err = k8smeta.ValidateOwnership(w.cfg, "Pod", &pod.ObjectMeta)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.Config)
+	}
+	log.Infof("restarting service %s", ws.service.Name())
+	w.emitEvent(&Event{
+		Service: ws.service.Name(),
+		Type:    EventRestarting,
+	})
+	err = w.podClient(ws.namespace).Delete(ws.podName, &metav1.DeleteOptions{
+		GracePeriodSeconds: k8smeta.GetGracePeriodSeconds(ws.service),
+		Preconditions: &metav1.Preconditions{
+			UID: &pod.UID,
+		},
+	})
+	if err != nil && !k8sError.IsNotFound(err) {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	err = w.waitForPodDeleted(pod, k8smeta.GetGracePeriodSeconds(ws.service))
+	if err != nil {
+		return err
+	}
+	podNew := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: pod.ObjectMeta.Annotations,
+			Labels:      pod.ObjectMeta.Labels,
+			Name:        pod.ObjectMeta.Name,
+		},
+		Spec: pod.Spec,
+	}
+	// Let the scheduler pick a node again, in case the old node is no longer available.
+	podNew.Spec.NodeName = ""
+	_, err = k8smeta.CreatePod(w.podClient(ws.namespace), w.k8sCoreRESTClient, ws.namespace, podNew)
+	if err != nil {
+		return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+	}
+	podCreationTime := time.Now()
+	err = w.waitForContainerRunning(ws)
+	if err != nil {
+		return err
+	}
+	w.metrics.readinessSeconds.Observe(time.Since(podCreationTime).Seconds(), ws.service.Name())
+	w.metrics.reconciles.Inc(ws.service.Name(), reconcileResultRestarted)
+	w.emitEvent(&Event{
+		Service: ws.service.Name(),
+		Type:    EventRestarted,
+	})
+	for _, wr := range ws.rules {
+		changed, _ := diffSnapshots(snapshot{}, wr.snapshot)
+		if len(changed) > 0 {
+			err = w.syncFiles(ws, wr.rule, changed, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// waitForPodDeleted waits until a pod no longer exists, for at most its grace period plus restartTimeout.
+func (w *watchRunner) waitForPodDeleted(pod *v1.Pod, gracePeriodSeconds *int64) error {
+	timeout := restartTimeout
+	if gracePeriodSeconds != nil {
+		timeout += time.Duration(*gracePeriodSeconds) * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		podServer, err := w.podClient(pod.ObjectMeta.Namespace).Get(pod.ObjectMeta.Name, metav1.GetOptions{})
+		if k8sError.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		} else if podServer.UID != pod.UID {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return exitcode.Wrap(fmt.Errorf("timed out after %v waiting until pod %s is deleted", timeout, pod.ObjectMeta.Name),
+				exitcode.ReadinessTimeout)
+		}
+		time.Sleep(podPollInterval)
+	}
+}
+
+// checkContainerWaiting returns an error if a container is waiting for a reason that it does not recover from without intervention: its
+// image cannot be pulled or it keeps crashing.
+func checkContainerWaiting(ws *watchedService, containerStatus *v1.ContainerStatus) error {
+	waiting := containerStatus.State.Waiting
+	if waiting == nil {
+		return nil
+	}
+	switch waiting.Reason {
+	case "ErrImagePull", "ImagePullBackOff":
+		return exitcode.Wrap(fmt.Errorf("container %s of pod %s could not pull image: %s", containerStatus.Name, ws.podName, waiting.Message),
+			exitcode.ImageTransfer)
+	case "CrashLoopBackOff":
+		return exitcode.Wrap(fmt.Errorf("container %s of pod %s keeps crashing while restarting service %s", containerStatus.Name,
+			ws.podName, ws.service.Name()), exitcode.ContainerFailure)
+	}
+	return nil
+}
+
+// waitForContainerRunning waits until the container of a restarted service is running, for at most restartTimeout.
+func (w *watchRunner) waitForContainerRunning(ws *watchedService) error {
+	deadline := time.Now().Add(restartTimeout)
+	for {
+		pod, err := w.podClient(ws.namespace).Get(ws.podName, metav1.GetOptions{})
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.ClusterConnectivity)
+		}
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			return exitcode.Wrap(fmt.Errorf("pod %s of service %s terminated while restarting", ws.podName, ws.service.Name()),
+				exitcode.ContainerFailure)
+		}
+		for i := range pod.Status.ContainerStatuses {
+			containerStatus := &pod.Status.ContainerStatuses[i]
+			if containerStatus.Name != k8smeta.GetShortName(ws.service) {
+				continue
+			}
+			if containerStatus.State.Running != nil {
+				return nil
+			}
+			err = checkContainerWaiting(ws, containerStatus)
+			if err != nil {
+				return err
+			}
+		}
+		if time.Now().After(deadline) {
+			return exitcode.Wrap(fmt.Errorf("timed out after %v waiting until the container of service %s is running", restartTimeout,
+				ws.service.Name()), exitcode.ReadinessTimeout)
+		}
+		time.Sleep(podPollInterval)
+	}
+}
+
+func (w *watchRunner) run() error {
+	err := w.initServices()
+	if err != nil {
+		return err
+	}
+	err = w.initKubernetesClientset()
+	if err != nil {
+		return err
+	}
+	log.Infof("watching %d service(s) for changes", len(w.services))
+	event := &Event{
+		Type: EventWatching,
+	}
+	for _, ws := range w.services {
+		event.Services = append(event.Services, ws.service.Name())
+	}
+	w.emitEvent(event)
+	for {
+		for _, ws := range w.services {
+			err = w.pollService(ws)
+			if err != nil {
+				// Errors are logged instead of returned so that watching continues, e.g. if a container is temporarily not running.
+				log.Error(err)
+				w.emitEvent(&Event{
+					Error:   err.Error(),
+					Service: ws.service.Name(),
+					Type:    EventError,
+				})
+			}
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Run watches the paths of the develop.watch rules of docker compose services, and synchronizes changed files into the running
+// containers of the services. Run only returns if an error occurs during initialization.
+func Run(cfg *config.Config, opts *Options) error {
+	if opts.Metrics == nil {
+		opts.Metrics = metrics.NewRegistry()
+	}
+	w := &watchRunner{
+		cfg:     cfg,
+		metrics: newWatchMetrics(opts.Metrics),
+		opts:    opts,
+	}
+	return w.run()
+}
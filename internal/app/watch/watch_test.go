@@ -0,0 +1,325 @@
+package watch
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/metrics"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type execCall struct {
+	command     []string
+	memberNames []string
+}
+
+type fakeExecutor struct {
+	calls []execCall
+}
+
+func (e *fakeExecutor) exec(namespace, podName, containerName string, command []string, stdin io.Reader) error {
+	call := execCall{
+		command: command,
+	}
+	if stdin != nil {
+		tarReader := tar.NewReader(stdin)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return err
+			}
+			call.memberNames = append(call.memberNames, header.Name)
+		}
+	}
+	e.calls = append(e.calls, call)
+	return nil
+}
+
+func newTestWatchRunner() *watchRunner {
+	cfg := &config.Config{
+		EnvironmentID: "myenv",
+	}
+	service := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "web",
+		Watch: []dockerComposeConfig.WatchRule{
+			{
+				Action: dockerComposeConfig.WatchActionSync,
+				Ignore: []string{"*.tmp"},
+				Path:   "/src",
+				Target: "/app",
+			},
+			{
+				Action: dockerComposeConfig.WatchActionRebuild,
+				Path:   "/package.json",
+			},
+		},
+	})
+	cfg.AddToFilter(service)
+	opts := &Options{
+		Metrics: metrics.NewRegistry(),
+	}
+	return &watchRunner{
+		cfg:      cfg,
+		executor: &fakeExecutor{},
+		metrics:  newWatchMetrics(opts.Metrics),
+		opts:     opts,
+	}
+}
+
+func TestCreateTarArchive_Success(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/src/lib/a.js": {
+			Content: []byte("a"),
+		},
+	}), func() {
+		buffer, err := createTarArchive("/src", "/app", []string{"lib/a.js"})
+		if err != nil {
+			t.Error(err)
+		} else {
+			e := &fakeExecutor{}
+			_ = e.exec("", "", "", nil, buffer)
+			if !reflect.DeepEqual(e.calls[0].memberNames, []string{"app/lib/a.js"}) {
+				t.Error(e.calls[0].memberNames)
+			}
+		}
+	})
+}
+
+func TestInitServices_SkipsRebuildRules(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{}), func() {
+		w := newTestWatchRunner()
+		err := w.initServices()
+		if err != nil {
+			t.Error(err)
+		} else if len(w.services) != 1 || len(w.services[0].rules) != 1 || w.services[0].podName != "web-myenv" {
+			t.Fail()
+		}
+	})
+}
+
+func TestInitServices_NoRules(t *testing.T) {
+	w := newTestWatchRunner()
+	w.cfg.Services["web"].DockerComposeService.Watch = nil
+	err := w.initServices()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestPollService_Success(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/src/a.js": {
+			Content: []byte("a"),
+		},
+		"/src/b.js": {
+			Content: []byte("b"),
+		},
+	})
+	withMockFS(vfs, func() {
+		w := newTestWatchRunner()
+		err := w.initServices()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		// Simulate changes: a.js is modified, b.js is removed and c.js and d.tmp are created.
+		fs.OS = fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+			"/src/a.js": {
+				Content: []byte("aa"),
+			},
+			"/src/c.js": {
+				Content: []byte("c"),
+			},
+			"/src/d.tmp": {
+				Content: []byte("d"),
+			},
+		})
+		err = w.pollService(w.services[0])
+		if err != nil {
+			t.Error(err)
+		}
+		expected := []execCall{
+			{
+				command:     []string{"tar", "-xmf", "-", "-C", "/"},
+				memberNames: []string{"app/a.js", "app/c.js"},
+			},
+			{
+				command: []string{"rm", "-f", "--", "/app/b.js"},
+			},
+		}
+		calls := w.executor.(*fakeExecutor).calls
+		if !reflect.DeepEqual(calls, expected) {
+			t.Error(calls)
+		}
+		// Nothing changed since the last poll.
+		err = w.pollService(w.services[0])
+		if err != nil || len(w.executor.(*fakeExecutor).calls) != 2 {
+			t.Fail()
+		}
+		var buffer bytes.Buffer
+		err = w.opts.Metrics.Write(&buffer)
+		if err != nil || !strings.Contains(buffer.String(), `kube_compose_reconciles_total{service="web",result="synced"} 1`) {
+			t.Error(buffer.String(), err)
+		}
+	})
+}
+
+func TestGetRestartDependents_Success(t *testing.T) {
+	w := newTestWatchRunner()
+	// api and worker restart when web restarts, and worker also restarts when api restarts. cron depends on web without restart.
+	api := w.cfg.AddService(&dockerComposeConfig.Service{
+		Name: "api",
+	})
+	worker := w.cfg.AddService(&dockerComposeConfig.Service{
+		Name: "worker",
+	})
+	cron := w.cfg.AddService(&dockerComposeConfig.Service{
+		Name: "cron",
+	})
+	api.DockerComposeService.DependsOnRestart = map[string]bool{
+		"web": true,
+	}
+	worker.DockerComposeService.DependsOnRestart = map[string]bool{
+		"api": true,
+		"web": true,
+	}
+	cron.DockerComposeService.DependsOn = map[string]dockerComposeConfig.ServiceHealthiness{
+		"web": dockerComposeConfig.ServiceStarted,
+	}
+	dependents := w.getRestartDependents(w.cfg.Services["web"])
+	if len(dependents) != 2 || dependents[0] != api || dependents[1] != worker {
+		t.Error(dependents)
+	}
+}
+
+func TestGetWatchedService_NotWatched(t *testing.T) {
+	w := newTestWatchRunner()
+	ws := w.getWatchedService(w.cfg.Services["web"])
+	if ws.podName != "web-myenv" || len(ws.rules) != 0 {
+		t.Fail()
+	}
+}
+
+func TestPollService_Events(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{}), func() {
+		w := newTestWatchRunner()
+		var buffer bytes.Buffer
+		w.opts.Events = &buffer
+		err := w.initServices()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		fs.OS = fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+			"/src/a.js": {
+				Content: []byte("a"),
+			},
+		})
+		err = w.pollService(w.services[0])
+		if err != nil {
+			t.Error(err)
+		}
+		decoder := json.NewDecoder(&buffer)
+		for _, eventType := range []EventType{EventTriggered, EventSynced} {
+			event := &Event{}
+			err = decoder.Decode(event)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if event.Type != eventType || event.Service != "web" || event.Path != "/src" ||
+				!reflect.DeepEqual(event.Changed, []string{"a.js"}) {
+				t.Error(event)
+			}
+		}
+		if decoder.More() {
+			t.Fail()
+		}
+	})
+}
+
+// newTestRestartedPod returns the pod of the service of newTestWatchRunner, whose container is waiting for a reason.
+func newTestRestartedPod(reason string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-myenv",
+			Namespace: "default",
+		},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name: "web",
+					State: v1.ContainerState{
+						Waiting: &v1.ContainerStateWaiting{
+							Reason: reason,
+						},
+					},
+				},
+			},
+			Phase: v1.PodPending,
+		},
+	}
+}
+
+// withShortRestartTimeout runs cb with waiting for restarted pods timing out quickly.
+func withShortRestartTimeout(cb func()) {
+	origPollInterval, origTimeout := podPollInterval, restartTimeout
+	defer func() {
+		podPollInterval, restartTimeout = origPollInterval, origTimeout
+	}()
+	podPollInterval, restartTimeout = time.Millisecond, 10*time.Millisecond
+	cb()
+}
+
+func TestWaitForContainerRunning_WaitingErrors(t *testing.T) {
+	testCases := []struct {
+		reason string
+		code   exitcode.Code
+	}{
+		{reason: "ErrImagePull", code: exitcode.ImageTransfer},
+		{reason: "ImagePullBackOff", code: exitcode.ImageTransfer},
+		{reason: "CrashLoopBackOff", code: exitcode.ContainerFailure},
+		{reason: "ContainerCreating", code: exitcode.ReadinessTimeout},
+	}
+	withShortRestartTimeout(func() {
+		for _, testCase := range testCases {
+			w := newTestWatchRunner()
+			w.k8sClientset = fake.NewSimpleClientset(newTestRestartedPod(testCase.reason))
+			ws := &watchedService{
+				namespace: "default",
+				podName:   "web-myenv",
+				service:   w.cfg.Services["web"],
+			}
+			err := w.waitForContainerRunning(ws)
+			if exitcode.FromError(err) != testCase.code {
+				t.Error(testCase.reason, err)
+			}
+		}
+	})
+}
+
+func TestWaitForPodDeleted_Timeout(t *testing.T) {
+	withShortRestartTimeout(func() {
+		w := newTestWatchRunner()
+		pod := newTestRestartedPod("")
+		w.k8sClientset = fake.NewSimpleClientset(pod)
+		err := w.waitForPodDeleted(pod, nil)
+		if exitcode.FromError(err) != exitcode.ReadinessTimeout {
+			t.Error(err)
+		}
+	})
+}
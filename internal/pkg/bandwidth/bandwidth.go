@@ -0,0 +1,116 @@
+// Package bandwidth limits the throughput of network transfers, so that kube-compose does not saturate shared network links.
+package bandwidth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	units "github.com/docker/go-units"
+	"golang.org/x/time/rate"
+)
+
+// minBurst is the minimum number of bytes that are transferred at once, so that low limits do not result in tiny reads.
+const minBurst = 32 << 10
+
+// ParseRate parses a rate in bytes per second, such as 10MB, 512KiB or 1.5MB/s.
+func ParseRate(s string) (int64, error) {
+	bytesPerSecond, err := units.RAMInBytes(strings.TrimSuffix(s, "/s"))
+	if err != nil || bytesPerSecond <= 0 {
+		return 0, fmt.Errorf("the rate %#v must be a positive number of bytes per second, such as 10MB or 512KiB", s)
+	}
+	return bytesPerSecond, nil
+}
+
+// Limiter limits the combined throughput of all transfers that use it. All methods are safe for concurrent use.
+type Limiter struct {
+	limiter *rate.Limiter
+}
+
+// NewLimiter returns a limiter of bytesPerSecond.
+func NewLimiter(bytesPerSecond int64) *Limiter {
+	burst := int(bytesPerSecond)
+	if burst < minBurst {
+		burst = minBurst
+	}
+	return &Limiter{
+		limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), burst),
+	}
+}
+
+type reader struct {
+	ctx     context.Context
+	limiter *rate.Limiter
+	r       io.Reader
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if burst := r.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return n, err
+}
+
+type readCloser struct {
+	reader
+	closer io.Closer
+}
+
+func (r *readCloser) Close() error {
+	return r.closer.Close()
+}
+
+// Reader returns a reader of r whose reads are throttled. Waiting is aborted when ctx is done.
+func (l *Limiter) Reader(ctx context.Context, r io.Reader) io.Reader {
+	return &reader{
+		ctx:     ctx,
+		limiter: l.limiter,
+		r:       r,
+	}
+}
+
+func (l *Limiter) readCloser(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	return &readCloser{
+		reader: reader{
+			ctx:     ctx,
+			limiter: l.limiter,
+			r:       rc,
+		},
+		closer: rc,
+	}
+}
+
+type transport struct {
+	base    http.RoundTripper
+	limiter *Limiter
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		// RoundTrip must not modify the request, so the body is throttled in a copy.
+		req = req.WithContext(req.Context())
+		req.Body = t.limiter.readCloser(req.Context(), req.Body)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = t.limiter.readCloser(req.Context(), resp.Body)
+	return resp, nil
+}
+
+// Transport returns a transport that throttles the bodies of the requests and responses of base.
+func (l *Limiter) Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{
+		base:    base,
+		limiter: l,
+	}
+}
@@ -0,0 +1,77 @@
+package bandwidth
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRate_Success(t *testing.T) {
+	for s, expected := range map[string]int64{
+		"512KiB":  512 << 10,
+		"10MB":    10 << 20,
+		"1.5MB/s": 3 << 19,
+		"100":     100,
+	} {
+		actual, err := ParseRate(s)
+		if err != nil || actual != expected {
+			t.Error(s, actual, err)
+		}
+	}
+}
+
+func TestParseRate_Invalid(t *testing.T) {
+	for _, s := range []string{"", "fast", "0", "-1MB"} {
+		_, err := ParseRate(s)
+		if err == nil {
+			t.Error(s)
+		}
+	}
+}
+
+func TestLimiter_Reader(t *testing.T) {
+	// The first second of data is read at once (the burst), so reading 1.1 seconds of data takes 0.1 seconds.
+	l := NewLimiter(10 << 20)
+	data := make([]byte, 11<<20)
+	start := time.Now()
+	actual, err := ioutil.ReadAll(l.Reader(context.Background(), bytes.NewReader(data)))
+	elapsed := time.Since(start)
+	if err != nil || len(actual) != len(data) || elapsed < 80*time.Millisecond {
+		t.Error(len(actual), elapsed, err)
+	}
+}
+
+func TestLimiter_ReaderCanceled(t *testing.T) {
+	l := NewLimiter(minBurst)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := ioutil.ReadAll(l.Reader(ctx, bytes.NewReader(make([]byte, 2*minBurst))))
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestLimiter_Transport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write(append(body, '!'))
+	}))
+	defer server.Close()
+	client := &http.Client{
+		Transport: NewLimiter(minBurst).Transport(http.DefaultTransport),
+	}
+	resp, err := client.Post(server.URL, "text/plain", bytes.NewReader([]byte("hello")))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil || string(body) != "hello!" {
+		t.Error(string(body), err)
+	}
+}
@@ -0,0 +1,107 @@
+package digestcache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+)
+
+// DefaultTTL is the default time after which a cached digest is no longer used. Registries may garbage collect manifests that are no
+// longer tagged, so digests are not cached indefinitely.
+const DefaultTTL = 24 * time.Hour
+
+type entry struct {
+	Digest string    `json:"digest"`
+	Time   time.Time `json:"time"`
+}
+
+// Cache is a file of the digests of images in registries, so that repeated runs do not contact registries for images that have not
+// changed. Entries are keyed by the ID of a local image and the reference of the image in a registry (which includes the registry's host).
+// All methods are safe for concurrent use.
+type Cache struct {
+	entries map[string]*entry
+	fs      fs.VirtualFileSystem
+	mutex   sync.Mutex
+	now     func() time.Time
+	path    string
+	ttl     time.Duration
+}
+
+// DefaultPath returns the path of the cache file in the user's cache directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "kube-compose", "digests.json"), nil
+}
+
+// Load loads the cache file at path. A cache file that does not exist is treated as an empty cache.
+func Load(vfs fs.VirtualFileSystem, path string, ttl time.Duration) (*Cache, error) {
+	c := &Cache{
+		entries: map[string]*entry{},
+		fs:      vfs,
+		now:     time.Now,
+		path:    path,
+		ttl:     ttl,
+	}
+	fd, err := vfs.Open(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(fd)
+	err = json.NewDecoder(fd).Decode(&c.entries)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func key(imageID, ref string) string {
+	return imageID + " " + ref
+}
+
+// Get returns the cached digest of a local image in a registry, and whether it is cached and has not expired.
+func (c *Cache) Get(imageID, ref string) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e := c.entries[key(imageID, ref)]
+	if e == nil || c.now().Sub(e.Time) >= c.ttl {
+		return "", false
+	}
+	return e.Digest, true
+}
+
+// Put caches the digest of a local image in a registry, and writes the cache file. Expired entries are removed from the cache file.
+func (c *Cache) Put(imageID, ref, digest string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now()
+	for k, e := range c.entries {
+		if now.Sub(e.Time) >= c.ttl {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key(imageID, ref)] = &entry{
+		Digest: digest,
+		Time:   now,
+	}
+	err := c.fs.MkdirAll(filepath.Dir(c.path), 0755)
+	if err != nil {
+		return err
+	}
+	fd, err := c.fs.Create(c.path)
+	if err != nil {
+		return err
+	}
+	defer util.CloseAndLogError(fd)
+	return json.NewEncoder(fd).Encode(c.entries)
+}
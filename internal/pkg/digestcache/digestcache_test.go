@@ -0,0 +1,78 @@
+package digestcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+const testPath = "/cache/kube-compose/digests.json"
+
+func newTestCache(t *testing.T, vfs fs.VirtualFileSystem, now *time.Time) *Cache {
+	c, err := Load(vfs, testPath, time.Hour)
+	if err != nil {
+		t.Error(err)
+		return nil
+	}
+	c.now = func() time.Time {
+		return *now
+	}
+	return c
+}
+
+func TestLoad_NotExists(t *testing.T) {
+	c, err := Load(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{}), testPath, time.Hour)
+	if err != nil || c == nil || len(c.entries) != 0 {
+		t.Error(c, err)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		testPath: {
+			Content: []byte("{"),
+		},
+	})
+	_, err := Load(vfs, testPath, time.Hour)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestPutGet_Success(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{})
+	now := time.Unix(0, 0)
+	c := newTestCache(t, vfs, &now)
+	err := c.Put("sha256:1", "registry/ns/a:tag", "sha256:2")
+	if err != nil {
+		t.Error(err)
+	}
+	// A second run loads the digest from the cache file.
+	c = newTestCache(t, vfs, &now)
+	if digest, ok := c.Get("sha256:1", "registry/ns/a:tag"); !ok || digest != "sha256:2" {
+		t.Error(digest, ok)
+	}
+	if _, ok := c.Get("sha256:1", "otherregistry/ns/a:tag"); ok {
+		t.Fail()
+	}
+	if _, ok := c.Get("sha256:3", "registry/ns/a:tag"); ok {
+		t.Fail()
+	}
+}
+
+func TestGet_Expired(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{})
+	now := time.Unix(0, 0)
+	c := newTestCache(t, vfs, &now)
+	_ = c.Put("sha256:1", "registry/ns/a:tag", "sha256:2")
+	now = now.Add(time.Hour)
+	if _, ok := c.Get("sha256:1", "registry/ns/a:tag"); ok {
+		t.Fail()
+	}
+	// Expired entries are removed when the cache file is written.
+	_ = c.Put("sha256:3", "registry/ns/b:tag", "sha256:4")
+	if len(c.entries) != 1 {
+		t.Error(c.entries)
+	}
+}
@@ -0,0 +1,124 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+)
+
+const successfullyBuiltPrefix = "Successfully built "
+
+var buildStepRegexp = regexp.MustCompile(`^Step (\d+)/(\d+) :`)
+
+type ImageBuilder interface {
+	ImageBuild(ctx context.Context, buildContext io.Reader, options dockerTypes.ImageBuildOptions) (dockerTypes.ImageBuildResponse, error)
+}
+
+// BuildImage builds an image from a build context (a tar archive) and returns the ID of the image. The ID may be a short ID if the docker
+// daemon does not output full IDs. onUpdate is called whenever the progress of the build may have changed, see Build.Wait.
+func BuildImage(ctx context.Context, builder ImageBuilder, buildContext io.Reader, options dockerTypes.ImageBuildOptions,
+	onUpdate func(*Build)) (string, error) {
+	response, err := builder.ImageBuild(ctx, buildContext, options)
+	if err != nil {
+		return "", err
+	}
+	defer util.CloseAndLogError(response.Body)
+	build := NewBuild(response.Body)
+	return build.Wait(onUpdate)
+}
+
+// Build tracks the progress of an image build by parsing the steps of the Dockerfile from the JSON stream of the docker daemon.
+type Build struct {
+	done   bool
+	reader io.Reader
+	step   int
+	steps  int
+}
+
+func NewBuild(r io.Reader) *Build {
+	return &Build{
+		reader: r,
+	}
+}
+
+// Progress returns the fraction of steps of the Dockerfile that have completed. A step is completed once the next step starts.
+func (b *Build) Progress() float64 {
+	if b.done {
+		return 1
+	}
+	if b.steps == 0 {
+		return 0
+	}
+	return float64(b.step-1) / float64(b.steps)
+}
+
+type buildWaiter struct {
+	imageID   string
+	lastError string
+	onUpdate  func(*Build)
+}
+
+func (waiter *buildWaiter) handleMessage(b *Build, msg *jsonmessage.JSONMessage) {
+	switch {
+	case msg.Error != nil && len(msg.Error.Message) > 0:
+		waiter.lastError = msg.Error.Message
+	case msg.Aux != nil:
+		var aux struct {
+			ID string `json:"ID"`
+		}
+		if json.Unmarshal(*msg.Aux, &aux) == nil && aux.ID != "" {
+			waiter.imageID = aux.ID
+		}
+	case strings.HasPrefix(msg.Stream, successfullyBuiltPrefix):
+		if waiter.imageID == "" {
+			waiter.imageID = strings.TrimSpace(msg.Stream[len(successfullyBuiltPrefix):])
+		}
+		b.done = true
+		waiter.onUpdate(b)
+	default:
+		if m := buildStepRegexp.FindStringSubmatch(msg.Stream); m != nil {
+			b.step, _ = strconv.Atoi(m[1])
+			b.steps, _ = strconv.Atoi(m[2])
+			waiter.onUpdate(b)
+		} else if imageID := FindDigest(msg.Stream); imageID != "" && waiter.imageID == "" {
+			// Only the image ID is output if output is suppressed.
+			waiter.imageID = imageID
+		}
+	}
+}
+
+// Wait processes a JSON stream (the body of an image build docker HTTP response) and returns the ID of the built image. An error is
+// returned if the stream has an error or the ID of the image could not be parsed. onUpdate is called whenever b.Progress() may return a
+// different value from the previous call.
+func (b *Build) Wait(onUpdate func(*Build)) (string, error) {
+	waiter := buildWaiter{
+		onUpdate: onUpdate,
+	}
+	decoder := json.NewDecoder(b.reader)
+	for {
+		var msg jsonmessage.JSONMessage
+		err := decoder.Decode(&msg)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", err
+		}
+		waiter.handleMessage(b, &msg)
+	}
+	if waiter.lastError != "" {
+		return "", fmt.Errorf("error while building image: %s", waiter.lastError)
+	}
+	if waiter.imageID == "" {
+		return "", fmt.Errorf("could not parse image ID from docker build output stream")
+	}
+	return waiter.imageID, nil
+}
@@ -0,0 +1,100 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	dockerTypes "github.com/docker/docker/api/types"
+)
+
+func TestBuildWait_Success(t *testing.T) {
+	reader := strings.NewReader(`{"stream":"Step 1/2 : FROM ubuntu:latest\n"}
+{"stream":"Step 2/2 : COPY . /app\n"}
+{"aux":{"ID":"` + testDigest + `"}}
+{"stream":"Successfully built f0b6db8bb4b7\n"}`)
+	build := NewBuild(reader)
+	var progress []float64
+	imageID, err := build.Wait(func(b *Build) {
+		progress = append(progress, b.Progress())
+	})
+	if err != nil || imageID != testDigest {
+		t.Error(imageID, err)
+	}
+	if len(progress) != 3 || progress[0] != 0 || progress[1] != 0.5 || progress[2] != 1 {
+		t.Error(progress)
+	}
+}
+
+func TestBuildWait_ShortID(t *testing.T) {
+	build := NewBuild(strings.NewReader(`{"stream":"Successfully built f0b6db8bb4b7\n"}`))
+	imageID, err := build.Wait(func(_ *Build) {})
+	if err != nil || imageID != "f0b6db8bb4b7" {
+		t.Error(imageID, err)
+	}
+}
+
+func TestBuildWait_SuppressedOutput(t *testing.T) {
+	build := NewBuild(strings.NewReader(`{"stream":"` + testDigest + `\n"}`))
+	imageID, err := build.Wait(func(_ *Build) {})
+	if err != nil || imageID != testDigest {
+		t.Error(imageID, err)
+	}
+}
+
+func TestBuildWait_Error(t *testing.T) {
+	build := NewBuild(strings.NewReader(`{"stream":"Step 1/1 : FROM ubuntu:latest\n"}
+{"errorDetail":{"message":"asdf"},"error":"asdf"}`))
+	_, err := build.Wait(func(_ *Build) {})
+	if err == nil || !strings.Contains(err.Error(), "asdf") {
+		t.Error(err)
+	}
+}
+
+func TestBuildWait_NoImageID(t *testing.T) {
+	build := NewBuild(strings.NewReader(`{"stream":"Step 1/1 : FROM ubuntu:latest\n"}`))
+	_, err := build.Wait(func(_ *Build) {})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+type mockImageBuilder struct {
+	body    string
+	err     error
+	options dockerTypes.ImageBuildOptions
+}
+
+func (m *mockImageBuilder) ImageBuild(_ context.Context, _ io.Reader, options dockerTypes.ImageBuildOptions) (
+	dockerTypes.ImageBuildResponse, error) {
+	m.options = options
+	return dockerTypes.ImageBuildResponse{
+		Body: ioutil.NopCloser(strings.NewReader(m.body)),
+	}, m.err
+}
+
+func TestBuildImage_Success(t *testing.T) {
+	builder := &mockImageBuilder{
+		body: `{"aux":{"ID":"` + testDigest + `"}}`,
+	}
+	imageID, err := BuildImage(context.Background(), builder, &bytes.Buffer{}, dockerTypes.ImageBuildOptions{
+		Dockerfile: "Dockerfile.dev",
+	}, func(_ *Build) {})
+	if err != nil || imageID != testDigest || builder.options.Dockerfile != "Dockerfile.dev" {
+		t.Error(imageID, err)
+	}
+}
+
+func TestBuildImage_Error(t *testing.T) {
+	builder := &mockImageBuilder{
+		err: errors.New("builderror"),
+	}
+	_, err := BuildImage(context.Background(), builder, &bytes.Buffer{}, dockerTypes.ImageBuildOptions{}, func(_ *Build) {})
+	if err == nil {
+		t.Fail()
+	}
+}
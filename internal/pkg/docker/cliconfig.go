@@ -0,0 +1,308 @@
+package docker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	dockerRef "github.com/docker/distribution/reference"
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// The key of Docker Hub in the auths of the docker CLI configuration.
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+type cliConfigAuth struct {
+	Auth          string `json:"auth"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+// CLIConfig is the configuration of the docker CLI (config.json), which is used so that kube-compose reuses the docker context and
+// registry credentials of the docker CLI.
+type CLIConfig struct {
+	Auths          map[string]*cliConfigAuth `json:"auths"`
+	CredHelpers    map[string]string         `json:"credHelpers"`
+	CredsStore     string                    `json:"credsStore"`
+	CurrentContext string                    `json:"currentContext"`
+	dir            string
+}
+
+// CLIConfigDir returns the directory of the docker CLI configuration, which is the environment variable DOCKER_CONFIG or else ~/.docker.
+func CLIConfigDir(getenv func(string) (string, bool)) (string, error) {
+	if dir, ok := getenv("DOCKER_CONFIG"); ok && dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".docker"), nil
+}
+
+// LoadCLIConfig loads the docker CLI configuration in a directory. A configuration file that does not exist is treated as an empty
+// configuration.
+func LoadCLIConfig(dir string) (*CLIConfig, error) {
+	c := &CLIConfig{
+		dir: dir,
+	}
+	fd, err := fs.OS.Open(filepath.Join(dir, "config.json"))
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(fd)
+	err = json.NewDecoder(fd).Decode(c)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while parsing the docker CLI configuration in %s", dir)
+	}
+	return c, nil
+}
+
+type cliContextMeta struct {
+	Endpoints struct {
+		Docker *struct {
+			Host          string
+			SkipTLSVerify bool
+		} `json:"docker"`
+	}
+}
+
+// loadContextMeta loads the metadata of a docker context. The docker CLI stores contexts in directories named after the SHA-256 of their
+// names, so the name of that directory is also returned.
+func (c *CLIConfig) loadContextMeta(name string) (*cliContextMeta, string, error) {
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])
+	fd, err := fs.OS.Open(filepath.Join(c.dir, "contexts", "meta", hash, "meta.json"))
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "could not load docker context %#v", name)
+	}
+	defer util.CloseAndLogError(fd)
+	meta := &cliContextMeta{}
+	err = json.NewDecoder(fd).Decode(meta)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "could not load docker context %#v", name)
+	}
+	if meta.Endpoints.Docker == nil || meta.Endpoints.Docker.Host == "" {
+		return nil, "", fmt.Errorf("docker context %#v has no docker endpoint", name)
+	}
+	return meta, hash, nil
+}
+
+// ContextEnv returns the environment variables that configure the docker client to connect to the docker daemon of the current docker
+// context, like the docker CLI does. The current docker context is the environment variable DOCKER_CONTEXT or else the context set by
+// "docker context use". Nil is returned if the environment variable DOCKER_HOST is set or the current context is the default context,
+// because the docker client already connects to the right daemon in these cases.
+func (c *CLIConfig) ContextEnv(getenv func(string) (string, bool)) (map[string]string, error) {
+	if host, ok := getenv("DOCKER_HOST"); ok && host != "" {
+		return nil, nil
+	}
+	name, ok := getenv("DOCKER_CONTEXT")
+	if !ok || name == "" {
+		name = c.CurrentContext
+	}
+	if name == "" || name == "default" {
+		return nil, nil
+	}
+	meta, hash, err := c.loadContextMeta(name)
+	if err != nil {
+		return nil, err
+	}
+	env := map[string]string{
+		"DOCKER_HOST": meta.Endpoints.Docker.Host,
+	}
+	tlsDir := filepath.Join(c.dir, "contexts", "tls", hash, "docker")
+	if _, err = fs.OS.Stat(tlsDir); err == nil {
+		env["DOCKER_CERT_PATH"] = tlsDir
+		if !meta.Endpoints.Docker.SkipTLSVerify {
+			env["DOCKER_TLS_VERIFY"] = "1"
+		}
+	}
+	return env, nil
+}
+
+// execCredentialHelper runs the get command of a docker credential helper, writing stdin to its standard input and returning its
+// standard output. It is a variable to improve testability.
+var execCredentialHelper = func(helper, stdin string) ([]byte, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+	if err != nil {
+		// Credential helpers write the error to standard output.
+		return nil, errors.Wrapf(err, "%s", bytes.TrimSpace(stdout.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// getAuthKey returns the key of the registry of an image in the auths of the docker CLI configuration.
+func getAuthKey(image string) (string, error) {
+	named, err := dockerRef.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+	domain := dockerRef.Domain(named)
+	if domain == DefaultDomain {
+		return dockerHubAuthKey, nil
+	}
+	return domain, nil
+}
+
+// getAuthKeyHost returns the host of a key of the auths of the docker CLI configuration, which may be a URL.
+func getAuthKeyHost(key string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(key, "http://"), "https://")
+	if i := strings.IndexByte(host, '/'); i >= 0 {
+		host = host[:i]
+	}
+	return host
+}
+
+func (c *CLIConfig) getAuthFromHelper(helper, key string) (*dockerTypes.AuthConfig, error) {
+	stdout, err := execCredentialHelper(helper, key)
+	if err != nil {
+		if strings.Contains(err.Error(), "credentials not found") {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "docker credential helper %#v failed", helper)
+	}
+	var credentials struct {
+		Username string
+		Secret   string
+	}
+	err = json.Unmarshal(stdout, &credentials)
+	if err != nil {
+		return nil, errors.Wrapf(err, "docker credential helper %#v wrote invalid output", helper)
+	}
+	if credentials.Username == "<token>" {
+		return &dockerTypes.AuthConfig{
+			IdentityToken: credentials.Secret,
+		}, nil
+	}
+	return &dockerTypes.AuthConfig{
+		Username: credentials.Username,
+		Password: credentials.Secret,
+	}, nil
+}
+
+func (c *CLIConfig) getAuthFromAuths(key string) (*dockerTypes.AuthConfig, error) {
+	auth := c.Auths[key]
+	if auth == nil {
+		host := getAuthKeyHost(key)
+		for key2, auth2 := range c.Auths {
+			if getAuthKeyHost(key2) == host {
+				auth = auth2
+				break
+			}
+		}
+	}
+	if auth == nil {
+		return nil, nil
+	}
+	authConfig := &dockerTypes.AuthConfig{
+		IdentityToken: auth.IdentityToken,
+	}
+	if auth.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid auth of registry %s in the docker CLI configuration", key)
+		}
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid auth of registry %s in the docker CLI configuration", key)
+		}
+		authConfig.Username = parts[0]
+		authConfig.Password = parts[1]
+	}
+	return authConfig, nil
+}
+
+// GetAuthConfig returns the credentials of the registry of an image like the docker CLI resolves them: from the credential helper of the
+// registry, the credentials store, or else the auths of the configuration. Nil is returned if there are no credentials.
+func (c *CLIConfig) GetAuthConfig(image string) (*dockerTypes.AuthConfig, error) {
+	key, err := getAuthKey(image)
+	if err != nil {
+		return nil, err
+	}
+	var authConfig *dockerTypes.AuthConfig
+	if helper := c.CredHelpers[getAuthKeyHost(key)]; helper != "" {
+		authConfig, err = c.getAuthFromHelper(helper, key)
+	} else if c.CredsStore != "" {
+		authConfig, err = c.getAuthFromHelper(c.CredsStore, key)
+	} else {
+		authConfig, err = c.getAuthFromAuths(key)
+	}
+	if err != nil || authConfig == nil {
+		return nil, err
+	}
+	authConfig.ServerAddress = key
+	return authConfig, nil
+}
+
+// GetRegistryAuth returns the encoded credentials of the registry of an image (as expected by the docker daemon) (see GetAuthConfig). The
+// empty string is returned if there are no credentials.
+func (c *CLIConfig) GetRegistryAuth(image string) (string, error) {
+	authConfig, err := c.GetAuthConfig(image)
+	if err != nil || authConfig == nil {
+		return "", err
+	}
+	authConfigBytes, err := json.Marshal(authConfig)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(authConfigBytes), nil
+}
+
+// dockerConfigJSONAuth is an entry of the auths of a docker config.json, as the kubelet reads it.
+type dockerConfigJSONAuth struct {
+	Auth     string `json:"auth"`
+	Password string `json:"password"`
+	Username string `json:"username"`
+}
+
+// GetDockerConfigJSON returns a docker config.json with the credentials of the registries of images (see GetAuthConfig), which is the
+// format of the data of Secrets of type kubernetes.io/dockerconfigjson. Identity tokens are left out, because the kubelet cannot pull
+// images with them. Nil is returned if there are no credentials.
+func (c *CLIConfig) GetDockerConfigJSON(images []string) ([]byte, error) {
+	seen := map[string]bool{}
+	auths := map[string]*dockerConfigJSONAuth{}
+	for _, image := range images {
+		key, err := getAuthKey(image)
+		if err != nil {
+			return nil, err
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		authConfig, err := c.GetAuthConfig(image)
+		if err != nil {
+			return nil, err
+		}
+		if authConfig == nil || authConfig.Username == "" {
+			continue
+		}
+		auths[key] = &dockerConfigJSONAuth{
+			Auth:     base64.StdEncoding.EncodeToString([]byte(authConfig.Username + ":" + authConfig.Password)),
+			Password: authConfig.Password,
+			Username: authConfig.Username,
+		}
+	}
+	if len(auths) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(map[string]interface{}{
+		"auths": auths,
+	})
+}
@@ -0,0 +1,179 @@
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+// The SHA-256 of "remote".
+const testContextHash = "b71199ebd070b36beab7317920c2c2f1d777df8d05e5527d8458fda57cb17a7a"
+
+func withMockCLIConfigFS(cb func()) {
+	orig := fs.OS
+	defer func() {
+		fs.OS = orig
+	}()
+	fs.OS = fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/home/.docker/config.json": {
+			Content: []byte(`{
+	"auths": {
+		"https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNzd29yZA=="},
+		"https://registry.example.com/v2/": {"identitytoken": "token1"}
+	},
+	"credHelpers": {"gcr.io": "gcloud"},
+	"currentContext": "remote"
+}`),
+		},
+		"/home/.docker/contexts/meta/" + testContextHash + "/meta.json": {
+			Content: []byte(`{"Name":"remote","Endpoints":{"docker":{"Host":"tcp://remote:2376","SkipTLSVerify":false}}}`),
+		},
+	})
+	cb()
+}
+
+func decodeRegistryAuth(t *testing.T, registryAuth string) *dockerTypes.AuthConfig {
+	data, err := base64.URLEncoding.DecodeString(registryAuth)
+	if err != nil {
+		t.Error(err)
+		return nil
+	}
+	authConfig := &dockerTypes.AuthConfig{}
+	err = json.Unmarshal(data, authConfig)
+	if err != nil {
+		t.Error(err)
+	}
+	return authConfig
+}
+
+func loadTestCLIConfig(t *testing.T) *CLIConfig {
+	c, err := LoadCLIConfig("/home/.docker")
+	if err != nil {
+		t.Error(err)
+	}
+	return c
+}
+
+func TestCLIConfig_GetRegistryAuth_Auths(t *testing.T) {
+	withMockCLIConfigFS(func() {
+		c := loadTestCLIConfig(t)
+		registryAuth, err := c.GetRegistryAuth("ubuntu:latest")
+		if err != nil {
+			t.Error(err)
+		}
+		authConfig := decodeRegistryAuth(t, registryAuth)
+		if authConfig.Username != "user" || authConfig.Password != "password" || authConfig.ServerAddress != dockerHubAuthKey {
+			t.Error(authConfig)
+		}
+		registryAuth, err = c.GetRegistryAuth("registry.example.com/app:1")
+		if err != nil {
+			t.Error(err)
+		}
+		if authConfig = decodeRegistryAuth(t, registryAuth); authConfig.IdentityToken != "token1" {
+			t.Error(authConfig)
+		}
+	})
+}
+
+func TestCLIConfig_GetRegistryAuth_None(t *testing.T) {
+	withMockCLIConfigFS(func() {
+		registryAuth, err := loadTestCLIConfig(t).GetRegistryAuth("other.example.com/app")
+		if err != nil || registryAuth != "" {
+			t.Error(registryAuth, err)
+		}
+	})
+}
+
+func TestCLIConfig_GetRegistryAuth_CredentialHelper(t *testing.T) {
+	orig := execCredentialHelper
+	defer func() {
+		execCredentialHelper = orig
+	}()
+	execCredentialHelper = func(helper, stdin string) ([]byte, error) {
+		if helper != "gcloud" || stdin != "gcr.io" {
+			return nil, fmt.Errorf("credentials not found in native keychain")
+		}
+		return []byte(`{"ServerURL":"gcr.io","Username":"oauth2accesstoken","Secret":"secret1"}`), nil
+	}
+	withMockCLIConfigFS(func() {
+		registryAuth, err := loadTestCLIConfig(t).GetRegistryAuth("gcr.io/project/app")
+		if err != nil {
+			t.Error(err)
+		}
+		authConfig := decodeRegistryAuth(t, registryAuth)
+		if authConfig.Username != "oauth2accesstoken" || authConfig.Password != "secret1" {
+			t.Error(authConfig)
+		}
+	})
+}
+
+func TestCLIConfig_ContextEnv(t *testing.T) {
+	withMockCLIConfigFS(func() {
+		c := loadTestCLIConfig(t)
+		env, err := c.ContextEnv(func(string) (string, bool) {
+			return "", false
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(env, map[string]string{"DOCKER_HOST": "tcp://remote:2376"}) {
+			t.Error(env)
+		}
+		env, err = c.ContextEnv(func(name string) (string, bool) {
+			return "unix:///var/run/docker.sock", name == "DOCKER_HOST"
+		})
+		if err != nil || env != nil {
+			t.Error(env, err)
+		}
+	})
+}
+
+func TestLoadCLIConfig_NotExists(t *testing.T) {
+	withMockCLIConfigFS(func() {
+		c, err := LoadCLIConfig("/other")
+		if err != nil || c.CurrentContext != "" {
+			t.Error(c, err)
+		}
+	})
+}
+
+func TestCLIConfig_GetDockerConfigJSON_Success(t *testing.T) {
+	withMockCLIConfigFS(func() {
+		data, err := loadTestCLIConfig(t).GetDockerConfigJSON([]string{
+			"ubuntu:latest",
+			"nginx@sha256:" + strings.Repeat("a", 64),
+			"registry.example.com/app:1",
+			"other.example.com/app",
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		var dockerConfigJSON struct {
+			Auths map[string]*dockerConfigJSONAuth
+		}
+		err = json.Unmarshal(data, &dockerConfigJSON)
+		if err != nil {
+			t.Error(err)
+		}
+		auth := dockerConfigJSON.Auths[dockerHubAuthKey]
+		if len(dockerConfigJSON.Auths) != 1 || auth == nil || auth.Auth != "dXNlcjpwYXNzd29yZA==" || auth.Username != "user" ||
+			auth.Password != "password" {
+			t.Error(string(data))
+		}
+	})
+}
+
+func TestCLIConfig_GetDockerConfigJSON_None(t *testing.T) {
+	withMockCLIConfigFS(func() {
+		data, err := loadTestCLIConfig(t).GetDockerConfigJSON([]string{"other.example.com/app"})
+		if err != nil || data != nil {
+			t.Error(string(data), err)
+		}
+	})
+}
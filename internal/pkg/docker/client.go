@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+
+	dockerClient "github.com/docker/docker/client"
+	"github.com/docker/go-connections/sockets"
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+// NewEnvClient creates a docker client from the environment variables DOCKER_HOST, DOCKER_CERT_PATH, DOCKER_TLS_VERIFY and
+// DOCKER_API_VERSION, like dockerClient.NewEnvClient. Unlike dockerClient.NewEnvClient, connections to docker daemons over TLS use the
+// proxies of the environment variables HTTP_PROXY, HTTPS_PROXY, ALL_PROXY and NO_PROXY, like connections without TLS do.
+func NewEnvClient() (*dockerClient.Client, error) {
+	dockerCertPath := os.Getenv("DOCKER_CERT_PATH")
+	if dockerCertPath == "" {
+		return dockerClient.NewEnvClient()
+	}
+	tlsc, err := tlsconfig.Client(tlsconfig.Options{
+		CAFile:             filepath.Join(dockerCertPath, "ca.pem"),
+		CertFile:           filepath.Join(dockerCertPath, "cert.pem"),
+		KeyFile:            filepath.Join(dockerCertPath, "key.pem"),
+		InsecureSkipVerify: os.Getenv("DOCKER_TLS_VERIFY") == "",
+	})
+	if err != nil {
+		return nil, err
+	}
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = dockerClient.DefaultDockerHost
+	}
+	proto, addr, _, err := dockerClient.ParseHost(host)
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{
+		TLSClientConfig: tlsc,
+	}
+	// Sets the proxy and the dialer of ALL_PROXY for TCP connections.
+	err = sockets.ConfigureTransport(transport, proto, addr)
+	if err != nil {
+		return nil, err
+	}
+	version := os.Getenv("DOCKER_API_VERSION")
+	if version == "" {
+		version = dockerClient.DefaultVersion
+	}
+	return dockerClient.NewClient(host, version, &http.Client{Transport: transport}, nil)
+}
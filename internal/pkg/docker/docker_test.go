@@ -9,6 +9,8 @@ import (
 	"testing"
 
 	dockerTypes "github.com/docker/docker/api/types"
+	dockerClient "github.com/docker/docker/client"
+	"github.com/kube-compose/kube-compose/pkg/docker/dockertest"
 )
 
 // The encoded form of user:password to be used as docker registry authentication header value
@@ -132,3 +134,62 @@ func TestPullImage_Error(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+const testImageID = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+
+func withTestDaemon(t *testing.T, cb func(d *dockertest.Daemon, dc *dockerClient.Client)) {
+	d := dockertest.NewDaemon()
+	defer d.Close()
+	dc, err := d.NewClient()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	cb(d, dc)
+}
+
+func TestPullImage_Daemon(t *testing.T) {
+	withTestDaemon(t, func(d *dockertest.Daemon, dc *dockerClient.Client) {
+		digestExpected, _ := d.Registry.AddImage("nginx:1.17", &dockertest.Image{
+			ID: testImageID,
+		})
+		progress := 0.0
+		digest, err := PullImage(context.Background(), dc, "docker.io/library/nginx:1.17", testToken, func(pull *PullOrPush) {
+			progress = pull.Progress()
+		})
+		if err != nil || digest != digestExpected || progress != 1 {
+			t.Error(digest, progress, err)
+		}
+		if image := d.Image("nginx:1.17"); image == nil || image.ID != testImageID {
+			t.Error(image)
+		}
+	})
+}
+
+func TestPushImage_Daemon(t *testing.T) {
+	withTestDaemon(t, func(d *dockertest.Daemon, dc *dockerClient.Client) {
+		d.AddImage(&dockertest.Image{
+			ID:       testImageID,
+			RepoTags: []string{"my-registry:5000/web:test"},
+		})
+		d.Registry.RequireAuth(testToken)
+		digest, err := PushImage(context.Background(), dc, "my-registry:5000/web:test", testToken, func(_ *PullOrPush) {})
+		if err != nil || digest == "" || digest != d.Registry.Digest("my-registry:5000/web:test") {
+			t.Error(digest, err)
+		}
+	})
+}
+
+func TestPushImage_DaemonUnauthorized(t *testing.T) {
+	withTestDaemon(t, func(d *dockertest.Daemon, dc *dockerClient.Client) {
+		d.AddImage(&dockertest.Image{
+			ID:       testImageID,
+			RepoTags: []string{"my-registry:5000/web:test"},
+		})
+		d.Registry.RequireAuth(testToken)
+		_, err := PushImage(context.Background(), dc, "my-registry:5000/web:test", "", func(_ *PullOrPush) {})
+		if err == nil || err.Error() != "error while pushing image: unauthorized: authentication required" {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package docker
+
+import (
+	"context"
+	"sync"
+)
+
+// pullManagerItem is the state of a pull of a PullManager.
+type pullManagerItem struct {
+	done     bool
+	progress float64
+}
+
+// PullManager pulls images concurrently, with at most a fixed number of pulls in flight, and tracks the aggregate progress of the pulls
+// so that the progress of pulling the images of a large project can be reported as a whole. All methods are safe for concurrent use.
+type PullManager struct {
+	// If not nil, this function is called whenever Progress or Pending may return a different value from the previous call. It may be
+	// called concurrently.
+	OnUpdate func(m *PullManager)
+	items    []*pullManagerItem
+	mutex    sync.Mutex
+	puller   ImagePuller
+	// A buffered channel with a capacity of the maximum number of pulls in flight, or nil if the number of pulls is not limited.
+	semaphore chan struct{}
+}
+
+// NewPullManager creates a PullManager that pulls images with puller. If concurrency is positive then at most concurrency images are
+// pulled at the same time, and otherwise the number of concurrent pulls is not limited.
+func NewPullManager(puller ImagePuller, concurrency int) *PullManager {
+	m := &PullManager{
+		puller: puller,
+	}
+	if concurrency > 0 {
+		m.semaphore = make(chan struct{}, concurrency)
+	}
+	return m
+}
+
+// Pull is like PullImage, but waits until fewer than the maximum number of pulls are in flight before starting the pull. The pull is
+// pending until Pull returns. onUpdate can be nil, and is otherwise called with the progress of this pull like PullImage.
+func (m *PullManager) Pull(ctx context.Context, image, registryAuth string, onUpdate func(*PullOrPush)) (string, error) {
+	item := &pullManagerItem{}
+	m.mutex.Lock()
+	m.items = append(m.items, item)
+	m.mutex.Unlock()
+	defer m.update(item, 1, true)
+	m.notify()
+	if m.semaphore != nil {
+		select {
+		case m.semaphore <- struct{}{}:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		defer func() {
+			<-m.semaphore
+		}()
+	}
+	return PullImage(ctx, m.puller, image, registryAuth, func(pull *PullOrPush) {
+		m.update(item, pull.Progress(), false)
+		if onUpdate != nil {
+			onUpdate(pull)
+		}
+	})
+}
+
+func (m *PullManager) update(item *pullManagerItem, progress float64, done bool) {
+	m.mutex.Lock()
+	item.progress = progress
+	item.done = done
+	m.mutex.Unlock()
+	m.notify()
+}
+
+func (m *PullManager) notify() {
+	if m.OnUpdate != nil {
+		m.OnUpdate(m)
+	}
+}
+
+// Progress returns the aggregate progress of all pulls of the manager, between 0 and 1. This is the average of the progress of each pull,
+// where pulls that wait to start have made no progress and completed pulls (including failed pulls) are complete. If the manager has no
+// pulls then 0 is returned.
+func (m *PullManager) Progress() float64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if len(m.items) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, item := range m.items {
+		sum += item.progress
+	}
+	return sum / float64(len(m.items))
+}
+
+// Pending returns the number of pulls of the manager that have not completed, including pulls that wait to start.
+func (m *PullManager) Pending() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	pending := 0
+	for _, item := range m.items {
+		if !item.done {
+			pending++
+		}
+	}
+	return pending
+}
@@ -0,0 +1,111 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"testing"
+
+	dockerTypes "github.com/docker/docker/api/types"
+)
+
+// testConcurrentImagePuller counts the pulls in flight, and blocks each pull until release is closed.
+type testConcurrentImagePuller struct {
+	inFlight    int
+	maxInFlight int
+	mutex       sync.Mutex
+	release     chan struct{}
+}
+
+func (t *testConcurrentImagePuller) ImagePull(_ context.Context, _ string, _ dockerTypes.ImagePullOptions) (io.ReadCloser, error) {
+	t.mutex.Lock()
+	t.inFlight++
+	if t.inFlight > t.maxInFlight {
+		t.maxInFlight = t.inFlight
+	}
+	t.mutex.Unlock()
+	<-t.release
+	t.mutex.Lock()
+	t.inFlight--
+	t.mutex.Unlock()
+	return &testReadCloser{
+		reader: bytes.NewReader([]byte(fmt.Sprintf(`{"status":"Downloading","id":"layer1","progressDetail":{"current":1,"total":2}}
+{"status":"%s "}`, testDigest))),
+	}, nil
+}
+
+func TestPullManager_Concurrency(t *testing.T) {
+	puller := &testConcurrentImagePuller{
+		release: make(chan struct{}),
+	}
+	m := NewPullManager(puller, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(image string) {
+			defer wg.Done()
+			digest, err := m.Pull(context.Background(), image, "", nil)
+			if err != nil || digest != testDigest {
+				t.Error(digest, err)
+			}
+		}(fmt.Sprintf("image%d", i))
+	}
+	// Wait until all pulls are pending, and the pulls that can start are in flight.
+	for {
+		puller.mutex.Lock()
+		inFlight := puller.inFlight
+		puller.mutex.Unlock()
+		if inFlight == 2 && m.Pending() == 5 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(puller.release)
+	wg.Wait()
+	if puller.maxInFlight != 2 || m.Pending() != 0 || m.Progress() != 1 {
+		t.Error(puller.maxInFlight, m.Pending(), m.Progress())
+	}
+}
+
+func TestPullManager_Progress(t *testing.T) {
+	puller := &testConcurrentImagePuller{
+		release: make(chan struct{}),
+	}
+	close(puller.release)
+	m := NewPullManager(puller, 0)
+	if m.Progress() != 0 {
+		t.Error(m.Progress())
+	}
+	var aggregates []float64
+	m.OnUpdate = func(m *PullManager) {
+		aggregates = append(aggregates, m.Progress())
+	}
+	var imageProgress float64
+	_, err := m.Pull(context.Background(), "image", "", func(pull *PullOrPush) {
+		imageProgress = pull.Progress()
+	})
+	if err != nil || imageProgress <= 0 || imageProgress >= 1 {
+		t.Error(imageProgress, err)
+	}
+	if len(aggregates) != 3 || aggregates[0] != 0 || aggregates[1] != imageProgress || aggregates[2] != 1 {
+		t.Error(aggregates)
+	}
+}
+
+func TestPullManager_Canceled(t *testing.T) {
+	puller := &testConcurrentImagePuller{
+		release: make(chan struct{}),
+	}
+	m := NewPullManager(puller, 1)
+	// Occupy the only slot, so that the next pull waits.
+	m.semaphore <- struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := m.Pull(ctx, "image", "", nil)
+	if err != context.Canceled || m.Pending() != 0 {
+		t.Error(m.Pending(), err)
+	}
+}
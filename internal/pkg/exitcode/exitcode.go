@@ -0,0 +1,71 @@
+package exitcode
+
+// Code is the exit code of the kube-compose process. Scripts can branch on the exit code to distinguish failure classes.
+type Code int
+
+const (
+	// Success indicates that the command succeeded.
+	Success Code = 0
+	// Generic indicates an error that does not belong to any of the other classes.
+	Generic Code = 1
+	// Config indicates that the docker compose files, the kube config, flags or environment variables are invalid.
+	Config Code = 2
+	// ImageTransfer indicates that an image could not be pulled or pushed.
+	ImageTransfer Code = 3
+	// ClusterConnectivity indicates that the Kubernetes cluster could not be reached or rejected a request.
+	ClusterConnectivity Code = 4
+	// ReadinessTimeout indicates that a docker compose service did not become ready in time.
+	ReadinessTimeout Code = 5
+	// ContainerFailure indicates that a container terminated abnormally.
+	ContainerFailure Code = 6
+	// TestFailure indicates that the container of the test service of the test command exited with a non-zero exit code.
+	TestFailure Code = 7
+)
+
+type codeError struct {
+	code Code
+	err  error
+}
+
+func (e *codeError) Error() string {
+	return e.err.Error()
+}
+
+// Cause implements the causer interface of github.com/pkg/errors.
+func (e *codeError) Cause() error {
+	return e.err
+}
+
+// Wrap annotates err with an exit code. If err is nil then Wrap returns nil.
+func Wrap(err error, code Code) error {
+	if err == nil {
+		return nil
+	}
+	return &codeError{
+		code: code,
+		err:  err,
+	}
+}
+
+type causer interface {
+	Cause() error
+}
+
+// FromError returns the exit code of the outermost error in the chain of causes of err that was annotated with Wrap. If err is nil
+// then Success is returned, and if no error was annotated then Generic is returned.
+func FromError(err error) Code {
+	if err == nil {
+		return Success
+	}
+	for err != nil {
+		if e, ok := err.(*codeError); ok {
+			return e.code
+		}
+		c, ok := err.(causer)
+		if !ok {
+			break
+		}
+		err = c.Cause()
+	}
+	return Generic
+}
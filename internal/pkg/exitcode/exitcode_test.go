@@ -0,0 +1,50 @@
+package exitcode
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func Test_FromError_Nil(t *testing.T) {
+	if FromError(nil) != Success {
+		t.Fail()
+	}
+}
+
+func Test_FromError_NotWrapped(t *testing.T) {
+	if FromError(fmt.Errorf("error")) != Generic {
+		t.Fail()
+	}
+}
+
+func Test_FromError_Wrapped(t *testing.T) {
+	err := Wrap(fmt.Errorf("error"), ImageTransfer)
+	if FromError(err) != ImageTransfer {
+		t.Fail()
+	}
+	if err.Error() != "error" {
+		t.Fail()
+	}
+}
+
+func Test_FromError_WrappedByPkgErrors(t *testing.T) {
+	err := errors.Wrap(Wrap(fmt.Errorf("error"), ClusterConnectivity), "context")
+	if FromError(err) != ClusterConnectivity {
+		t.Fail()
+	}
+}
+
+func Test_FromError_OutermostWins(t *testing.T) {
+	err := Wrap(errors.Wrap(Wrap(fmt.Errorf("error"), ClusterConnectivity), "context"), Config)
+	if FromError(err) != Config {
+		t.Fail()
+	}
+}
+
+func Test_Wrap_Nil(t *testing.T) {
+	if Wrap(nil, Config) != nil {
+		t.Fail()
+	}
+}
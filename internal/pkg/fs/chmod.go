@@ -0,0 +1,21 @@
+package fs
+
+import (
+	"os"
+)
+
+func (fs *osFileSystem) Chmod(name string, mode os.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+// Chmod should behave the same as os.Chmod but operates on the virtual file system. Like os.Chmod, symlinks are followed and only the
+// permission bits, setuid, setgid and sticky bits of mode are used.
+func (fs *InMemoryFileSystem) Chmod(name string, mode os.FileMode) error {
+	n, _, err := fs.find(name, false, true)
+	if err != nil {
+		return err
+	}
+	const chmodMask = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+	n.mode = (n.mode &^ chmodMask) | (mode & chmodMask)
+	return nil
+}
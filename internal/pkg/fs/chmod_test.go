@@ -0,0 +1,42 @@
+package fs
+
+import (
+	"os"
+	"testing"
+)
+
+func Test_OSFileSystem_Chmod(t *testing.T) {
+	_ = OS.Chmod("", 0)
+}
+
+func Test_VirtualFileSystem_Chmod_Success(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"file": {
+			Mode: 0644,
+		},
+		"link": {
+			Content: []byte("file"),
+			Mode:    os.ModeSymlink,
+		},
+	})
+	err := fs.Chmod("link", os.ModeDir|0755)
+	if err != nil {
+		t.Error(err)
+	}
+	fileInfo, _ := fs.Lstat("file")
+	if fileInfo.Mode() != 0755 {
+		t.Error(fileInfo.Mode())
+	}
+	fileInfo, _ = fs.Lstat("link")
+	if fileInfo.Mode() != os.ModeSymlink {
+		t.Error(fileInfo.Mode())
+	}
+}
+
+func Test_VirtualFileSystem_Chmod_ENOENT(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{})
+	err := fs.Chmod("file", 0644)
+	if !os.IsNotExist(err) {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,11 @@
+package fs
+
+import (
+	"os"
+)
+
+// Create creates or truncates the regular file at name, and opens it for reading and writing. Like os.Create, the parent directory of
+// name must exist.
+func (fs *InMemoryFileSystem) Create(name string) (FileDescriptor, error) {
+	return fs.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
@@ -0,0 +1,95 @@
+package fs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func Test_VirtualFileSystem_Create_Success(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"dir": {
+			Mode: os.ModeDir,
+		},
+	})
+	fd, err := fs.Create("dir/file")
+	if err != nil {
+		t.Error(err)
+	} else {
+		_, _ = fd.Write([]byte("hello "))
+		_, _ = fd.Write([]byte("world"))
+		_ = fd.Close()
+		fd, err = fs.Open("dir/file")
+		if err != nil {
+			t.Error(err)
+		} else {
+			data, _ := ioutil.ReadAll(fd)
+			if string(data) != "hello world" {
+				t.Fail()
+			}
+		}
+	}
+}
+
+func Test_VirtualFileSystem_Create_Truncates(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"file": {
+			Content: []byte("old content"),
+		},
+	})
+	_, err := fs.Create("file")
+	if err != nil {
+		t.Error(err)
+	} else {
+		fileInfo, _ := fs.Stat("file")
+		if fileInfo.Size() != 0 {
+			t.Fail()
+		}
+	}
+}
+
+func Test_VirtualFileSystem_Create_EISDIR(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{})
+	_, err := fs.Create("/")
+	if err != syscall.EISDIR {
+		t.Fail()
+	}
+}
+
+func Test_VirtualFileSystem_Create_ENOENT(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{})
+	_, err := fs.Create("asdf/asdf")
+	if !os.IsNotExist(err) {
+		t.Fail()
+	}
+}
+
+func Test_VirtualFileSystem_Create_ErrorOpen(t *testing.T) {
+	errExpected := fmt.Errorf("openError")
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"file": {
+			OpenError: errExpected,
+		},
+	})
+	_, err := fs.Create("file")
+	if err != errExpected {
+		t.Fail()
+	}
+}
+
+func Test_VirtualFileSystem_Write_EBADF(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"file": {},
+	})
+	fd, err := fs.Open("file")
+	if err != nil {
+		t.Error(err)
+	} else {
+		_, err = fd.Write([]byte("data"))
+		if err != syscall.EBADF {
+			t.Fail()
+		}
+	}
+}
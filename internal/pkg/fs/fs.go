@@ -11,7 +11,7 @@ import (
 
 // FileDescriptor is an abstraction of os.File to improve testability of code.
 type FileDescriptor interface {
-	io.ReadCloser
+	io.ReadWriteCloser
 	Readdir(n int) ([]os.FileInfo, error)
 }
 
@@ -19,13 +19,19 @@ type FileDescriptor interface {
 type VirtualFileSystem interface {
 	Abs(name string) (string, error)
 	Chdir(dir string) error
+	Chmod(name string, mode os.FileMode) error
+	Create(name string) (FileDescriptor, error)
 	EvalSymlinks(path string) (string, error)
 	Getwd() (string, error)
 	Mkdir(name string, perm os.FileMode) error
 	MkdirAll(name string, perm os.FileMode) error
 	Lstat(name string) (os.FileInfo, error)
 	Open(name string) (FileDescriptor, error)
+	OpenFile(name string, flag int, perm os.FileMode) (FileDescriptor, error)
 	Readlink(name string) (string, error)
+	Remove(name string) error
+	RemoveAll(name string) error
+	Rename(oldpath, newpath string) error
 	Stat(name string) (os.FileInfo, error)
 }
 
@@ -36,6 +42,10 @@ func (fs *osFileSystem) Abs(name string) (string, error) {
 	return filepath.Abs(name)
 }
 
+func (fs *osFileSystem) Create(name string) (FileDescriptor, error) {
+	return os.Create(name)
+}
+
 func (fs *osFileSystem) EvalSymlinks(path string) (string, error) {
 	return filepath.EvalSymlinks(path)
 }
@@ -316,8 +326,12 @@ func (fs *InMemoryFileSystem) Set(name string, vfile *InMemoryFile) {
 }
 
 type virtualFileDescriptor struct {
-	node    *node
-	readPos int
+	// If true then each write appends to the file (see os.O_APPEND).
+	appendMode bool
+	node       *node
+	readPos    int
+	writable   bool
+	writeOnly  bool
 }
 
 func (r *virtualFileDescriptor) Close() error {
@@ -325,6 +339,10 @@ func (r *virtualFileDescriptor) Close() error {
 }
 
 func (r *virtualFileDescriptor) Read(p []byte) (n int, err error) {
+	if r.writeOnly {
+		err = syscall.EBADF
+		return
+	}
 	if !r.node.mode.IsRegular() && (r.node.mode&os.ModeDevice) == 0 {
 		err = errBadMode
 		return
@@ -344,6 +362,31 @@ func (r *virtualFileDescriptor) Read(p []byte) (n int, err error) {
 	return
 }
 
+func (r *virtualFileDescriptor) Write(p []byte) (n int, err error) {
+	if !r.writable {
+		err = syscall.EBADF
+		return
+	}
+	if !r.node.mode.IsRegular() {
+		err = errBadMode
+		return
+	}
+	fileContents := r.node.extra.([]byte)
+	if r.appendMode {
+		r.readPos = len(fileContents)
+	}
+	end := r.readPos + len(p)
+	if end > len(fileContents) {
+		newFileContents := make([]byte, end)
+		copy(newFileContents, fileContents)
+		fileContents = newFileContents
+	}
+	n = copy(fileContents[r.readPos:], p)
+	r.readPos += n
+	r.node.extra = fileContents
+	return
+}
+
 func (r *virtualFileDescriptor) Readdir(n int) ([]os.FileInfo, error) {
 	if !r.node.mode.IsDir() {
 		return nil, syscall.ENOTDIR
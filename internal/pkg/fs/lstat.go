@@ -6,33 +6,40 @@ import (
 	"syscall"
 )
 
-func (fs *InMemoryFileSystem) lstatNode(name string) (*node, error) {
+// lookupParent returns the directory that contains the file at name, resolving symlinks in all but the last path component of name, and
+// the last path component of name. The directory is nil if name is the root directory.
+func (fs *InMemoryFileSystem) lookupParent(name string) (parent *node, nameComp string, err error) {
 	if name == "" {
 		name = fs.cwd
 	}
 	name = trimTrailingSlashes(name)
-	var n *node
-	if name != "" {
-		i := strings.LastIndexByte(name, '/')
-		var err error
-		n, _, err = fs.find(name[:i+1], false, true)
-		if err != nil {
-			return nil, err
-		}
-		if (n.mode & os.ModeDir) == 0 {
-			return nil, syscall.ENOTDIR
-		}
-		nameComp := name
-		if i >= 0 {
-			nameComp = name[i+1:]
-		}
-		validateNameComp(nameComp)
-		n = n.dirLookup(nameComp)
+	if name == "" {
+		return nil, "", nil
+	}
+	i := strings.LastIndexByte(name, '/')
+	parent, _, err = fs.find(name[:i+1], false, true)
+	if err != nil {
+		return nil, "", err
+	}
+	if (parent.mode & os.ModeDir) == 0 {
+		return nil, "", syscall.ENOTDIR
+	}
+	nameComp = name[i+1:]
+	validateNameComp(nameComp)
+	return parent, nameComp, nil
+}
+
+func (fs *InMemoryFileSystem) lstatNode(name string) (*node, error) {
+	parent, nameComp, err := fs.lookupParent(name)
+	if err != nil {
+		return nil, err
+	}
+	n := fs.root
+	if parent != nil {
+		n = parent.dirLookup(nameComp)
 		if n == nil {
 			return nil, os.ErrNotExist
 		}
-	} else {
-		n = fs.root
 	}
 	if n.err != nil {
 		return nil, n.err
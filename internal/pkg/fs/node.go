@@ -33,6 +33,16 @@ func (n *node) dirAppend(childN *node) {
 	n.extra = dir
 }
 
+func (n *node) dirRemove(childN *node) {
+	dir := n.extra.([]*node)
+	for i := range dir {
+		if dir[i] == childN {
+			n.extra = append(dir[:i:i], dir[i+1:]...)
+			return
+		}
+	}
+}
+
 func (n *node) dirLookup(nameComp string) *node {
 	dir := n.extra.([]*node)
 	for _, childN := range dir {
@@ -0,0 +1,68 @@
+package fs
+
+import (
+	"os"
+	"strings"
+	"syscall"
+)
+
+func (fs *osFileSystem) OpenFile(name string, flag int, perm os.FileMode) (FileDescriptor, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+// OpenFile should behave the same as os.OpenFile but operates on the virtual file system. The flags O_RDONLY, O_WRONLY, O_RDWR, O_APPEND,
+// O_CREATE, O_EXCL and O_TRUNC are supported, and perm is the mode of the regular file if it is created.
+func (fs *InMemoryFileSystem) OpenFile(name string, flag int, perm os.FileMode) (FileDescriptor, error) {
+	if (perm & os.ModeType) != 0 {
+		return nil, errBadMode
+	}
+	n, nameRem, err := fs.find(name, false, true)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	accessMode := flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	if nameRem == "" {
+		err = openExisting(n, flag, accessMode)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if (flag&os.O_CREATE) == 0 || strings.IndexByte(nameRem, '/') >= 0 {
+			return nil, os.ErrNotExist
+		}
+		validateNameComp(nameRem)
+		childN := &node{
+			extra: []byte{},
+			mode:  perm,
+			name:  nameRem,
+		}
+		n.dirAppend(childN)
+		n = childN
+	}
+	return &virtualFileDescriptor{
+		appendMode: (flag & os.O_APPEND) != 0,
+		node:       n,
+		writable:   accessMode != os.O_RDONLY,
+		writeOnly:  accessMode == os.O_WRONLY,
+	}, nil
+}
+
+// openExisting checks whether an existing file can be opened with flag, and truncates it if flag has O_TRUNC.
+func openExisting(n *node, flag, accessMode int) error {
+	if (flag&os.O_CREATE) != 0 && (flag&os.O_EXCL) != 0 {
+		return os.ErrExist
+	}
+	if n.errOpen != nil {
+		return n.errOpen
+	}
+	if n.mode.IsDir() && accessMode != os.O_RDONLY {
+		return syscall.EISDIR
+	}
+	if (flag & os.O_TRUNC) != 0 {
+		if !n.mode.IsRegular() {
+			return errBadMode
+		}
+		n.extra = []byte{}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func Test_OSFileSystem_OpenFile(t *testing.T) {
+	file, err := OS.OpenFile("", os.O_RDONLY, 0)
+	if err == nil {
+		file.Close()
+	}
+}
+
+func Test_VirtualFileSystem_OpenFile_Append(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"file": {
+			Content: []byte("hello"),
+		},
+	})
+	fd, err := fs.OpenFile("file", os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Error(err)
+	} else {
+		_, _ = fd.Write([]byte(" world"))
+		_, err = fd.Read(make([]byte, 1))
+		if err != syscall.EBADF {
+			t.Error(err)
+		}
+		fd, _ = fs.Open("file")
+		data, _ := ioutil.ReadAll(fd)
+		if string(data) != "hello world" {
+			t.Error(string(data))
+		}
+	}
+}
+
+func Test_VirtualFileSystem_OpenFile_CreatePerm(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{})
+	_, err := fs.OpenFile("file", os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		t.Error(err)
+	} else {
+		fileInfo, _ := fs.Stat("file")
+		if fileInfo.Mode() != 0600 {
+			t.Error(fileInfo.Mode())
+		}
+	}
+}
+
+func Test_VirtualFileSystem_OpenFile_ENOENT(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{})
+	_, err := fs.OpenFile("file", os.O_RDWR, 0)
+	if !os.IsNotExist(err) {
+		t.Error(err)
+	}
+}
+
+func Test_VirtualFileSystem_OpenFile_EEXIST(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"file": {},
+	})
+	_, err := fs.OpenFile("file", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0)
+	if !os.IsExist(err) {
+		t.Error(err)
+	}
+}
+
+func Test_VirtualFileSystem_OpenFile_TruncateErrorBadMode(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"device": {
+			Mode: os.ModeDevice,
+		},
+	})
+	_, err := fs.OpenFile("device", os.O_WRONLY|os.O_TRUNC, 0)
+	if err != errBadMode {
+		t.Error(err)
+	}
+}
+
+func Test_VirtualFileSystem_OpenFile_ErrorBadMode(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{})
+	_, err := fs.OpenFile("file", os.O_WRONLY|os.O_CREATE, os.ModeSymlink)
+	if err != errBadMode {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,52 @@
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+func (fs *osFileSystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (fs *osFileSystem) RemoveAll(name string) error {
+	return os.RemoveAll(name)
+}
+
+func (fs *InMemoryFileSystem) removeCommon(name string, all bool) error {
+	parent, nameComp, err := fs.lookupParent(name)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		return syscall.EBUSY
+	}
+	n := parent.dirLookup(nameComp)
+	if n == nil {
+		return os.ErrNotExist
+	}
+	if n.err != nil {
+		return n.err
+	}
+	if !all && n.mode.IsDir() && len(n.extra.([]*node)) > 0 {
+		return syscall.ENOTEMPTY
+	}
+	parent.dirRemove(n)
+	return nil
+}
+
+// Remove should behave the same as os.Remove but operates on the virtual file system. Like os.Remove, symlinks are removed instead of
+// the files they point to, and directories must be empty.
+func (fs *InMemoryFileSystem) Remove(name string) error {
+	return fs.removeCommon(name, false)
+}
+
+// RemoveAll should behave the same as os.RemoveAll but operates on the virtual file system. Like os.RemoveAll, nil is returned if name
+// does not exist.
+func (fs *InMemoryFileSystem) RemoveAll(name string) error {
+	err := fs.removeCommon(name, true)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
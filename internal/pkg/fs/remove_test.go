@@ -0,0 +1,103 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func Test_OSFileSystem_Remove(t *testing.T) {
+	_ = OS.Remove("")
+}
+
+func Test_OSFileSystem_RemoveAll(t *testing.T) {
+	_ = OS.RemoveAll("")
+}
+
+func Test_VirtualFileSystem_Remove_Success(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"dir/file": {},
+		"dir/link": {
+			Content: []byte("file"),
+			Mode:    os.ModeSymlink,
+		},
+	})
+	err := fs.Remove("dir/link")
+	if err != nil {
+		t.Error(err)
+	}
+	err = fs.Remove("dir/file")
+	if err != nil {
+		t.Error(err)
+	}
+	err = fs.Remove("dir")
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = fs.Lstat("dir")
+	if !os.IsNotExist(err) {
+		t.Error(err)
+	}
+}
+
+func Test_VirtualFileSystem_Remove_ENOTEMPTY(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"dir/file": {},
+	})
+	err := fs.Remove("dir")
+	if err != syscall.ENOTEMPTY {
+		t.Error(err)
+	}
+}
+
+func Test_VirtualFileSystem_Remove_ENOENT(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{})
+	err := fs.Remove("file")
+	if !os.IsNotExist(err) {
+		t.Error(err)
+	}
+}
+
+func Test_VirtualFileSystem_Remove_Root(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{})
+	err := fs.Remove("/")
+	if err != syscall.EBUSY {
+		t.Error(err)
+	}
+}
+
+func Test_VirtualFileSystem_Remove_ErrorInjectedFault(t *testing.T) {
+	errExpected := fmt.Errorf("injectedFault")
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"file": {
+			Error: errExpected,
+		},
+	})
+	err := fs.Remove("file")
+	if err != errExpected {
+		t.Error(err)
+	}
+}
+
+func Test_VirtualFileSystem_RemoveAll_Success(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"dir/subdir/file": {},
+	})
+	err := fs.RemoveAll("dir")
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = fs.Stat("dir/subdir/file")
+	if !os.IsNotExist(err) {
+		t.Error(err)
+	}
+}
+
+func Test_VirtualFileSystem_RemoveAll_ENOENT(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{})
+	err := fs.RemoveAll("dir/file")
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,73 @@
+package fs
+
+import (
+	"os"
+	"strings"
+	"syscall"
+)
+
+func (fs *osFileSystem) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+// Rename should behave the same as os.Rename on Unix but operates on the virtual file system. If newpath exists it is replaced, unless
+// one of oldpath and newpath is a directory and the other is not, or newpath is a non-empty directory.
+func (fs *InMemoryFileSystem) Rename(oldpath, newpath string) error {
+	oldParent, oldNameComp, err := fs.lookupParent(oldpath)
+	if err != nil {
+		return err
+	}
+	newParent, newNameComp, err := fs.lookupParent(newpath)
+	if err != nil {
+		return err
+	}
+	if oldParent == nil || newParent == nil {
+		return syscall.EBUSY
+	}
+	n := oldParent.dirLookup(oldNameComp)
+	if n == nil {
+		return os.ErrNotExist
+	}
+	if n.err != nil {
+		return n.err
+	}
+	existing := newParent.dirLookup(newNameComp)
+	if existing == n {
+		return nil
+	}
+	if n.mode.IsDir() && strings.HasPrefix(fs.abs(trimTrailingSlashes(newpath))+"/", fs.abs(trimTrailingSlashes(oldpath))+"/") {
+		// A directory cannot become a subdirectory of itself.
+		return syscall.EINVAL
+	}
+	if existing != nil {
+		err = checkRenameReplace(n, existing)
+		if err != nil {
+			return err
+		}
+		newParent.dirRemove(existing)
+	}
+	oldParent.dirRemove(n)
+	n.name = newNameComp
+	newParent.dirAppend(n)
+	return nil
+}
+
+// checkRenameReplace returns an error if the file n cannot replace the existing file by renaming.
+func checkRenameReplace(n, existing *node) error {
+	if existing.err != nil {
+		return existing.err
+	}
+	if !existing.mode.IsDir() {
+		if n.mode.IsDir() {
+			return syscall.ENOTDIR
+		}
+		return nil
+	}
+	if !n.mode.IsDir() {
+		return syscall.EISDIR
+	}
+	if len(existing.extra.([]*node)) > 0 {
+		return syscall.ENOTEMPTY
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func Test_OSFileSystem_Rename(t *testing.T) {
+	_ = OS.Rename("", "")
+}
+
+func Test_VirtualFileSystem_Rename_Success(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"dir1/file": {
+			Content: []byte("content"),
+		},
+		"dir2": {
+			Mode: os.ModeDir,
+		},
+	})
+	err := fs.Rename("dir1/file", "dir2/file2")
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = fs.Stat("dir1/file")
+	if !os.IsNotExist(err) {
+		t.Error(err)
+	}
+	fileInfo, err := fs.Stat("dir2/file2")
+	if err != nil {
+		t.Error(err)
+	} else if fileInfo.Name() != "file2" || fileInfo.Size() != 7 {
+		t.Error(fileInfo)
+	}
+}
+
+func Test_VirtualFileSystem_Rename_ReplacesFile(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"file1": {
+			Content: []byte("new"),
+		},
+		"file2": {
+			Content: []byte("old content"),
+		},
+	})
+	err := fs.Rename("file1", "file2")
+	if err != nil {
+		t.Error(err)
+	}
+	fd, _ := fs.Open("file2")
+	data, _ := ioutil.ReadAll(fd)
+	if string(data) != "new" {
+		t.Error(string(data))
+	}
+	if dir := fs.root.extra.([]*node); len(dir) != 1 {
+		t.Error(dir)
+	}
+}
+
+func Test_VirtualFileSystem_Rename_Self(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"dir": {
+			Mode: os.ModeDir,
+		},
+	})
+	err := fs.Rename("dir", "dir")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_VirtualFileSystem_Rename_EINVAL(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"dir": {
+			Mode: os.ModeDir,
+		},
+	})
+	err := fs.Rename("dir", "dir/subdir")
+	if err != syscall.EINVAL {
+		t.Error(err)
+	}
+}
+
+func Test_VirtualFileSystem_Rename_EISDIR(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"dir": {
+			Mode: os.ModeDir,
+		},
+		"file": {},
+	})
+	err := fs.Rename("file", "dir")
+	if err != syscall.EISDIR {
+		t.Error(err)
+	}
+}
+
+func Test_VirtualFileSystem_Rename_ENOTDIR(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"dir": {
+			Mode: os.ModeDir,
+		},
+		"file": {},
+	})
+	err := fs.Rename("dir", "file")
+	if err != syscall.ENOTDIR {
+		t.Error(err)
+	}
+}
+
+func Test_VirtualFileSystem_Rename_ENOTEMPTY(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{
+		"dir1": {
+			Mode: os.ModeDir,
+		},
+		"dir2/file": {},
+	})
+	err := fs.Rename("dir1", "dir2")
+	if err != syscall.ENOTEMPTY {
+		t.Error(err)
+	}
+}
+
+func Test_VirtualFileSystem_Rename_ENOENT(t *testing.T) {
+	fs := NewInMemoryUnixFileSystem(map[string]InMemoryFile{})
+	err := fs.Rename("file1", "file2")
+	if !os.IsNotExist(err) {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package keychain stores secrets in the keychain of the operating system, so that secrets are never stored in plaintext files. On macOS
+// the login keychain is accessed with the security executable, and on other Unix systems the Secret Service (e.g. GNOME Keyring or
+// KWallet) is accessed with the secret-tool executable of libsecret. Secrets are never passed as command line arguments, because those
+// are visible to other users.
+package keychain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+// The exit code of the security executable if an item does not exist.
+const securityExitCodeNotFound = 44
+
+// ErrUnsupported is returned if the keychain of the operating system cannot be accessed.
+var ErrUnsupported = errors.New("the keychain of the operating system is not supported on " + runtime.GOOS)
+
+// goos is the operating system. It is a variable to improve testability.
+var goos = runtime.GOOS
+
+// execCommand runs an executable with the specified arguments, writing stdin to its standard input, and returns its standard output and
+// exit code. The error is nil if the executable exited with a non-zero exit code. It is a variable to improve testability.
+var execCommand = func(stdin []byte, name string, args ...string) ([]byte, int, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return stdout, exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return stdout, 0, nil
+}
+
+// Item is an item of the keychain, which is identified by a service and an account. Items of kube-compose use their own service, so that
+// they cannot be mistaken for the credentials of other applications such as the docker CLI.
+type Item struct {
+	Service string
+	Account string
+}
+
+// run runs an executable, and returns an error if it exits with an exit code other than 0 and allowedExitCode.
+func run(stdin []byte, allowedExitCode int, name string, args ...string) ([]byte, int, error) {
+	stdout, exitCode, err := execCommand(stdin, name, args...)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "error while running %s", name)
+	}
+	if exitCode != 0 && exitCode != allowedExitCode {
+		return nil, 0, fmt.Errorf("%s exited with exit code %d", name, exitCode)
+	}
+	return stdout, exitCode, nil
+}
+
+// Get returns the data of the item, or nil if the item does not exist.
+func (i *Item) Get() ([]byte, error) {
+	var stdout []byte
+	var err error
+	switch goos {
+	case "windows":
+		return nil, ErrUnsupported
+	case "darwin":
+		var exitCode int
+		stdout, exitCode, err = run(nil, securityExitCodeNotFound, "security", "find-generic-password", "-s", i.Service, "-a", i.Account,
+			"-w")
+		if err != nil || exitCode == securityExitCodeNotFound {
+			return nil, err
+		}
+	default:
+		// secret-tool exits with exit code 1 and no output if the item does not exist.
+		stdout, _, err = run(nil, 1, "secret-tool", "lookup", "service", i.Service, "account", i.Account)
+		if err != nil || len(stdout) == 0 {
+			return nil, err
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(stdout)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid data of keychain item %s", i.Service)
+	}
+	return data, nil
+}
+
+// Set sets the data of the item, creating the item if it does not exist. The data is base64 encoded, because keychains store text.
+func (i *Item) Set(data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var err error
+	switch goos {
+	case "windows":
+		return ErrUnsupported
+	case "darwin":
+		// The commands of security -i are read from standard input, and -X specifies the password in hexadecimal so that it does not
+		// need to be quoted.
+		_, _, err = run([]byte(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", i.Service, i.Account,
+			hex.EncodeToString([]byte(encoded)))), 0, "security", "-i")
+	default:
+		_, _, err = run([]byte(encoded), 0, "secret-tool", "store", "--label", i.Service, "service", i.Service, "account", i.Account)
+	}
+	return err
+}
+
+// Delete deletes the item. Items that do not exist are ignored.
+func (i *Item) Delete() error {
+	var err error
+	switch goos {
+	case "windows":
+		return ErrUnsupported
+	case "darwin":
+		_, _, err = run(nil, securityExitCodeNotFound, "security", "delete-generic-password", "-s", i.Service, "-a", i.Account)
+	default:
+		_, _, err = run(nil, 0, "secret-tool", "clear", "service", i.Service, "account", i.Account)
+	}
+	return err
+}
@@ -0,0 +1,130 @@
+package keychain
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// withMockCommand runs cb with the operating system goosMock, and executables that are mocked by execMock.
+func withMockCommand(goosMock string, execMock func(stdin []byte, name string, args ...string) ([]byte, int, error), cb func()) {
+	origGOOS := goos
+	origExecCommand := execCommand
+	defer func() {
+		goos = origGOOS
+		execCommand = origExecCommand
+	}()
+	goos = goosMock
+	execCommand = execMock
+	cb()
+}
+
+var testItem = &Item{
+	Service: "kube-compose",
+	Account: "registry-tokens",
+}
+
+func TestItemGet_Darwin(t *testing.T) {
+	withMockCommand("darwin", func(_ []byte, name string, args ...string) ([]byte, int, error) {
+		if name != "security" || !reflect.DeepEqual(args, []string{"find-generic-password", "-s", "kube-compose", "-a",
+			"registry-tokens", "-w"}) {
+			t.Error(name, args)
+		}
+		return []byte(base64.StdEncoding.EncodeToString([]byte("data")) + "\n"), 0, nil
+	}, func() {
+		data, err := testItem.Get()
+		if err != nil || string(data) != "data" {
+			t.Error(string(data), err)
+		}
+	})
+}
+
+func TestItemGet_DarwinNotFound(t *testing.T) {
+	withMockCommand("darwin", func(_ []byte, _ string, _ ...string) ([]byte, int, error) {
+		return nil, securityExitCodeNotFound, nil
+	}, func() {
+		data, err := testItem.Get()
+		if err != nil || data != nil {
+			t.Error(data, err)
+		}
+	})
+}
+
+func TestItemGet_LinuxNotFound(t *testing.T) {
+	withMockCommand("linux", func(_ []byte, name string, _ ...string) ([]byte, int, error) {
+		if name != "secret-tool" {
+			t.Error(name)
+		}
+		return nil, 1, nil
+	}, func() {
+		data, err := testItem.Get()
+		if err != nil || data != nil {
+			t.Error(data, err)
+		}
+	})
+}
+
+func TestItemGet_LinuxError(t *testing.T) {
+	withMockCommand("linux", func(_ []byte, _ string, _ ...string) ([]byte, int, error) {
+		return nil, 2, nil
+	}, func() {
+		_, err := testItem.Get()
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestItemGet_Windows(t *testing.T) {
+	withMockCommand("windows", nil, func() {
+		_, err := testItem.Get()
+		if err != ErrUnsupported {
+			t.Error(err)
+		}
+	})
+}
+
+func TestItemSet_Darwin(t *testing.T) {
+	withMockCommand("darwin", func(stdin []byte, name string, args ...string) ([]byte, int, error) {
+		encoded := hex.EncodeToString([]byte(base64.StdEncoding.EncodeToString([]byte("secret"))))
+		if name != "security" || !reflect.DeepEqual(args, []string{"-i"}) ||
+			string(stdin) != "add-generic-password -U -s kube-compose -a registry-tokens -X "+encoded+"\n" {
+			t.Error(name, args, string(stdin))
+		}
+		return nil, 0, nil
+	}, func() {
+		err := testItem.Set([]byte("secret"))
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestItemSet_Linux(t *testing.T) {
+	withMockCommand("linux", func(stdin []byte, name string, args ...string) ([]byte, int, error) {
+		// The secret is written to standard input, and is not an argument.
+		if name != "secret-tool" || args[0] != "store" || strings.Contains(strings.Join(args, " "), string(stdin)) ||
+			string(stdin) != base64.StdEncoding.EncodeToString([]byte("secret")) {
+			t.Error(name, args, string(stdin))
+		}
+		return nil, 0, nil
+	}, func() {
+		err := testItem.Set([]byte("secret"))
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestItemDelete_DarwinNotFound(t *testing.T) {
+	withMockCommand("darwin", func(_ []byte, _ string, _ ...string) ([]byte, int, error) {
+		return nil, securityExitCodeNotFound, nil
+	}, func() {
+		err := testItem.Delete()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
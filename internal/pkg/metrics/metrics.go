@@ -0,0 +1,256 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultDurationBuckets are the upper bounds (in seconds) of the buckets of histograms that measure durations of operations against a
+// docker daemon or a Kubernetes cluster.
+var DefaultDurationBuckets = []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+// Registry is a minimal collection of counters and histograms that can be exposed in the Prometheus text exposition format:
+// https://prometheus.io/docs/instrumenting/exposition_formats/#text-based-format.
+// All methods are safe for concurrent use.
+type Registry struct {
+	mutex      sync.Mutex
+	collectors []collector
+}
+
+type collector interface {
+	write(w io.Writer) error
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+type vecCommon struct {
+	help       string
+	labelNames []string
+	name       string
+	r          *Registry
+}
+
+func (v *vecCommon) key(labelValues []string) string {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Errorf("metric %s expects %d label values but got %d", v.name, len(v.labelNames), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\x00")
+}
+
+func (v *vecCommon) writeHeader(w io.Writer, metricType string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, escapeHelp(v.help), v.name, metricType)
+	return err
+}
+
+// formatLabels formats label pairs, including any extra label (e.g. "le" for histogram buckets).
+func (v *vecCommon) formatLabels(key, extraName, extraValue string) string {
+	labelValues := strings.Split(key, "\x00")
+	var sb strings.Builder
+	for i, labelName := range v.labelNames {
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(labelName)
+		sb.WriteString("=\"")
+		sb.WriteString(escapeLabelValue(labelValues[i]))
+		sb.WriteByte('"')
+	}
+	if extraName != "" {
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(extraName)
+		sb.WriteString("=\"")
+		sb.WriteString(extraValue)
+		sb.WriteByte('"')
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	return "{" + sb.String() + "}"
+}
+
+// CounterVec is a family of counters partitioned by label values.
+type CounterVec struct {
+	vecCommon
+	values map[string]float64
+}
+
+// NewCounterVec registers a new family of counters.
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		vecCommon: vecCommon{
+			help:       help,
+			labelNames: labelNames,
+			name:       name,
+			r:          r,
+		},
+		values: map[string]float64{},
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.collectors = append(r.collectors, c)
+	return c
+}
+
+// Inc increments the counter identified by labelValues by one.
+func (c *CounterVec) Inc(labelValues ...string) {
+	key := c.key(labelValues)
+	c.r.mutex.Lock()
+	defer c.r.mutex.Unlock()
+	c.values[key]++
+}
+
+func (c *CounterVec) write(w io.Writer) error {
+	err := c.writeHeader(w, "counter")
+	if err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(c.values) {
+		_, err = fmt.Fprintf(w, "%s%s %s\n", c.name, c.formatLabels(key, "", ""), formatFloat(c.values[key]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type histogramValue struct {
+	bucketCounts []uint64
+	count        uint64
+	sum          float64
+}
+
+// HistogramVec is a family of histograms partitioned by label values.
+type HistogramVec struct {
+	vecCommon
+	buckets []float64
+	values  map[string]*histogramValue
+}
+
+// NewHistogramVec registers a new family of histograms. buckets are the upper bounds of the buckets and must be sorted in increasing
+// order.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	h := &HistogramVec{
+		vecCommon: vecCommon{
+			help:       help,
+			labelNames: labelNames,
+			name:       name,
+			r:          r,
+		},
+		buckets: buckets,
+		values:  map[string]*histogramValue{},
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.collectors = append(r.collectors, h)
+	return h
+}
+
+// Observe adds a single observation to the histogram identified by labelValues.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.r.mutex.Lock()
+	defer h.r.mutex.Unlock()
+	hv := h.values[key]
+	if hv == nil {
+		hv = &histogramValue{
+			bucketCounts: make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = hv
+	}
+	for i, upperBound := range h.buckets {
+		if v <= upperBound {
+			hv.bucketCounts[i]++
+		}
+	}
+	hv.count++
+	hv.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) error {
+	err := h.writeHeader(w, "histogram")
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		hv := h.values[key]
+		for i, upperBound := range h.buckets {
+			_, err = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.formatLabels(key, "le", formatFloat(upperBound)), hv.bucketCounts[i])
+			if err != nil {
+				return err
+			}
+		}
+		_, err = fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, h.formatLabels(key, "le", "+Inf"), hv.count,
+			h.name, h.formatLabels(key, "", ""), formatFloat(hv.sum),
+			h.name, h.formatLabels(key, "", ""), hv.count,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write writes all metrics of the registry in the Prometheus text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, c := range r.collectors {
+		err := c.write(w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP implements http.Handler so that a registry can be served as a /metrics endpoint.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_ = r.Write(w)
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var (
+	helpReplacer       = strings.NewReplacer("\\", "\\\\", "\n", "\\n")
+	labelValueReplacer = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\"", "\\\"")
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
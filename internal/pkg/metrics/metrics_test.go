@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCounterVec_Success(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_total", "A test counter.", "service")
+	c.Inc("b")
+	c.Inc("a")
+	c.Inc("a")
+	var buffer bytes.Buffer
+	err := r.Write(&buffer)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := `# HELP test_total A test counter.
+# TYPE test_total counter
+test_total{service="a"} 2
+test_total{service="b"} 1
+`
+	if buffer.String() != expected {
+		t.Error(buffer.String())
+	}
+}
+
+func TestCounterVec_EscapeLabelValue(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_total", "A\ntest counter.", "service")
+	c.Inc("\"\\\n")
+	var buffer bytes.Buffer
+	_ = r.Write(&buffer)
+	expected := `# HELP test_total A\ntest counter.
+# TYPE test_total counter
+test_total{service="\"\\\n"} 1
+`
+	if buffer.String() != expected {
+		t.Error(buffer.String())
+	}
+}
+
+func TestCounterVec_PanicsOnLabelCountMismatch(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_total", "A test counter.", "service")
+	defer func() {
+		if v := recover(); v == nil {
+			t.Fail()
+		}
+	}()
+	c.Inc()
+}
+
+func TestHistogramVec_Success(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("test_seconds", "A test histogram.", []float64{1, 5}, "service")
+	h.Observe(0.5, "a")
+	h.Observe(3, "a")
+	h.Observe(10, "a")
+	var buffer bytes.Buffer
+	err := r.Write(&buffer)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := `# HELP test_seconds A test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{service="a",le="1"} 1
+test_seconds_bucket{service="a",le="5"} 2
+test_seconds_bucket{service="a",le="+Inf"} 3
+test_seconds_sum{service="a"} 13.5
+test_seconds_count{service="a"} 3
+`
+	if buffer.String() != expected {
+		t.Error(buffer.String())
+	}
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "A test counter.").Inc()
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	expected := `# HELP test_total A test counter.
+# TYPE test_total counter
+test_total 1
+`
+	if recorder.Body.String() != expected {
+		t.Error(recorder.Body.String())
+	}
+}
@@ -0,0 +1,167 @@
+// Package mutate mutates the Kubernetes objects generated by kube-compose before they are applied, so that platform teams can centrally
+// inject sidecars, labels or security settings. Mutators are implemented in Go (see Mutator), by an executable (see Exec) or by a
+// webhook (see Webhook).
+package mutate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"reflect"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// Mutator mutates a Kubernetes object, which is given in its JSON decoded form. The returned object replaces the object, and may be the
+// given object after it was modified in place.
+type Mutator interface {
+	Mutate(obj map[string]interface{}) (map[string]interface{}, error)
+}
+
+// getIdentity returns the fields of an object that identify it, which mutators must not change.
+func getIdentity(obj map[string]interface{}) [4]string {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	return [4]string{
+		fmt.Sprint(obj["apiVersion"]),
+		fmt.Sprint(obj["kind"]),
+		fmt.Sprint(metadata["name"]),
+		fmt.Sprint(metadata["namespace"]),
+	}
+}
+
+// Apply applies mutators to an object in order. obj must be a pointer to a Kubernetes object that JSON encodes with its apiVersion and
+// kind set (such as a *v1.Pod), or the map[string]interface{} of an unstructured object. The object is updated in place. An error is
+// returned if a mutator changes the apiVersion, kind, name or namespace of the object.
+func Apply(mutators []Mutator, obj interface{}) error {
+	if len(mutators) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return err
+	}
+	identity := getIdentity(m)
+	for _, mutator := range mutators {
+		m, err = mutator.Mutate(m)
+		if err != nil {
+			return errors.Wrapf(err, "error while mutating %s %s", identity[1], identity[2])
+		}
+		if getIdentity(m) != identity {
+			return fmt.Errorf("a mutator changed the apiVersion, kind, name or namespace of %s %s, which is not allowed", identity[1],
+				identity[2])
+		}
+	}
+	if u, ok := obj.(map[string]interface{}); ok {
+		for key := range u {
+			delete(u, key)
+		}
+		for key, value := range m {
+			u[key] = value
+		}
+		return nil
+	}
+	data, err = json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	// Reset the object, so that fields that were removed by mutators are removed from the object.
+	v := reflect.ValueOf(obj).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	return json.Unmarshal(data, obj)
+}
+
+func decodeObject(data []byte) (map[string]interface{}, error) {
+	var obj map[string]interface{}
+	err := json.Unmarshal(data, &obj)
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	return obj, nil
+}
+
+// execCommand runs an executable, writing stdin to its standard input and returning its standard output. It is a variable to improve
+// testability.
+var execCommand = func(stdin []byte, name string) ([]byte, error) {
+	cmd := exec.Command(name)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s", bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout, nil
+}
+
+// Exec is a mutator that runs an executable for each object. The JSON of the object is written to the standard input of the executable,
+// which must write the JSON of the mutated object to its standard output.
+type Exec struct {
+	Command string
+}
+
+func (e *Exec) Mutate(obj map[string]interface{}) (map[string]interface{}, error) {
+	input, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := execCommand(input, e.Command)
+	if err != nil {
+		return nil, errors.Wrapf(err, "mutator %#v failed", e.Command)
+	}
+	mutated, err := decodeObject(stdout)
+	return mutated, errors.Wrapf(err, "mutator %#v wrote invalid output", e.Command)
+}
+
+// The timeout of requests to webhooks.
+const webhookTimeout = 10 * time.Second
+
+// Webhook is a mutator that posts the JSON of each object to a URL. The response must have status 200 and the JSON of the mutated object
+// as its body.
+type Webhook struct {
+	Client *http.Client
+	URL    string
+}
+
+// NewWebhook creates a webhook mutator that posts objects to url.
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		Client: &http.Client{
+			Timeout: webhookTimeout,
+		},
+		URL: url,
+	}
+}
+
+func (w *Webhook) Mutate(obj map[string]interface{}) (map[string]interface{}, error) {
+	input, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	response, err := w.Client.Post(w.URL, "application/json", bytes.NewReader(input))
+	if err != nil {
+		return nil, errors.Wrapf(err, "mutating webhook %s failed", w.URL)
+	}
+	defer util.CloseAndLogError(response.Body)
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "mutating webhook %s failed", w.URL)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("mutating webhook %s responded with status %s: %s", w.URL, response.Status, bytes.TrimSpace(body))
+	}
+	mutated, err := decodeObject(body)
+	return mutated, errors.Wrapf(err, "mutating webhook %s responded with an invalid body", w.URL)
+}
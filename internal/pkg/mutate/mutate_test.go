@@ -0,0 +1,169 @@
+package mutate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type mutatorFunc func(obj map[string]interface{}) (map[string]interface{}, error)
+
+func (f mutatorFunc) Mutate(obj map[string]interface{}) (map[string]interface{}, error) {
+	return f(obj)
+}
+
+func withMockCommand(mock func(stdin []byte, name string) ([]byte, error), cb func()) {
+	orig := execCommand
+	defer func() {
+		execCommand = orig
+	}()
+	execCommand = mock
+	cb()
+}
+
+func newTestPod() *v1.Pod {
+	return &v1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"app":    "web",
+				"remove": "me",
+			},
+			Name: "web-env1",
+		},
+	}
+}
+
+// setLabels is a mutator that adds the label team=platform and removes the label remove.
+var setLabels = mutatorFunc(func(obj map[string]interface{}) (map[string]interface{}, error) {
+	labels := obj["metadata"].(map[string]interface{})["labels"].(map[string]interface{})
+	labels["team"] = "platform"
+	delete(labels, "remove")
+	return obj, nil
+})
+
+func TestApply_TypedObject(t *testing.T) {
+	pod := newTestPod()
+	err := Apply([]Mutator{setLabels}, pod)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := map[string]string{
+		"app":  "web",
+		"team": "platform",
+	}
+	if !reflect.DeepEqual(pod.ObjectMeta.Labels, expected) || pod.ObjectMeta.Name != "web-env1" {
+		t.Error(pod.ObjectMeta)
+	}
+}
+
+func TestApply_Unstructured(t *testing.T) {
+	obj := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{},
+			"name":   "config",
+		},
+	}
+	err := Apply([]Mutator{setLabels}, obj)
+	if err != nil {
+		t.Error(err)
+	}
+	labels := obj["metadata"].(map[string]interface{})["labels"]
+	if !reflect.DeepEqual(labels, map[string]interface{}{"team": "platform"}) {
+		t.Error(labels)
+	}
+}
+
+func TestApply_IdentityChanged(t *testing.T) {
+	rename := mutatorFunc(func(obj map[string]interface{}) (map[string]interface{}, error) {
+		obj["metadata"].(map[string]interface{})["name"] = "other"
+		return obj, nil
+	})
+	pod := newTestPod()
+	err := Apply([]Mutator{rename}, pod)
+	if err == nil || pod.ObjectMeta.Name != "web-env1" {
+		t.Error(err, pod.ObjectMeta.Name)
+	}
+}
+
+func TestApply_Error(t *testing.T) {
+	fail := mutatorFunc(func(obj map[string]interface{}) (map[string]interface{}, error) {
+		return nil, fmt.Errorf("mutator error")
+	})
+	err := Apply([]Mutator{fail}, newTestPod())
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestExec_Success(t *testing.T) {
+	withMockCommand(func(stdin []byte, name string) ([]byte, error) {
+		if name != "./mutate.sh" {
+			t.Error(name)
+		}
+		var obj map[string]interface{}
+		_ = json.Unmarshal(stdin, &obj)
+		obj["spec"] = map[string]interface{}{
+			"priorityClassName": "low",
+		}
+		return json.Marshal(obj)
+	}, func() {
+		pod := newTestPod()
+		err := Apply([]Mutator{&Exec{Command: "./mutate.sh"}}, pod)
+		if err != nil || pod.Spec.PriorityClassName != "low" {
+			t.Error(err, pod.Spec)
+		}
+	})
+}
+
+func TestExec_InvalidOutput(t *testing.T) {
+	withMockCommand(func(stdin []byte, name string) ([]byte, error) {
+		return []byte("null"), nil
+	}, func() {
+		_, err := (&Exec{Command: "./mutate.sh"}).Mutate(map[string]interface{}{})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestWebhook_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var obj map[string]interface{}
+		_ = json.Unmarshal(body, &obj)
+		obj["metadata"].(map[string]interface{})["annotations"] = map[string]interface{}{
+			"mutated": "true",
+		}
+		_ = json.NewEncoder(w).Encode(obj)
+	}))
+	defer server.Close()
+	pod := newTestPod()
+	err := Apply([]Mutator{NewWebhook(server.URL)}, pod)
+	if err != nil || pod.ObjectMeta.Annotations["mutated"] != "true" {
+		t.Error(err, pod.ObjectMeta)
+	}
+}
+
+func TestWebhook_Status(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "denied", http.StatusForbidden)
+	}))
+	defer server.Close()
+	_, err := NewWebhook(server.URL).Mutate(map[string]interface{}{})
+	if err == nil {
+		t.Fail()
+	}
+}
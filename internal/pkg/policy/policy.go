@@ -0,0 +1,199 @@
+// Package policy evaluates Kubernetes objects against user-provided Rego policies
+// (https://www.openpolicyagent.org/docs/latest/policy-language/), so that platform guardrails are enforced before objects are applied.
+// Evaluation is delegated to the opa executable. Policies follow the conventions of conftest (https://www.conftest.dev/): the rules named
+// deny of the package main produce the violation messages of the object that is the input.
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// Query is the Rego query whose results are the violation messages of an object.
+const Query = "data.main.deny"
+
+// execOPA runs opa with the specified arguments, writing stdin to its standard input and returning its standard output. It is a
+// variable to improve testability.
+var execOPA = func(stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("opa", args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s", bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout, nil
+}
+
+// Policies is a directory of Rego policies.
+type Policies struct {
+	dir string
+}
+
+// ViolationError is the error returned when an object violates policies.
+type ViolationError struct {
+	Kind     string
+	Messages []string
+	Name     string
+}
+
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("%s %s violates policies: %s", e.Kind, e.Name, strings.Join(e.Messages, "; "))
+}
+
+// findPolicyFiles returns the files in dir (recursively) that have one of the extensions .rego and .cel.
+func findPolicyFiles(dir string) (regoFiles, celFiles []string, err error) {
+	fd, err := fs.OS.Open(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	fileInfos, err := fd.Readdir(-1)
+	util.CloseAndLogError(fd)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, fileInfo := range fileInfos {
+		file := filepath.Join(dir, fileInfo.Name())
+		if fileInfo.IsDir() {
+			regoFilesChild, celFilesChild, err := findPolicyFiles(file)
+			if err != nil {
+				return nil, nil, err
+			}
+			regoFiles = append(regoFiles, regoFilesChild...)
+			celFiles = append(celFiles, celFilesChild...)
+			continue
+		}
+		switch filepath.Ext(file) {
+		case ".rego":
+			regoFiles = append(regoFiles, file)
+		case ".cel":
+			celFiles = append(celFiles, file)
+		}
+	}
+	return regoFiles, celFiles, nil
+}
+
+// Load loads the policies in dir. An error is returned if dir does not contain Rego policies, or contains CEL policies (which are not
+// supported), so that guardrails are never silently skipped.
+func Load(dir string) (*Policies, error) {
+	regoFiles, celFiles, err := findPolicyFiles(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while loading policies from %#v", dir)
+	}
+	if len(celFiles) > 0 {
+		sort.Strings(celFiles)
+		return nil, fmt.Errorf("policy %#v is a CEL policy, but only Rego policies are supported", celFiles[0])
+	}
+	if len(regoFiles) == 0 {
+		return nil, fmt.Errorf("the policy directory %#v does not contain Rego policies (files with the extension .rego)", dir)
+	}
+	return &Policies{
+		dir: dir,
+	}, nil
+}
+
+type evalOutput struct {
+	Result []struct {
+		Expressions []struct {
+			Value []interface{} `json:"value"`
+		} `json:"expressions"`
+	} `json:"result"`
+}
+
+// formatMessage formats a result of a deny rule. Rules can produce strings, or objects with the message in the field msg.
+func formatMessage(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if msg, ok := v["msg"].(string); ok {
+			return msg
+		}
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// Evaluate evaluates an object against the policies. obj must JSON encode to a Kubernetes object with its apiVersion and kind set. If
+// the object violates policies then a *ViolationError is returned.
+func (p *Policies) Evaluate(obj interface{}) error {
+	input, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	var meta struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}
+	err = json.Unmarshal(input, &meta)
+	if err != nil {
+		return err
+	}
+	stdout, err := execOPA(input, "eval", "--format", "json", "--data", p.dir, "--stdin-input", Query)
+	if err != nil {
+		return errors.Wrap(err, "error while evaluating policies with opa (is opa installed?)")
+	}
+	var output evalOutput
+	err = json.Unmarshal(stdout, &output)
+	if err != nil {
+		return errors.Wrap(err, "error while decoding the output of opa")
+	}
+	var messages []string
+	for _, result := range output.Result {
+		for _, expression := range result.Expressions {
+			for _, value := range expression.Value {
+				messages = append(messages, formatMessage(value))
+			}
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	sort.Strings(messages)
+	return &ViolationError{
+		Kind:     meta.Kind,
+		Messages: messages,
+		Name:     meta.Metadata.Name,
+	}
+}
+
+// Violations is the error returned when objects violate policies, with the violations of each object.
+type Violations []*ViolationError
+
+func (v Violations) Error() string {
+	messages := make([]string, len(v))
+	for i, violationError := range v {
+		messages[i] = violationError.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// EvaluateAll evaluates objects against the policies (see Evaluate), so that the violations of all objects are reported at once. If objects
+// violate policies then Violations is returned. Other errors are returned immediately.
+func (p *Policies) EvaluateAll(objs []interface{}) error {
+	var violations Violations
+	for _, obj := range objs {
+		err := p.Evaluate(obj)
+		if violationError, ok := err.(*ViolationError); ok {
+			violations = append(violations, violationError)
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return violations
+}
@@ -0,0 +1,219 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func withMockFS(vfsMock fs.VirtualFileSystem, cb func()) {
+	orig := fs.OS
+	defer func() {
+		fs.OS = orig
+	}()
+	fs.OS = vfsMock
+	cb()
+}
+
+func withMockOPA(mock func(stdin []byte, args ...string) ([]byte, error), cb func()) {
+	orig := execOPA
+	defer func() {
+		execOPA = orig
+	}()
+	execOPA = mock
+	cb()
+}
+
+var testPod = map[string]interface{}{
+	"apiVersion": "v1",
+	"kind":       "Pod",
+	"metadata": map[string]interface{}{
+		"name": "web-env1",
+	},
+}
+
+func TestLoad_Success(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/policies/pods/deny.rego": {
+			Content: []byte("package main\n"),
+		},
+		"/policies/README.md": {},
+	}), func() {
+		p, err := Load("/policies")
+		if err != nil {
+			t.Error(err)
+		} else if p.dir != "/policies" {
+			t.Error(p.dir)
+		}
+	})
+}
+
+func TestLoad_CELPolicy(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/policies/deny.rego": {},
+		"/policies/deny.cel":  {},
+	}), func() {
+		_, err := Load("/policies")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestLoad_NoPolicies(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/policies": {
+			Mode: os.ModeDir,
+		},
+	}), func() {
+		_, err := Load("/policies")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestLoad_DirectoryDoesNotExist(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{}), func() {
+		_, err := Load("/policies")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestEvaluate_Violations(t *testing.T) {
+	withMockOPA(func(stdin []byte, args ...string) ([]byte, error) {
+		expectedArgs := []string{"eval", "--format", "json", "--data", "/policies", "--stdin-input", Query}
+		if !reflect.DeepEqual(args, expectedArgs) {
+			t.Error(args)
+		}
+		return []byte(`{"result":[{"expressions":[{"value":["pods must set resource limits",{"msg":"privileged containers ` +
+			`are not allowed"}],"text":"data.main.deny"}]}]}`), nil
+	}, func() {
+		p := &Policies{
+			dir: "/policies",
+		}
+		err := p.Evaluate(testPod)
+		if violationError, ok := err.(*ViolationError); !ok {
+			t.Error(err)
+		} else {
+			expected := &ViolationError{
+				Kind: "Pod",
+				Messages: []string{
+					"pods must set resource limits",
+					"privileged containers are not allowed",
+				},
+				Name: "web-env1",
+			}
+			if !reflect.DeepEqual(violationError, expected) {
+				t.Error(violationError)
+			}
+		}
+	})
+}
+
+func TestEvaluate_NoViolations(t *testing.T) {
+	withMockOPA(func(stdin []byte, args ...string) ([]byte, error) {
+		return []byte(`{"result":[{"expressions":[{"value":[],"text":"data.main.deny"}]}]}`), nil
+	}, func() {
+		p := &Policies{
+			dir: "/policies",
+		}
+		err := p.Evaluate(testPod)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestEvaluate_Undefined(t *testing.T) {
+	withMockOPA(func(stdin []byte, args ...string) ([]byte, error) {
+		return []byte(`{}`), nil
+	}, func() {
+		p := &Policies{
+			dir: "/policies",
+		}
+		err := p.Evaluate(testPod)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestEvaluate_OPAError(t *testing.T) {
+	withMockOPA(func(stdin []byte, args ...string) ([]byte, error) {
+		return nil, fmt.Errorf("rego_parse_error")
+	}, func() {
+		p := &Policies{
+			dir: "/policies",
+		}
+		err := p.Evaluate(testPod)
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestEvaluateAll_Violations(t *testing.T) {
+	withMockOPA(func(stdin []byte, args ...string) ([]byte, error) {
+		if string(stdin) == `{"apiVersion":"v1","kind":"Service","metadata":{"name":"web-env1"}}` {
+			return []byte(`{}`), nil
+		}
+		return []byte(`{"result":[{"expressions":[{"value":["denied"],"text":"data.main.deny"}]}]}`), nil
+	}, func() {
+		p := &Policies{
+			dir: "/policies",
+		}
+		testService := map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata": map[string]interface{}{
+				"name": "web-env1",
+			},
+		}
+		testSecret := map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]interface{}{
+				"name": "web-env1",
+			},
+		}
+		err := p.EvaluateAll([]interface{}{testPod, testService, testSecret})
+		if violations, ok := err.(Violations); !ok || len(violations) != 2 || violations[0].Kind != "Pod" ||
+			violations[1].Kind != "Secret" {
+			t.Error(err)
+		}
+	})
+}
+
+func TestEvaluateAll_NoViolations(t *testing.T) {
+	withMockOPA(func(stdin []byte, args ...string) ([]byte, error) {
+		return []byte(`{}`), nil
+	}, func() {
+		p := &Policies{
+			dir: "/policies",
+		}
+		err := p.EvaluateAll([]interface{}{testPod})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestEvaluateAll_OPAError(t *testing.T) {
+	withMockOPA(func(stdin []byte, args ...string) ([]byte, error) {
+		return nil, fmt.Errorf("rego_parse_error")
+	}, func() {
+		p := &Policies{
+			dir: "/policies",
+		}
+		err := p.EvaluateAll([]interface{}{testPod})
+		if _, ok := err.(Violations); ok || err == nil {
+			t.Error(err)
+		}
+	})
+}
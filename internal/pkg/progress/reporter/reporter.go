@@ -31,6 +31,11 @@ var (
 		"▉",
 		"█",
 	}
+	StatusDockerBuild = &Status{
+		Text:      "building image",
+		TextWidth: 14,
+		Priority:  1,
+	}
 	StatusDockerPush = &Status{
 		Text:      "pushing image",
 		TextWidth: 13,
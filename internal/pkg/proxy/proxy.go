@@ -0,0 +1,38 @@
+// Package proxy selects the proxies of the connections of kube-compose with registries.
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Direct is the proxy of hosts that are connected to without a proxy.
+const Direct = "direct"
+
+// Func returns a function that selects the proxy of a request (see http.Transport.Proxy). overrides maps hosts (with or without a port)
+// to proxy URLs (e.g. http://proxy:3128 or socks5://proxy:1080), or to Direct. The proxy of a request to any other host is selected by
+// the environment variables HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+func Func(overrides map[string]string) (func(*http.Request) (*url.URL, error), error) {
+	proxies := map[string]*url.URL{}
+	for host, proxy := range overrides {
+		if proxy == Direct {
+			proxies[host] = nil
+			continue
+		}
+		u, err := url.Parse(proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("the proxy %#v of host %s must be a URL such as http://proxy:3128, or %s", proxy, host, Direct)
+		}
+		proxies[host] = u
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		if u, ok := proxies[req.URL.Host]; ok {
+			return u, nil
+		}
+		if u, ok := proxies[req.URL.Hostname()]; ok {
+			return u, nil
+		}
+		return http.ProxyFromEnvironment(req)
+	}, nil
+}
@@ -0,0 +1,44 @@
+package proxy
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestFunc_Success(t *testing.T) {
+	f, err := Func(map[string]string{
+		"registry.example.com":      "http://proxy:3128",
+		"registry.example.com:5000": Direct,
+		"socks.example.com":         "socks5://proxy:1080",
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for rawURL, expected := range map[string]string{
+		"https://registry.example.com/v2/":      "http://proxy:3128",
+		"https://registry.example.com:443/v2/":  "http://proxy:3128",
+		"https://registry.example.com:5000/v2/": "",
+		"https://socks.example.com/v2/":         "socks5://proxy:1080",
+	} {
+		u, _ := url.Parse(rawURL)
+		proxyURL, err := f(&http.Request{URL: u})
+		actual := ""
+		if proxyURL != nil {
+			actual = proxyURL.String()
+		}
+		if err != nil || actual != expected {
+			t.Error(rawURL, actual, err)
+		}
+	}
+}
+
+func TestFunc_Invalid(t *testing.T) {
+	_, err := Func(map[string]string{
+		"registry.example.com": "proxy",
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
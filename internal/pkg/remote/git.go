@@ -0,0 +1,78 @@
+package remote
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// gitPrefix is the prefix of git sources, which have the form git::<repository>//<path>[?ref=<branch or tag>].
+const gitPrefix = "git::"
+
+// runGit runs git with the specified arguments. It is a variable to improve testability.
+var runGit = func(args ...string) error {
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "git %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// GitFetcher fetches a docker compose file from a git repository, by making a shallow clone of the repository. Since the whole repository
+// is cloned, the files that the docker compose file refers to (such as env files) are fetched as well.
+type GitFetcher struct {
+}
+
+// Matches implements Fetcher.
+func (f *GitFetcher) Matches(source string) bool {
+	return strings.HasPrefix(source, gitPrefix)
+}
+
+// parseGitSource parses a source of the form git::<repository>//<path>[?ref=<branch or tag>]. The repository may be a URL (which has
+// its own //) or an scp-like address such as git@github.com:org/repo.git.
+func parseGitSource(source string) (repo, p, ref string, err error) {
+	s := strings.TrimPrefix(source, gitPrefix)
+	if i := strings.LastIndex(s, "?ref="); i >= 0 {
+		ref = s[i+len("?ref="):]
+		s = s[:i]
+	}
+	start := 0
+	if i := strings.Index(s, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	i := strings.Index(s[start:], "//")
+	if i < 0 || start+i == 0 || start+i+2 == len(s) {
+		return "", "", "", fmt.Errorf("the git source %s must have the form git::<repository>//<path>[?ref=<branch or tag>]", source)
+	}
+	return s[:start+i], s[start+i+2:], ref, nil
+}
+
+// Fetch implements Fetcher.
+func (f *GitFetcher) Fetch(source, dir string) ([]string, error) {
+	repo, p, ref, err := parseGitSource(source)
+	if err != nil {
+		return nil, err
+	}
+	// The repository is cloned into a directory named after the repository, so that the project name is the name of the repository if
+	// the docker compose file is at the root of the repository.
+	repoDir, err := joinRelative(dir, strings.TrimSuffix(path.Base(strings.Replace(repo, ":", "/", -1)), ".git"))
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"clone", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	err = runGit(append(args, "--", repo, repoDir)...)
+	if err != nil {
+		return nil, err
+	}
+	file, err := joinRelative(repoDir, p)
+	if err != nil {
+		return nil, err
+	}
+	return []string{file}, nil
+}
@@ -0,0 +1,75 @@
+package remote
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func withMockRunGit(err error, cb func(calls *[][]string)) {
+	orig := runGit
+	defer func() {
+		runGit = orig
+	}()
+	var calls [][]string
+	runGit = func(args ...string) error {
+		calls = append(calls, args)
+		return err
+	}
+	cb(&calls)
+}
+
+func TestParseGitSource_Success(t *testing.T) {
+	for source, expected := range map[string][3]string{
+		"git::https://github.com/org/repo.git//deploy/docker-compose.yml?ref=v1": {
+			"https://github.com/org/repo.git", "deploy/docker-compose.yml", "v1",
+		},
+		"git::git@github.com:org/repo.git//docker-compose.yml": {
+			"git@github.com:org/repo.git", "docker-compose.yml", "",
+		},
+	} {
+		repo, p, ref, err := parseGitSource(source)
+		if err != nil || [3]string{repo, p, ref} != expected {
+			t.Error(source, repo, p, ref, err)
+		}
+	}
+}
+
+func TestParseGitSource_Invalid(t *testing.T) {
+	for _, source := range []string{
+		"git::https://github.com/org/repo.git",
+		"git::https://github.com/org/repo.git//",
+		"git:://docker-compose.yml",
+	} {
+		_, _, _, err := parseGitSource(source)
+		if err == nil {
+			t.Error(source)
+		}
+	}
+}
+
+func TestGitFetcher_Success(t *testing.T) {
+	withMockRunGit(nil, func(calls *[][]string) {
+		f := &GitFetcher{}
+		files, err := f.Fetch("git::https://github.com/org/repo.git//deploy/docker-compose.yml?ref=v1", "/cache/1")
+		if err != nil || !reflect.DeepEqual(files, []string{"/cache/1/repo/deploy/docker-compose.yml"}) {
+			t.Error(files, err)
+		}
+		expected := [][]string{
+			{"clone", "--depth", "1", "--branch", "v1", "--", "https://github.com/org/repo.git", "/cache/1/repo"},
+		}
+		if !reflect.DeepEqual(*calls, expected) {
+			t.Error(*calls)
+		}
+	})
+}
+
+func TestGitFetcher_CloneError(t *testing.T) {
+	withMockRunGit(fmt.Errorf("cloneerror"), func(_ *[][]string) {
+		f := &GitFetcher{}
+		_, err := f.Fetch("git::git@github.com:org/repo.git//docker-compose.yml", "/cache/1")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
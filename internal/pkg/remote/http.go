@@ -0,0 +1,96 @@
+package remote
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+)
+
+// HTTPFetcher fetches a docker compose file from an http:// or https:// URL, along with the env files that it refers to, which are
+// resolved relative to the URL.
+type HTTPFetcher struct {
+	// The HTTP client, or nil to use http.DefaultClient.
+	Client *http.Client
+}
+
+// Matches implements Fetcher.
+func (f *HTTPFetcher) Matches(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+func (f *HTTPFetcher) get(u *url.URL) ([]byte, error) {
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned status %s", u.Redacted(), resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Fetch implements Fetcher.
+func (f *HTTPFetcher) Fetch(source, dir string) ([]string, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	data, err := f.get(u)
+	if err != nil {
+		return nil, err
+	}
+	projectDir, err := joinRelative(dir, getProjectDirName(u.Path, u.Hostname()))
+	if err != nil {
+		return nil, err
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		name = "docker-compose.yml"
+	}
+	file, err := joinRelative(projectDir, name)
+	if err != nil {
+		return nil, err
+	}
+	err = writeFile(file, data)
+	if err != nil {
+		return nil, err
+	}
+	envFiles, err := GetEnvFiles(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, envFile := range envFiles {
+		err = f.fetchEnvFile(u, projectDir, envFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return []string{file}, nil
+}
+
+// fetchEnvFile fetches an env file that a docker compose file at u refers to into projectDir.
+func (f *HTTPFetcher) fetchEnvFile(u *url.URL, projectDir, envFile string) error {
+	file, err := joinRelative(projectDir, envFile)
+	if err != nil {
+		return err
+	}
+	ref, err := url.Parse(envFile)
+	if err != nil {
+		return err
+	}
+	data, err := f.get(u.ResolveReference(ref))
+	if err != nil {
+		return err
+	}
+	return writeFile(file, data)
+}
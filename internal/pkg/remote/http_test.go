@@ -0,0 +1,63 @@
+package remote
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func newTestHTTPServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/org/app/docker-compose.yml":
+			_, _ = w.Write([]byte("services:\n  web:\n    image: nginx\n    env_file: env/web.env\n"))
+		case "/org/app/env/web.env":
+			_, _ = w.Write([]byte("A=1\n"))
+		case "/org/missing/docker-compose.yml":
+			_, _ = w.Write([]byte("services:\n  web:\n    env_file: web.env\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestHTTPFetcher_Success(t *testing.T) {
+	server := newTestHTTPServer()
+	defer server.Close()
+	withMocks(nil, func(_ *[]string) {
+		f := &HTTPFetcher{}
+		files, err := f.Fetch(server.URL+"/org/app/docker-compose.yml", "/cache/1")
+		if err != nil || !reflect.DeepEqual(files, []string{"/cache/1/app/docker-compose.yml"}) {
+			t.Error(files, err)
+			return
+		}
+		if content := readFile(t, "/cache/1/app/env/web.env"); content != "A=1\n" {
+			t.Error(content)
+		}
+	})
+}
+
+func TestHTTPFetcher_EnvFileNotFound(t *testing.T) {
+	server := newTestHTTPServer()
+	defer server.Close()
+	withMocks(nil, func(_ *[]string) {
+		f := &HTTPFetcher{}
+		_, err := f.Fetch(server.URL+"/org/missing/docker-compose.yml", "/cache/1")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestHTTPFetcher_NotFound(t *testing.T) {
+	server := newTestHTTPServer()
+	defer server.Close()
+	withMocks(nil, func(_ *[]string) {
+		f := &HTTPFetcher{}
+		_, err := f.Fetch(server.URL+"/docker-compose.yml", "/cache/1")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	dockerRef "github.com/docker/distribution/reference"
+)
+
+// dockerHubHost is the host of the registry API of Docker Hub, which serves the images of the domain docker.io.
+const dockerHubHost = "registry-1.docker.io"
+
+// ParseImageReference parses an image reference like docker does (e.g. nginx is docker.io/library/nginx:latest), and returns the reference
+// of the image in its registry.
+func ParseImageReference(image string) (*Reference, error) {
+	named, err := dockerRef.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil, err
+	}
+	ref := &Reference{
+		Host:       dockerRef.Domain(named),
+		Repository: dockerRef.Path(named),
+	}
+	if ref.Host == "docker.io" {
+		ref.Host = dockerHubHost
+	}
+	if canonical, ok := named.(dockerRef.Canonical); ok {
+		ref.Tag = canonical.Digest().String()
+	} else {
+		ref.Tag = dockerRef.TagNameOnly(named).(dockerRef.Tagged).Tag()
+	}
+	return ref, nil
+}
+
+// Platform is the platform of an image.
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (p *Platform) String() string {
+	if p.Variant != "" {
+		return p.OS + "/" + p.Architecture + "/" + p.Variant
+	}
+	return p.OS + "/" + p.Architecture
+}
+
+// ParsePlatform parses a platform of the form <os>/<architecture>[/<variant>] (e.g. linux/arm64/v8).
+func ParsePlatform(s string) (*Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("the platform %#v must have the form <os>/<architecture>[/<variant>]", s)
+	}
+	p := &Platform{
+		Architecture: parts[1],
+		OS:           parts[0],
+	}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// matches returns true if p matches the requested platform. The variant only needs to match if it is requested.
+func (p *Platform) matches(requested *Platform) bool {
+	return p.OS == requested.OS && p.Architecture == requested.Architecture && (requested.Variant == "" || p.Variant == requested.Variant)
+}
+
+// Image is an image in a registry.
+type Image struct {
+	// The configuration of the image (in the format of the OCI image spec), such as its environment variables and exposed ports.
+	Config []byte
+	// The digest of the manifest of the image. If the image is multi-platform, this is the digest of the manifest of the platform.
+	Digest   string
+	Manifest *Manifest
+}
+
+// index is an index of a multi-platform image, which is either an OCI image index or a docker manifest list.
+type index struct {
+	Manifests []struct {
+		Descriptor
+		Platform *Platform `json:"platform"`
+	} `json:"manifests"`
+}
+
+// getImageManifest gets the manifest (or index) of an image, and returns its data and digest.
+func (c *registryClient) getImageManifest(ref *Reference) (data []byte, digest string, err error) {
+	data, err = c.get(ref.baseURL()+"/manifests/"+ref.Tag, strings.Join(resolveDigestMediaTypes, ", "))
+	if err != nil {
+		return nil, "", err
+	}
+	digest = digestOf(data)
+	if strings.HasPrefix(ref.Tag, "sha256:") && digest != ref.Tag {
+		return nil, "", fmt.Errorf("the manifest of %s does not match its digest", ref)
+	}
+	return data, digest, nil
+}
+
+// GetImage gets the manifest and configuration of an image. If the image is multi-platform, the manifest of platform is used.
+func GetImage(client *http.Client, credentials *Credentials, ref *Reference, platform *Platform) (*Image, error) {
+	c := newRegistryClient(client, credentials)
+	data, digest, err := c.getImageManifest(ref)
+	if err != nil {
+		return nil, err
+	}
+	idx := &index{}
+	err = json.Unmarshal(data, idx)
+	if err != nil {
+		return nil, err
+	}
+	if len(idx.Manifests) > 0 {
+		var platformRef *Reference
+		for _, m := range idx.Manifests {
+			if m.Platform != nil && m.Platform.matches(platform) {
+				platformRef = &Reference{
+					Host:       ref.Host,
+					Repository: ref.Repository,
+					Tag:        m.Digest,
+				}
+				break
+			}
+		}
+		if platformRef == nil {
+			return nil, fmt.Errorf("the image %s does not have the platform %s", ref, platform)
+		}
+		data, digest, err = c.getImageManifest(platformRef)
+		if err != nil {
+			return nil, err
+		}
+	}
+	image := &Image{
+		Digest:   digest,
+		Manifest: &Manifest{},
+	}
+	err = json.Unmarshal(data, image.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	image.Config, err = c.getBlob(ref, image.Manifest.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+	return image, nil
+}
@@ -0,0 +1,55 @@
+package remote
+
+import (
+	"testing"
+)
+
+const testDigest = "sha256:0123456789012345678901234567890123456789012345678901234567890123"
+
+func TestParseImageReference_Success(t *testing.T) {
+	testCases := []struct {
+		image    string
+		expected Reference
+	}{
+		{"nginx", Reference{Host: "registry-1.docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"org/app:1.0", Reference{Host: "registry-1.docker.io", Repository: "org/app", Tag: "1.0"}},
+		{"localhost:5000/app:1.0@" + testDigest, Reference{Host: "localhost:5000", Repository: "app", Tag: testDigest}},
+	}
+	for _, testCase := range testCases {
+		ref, err := ParseImageReference(testCase.image)
+		if err != nil || *ref != testCase.expected {
+			t.Error(testCase.image, ref, err)
+		}
+	}
+}
+
+func TestParseImageReference_Invalid(t *testing.T) {
+	_, err := ParseImageReference("UPPERCASE")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestGetImage_PlatformNotFound(t *testing.T) {
+	withMemoryRegistry(nil, func(reg *memoryRegistry, ref *Reference) {
+		reg.manifests["1.0"] = []byte(`{"manifests":[{"digest":"` + testDigest + `","platform":{"architecture":"arm64","os":"linux"}}]}`)
+		_, err := GetImage(nil, nil, ref, &Platform{Architecture: "amd64", OS: "linux"})
+		if err == nil || err.Error() != "the image "+ref.String()+" does not have the platform linux/amd64" {
+			t.Error(err)
+		}
+	})
+}
+
+func TestParsePlatform_Success(t *testing.T) {
+	p, err := ParsePlatform("linux/arm64/v8")
+	if err != nil || *p != (Platform{Architecture: "arm64", OS: "linux", Variant: "v8"}) || p.String() != "linux/arm64/v8" {
+		t.Error(p, err)
+	}
+}
+
+func TestParsePlatform_Invalid(t *testing.T) {
+	_, err := ParsePlatform("linux")
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,356 @@
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+)
+
+// ociPrefix is the prefix of OCI sources, which have the form oci://<registry>/<repository>[:<tag>|@<digest>].
+const ociPrefix = "oci://"
+
+// The media types and annotations of docker compose projects that are published as OCI artifacts, which are the same as those of docker
+// compose publish.
+const (
+	ArtifactType          = "application/vnd.docker.compose.project"
+	ComposeFileMediaType  = "application/vnd.docker.compose.file+yaml"
+	EnvFileMediaType      = "application/vnd.docker.compose.envfile"
+	ComposeFileAnnotation = "com.docker.compose.file"
+	EnvFileAnnotation     = "com.docker.compose.envfile"
+	manifestMediaType     = "application/vnd.oci.image.manifest.v1+json"
+)
+
+// Descriptor describes a blob of an OCI artifact.
+type Descriptor struct {
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Digest      string            `json:"digest"`
+	MediaType   string            `json:"mediaType"`
+	Size        int64             `json:"size"`
+}
+
+// Manifest is the manifest of an OCI artifact.
+type Manifest struct {
+	ArtifactType  string       `json:"artifactType,omitempty"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+	MediaType     string       `json:"mediaType"`
+	SchemaVersion int          `json:"schemaVersion"`
+}
+
+// Reference is a reference to an OCI artifact in a registry.
+type Reference struct {
+	Host       string
+	Repository string
+	// The tag or the digest of the artifact.
+	Tag string
+}
+
+// ParseReference parses a reference of the form <registry>/<repository>[:<tag>|@<digest>]. The tag defaults to latest.
+func ParseReference(s string) (*Reference, error) {
+	i := strings.IndexByte(s, '/')
+	if i <= 0 || i == len(s)-1 {
+		return nil, fmt.Errorf("the reference %s must have the form <registry>/<repository>[:<tag>|@<digest>]", s)
+	}
+	ref := &Reference{
+		Host:       s[:i],
+		Repository: s[i+1:],
+		Tag:        "latest",
+	}
+	if j := strings.IndexByte(ref.Repository, '@'); j >= 0 {
+		ref.Tag = ref.Repository[j+1:]
+		ref.Repository = ref.Repository[:j]
+	} else if j := strings.LastIndexByte(ref.Repository, ':'); j >= 0 {
+		ref.Tag = ref.Repository[j+1:]
+		ref.Repository = ref.Repository[:j]
+	}
+	if ref.Repository == "" || ref.Tag == "" {
+		return nil, fmt.Errorf("the reference %s must have the form <registry>/<repository>[:<tag>|@<digest>]", s)
+	}
+	return ref, nil
+}
+
+func (r *Reference) String() string {
+	if strings.HasPrefix(r.Tag, "sha256:") {
+		return r.Host + "/" + r.Repository + "@" + r.Tag
+	}
+	return r.Host + "/" + r.Repository + ":" + r.Tag
+}
+
+// baseURL returns the URL of the registry API. Like docker, plain HTTP is used for registries on the loopback interface.
+func (r *Reference) baseURL() string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return "http://" + r.Host + "/v2/" + r.Repository
+	}
+	return "https://" + r.Host + "/v2/" + r.Repository
+}
+
+var challengeParamRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// Credentials are the credentials of a registry.
+type Credentials struct {
+	Password string
+	Username string
+}
+
+// GetCredentials returns the credentials of the registry of an image (or artifact) in the configuration of the docker CLI, or nil if
+// cliConfig is nil or does not have credentials for the registry.
+func GetCredentials(cliConfig *docker.CLIConfig, image string) (*Credentials, error) {
+	if cliConfig == nil {
+		return nil, nil
+	}
+	authConfig, err := cliConfig.GetAuthConfig(image)
+	if err != nil || authConfig == nil || authConfig.Username == "" {
+		return nil, err
+	}
+	return &Credentials{
+		Password: authConfig.Password,
+		Username: authConfig.Username,
+	}, nil
+}
+
+// registryClient is a minimal client of the OCI distribution API, that authenticates with bearer tokens or basic authentication when the
+// registry requires it. Without credentials, bearer tokens are requested anonymously. Bearer tokens are cached (see Tokens).
+type registryClient struct {
+	client      *http.Client
+	credentials *Credentials
+	token       string
+	// True if and only if token was taken from the cache.
+	tokenFromCache bool
+	useBasic       bool
+}
+
+func newRegistryClient(client *http.Client, credentials *Credentials) *registryClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &registryClient{
+		client:      client,
+		credentials: credentials,
+	}
+}
+
+// authenticate handles the WWW-Authenticate challenge of a response of a registry with status 401, by getting a bearer token or by using
+// basic authentication for subsequent requests. If useCache is true then a cached bearer token is used if there is one.
+func (c *registryClient) authenticate(registry, challenge string, useCache bool) error {
+	if strings.HasPrefix(challenge, "Basic ") && c.credentials != nil && !c.useBasic {
+		c.useBasic = true
+		return nil
+	}
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return fmt.Errorf("the registry requires authentication %q, but no (valid) credentials are available", challenge)
+	}
+	params := map[string]string{}
+	for _, match := range challengeParamRegexp.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+	u, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return fmt.Errorf("the registry returned an invalid authentication challenge %q", challenge)
+	}
+	query := u.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	u.RawQuery = query.Encode()
+	username := ""
+	if c.credentials != nil {
+		username = c.credentials.Username
+	}
+	if useCache && Tokens != nil {
+		if t := Tokens.getToken(u.String(), username); t != nil {
+			c.token = t.Value
+			c.tokenFromCache = true
+			return nil
+		}
+	}
+	t, err := requestToken(c.client, registry, u.String(), c.credentials)
+	if err != nil {
+		return err
+	}
+	c.token = t.Value
+	c.tokenFromCache = false
+	if Tokens != nil {
+		Tokens.putToken(t)
+	}
+	return nil
+}
+
+// do sends a request created by newRequest, and sends it again with a bearer token if the registry requires authentication. The request
+// is created again, because its body may have been consumed. If the registry rejects a cached bearer token (e.g. because it was
+// revoked) then the request is sent once more with a new bearer token.
+func (c *registryClient) do(newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		} else if c.useBasic {
+			req.SetBasicAuth(c.credentials.Username, c.credentials.Password)
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 1 || (attempt == 1 && !c.tokenFromCache) {
+			return resp, nil
+		}
+		util.CloseAndLogError(resp.Body)
+		err = c.authenticate(req.URL.Host, resp.Header.Get("WWW-Authenticate"), attempt == 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// get sends a GET request to the registry API and returns the body of the response, which must have status 200.
+func (c *registryClient) get(u, accept string) ([]byte, error) {
+	resp, err := c.do(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err == nil && accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		return req, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned status %s", u, resp.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxBlobSize))
+}
+
+// maxBlobSize is the maximum size of manifests and blobs that are read, since docker compose files are small.
+const maxBlobSize = 16 << 20
+
+// digestOf returns the digest of data, in the form used by registries.
+func digestOf(data []byte) string {
+	h := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(h[:])
+}
+
+// getManifest gets the manifest of an artifact.
+func (c *registryClient) getManifest(ref *Reference) (*Manifest, error) {
+	data, err := c.get(ref.baseURL()+"/manifests/"+ref.Tag, manifestMediaType)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(ref.Tag, "sha256:") && digestOf(data) != ref.Tag {
+		return nil, fmt.Errorf("the manifest of %s does not match its digest", ref)
+	}
+	manifest := &Manifest{}
+	err = json.Unmarshal(data, manifest)
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// getBlob gets a blob of an artifact and verifies its digest.
+func (c *registryClient) getBlob(ref *Reference, digest string) ([]byte, error) {
+	data, err := c.get(ref.baseURL()+"/blobs/"+digest, "")
+	if err != nil {
+		return nil, err
+	}
+	if digestOf(data) != digest {
+		return nil, fmt.Errorf("the blob %s of %s does not match its digest", digest, ref)
+	}
+	return data, nil
+}
+
+// OCIFetcher fetches the docker compose files and env files of a docker compose project that was published as an OCI artifact (see
+// ArtifactType).
+type OCIFetcher struct {
+	// The HTTP client, or nil to use http.DefaultClient.
+	Client *http.Client
+}
+
+// Matches implements Fetcher.
+func (f *OCIFetcher) Matches(source string) bool {
+	return strings.HasPrefix(source, ociPrefix)
+}
+
+// Fetch implements Fetcher.
+func (f *OCIFetcher) Fetch(source, dir string) ([]string, error) {
+	ref, err := ParseReference(strings.TrimPrefix(source, ociPrefix))
+	if err != nil {
+		return nil, err
+	}
+	c := newRegistryClient(f.Client, nil)
+	manifest, err := c.getManifest(ref)
+	if err != nil {
+		return nil, err
+	}
+	projectDir, err := joinRelative(dir, path.Base(ref.Repository))
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for i, layer := range manifest.Layers {
+		name := getLayerFileName(i, layer)
+		if name == "" {
+			continue
+		}
+		var file string
+		file, err = f.fetchLayer(c, ref, layer, projectDir, name)
+		if err != nil {
+			return nil, err
+		}
+		if layer.MediaType == ComposeFileMediaType {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("the artifact %s does not have docker compose files", ref)
+	}
+	return files, nil
+}
+
+// getLayerFileName returns the name of the file of the i-th layer of an artifact, or the empty string if the layer is neither a docker
+// compose file nor an env file.
+func getLayerFileName(i int, layer Descriptor) string {
+	switch layer.MediaType {
+	case ComposeFileMediaType:
+		if name := layer.Annotations[ComposeFileAnnotation]; name != "" {
+			return name
+		}
+		return fmt.Sprintf("docker-compose-%d.yml", i)
+	case EnvFileMediaType:
+		return layer.Annotations[EnvFileAnnotation]
+	}
+	return ""
+}
+
+// fetchLayer fetches a layer of an artifact into the file name of projectDir, and returns the path of the file.
+func (f *OCIFetcher) fetchLayer(c *registryClient, ref *Reference, layer Descriptor, projectDir, name string) (string, error) {
+	file, err := joinRelative(projectDir, name)
+	if err != nil {
+		return "", err
+	}
+	data, err := c.getBlob(ref, layer.Digest)
+	if err != nil {
+		return "", err
+	}
+	return file, writeFile(file, data)
+}
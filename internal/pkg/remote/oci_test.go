@@ -0,0 +1,155 @@
+package remote
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestRegistry returns a registry with the artifact app:1.0, that requires a bearer token obtained from /token.
+func newTestRegistry(blobs map[string][]byte, manifest *Manifest) *httptest.Server {
+	manifestData, _ := json.Marshal(manifest)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:org/app:pull" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"secret"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry",scope="repository:org/app:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/v2/org/app/manifests/1.0" || r.URL.Path == "/v2/org/app/manifests/"+digestOf(manifestData):
+			_, _ = w.Write(manifestData)
+		case strings.HasPrefix(r.URL.Path, "/v2/org/app/blobs/") && blobs[strings.TrimPrefix(r.URL.Path, "/v2/org/app/blobs/")] != nil:
+			_, _ = w.Write(blobs[strings.TrimPrefix(r.URL.Path, "/v2/org/app/blobs/")])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+func newTestArtifact(composeFile, envFile []byte) (map[string][]byte, *Manifest) {
+	blobs := map[string][]byte{
+		digestOf(composeFile): composeFile,
+		digestOf(envFile):     envFile,
+	}
+	manifest := &Manifest{
+		ArtifactType: ArtifactType,
+		Layers: []Descriptor{
+			{
+				Annotations: map[string]string{ComposeFileAnnotation: "compose.yaml"},
+				Digest:      digestOf(composeFile),
+				MediaType:   ComposeFileMediaType,
+			},
+			{
+				Annotations: map[string]string{EnvFileAnnotation: "web.env"},
+				Digest:      digestOf(envFile),
+				MediaType:   EnvFileMediaType,
+			},
+		},
+		MediaType:     manifestMediaType,
+		SchemaVersion: 2,
+	}
+	return blobs, manifest
+}
+
+func TestParseReference_Success(t *testing.T) {
+	for s, expected := range map[string]Reference{
+		"localhost:5000/org/app:1.0":         {Host: "localhost:5000", Repository: "org/app", Tag: "1.0"},
+		"registry.example.com/app@sha256:1a": {Host: "registry.example.com", Repository: "app", Tag: "sha256:1a"},
+	} {
+		ref, err := ParseReference(s)
+		if err != nil || *ref != expected || ref.String() != s {
+			t.Error(s, ref, err)
+		}
+	}
+}
+
+func TestParseReference_DefaultTag(t *testing.T) {
+	ref, err := ParseReference("registry.example.com/app")
+	if err != nil || ref.Tag != "latest" {
+		t.Error(ref, err)
+	}
+}
+
+func TestParseReference_Invalid(t *testing.T) {
+	for _, s := range []string{"app", "registry.example.com/", "registry.example.com/app:", "/app"} {
+		_, err := ParseReference(s)
+		if err == nil {
+			t.Error(s)
+		}
+	}
+}
+
+func TestOCIFetcher_Success(t *testing.T) {
+	composeFile := []byte("services:\n  web:\n    image: nginx\n    env_file: web.env\n")
+	server := newTestRegistry(newTestArtifact(composeFile, []byte("A=1\n")))
+	defer server.Close()
+	withMocks(nil, func(_ *[]string) {
+		f := &OCIFetcher{}
+		source := "oci://" + strings.TrimPrefix(server.URL, "http://") + "/org/app:1.0"
+		files, err := f.Fetch(source, "/cache/1")
+		if err != nil || !reflect.DeepEqual(files, []string{"/cache/1/app/compose.yaml"}) {
+			t.Error(files, err)
+			return
+		}
+		if content := readFile(t, "/cache/1/app/compose.yaml"); content != string(composeFile) {
+			t.Error(content)
+		}
+		if content := readFile(t, "/cache/1/app/web.env"); content != "A=1\n" {
+			t.Error(content)
+		}
+	})
+}
+
+func TestOCIFetcher_DigestMismatch(t *testing.T) {
+	blobs, manifest := newTestArtifact([]byte("services: {}\n"), []byte("A=1\n"))
+	blobs[manifest.Layers[0].Digest] = []byte("services:\n  evil: {}\n")
+	server := newTestRegistry(blobs, manifest)
+	defer server.Close()
+	withMocks(nil, func(_ *[]string) {
+		f := &OCIFetcher{}
+		_, err := f.Fetch("oci://"+strings.TrimPrefix(server.URL, "http://")+"/org/app:1.0", "/cache/1")
+		if err == nil || !strings.Contains(err.Error(), "does not match its digest") {
+			t.Error(err)
+		}
+	})
+}
+
+func TestOCIFetcher_NoComposeFiles(t *testing.T) {
+	blobs, manifest := newTestArtifact([]byte("services: {}\n"), []byte("A=1\n"))
+	manifest.Layers = manifest.Layers[1:]
+	server := newTestRegistry(blobs, manifest)
+	defer server.Close()
+	withMocks(nil, func(_ *[]string) {
+		f := &OCIFetcher{}
+		_, err := f.Fetch("oci://"+strings.TrimPrefix(server.URL, "http://")+"/org/app:1.0", "/cache/1")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestOCIFetcher_NotFound(t *testing.T) {
+	blobs, manifest := newTestArtifact([]byte("services: {}\n"), []byte("A=1\n"))
+	server := newTestRegistry(blobs, manifest)
+	defer server.Close()
+	withMocks(nil, func(_ *[]string) {
+		f := &OCIFetcher{}
+		_, err := f.Fetch("oci://"+strings.TrimPrefix(server.URL, "http://")+"/org/app:2.0", "/cache/1")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+)
+
+// The media types of manifests that are accepted when resolving digests, which include the indexes of multi-platform images.
+var resolveDigestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	manifestMediaType,
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// emptyConfig is the config of artifacts, which do not have a config.
+var emptyConfig = []byte("{}")
+
+const emptyConfigMediaType = "application/vnd.oci.empty.v1+json"
+
+// Layer is a layer of an artifact that is to be pushed.
+type Layer struct {
+	Annotations map[string]string
+	Data        []byte
+	MediaType   string
+}
+
+// checkStatus returns an error if the status of a response is not one of the expected statuses.
+func checkStatus(resp *http.Response, method, u string, expected ...int) error {
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %s returned status %s", method, u, resp.Status)
+}
+
+// send sends a request to the registry API, checks the status of the response and closes the response. It returns the response so that
+// its headers can be read.
+func (c *registryClient) send(method, u string, header http.Header, data []byte, expected ...int) (*http.Response, error) {
+	resp, err := c.do(func() (*http.Request, error) {
+		req, err := http.NewRequest(method, u, bytes.NewReader(data))
+		if err == nil {
+			for key, values := range header {
+				req.Header[key] = values
+			}
+		}
+		return req, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	util.CloseAndLogError(resp.Body)
+	return resp, checkStatus(resp, method, u, expected...)
+}
+
+// chunkSize is the size of the chunks of blob uploads. Blobs that are larger are uploaded in chunks, so that an upload that fails is
+// resumed from the data that the registry received, instead of being restarted. It is a variable to improve testability.
+var chunkSize = 8 << 20
+
+// maxChunkAttempts is the maximum number of attempts to upload a chunk.
+const maxChunkAttempts = 4
+
+// getUploadLocation returns the URL of the next request of an upload, which is the Location of the response of the previous request.
+func getUploadLocation(resp *http.Response, location *url.URL) (*url.URL, error) {
+	if l := resp.Header.Get("Location"); l != "" {
+		return resp.Request.URL.Parse(l)
+	}
+	return location, nil
+}
+
+// getUploadOffset returns the number of bytes of an upload that the registry received, and the URL of the next request of the upload.
+func (c *registryClient) getUploadOffset(location *url.URL) (int, *url.URL, error) {
+	resp, err := c.send(http.MethodGet, location.String(), nil, nil, http.StatusNoContent)
+	if err != nil {
+		return 0, nil, err
+	}
+	location, err = getUploadLocation(resp, location)
+	if err != nil {
+		return 0, nil, err
+	}
+	// The range is inclusive and always starts at 0 (e.g. 0-1023). Registries omit it if they did not receive any data.
+	var start, end int
+	if r := resp.Header.Get("Range"); r != "" {
+		if _, err = fmt.Sscanf(r, "%d-%d", &start, &end); err != nil {
+			return 0, nil, fmt.Errorf("the registry returned an invalid range %q of an upload", r)
+		}
+		return end + 1, location, nil
+	}
+	return 0, location, nil
+}
+
+// uploadChunks uploads data in chunks to the upload at location, and returns the URL of the request that completes the upload. If a chunk
+// fails to upload, the upload is resumed at the offset that the registry reports.
+func (c *registryClient) uploadChunks(location *url.URL, data []byte) (*url.URL, error) {
+	offset, attempts := 0, 0
+	for offset < len(data) {
+		end := offset + chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		header := http.Header{
+			"Content-Range": {fmt.Sprintf("%d-%d", offset, end-1)},
+			"Content-Type":  {"application/octet-stream"},
+		}
+		resp, err := c.send(http.MethodPatch, location.String(), header, data[offset:end], http.StatusAccepted)
+		if err == nil {
+			offset, attempts = end, 0
+			location, err = getUploadLocation(resp, location)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		attempts++
+		if attempts >= maxChunkAttempts {
+			return nil, err
+		}
+		log.Debugf("resuming the upload of a blob after an error: %v", err)
+		offset, location, err = c.getUploadOffset(location)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return location, nil
+}
+
+// pushBlob pushes a blob in a single request (or in chunks if it is larger than chunkSize), unless the registry already has the blob.
+func (c *registryClient) pushBlob(ref *Reference, data []byte) (*Descriptor, error) {
+	desc := &Descriptor{
+		Digest: digestOf(data),
+		Size:   int64(len(data)),
+	}
+	resp, err := c.send(http.MethodHead, ref.baseURL()+"/blobs/"+desc.Digest, nil, nil, http.StatusOK, http.StatusNotFound)
+	if err != nil || resp.StatusCode == http.StatusOK {
+		return desc, err
+	}
+	resp, err = c.send(http.MethodPost, ref.baseURL()+"/blobs/uploads/", nil, nil, http.StatusAccepted)
+	if err != nil {
+		return nil, err
+	}
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > chunkSize {
+		location, err = c.uploadChunks(location, data)
+		if err != nil {
+			return nil, err
+		}
+		data = nil
+	}
+	query := location.Query()
+	query.Set("digest", desc.Digest)
+	location.RawQuery = query.Encode()
+	_, err = c.send(http.MethodPut, location.String(), http.Header{"Content-Type": {"application/octet-stream"}}, data, http.StatusCreated)
+	return desc, err
+}
+
+// PushArtifact pushes an OCI artifact of the specified type with the specified layers to ref, and returns the digest of its manifest.
+func PushArtifact(client *http.Client, credentials *Credentials, ref *Reference, artifactType string, layers []*Layer) (string, error) {
+	c := newRegistryClient(client, credentials)
+	config, err := c.pushBlob(ref, emptyConfig)
+	if err != nil {
+		return "", err
+	}
+	config.MediaType = emptyConfigMediaType
+	manifest := &Manifest{
+		ArtifactType:  artifactType,
+		Config:        *config,
+		MediaType:     manifestMediaType,
+		SchemaVersion: 2,
+	}
+	for _, layer := range layers {
+		var desc *Descriptor
+		desc, err = c.pushBlob(ref, layer.Data)
+		if err != nil {
+			return "", err
+		}
+		desc.Annotations = layer.Annotations
+		desc.MediaType = layer.MediaType
+		manifest.Layers = append(manifest.Layers, *desc)
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	_, err = c.send(http.MethodPut, ref.baseURL()+"/manifests/"+url.PathEscape(ref.Tag),
+		http.Header{"Content-Type": {manifestMediaType}}, data, http.StatusCreated)
+	if err != nil {
+		return "", err
+	}
+	return digestOf(data), nil
+}
+
+// ResolveDigest returns the digest of the manifest (or index) of an image or artifact in its registry.
+func ResolveDigest(client *http.Client, credentials *Credentials, ref *Reference) (string, error) {
+	c := newRegistryClient(client, credentials)
+	u := ref.baseURL() + "/manifests/" + ref.Tag
+	resp, err := c.do(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodHead, u, nil)
+		if err == nil {
+			req.Header.Set("Accept", strings.Join(resolveDigestMediaTypes, ", "))
+		}
+		return req, err
+	})
+	if err != nil {
+		return "", err
+	}
+	util.CloseAndLogError(resp.Body)
+	if err = checkStatus(resp, http.MethodHead, u, http.StatusOK); err != nil {
+		return "", err
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if !strings.HasPrefix(digest, "sha256:") {
+		return "", fmt.Errorf("HEAD %s did not return the digest of the manifest", u)
+	}
+	return digest, nil
+}
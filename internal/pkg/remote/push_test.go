@@ -0,0 +1,231 @@
+package remote
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memoryRegistry is a registry that stores blobs and manifests in memory, and requires basic authentication if credentials are set.
+type memoryRegistry struct {
+	blobs       map[string][]byte
+	credentials *Credentials
+	// The number of PATCH requests that fail after half of the chunk was received, to simulate a flaky connection.
+	failPatches int
+	manifests   map[string][]byte
+	mutex       sync.Mutex
+	// The data of the upload in progress.
+	upload  []byte
+	uploads int
+}
+
+// handleUpload handles the requests of chunked uploads.
+func (reg *memoryRegistry) handleUpload(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Location", "/v2/org/app/uploads/1?state=a")
+	if r.Method == http.MethodGet {
+		if len(reg.upload) > 0 {
+			w.Header().Set("Range", fmt.Sprintf("0-%d", len(reg.upload)-1))
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	data, _ := ioutil.ReadAll(r.Body)
+	if r.Header.Get("Content-Range") != fmt.Sprintf("%d-%d", len(reg.upload), len(reg.upload)+len(data)-1) {
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	if reg.failPatches > 0 {
+		reg.failPatches--
+		reg.upload = append(reg.upload, data[:len(data)/2]...)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	reg.upload = append(reg.upload, data...)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (reg *memoryRegistry) handleBlob(w http.ResponseWriter, r *http.Request, digest string) {
+	data, ok := reg.blobs[digest]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(data)
+	}
+}
+
+func (reg *memoryRegistry) handleManifest(w http.ResponseWriter, r *http.Request, tag string) {
+	if r.Method == http.MethodPut {
+		data, _ := ioutil.ReadAll(r.Body)
+		reg.manifests[tag] = data
+		reg.manifests[digestOf(data)] = data
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	data, ok := reg.manifests[tag]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Docker-Content-Digest", digestOf(data))
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(data)
+	}
+}
+
+func (reg *memoryRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+	if reg.credentials != nil {
+		if username, password, ok := r.BasicAuth(); !ok || username != reg.credentials.Username || password != reg.credentials.Password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	p := strings.TrimPrefix(r.URL.Path, "/v2/org/app")
+	switch {
+	case p == "/blobs/uploads/" && r.Method == http.MethodPost:
+		w.Header().Set("Location", "/v2/org/app/uploads/1?state=a")
+		w.WriteHeader(http.StatusAccepted)
+	case p == "/uploads/1" && r.Method != http.MethodPut:
+		reg.handleUpload(w, r)
+	case p == "/uploads/1":
+		data, _ := ioutil.ReadAll(r.Body)
+		data = append(reg.upload, data...)
+		reg.upload = nil
+		if digestOf(data) != r.URL.Query().Get("digest") || r.URL.Query().Get("state") != "a" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reg.blobs[digestOf(data)] = data
+		reg.uploads++
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(p, "/blobs/"):
+		reg.handleBlob(w, r, strings.TrimPrefix(p, "/blobs/"))
+	case strings.HasPrefix(p, "/manifests/"):
+		reg.handleManifest(w, r, strings.TrimPrefix(p, "/manifests/"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func withMemoryRegistry(credentials *Credentials, cb func(reg *memoryRegistry, ref *Reference)) {
+	reg := &memoryRegistry{
+		blobs:       map[string][]byte{},
+		credentials: credentials,
+		manifests:   map[string][]byte{},
+	}
+	server := httptest.NewServer(reg)
+	defer server.Close()
+	cb(reg, &Reference{
+		Host:       strings.TrimPrefix(server.URL, "http://"),
+		Repository: "org/app",
+		Tag:        "1.0",
+	})
+}
+
+func TestPushArtifact_Success(t *testing.T) {
+	credentials := &Credentials{
+		Password: "secret",
+		Username: "user",
+	}
+	withMemoryRegistry(credentials, func(reg *memoryRegistry, ref *Reference) {
+		composeFile := []byte("services:\n  web:\n    image: nginx\n")
+		layers := []*Layer{
+			{
+				Annotations: map[string]string{ComposeFileAnnotation: "docker-compose.yml"},
+				Data:        composeFile,
+				MediaType:   ComposeFileMediaType,
+			},
+		}
+		digest, err := PushArtifact(nil, credentials, ref, ArtifactType, layers)
+		if err != nil || digest != digestOf(reg.manifests["1.0"]) {
+			t.Error(digest, err)
+			return
+		}
+		// Blobs that the registry has are not uploaded again.
+		_, err = PushArtifact(nil, credentials, ref, ArtifactType, layers)
+		if err != nil || reg.uploads != 2 {
+			t.Error(reg.uploads, err)
+		}
+		c := newRegistryClient(nil, credentials)
+		manifest, err := c.getManifest(ref)
+		if err != nil || manifest.ArtifactType != ArtifactType || len(manifest.Layers) != 1 || manifest.Config.MediaType != emptyConfigMediaType {
+			t.Error(manifest, err)
+			return
+		}
+		data, err := c.getBlob(ref, manifest.Layers[0].Digest)
+		if err != nil || string(data) != string(composeFile) {
+			t.Error(string(data), err)
+		}
+	})
+}
+
+func TestPushArtifact_Unauthorized(t *testing.T) {
+	withMemoryRegistry(&Credentials{Username: "user", Password: "secret"}, func(_ *memoryRegistry, ref *Reference) {
+		_, err := PushArtifact(nil, &Credentials{Username: "user", Password: "invalid"}, ref, ArtifactType, nil)
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestResolveDigest_Success(t *testing.T) {
+	withMemoryRegistry(nil, func(reg *memoryRegistry, ref *Reference) {
+		reg.manifests["1.0"] = []byte("{}")
+		digest, err := ResolveDigest(nil, nil, ref)
+		if err != nil || digest != digestOf([]byte("{}")) {
+			t.Error(digest, err)
+		}
+	})
+}
+
+func TestResolveDigest_NotFound(t *testing.T) {
+	withMemoryRegistry(nil, func(_ *memoryRegistry, ref *Reference) {
+		_, err := ResolveDigest(nil, nil, ref)
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func withChunkSize(size int, cb func()) {
+	orig := chunkSize
+	defer func() {
+		chunkSize = orig
+	}()
+	chunkSize = size
+	cb()
+}
+
+func TestPushBlob_ChunkedResumeSuccess(t *testing.T) {
+	withChunkSize(4, func() {
+		withMemoryRegistry(nil, func(reg *memoryRegistry, ref *Reference) {
+			reg.failPatches = 2
+			data := []byte("0123456789")
+			desc, err := newRegistryClient(nil, nil).pushBlob(ref, data)
+			if err != nil || desc.Digest != digestOf(data) || !bytes.Equal(reg.blobs[desc.Digest], data) || reg.uploads != 1 {
+				t.Error(desc, err)
+			}
+		})
+	})
+}
+
+func TestPushBlob_ChunkedTooManyFailures(t *testing.T) {
+	withChunkSize(4, func() {
+		withMemoryRegistry(nil, func(reg *memoryRegistry, ref *Reference) {
+			reg.failPatches = maxChunkAttempts
+			_, err := newRegistryClient(nil, nil).pushBlob(ref, []byte("0123456789"))
+			if err == nil || reg.uploads != 0 {
+				t.Error(err)
+			}
+		})
+	})
+}
@@ -0,0 +1,162 @@
+// Package remote fetches docker compose files from remote sources, so that canonical docker compose files can be shared between projects.
+// A remote source is fetched by the first Fetcher of Fetchers that matches it into a local directory, after which the fetched docker
+// compose files are loaded like local files.
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Fetcher fetches the docker compose files of remote sources of a particular kind.
+type Fetcher interface {
+	// Matches returns true if and only if source is a source of the kind that this fetcher fetches.
+	Matches(source string) bool
+	// Fetch fetches source into dir, which does not exist, and returns the paths of the fetched docker compose files in the order in which
+	// they are merged. The files should be in a directory named after the project, because the project name defaults to the name of the
+	// directory of the first docker compose file.
+	Fetch(source, dir string) ([]string, error)
+}
+
+// Fetchers are the fetchers of remote sources, in order of precedence. Other kinds of sources can be supported by adding fetchers.
+var Fetchers = []Fetcher{
+	&HTTPFetcher{},
+	&GitFetcher{},
+	&OCIFetcher{},
+}
+
+// removeAll removes a directory and its contents. It is a variable to improve testability.
+var removeAll = os.RemoveAll
+
+// getFetcher returns the first fetcher of Fetchers that matches source, or nil if source is a local file.
+func getFetcher(source string) Fetcher {
+	for _, fetcher := range Fetchers {
+		if fetcher.Matches(source) {
+			return fetcher
+		}
+	}
+	return nil
+}
+
+// IsRemote returns true if and only if source is a remote source (i.e. not a local file).
+func IsRemote(source string) bool {
+	return getFetcher(source) != nil
+}
+
+// DefaultDir returns the directory in the user's cache directory into which remote sources are fetched.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "kube-compose", "remote"), nil
+}
+
+// Resolve replaces the remote sources of files by the paths of their fetched docker compose files. Each remote source is fetched again
+// into its own subdirectory of dir, so that changes of remote sources are picked up.
+func Resolve(files []string, dir string) ([]string, error) {
+	var resolved []string
+	for _, file := range files {
+		fetcher := getFetcher(file)
+		if fetcher == nil {
+			resolved = append(resolved, file)
+			continue
+		}
+		h := sha256.Sum256([]byte(file))
+		sourceDir := filepath.Join(dir, hex.EncodeToString(h[:8]))
+		err := removeAll(sourceDir)
+		if err != nil {
+			return nil, err
+		}
+		fetched, err := fetcher.Fetch(file, sourceDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error while fetching the docker compose file %s", file)
+		}
+		resolved = append(resolved, fetched...)
+	}
+	return resolved, nil
+}
+
+// getProjectDirName returns the name of the directory of fetched docker compose files, given the path of a source (e.g. the path of a
+// URL). This is the name of the parent directory of the path, or fallback if the path does not have a parent directory.
+func getProjectDirName(p, fallback string) string {
+	name := path.Base(path.Dir(path.Clean("/" + p)))
+	if name == "/" || name == "." {
+		return fallback
+	}
+	return name
+}
+
+// joinRelative joins dir and a relative slash-separated path, and returns an error if the path is absolute or is not within dir, so that
+// remote sources cannot write files elsewhere.
+func joinRelative(dir, p string) (string, error) {
+	cleaned := path.Clean(p)
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") || filepath.VolumeName(p) != "" {
+		return "", fmt.Errorf("the path %s must be relative and within the directory of the docker compose file", p)
+	}
+	return filepath.Join(dir, filepath.FromSlash(cleaned)), nil
+}
+
+// writeFile writes data to a file, creating its directory if it does not exist.
+func writeFile(name string, data []byte) error {
+	err := fs.OS.MkdirAll(filepath.Dir(name), 0755)
+	if err != nil {
+		return err
+	}
+	fd, err := fs.OS.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = fd.Write(data)
+	if err != nil {
+		_ = fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+// GetEnvFiles returns the relative paths of the env files of the services of a docker compose file, so that they can be fetched or
+// published along with the docker compose file. Absolute paths are ignored, because they refer to files of the host.
+func GetEnvFiles(data []byte) ([]string, error) {
+	var dcFile struct {
+		Services map[string]struct {
+			EnvFile interface{} `yaml:"env_file"`
+		} `yaml:"services"`
+	}
+	err := yaml.Unmarshal(data, &dcFile)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var envFiles []string
+	add := func(v interface{}) {
+		if m, ok := v.(map[interface{}]interface{}); ok {
+			v = m["path"]
+		}
+		if s, ok := v.(string); ok && !path.IsAbs(s) && !seen[s] {
+			seen[s] = true
+			envFiles = append(envFiles, s)
+		}
+	}
+	for _, service := range dcFile.Services {
+		if list, ok := service.EnvFile.([]interface{}); ok {
+			for _, item := range list {
+				add(item)
+			}
+		} else {
+			add(service.EnvFile)
+		}
+	}
+	sort.Strings(envFiles)
+	return envFiles, nil
+}
@@ -0,0 +1,135 @@
+package remote
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+type mockFetcher struct {
+	dirs []string
+	err  error
+}
+
+func (f *mockFetcher) Matches(source string) bool {
+	return source == "mock://a"
+}
+
+func (f *mockFetcher) Fetch(source, dir string) ([]string, error) {
+	f.dirs = append(f.dirs, dir)
+	return []string{dir + "/a/docker-compose.yml", dir + "/a/docker-compose.override.yml"}, f.err
+}
+
+// withMocks mocks the file system, the fetchers and removeAll, and records the directories that were removed.
+func withMocks(fetchers []Fetcher, cb func(removed *[]string)) {
+	origFS, origFetchers, origRemoveAll := fs.OS, Fetchers, removeAll
+	defer func() {
+		fs.OS, Fetchers, removeAll = origFS, origFetchers, origRemoveAll
+	}()
+	var removed []string
+	fs.OS = fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{})
+	Fetchers = fetchers
+	removeAll = func(dir string) error {
+		removed = append(removed, dir)
+		return nil
+	}
+	cb(&removed)
+}
+
+func readFile(t *testing.T, name string) string {
+	fd, err := fs.OS.Open(name)
+	if err != nil {
+		t.Error(err)
+		return ""
+	}
+	defer fd.Close()
+	data, err := ioutil.ReadAll(fd)
+	if err != nil {
+		t.Error(err)
+	}
+	return string(data)
+}
+
+func TestResolve_Success(t *testing.T) {
+	fetcher := &mockFetcher{}
+	withMocks([]Fetcher{fetcher}, func(removed *[]string) {
+		files, err := Resolve([]string{"docker-compose.yml", "mock://a"}, "/cache")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(fetcher.dirs) != 1 || !reflect.DeepEqual(*removed, fetcher.dirs) {
+			t.Error(fetcher.dirs, *removed)
+			return
+		}
+		dir := fetcher.dirs[0]
+		expected := []string{"docker-compose.yml", dir + "/a/docker-compose.yml", dir + "/a/docker-compose.override.yml"}
+		if !reflect.DeepEqual(files, expected) {
+			t.Error(files)
+		}
+	})
+}
+
+func TestResolve_FetchError(t *testing.T) {
+	fetcher := &mockFetcher{
+		err: fmt.Errorf("fetcherror"),
+	}
+	withMocks([]Fetcher{fetcher}, func(_ *[]string) {
+		_, err := Resolve([]string{"mock://a"}, "/cache")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestIsRemote(t *testing.T) {
+	for source, expected := range map[string]bool{
+		"docker-compose.yml":                 false,
+		"/project/docker-compose.yml":        false,
+		"https://example.com/compose.yml":    true,
+		"git::https://example.com/repo//a":   true,
+		"oci://registry.example.com/app:1.0": true,
+	} {
+		if IsRemote(source) != expected {
+			t.Error(source)
+		}
+	}
+}
+
+func TestJoinRelative(t *testing.T) {
+	if file, err := joinRelative("/dir", "env/.env"); err != nil || file != "/dir/env/.env" {
+		t.Error(file, err)
+	}
+	for _, p := range []string{"/etc/passwd", "..", "../.env", "a/../../.env"} {
+		if _, err := joinRelative("/dir", p); err == nil {
+			t.Error(p)
+		}
+	}
+}
+
+func TestGetProjectDirName(t *testing.T) {
+	if name := getProjectDirName("/org/app/docker-compose.yml", "example.com"); name != "app" {
+		t.Error(name)
+	}
+	if name := getProjectDirName("/docker-compose.yml", "example.com"); name != "example.com" {
+		t.Error(name)
+	}
+}
+
+func TestGetEnvFiles(t *testing.T) {
+	envFiles, err := GetEnvFiles([]byte(`services:
+  a:
+    env_file: a.env
+  b:
+    env_file:
+    - a.env
+    - /etc/b.env
+    - path: c.env
+`))
+	if err != nil || !reflect.DeepEqual(envFiles, []string{"a.env", "c.env"}) {
+		t.Error(envFiles, err)
+	}
+}
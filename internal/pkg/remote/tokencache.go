@@ -0,0 +1,288 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	"github.com/kube-compose/kube-compose/internal/pkg/keychain"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// The lifetime of tokens whose token response has no expires_in, as specified by the token authentication of the distribution API.
+const defaultTokenLifetime = 60 * time.Second
+
+// Cached tokens that expire within tokenExpiryMargin are not used, so that they do not expire during a request.
+const tokenExpiryMargin = 10 * time.Second
+
+// Token is a bearer token that a registry issued to kube-compose.
+type Token struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+	// The host of the registry that the token authenticates with.
+	Registry string `json:"registry"`
+	// The URL from which the token was requested: the realm of the authentication challenge of the registry, with the service and scope.
+	URL string `json:"url"`
+	// The username of the credentials with which the token was requested, or the empty string if the token was requested anonymously.
+	Username string `json:"username,omitempty"`
+	Value    string `json:"value"`
+}
+
+// Scope returns the scope of the token, such as repository:org/app:pull.
+func (t *Token) Scope() string {
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("scope")
+}
+
+// tokenStore stores the data of the token cache, like keychain.Item.
+type tokenStore interface {
+	Get() ([]byte, error)
+	Set(data []byte) error
+	Delete() error
+}
+
+// TokenCache caches the bearer tokens that registries issue to kube-compose across commands, so that tokens are not requested again for
+// every command. The tokens are stored in one item of the keychain of the operating system with the service kube-compose, rather than in
+// plaintext files or the credentials store of the docker CLI. The item is read at most once per command.
+type TokenCache struct {
+	store  tokenStore
+	mutex  sync.Mutex
+	loaded bool
+	// The tokens keyed by tokenKey.
+	tokens map[string]*Token
+}
+
+// NewTokenCache returns a cache that stores tokens in the keychain of the operating system (see package keychain).
+func NewTokenCache() *TokenCache {
+	return &TokenCache{
+		store: &keychain.Item{
+			Service: "kube-compose",
+			Account: "registry-tokens",
+		},
+	}
+}
+
+// Tokens caches the bearer tokens of registries, or is nil to only keep tokens in memory for the duration of a command. It is set by the
+// command line interface.
+var Tokens *TokenCache
+
+// tokenKey returns the key of the token that was requested from tokenURL with the credentials of a username.
+func tokenKey(tokenURL, username string) string {
+	return username + "\n" + tokenURL
+}
+
+// load reads the tokens from the keychain, unless they were read before. It must be called while holding the lock of the cache.
+func (c *TokenCache) load() error {
+	if c.loaded {
+		return nil
+	}
+	data, err := c.store.Get()
+	if err != nil {
+		return err
+	}
+	var tokens []*Token
+	if data != nil {
+		err = json.Unmarshal(data, &tokens)
+		if err != nil {
+			return errors.Wrap(err, "invalid cached registry tokens")
+		}
+	}
+	c.tokens = map[string]*Token{}
+	for _, t := range tokens {
+		c.tokens[tokenKey(t.URL, t.Username)] = t
+	}
+	c.loaded = true
+	return nil
+}
+
+// save writes the tokens to the keychain, or deletes the item of the keychain if there are no tokens. It must be called while holding the
+// lock of the cache.
+func (c *TokenCache) save() error {
+	if len(c.tokens) == 0 {
+		return c.store.Delete()
+	}
+	data, err := json.Marshal(c.sortedTokens(""))
+	if err != nil {
+		return err
+	}
+	return c.store.Set(data)
+}
+
+// sortedTokens returns the tokens of a registry, or of all registries if registry is the empty string, sorted by registry and URL. It must
+// be called while holding the lock of the cache.
+func (c *TokenCache) sortedTokens(registry string) []*Token {
+	var tokens []*Token
+	for _, t := range c.tokens {
+		if registry == "" || t.Registry == registry {
+			tokens = append(tokens, t)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].Registry != tokens[j].Registry {
+			return tokens[i].Registry < tokens[j].Registry
+		}
+		return tokens[i].URL < tokens[j].URL
+	})
+	return tokens
+}
+
+// getToken returns the cached token that was requested from tokenURL with the credentials of a username, or nil if no token is cached or the
+// cached token (nearly) expired. Errors of the keychain are logged, so that kube-compose works without a keychain.
+func (c *TokenCache) getToken(tokenURL, username string) *Token {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	err := c.load()
+	if err != nil {
+		log.Debugf("not using cached registry tokens: %v", err)
+		return nil
+	}
+	t := c.tokens[tokenKey(tokenURL, username)]
+	if t == nil || time.Until(t.ExpiresAt) < tokenExpiryMargin {
+		return nil
+	}
+	return t
+}
+
+// putToken caches a token. Errors of the keychain are logged, so that kube-compose works without a keychain.
+func (c *TokenCache) putToken(t *Token) {
+	err := c.put(t)
+	if err != nil {
+		log.Debugf("not caching registry token: %v", err)
+	}
+}
+
+func (c *TokenCache) put(t *Token) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	err := c.load()
+	if err != nil {
+		return err
+	}
+	c.tokens[tokenKey(t.URL, t.Username)] = t
+	return c.save()
+}
+
+// List returns the cached tokens of a registry, or of all registries if registry is the empty string, sorted by registry and URL. Expired
+// tokens are included.
+func (c *TokenCache) List(registry string) ([]*Token, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	return c.sortedTokens(registry), nil
+}
+
+// Clear removes the cached tokens of a registry, or of all registries if registry is the empty string, and returns the number of removed
+// tokens.
+func (c *TokenCache) Clear(registry string) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	err := c.load()
+	if err != nil {
+		return 0, err
+	}
+	tokens := c.sortedTokens(registry)
+	for _, t := range tokens {
+		delete(c.tokens, tokenKey(t.URL, t.Username))
+	}
+	return len(tokens), c.save()
+}
+
+// Refresh requests new tokens for the cached tokens of a registry, or of all registries if registry is the empty string, and returns the
+// refreshed tokens. Tokens that were requested with credentials are requested with the current credentials of the docker CLI
+// configuration, which must be of the same user.
+func (c *TokenCache) Refresh(cliConfig *docker.CLIConfig, client *http.Client, registry string) ([]*Token, error) {
+	tokens, err := c.List(registry)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	refreshed := make([]*Token, len(tokens))
+	for i, t := range tokens {
+		var credentials *Credentials
+		if t.Username != "" {
+			// GetCredentials expects an image, so a repository of the registry is appended.
+			credentials, err = GetCredentials(cliConfig, t.Registry+"/repository")
+			if err != nil {
+				return nil, err
+			}
+			if credentials == nil || credentials.Username != t.Username {
+				return nil, fmt.Errorf("the credentials of user %s for registry %s are no longer available, log in with docker login or "+
+					"clear the tokens of the registry", t.Username, t.Registry)
+			}
+		}
+		refreshed[i], err = requestToken(client, t.Registry, t.URL, credentials)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error while refreshing the token of registry %s", t.Registry)
+		}
+		err = c.put(refreshed[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return refreshed, nil
+}
+
+// requestToken requests a bearer token for a registry from the URL of its token server (tokenURL), with basic authentication if credentials is not
+// nil.
+func requestToken(client *http.Client, registry, tokenURL string, credentials *Credentials) (*Token, error) {
+	req, err := http.NewRequest(http.MethodGet, tokenURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if credentials != nil {
+		req.SetBasicAuth(credentials.Username, credentials.Password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned status %s", req.URL.Redacted(), resp.Status)
+	}
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		IssuedAt    string `json:"issued_at"`
+		Token       string `json:"token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, err
+	}
+	t := &Token{
+		Registry: registry,
+		URL:      tokenURL,
+		Value:    response.Token,
+	}
+	if t.Value == "" {
+		t.Value = response.AccessToken
+	}
+	if credentials != nil {
+		t.Username = credentials.Username
+	}
+	issuedAt, err := time.Parse(time.RFC3339, response.IssuedAt)
+	if err != nil {
+		issuedAt = time.Now()
+	}
+	lifetime := defaultTokenLifetime
+	if response.ExpiresIn > 0 {
+		lifetime = time.Duration(response.ExpiresIn) * time.Second
+	}
+	t.ExpiresAt = issuedAt.Add(lifetime)
+	return t, nil
+}
@@ -0,0 +1,218 @@
+package remote
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockTokenStore is an in-memory keychain item that counts its reads and writes.
+type mockTokenStore struct {
+	data   []byte
+	gets   int
+	writes int
+}
+
+func (s *mockTokenStore) Get() ([]byte, error) {
+	s.gets++
+	return s.data, nil
+}
+
+func (s *mockTokenStore) Set(data []byte) error {
+	s.writes++
+	s.data = data
+	return nil
+}
+
+func (s *mockTokenStore) Delete() error {
+	s.writes++
+	s.data = nil
+	return nil
+}
+
+// withTokens runs cb with tokens cached in store.
+func withTokens(store tokenStore, cb func()) {
+	orig := Tokens
+	defer func() {
+		Tokens = orig
+	}()
+	Tokens = &TokenCache{
+		store: store,
+	}
+	cb()
+}
+
+// countingTransport counts the requests for bearer tokens.
+type countingTransport struct {
+	tokenRequests int
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Path == "/token" {
+		c.tokenRequests++
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newTestTokenURL(server *httptest.Server) string {
+	return server.URL + "/token?scope=repository%3Aorg%2Fapp%3Apull&service=registry"
+}
+
+func TestOCIFetcher_CachedToken(t *testing.T) {
+	server := newTestRegistry(newTestArtifact([]byte("services: {}\n"), []byte("A=1\n")))
+	defer server.Close()
+	store := &mockTokenStore{}
+	transport := &countingTransport{}
+	withTokens(store, func() {
+		withMocks(nil, func(_ *[]string) {
+			f := &OCIFetcher{
+				Client: &http.Client{
+					Transport: transport,
+				},
+			}
+			for i := 0; i < 2; i++ {
+				_, err := f.Fetch("oci://"+strings.TrimPrefix(server.URL, "http://")+"/org/app:1.0", fmt.Sprintf("/cache/%d", i))
+				if err != nil {
+					t.Error(err)
+				}
+			}
+		})
+		if transport.tokenRequests != 1 {
+			t.Error(transport.tokenRequests)
+		}
+		tokens, err := Tokens.List("")
+		if err != nil || len(tokens) != 1 || tokens[0].Value != "secret" || tokens[0].Scope() != "repository:org/app:pull" ||
+			tokens[0].Registry != strings.TrimPrefix(server.URL, "http://") {
+			t.Error(tokens, err)
+		}
+	})
+	// The keychain is read once, and written once when the token is issued, rather than for every request.
+	if store.gets != 1 || store.writes != 1 {
+		t.Error(store.gets, store.writes)
+	}
+	// A new command reads the token from the keychain.
+	withTokens(store, func() {
+		tokens, err := Tokens.List("")
+		if err != nil || len(tokens) != 1 || tokens[0].Value != "secret" {
+			t.Error(tokens, err)
+		}
+	})
+}
+
+func TestOCIFetcher_RevokedCachedToken(t *testing.T) {
+	server := newTestRegistry(newTestArtifact([]byte("services: {}\n"), []byte("A=1\n")))
+	defer server.Close()
+	withTokens(&mockTokenStore{}, func() {
+		registry := strings.TrimPrefix(server.URL, "http://")
+		err := Tokens.put(&Token{
+			ExpiresAt: time.Now().Add(time.Hour),
+			Registry:  registry,
+			URL:       newTestTokenURL(server),
+			Value:     "revoked",
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		withMocks(nil, func(_ *[]string) {
+			_, err = (&OCIFetcher{}).Fetch("oci://"+registry+"/org/app:1.0", "/cache/1")
+			if err != nil {
+				t.Error(err)
+			}
+		})
+		tokens, err := Tokens.List("")
+		if err != nil || len(tokens) != 1 || tokens[0].Value != "secret" {
+			t.Error(tokens, err)
+		}
+	})
+}
+
+func TestTokenCache_ExpiredToken(t *testing.T) {
+	withTokens(&mockTokenStore{}, func() {
+		err := Tokens.put(&Token{
+			ExpiresAt: time.Now().Add(time.Second),
+			Registry:  "registry.example.com",
+			URL:       "https://auth.example.com/token",
+			Value:     "secret",
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		if token := Tokens.getToken("https://auth.example.com/token", ""); token != nil {
+			t.Error(token)
+		}
+	})
+}
+
+func TestTokenCache_Clear(t *testing.T) {
+	store := &mockTokenStore{}
+	withTokens(store, func() {
+		for _, registry := range []string{"a.example.com", "b.example.com"} {
+			err := Tokens.put(&Token{
+				Registry: registry,
+				URL:      "https://" + registry + "/token",
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}
+		n, err := Tokens.Clear("a.example.com")
+		if err != nil || n != 1 {
+			t.Error(n, err)
+		}
+		tokens, err := Tokens.List("")
+		if err != nil || len(tokens) != 1 || tokens[0].Registry != "b.example.com" {
+			t.Error(tokens, err)
+		}
+		n, err = Tokens.Clear("")
+		if err != nil || n != 1 {
+			t.Error(n, err)
+		}
+	})
+	// The keychain item is deleted when the last token is cleared.
+	if store.data != nil {
+		t.Error(string(store.data))
+	}
+}
+
+func TestTokenCache_Refresh(t *testing.T) {
+	server := newTestRegistry(newTestArtifact([]byte("services: {}\n"), []byte("A=1\n")))
+	defer server.Close()
+	withTokens(&mockTokenStore{}, func() {
+		err := Tokens.put(&Token{
+			ExpiresAt: time.Now().Add(-time.Hour),
+			Registry:  strings.TrimPrefix(server.URL, "http://"),
+			URL:       newTestTokenURL(server),
+			Value:     "expired",
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		refreshed, err := Tokens.Refresh(nil, nil, "")
+		if err != nil || len(refreshed) != 1 || refreshed[0].Value != "secret" || time.Until(refreshed[0].ExpiresAt) <= 0 {
+			t.Error(refreshed, err)
+		}
+		if token := Tokens.getToken(newTestTokenURL(server), ""); token == nil || token.Value != "secret" {
+			t.Error(token)
+		}
+	})
+}
+
+func TestTokenCache_RefreshNoCredentials(t *testing.T) {
+	withTokens(&mockTokenStore{}, func() {
+		err := Tokens.put(&Token{
+			Registry: "registry.example.com",
+			URL:      "https://registry.example.com/token",
+			Username: "user",
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		_, err = Tokens.Refresh(nil, nil, "")
+		if err == nil || !strings.Contains(err.Error(), "docker login") {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+// Package sops decrypts files encrypted with SOPS (https://github.com/mozilla/sops) in memory, so that decrypted secrets never hit
+// the disk. Decryption is delegated to the sops executable, which supports all of SOPS' key management (age, PGP, cloud KMS, etc.).
+package sops
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+)
+
+// Format is the format of a file, as understood by sops.
+type Format string
+
+const (
+	// FormatDotenv is the format of files with lines of the form NAME=VALUE.
+	FormatDotenv Format = "dotenv"
+	// FormatYAML is the format of YAML files.
+	FormatYAML Format = "yaml"
+)
+
+// execSops runs sops with the specified arguments and returns its standard output. It is a variable to improve testability.
+var execSops = func(args ...string) ([]byte, error) {
+	cmd := exec.Command("sops", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s", bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout, nil
+}
+
+// IsEncryptedYAML determines whether a decoded YAML document is encrypted with SOPS, by checking for SOPS' metadata. Maps of any type
+// with keys of type interface{} are supported, because YAML decoders decode nested maps with the type of the outer map.
+func IsEncryptedYAML(data interface{}) bool {
+	metadata := mapIndex(data, "sops")
+	return metadata != nil && mapIndex(metadata, "mac") != nil
+}
+
+// mapIndex returns the value of key in m, or nil if m is not a map with keys of type interface{} or m does not contain key.
+func mapIndex(m interface{}, key string) interface{} {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.Interface {
+		return nil
+	}
+	value := v.MapIndex(reflect.ValueOf(key))
+	if !value.IsValid() {
+		return nil
+	}
+	return value.Interface()
+}
+
+// IsEncryptedDotenv determines whether a dotenv file is encrypted with SOPS, by checking for SOPS' metadata.
+func IsEncryptedDotenv(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if strings.HasPrefix(strings.TrimSpace(scanner.Text()), "sops_mac=") {
+			return true
+		}
+	}
+	return false
+}
+
+// Decrypt decrypts a file of the specified format that is encrypted with SOPS, and returns the decrypted file in memory. The encrypted
+// file is passed to sops as a temporary file, because sops cannot read standard input on all platforms (e.g. /dev/stdin does not exist on
+// Windows). sops writes the decrypted file to its standard output, so that it never hits the disk.
+func Decrypt(data []byte, format Format) ([]byte, error) {
+	file, err := writeTempFile(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while writing an encrypted file for sops")
+	}
+	defer func() {
+		err := os.Remove(file)
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+	decrypted, err := execSops("--decrypt", "--input-type", string(format), "--output-type", string(format), file)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while decrypting a file with sops (is sops installed and are keys available?)")
+	}
+	return decrypted, nil
+}
+
+// writeTempFile writes data to a new temporary file, and returns the name of the file.
+func writeTempFile(data []byte) (string, error) {
+	file, err := ioutil.TempFile("", "kube-compose-sops-")
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(data)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
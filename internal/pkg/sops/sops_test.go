@@ -0,0 +1,96 @@
+package sops
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func withMockSops(mock func(args ...string) ([]byte, error), cb func()) {
+	orig := execSops
+	defer func() {
+		execSops = orig
+	}()
+	execSops = mock
+	cb()
+}
+
+func TestIsEncryptedYAML_True(t *testing.T) {
+	data := map[interface{}]interface{}{
+		"services": map[interface{}]interface{}{},
+		"sops": map[interface{}]interface{}{
+			"mac": "ENC[AES256_GCM,data:...]",
+		},
+	}
+	if !IsEncryptedYAML(data) {
+		t.Fail()
+	}
+}
+
+type testGenericMap map[interface{}]interface{}
+
+func TestIsEncryptedYAML_NamedMapType(t *testing.T) {
+	data := testGenericMap{
+		"sops": testGenericMap{
+			"mac": "ENC[AES256_GCM,data:...]",
+		},
+	}
+	if !IsEncryptedYAML(data) {
+		t.Fail()
+	}
+}
+
+func TestIsEncryptedYAML_False(t *testing.T) {
+	data := map[interface{}]interface{}{
+		"services": map[interface{}]interface{}{},
+		"sops":     "not metadata",
+	}
+	if IsEncryptedYAML(data) {
+		t.Fail()
+	}
+}
+
+func TestIsEncryptedDotenv_Success(t *testing.T) {
+	if !IsEncryptedDotenv([]byte("PASSWORD=ENC[AES256_GCM,data:...]\nsops_mac=ENC[AES256_GCM,data:...]\n")) {
+		t.Fail()
+	}
+	if IsEncryptedDotenv([]byte("PASSWORD=secret\n")) {
+		t.Fail()
+	}
+}
+
+func TestDecrypt_Success(t *testing.T) {
+	var file string
+	withMockSops(func(args ...string) ([]byte, error) {
+		// The encrypted file is passed as a file rather than /dev/stdin, which does not exist on Windows.
+		file = args[len(args)-1]
+		expectedArgs := []string{"--decrypt", "--input-type", "yaml", "--output-type", "yaml", file}
+		data, err := ioutil.ReadFile(file)
+		if err != nil || string(data) != "encrypted" || !reflect.DeepEqual(args, expectedArgs) {
+			t.Error(string(data), err, args)
+		}
+		return []byte("decrypted"), nil
+	}, func() {
+		decrypted, err := Decrypt([]byte("encrypted"), FormatYAML)
+		if err != nil || string(decrypted) != "decrypted" {
+			t.Fail()
+		}
+	})
+	// The temporary file is removed.
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Error(err)
+	}
+}
+
+func TestDecrypt_Error(t *testing.T) {
+	withMockSops(func(_ ...string) ([]byte, error) {
+		return nil, fmt.Errorf("no key could decrypt the data key")
+	}, func() {
+		_, err := Decrypt([]byte("encrypted"), FormatDotenv)
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,249 @@
+package timing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+)
+
+// The environment variables of the OpenTelemetry specification that configure the OTLP exporter:
+// https://opentelemetry.io/docs/specs/otel/protocol/exporter/
+const (
+	OTLPEndpointEnvVarName       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	OTLPTracesEndpointEnvVarName = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	OTLPHeadersEnvVarName        = "OTEL_EXPORTER_OTLP_HEADERS"
+)
+
+// The timeout of requests to export spans.
+const otlpExportTimeout = 10 * time.Second
+
+// OTLPExporter exports the spans of a recorder to an OpenTelemetry collector with OTLP over HTTP, using the JSON encoding.
+type OTLPExporter struct {
+	Client *http.Client
+	// The URL to which spans are posted, including the path (typically /v1/traces).
+	Endpoint string
+	// Extra headers of requests, such as authentication headers.
+	Headers map[string]string
+}
+
+// NewOTLPExporterFromEnv creates an exporter that is configured by the environment variables of the OpenTelemetry specification. Nil is
+// returned if neither OTEL_EXPORTER_OTLP_TRACES_ENDPOINT nor OTEL_EXPORTER_OTLP_ENDPOINT is set.
+func NewOTLPExporterFromEnv(getenv func(string) (string, bool)) (*OTLPExporter, error) {
+	endpoint, ok := getenv(OTLPTracesEndpointEnvVarName)
+	if !ok || endpoint == "" {
+		endpoint, ok = getenv(OTLPEndpointEnvVarName)
+		if !ok || endpoint == "" {
+			return nil, nil
+		}
+		endpoint = strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	}
+	e := &OTLPExporter{
+		Client: &http.Client{
+			Timeout: otlpExportTimeout,
+		},
+		Endpoint: endpoint,
+		Headers:  map[string]string{},
+	}
+	headers, _ := getenv(OTLPHeadersEnvVarName)
+	for _, header := range strings.Split(headers, ",") {
+		if strings.TrimSpace(header) == "" {
+			continue
+		}
+		i := strings.IndexByte(header, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("the environment variable %s must be a comma-separated list of key=value pairs", OTLPHeadersEnvVarName)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(header[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("the environment variable %s has an invalid value of header %s: %v", OTLPHeadersEnvVarName,
+				strings.TrimSpace(header[:i]), err)
+		}
+		e.Headers[strings.TrimSpace(header[:i])] = value
+	}
+	return e, nil
+}
+
+// The types below are the subset of the JSON encoding of OTLP that is needed to export spans:
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpSpan struct {
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	EndTime      string         `json:"endTimeUnixNano"`
+	Kind         int            `json:"kind"`
+	Name         string         `json:"name"`
+	ParentSpanID string         `json:"parentSpanId,omitempty"`
+	SpanID       string         `json:"spanId"`
+	StartTime    string         `json:"startTimeUnixNano"`
+	TraceID      string         `json:"traceId"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []*otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []*otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpExportRequest struct {
+	ResourceSpans []*otlpResourceSpans `json:"resourceSpans"`
+}
+
+// The kind SPAN_KIND_INTERNAL of OTLP.
+const otlpSpanKindInternal = 1
+
+func newOTLPAttributes(attributes map[string]string) []otlpKeyValue {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	keyValues := make([]otlpKeyValue, len(keys))
+	for i, key := range keys {
+		keyValues[i] = otlpKeyValue{
+			Key: key,
+			Value: otlpAnyValue{
+				StringValue: attributes[key],
+			},
+		}
+	}
+	return keyValues
+}
+
+func formatUnixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func newRandomID(n int, random io.Reader) (string, error) {
+	b := make([]byte, n)
+	_, err := io.ReadFull(random, b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// newOTLPExportRequest returns a request that exports the finished spans of a recorder as the children of a root span named rootName.
+// The root span spans all finished spans and has the given attributes, so that the spans can be correlated with the environment.
+func (r *Recorder) newOTLPExportRequest(rootName string, attributes map[string]string, random io.Reader) (*otlpExportRequest, error) {
+	spans := r.finishedSpans()
+	traceID, err := newRandomID(16, random)
+	if err != nil {
+		return nil, err
+	}
+	root := &otlpSpan{
+		Attributes: newOTLPAttributes(attributes),
+		Kind:       otlpSpanKindInternal,
+		Name:       rootName,
+		TraceID:    traceID,
+	}
+	root.SpanID, err = newRandomID(8, random)
+	if err != nil {
+		return nil, err
+	}
+	var start, end time.Time
+	otlpSpans := []*otlpSpan{root}
+	for _, s := range spans {
+		if start.IsZero() || s.Start.Before(start) {
+			start = s.Start
+		}
+		if s.End.After(end) {
+			end = s.End
+		}
+		span := &otlpSpan{
+			EndTime:      formatUnixNano(s.End),
+			Kind:         otlpSpanKindInternal,
+			Name:         s.Name,
+			ParentSpanID: root.SpanID,
+			StartTime:    formatUnixNano(s.Start),
+			TraceID:      traceID,
+		}
+		if s.Service != "" {
+			span.Attributes = newOTLPAttributes(map[string]string{
+				"kube_compose.service": s.Service,
+			})
+		}
+		span.SpanID, err = newRandomID(8, random)
+		if err != nil {
+			return nil, err
+		}
+		otlpSpans = append(otlpSpans, span)
+	}
+	root.StartTime = formatUnixNano(start)
+	root.EndTime = formatUnixNano(end)
+	scopeSpans := &otlpScopeSpans{
+		Spans: otlpSpans,
+	}
+	scopeSpans.Scope.Name = "kube-compose"
+	resourceSpans := &otlpResourceSpans{
+		ScopeSpans: []*otlpScopeSpans{scopeSpans},
+	}
+	resourceSpans.Resource.Attributes = newOTLPAttributes(map[string]string{
+		"service.name": "kube-compose",
+	})
+	return &otlpExportRequest{
+		ResourceSpans: []*otlpResourceSpans{resourceSpans},
+	}, nil
+}
+
+// Export exports the finished spans of a recorder as one trace, whose root span is named rootName and has the given attributes. Nothing
+// is exported if the recorder has no finished spans.
+func (e *OTLPExporter) Export(r *Recorder, rootName string, attributes map[string]string) error {
+	if len(r.finishedSpans()) == 0 {
+		return nil
+	}
+	request, err := r.newOTLPExportRequest(rootName, attributes, rand.Reader)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	httpRequest, err := http.NewRequest(http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	for key, value := range e.Headers {
+		httpRequest.Header.Set(key, value)
+	}
+	response, err := e.Client.Do(httpRequest)
+	if err != nil {
+		return fmt.Errorf("error while exporting spans to %s: %v", e.Endpoint, err)
+	}
+	defer util.CloseAndLogError(response.Body)
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		responseBody, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("error while exporting spans to %s: unexpected status %s: %s", e.Endpoint, response.Status,
+			strings.TrimSpace(string(responseBody)))
+	}
+	return nil
+}
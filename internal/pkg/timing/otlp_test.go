@@ -0,0 +1,129 @@
+package timing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func newTestGetenv(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+func TestNewOTLPExporterFromEnv_NotConfigured(t *testing.T) {
+	e, err := NewOTLPExporterFromEnv(newTestGetenv(map[string]string{}))
+	if e != nil || err != nil {
+		t.Error(e, err)
+	}
+}
+
+func TestNewOTLPExporterFromEnv_Endpoint(t *testing.T) {
+	e, err := NewOTLPExporterFromEnv(newTestGetenv(map[string]string{
+		OTLPEndpointEnvVarName: "http://collector:4318/",
+		OTLPHeadersEnvVarName:  "authorization=Bearer%20token, x-tenant=a",
+	}))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expectedHeaders := map[string]string{
+		"authorization": "Bearer token",
+		"x-tenant":      "a",
+	}
+	if e.Endpoint != "http://collector:4318/v1/traces" || !reflect.DeepEqual(e.Headers, expectedHeaders) {
+		t.Error(e.Endpoint, e.Headers)
+	}
+}
+
+func TestNewOTLPExporterFromEnv_TracesEndpoint(t *testing.T) {
+	e, err := NewOTLPExporterFromEnv(newTestGetenv(map[string]string{
+		OTLPEndpointEnvVarName:       "http://collector:4318",
+		OTLPTracesEndpointEnvVarName: "http://collector:4318/custom",
+	}))
+	if err != nil || e.Endpoint != "http://collector:4318/custom" {
+		t.Error(e, err)
+	}
+}
+
+func TestNewOTLPExporterFromEnv_InvalidHeaders(t *testing.T) {
+	_, err := NewOTLPExporterFromEnv(newTestGetenv(map[string]string{
+		OTLPEndpointEnvVarName: "http://collector:4318",
+		OTLPHeadersEnvVarName:  "authorization",
+	}))
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestOTLPExporterExport_Success(t *testing.T) {
+	var request otlpExportRequest
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &request)
+	}))
+	defer server.Close()
+	r := newTestRecorder()
+	r.Start("pull image", "db").Finish()
+	r.Start("create services", "").Finish()
+	e := &OTLPExporter{
+		Client:   server.Client(),
+		Endpoint: server.URL,
+		Headers: map[string]string{
+			"Authorization": "Bearer token",
+		},
+	}
+	err := e.Export(r, "kube-compose up", map[string]string{
+		"k8s.namespace.name": "ns",
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if header.Get("Authorization") != "Bearer token" || header.Get("Content-Type") != "application/json" {
+		t.Error(header)
+	}
+	if len(request.ResourceSpans) != 1 || len(request.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Error(request)
+		return
+	}
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Error(spans)
+		return
+	}
+	root := spans[0]
+	if root.Name != "kube-compose up" || root.StartTime != "1000000000" || root.EndTime != "4000000000" || len(root.TraceID) != 32 ||
+		!reflect.DeepEqual(root.Attributes, []otlpKeyValue{{Key: "k8s.namespace.name", Value: otlpAnyValue{StringValue: "ns"}}}) {
+		t.Error(root)
+	}
+	for _, span := range spans[1:] {
+		if span.TraceID != root.TraceID || span.ParentSpanID != root.SpanID || len(span.SpanID) != 16 {
+			t.Error(span)
+		}
+	}
+}
+
+func TestOTLPExporterExport_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	r := newTestRecorder()
+	r.Start("pull image", "db").Finish()
+	e := &OTLPExporter{
+		Client:   server.Client(),
+		Endpoint: server.URL,
+	}
+	err := e.Export(r, "kube-compose up", nil)
+	if err == nil {
+		t.Fail()
+	}
+}
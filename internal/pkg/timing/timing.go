@@ -0,0 +1,232 @@
+package timing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Span is a timed phase of an operation, such as pulling the image of a docker compose service.
+type Span struct {
+	Name string
+	// The docker compose service of the phase, or the empty string if the phase is not specific to a service.
+	Service string
+	Start   time.Time
+	End     time.Time
+	r       *Recorder
+}
+
+// Finish records the end of the span. Finishing a span more than once has no effect.
+func (s *Span) Finish() {
+	s.r.mutex.Lock()
+	defer s.r.mutex.Unlock()
+	if s.End.IsZero() {
+		s.End = s.r.now()
+	}
+}
+
+// Duration returns the duration of a finished span.
+func (s *Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Recorder records the spans of an operation, so that users can find its slowest phases. All methods are safe for concurrent use.
+type Recorder struct {
+	mutex sync.Mutex
+	now   func() time.Time
+	spans []*Span
+}
+
+// NewRecorder creates a recorder without spans.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		now: time.Now,
+	}
+}
+
+// Start starts a span of a docker compose service. If service is the empty string then the span is not specific to a service.
+func (r *Recorder) Start(name, service string) *Span {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	s := &Span{
+		Name:    name,
+		Service: service,
+		Start:   r.now(),
+		r:       r,
+	}
+	r.spans = append(r.spans, s)
+	return s
+}
+
+// Record records a span that started at start and ends now, for phases whose start is only known afterwards.
+func (r *Recorder) Record(name, service string, start time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.spans = append(r.spans, &Span{
+		Name:    name,
+		Service: service,
+		Start:   start,
+		End:     r.now(),
+		r:       r,
+	})
+}
+
+// finishedSpans returns the finished spans, ordered by service and then by start time. Spans that are not specific to a service come
+// first.
+func (r *Recorder) finishedSpans() []*Span {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var spans []*Span
+	for _, s := range r.spans {
+		if !s.End.IsZero() {
+			spans = append(spans, s)
+		}
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].Service != spans[j].Service {
+			return spans[i].Service < spans[j].Service
+		}
+		return spans[i].Start.Before(spans[j].Start)
+	})
+	return spans
+}
+
+type summaryRow struct {
+	name     string
+	service  string
+	duration time.Duration
+}
+
+// summarize returns the total duration of each phase of each service, in the order of finishedSpans.
+func (r *Recorder) summarize() []*summaryRow {
+	var rows []*summaryRow
+	index := map[[2]string]*summaryRow{}
+	for _, s := range r.finishedSpans() {
+		key := [2]string{s.Service, s.Name}
+		row := index[key]
+		if row == nil {
+			row = &summaryRow{
+				name:    s.Name,
+				service: s.Service,
+			}
+			index[key] = row
+			rows = append(rows, row)
+		}
+		row.duration += s.Duration()
+	}
+	return rows
+}
+
+// WriteSummary writes a table with the total duration of each phase of each service, followed by the slowest phase. Phases that are not
+// specific to a service are listed first.
+func (r *Recorder) WriteSummary(w io.Writer) error {
+	rows := r.summarize()
+	if len(rows) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, err := fmt.Fprintln(tw, "SERVICE\tPHASE\tDURATION")
+	if err != nil {
+		return err
+	}
+	var slowest *summaryRow
+	for _, row := range rows {
+		service := row.service
+		if service == "" {
+			service = "-"
+		}
+		_, err = fmt.Fprintf(tw, "%s\t%s\t%s\n", service, row.name, formatDuration(row.duration))
+		if err != nil {
+			return err
+		}
+		if slowest == nil || row.duration > slowest.duration {
+			slowest = row
+		}
+	}
+	err = tw.Flush()
+	if err != nil {
+		return err
+	}
+	if slowest.service == "" {
+		_, err = fmt.Fprintf(w, "slowest phase: %s (%s)\n", slowest.name, formatDuration(slowest.duration))
+	} else {
+		_, err = fmt.Fprintf(w, "slowest phase: %s of service %s (%s)\n", slowest.name, slowest.service, formatDuration(slowest.duration))
+	}
+	return err
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+// traceEvent is an event of the Trace Event Format:
+// https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+type traceEvent struct {
+	Args     map[string]string `json:"args,omitempty"`
+	Category string            `json:"cat,omitempty"`
+	Duration *int64            `json:"dur,omitempty"`
+	Name     string            `json:"name"`
+	Phase    string            `json:"ph"`
+	PID      int               `json:"pid"`
+	TID      int               `json:"tid"`
+	// The timestamp in microseconds.
+	Timestamp int64 `json:"ts"`
+}
+
+// WriteChromeTrace writes the finished spans in the Trace Event Format, which can be opened with chrome://tracing or Perfetto. Each
+// service is shown as a thread, and the spans of a service as the slices of its thread.
+func (r *Recorder) WriteChromeTrace(w io.Writer) error {
+	spans := r.finishedSpans()
+	var origin time.Time
+	for _, s := range spans {
+		if origin.IsZero() || s.Start.Before(origin) {
+			origin = s.Start
+		}
+	}
+	events := []*traceEvent{}
+	tids := map[string]int{}
+	for _, s := range spans {
+		tid, ok := tids[s.Service]
+		if !ok {
+			tid = len(tids) + 1
+			tids[s.Service] = tid
+			threadName := s.Service
+			if threadName == "" {
+				threadName = "kube-compose"
+			}
+			events = append(events, &traceEvent{
+				Args: map[string]string{
+					"name": threadName,
+				},
+				Name:  "thread_name",
+				Phase: "M",
+				PID:   1,
+				TID:   tid,
+			})
+		}
+		duration := s.Duration().Nanoseconds() / int64(time.Microsecond)
+		event := &traceEvent{
+			Category:  "kube-compose",
+			Duration:  &duration,
+			Name:      s.Name,
+			Phase:     "X",
+			PID:       1,
+			TID:       tid,
+			Timestamp: s.Start.Sub(origin).Nanoseconds() / int64(time.Microsecond),
+		}
+		if s.Service != "" {
+			event.Args = map[string]string{
+				"service": s.Service,
+			}
+		}
+		events = append(events, event)
+	}
+	return json.NewEncoder(w).Encode(map[string]interface{}{
+		"displayTimeUnit": "ms",
+		"traceEvents":     events,
+	})
+}
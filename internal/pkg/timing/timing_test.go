@@ -0,0 +1,102 @@
+package timing
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// newTestRecorder returns a recorder whose clock advances by one second every time it is read.
+func newTestRecorder() *Recorder {
+	r := NewRecorder()
+	t := time.Unix(0, 0)
+	r.now = func() time.Time {
+		t = t.Add(time.Second)
+		return t
+	}
+	return r
+}
+
+func TestWriteSummary_Success(t *testing.T) {
+	r := newTestRecorder()
+	s1 := r.Start("resolve image", "db")
+	s2 := r.Start("create services", "")
+	s3 := r.Start("push image", "db")
+	s3.Finish()
+	s1.Finish()
+	s2.Finish()
+	s4 := r.Start("push image", "db")
+	s4.Finish()
+	s4.Finish()
+	// Unfinished spans are not reported.
+	r.Start("pull image", "app")
+	var buffer bytes.Buffer
+	err := r.WriteSummary(&buffer)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := `SERVICE  PHASE            DURATION
+-        create services  4s
+db       resolve image    4s
+db       push image       2s
+slowest phase: create services (4s)
+`
+	if buffer.String() != expected {
+		t.Error(buffer.String())
+	}
+}
+
+func TestWriteSummary_Empty(t *testing.T) {
+	r := newTestRecorder()
+	var buffer bytes.Buffer
+	err := r.WriteSummary(&buffer)
+	if err != nil || buffer.Len() != 0 {
+		t.Error(buffer.String(), err)
+	}
+}
+
+func TestRecord_Success(t *testing.T) {
+	r := newTestRecorder()
+	r.Record("wait for readiness", "db", time.Unix(0, 0))
+	var buffer bytes.Buffer
+	_ = r.WriteSummary(&buffer)
+	expected := `SERVICE  PHASE               DURATION
+db       wait for readiness  1s
+slowest phase: wait for readiness of service db (1s)
+`
+	if buffer.String() != expected {
+		t.Error(buffer.String())
+	}
+}
+
+func TestWriteChromeTrace_Success(t *testing.T) {
+	r := newTestRecorder()
+	r.Start("create services", "").Finish()
+	r.Start("pull image", "db").Finish()
+	var buffer bytes.Buffer
+	err := r.WriteChromeTrace(&buffer)
+	if err != nil {
+		t.Error(err)
+	}
+	var trace struct {
+		TraceEvents []traceEvent `json:"traceEvents"`
+	}
+	err = json.Unmarshal(buffer.Bytes(), &trace)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(trace.TraceEvents) != 4 {
+		t.Error(trace.TraceEvents)
+		return
+	}
+	thread, event := trace.TraceEvents[2], trace.TraceEvents[3]
+	if thread.Phase != "M" || thread.Args["name"] != "db" || thread.TID != 2 {
+		t.Error(thread)
+	}
+	if event.Phase != "X" || event.Name != "pull image" || event.TID != 2 || event.Timestamp != 2000000 || *event.Duration != 1000000 ||
+		event.Args["service"] != "db" {
+		t.Error(event)
+	}
+}
@@ -1,11 +1,15 @@
 package util
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"math"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 
 	log "github.com/Sirupsen/logrus"
 )
@@ -97,6 +101,37 @@ func EscapeName(input string) string {
 	return sb.String()
 }
 
+// NormalizeName lowercases name and replaces each run of characters other than ASCII letters and digits with a single dash, without
+// leading or trailing dashes, so that the result can be used in DNS labels and label values.
+func NormalizeName(name string) string {
+	var builder strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			builder.WriteRune(r)
+			dash = false
+		} else if !dash {
+			builder.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.Trim(builder.String(), "-")
+}
+
+// nameHashLength is the number of hexadecimal characters of the hash that TruncateName appends to names.
+const nameHashLength = 8
+
+// TruncateName deterministically shortens name to at most maxLength characters by replacing its tail with a hash of the whole name, so
+// that distinct long names remain distinct. Names of at most maxLength characters are returned unchanged. maxLength must be greater than
+// 9. If name matches the grammar of EscapeName then so does the result.
+func TruncateName(name string, maxLength int) string {
+	if len(name) <= maxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	return strings.TrimRight(name[:maxLength-nameHashLength-1], "-") + "-" + hex.EncodeToString(sum[:])[:nameHashLength]
+}
+
 // TryParseInt64 is a convenience method to parse a string into an *int64, allowing only one or more ASCII digits and an optional sign
 // prefix.
 func TryParseInt64(s string) *int64 {
@@ -169,3 +204,51 @@ func FormatTable(rows [][]string) string {
 	}
 	return sb.String()
 }
+
+// This doesn't deserve the name palette.
+var serviceColorPalette = []int{
+	37, // gray
+	36, // blue
+	35, // magenta
+	33, // yellow
+	32, // green
+}
+
+// ServiceColor returns the ANSI color of the prefixes of the log lines of the i-th service. Colors are reused if there are more services
+// than colors.
+func ServiceColor(i int) int {
+	return serviceColorPalette[i%len(serviceColorPalette)]
+}
+
+// ForEachConcurrently calls f(i) for every i in [0, n), with at most runtime.GOMAXPROCS(0) calls running at the same time. f must be safe
+// for concurrent use. If any call fails then the error of the call with the smallest i is returned, so that errors are deterministic if
+// the indices are in a deterministic order.
+func ForEachConcurrently(n int, f func(i int) error) error {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+	errs := make([]error, n)
+	indices := make(chan int, n)
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				errs[i] = f(i)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package util
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -73,6 +74,36 @@ func TestEscapeName_Success2(t *testing.T) {
 	}
 }
 
+func TestTruncateName_Short(t *testing.T) {
+	r := TruncateName("web-env1", 63)
+	if r != "web-env1" {
+		t.Error(r)
+	}
+}
+
+func TestTruncateName_Long(t *testing.T) {
+	name1 := strings.Repeat("a", 60) + "-service1-env1"
+	name2 := strings.Repeat("a", 60) + "-service2-env1"
+	r1 := TruncateName(name1, 63)
+	r2 := TruncateName(name2, 63)
+	if len(r1) > 63 || !strings.HasPrefix(r1, strings.Repeat("a", 54)+"-") {
+		t.Error(r1)
+	}
+	if r1 == r2 {
+		t.Error(r1, r2)
+	}
+	if TruncateName(name1, 63) != r1 {
+		t.Fail()
+	}
+}
+
+func TestTruncateName_TrailingDash(t *testing.T) {
+	r := TruncateName(strings.Repeat("a", 53)+"--"+strings.Repeat("b", 20), 63)
+	if len(r) != 62 || strings.Contains(r, "--") {
+		t.Error(r)
+	}
+}
+
 func TestTryParseInt64_Error(t *testing.T) {
 	uid := TryParseInt64("asdf")
 	if uid != nil {
@@ -150,3 +181,54 @@ func Test_NewBool_Success(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestServiceColor_Reused(t *testing.T) {
+	if ServiceColor(0) != 37 || ServiceColor(len(serviceColorPalette)) != ServiceColor(0) {
+		t.Fail()
+	}
+}
+
+func TestForEachConcurrently_Success(t *testing.T) {
+	n := 100
+	visited := make([]bool, n)
+	err := ForEachConcurrently(n, func(i int) error {
+		visited[i] = true
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	for i, v := range visited {
+		if !v {
+			t.Error(i)
+		}
+	}
+}
+
+func TestForEachConcurrently_FirstError(t *testing.T) {
+	err := ForEachConcurrently(10, func(i int) error {
+		if i == 3 || i == 7 {
+			return fmt.Errorf("error %d", i)
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "error 3" {
+		t.Error(err)
+	}
+}
+
+func TestForEachConcurrently_Zero(t *testing.T) {
+	err := ForEachConcurrently(0, func(i int) error {
+		return fmt.Errorf("unexpected call")
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNormalizeName_Success(t *testing.T) {
+	r := NormalizeName("_My Project__v2.")
+	if r != "my-project-v2" {
+		t.Error(r)
+	}
+}
@@ -0,0 +1,136 @@
+// Package vault resolves references to secrets stored in HashiCorp Vault (https://www.vaultproject.io/) using Vault's HTTP API.
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/kube-compose/kube-compose/pkg/expanduser"
+	"github.com/pkg/errors"
+)
+
+// ReferencePrefix is the prefix of references to secrets in Vault. A reference has the form vault://path#key.
+const ReferencePrefix = "vault://"
+
+// lookupEnv is a function used to get environment variables. It is a variable to improve testability.
+var lookupEnv = os.LookupEnv
+
+// ParseReference parses a reference of the form vault://path#key. The last return value is false if s is not a reference.
+func ParseReference(s string) (path, key string, ok bool) {
+	if !strings.HasPrefix(s, ReferencePrefix) {
+		return "", "", false
+	}
+	s = s[len(ReferencePrefix):]
+	i := strings.LastIndexByte(s, '#')
+	if i <= 0 || i == len(s)-1 {
+		return "", "", false
+	}
+	return strings.Trim(s[:i], "/"), s[i+1:], true
+}
+
+// Client reads secrets from Vault. Secrets are cached, so that each path is read at most once.
+type Client struct {
+	address    string
+	cache      map[string]map[string]interface{}
+	httpClient *http.Client
+	mutex      sync.Mutex
+	token      string
+}
+
+// NewClient creates a client for the Vault server at address, that authenticates with token.
+func NewClient(address, token string) *Client {
+	return &Client{
+		address:    strings.TrimRight(address, "/"),
+		cache:      map[string]map[string]interface{}{},
+		httpClient: http.DefaultClient,
+		token:      token,
+	}
+}
+
+// NewClientFromEnvironment creates a client like the Vault CLI does: the address is read from the environment variable VAULT_ADDR, and
+// the token is read from the environment variable VAULT_TOKEN or the file ~/.vault-token.
+func NewClientFromEnvironment() (*Client, error) {
+	address, ok := lookupEnv("VAULT_ADDR")
+	if !ok || address == "" {
+		return nil, fmt.Errorf("the environment variable VAULT_ADDR must be set to resolve references to secrets in Vault")
+	}
+	token, ok := lookupEnv("VAULT_TOKEN")
+	if !ok || token == "" {
+		tokenFile := filepath.Join(expanduser.ExpandUser("~"), ".vault-token")
+		fd, err := fs.OS.Open(tokenFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "either the environment variable VAULT_TOKEN or the file ~/.vault-token must be set to resolve "+
+				"references to secrets in Vault")
+		}
+		data, err := ioutil.ReadAll(fd)
+		util.CloseAndLogError(fd)
+		if err != nil {
+			return nil, err
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	return NewClient(address, token), nil
+}
+
+// read reads the secret at a path. Both versions of the key/value secrets engine are supported: for version 2, path must include the
+// data segment (e.g. secret/data/myapp).
+func (c *Client) read(path string) (map[string]interface{}, error) {
+	req, err := http.NewRequest(http.MethodGet, c.address+"/v1/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d when reading secret %s from Vault", resp.StatusCode, path)
+	}
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while decoding secret %s from Vault", path)
+	}
+	// Version 2 of the key/value secrets engine nests the secret's data and adds metadata.
+	if data, ok := body.Data["data"].(map[string]interface{}); ok {
+		if _, ok := body.Data["metadata"]; ok {
+			return data, nil
+		}
+	}
+	return body.Data, nil
+}
+
+// Resolve returns the value of key of the secret at path.
+func (c *Client) Resolve(path, key string) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	data, ok := c.cache[path]
+	if !ok {
+		var err error
+		data, err = c.read(path)
+		if err != nil {
+			return "", err
+		}
+		c.cache[path] = data
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s in Vault does not have key %s", path, key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
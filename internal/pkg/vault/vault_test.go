@@ -0,0 +1,134 @@
+package vault
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func withMockEnv(env map[string]string, cb func()) {
+	orig := lookupEnv
+	defer func() {
+		lookupEnv = orig
+	}()
+	lookupEnv = func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	cb()
+}
+
+func newTestServer(requestCount *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requestCount++
+		if r.Header.Get("X-Vault-Token") != "token1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/myapp":
+			fmt.Fprint(w, `{"data":{"password":"hunter2","port":5432}}`)
+		case "/v1/secret/data/myapp":
+			fmt.Fprint(w, `{"data":{"data":{"password":"hunter3"},"metadata":{"version":1}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestParseReference_Success(t *testing.T) {
+	path, key, ok := ParseReference("vault://secret/data/myapp#password")
+	if !ok || path != "secret/data/myapp" || key != "password" {
+		t.Fail()
+	}
+}
+
+func TestParseReference_NotAReference(t *testing.T) {
+	for _, s := range []string{"", "secret/myapp#password", "vault://secret/myapp", "vault://#password", "vault://secret/myapp#"} {
+		_, _, ok := ParseReference(s)
+		if ok {
+			t.Error(s)
+		}
+	}
+}
+
+func TestClientResolve_KVVersion1(t *testing.T) {
+	requestCount := 0
+	server := newTestServer(&requestCount)
+	defer server.Close()
+	c := NewClient(server.URL, "token1")
+	value, err := c.Resolve("secret/myapp", "password")
+	if err != nil {
+		t.Error(err)
+	} else if value != "hunter2" {
+		t.Error(value)
+	}
+	value, err = c.Resolve("secret/myapp", "port")
+	if err != nil {
+		t.Error(err)
+	} else if value != "5432" {
+		t.Error(value)
+	}
+	if requestCount != 1 {
+		t.Error(requestCount)
+	}
+}
+
+func TestClientResolve_KVVersion2(t *testing.T) {
+	requestCount := 0
+	server := newTestServer(&requestCount)
+	defer server.Close()
+	c := NewClient(server.URL, "token1")
+	value, err := c.Resolve("secret/data/myapp", "password")
+	if err != nil {
+		t.Error(err)
+	} else if value != "hunter3" {
+		t.Error(value)
+	}
+}
+
+func TestClientResolve_MissingKey(t *testing.T) {
+	requestCount := 0
+	server := newTestServer(&requestCount)
+	defer server.Close()
+	c := NewClient(server.URL, "token1")
+	_, err := c.Resolve("secret/myapp", "username")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestClientResolve_Forbidden(t *testing.T) {
+	requestCount := 0
+	server := newTestServer(&requestCount)
+	defer server.Close()
+	c := NewClient(server.URL, "token2")
+	_, err := c.Resolve("secret/myapp", "password")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestNewClientFromEnvironment_Success(t *testing.T) {
+	withMockEnv(map[string]string{
+		"VAULT_ADDR":  "https://vault.example.com/",
+		"VAULT_TOKEN": "token1",
+	}, func() {
+		c, err := NewClientFromEnvironment()
+		if err != nil {
+			t.Error(err)
+		} else if c.address != "https://vault.example.com" || c.token != "token1" {
+			t.Error(c)
+		}
+	})
+}
+
+func TestNewClientFromEnvironment_AddressNotSet(t *testing.T) {
+	withMockEnv(map[string]string{}, func() {
+		_, err := NewClientFromEnvironment()
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
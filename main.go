@@ -4,11 +4,12 @@ import (
 	"os"
 
 	"github.com/kube-compose/kube-compose/cmd"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
 )
 
 func main() {
 	err := cmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(int(exitcode.FromError(err)))
 	}
 }
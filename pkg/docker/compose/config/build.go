@@ -0,0 +1,82 @@
+package config
+
+import (
+	"github.com/uber-go/mapdecode"
+)
+
+// Build is the build section of a docker compose service, which describes how the image of the service is built.
+// See https://docs.docker.com/compose/compose-file/build/.
+type Build struct {
+	// The build arguments. Arguments without a value are set to the value of the variable of the environment, and are omitted if the
+	// variable is not set.
+	Args map[string]string
+	// The absolute path of the build context on the host.
+	Context string
+	// The path of the Dockerfile relative to Context, or an absolute path. The empty string denotes the Dockerfile in Context.
+	Dockerfile string
+	// The stage of a multi-stage Dockerfile to build, or the empty string to build the last stage.
+	Target string
+}
+
+type buildInternal struct {
+	Args       *environment
+	argsParsed map[string]string
+	Context    string
+	Dockerfile string
+	Target     string
+}
+
+func (b *buildInternal) Decode(into mapdecode.Into) error {
+	var longSyntax struct {
+		Args       *environment `mapdecode:"args"`
+		Context    *string      `mapdecode:"context"`
+		Dockerfile *string      `mapdecode:"dockerfile"`
+		Target     *string      `mapdecode:"target"`
+	}
+	err := into(&longSyntax)
+	if err != nil {
+		// The short syntax is the path of the build context.
+		var shortSyntax string
+		err = into(&shortSyntax)
+		if err != nil {
+			return err
+		}
+		longSyntax.Context = &shortSyntax
+	}
+	b.Args = longSyntax.Args
+	b.Context = "."
+	if longSyntax.Context != nil && *longSyntax.Context != "" {
+		b.Context = *longSyntax.Context
+	}
+	if longSyntax.Dockerfile != nil {
+		b.Dockerfile = *longSyntax.Dockerfile
+	}
+	if longSyntax.Target != nil {
+		b.Target = *longSyntax.Target
+	}
+	return nil
+}
+
+// parseBuild resolves the build context of a build section relative to the docker compose file, and resolves its arguments like
+// environment variables.
+func (c *configLoader) parseBuild(resolvedFile string, b *buildInternal) error {
+	b.Context = expandPath(resolvedFile, b.Context)
+	if b.Args == nil {
+		return nil
+	}
+	var err error
+	b.argsParsed, err = c.parseEnvironment(b.Args.Values)
+	return err
+}
+
+func finalizeBuild(b *buildInternal) *Build {
+	if b == nil {
+		return nil
+	}
+	return &Build{
+		Args:       b.argsParsed,
+		Context:    b.Context,
+		Dockerfile: b.Dockerfile,
+		Target:     b.Target,
+	}
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func Test_New_BuildSuccess(t *testing.T) {
+	file := "/project/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    build:
+      context: ./web
+      dockerfile: Dockerfile.dev
+      args:
+        VERSION: 1
+        KUBE_COMPOSE_BUILD_TEST_UNSET:
+      target: dev
+  service2:
+    build: api
+  service3:
+    image: ubuntu:latest
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		expected := &Build{
+			Args: map[string]string{
+				"VERSION": "1",
+			},
+			Context:    "/project/web",
+			Dockerfile: "Dockerfile.dev",
+			Target:     "dev",
+		}
+		if !reflect.DeepEqual(c.Services["service1"].Build, expected) {
+			t.Error(c.Services["service1"].Build)
+		}
+		if build := c.Services["service2"].Build; build == nil || build.Context != "/project/api" || build.Dockerfile != "" {
+			t.Error(build)
+		}
+		if c.Services["service3"].Build != nil {
+			t.Error(c.Services["service3"].Build)
+		}
+	})
+}
+
+func Test_New_BuildDefaultContext(t *testing.T) {
+	file := "/project/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    build:
+      target: dev
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if build := c.Services["service1"].Build; build == nil || build.Context != "/project" || build.Target != "dev" {
+			t.Error(build)
+		}
+	})
+}
+
+func Test_New_BuildInvalid(t *testing.T) {
+	file := "/project/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    build:
+    - .
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -1,14 +1,20 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	version "github.com/hashicorp/go-version"
 	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/sops"
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	"github.com/pkg/errors"
 	"github.com/uber-go/mapdecode"
@@ -33,10 +39,17 @@ type XProperties map[string]interface{}
 // It represents one ore more docker compose files that have been merged together using logic close to docker compose.
 // Similarly, extends will have been processed as well (see https://docs.docker.com/compose/compose-file/compose-file-v2/#extends).
 type CanonicalDockerComposeConfig struct {
+	// The docker compose files that were merged, in order, including the developer-specific docker compose file if it exists.
+	Files []string
+	// The top-level secrets, keyed by the names used to refer to them from services.
+	Secrets  map[string]*Secret
 	Services map[string]*Service
+	// The top-level volumes, keyed by the names used to refer to them from services.
+	Volumes map[string]*Volume
 	// For each docker compose file that was merged together, the root level x- properties as a generic map.
-	// Givens elements e_i and e_j of the slice, with indices i and j, respectively, such that i > j, XProperties e_i have a higher priority
-	// than XProperties e_j. Intuitively, elements later in the list take precedence over those earlier in the list.
+	// Givens elements e_i and e_j of the slice, with indices i and j, respectively, such that i < j, XProperties e_i have a higher priority
+	// than XProperties e_j. Intuitively, elements earlier in the list take precedence over those later in the list, because the last docker
+	// compose file is the first element.
 	// The user of this package can choose to implement merging of XProperties as appropriate.
 	XProperties []XProperties
 }
@@ -45,51 +58,104 @@ type CanonicalDockerComposeConfig struct {
 // is a smaller piece of CanonicalDockerComposeConfig.
 type Service struct {
 	// When adding a field here, please update merge.go with the logic required to merge these fields.
+	// The build section of the service, or nil if the image of the service is not built.
+	Build   *Build
 	Command []string
 	// TODO https://github.com/kube-compose/kube-compose/issues/214 consider simplifying to map[string]ServiceHealthiness
-	DependsOn           map[string]ServiceHealthiness
-	Entrypoint          []string
-	Environment         map[string]string
+	DependsOn map[string]ServiceHealthiness
+	// The dependencies of DependsOn that have restart: true, i.e. whose redeployment also redeploys this service.
+	DependsOnRestart map[string]bool
+	// The devices reserved for the service (deploy.resources.reservations.devices), such as GPUs.
+	Devices       []DeviceRequest
+	Entrypoint    []string
+	Environment   map[string]string
+	ExternalLinks []ExternalLink
+	// The names of groups and GIDs that the service's containers are members of, in addition to the group of the user (group_add).
+	GroupAdd            []string
 	Healthcheck         *Healthcheck
 	HealthcheckDisabled bool
 	Image               string
-	Name                string
-	Ports               []PortBinding
-	Privileged          bool
-	Restart             string
-	User                *string
-	Volumes             []ServiceVolume
-	WorkingDir          string
+	// The IPC mode of the service (ipc), such as IPCModeHost, or the empty string if not set.
+	IPC  string
+	Name string
+	// The names of the networks the service is connected to, sorted. If the service does not specify networks then this is
+	// []string{DefaultNetwork}.
+	Networks   []string
+	Ports      []PortBinding
+	Privileged bool
+	Resources  Resources
+	Restart    string
+	Secrets    []ServiceSecret
+	// The security options of the service (security_opt).
+	SecurityOptions SecurityOptions
+	// The time to wait for the service's containers to stop gracefully, or nil if it was not specified.
+	StopGracePeriod *time.Duration
+	User            *string
+	// The volume driver of the service's volumes (volume_driver), or the empty string if the service's volumes are bind mounts or
+	// named volumes.
+	VolumeDriver string
+	Volumes      []ServiceVolume
+	// The rules of the develop.watch section, used to synchronize files into running containers.
+	Watch      []WatchRule
+	WorkingDir string
+	// The extension fields of the service. This allows users of this package to read service specific configuration. The extension
+	// fields are not JSON encoded, because YAML maps do not have string keys.
+	XProperties XProperties `json:"-"`
 }
 
 // serviceInternal is a helper struct that is a smaller piece of dockerComposeFile.
 // TODO https://github.com/kube-compose/kube-compose/issues/211 merge with composeFileService struct
 type serviceInternal struct {
+	Build *buildInternal `mapdecode:"build"`
 	// TODO https://github.com/kube-compose/kube-compose/issues/153 interpret string command/entrypoint correctly
-	Command   *stringOrStringSlice `mapdecode:"command"`
-	DependsOn *dependsOn           `mapdecode:"depends_on"`
+	CgroupParent *string              `mapdecode:"cgroup_parent"`
+	Command      *stringOrStringSlice `mapdecode:"command"`
+	CPUs         *floatOrString       `mapdecode:"cpus"`
+	CPUSet       *string              `mapdecode:"cpuset"`
+	CPUShares    *int64               `mapdecode:"cpu_shares"`
+	DependsOn    *dependsOn           `mapdecode:"depends_on"`
+	Deploy       *deploy              `mapdecode:"deploy"`
+	Develop      *develop             `mapdecode:"develop"`
 	// TODO https://github.com/kube-compose/kube-compose/issues/153 interpret string command/entrypoint correctly
-	Entrypoint        *stringOrStringSlice `mapdecode:"entrypoint"`
-	Environment       *environment         `mapdecode:"environment"`
-	environmentParsed map[string]string
-	Extends           *extends `mapdecode:"extends"`
+	Entrypoint          *stringOrStringSlice `mapdecode:"entrypoint"`
+	EnvFile             *stringOrStringSlice `mapdecode:"env_file"`
+	Environment         *environment         `mapdecode:"environment"`
+	environmentParsed   map[string]string
+	Extends             *extends `mapdecode:"extends"`
+	ExternalLinks       []string `mapdecode:"external_links"`
+	externalLinksParsed []ExternalLink
 	// The final docker compose service in CanonicalDockerComposeConfig (only set if this is not an intermediate result).
-	finalService *Service
-	Healthcheck  *healthcheckInternal `mapdecode:"healthcheck"`
-	Image        *string              `mapdecode:"image"`
+	finalService   *Service
+	GroupAdd       []group              `mapdecode:"group_add"`
+	Healthcheck    *healthcheckInternal `mapdecode:"healthcheck"`
+	Image          *string              `mapdecode:"image"`
+	IPC            *string              `mapdecode:"ipc"`
+	Links          []string             `mapdecode:"links"`
+	linksParsed    []ExternalLink
+	MemLimit       *byteSize `mapdecode:"mem_limit"`
+	MemReservation *byteSize `mapdecode:"mem_reservation"`
 	// Convenient copy of the name so that we do not have to pass names around to preserve context.
-	name        string
-	Ports       []port `mapdecode:"ports"`
-	portsParsed []PortBinding
-	Privileged  *bool `mapdecode:"privileged"`
+	name           string
+	Networks       *serviceNetworks `mapdecode:"networks"`
+	networksParsed []string
+	OOMKillDisable *bool  `mapdecode:"oom_kill_disable"`
+	OOMScoreAdj    *int64 `mapdecode:"oom_score_adj"`
+	Ports          []port `mapdecode:"ports"`
+	portsParsed    []PortBinding
+	Privileged     *bool `mapdecode:"privileged"`
 	// Helper data used to detect cycles during process of extends and depends_on.
-	recStack bool
-	Restart  *string `mapdecode:"restart"`
-	User     *string `mapdecode:"user"`
+	recStack        bool
+	Restart         *string         `mapdecode:"restart"`
+	Secrets         []serviceSecret `mapdecode:"secrets"`
+	SecurityOpt     []string        `mapdecode:"security_opt"`
+	StopGracePeriod *string         `mapdecode:"stop_grace_period"`
+	User            *string         `mapdecode:"user"`
 	// Helper data used to detect cycles during process of extends and depends_on.
-	visited    bool
-	Volumes    []ServiceVolume `mapdecode:"volumes"`
-	WorkingDir *string         `mapdecode:"working_dir"`
+	visited      bool
+	VolumeDriver *string         `mapdecode:"volume_driver"`
+	Volumes      []ServiceVolume `mapdecode:"volumes"`
+	WorkingDir   *string         `mapdecode:"working_dir"`
+	xProperties  XProperties
 }
 
 // A helper for defer
@@ -101,7 +167,11 @@ func (c *serviceInternal) clearRecStack() {
 // of the docker compose configuration.
 // TODO https://github.com/kube-compose/kube-compose/issues/211 merge with composeFile struct
 type dockerComposeFile struct {
+	Secrets  map[string]*secretInternal `mapdecode:"secrets"`
+	secrets  map[string]*Secret
 	Services map[string]*serviceInternal `mapdecode:"services"`
+	Volumes  map[string]*volumeInternal  `mapdecode:"volumes"`
+	volumes  map[string]*Volume
 	version  *version.Version
 	// Extension fields at the root of the compose file represented by this struct.
 	xProperties XProperties
@@ -119,9 +189,15 @@ type loadResolvedFileCacheItem struct {
 
 type configLoader struct {
 	environmentGetter ValueGetter
+	// Whether docker compose files are rendered as Go templates before they are parsed (see renderTemplate).
+	template bool
 	// A cache required to detect cycles when processing extends. Additionally, each file is only
 	// processed once so that loading of configuration is faster.
 	loadResolvedFileCache map[string]*loadResolvedFileCacheItem
+	// A cache of parsed env files, because services of large projects often share env files. Safe for concurrent use.
+	envFileCache envFileCache
+	// Deduplicates the strings of loaded docker compose files, so that the loaded configuration of large files retains less memory.
+	strings stringInterner
 }
 
 // loadFile loads the specified file. If the file has already been loaded then a cache lookup is performed.
@@ -167,16 +243,75 @@ func (c *configLoader) loadResolvedFile(resolvedFile string) (*dockerComposeFile
 	return cacheItem.parsed, cacheItem.err
 }
 
-// loadYamlFileAsGenericMap is a helper used to YAML decode a file into a map[interface{}]interface{}.
-func loadYamlFileAsGenericMap(file string) (genericMap, error) {
+// readFile reads the contents of a file.
+func readFile(file string) ([]byte, error) {
 	reader, err := fs.OS.Open(file)
 	if err != nil {
 		return nil, err
 	}
 	defer util.CloseAndLogError(reader)
+	return ioutil.ReadAll(reader)
+}
+
+// loadYamlFileAsGenericMap is a helper used to YAML decode a file into a map[interface{}]interface{}. Files encrypted with SOPS are
+// decrypted in memory, before templates are rendered because SOPS authenticates the contents of the file as they were encrypted. Unless
+// templating is enabled, a file that is not encrypted is decoded from the open file, so that its contents are not read into a buffer
+// first. This does not bound memory usage: the YAML decoder builds the node tree of the whole file before it is decoded, so peak memory
+// still grows with the size of the file.
+func (c *configLoader) loadYamlFileAsGenericMap(file string) (genericMap, error) {
+	if !c.template {
+		dataMap, err := decodeYamlFileAsGenericMap(file)
+		if err != nil || !sops.IsEncryptedYAML(dataMap) {
+			return dataMap, err
+		}
+	}
+	data, err := readFile(file)
+	if err != nil {
+		return nil, err
+	}
+	data, err = decryptYamlIfEncrypted(file, data)
+	if err != nil {
+		return nil, err
+	}
+	if c.template {
+		data, err = renderTemplate(file, data, c.environmentGetter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return decodeYamlAsGenericMap(bytes.NewReader(data))
+}
+
+// sopsDecrypt decrypts a file that is encrypted with SOPS. It is a variable to improve testability.
+var sopsDecrypt = sops.Decrypt
+
+// decryptYamlIfEncrypted decrypts the contents of a docker compose file in memory if they are encrypted with SOPS. Contents that are not
+// valid YAML (e.g. templates that are not rendered yet) are not encrypted, because SOPS only encrypts valid YAML.
+func decryptYamlIfEncrypted(file string, data []byte) ([]byte, error) {
+	dataMap, err := decodeYamlAsGenericMap(bytes.NewReader(data))
+	if err != nil || !sops.IsEncryptedYAML(dataMap) {
+		return data, nil
+	}
+	data, err = sopsDecrypt(data, sops.FormatYAML)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while decrypting docker compose file %#v", file)
+	}
+	return data, nil
+}
+
+func decodeYamlFileAsGenericMap(file string) (genericMap, error) {
+	reader, err := fs.OS.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(reader)
+	return decodeYamlAsGenericMap(reader)
+}
+
+func decodeYamlAsGenericMap(reader io.Reader) (genericMap, error) {
 	decoder := yaml.NewDecoder(reader)
 	var dataMap genericMap
-	err = decoder.Decode(&dataMap)
+	err := decoder.Decode(&dataMap)
 	return dataMap, err
 }
 
@@ -187,7 +322,7 @@ func (c *configLoader) loadResolvedFileCore(resolvedFile string, dcFile *dockerC
 
 	// Load YAML file as map[interface{}]interface{}. This type is used so that we can subsequently
 	// interpolate environment variables and extract x- properties.
-	dataMap, err := loadYamlFileAsGenericMap(resolvedFile)
+	dataMap, err := c.loadYamlFileAsGenericMap(resolvedFile)
 	if err != nil {
 		return err
 	}
@@ -203,10 +338,14 @@ func (c *configLoader) loadResolvedFileCore(resolvedFile string, dcFile *dockerC
 	if err != nil {
 		return err
 	}
+	dataMap = c.strings.internMap(dataMap)
 
+	var secretsRaw, volumesRaw interface{}
 	if !dcFile.version.Equal(v1) {
 		// extract x- properties
 		dcFile.xProperties = getXProperties(dataMap)
+		secretsRaw = dataMap["secrets"]
+		volumesRaw = dataMap["volumes"]
 	} else {
 		dataMap = map[interface{}]interface{}{
 			"services": dataMap,
@@ -217,6 +356,17 @@ func (c *configLoader) loadResolvedFileCore(resolvedFile string, dcFile *dockerC
 	if err != nil {
 		return err
 	}
+	dcFile.secrets, err = parseSecrets(resolvedFile, dcFile.Secrets, secretsRaw)
+	if err != nil {
+		return err
+	}
+	dcFile.volumes = parseVolumes(dcFile.Volumes, volumesRaw)
+	servicesRaw, _ := dataMap["services"].(genericMap)
+	for name, s := range dcFile.Services {
+		if s != nil {
+			s.xProperties = getXProperties(servicesRaw[name])
+		}
+	}
 
 	// validation after parsing
 	return c.parseDockerComposeFile(dcFile)
@@ -265,6 +415,13 @@ func (c *configLoader) loadStandardFilesTry(dir, resolvedDir, override string) (
 		file := dir + basename
 		resolvedFile, err = fs.OS.EvalSymlinks(resolvedDir + "/" + basename)
 		if err == nil {
+			if override == "" {
+				// The directory of the standard docker compose file is the project directory.
+				err = c.loadDotEnvFile(resolvedDir)
+				if err != nil {
+					return "", err
+				}
+			}
 			_, err = c.loadResolvedFile(resolvedFile)
 			if err != nil {
 				return "", errors.Wrapf(err, "error while loading docker compose file %s (%#v)", dir+file, resolvedFile)
@@ -278,6 +435,34 @@ func (c *configLoader) loadStandardFilesTry(dir, resolvedDir, override string) (
 	return "", err
 }
 
+func containsString(slice []string, s string) bool {
+	for _, s2 := range slice {
+		if s2 == s {
+			return true
+		}
+	}
+	return false
+}
+
+// loadLocalFile loads the developer-specific docker compose file. The empty string is returned if the file does not exist.
+func (c *configLoader) loadLocalFile(dir, localFile string) (string, error) {
+	file := localFile
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	resolvedFile, err := fs.OS.EvalSymlinks(file)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", errors.Wrapf(err, "error when evaluating symlinks %#v", file)
+	}
+	_, err = c.loadResolvedFile(resolvedFile)
+	if err != nil {
+		return "", errors.Wrapf(err, "error while loading docker compose file %#v", resolvedFile)
+	}
+	return resolvedFile, nil
+}
+
 // processExtends process the extends field of a docker compose service. That is: given a docker compose service X,
 // if X extends another service Y then processExtends copies inherited configuration Y into the representation of X.
 func (c *configLoader) processExtends(
@@ -341,6 +526,11 @@ func (c *configLoader) resolveExtends(
 			s.Extends.Service,
 		)
 	}
+	if len(sExtended.Links) > 0 {
+		return nil, fmt.Errorf("cannot extend service %s: services with 'links' cannot be extended",
+			s.Extends.Service,
+		)
+	}
 	// TODO https://github.com/kube-compose/kube-compose/issues/122 perform full validation of extended service
 	err := c.processExtends(sExtended, dcFileExtended)
 	if err != nil {
@@ -374,17 +564,53 @@ func extendsNotFoundError(name1, file1, name2, file2 string) error {
 	)
 }
 
+// DefaultLocalFile is the default name of the developer-specific docker compose file, which is typically git-ignored.
+const DefaultLocalFile = "docker-compose.local.yml"
+
 // New loads docker compose configuration from a slice of files.
 // If files is an empty slice then the standard docker compose file locations (relative to the current working directory are considered).
 func New(files []string) (*CanonicalDockerComposeConfig, error) {
+	return NewWithLocalFile(files, "")
+}
+
+// NewWithLocalFile is like New, but additionally merges the developer-specific docker compose file localFile last, if it exists. A
+// relative localFile is interpreted relative to the directory of the first docker compose file. If localFile is empty then no
+// developer-specific docker compose file is loaded.
+func NewWithLocalFile(files []string, localFile string) (*CanonicalDockerComposeConfig, error) {
+	return NewWithOptions(files, &LoadOptions{
+		LocalFile: localFile,
+	})
+}
+
+// LoadOptions are the options of NewWithOptions.
+type LoadOptions struct {
+	// The developer-specific docker compose file (see NewWithLocalFile).
+	LocalFile string
+	// If true then each docker compose file is rendered as a Go text/template before it is parsed, which allows for conditional
+	// services. The template functions env, file and b64enc are available.
+	Template bool
+	// Extension fields with a lower priority than those of the docker compose files (e.g. the defaults of a project), or nil. If not nil,
+	// they are the last element of CanonicalDockerComposeConfig.XProperties.
+	XProperties XProperties
+}
+
+// NewWithOptions is like New, but with additional options.
+func NewWithOptions(files []string, opts *LoadOptions) (*CanonicalDockerComposeConfig, error) {
 	c := &configLoader{
 		environmentGetter:     os.LookupEnv,
 		loadResolvedFileCache: map[string]*loadResolvedFileCacheItem{},
+		template:              opts.Template,
 	}
+	localFile := opts.LocalFile
 	var resolvedFiles []string
 	if len(files) > 0 {
+		err := c.loadDotEnvFile(filepath.Dir(files[0]))
+		if err != nil {
+			return nil, err
+		}
 		for _, file := range files {
-			dcFile, err := c.loadFile(file)
+			var dcFile *dockerComposeFile
+			dcFile, err = c.loadFile(file)
 			if err != nil {
 				return nil, err
 			}
@@ -397,6 +623,15 @@ func New(files []string) (*CanonicalDockerComposeConfig, error) {
 			return nil, err
 		}
 	}
+	if localFile != "" {
+		resolvedLocalFile, err := c.loadLocalFile(filepath.Dir(resolvedFiles[0]), localFile)
+		if err != nil {
+			return nil, err
+		}
+		if resolvedLocalFile != "" && !containsString(resolvedFiles, resolvedLocalFile) {
+			resolvedFiles = append(resolvedFiles, resolvedLocalFile)
+		}
+	}
 	dcFileMerged, xProperties := c.merge(resolvedFiles)
 	for _, s := range dcFileMerged.Services {
 		err := c.processExtends(s, dcFileMerged)
@@ -408,16 +643,40 @@ func New(files []string) (*CanonicalDockerComposeConfig, error) {
 	if err != nil {
 		return nil, err
 	}
-	// TODO https://github.com/kube-compose/kube-compose/issues/165 resolve named volumes
-	// TODO https://github.com/kube-compose/kube-compose/issues/166 error on duplicate mount points
-	configCanonical := &CanonicalDockerComposeConfig{}
-	configCanonical.Services = map[string]*Service{}
-	for name, s := range dcFileMerged.Services {
-		err = finalizeService(s)
+	err = resolveServiceSecrets(dcFileMerged.Services, dcFileMerged.secrets)
+	if err != nil {
+		return nil, err
+	}
+	err = resolveIPC(dcFileMerged.Services)
+	if err != nil {
+		return nil, err
+	}
+	// Named volumes of version 1 docker compose files are created implicitly, since these files cannot have top-level volumes.
+	if !dcFileMerged.version.Equal(v1) {
+		err = resolveServiceVolumes(dcFileMerged.Services, dcFileMerged.volumes)
 		if err != nil {
 			return nil, err
 		}
-		configCanonical.Services[name] = s.finalService
+	}
+	// TODO https://github.com/kube-compose/kube-compose/issues/166 error on duplicate mount points
+	configCanonical := &CanonicalDockerComposeConfig{
+		Files:   resolvedFiles,
+		Secrets: dcFileMerged.secrets,
+		Volumes: dcFileMerged.volumes,
+	}
+	configCanonical.Services = map[string]*Service{}
+	names := getSortedServiceNames(dcFileMerged.Services)
+	err = util.ForEachConcurrently(len(names), func(i int) error {
+		return finalizeService(dcFileMerged.Services[names[i]])
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		configCanonical.Services[name] = dcFileMerged.Services[name].finalService
+	}
+	if opts.XProperties != nil {
+		xProperties = append(xProperties, opts.XProperties)
 	}
 	configCanonical.XProperties = xProperties
 	return configCanonical, nil
@@ -437,6 +696,8 @@ func (c *configLoader) merge(resolvedFiles []string) (dcFileMerged *dockerCompos
 		for i := len(resolvedFiles) - 1; i >= 0; i-- {
 			dcFile := c.loadResolvedFileCache[resolvedFiles[i]].parsed
 			mergeServices(dcFileMerged.Services, dcFile.Services)
+			dcFileMerged.secrets = mergeSecrets(dcFileMerged.secrets, dcFile.secrets)
+			dcFileMerged.volumes = mergeTopLevelVolumes(dcFileMerged.volumes, dcFile.volumes)
 			if dcFile.xProperties != nil {
 				xProperties = append(xProperties, dcFile.xProperties)
 			}
@@ -452,6 +713,7 @@ func (c *configLoader) merge(resolvedFiles []string) (dcFileMerged *dockerCompos
 }
 
 func finalizeService(s *serviceInternal) error {
+	s.finalService.Build = finalizeBuild(s.Build)
 	if s.Command != nil {
 		s.finalService.Command = s.Command.Values
 	}
@@ -459,6 +721,10 @@ func finalizeService(s *serviceInternal) error {
 		s.finalService.Entrypoint = s.Entrypoint.Values
 	}
 	s.finalService.Environment = s.environmentParsed
+	s.finalService.ExternalLinks = s.externalLinksParsed
+	for _, group := range s.GroupAdd {
+		s.finalService.GroupAdd = append(s.finalService.GroupAdd, group.Value)
+	}
 
 	// Healthchecks are processed after merging.
 	healthcheck, healthcheckDisabled, err := ParseHealthcheck(s.Healthcheck)
@@ -472,6 +738,10 @@ func finalizeService(s *serviceInternal) error {
 		s.finalService.Image = *s.Image
 	}
 	s.finalService.Name = s.name
+	s.finalService.Networks = s.networksParsed
+	if len(s.finalService.Networks) == 0 {
+		s.finalService.Networks = []string{DefaultNetwork}
+	}
 	s.finalService.Ports = s.portsParsed
 	if s.Privileged != nil {
 		s.finalService.Privileged = *s.Privileged
@@ -479,11 +749,47 @@ func finalizeService(s *serviceInternal) error {
 	if s.Restart != nil {
 		s.finalService.Restart = *s.Restart
 	}
+	for _, secret := range s.Secrets {
+		s.finalService.Secrets = append(s.finalService.Secrets, secret.ServiceSecret)
+	}
+	s.finalService.SecurityOptions, err = parseSecurityOpts(s.name, s.SecurityOpt)
+	if err != nil {
+		return err
+	}
 	s.finalService.User = s.User
+	if s.VolumeDriver != nil {
+		s.finalService.VolumeDriver = *s.VolumeDriver
+	}
 	s.finalService.Volumes = s.Volumes
 	if s.WorkingDir != nil {
 		s.finalService.WorkingDir = *s.WorkingDir
 	}
+	s.finalService.XProperties = s.xProperties
+	s.finalService.Watch, err = parseDevelopWatch(s.name, s.Develop)
+	if err != nil {
+		return err
+	}
+	err = finalizeServiceResources(s)
+	if err != nil {
+		return err
+	}
+	return finalizeServiceStopGracePeriod(s)
+}
+
+func finalizeServiceStopGracePeriod(s *serviceInternal) error {
+	if s.StopGracePeriod == nil {
+		return nil
+	}
+	// time.ParseDuration supports a superset of durations compared to docker-compose:
+	// https://docs.docker.com/compose/compose-file/compose-file-v2/#specifying-durations
+	stopGracePeriod, err := time.ParseDuration(*s.StopGracePeriod)
+	if err != nil {
+		return errors.Wrapf(err, "service %s has an invalid stop_grace_period", s.name)
+	}
+	if stopGracePeriod < 0 {
+		return fmt.Errorf("service %s has a negative stop_grace_period", s.name)
+	}
+	s.finalService.StopGracePeriod = &stopGracePeriod
 	return nil
 }
 
@@ -510,6 +816,10 @@ func resolveDependsOn(services map[string]*serviceInternal) error {
 	for _, s1 := range services {
 		s1.finalService = &Service{}
 	}
+	err := addLinksToDependsOn(services)
+	if err != nil {
+		return err
+	}
 	for name1, s1 := range services {
 		if s1.DependsOn != nil {
 			for name2 := range s1.DependsOn.Values {
@@ -519,15 +829,17 @@ func resolveDependsOn(services map[string]*serviceInternal) error {
 				}
 			}
 			s1.finalService.DependsOn = s1.DependsOn.Values
+			s1.finalService.DependsOnRestart = s1.DependsOn.Restart
 		}
 	}
-	for _, s1 := range services {
+	// Services and dependencies are visited in sorted order so that the reported cycle is deterministic.
+	for _, name := range getSortedServiceNames(services) {
 		// Reset the visited marker on each service. This is a precondition of ensureNoDependsOnCycle.
 		for _, s2 := range services {
 			s2.visited = false
 		}
 		// Run the cycle detection algorithm...
-		err := ensureNoDependsOnCycle(s1, services)
+		err = ensureNoDependsOnCycle(services[name], services, nil)
 		if err != nil {
 			return err
 		}
@@ -535,38 +847,64 @@ func resolveDependsOn(services map[string]*serviceInternal) error {
 	return nil
 }
 
+func getSortedServiceNames(services map[string]*serviceInternal) []string {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // https://www.geeksforgeeks.org/detect-cycle-in-a-graph/
-func ensureNoDependsOnCycle(s1 *serviceInternal, services map[string]*serviceInternal) error {
+// path is the list of names of the services on the recursion stack, and is used to report the exact cycle.
+func ensureNoDependsOnCycle(s1 *serviceInternal, services map[string]*serviceInternal, path []string) error {
 	s1.visited = true
 	s1.recStack = true
 	defer s1.clearRecStack()
+	path = append(path, s1.name)
 	if s1.DependsOn != nil {
+		names := make([]string, 0, len(s1.DependsOn.Values))
 		for name := range s1.DependsOn.Values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
 			s2 := services[name]
 			if !s2.visited {
-				err := ensureNoDependsOnCycle(s2, services)
+				err := ensureNoDependsOnCycle(s2, services, path)
 				if err != nil {
 					return err
 				}
 			} else if s2.recStack {
-				return fmt.Errorf("a service %s depends on a service %s, but this means there is a cycle in the depends_on relationship",
-					s1.name, name)
+				return newDependsOnCycleError(path, name)
 			}
 		}
 	}
 	return nil
 }
 
+// newDependsOnCycleError creates an error describing the cycle that is closed by the last service of path depending on name, which
+// must be an element of path.
+func newDependsOnCycleError(path []string, name string) error {
+	i := len(path) - 1
+	for path[i] != name {
+		i--
+	}
+	cycle := append(append([]string{}, path[i:]...), name)
+	return fmt.Errorf("the depends_on relationship has a cycle: %s", strings.Join(cycle, " → "))
+}
+
 // https://github.com/docker/compose/blob/master/compose/config/config_schema_v2.1.json
+// The services are parsed concurrently (see util.ForEachConcurrently).
 func (c *configLoader) parseDockerComposeFile(dcFile *dockerComposeFile) error {
-	for name, s := range dcFile.Services {
-		s.name = name
-		err := c.parseDockerComposeFileService(dcFile, s)
-		if err != nil {
-			return err
-		}
+	names := getSortedServiceNames(dcFile.Services)
+	for _, name := range names {
+		dcFile.Services[name].name = name
 	}
-	return nil
+	return util.ForEachConcurrently(len(names), func(i int) error {
+		return c.parseDockerComposeFileService(dcFile, dcFile.Services[names[i]])
+	})
 }
 
 func (c *configLoader) parseDockerComposeFileService(dcFile *dockerComposeFile, s *serviceInternal) error {
@@ -575,19 +913,50 @@ func (c *configLoader) parseDockerComposeFileService(dcFile *dockerComposeFile,
 	if err != nil {
 		return err
 	}
+	s.externalLinksParsed, err = parseExternalLinks(s.ExternalLinks)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	s.linksParsed, err = parseLinks(s.Links)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	if s.Networks != nil {
+		s.networksParsed = s.Networks.Values
+	}
 	if s.Environment != nil {
 		s.environmentParsed, err = c.parseEnvironment(s.Environment.Values)
 		if err != nil {
 			return err
 		}
 	}
-	// TODO https://github.com/kube-compose/kube-compose/issues/163 only resolve volume paths if volume_driver is not set.
-	for i := 0; i < len(s.Volumes); i++ {
-		resolveBindMountVolumeHostPath(dcFile.resolvedFile, &s.Volumes[i])
+	if s.EnvFile != nil {
+		// Variables set with the environment key take precedence over variables set in env files.
+		envFileParsed, err := c.loadEnvFiles(dcFile.resolvedFile, s.EnvFile.Values)
+		if err != nil {
+			return errors.Wrapf(err, "service %s", s.name)
+		}
+		s.environmentParsed = mergeStringMaps(s.environmentParsed, envFileParsed)
+	}
+	// Like docker compose, volume paths are only resolved if volume_driver is not set, because otherwise host paths are names of volumes
+	// of the volume driver.
+	if s.VolumeDriver == nil || *s.VolumeDriver == "" {
+		for i := 0; i < len(s.Volumes); i++ {
+			resolveBindMountVolumeHostPath(dcFile.resolvedFile, &s.Volumes[i])
+		}
 	}
 	if s.Extends != nil && s.Extends.File != nil {
 		*s.Extends.File = expandPath(dcFile.resolvedFile, *s.Extends.File)
 	}
+	if s.Develop != nil {
+		resolveDevelopWatchPaths(dcFile.resolvedFile, s.Develop)
+	}
+	if s.Build != nil {
+		err = c.parseBuild(dcFile.resolvedFile, s.Build)
+		if err != nil {
+			return errors.Wrapf(err, "service %s has an invalid build section", s.name)
+		}
+	}
 	return nil
 }
 
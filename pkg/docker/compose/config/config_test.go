@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/kube-compose/kube-compose/internal/pkg/fs"
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
@@ -78,6 +79,8 @@ services:
     extends:
       file: '` + testDockerComposeYml[1:] + `'
       service: testservice
+volumes:
+  aa: {}
 `),
 	},
 	testDockerComposeYmlExtendsCycle: {
@@ -311,6 +314,7 @@ func Test_ConfigLoader_LoadFile_Success(t *testing.T) {
 								HasHostPath:   true,
 								HasMode:       true,
 								HostPath:      "aa",
+								NamedVolume:   true,
 								Mode:          "cc",
 							},
 						},
@@ -710,13 +714,50 @@ func Test_New_DependsOnCycle1(t *testing.T) {
 		_, err := New([]string{
 			testDockerComposeYmlDependsOnCycle1,
 		})
-		if err == nil {
-			t.Fail()
-		} else {
-			t.Log(err)
+		if err == nil || err.Error() != "the depends_on relationship has a cycle: service1 → service2 → service1" {
+			t.Error(err)
 		}
 	})
 }
+
+func newTestServiceInternalDependsOn(name string, dependsOnNames ...string) *serviceInternal {
+	s := &serviceInternal{
+		name: name,
+	}
+	if len(dependsOnNames) > 0 {
+		s.DependsOn = &dependsOn{
+			Values: map[string]ServiceHealthiness{},
+		}
+		for _, dependsOnName := range dependsOnNames {
+			s.DependsOn.Values[dependsOnName] = ServiceStarted
+		}
+	}
+	return s
+}
+
+func Test_ResolveDependsOn_CyclePathExcludesTail(t *testing.T) {
+	services := map[string]*serviceInternal{
+		"a": newTestServiceInternalDependsOn("a", "b"),
+		"b": newTestServiceInternalDependsOn("b", "c"),
+		"c": newTestServiceInternalDependsOn("c", "d"),
+		"d": newTestServiceInternalDependsOn("d", "b"),
+	}
+	err := resolveDependsOn(services)
+	if err == nil || err.Error() != "the depends_on relationship has a cycle: b → c → d → b" {
+		t.Error(err)
+	}
+}
+
+func Test_ResolveDependsOn_SelfCycle(t *testing.T) {
+	services := map[string]*serviceInternal{
+		"a": newTestServiceInternalDependsOn("a", "a"),
+	}
+	err := resolveDependsOn(services)
+	if err == nil || err.Error() != "the depends_on relationship has a cycle: a → a" {
+		t.Error(err)
+	}
+}
+
 func Test_New_DependsOnCycle2(t *testing.T) {
 	withMockFS(func() {
 		_, err := New([]string{
@@ -760,6 +801,105 @@ func Test_New_DependsOnSuccess(t *testing.T) {
 	})
 }
 
+func Test_New_StopGracePeriodSuccess(t *testing.T) {
+	file := "/stopgraceperiodsuccess"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    stop_grace_period: 1m30s
+  service2: {}
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else {
+			stopGracePeriod := c.Services["service1"].StopGracePeriod
+			if stopGracePeriod == nil || *stopGracePeriod != 90*time.Second || c.Services["service2"].StopGracePeriod != nil {
+				t.Fail()
+			}
+		}
+	})
+}
+
+func Test_New_ServiceXPropertiesMerged(t *testing.T) {
+	file1 := "/servicexproperties1"
+	file2 := "/servicexproperties2"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file1: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    x-kube-compose:
+      priority_class_name: low
+      runtime_class_name: gvisor
+`),
+		},
+		file2: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    x-kube-compose:
+      priority_class_name: high
+`),
+		},
+	}), func() {
+		c, err := New([]string{file1, file2})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		expected := XProperties{
+			"x-kube-compose": genericMap{
+				"priority_class_name": "high",
+				"runtime_class_name":  "gvisor",
+			},
+		}
+		if !reflect.DeepEqual(c.Services["service1"].XProperties, expected) {
+			t.Error(c.Services["service1"].XProperties)
+		}
+	})
+}
+
+func Test_New_StopGracePeriodInvalid(t *testing.T) {
+	file := "/stopgraceperiodinvalid"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    stop_grace_period: henkie
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_StopGracePeriodNegative(t *testing.T) {
+	file := "/stopgraceperiodnegative"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    stop_grace_period: -1s
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
 func Test_New_InvalidHealthcheckError1(t *testing.T) {
 	withMockFS(func() {
 		_, err := New([]string{
@@ -843,6 +983,7 @@ func Test_New_ExtendsSuccess(t *testing.T) {
 								HasHostPath:   true,
 								HasMode:       true,
 								HostPath:      "aa",
+								NamedVolume:   true,
 								Mode:          "cc",
 							},
 						},
@@ -862,6 +1003,7 @@ func Test_New_ExtendsSuccess(t *testing.T) {
 								HasHostPath:   true,
 								HasMode:       true,
 								HostPath:      "aa",
+								NamedVolume:   true,
 								Mode:          "cc",
 							},
 						},
@@ -878,6 +1020,7 @@ func Test_New_ExtendsSuccess(t *testing.T) {
 								HasHostPath:   true,
 								HasMode:       true,
 								HostPath:      "aa",
+								NamedVolume:   true,
 								Mode:          "cc",
 							},
 						},
@@ -1185,6 +1328,119 @@ func Test_New_OverrideSuccess(t *testing.T) {
 	})
 }
 
+func Test_NewWithLocalFile_MergedLast(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yaml": {
+			Content: []byte(`s:
+  environment:
+    ENV: '1'`),
+		},
+		"/docker-compose.override.yaml": {
+			Content: []byte(`s:
+  environment:
+    ENV: '2'`),
+		},
+		"/docker-compose.local.yml": {
+			Content: []byte(`s:
+  environment:
+    ENV: '3'`),
+		},
+	})
+	withMockFS2(vfs, func() {
+		c, err := NewWithLocalFile(nil, DefaultLocalFile)
+		if err != nil {
+			t.Error(err)
+		} else {
+			assertServiceMapsEqual(t, c.Services, map[string]*Service{
+				"s": {
+					Environment: map[string]string{
+						"ENV": "3",
+					},
+				},
+			})
+		}
+	})
+}
+
+func Test_NewWithLocalFile_RelativeToFirstFile(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project/docker-compose.yaml": {
+			Content: []byte(`s:
+  environment:
+    ENV: '1'`),
+		},
+		"/project/my-local.yml": {
+			Content: []byte(`s:
+  environment:
+    ENV: '2'`),
+		},
+	})
+	withMockFS2(vfs, func() {
+		c, err := NewWithLocalFile([]string{"/project/docker-compose.yaml"}, "my-local.yml")
+		if err != nil {
+			t.Error(err)
+		} else if c.Services["s"].Environment["ENV"] != "2" {
+			t.Fail()
+		}
+	})
+}
+
+func Test_NewWithLocalFile_DoesNotExist(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yaml": {
+			Content: []byte(`s:
+  environment:
+    ENV: '1'`),
+		},
+	})
+	withMockFS2(vfs, func() {
+		c, err := NewWithLocalFile(nil, DefaultLocalFile)
+		if err != nil {
+			t.Error(err)
+		} else if c.Services["s"].Environment["ENV"] != "1" {
+			t.Fail()
+		}
+	})
+}
+
+func Test_NewWithLocalFile_LoadError(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yaml": {
+			Content: []byte(`s: {}`),
+		},
+		"/docker-compose.local.yml": {
+			ReadError: fmt.Errorf("localfileerror"),
+		},
+	})
+	withMockFS2(vfs, func() {
+		_, err := NewWithLocalFile(nil, DefaultLocalFile)
+		if err == nil || !strings.Contains(err.Error(), "localfileerror") {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_SopsDecryptError(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yaml": {
+			Content: []byte(`version: '2.4'
+services:
+  s:
+    environment:
+      PASSWORD: ENC[AES256_GCM,data:invalid,type:str]
+sops:
+  mac: ENC[AES256_GCM,data:invalid,type:str]
+`),
+		},
+	})
+	withMockFS2(vfs, func() {
+		_, err := New(nil)
+		if err == nil || !strings.Contains(err.Error(), "error while decrypting docker compose file") {
+			t.Error(err)
+		}
+	})
+}
+
 func Test_LoadStandardFilesTry_LoadResolvedFileError(t *testing.T) {
 	msg := "tryloadresolvedfileerror"
 	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+)
+
+// WatchAction is the action of a rule of the develop.watch section of a docker compose service.
+type WatchAction string
+
+const (
+	// WatchActionRebuild rebuilds the image of the service when files change.
+	WatchActionRebuild WatchAction = "rebuild"
+	// WatchActionSync copies changed files into the containers of the service.
+	WatchActionSync WatchAction = "sync"
+	// WatchActionSyncRestart copies changed files into the containers of the service, and then restarts the containers.
+	WatchActionSyncRestart WatchAction = "sync+restart"
+)
+
+// WatchRule is a rule of the develop.watch section of a docker compose service.
+// See https://docs.docker.com/compose/file-watch/.
+type WatchRule struct {
+	Action WatchAction
+	// Patterns of paths relative to Path that are ignored.
+	Ignore []string
+	// The absolute path on the host that is watched.
+	Path string
+	// The path in the container that corresponds to Path. Only set if Action is WatchActionSync or WatchActionSyncRestart.
+	Target string
+}
+
+type watchRuleInternal struct {
+	Action string   `mapdecode:"action"`
+	Ignore []string `mapdecode:"ignore"`
+	Path   string   `mapdecode:"path"`
+	Target *string  `mapdecode:"target"`
+}
+
+type develop struct {
+	Watch []watchRuleInternal `mapdecode:"watch"`
+}
+
+func resolveDevelopWatchPaths(resolvedFile string, d *develop) {
+	for i := 0; i < len(d.Watch); i++ {
+		d.Watch[i].Path = expandPath(resolvedFile, d.Watch[i].Path)
+	}
+}
+
+func parseDevelopWatch(name string, d *develop) ([]WatchRule, error) {
+	if d == nil || len(d.Watch) == 0 {
+		return nil, nil
+	}
+	rules := make([]WatchRule, len(d.Watch))
+	for i, ruleInternal := range d.Watch {
+		rule := WatchRule{
+			Action: WatchAction(ruleInternal.Action),
+			Ignore: ruleInternal.Ignore,
+			Path:   ruleInternal.Path,
+		}
+		if ruleInternal.Path == "" {
+			return nil, fmt.Errorf("service %s has a develop.watch rule without a path", name)
+		}
+		switch rule.Action {
+		case WatchActionSync, WatchActionSyncRestart:
+			if ruleInternal.Target == nil || *ruleInternal.Target == "" {
+				return nil, fmt.Errorf("service %s has a develop.watch rule with action %s but without a target", name, rule.Action)
+			}
+			rule.Target = *ruleInternal.Target
+		case WatchActionRebuild:
+		default:
+			return nil, fmt.Errorf("service %s has a develop.watch rule with invalid action %#v: value must be one of %s, %s and %s", name,
+				ruleInternal.Action, WatchActionRebuild, WatchActionSync, WatchActionSyncRestart)
+		}
+		rules[i] = rule
+	}
+	return rules, nil
+}
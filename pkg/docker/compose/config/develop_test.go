@@ -0,0 +1,100 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func Test_New_DevelopWatchSuccess(t *testing.T) {
+	file := "/project/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    develop:
+      watch:
+      - action: sync
+        path: ./src
+        target: /app/src
+        ignore:
+        - node_modules/
+      - action: rebuild
+        path: package.json
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else {
+			expected := []WatchRule{
+				{
+					Action: WatchActionSync,
+					Ignore: []string{"node_modules/"},
+					Path:   "/project/src",
+					Target: "/app/src",
+				},
+				{
+					Action: WatchActionRebuild,
+					Path:   "/project/package.json",
+				},
+			}
+			if !reflect.DeepEqual(c.Services["service1"].Watch, expected) {
+				t.Error(c.Services["service1"].Watch)
+			}
+		}
+	})
+}
+
+func Test_ParseDevelopWatch_Nil(t *testing.T) {
+	rules, err := parseDevelopWatch("service1", nil)
+	if err != nil || rules != nil {
+		t.Fail()
+	}
+}
+
+func Test_ParseDevelopWatch_InvalidAction(t *testing.T) {
+	_, err := parseDevelopWatch("service1", &develop{
+		Watch: []watchRuleInternal{
+			{
+				Action: "copy",
+				Path:   "/src",
+			},
+		},
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_ParseDevelopWatch_MissingTarget(t *testing.T) {
+	_, err := parseDevelopWatch("service1", &develop{
+		Watch: []watchRuleInternal{
+			{
+				Action: string(WatchActionSyncRestart),
+				Path:   "/src",
+			},
+		},
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_ParseDevelopWatch_MissingPath(t *testing.T) {
+	target := "/app"
+	_, err := parseDevelopWatch("service1", &develop{
+		Watch: []watchRuleInternal{
+			{
+				Action: string(WatchActionSync),
+				Target: &target,
+			},
+		},
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/sops"
+	"github.com/pkg/errors"
+)
+
+// parseEnvFile parses a file with lines of the form NAME=VALUE, as referenced by the env_file key of a docker compose service. Empty
+// lines and lines starting with # are ignored. Like docker compose, a line that only consists of a name takes the value of the
+// environment variable with that name, and is ignored if that environment variable is not set. Lines may end with CRLF, because env
+// files are often written on Windows.
+func parseEnvFile(reader io.Reader, environmentGetter ValueGetter) (map[string]string, error) {
+	env := map[string]string{}
+	scanner := bufio.NewScanner(reader)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+		var name, value string
+		i := strings.IndexByte(line, '=')
+		if i < 0 {
+			name = trimmed
+			var ok bool
+			if value, ok = environmentGetter(name); !ok {
+				continue
+			}
+		} else {
+			name = strings.TrimSpace(line[:i])
+			value = line[i+1:]
+		}
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("line %d: invalid environment variable name %#v", lineNumber, name)
+		}
+		env[name] = value
+	}
+	return env, scanner.Err()
+}
+
+type envFileCacheItem struct {
+	env  map[string]string
+	err  error
+	once sync.Once
+}
+
+type envFileCache struct {
+	items map[string]*envFileCacheItem
+	mutex sync.Mutex
+}
+
+// get returns the cache item of an env file, creating it if it does not exist.
+func (cache *envFileCache) get(envFile string) *envFileCacheItem {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.items == nil {
+		cache.items = map[string]*envFileCacheItem{}
+	}
+	item := cache.items[envFile]
+	if item == nil {
+		item = &envFileCacheItem{}
+		cache.items[envFile] = item
+	}
+	return item
+}
+
+// readEnvFile reads an env file, and decrypts it in memory if it is encrypted with SOPS.
+func readEnvFile(file string) ([]byte, error) {
+	data, err := readFile(file)
+	if err != nil || !sops.IsEncryptedDotenv(data) {
+		return data, err
+	}
+	data, err = sops.Decrypt(data, sops.FormatDotenv)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while decrypting env file %#v", file)
+	}
+	return data, nil
+}
+
+func (c *configLoader) loadEnvFile(envFile string) (map[string]string, error) {
+	data, err := readEnvFile(envFile)
+	if err != nil {
+		return nil, err
+	}
+	env, err := parseEnvFile(bytes.NewReader(data), c.environmentGetter)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while parsing env file %#v", envFile)
+	}
+	return env, nil
+}
+
+// DotEnvFile is the name of the file in the project directory whose variables are used to interpolate docker compose files, like docker
+// compose does. The project directory is the directory of the first docker compose file.
+const DotEnvFile = ".env"
+
+// loadDotEnvFile loads the .env file of the project directory if it exists, so that its variables are used wherever the loader reads the
+// environment (e.g. for interpolation, and for environment variables without a value). Variables of the environment take precedence over
+// variables of the .env file.
+func (c *configLoader) loadDotEnvFile(projectDir string) error {
+	file := filepath.Join(projectDir, DotEnvFile)
+	data, err := readEnvFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	env, err := parseEnvFile(bytes.NewReader(data), c.environmentGetter)
+	if err != nil {
+		return errors.Wrapf(err, "error while parsing env file %#v", file)
+	}
+	environmentGetter := c.environmentGetter
+	c.environmentGetter = func(name string) (string, bool) {
+		if value, ok := environmentGetter(name); ok {
+			return value, true
+		}
+		value, ok := env[name]
+		return value, ok
+	}
+	return nil
+}
+
+// loadEnvFiles loads the env files of a docker compose service. Later env files take precedence over earlier env files. Each env file is
+// only loaded once, even if it is shared by several services.
+func (c *configLoader) loadEnvFiles(resolvedFile string, envFiles []string) (map[string]string, error) {
+	env := map[string]string{}
+	for _, envFile := range envFiles {
+		envFile = expandPath(resolvedFile, envFile)
+		item := c.envFileCache.get(envFile)
+		item.once.Do(func() {
+			item.env, item.err = c.loadEnvFile(envFile)
+		})
+		if item.err != nil {
+			return nil, item.err
+		}
+		// The cached map is copied, because mergeStringMaps modifies its first argument.
+		envFileParsed := make(map[string]string, len(item.env))
+		for name, value := range item.env {
+			envFileParsed[name] = value
+		}
+		env = mergeStringMaps(envFileParsed, env)
+	}
+	return env, nil
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func TestParseEnvFile_CRLF(t *testing.T) {
+	reader := strings.NewReader("# comment\r\nA=1\r\n\r\nB = two words\r\nC\r\nD\r\n")
+	env, err := parseEnvFile(reader, mapValueGetter(map[string]string{
+		"C": "3",
+	}))
+	if err != nil {
+		t.Error(err)
+	}
+	expected := map[string]string{
+		"A": "1",
+		"B": " two words",
+		"C": "3",
+	}
+	if !reflect.DeepEqual(env, expected) {
+		t.Error(env)
+	}
+}
+
+func TestParseEnvFile_InvalidName(t *testing.T) {
+	_, err := parseEnvFile(strings.NewReader("=1\n"), mapValueGetter(nil))
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_New_EnvFileSuccess(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project/docker-compose.yml": {
+			Content: []byte("version: '2.4'\r\nservices:\r\n  service1:\r\n    env_file: ['.\\config\\a.env', b.env]\r\n" +
+				"    environment:\r\n      A: from-environment\r\n"),
+		},
+		"/project/config/a.env": {
+			Content: []byte("A=from-a\r\nB=from-a\r\nC=from-a\r\n"),
+		},
+		"/project/b.env": {
+			Content: []byte("B=from-b\r\n"),
+		},
+	}), func() {
+		c, err := New([]string{"/project/docker-compose.yml"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		expected := map[string]string{
+			"A": "from-environment",
+			"B": "from-b",
+			"C": "from-a",
+		}
+		if env := c.Services["service1"].Environment; !reflect.DeepEqual(env, expected) {
+			t.Error(env)
+		}
+	})
+}
+
+func Test_New_EnvFileNotFound(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project/docker-compose.yml": {
+			Content: []byte("version: '2.4'\nservices:\n  service1:\n    env_file: missing.env\n"),
+		},
+	}), func() {
+		_, err := New([]string{"/project/docker-compose.yml"})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+// testEncryptedEnvFile is an env file with SOPS metadata whose values cannot be decrypted.
+const testEncryptedEnvFile = "PASSWORD=ENC[AES256_GCM,data:invalid,type:str]\nsops_mac=ENC[AES256_GCM,data:invalid,type:str]\n"
+
+func Test_New_EnvFileSopsDecryptError(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project/docker-compose.yml": {
+			Content: []byte("version: '2.4'\nservices:\n  service1:\n    env_file: secrets.env\n"),
+		},
+		"/project/secrets.env": {
+			Content: []byte(testEncryptedEnvFile),
+		},
+	}), func() {
+		_, err := New([]string{"/project/docker-compose.yml"})
+		if err == nil || !strings.Contains(err.Error(), "error while decrypting env file") {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_New_EnvFileSharedByServices(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project/docker-compose.yml": {
+			Content: []byte("version: '2.4'\nservices:\n  service1:\n    env_file: common.env\n    environment:\n      A: from-service1\n" +
+				"  service2:\n    env_file: common.env\n"),
+		},
+		"/project/common.env": {
+			Content: []byte("A=from-common\nB=from-common\n"),
+		},
+	}), func() {
+		c, err := New([]string{"/project/docker-compose.yml"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		// The environment of service1 must not leak into the cached env file of service2.
+		expected1 := map[string]string{
+			"A": "from-service1",
+			"B": "from-common",
+		}
+		expected2 := map[string]string{
+			"A": "from-common",
+			"B": "from-common",
+		}
+		if env := c.Services["service1"].Environment; !reflect.DeepEqual(env, expected1) {
+			t.Error(env)
+		}
+		if env := c.Services["service2"].Environment; !reflect.DeepEqual(env, expected2) {
+			t.Error(env)
+		}
+	})
+}
+
+func Test_ConfigLoader_LoadDotEnvFile_EnvironmentTakesPrecedence(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project/.env": {
+			Content: []byte("TAG=dotenv\nREGISTRY=registry.example.com\n"),
+		},
+	}), func() {
+		c := newTestConfigLoader(map[string]string{
+			"TAG": "environment",
+		})
+		err := c.loadDotEnvFile("/project")
+		if err != nil {
+			t.Error(err)
+		}
+		tag, _ := c.environmentGetter("TAG")
+		registry, _ := c.environmentGetter("REGISTRY")
+		_, ok := c.environmentGetter("UNSET")
+		if tag != "environment" || registry != "registry.example.com" || ok {
+			t.Error(tag, registry, ok)
+		}
+	})
+}
+
+func Test_ConfigLoader_LoadDotEnvFile_NotFound(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{}), func() {
+		c := newTestConfigLoader(nil)
+		err := c.loadDotEnvFile("/project")
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_ConfigLoader_LoadDotEnvFile_Invalid(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project/.env": {
+			Content: []byte("INVALID NAME=1\n"),
+		},
+	}), func() {
+		c := newTestConfigLoader(nil)
+		err := c.loadDotEnvFile("/project")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_ConfigLoader_LoadDotEnvFile_SopsDecryptError(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project/.env": {
+			Content: []byte(testEncryptedEnvFile),
+		},
+	}), func() {
+		c := newTestConfigLoader(nil)
+		err := c.loadDotEnvFile("/project")
+		if err == nil || !strings.Contains(err.Error(), "error while decrypting env file") {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_New_DotEnvFileStandardFiles(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project/.env": {
+			Content: []byte("KUBE_COMPOSE_DOT_ENV_TEST_TAG=1.17\n"),
+		},
+		"/project/docker-compose.yml": {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    image: nginx:${KUBE_COMPOSE_DOT_ENV_TEST_TAG:-latest}
+    environment:
+    - KUBE_COMPOSE_DOT_ENV_TEST_TAG
+`),
+		},
+	})
+	err := vfs.Chdir("/project")
+	if err != nil {
+		t.Error(err)
+	}
+	withMockFS2(vfs, func() {
+		c, err := New(nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		service1 := c.Services["service1"]
+		if service1.Image != "nginx:1.17" || service1.Environment["KUBE_COMPOSE_DOT_ENV_TEST_TAG"] != "1.17" {
+			t.Error(service1.Image, service1.Environment)
+		}
+	})
+}
+
+func Test_New_DotEnvFileOfFirstFile(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project/.env": {
+			Content: []byte("KUBE_COMPOSE_DOT_ENV_TEST_TAG=1.17\n"),
+		},
+		"/project/docker-compose.yml": {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    image: nginx:$KUBE_COMPOSE_DOT_ENV_TEST_TAG
+`),
+		},
+	}), func() {
+		c, err := New([]string{"/project/docker-compose.yml"})
+		if err != nil || c.Services["service1"].Image != "nginx:1.17" {
+			t.Error(c, err)
+		}
+	})
+}
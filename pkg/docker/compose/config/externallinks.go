@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExternalLink is an entry of the external_links of a docker compose service, which links to a container that is not part of the
+// docker compose configuration. See https://docs.docker.com/compose/compose-file/compose-file-v2/#external_links.
+type ExternalLink struct {
+	// The host name by which the service refers to the linked container.
+	Alias string
+	// The name of the linked container.
+	Target string
+}
+
+// parseExternalLinks parses external links of the form TARGET or TARGET:ALIAS. Like docker compose, the alias defaults to the target.
+func parseExternalLinks(values []string) ([]ExternalLink, error) {
+	return parseLinkValues("external link", values)
+}
+
+// parseLinkValues parses links or external links (depending on kind) of the form TARGET or TARGET:ALIAS.
+func parseLinkValues(kind string, values []string) ([]ExternalLink, error) {
+	var externalLinks []ExternalLink
+	for _, value := range values {
+		parts := strings.Split(value, ":")
+		if len(parts) > 2 || parts[0] == "" || parts[len(parts)-1] == "" {
+			return nil, fmt.Errorf("invalid %s %#v", kind, value)
+		}
+		externalLinks = append(externalLinks, ExternalLink{
+			Alias:  parts[len(parts)-1],
+			Target: parts[0],
+		})
+	}
+	return externalLinks, nil
+}
+
+func addExternalLink(externalLinks []ExternalLink, externalLink1 ExternalLink) []ExternalLink {
+	for _, externalLink2 := range externalLinks {
+		if externalLink1.Alias == externalLink2.Alias {
+			return externalLinks
+		}
+	}
+	return append(externalLinks, externalLink1)
+}
+
+// mergeExternalLinks merges the external links of docker compose services. External links in into take precedence over external links
+// in from with the same alias.
+func mergeExternalLinks(into, from []ExternalLink) []ExternalLink {
+	if len(into) == 0 {
+		return from
+	}
+	for _, externalLink := range from {
+		into = addExternalLink(into, externalLink)
+	}
+	return into
+}
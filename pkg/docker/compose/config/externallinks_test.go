@@ -0,0 +1,62 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExternalLinks_Success(t *testing.T) {
+	externalLinks, err := parseExternalLinks([]string{"redis_1", "project_db_1:mysql"})
+	if err != nil {
+		t.Error(err)
+	}
+	expected := []ExternalLink{
+		{
+			Alias:  "redis_1",
+			Target: "redis_1",
+		},
+		{
+			Alias:  "mysql",
+			Target: "project_db_1",
+		},
+	}
+	if !reflect.DeepEqual(externalLinks, expected) {
+		t.Error(externalLinks)
+	}
+}
+
+func TestParseExternalLinks_Invalid(t *testing.T) {
+	for _, value := range []string{"", ":mysql", "db:", "a:b:c"} {
+		_, err := parseExternalLinks([]string{value})
+		if err == nil {
+			t.Error(value)
+		}
+	}
+}
+
+func TestMergeExternalLinks_Duplicate(t *testing.T) {
+	into := []ExternalLink{
+		{
+			Alias:  "mysql",
+			Target: "db1",
+		},
+	}
+	from := []ExternalLink{
+		{
+			Alias:  "mysql",
+			Target: "db2",
+		},
+		{
+			Alias:  "redis",
+			Target: "redis",
+		},
+	}
+	merged := mergeExternalLinks(into, from)
+	expected := []ExternalLink{
+		into[0],
+		from[1],
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Error(merged)
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/uber-go/mapdecode"
+)
+
+// group is a helper type used to decode an element of group_add, which is either the name of a group or a GID.
+type group struct {
+	Value string
+}
+
+func (g *group) Decode(into mapdecode.Into) error {
+	err := into(&g.Value)
+	if err != nil {
+		var gid int64
+		if into(&gid) != nil {
+			return err
+		}
+		g.Value = strconv.FormatInt(gid, 10)
+	}
+	if g.Value == "" {
+		return fmt.Errorf("group_add has an empty group")
+	}
+	return nil
+}
+
+// mergeGroupAdd returns the union of the groups of two services, without duplicates.
+func mergeGroupAdd(into, from []group) []group {
+	for _, group1 := range from {
+		found := false
+		for _, group2 := range into {
+			if group1 == group2 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			into = append(into, group1)
+		}
+	}
+	return into
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func TestMergeGroupAdd(t *testing.T) {
+	merged := mergeGroupAdd([]group{{Value: "audio"}}, []group{{Value: "1000"}, {Value: "audio"}})
+	expected := []group{{Value: "audio"}, {Value: "1000"}}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Error(merged)
+	}
+}
+
+func Test_New_GroupAdd(t *testing.T) {
+	file := "/groupadd"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    group_add:
+    - video
+    - 1000
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		groupAdd := c.Services["service1"].GroupAdd
+		if !reflect.DeepEqual(groupAdd, []string{"video", "1000"}) {
+			t.Error(groupAdd)
+		}
+	})
+}
+
+func Test_New_GroupAddInvalid(t *testing.T) {
+	file := "/groupaddinvalid"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    group_add:
+    - ''
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
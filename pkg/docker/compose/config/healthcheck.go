@@ -21,8 +21,10 @@ type Healthcheck struct {
 	IsShell     bool
 	StartPeriod time.Duration
 	Retries     uint
-	Test        []string
-	Timeout     time.Duration
+	// Test is nil if the docker compose healthcheck does not set test. Like docker, such a healthcheck inherits the test of the image's
+	// healthcheck, and Interval, Timeout, Retries and StartPeriod are zero unless they are set so that they can be inherited too.
+	Test    []string
+	Timeout time.Duration
 }
 
 func ParseHealthcheck(i *healthcheckInternal) (*Healthcheck, bool, error) {
@@ -33,6 +35,9 @@ func ParseHealthcheck(i *healthcheckInternal) (*Healthcheck, bool, error) {
 		return nil, true, nil
 	}
 	healthcheck := &Healthcheck{}
+	if i.GetTest() == nil {
+		return parseHealthcheckWithoutTest(i)
+	}
 	err := healthcheck.parseTest(i.GetTest())
 	if err != nil {
 		if err == errorCommandIsNone {
@@ -49,6 +54,36 @@ func ParseHealthcheck(i *healthcheckInternal) (*Healthcheck, bool, error) {
 		return nil, false, err
 	}
 	healthcheck.parseRetries(i.Retries)
+	err = healthcheck.parseStartPeriod(i.StartPeriod)
+	if err != nil {
+		return nil, false, err
+	}
+	return healthcheck, false, nil
+}
+
+// parseHealthcheckWithoutTest parses a healthcheck that overrides fields of the image's healthcheck. Fields that are not set are left
+// zero, instead of being set to their default values.
+func parseHealthcheckWithoutTest(i *healthcheckInternal) (*Healthcheck, bool, error) {
+	healthcheck := &Healthcheck{}
+	if i.Interval != nil {
+		err := healthcheck.parseInterval(i.Interval)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	if i.Timeout != nil {
+		err := healthcheck.parseTimeout(i.Timeout)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	if i.Retries != nil {
+		healthcheck.Retries = *i.Retries
+	}
+	err := healthcheck.parseStartPeriod(i.StartPeriod)
+	if err != nil {
+		return nil, false, err
+	}
 	return healthcheck, false, nil
 }
 
@@ -91,8 +126,6 @@ func (healthcheck *Healthcheck) parseTest(test []string) error {
 }
 
 func (healthcheck *Healthcheck) parseInterval(value *string) error {
-	// We do not set StartPeriod because it is unsupported in docker-compose 2.1 and
-	// should therefore be treated as 0.
 	// time.ParseDuration supports a superset of durations compared to docker-compose:
 	// https://golang.org/pkg/time/#Duration
 	// https://docs.docker.com/compose/compose-file/compose-file-v2/#specifying-durations
@@ -111,6 +144,21 @@ func (healthcheck *Healthcheck) parseInterval(value *string) error {
 	return nil
 }
 
+// parseStartPeriod parses the start period of a healthcheck, which defaults to zero like in docker.
+func (healthcheck *Healthcheck) parseStartPeriod(value *string) error {
+	if value != nil {
+		startPeriod, err := time.ParseDuration(*value)
+		if err != nil {
+			return err
+		}
+		if startPeriod < 0 {
+			return fmt.Errorf("field \"start_period\" of Healthcheck must not be negative")
+		}
+		healthcheck.StartPeriod = startPeriod
+	}
+	return nil
+}
+
 func (healthcheck *Healthcheck) parseRetries(value *uint) {
 	if value != nil {
 		healthcheck.Retries = *value
@@ -98,6 +98,33 @@ func TestParseTimeout_Default(t *testing.T) {
 	}
 }
 
+func TestParseStartPeriod_Normal(t *testing.T) {
+	h := &Healthcheck{}
+	err := h.parseStartPeriod(util.NewString("40s"))
+	if err != nil {
+		t.Error(err)
+	}
+	if h.StartPeriod != 40*time.Second {
+		t.Fail()
+	}
+}
+
+func TestParseStartPeriod_NegativeDuration(t *testing.T) {
+	h := &Healthcheck{}
+	err := h.parseStartPeriod(util.NewString("-1s"))
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestParseStartPeriod_Default(t *testing.T) {
+	h := &Healthcheck{}
+	err := h.parseStartPeriod(nil)
+	if err != nil || h.StartPeriod != 0 {
+		t.Error(err, h.StartPeriod)
+	}
+}
+
 func TestParseTest_EmptySlice(t *testing.T) {
 	h := &Healthcheck{}
 	err := h.parseTest([]string{})
@@ -172,6 +199,19 @@ func TestParseHealthcheck_Disabled(t *testing.T) {
 		t.Fail()
 	}
 }
+func TestParseHealthcheck_NotDisabled(t *testing.T) {
+	healthcheckYAML := &healthcheckInternal{
+		Disable: util.NewBool(false),
+	}
+	healthcheck, isDisabled, err := ParseHealthcheck(healthcheckYAML)
+	if err != nil {
+		t.Error(err)
+	}
+	if isDisabled || healthcheck != nil {
+		t.Fail()
+	}
+}
+
 func TestParseHealthcheck_Nil(t *testing.T) {
 	healthcheck, isDisabled, err := ParseHealthcheck(nil)
 	if err != nil {
@@ -256,3 +296,53 @@ func TestParseHealthcheck_Success(t *testing.T) {
 		t.Errorf("%+v\n", *healthcheck)
 	}
 }
+
+func TestParseHealthcheck_WithoutTest(t *testing.T) {
+	healthcheckYAML := &healthcheckInternal{
+		Interval: util.NewString("5s"),
+	}
+	healthcheck, isDisabled, err := ParseHealthcheck(healthcheckYAML)
+	if err != nil {
+		t.Error(err)
+	}
+	if isDisabled || !reflect.DeepEqual(healthcheck, &Healthcheck{
+		Interval: 5 * time.Second,
+	}) {
+		t.Error(healthcheck)
+	}
+}
+
+func TestParseHealthcheck_WithoutTestInvalidTimeout(t *testing.T) {
+	healthcheckYAML := &healthcheckInternal{
+		Timeout: util.NewString("asdf7"),
+	}
+	_, _, err := ParseHealthcheck(healthcheckYAML)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestParseHealthcheck_StartPeriod(t *testing.T) {
+	healthcheckYAML := &healthcheckInternal{
+		Test: HealthcheckTest{
+			Values: []string{HealthcheckCommandCmd, "true"},
+		},
+		StartPeriod: util.NewString("1m"),
+	}
+	healthcheck, _, err := ParseHealthcheck(healthcheckYAML)
+	if err != nil || healthcheck.StartPeriod != time.Minute {
+		t.Error(healthcheck, err)
+	}
+}
+
+func TestParseHealthcheck_WithoutTestStartPeriod(t *testing.T) {
+	healthcheckYAML := &healthcheckInternal{
+		StartPeriod: util.NewString("30s"),
+	}
+	healthcheck, isDisabled, err := ParseHealthcheck(healthcheckYAML)
+	if err != nil || isDisabled || !reflect.DeepEqual(healthcheck, &Healthcheck{
+		StartPeriod: 30 * time.Second,
+	}) {
+		t.Error(healthcheck, err)
+	}
+}
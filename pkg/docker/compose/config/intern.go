@@ -0,0 +1,50 @@
+package config
+
+// stringInterner deduplicates equal strings. Large docker compose files repeat the same keys (e.g. "image" and "environment") and
+// values (e.g. images and environment variables shared by services) many times, and the YAML decoder allocates each occurrence
+// separately. Interning them reduces the memory that the loaded configuration retains once the decoded files are garbage collected. It
+// does not reduce the peak memory of loading, because maps are copied while they are interned. The zero value is an empty interner.
+type stringInterner struct {
+	strings map[string]string
+}
+
+func (si *stringInterner) intern(s string) string {
+	if interned, ok := si.strings[s]; ok {
+		return interned
+	}
+	if si.strings == nil {
+		si.strings = map[string]string{}
+	}
+	si.strings[s] = s
+	return s
+}
+
+// internValue interns the strings of a decoded YAML value. Maps are copied, because their keys cannot be replaced in place. Slices are
+// updated in place.
+func (si *stringInterner) internValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return si.intern(t)
+	case genericMap:
+		return si.internMap(t)
+	case map[interface{}]interface{}:
+		return map[interface{}]interface{}(si.internMap(t))
+	case []interface{}:
+		for i, item := range t {
+			t[i] = si.internValue(item)
+		}
+		return t
+	}
+	return v
+}
+
+func (si *stringInterner) internMap(m genericMap) genericMap {
+	if m == nil {
+		return nil
+	}
+	interned := make(genericMap, len(m))
+	for key, value := range m {
+		interned[si.internValue(key)] = si.internValue(value)
+	}
+	return interned
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestStringInterner_Intern(t *testing.T) {
+	var si stringInterner
+	// strings.Repeat allocates, so s1 and s2 are equal strings with different data.
+	s1 := strings.Repeat("a", 10)
+	s2 := strings.Repeat("a", 10)
+	if si.intern(s1) != s1 || stringData(si.intern(s2)) != stringData(s1) {
+		t.Fail()
+	}
+}
+
+func TestStringInterner_InternMap(t *testing.T) {
+	var si stringInterner
+	m := genericMap{
+		"services": map[interface{}]interface{}{
+			"a": map[interface{}]interface{}{
+				"image": "postgres",
+				"ports": []interface{}{"8080:80", 443},
+			},
+			"b": map[interface{}]interface{}{
+				"image": strings.Repeat("postgres", 1),
+			},
+		},
+	}
+	expected := genericMap{
+		"services": map[interface{}]interface{}{
+			"a": map[interface{}]interface{}{
+				"image": "postgres",
+				"ports": []interface{}{"8080:80", 443},
+			},
+			"b": map[interface{}]interface{}{
+				"image": "postgres",
+			},
+		},
+	}
+	interned := si.internMap(m)
+	if !reflect.DeepEqual(interned, expected) {
+		t.Error(interned)
+	}
+	if len(si.strings) != 7 {
+		t.Error(si.strings)
+	}
+}
+
+func TestStringInterner_InternMapNil(t *testing.T) {
+	var si stringInterner
+	if si.internMap(nil) != nil {
+		t.Fail()
+	}
+}
@@ -3,9 +3,11 @@ package config
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	version "github.com/hashicorp/go-version"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
 )
 
 type ValueGetter func(name string) (string, bool)
@@ -44,7 +46,8 @@ func (p path) pop() path {
 
 func (c *configInterpolator) run() error {
 	if !c.version.GreaterThan(v1) {
-		c.interpolateSection(c.config, path{})
+		// Version 1 docker compose files consist of services only.
+		c.interpolateServices(c.config, path{})
 	} else {
 		c.interpolateSectionByName("services")
 		if !c.version.LessThan(v3_1) {
@@ -66,9 +69,41 @@ func (c *configInterpolator) run() error {
 func (c *configInterpolator) interpolateSectionByName(name string) {
 	if sectionRaw, ok := c.config[name]; ok {
 		if section, ok := sectionRaw.(genericMap); ok {
-			c.interpolateSection(section, (path{}).appendStr(name))
+			if name == "services" {
+				c.interpolateServices(section, (path{}).appendStr(name))
+			} else {
+				c.interpolateSection(section, (path{}).appendStr(name))
+			}
+		}
+	}
+}
+
+// interpolateServices is like interpolateSection, but interpolates the services of a docker compose file concurrently (see
+// util.ForEachConcurrently), because files of large projects have many services. Errors are reported in the order of the names of services.
+func (c *configInterpolator) interpolateServices(services genericMap, p path) {
+	var names []string
+	for nameRaw := range services {
+		if name, ok := nameRaw.(string); ok {
+			names = append(names, name)
 		}
 	}
+	sort.Strings(names)
+	values := make([]interface{}, len(names))
+	errorLists := make([][]error, len(names))
+	_ = util.ForEachConcurrently(len(names), func(i int) error {
+		c2 := &configInterpolator{
+			valueGetter: c.valueGetter,
+			version:     c.version,
+		}
+		// Copy the path so that concurrent appends do not share a backing array.
+		values[i] = c2.interpolateRecursive(services[names[i]], append(path{}, p...).appendStr(names[i]))
+		errorLists[i] = c2.errorList
+		return nil
+	})
+	for i, name := range names {
+		services[name] = values[i]
+		c.errorList = append(c.errorList, errorLists[i]...)
+	}
 }
 
 func (c *configInterpolator) interpolateSection(configDict genericMap, p path) {
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// IPCModeHost is the IPC mode of services that use the IPC namespace of the host.
+	IPCModeHost = "host"
+	// IPCModeServicePrefix is the prefix of the IPC mode of services that use the IPC namespace of another service.
+	IPCModeServicePrefix = "service:"
+	// IPCModeContainerPrefix is the prefix of the IPC mode of services that use the IPC namespace of a container.
+	IPCModeContainerPrefix = "container:"
+)
+
+// resolveIPC validates the IPC modes of docker compose services (ipc), including that services whose IPC mode is "service:<name>" refer
+// to another existing service.
+func resolveIPC(services map[string]*serviceInternal) error {
+	for name, s := range services {
+		if s.IPC == nil {
+			continue
+		}
+		ipc := *s.IPC
+		switch {
+		case ipc == "", ipc == IPCModeHost, ipc == "none", ipc == "private", ipc == "shareable":
+		case strings.HasPrefix(ipc, IPCModeServicePrefix):
+			name2 := ipc[len(IPCModeServicePrefix):]
+			if name2 == name || services[name2] == nil {
+				return fmt.Errorf("service %s refers to a non-existing service in its ipc: %s", name, name2)
+			}
+		case strings.HasPrefix(ipc, IPCModeContainerPrefix) && len(ipc) > len(IPCModeContainerPrefix):
+		default:
+			return fmt.Errorf("service %s has an invalid ipc %#v", name, ipc)
+		}
+		s.finalService.IPC = ipc
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func Test_New_IPC(t *testing.T) {
+	file := "/ipc"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    ipc: host
+  service2:
+    ipc: 'service:service1'
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if c.Services["service1"].IPC != IPCModeHost || c.Services["service2"].IPC != "service:service1" {
+			t.Error(c.Services["service1"].IPC, c.Services["service2"].IPC)
+		}
+	})
+}
+
+func Test_New_IPCInvalid(t *testing.T) {
+	testCases := []string{
+		"ipc: 'service:service2'",
+		"ipc: 'service:service1'",
+		"ipc: bogus",
+	}
+	for _, testCase := range testCases {
+		file := "/ipcinvalid"
+		withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+			file: {
+				Content: []byte(`version: '2.4'
+services:
+  service1:
+    ` + testCase + `
+`),
+			},
+		}), func() {
+			_, err := New([]string{file})
+			if err == nil {
+				t.Error(testCase)
+			}
+		})
+	}
+}
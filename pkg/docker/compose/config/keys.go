@@ -0,0 +1,32 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+)
+
+// getMapdecodeKeys returns the sorted keys of the mapdecode tags of the fields of a struct type.
+func getMapdecodeKeys(t reflect.Type) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		if key := t.Field(i).Tag.Get("mapdecode"); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FileKeys returns the sorted keys at the root of docker compose files that are interpreted when loading. Other keys (except extension
+// fields) are ignored.
+func FileKeys() []string {
+	keys := append(getMapdecodeKeys(reflect.TypeOf(dockerComposeFile{})), "version")
+	sort.Strings(keys)
+	return keys
+}
+
+// ServiceKeys returns the sorted keys of docker compose services that are interpreted when loading. Other keys (except extension fields)
+// are ignored.
+func ServiceKeys() []string {
+	return getMapdecodeKeys(reflect.TypeOf(serviceInternal{}))
+}
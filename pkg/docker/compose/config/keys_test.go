@@ -0,0 +1,20 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFileKeys(t *testing.T) {
+	keys := FileKeys()
+	if !reflect.DeepEqual(keys, []string{"secrets", "services", "version", "volumes"}) {
+		t.Error(keys)
+	}
+}
+
+func TestServiceKeys(t *testing.T) {
+	keys := ServiceKeys()
+	if len(keys) == 0 || keys[0] != "build" || keys[len(keys)-1] != "working_dir" {
+		t.Error(keys)
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+)
+
+// parseLinks parses the links of a docker compose service, which have the same form as external links but whose targets are the names
+// of services. See https://docs.docker.com/compose/compose-file/compose-file-v2/#links.
+func parseLinks(values []string) ([]ExternalLink, error) {
+	return parseLinkValues("link", values)
+}
+
+// addLinksToDependsOn adds the targets of the links of each service to its depends_on with condition service_started, unless the
+// service already depends on them. Like docker compose, links imply dependencies, so they are ordered and checked for cycles like
+// depends_on. The aliases of links are not resolvable by pods, because pods can only resolve the names of services.
+func addLinksToDependsOn(services map[string]*serviceInternal) error {
+	for name1, s1 := range services {
+		for _, link := range s1.linksParsed {
+			if services[link.Target] == nil {
+				return fmt.Errorf("service %s refers to a non-existing service in its links: %s", name1, link.Target)
+			}
+			if s1.DependsOn == nil {
+				s1.DependsOn = &dependsOn{
+					Values: map[string]ServiceHealthiness{},
+				}
+			}
+			if _, ok := s1.DependsOn.Values[link.Target]; !ok {
+				s1.DependsOn.Values[link.Target] = ServiceStarted
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func TestAddLinksToDependsOn_Success(t *testing.T) {
+	services := map[string]*serviceInternal{
+		"db": newTestServiceInternalDependsOn("db"),
+		"web": {
+			DependsOn: &dependsOn{
+				Values: map[string]ServiceHealthiness{
+					"db": ServiceHealthy,
+				},
+			},
+			linksParsed: []ExternalLink{
+				{
+					Alias:  "database",
+					Target: "db",
+				},
+			},
+			name: "web",
+		},
+		"worker": {
+			linksParsed: []ExternalLink{
+				{
+					Alias:  "db",
+					Target: "db",
+				},
+			},
+			name: "worker",
+		},
+	}
+	err := addLinksToDependsOn(services)
+	if err != nil {
+		t.Error(err)
+	}
+	// A link does not override the condition of a depends_on of the same service.
+	if services["web"].DependsOn.Values["db"] != ServiceHealthy || services["worker"].DependsOn.Values["db"] != ServiceStarted {
+		t.Fail()
+	}
+}
+
+func TestAddLinksToDependsOn_NotFound(t *testing.T) {
+	services := map[string]*serviceInternal{
+		"web": {
+			linksParsed: []ExternalLink{
+				{
+					Alias:  "db",
+					Target: "db",
+				},
+			},
+			name: "web",
+		},
+	}
+	err := addLinksToDependsOn(services)
+	if err == nil || err.Error() != "service web refers to a non-existing service in its links: db" {
+		t.Error(err)
+	}
+}
+
+func Test_New_LinksCycle(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yml": {
+			Content: []byte(`version: '2.4'
+services:
+  db:
+    depends_on:
+    - web
+  web:
+    links:
+    - db:database
+`),
+		},
+	}), func() {
+		_, err := New([]string{"/docker-compose.yml"})
+		if err == nil || err.Error() != "the depends_on relationship has a cycle: db → web → db" {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_New_LinksInvalid(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yml": {
+			Content: []byte(`version: '2.4'
+services:
+  web:
+    links:
+    - 'db:'
+`),
+		},
+	}), func() {
+		_, err := New([]string{"/docker-compose.yml"})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
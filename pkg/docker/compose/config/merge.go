@@ -22,13 +22,40 @@ func addVolume(volumes []ServiceVolume, volume1 ServiceVolume) []ServiceVolume {
 
 func merge(into, from *serviceInternal, mergeExtends bool) {
 	// Rules here are based on https://docs.docker.com/compose/extends/#adding-and-overriding-configuration
+	if into.Build == nil {
+		into.Build = from.Build
+	}
+	if into.CgroupParent == nil {
+		into.CgroupParent = from.CgroupParent
+	}
 	if into.Command == nil {
 		into.Command = from.Command
 	}
+	if into.CPUs == nil {
+		into.CPUs = from.CPUs
+	}
+	if into.CPUSet == nil {
+		into.CPUSet = from.CPUSet
+	}
+	if into.CPUShares == nil {
+		into.CPUShares = from.CPUShares
+	}
 	into.DependsOn = mergeDependsOnMaps(into.DependsOn, from.DependsOn)
+	if into.Deploy == nil {
+		into.Deploy = from.Deploy
+	}
+	if into.Develop == nil {
+		into.Develop = from.Develop
+	}
 	into.environmentParsed = mergeStringMaps(into.environmentParsed, from.environmentParsed)
+	into.externalLinksParsed = mergeExternalLinks(into.externalLinksParsed, from.externalLinksParsed)
+	into.GroupAdd = mergeGroupAdd(into.GroupAdd, from.GroupAdd)
 	into.Healthcheck = mergeHealthchecks(into.Healthcheck, from.Healthcheck)
+	into.linksParsed = mergeExternalLinks(into.linksParsed, from.linksParsed)
+	into.networksParsed = mergeNetworks(into.networksParsed, from.networksParsed)
 	into.portsParsed = mergePortBindings(into.portsParsed, from.portsParsed)
+	into.Secrets = mergeServiceSecrets(into.Secrets, from.Secrets)
+	into.SecurityOpt = mergeSecurityOpts(into.SecurityOpt, from.SecurityOpt)
 	into.Volumes = mergeVolumes(into.Volumes, from.Volumes)
 
 	if into.Entrypoint == nil {
@@ -37,18 +64,40 @@ func merge(into, from *serviceInternal, mergeExtends bool) {
 	if into.Image == nil {
 		into.Image = from.Image
 	}
+	if into.IPC == nil {
+		into.IPC = from.IPC
+	}
+	if into.MemLimit == nil {
+		into.MemLimit = from.MemLimit
+	}
+	if into.MemReservation == nil {
+		into.MemReservation = from.MemReservation
+	}
+	if into.OOMKillDisable == nil {
+		into.OOMKillDisable = from.OOMKillDisable
+	}
+	if into.OOMScoreAdj == nil {
+		into.OOMScoreAdj = from.OOMScoreAdj
+	}
 	if into.Privileged == nil {
 		into.Privileged = from.Privileged
 	}
 	if into.Restart == nil {
 		into.Restart = from.Restart
 	}
+	if into.StopGracePeriod == nil {
+		into.StopGracePeriod = from.StopGracePeriod
+	}
 	if into.User == nil {
 		into.User = from.User
 	}
+	if into.VolumeDriver == nil {
+		into.VolumeDriver = from.VolumeDriver
+	}
 	if mergeExtends && into.Extends == nil {
 		into.Extends = from.Extends
 	}
+	into.xProperties = mergeXProperties(into.xProperties, from.xProperties)
 }
 
 func mergeDependsOnMaps(into, from *dependsOn) *dependsOn {
@@ -59,6 +108,12 @@ func mergeDependsOnMaps(into, from *dependsOn) *dependsOn {
 		for k, v := range from.Values {
 			if _, ok := into.Values[k]; !ok {
 				into.Values[k] = v
+				if from.Restart[k] {
+					if into.Restart == nil {
+						into.Restart = map[string]bool{}
+					}
+					into.Restart[k] = true
+				}
 			}
 		}
 	}
@@ -118,6 +173,7 @@ func mergeServices(into, from map[string]*serviceInternal) {
 				Healthcheck:       &healthcheckInternal{},
 				name:              name,
 				portsParsed:       []PortBinding{},
+				Secrets:           []serviceSecret{},
 				Volumes:           []ServiceVolume{},
 			}
 			into[name] = intoService
@@ -147,3 +203,40 @@ func mergeVolumes(into, from []ServiceVolume) []ServiceVolume {
 	}
 	return into
 }
+
+// mergeXProperties merges the extension fields of docker compose services. Extension fields in into take precedence over those in from,
+// but (nested) maps are merged recursively, so that a docker compose file can override a single field of an extension. The maps of from
+// are copied, so that they are not modified by subsequent merges.
+func mergeXProperties(into, from XProperties) XProperties {
+	if len(from) == 0 {
+		return into
+	}
+	if into == nil {
+		into = XProperties{}
+	}
+	for k, v := range from {
+		into[k] = mergeGenericValues(into[k], v)
+	}
+	return into
+}
+
+func mergeGenericValues(into, from interface{}) interface{} {
+	fromMap, ok := from.(genericMap)
+	if !ok {
+		if into == nil {
+			return from
+		}
+		return into
+	}
+	intoMap, ok := into.(genericMap)
+	if !ok {
+		if into != nil {
+			return into
+		}
+		intoMap = genericMap{}
+	}
+	for k, v := range fromMap {
+		intoMap[k] = mergeGenericValues(intoMap[k], v)
+	}
+	return intoMap
+}
@@ -156,7 +156,7 @@ func Test_MergeDependsOnMaps_Success(t *testing.T) {
 	}
 	actual := mergeDependsOnMaps(into, from)
 	if !reflect.DeepEqual(actual, &dependsOn{
-		map[string]ServiceHealthiness{
+		Values: map[string]ServiceHealthiness{
 			"mergedependsonsuccess": ServiceStarted,
 		},
 	}) {
@@ -164,6 +164,30 @@ func Test_MergeDependsOnMaps_Success(t *testing.T) {
 	}
 }
 
+func Test_MergeDependsOnMaps_Restart(t *testing.T) {
+	into := &dependsOn{
+		Values: map[string]ServiceHealthiness{
+			"a": ServiceStarted,
+		},
+	}
+	from := &dependsOn{
+		Values: map[string]ServiceHealthiness{
+			"a": ServiceHealthy,
+			"b": ServiceStarted,
+		},
+		Restart: map[string]bool{
+			"a": true,
+			"b": true,
+		},
+	}
+	actual := mergeDependsOnMaps(into, from)
+	if !reflect.DeepEqual(actual.Restart, map[string]bool{
+		"b": true,
+	}) {
+		t.Error(actual.Restart)
+	}
+}
+
 func Test_MergeHealthchecks_Success(t *testing.T) {
 	into := &healthcheckInternal{}
 	from := &healthcheckInternal{
@@ -180,3 +204,29 @@ func Test_MergeHealthchecks_Success(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_MergeXProperties_DoesNotModifyFrom(t *testing.T) {
+	from1 := XProperties{
+		"x-a": genericMap{
+			"b": 1,
+		},
+	}
+	from2 := XProperties{
+		"x-a": genericMap{
+			"c": 2,
+		},
+		"x-d": 3,
+	}
+	into := mergeXProperties(nil, from1)
+	into = mergeXProperties(into, from2)
+	expected := XProperties{
+		"x-a": genericMap{
+			"b": 1,
+			"c": 2,
+		},
+		"x-d": 3,
+	}
+	if !reflect.DeepEqual(into, expected) || len(from1["x-a"].(genericMap)) != 1 {
+		t.Error(into, from1)
+	}
+}
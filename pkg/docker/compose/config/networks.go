@@ -0,0 +1,57 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/uber-go/mapdecode"
+)
+
+// DefaultNetwork is the network that docker compose services are connected to if they do not specify networks.
+const DefaultNetwork = "default"
+
+// serviceNetworks is a helper type used to decode the networks of a docker compose service, which are either a list of network names
+// or a map from network names to network configuration (such as aliases). Only the names of the networks are retained.
+type serviceNetworks struct {
+	Values []string
+}
+
+func (n *serviceNetworks) Decode(into mapdecode.Into) error {
+	var networkMap map[string]interface{}
+	err := into(&networkMap)
+	if err != nil {
+		var networkSlice []string
+		err = into(&networkSlice)
+		if err != nil {
+			return err
+		}
+		n.Values = mergeNetworks(nil, networkSlice)
+		return nil
+	}
+	names := make([]string, 0, len(networkMap))
+	for name := range networkMap {
+		names = append(names, name)
+	}
+	n.Values = mergeNetworks(nil, names)
+	return nil
+}
+
+// mergeNetworks returns the sorted union of the networks of docker compose services, because docker compose connects a service to all
+// networks of the files it is declared in.
+func mergeNetworks(into, from []string) []string {
+	set := map[string]bool{}
+	for _, name := range into {
+		set[name] = true
+	}
+	for _, name := range from {
+		set[name] = true
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	merged := make([]string, 0, len(set))
+	for name := range set {
+		merged = append(merged, name)
+	}
+	sort.Strings(merged)
+	return merged
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func Test_New_Networks(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project/docker-compose.yml": {
+			Content: []byte(`version: '2.4'
+services:
+  a:
+    networks: [front, back, front]
+  b:
+    networks:
+      back:
+        aliases: [database]
+  c: {}
+`),
+		},
+		"/project/docker-compose.override.yml": {
+			Content: []byte("version: '2.4'\nservices:\n  b:\n    networks: [admin]\n"),
+		},
+	}), func() {
+		c, err := New([]string{"/project/docker-compose.yml", "/project/docker-compose.override.yml"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		expected := map[string][]string{
+			"a": {"back", "front"},
+			"b": {"admin", "back"},
+			"c": {DefaultNetwork},
+		}
+		for name, networks := range expected {
+			if !reflect.DeepEqual(c.Services[name].Networks, networks) {
+				t.Error(name, c.Services[name].Networks)
+			}
+		}
+	})
+}
+
+func Test_New_NetworksInvalid(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project/docker-compose.yml": {
+			Content: []byte("version: '2.4'\nservices:\n  a:\n    networks: front\n"),
+		},
+	}), func() {
+		_, err := New([]string{"/project/docker-compose.yml"})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
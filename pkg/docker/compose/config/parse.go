@@ -51,15 +51,20 @@ type healthcheckInternal struct {
 	Disable  *bool   `mapdecode:"disable"`
 	Interval *string `mapdecode:"interval"`
 	Retries  *uint   `mapdecode:"retries"`
+	// start_period is only available in docker-compose 2.3 or higher, but like other fields of later versions it is parsed regardless of
+	// the version of the docker compose file.
+	StartPeriod *string `mapdecode:"start_period"`
 	// Test.Values is nil if and only if the field "test" is not present in the map.
 	// If the field "test" is present and is an empty slice, then Test.Values will not be nil.
 	Test    HealthcheckTest `mapdecode:"test"`
 	Timeout *string         `mapdecode:"timeout"`
-	// start_period is only available in docker-compose 2.3 or higher
 }
 
+// IsEmpty returns true if and only if the healthcheck sets no fields other than disable: false, in which case the healthcheck of the
+// image applies.
 func (h *healthcheckInternal) IsEmpty() bool {
-	return h.Disable == nil && h.Interval == nil && h.Retries == nil && h.GetTest() == nil && h.Timeout == nil
+	return (h.Disable == nil || !*h.Disable) && h.Interval == nil && h.Retries == nil && h.StartPeriod == nil &&
+		h.GetTest() == nil && h.Timeout == nil
 }
 
 func (h *healthcheckInternal) GetTest() []string {
@@ -68,11 +73,14 @@ func (h *healthcheckInternal) GetTest() []string {
 
 type dependsOn struct {
 	Values map[string]ServiceHealthiness
+	// The dependencies with the long syntax field restart set to true, or nil if there are none.
+	Restart map[string]bool
 }
 
 func (t *dependsOn) Decode(into mapdecode.Into) error {
 	var strMap map[string]struct {
 		Condition string `mapdecode:"condition"`
+		Restart   bool   `mapdecode:"restart"`
 	}
 	err := into(&strMap)
 	if err != nil {
@@ -96,11 +104,18 @@ func (t *dependsOn) Decode(into mapdecode.Into) error {
 			switch obj.Condition {
 			case "service_healthy":
 				t.Values[service] = ServiceHealthy
-			case "service_started":
+			// Like docker compose, the condition defaults to service_started.
+			case "", "service_started":
 				t.Values[service] = ServiceStarted
 			default:
 				return fmt.Errorf("depends_on map contains an entry with an invalid condition: %s", obj.Condition)
 			}
+			if obj.Restart {
+				if t.Restart == nil {
+					t.Restart = map[string]bool{}
+				}
+				t.Restart[service] = true
+			}
 		}
 	}
 	return nil
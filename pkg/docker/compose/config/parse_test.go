@@ -284,6 +284,47 @@ func TestDependsOnDecode_MapSuccess(t *testing.T) {
 	}
 }
 
+func TestDependsOnDecode_MapRestart(t *testing.T) {
+	src := map[string]map[string]interface{}{
+		"service-bla-3": {
+			"condition": "service_started",
+			"restart":   true,
+		},
+		"service-bla-4": {
+			"condition": "service_healthy",
+			"restart":   false,
+		},
+	}
+	var dst dependsOn
+	err := mapdecode.Decode(&dst, src)
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(dst.Restart, map[string]bool{
+		"service-bla-3": true,
+	}) {
+		t.Error(dst)
+	}
+}
+
+func TestDependsOnDecode_MapDefaultCondition(t *testing.T) {
+	src := map[string]map[string]interface{}{
+		"service-bla-5": {
+			"restart": true,
+		},
+	}
+	var dst dependsOn
+	err := mapdecode.Decode(&dst, src)
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(dst.Values, map[string]ServiceHealthiness{
+		"service-bla-5": ServiceStarted,
+	}) {
+		t.Error(dst)
+	}
+}
+
 func TestDependsOnDecode_MapInvalidCondition(t *testing.T) {
 	src := map[string]map[string]string{
 		"service-bla-6": {
@@ -413,6 +454,7 @@ func TestServiceVolumeDecode_Success(t *testing.T) {
 			HasHostPath:   true,
 			HasMode:       true,
 			HostPath:      "aa",
+			NamedVolume:   true,
 			Mode:          "cc",
 		},
 	}) {
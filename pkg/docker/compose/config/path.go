@@ -2,14 +2,29 @@ package config
 
 import (
 	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/kube-compose/kube-compose/pkg/expanduser"
 )
 
+// goos is the operating system. It is a variable to improve testability.
+var goos = runtime.GOOS
+
 func expandPath(workingDirChild, path string) string {
-	path = expanduser.ExpandUser(path)
+	path = expanduser.ExpandUser(normalizeRelativePath(path))
 	if !filepath.IsAbs(path) {
 		path = filepath.Join(filepath.Dir(workingDirChild), path)
 	}
 	return path
 }
+
+// normalizeRelativePath converts the backslashes of a path to slashes, so that docker compose files written on Windows can be used on any
+// platform. On Windows every backslash is a separator, so all backslashes are converted. On other platforms backslashes can be part of
+// file names, so only the backslashes of relative paths that start with .\ or ..\ are converted.
+func normalizeRelativePath(path string) string {
+	if goos == "windows" || strings.HasPrefix(path, `.\`) || strings.HasPrefix(path, `..\`) {
+		return strings.Replace(path, `\`, "/", -1)
+	}
+	return path
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+)
+
+// withMockGOOS runs cb with the operating system goosMock.
+func withMockGOOS(goosMock string, cb func()) {
+	orig := goos
+	defer func() {
+		goos = orig
+	}()
+	goos = goosMock
+	cb()
+}
+
+func TestNormalizeRelativePath_Windows(t *testing.T) {
+	withMockGOOS("windows", func() {
+		for path, expected := range map[string]string{
+			`config\a.env`:       "config/a.env",
+			`.\config\a.env`:     "./config/a.env",
+			`C:\Users\henk\data`: "C:/Users/henk/data",
+		} {
+			if actual := normalizeRelativePath(path); actual != expected {
+				t.Error(path, actual)
+			}
+		}
+	})
+}
+
+func TestNormalizeRelativePath_Linux(t *testing.T) {
+	withMockGOOS("linux", func() {
+		for path, expected := range map[string]string{
+			`config\a.env`:    `config\a.env`,
+			`..\config\a.env`: "../config/a.env",
+		} {
+			if actual := normalizeRelativePath(path); actual != expected {
+				t.Error(path, actual)
+			}
+		}
+	})
+}
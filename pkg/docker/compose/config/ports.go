@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"regexp"
 	"strconv"
 
@@ -13,7 +14,7 @@ import (
 var portBindingSpecRegexp = regexp.MustCompile(
 	"^" + // Match full string
 		"(?:" + // External part
-		"(?:(?P<host>[a-fA-F\\d.:]+?):)?" + // IP address
+		"(?:(?:\\[(?P<host6>[a-fA-F\\d.:]+)\\]|(?P<host>[a-fA-F\\d.:]+?)):)?" + // IP address, IPv6 addresses may be in brackets
 		"(?P<externalMin>[\\d]*)(?:-(?P<externalMax>\\d+))?:" + // External range
 		")?" +
 		"(?P<internalMin>\\d+)(?:-(?P<internalMax>\\d+))?" + // Internal range
@@ -130,6 +131,7 @@ func (parser *portBindingParser) buildResult() error {
 //  - "49100:22"
 //  - "127.0.0.1:8001:8001"
 //  - "127.0.0.1:5000-5010:5000-5010"
+//  - "[::1]:8001:8001"
 //  - "6060:6060/udp"
 //  - "12400-12500:1240"
 func parsePortBindings(spec string, portBindings []PortBinding) ([]PortBinding, error) {
@@ -143,6 +145,12 @@ func parsePortBindings(spec string, portBindings []PortBinding) ([]PortBinding,
 	matchMap := util.BuildRegexpMatchMap(portBindingSpecRegexp, matches)
 
 	parser.host = matchMap["host"]
+	if host6 := matchMap["host6"]; host6 != "" {
+		parser.host = host6
+	}
+	if parser.host != "" && net.ParseIP(parser.host) == nil {
+		return nil, fmt.Errorf("invalid port %q, the host %s is not an IP address", spec, parser.host)
+	}
 	parser.protocol = matchMap["protocol"]
 	if parser.protocol == "" {
 		parser.protocol = "tcp"
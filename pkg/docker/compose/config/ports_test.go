@@ -182,3 +182,28 @@ func Test_ParsePorts_DuplicatePortsError(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_ParsePortBindings_SuccessIPv6Host(t *testing.T) {
+	expected := []PortBinding{
+		{
+			Internal:    80,
+			ExternalMin: 8080,
+			ExternalMax: 8080,
+			Protocol:    "tcp",
+			Host:        "::1",
+		},
+	}
+	actual, err := parsePortBindings("[::1]:8080:80", nil)
+	if err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(actual, expected) {
+		t.Error(actual)
+	}
+}
+
+func Test_ParsePortBindings_InvalidHost(t *testing.T) {
+	_, err := parsePortBindings("1.2.3:8080:80", nil)
+	if err == nil {
+		t.Fail()
+	}
+}
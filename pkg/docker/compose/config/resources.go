@@ -0,0 +1,266 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/uber-go/mapdecode"
+)
+
+// Resources are the resource constraints of a docker compose service, as set by the version 2 keys cpus, cpu_shares, cpuset,
+// mem_limit and mem_reservation, and the low-level keys cgroup_parent, oom_kill_disable and oom_score_adj.
+type Resources struct {
+	// The parent cgroup of the service's containers (cgroup_parent), or the empty string if not set.
+	CgroupParent string
+	// The maximum number of CPUs the service can use, or 0 if not limited. Set by cpus, or else by the number of CPUs of cpuset.
+	CPULimit float64
+	// The relative CPU weight of the service (cpu_shares), or 0 if not set. Docker's default weight is 1024, which is also the weight
+	// that Kubernetes gives a container per CPU that it requests.
+	CPUShares int64
+	// The maximum amount of memory in bytes (mem_limit), or 0 if not limited.
+	MemoryLimit int64
+	// The amount of memory in bytes that is reserved for the service (mem_reservation), or 0 if not set.
+	MemoryReservation int64
+	// True if the OOM killer must not kill the service's containers (oom_kill_disable).
+	OOMKillDisable bool
+	// The preference of the OOM killer for killing the service's containers (oom_score_adj), from -1000 to 1000, or nil if not set.
+	OOMScoreAdj *int64
+}
+
+// DeviceRequestCountAll is the Count of a DeviceRequest that reserves all devices (count: all).
+const DeviceRequestCountAll = -1
+
+// DeviceRequest is a reservation of devices of the Compose Spec. See
+// https://github.com/compose-spec/compose-spec/blob/master/deploy.md#devices.
+type DeviceRequest struct {
+	// The capabilities the devices must have, such as "gpu".
+	Capabilities []string
+	// The number of devices, or DeviceRequestCountAll. If count and device_ids are not set then this is DeviceRequestCountAll, like
+	// docker compose.
+	Count int64
+	// The IDs of specific devices, if any.
+	DeviceIDs []string
+	// The driver of the devices, such as "nvidia", or the empty string if not set.
+	Driver string
+}
+
+type deviceRequest struct {
+	Capabilities []string            `mapdecode:"capabilities"`
+	Count        *deviceRequestCount `mapdecode:"count"`
+	DeviceIDs    []string            `mapdecode:"device_ids"`
+	Driver       *string             `mapdecode:"driver"`
+}
+
+// deviceRequestCount is a helper type used to decode the count of a device request, which is either an integer or "all".
+type deviceRequestCount struct {
+	Value int64
+}
+
+func (c *deviceRequestCount) Decode(into mapdecode.Into) error {
+	var str string
+	err := into(&str)
+	if err != nil {
+		return into(&c.Value)
+	}
+	if str != "all" {
+		return fmt.Errorf("invalid device count %#v", str)
+	}
+	c.Value = DeviceRequestCountAll
+	return nil
+}
+
+// deploy is the subset of the deploy key of the Compose Spec that is supported.
+type deploy struct {
+	Resources *struct {
+		Reservations *struct {
+			Devices []deviceRequest `mapdecode:"devices"`
+		} `mapdecode:"reservations"`
+	} `mapdecode:"resources"`
+}
+
+// parseDeviceRequests returns the device reservations of a docker compose service.
+func parseDeviceRequests(d *deploy) ([]DeviceRequest, error) {
+	if d == nil || d.Resources == nil || d.Resources.Reservations == nil {
+		return nil, nil
+	}
+	var deviceRequests []DeviceRequest
+	for _, dr := range d.Resources.Reservations.Devices {
+		if len(dr.Capabilities) == 0 {
+			return nil, fmt.Errorf("a device reservation must have capabilities")
+		}
+		if dr.Count != nil && len(dr.DeviceIDs) > 0 {
+			return nil, fmt.Errorf("a device reservation cannot set both count and device_ids")
+		}
+		deviceRequest := DeviceRequest{
+			Capabilities: dr.Capabilities,
+			Count:        DeviceRequestCountAll,
+			DeviceIDs:    dr.DeviceIDs,
+		}
+		if dr.Count != nil {
+			if dr.Count.Value < 0 && dr.Count.Value != DeviceRequestCountAll {
+				return nil, fmt.Errorf("a device reservation has a negative count")
+			}
+			deviceRequest.Count = dr.Count.Value
+		} else if len(dr.DeviceIDs) > 0 {
+			deviceRequest.Count = int64(len(dr.DeviceIDs))
+		}
+		if dr.Driver != nil {
+			deviceRequest.Driver = *dr.Driver
+		}
+		deviceRequests = append(deviceRequests, deviceRequest)
+	}
+	return deviceRequests, nil
+}
+
+// byteSize is a helper type used to decode an amount of bytes that is either an integer or a string with a unit, such as "512m".
+type byteSize struct {
+	Value int64
+}
+
+func (b *byteSize) Decode(into mapdecode.Into) error {
+	var str string
+	err := into(&str)
+	if err != nil {
+		return into(&b.Value)
+	}
+	b.Value, err = parseBytes(str)
+	return err
+}
+
+// parseBytes has the same logic as parse_bytes of docker-py: a number optionally followed by one of the (case insensitive) units b, k,
+// m and g, which may be followed by a b.
+// https://github.com/docker/docker-py/blob/ac922192959870774ad8428344d9faa0555f7ba6/docker/utils/utils.py#L376
+func parseBytes(s string) (int64, error) {
+	str := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), "b")
+	multiplier := int64(1)
+	if str != "" {
+		switch str[len(str)-1] {
+		case 'k':
+			multiplier = 1 << 10
+		case 'm':
+			multiplier = 1 << 20
+		case 'g':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			str = str[:len(str)-1]
+		}
+	}
+	value, err := strconv.ParseFloat(str, 64)
+	if err != nil || value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, fmt.Errorf("invalid amount of bytes %#v", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// floatOrString is a helper type used to decode a number that may be written as a string, such as the value of cpus.
+type floatOrString struct {
+	Value float64
+}
+
+func (f *floatOrString) Decode(into mapdecode.Into) error {
+	var str string
+	err := into(&str)
+	if err != nil {
+		return into(&f.Value)
+	}
+	f.Value, err = strconv.ParseFloat(strings.TrimSpace(str), 64)
+	return err
+}
+
+// maxCPUSetCPU is the highest CPU number that a cpuset can contain. Linux supports at most 8192 CPUs (CONFIG_NR_CPUS).
+const maxCPUSetCPU = 8191
+
+// parseCPUSet returns the number of CPUs of a cpuset such as "0-3,6". The ranges of the cpuset may overlap, so they are merged after
+// sorting them by their first CPU.
+func parseCPUSet(cpuset string) (int, error) {
+	var ranges [][2]int
+	for _, part := range strings.Split(cpuset, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil || first < 0 || first > maxCPUSetCPU {
+			return 0, fmt.Errorf("invalid cpuset %#v", cpuset)
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil || last < first || last > maxCPUSetCPU {
+				return 0, fmt.Errorf("invalid cpuset %#v", cpuset)
+			}
+		}
+		ranges = append(ranges, [2]int{first, last})
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i][0] < ranges[j][0]
+	})
+	n := 0
+	next := 0
+	for _, r := range ranges {
+		if r[0] < next {
+			r[0] = next
+		}
+		if r[0] <= r[1] {
+			n += r[1] - r[0] + 1
+			next = r[1] + 1
+		}
+	}
+	return n, nil
+}
+
+// finalizeServiceResources sets the resource constraints of a docker compose service. Kubernetes cannot pin containers to specific CPUs,
+// so a cpuset is approximated by limiting the service to as many CPUs as the cpuset has.
+func finalizeServiceResources(s *serviceInternal) error {
+	r := &s.finalService.Resources
+	if s.CPUs != nil {
+		if s.CPUs.Value < 0 {
+			return fmt.Errorf("service %s has a negative value for cpus", s.name)
+		}
+		r.CPULimit = s.CPUs.Value
+	} else if s.CPUSet != nil {
+		n, err := parseCPUSet(*s.CPUSet)
+		if err != nil {
+			return errors.Wrapf(err, "service %s", s.name)
+		}
+		r.CPULimit = float64(n)
+	}
+	if s.CPUShares != nil {
+		if *s.CPUShares < 0 {
+			return fmt.Errorf("service %s has a negative value for cpu_shares", s.name)
+		}
+		r.CPUShares = *s.CPUShares
+	}
+	if s.MemLimit != nil {
+		r.MemoryLimit = s.MemLimit.Value
+	}
+	if s.MemReservation != nil {
+		r.MemoryReservation = s.MemReservation.Value
+	}
+	if r.MemoryLimit < 0 || r.MemoryReservation < 0 {
+		return fmt.Errorf("service %s has a negative value for mem_limit or mem_reservation", s.name)
+	}
+	if r.MemoryLimit > 0 && r.MemoryReservation > r.MemoryLimit {
+		return fmt.Errorf("service %s has a mem_reservation that is greater than its mem_limit", s.name)
+	}
+	if s.CgroupParent != nil {
+		r.CgroupParent = *s.CgroupParent
+	}
+	if s.OOMKillDisable != nil {
+		r.OOMKillDisable = *s.OOMKillDisable
+	}
+	if s.OOMScoreAdj != nil {
+		if *s.OOMScoreAdj < -1000 || *s.OOMScoreAdj > 1000 {
+			return fmt.Errorf("service %s has a value for oom_score_adj that is not between -1000 and 1000", s.name)
+		}
+		r.OOMScoreAdj = s.OOMScoreAdj
+	}
+	var err error
+	s.finalService.Devices, err = parseDeviceRequests(s.Deploy)
+	if err != nil {
+		return errors.Wrapf(err, "service %s", s.name)
+	}
+	return nil
+}
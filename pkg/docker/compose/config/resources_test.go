@@ -0,0 +1,218 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func TestParseBytes_Success(t *testing.T) {
+	testCases := map[string]int64{
+		"1024":  1024,
+		"512m":  512 << 20,
+		"1.5g":  3 << 29,
+		"64KB":  64 << 10,
+		"100b":  100,
+		" 2g ":  2 << 30,
+		"0.5kb": 512,
+	}
+	for s, expected := range testCases {
+		if value, err := parseBytes(s); err != nil || value != expected {
+			t.Error(s, value, err)
+		}
+	}
+}
+
+func TestParseBytes_Invalid(t *testing.T) {
+	for _, s := range []string{"", "m", "-1m", "1t"} {
+		if _, err := parseBytes(s); err == nil {
+			t.Error(s)
+		}
+	}
+}
+
+func TestParseCPUSet_Success(t *testing.T) {
+	n, err := parseCPUSet("0-3,6,2")
+	if err != nil || n != 5 {
+		t.Error(n, err)
+	}
+}
+
+func TestParseCPUSet_Overlapping(t *testing.T) {
+	n, err := parseCPUSet("4-7,0-5,6,9-8191")
+	if err != nil || n != 8191 {
+		t.Error(n, err)
+	}
+}
+
+func TestParseCPUSet_Invalid(t *testing.T) {
+	for _, cpuset := range []string{"", "a", "3-1", "-1", "8192", "0-2147483647"} {
+		if _, err := parseCPUSet(cpuset); err == nil {
+			t.Error(cpuset)
+		}
+	}
+}
+
+func Test_New_LegacyResources(t *testing.T) {
+	file := "/legacyresources"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    cpus: '0.5'
+    cpu_shares: 512
+    mem_limit: 1g
+    mem_reservation: 268435456
+  service2:
+    cpuset: 0-1
+    volume_driver: flocker
+    volumes:
+    - ./data:/data
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		expected := Resources{
+			CPULimit:          0.5,
+			CPUShares:         512,
+			MemoryLimit:       1 << 30,
+			MemoryReservation: 256 << 20,
+		}
+		if c.Services["service1"].Resources != expected {
+			t.Error(c.Services["service1"].Resources)
+		}
+		service2 := c.Services["service2"]
+		if service2.Resources.CPULimit != 2 || service2.VolumeDriver != "flocker" || service2.Volumes[0].Short.HostPath != "./data" {
+			t.Error(service2)
+		}
+	})
+}
+
+func Test_New_LegacyResourcesReservationExceedsLimit(t *testing.T) {
+	file := "/legacyresourcesinvalid"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    mem_limit: 256m
+    mem_reservation: 512m
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_LowLevelFields(t *testing.T) {
+	file := "/lowlevelfields"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    cgroup_parent: m-executor-abcd
+    oom_kill_disable: true
+    oom_score_adj: -500
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		r := c.Services["service1"].Resources
+		if r.CgroupParent != "m-executor-abcd" || !r.OOMKillDisable || r.OOMScoreAdj == nil || *r.OOMScoreAdj != -500 {
+			t.Error(r)
+		}
+	})
+}
+
+func Test_New_OOMScoreAdjInvalid(t *testing.T) {
+	file := "/oomscoreadjinvalid"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    oom_score_adj: 1001
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_DeviceReservations(t *testing.T) {
+	file := "/devices"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '3.8'
+services:
+  service1:
+    deploy:
+      resources:
+        reservations:
+          devices:
+          - capabilities: [gpu]
+            count: 2
+            driver: nvidia
+          - capabilities: [gpu]
+            device_ids: ['0', '3']
+          - capabilities: [gpu]
+            count: all
+          - capabilities: [gpu]
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		expected := []DeviceRequest{
+			{Capabilities: []string{"gpu"}, Count: 2, Driver: "nvidia"},
+			{Capabilities: []string{"gpu"}, Count: 2, DeviceIDs: []string{"0", "3"}},
+			{Capabilities: []string{"gpu"}, Count: DeviceRequestCountAll},
+			{Capabilities: []string{"gpu"}, Count: DeviceRequestCountAll},
+		}
+		if !reflect.DeepEqual(c.Services["service1"].Devices, expected) {
+			t.Error(c.Services["service1"].Devices)
+		}
+	})
+}
+
+func Test_New_DeviceReservationsInvalid(t *testing.T) {
+	for _, device := range []string{
+		"{count: 1}",
+		"{capabilities: [gpu], count: some}",
+		"{capabilities: [gpu], count: -2}",
+		"{capabilities: [gpu], count: 1, device_ids: ['0']}",
+	} {
+		file := "/devicesinvalid"
+		withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+			file: {
+				Content: []byte("version: '3.8'\nservices:\n  service1:\n    deploy:\n      resources:\n        reservations:\n" +
+					"          devices: [" + device + "]\n"),
+			},
+		}), func() {
+			_, err := New([]string{file})
+			if err == nil {
+				t.Error(device)
+			}
+		})
+	}
+}
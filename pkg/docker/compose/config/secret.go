@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	pathPackage "path"
+
+	"github.com/uber-go/mapdecode"
+)
+
+// defaultSecretsDir is the directory in which secrets are mounted if the target of a secret is not an absolute path.
+const defaultSecretsDir = "/run/secrets"
+
+// Secret is a top-level secret of the docker compose configuration.
+// See https://docs.docker.com/compose/compose-file/#secrets-configuration-reference.
+type Secret struct {
+	// True if and only if the secret is managed outside of docker compose.
+	External bool
+	// The absolute path of the file that contains the secret. Only set if External is false.
+	File string
+	// The name of the secret, which defaults to the key of the secret in the secrets section.
+	Name string
+	// The extension fields of the secret. This allows users of this package to read provider specific configuration.
+	XProperties XProperties
+}
+
+// ServiceSecret is a reference of a docker compose service to a top-level secret.
+// See https://docs.docker.com/compose/compose-file/#secrets.
+type ServiceSecret struct {
+	// The key of the top-level secret.
+	Source string
+	// The absolute path at which the secret is mounted in the containers of the service.
+	Target string
+}
+
+type serviceSecret struct {
+	ServiceSecret
+}
+
+func (s *serviceSecret) Decode(into mapdecode.Into) error {
+	var longSyntax struct {
+		Source string  `mapdecode:"source"`
+		Target *string `mapdecode:"target"`
+	}
+	err := into(&longSyntax)
+	if err != nil {
+		var shortSyntax string
+		err = into(&shortSyntax)
+		if err != nil {
+			return err
+		}
+		longSyntax.Source = shortSyntax
+	}
+	if longSyntax.Source == "" {
+		return fmt.Errorf("a secret of a service must have a source")
+	}
+	s.Source = longSyntax.Source
+	s.Target = longSyntax.Source
+	if longSyntax.Target != nil && *longSyntax.Target != "" {
+		s.Target = *longSyntax.Target
+	}
+	if !pathPackage.IsAbs(s.Target) {
+		s.Target = pathPackage.Join(defaultSecretsDir, s.Target)
+	}
+	return nil
+}
+
+type external struct {
+	Value bool
+	// The legacy syntax external: {name: ...} allows the name to be specified.
+	Name *string
+}
+
+func (e *external) Decode(into mapdecode.Into) error {
+	err := into(&e.Value)
+	if err != nil {
+		var legacySyntax struct {
+			Name *string `mapdecode:"name"`
+		}
+		err = into(&legacySyntax)
+		if err != nil {
+			return err
+		}
+		e.Value = true
+		e.Name = legacySyntax.Name
+	}
+	return nil
+}
+
+type secretInternal struct {
+	External external `mapdecode:"external"`
+	File     *string  `mapdecode:"file"`
+	Name     *string  `mapdecode:"name"`
+}
+
+// parseSecrets converts the secrets section of a docker compose file. secretsRaw is the secrets section before mapdecode was applied,
+// and is used to extract extension fields.
+func parseSecrets(resolvedFile string, secrets map[string]*secretInternal, secretsRaw interface{}) (map[string]*Secret, error) {
+	if len(secrets) == 0 {
+		return nil, nil
+	}
+	secretsRawMap, _ := secretsRaw.(genericMap)
+	result := make(map[string]*Secret, len(secrets))
+	for key, secretInternal := range secrets {
+		secret := &Secret{
+			External:    secretInternal.External.Value,
+			Name:        key,
+			XProperties: getXProperties(secretsRawMap[key]),
+		}
+		switch {
+		case secretInternal.Name != nil:
+			secret.Name = *secretInternal.Name
+		case secretInternal.External.Name != nil:
+			secret.Name = *secretInternal.External.Name
+		}
+		if secretInternal.File != nil {
+			if secret.External {
+				return nil, fmt.Errorf("secret %s cannot be both external and have a file", key)
+			}
+			secret.File = expandPath(resolvedFile, *secretInternal.File)
+		} else if !secret.External {
+			return nil, fmt.Errorf("secret %s must either be external or have a file", key)
+		}
+		result[key] = secret
+	}
+	return result, nil
+}
+
+func addServiceSecret(secrets []serviceSecret, secret1 serviceSecret) []serviceSecret {
+	for _, secret2 := range secrets {
+		if secret2.Target == secret1.Target {
+			return secrets
+		}
+	}
+	return append(secrets, secret1)
+}
+
+func mergeServiceSecrets(into, from []serviceSecret) []serviceSecret {
+	if len(into) == 0 {
+		return from
+	}
+	for _, v := range from {
+		into = addServiceSecret(into, v)
+	}
+	return into
+}
+
+// mergeSecrets merges the secrets sections of docker compose files. Secrets in into take precedence over those in from.
+func mergeSecrets(into, from map[string]*Secret) map[string]*Secret {
+	if into == nil {
+		into = map[string]*Secret{}
+	}
+	for key, secret := range from {
+		if _, ok := into[key]; !ok {
+			into[key] = secret
+		}
+	}
+	return into
+}
+
+// resolveServiceSecrets checks that the secrets of services refer to existing top-level secrets.
+func resolveServiceSecrets(services map[string]*serviceInternal, secrets map[string]*Secret) error {
+	for name, s := range services {
+		for _, secret := range s.Secrets {
+			if secrets[secret.Source] == nil {
+				return fmt.Errorf("service %s refers to a non-existing secret: %s", name, secret.Source)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func Test_New_SecretsSuccess(t *testing.T) {
+	file := "/project/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '3.7'
+services:
+  service1:
+    secrets:
+    - db_password
+    - source: api_key
+      target: api
+    - source: tls_key
+      target: /etc/tls/key.pem
+secrets:
+  db_password:
+    external: true
+    x-kube-compose:
+      provider: csi
+  api_key:
+    external:
+      name: legacy_api_key
+  tls_key:
+    file: ./tls.key
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else {
+			expectedServiceSecrets := []ServiceSecret{
+				{
+					Source: "db_password",
+					Target: "/run/secrets/db_password",
+				},
+				{
+					Source: "api_key",
+					Target: "/run/secrets/api",
+				},
+				{
+					Source: "tls_key",
+					Target: "/etc/tls/key.pem",
+				},
+			}
+			if !reflect.DeepEqual(c.Services["service1"].Secrets, expectedServiceSecrets) {
+				t.Error(c.Services["service1"].Secrets)
+			}
+			dbPassword := c.Secrets["db_password"]
+			if dbPassword == nil || !dbPassword.External || dbPassword.Name != "db_password" || dbPassword.XProperties["x-kube-compose"] == nil {
+				t.Error(dbPassword)
+			}
+			apiKey := c.Secrets["api_key"]
+			if apiKey == nil || !apiKey.External || apiKey.Name != "legacy_api_key" {
+				t.Error(apiKey)
+			}
+			tlsKey := c.Secrets["tls_key"]
+			if tlsKey == nil || tlsKey.External || tlsKey.File != "/project/tls.key" {
+				t.Error(tlsKey)
+			}
+		}
+	})
+}
+
+func Test_New_SecretsMerge(t *testing.T) {
+	file1 := "/project/docker-compose.yml"
+	file2 := "/project/docker-compose.override.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file1: {
+			Content: []byte(`version: '3.7'
+services:
+  service1:
+    secrets:
+    - db_password
+secrets:
+  db_password:
+    file: ./db_password.txt
+`),
+		},
+		file2: {
+			Content: []byte(`version: '3.7'
+services:
+  service1:
+    secrets:
+    - source: db_password
+      target: db_password
+    - api_key
+secrets:
+  db_password:
+    external: true
+  api_key:
+    external: true
+`),
+		},
+	}), func() {
+		c, err := New([]string{file1, file2})
+		if err != nil {
+			t.Error(err)
+		} else {
+			if len(c.Services["service1"].Secrets) != 2 {
+				t.Error(c.Services["service1"].Secrets)
+			}
+			if len(c.Secrets) != 2 || !c.Secrets["db_password"].External {
+				t.Error(c.Secrets)
+			}
+		}
+	})
+}
+
+func Test_New_SecretDoesNotExist(t *testing.T) {
+	file := "/project/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '3.7'
+services:
+  service1:
+    secrets:
+    - db_password
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_SecretInvalid(t *testing.T) {
+	file := "/project/docker-compose.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '3.7'
+services: {}
+secrets:
+  db_password: {}
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SecurityOptUnconfined is the value of SecurityOptions.Seccomp and SecurityOptions.AppArmor of services that run without a profile.
+const SecurityOptUnconfined = "unconfined"
+
+// SecurityOptions are the security options of a docker compose service (security_opt).
+type SecurityOptions struct {
+	// The path of the seccomp profile of the service (seccomp=profile.json), SecurityOptUnconfined, or the empty string if not set.
+	Seccomp string
+	// The name of the AppArmor profile of the service (apparmor=profile), SecurityOptUnconfined, or the empty string if not set.
+	AppArmor string
+	// True if the processes of the service's containers cannot gain privileges (no-new-privileges).
+	NoNewPrivileges bool
+	// The SELinux label options of the service (label=type:TYPE), in order.
+	Labels []string
+}
+
+// splitSecurityOpt splits a security option into its key and value. Docker accepts both = and the deprecated : as separator.
+func splitSecurityOpt(securityOpt string) (key, value string, hasValue bool) {
+	i := strings.IndexAny(securityOpt, "=:")
+	if i < 0 {
+		return securityOpt, "", false
+	}
+	return securityOpt[:i], securityOpt[i+1:], true
+}
+
+// mergeSecurityOpts merges the security options of two services, where the options of into override options of from with the same key.
+// The label options are combined.
+func mergeSecurityOpts(into, from []string) []string {
+	keys := map[string]bool{}
+	for _, securityOpt := range into {
+		key, _, _ := splitSecurityOpt(securityOpt)
+		keys[key] = true
+	}
+	for _, securityOpt := range from {
+		key, _, _ := splitSecurityOpt(securityOpt)
+		if key == "label" || !keys[key] {
+			into = append(into, securityOpt)
+		}
+	}
+	return into
+}
+
+func parseSecurityOpts(name string, securityOpts []string) (SecurityOptions, error) {
+	var options SecurityOptions
+	for _, securityOpt := range securityOpts {
+		key, value, hasValue := splitSecurityOpt(securityOpt)
+		switch key {
+		case "seccomp", "apparmor", "label":
+			if value == "" {
+				return options, fmt.Errorf("service %s has an invalid security_opt %#v: the %s option requires a value", name, securityOpt,
+					key)
+			}
+			switch key {
+			case "seccomp":
+				options.Seccomp = value
+			case "apparmor":
+				options.AppArmor = value
+			default:
+				options.Labels = append(options.Labels, value)
+			}
+		case "no-new-privileges":
+			options.NoNewPrivileges = true
+			if hasValue {
+				noNewPrivileges, err := strconv.ParseBool(value)
+				if err != nil {
+					return options, fmt.Errorf("service %s has an invalid security_opt %#v: the value of no-new-privileges must be a "+
+						"boolean", name, securityOpt)
+				}
+				options.NoNewPrivileges = noNewPrivileges
+			}
+		default:
+			return options, fmt.Errorf("service %s has an invalid security_opt %#v: the option %s is not supported", name, securityOpt,
+				key)
+		}
+	}
+	return options, nil
+}
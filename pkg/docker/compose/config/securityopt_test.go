@@ -0,0 +1,79 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func TestParseSecurityOpts_Success(t *testing.T) {
+	options, err := parseSecurityOpts("service1", []string{
+		"seccomp=/profiles/strict.json",
+		"apparmor:docker-default",
+		"no-new-privileges",
+		"label=type:svirt_apache_t",
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	expected := SecurityOptions{
+		Seccomp:         "/profiles/strict.json",
+		AppArmor:        "docker-default",
+		NoNewPrivileges: true,
+		Labels:          []string{"type:svirt_apache_t"},
+	}
+	if !reflect.DeepEqual(options, expected) {
+		t.Error(options)
+	}
+}
+
+func TestParseSecurityOpts_NoNewPrivilegesFalse(t *testing.T) {
+	options, err := parseSecurityOpts("service1", []string{"no-new-privileges=false"})
+	if err != nil || options.NoNewPrivileges {
+		t.Error(options, err)
+	}
+}
+
+func TestParseSecurityOpts_Invalid(t *testing.T) {
+	for _, securityOpt := range []string{"seccomp", "no-new-privileges=maybe", "systempaths=unconfined"} {
+		_, err := parseSecurityOpts("service1", []string{securityOpt})
+		if err == nil {
+			t.Error(securityOpt)
+		}
+	}
+}
+
+func TestMergeSecurityOpts(t *testing.T) {
+	merged := mergeSecurityOpts([]string{"seccomp=b.json", "label=level:s0"}, []string{"seccomp=a.json", "no-new-privileges",
+		"label=type:t"})
+	expected := []string{"seccomp=b.json", "label=level:s0", "no-new-privileges", "label=type:t"}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Error(merged)
+	}
+}
+
+func Test_New_SecurityOpt(t *testing.T) {
+	file := "/securityopt"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    security_opt:
+    - seccomp=unconfined
+    - no-new-privileges:true
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		securityOptions := c.Services["service1"].SecurityOptions
+		if securityOptions.Seccomp != SecurityOptUnconfined || !securityOptions.NoNewPrivileges {
+			t.Error(securityOptions)
+		}
+	})
+}
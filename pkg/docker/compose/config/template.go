@@ -0,0 +1,50 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"text/template"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// renderTemplate executes a docker compose file as a Go text/template, so that services can be included conditionally. The template
+// function env returns the value of an environment variable (or the empty string if it is not set), file returns the content of a file
+// resolved relative to the docker compose file, and b64enc encodes a string as standard base64.
+func renderTemplate(resolvedFile string, data []byte, environmentGetter ValueGetter) ([]byte, error) {
+	funcs := template.FuncMap{
+		"b64enc": func(value string) string {
+			return base64.StdEncoding.EncodeToString([]byte(value))
+		},
+		"env": func(name string) string {
+			value, _ := environmentGetter(name)
+			return value
+		},
+		"file": func(path string) (string, error) {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(resolvedFile), path)
+			}
+			reader, err := fs.OS.Open(path)
+			if err != nil {
+				return "", err
+			}
+			content, err := ioutil.ReadAll(reader)
+			util.CloseAndLogError(reader)
+			return string(content), err
+		},
+	}
+	tmpl, err := template.New(filepath.Base(resolvedFile)).Funcs(funcs).Parse(string(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while parsing docker compose file %#v as a template", resolvedFile)
+	}
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while rendering docker compose file %#v", resolvedFile)
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/sops"
+)
+
+func TestRenderTemplate_Success(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/project/token.txt": {
+			Content: []byte("secret"),
+		},
+	}), func() {
+		data := []byte(`{{ if eq (env "DEBUG") "1" }}debug: true{{ end }}
+token: {{ file "token.txt" | b64enc }}`)
+		rendered, err := renderTemplate("/project/docker-compose.yml", data, mapValueGetter(map[string]string{
+			"DEBUG": "1",
+		}))
+		if err != nil {
+			t.Error(err)
+		} else if s := string(rendered); s != "debug: true\ntoken: c2VjcmV0" {
+			t.Error(s)
+		}
+	})
+}
+
+func TestRenderTemplate_FileError(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{}), func() {
+		_, err := renderTemplate("/project/docker-compose.yml", []byte(`{{ file "missing.txt" }}`), mapValueGetter(nil))
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestRenderTemplate_ParseError(t *testing.T) {
+	_, err := renderTemplate("/project/docker-compose.yml", []byte(`{{ if }}`), mapValueGetter(nil))
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_NewWithOptions_Template(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yml": {
+			Content: []byte(`version: '2.4'
+services:
+  web: {}
+{{- if env "KUBE_COMPOSE_TEST_TEMPLATE_UNSET" }}
+  db: {}
+{{- end }}`),
+		},
+	}), func() {
+		c, err := NewWithOptions([]string{"/docker-compose.yml"}, &LoadOptions{
+			Template: true,
+		})
+		if err != nil {
+			t.Error(err)
+		} else if len(c.Services) != 1 || c.Services["web"] == nil {
+			t.Error(c.Services)
+		}
+	})
+}
+
+func Test_NewWithOptions_TemplateEncrypted(t *testing.T) {
+	encrypted := `version: '2.4'
+services:
+  web:
+    image: ENC[AES256_GCM,data:invalid,type:str]
+sops:
+  mac: ENC[AES256_GCM,data:invalid,type:str]
+`
+	orig := sopsDecrypt
+	defer func() {
+		sopsDecrypt = orig
+	}()
+	sopsDecrypt = func(data []byte, _ sops.Format) ([]byte, error) {
+		// The file is decrypted as it was encrypted, before it is rendered.
+		if string(data) != encrypted {
+			t.Error(string(data))
+		}
+		return []byte("version: '2.4'\nservices:\n  web:\n    image: '{{ b64enc \"nginx\" }}'\n"), nil
+	}
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yml": {
+			Content: []byte(encrypted),
+		},
+	}), func() {
+		c, err := NewWithOptions([]string{"/docker-compose.yml"}, &LoadOptions{
+			Template: true,
+		})
+		if err != nil {
+			t.Error(err)
+		} else if c.Services["web"] == nil || c.Services["web"].Image != "bmdpbng=" {
+			t.Error(c.Services)
+		}
+	})
+}
+
+func Test_NewWithOptions_XPropertiesLast(t *testing.T) {
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/docker-compose.yml": {
+			Content: []byte(`version: '2.4'
+services:
+  web: {}
+x-kube-compose:
+  presets: true`),
+		},
+	}), func() {
+		defaults := XProperties{
+			"x-kube-compose": "defaults",
+		}
+		c, err := NewWithOptions([]string{"/docker-compose.yml"}, &LoadOptions{
+			XProperties: defaults,
+		})
+		if err != nil {
+			t.Error(err)
+		} else if len(c.XProperties) != 2 || !reflect.DeepEqual(c.XProperties[1], defaults) {
+			t.Error(c.XProperties)
+		}
+	})
+}
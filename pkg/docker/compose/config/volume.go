@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"strings"
 
 	fsPackage "github.com/kube-compose/kube-compose/internal/pkg/fs"
@@ -17,6 +18,13 @@ type PathMapping struct {
 	HostPath      string // If this starts with a . or ~ then those should be expanded as appropriate.
 	Mode          string
 	ContainerPath string
+	NamedVolume   bool // true if and only if HostPath is the key of a top-level volume (e.g. data:/var/lib/data).
+}
+
+// isNamedVolume returns true if and only if the host path of a path mapping is the name of a volume. Like docker compose, host paths that
+// do not start with ., / or ~ (and are not Windows paths) are names of volumes.
+func isNamedVolume(hostPath string) bool {
+	return hostPath != "" && volumeNameLength(hostPath) == 0 && !strings.ContainsAny(hostPath[:1], "./~\\")
 }
 
 // parsePathMapping has the same logic as split_path_mapping:
@@ -33,6 +41,7 @@ func parsePathMapping(shortSyntax string) PathMapping {
 	} else {
 		r.HostPath = hostDrive + remaining[:i]
 		r.HasHostPath = true
+		r.NamedVolume = isNamedVolume(r.HostPath)
 		remaining = remaining[i+1:]
 
 		i = volumeNameLength(remaining)
@@ -78,7 +87,7 @@ func volumeNameLength(s string) int {
 // Copy of the resolve_volume_path function:
 // https://github.com/docker/compose/blob/99e67d0c061fa3d9b9793391f3b7c8bdf8e841fc/compose/config/config.py#L1354
 func resolveBindMountVolumeHostPath(resolvedFile string, sv *ServiceVolume) {
-	if sv.Short != nil && sv.Short.HasHostPath && sv.Short.HostPath != "" {
+	if sv.Short != nil && sv.Short.HasHostPath && sv.Short.HostPath != "" && !sv.Short.NamedVolume {
 		// The intent of the following if is to resolve relative file paths, but not all relative file paths start with a full stop. We
 		// still perform the check as follows, because docker compose also allows specifying named volumes.
 		if sv.Short.HostPath[0] == '.' {
@@ -89,3 +98,70 @@ func resolveBindMountVolumeHostPath(resolvedFile string, sv *ServiceVolume) {
 	}
 	// TODO https://github.com/kube-compose/kube-compose/issues/161 expanding source of long volume syntax
 }
+
+// Volume is a top-level volume of the docker compose configuration.
+// See https://docs.docker.com/compose/compose-file/#volume-configuration-reference.
+type Volume struct {
+	// True if and only if the volume is managed outside of docker compose.
+	External bool
+	// The name of the volume, which defaults to the key of the volume in the volumes section.
+	Name string
+	// The extension fields of the volume. This allows users of this package to read platform specific configuration.
+	XProperties XProperties
+}
+
+type volumeInternal struct {
+	External external `mapdecode:"external"`
+	Name     *string  `mapdecode:"name"`
+}
+
+// parseVolumes converts the volumes section of a docker compose file. volumesRaw is the volumes section before mapdecode was applied, and
+// is used to extract extension fields. Volumes without configuration (e.g. data: {}) are nil in volumes.
+func parseVolumes(volumes map[string]*volumeInternal, volumesRaw interface{}) map[string]*Volume {
+	if len(volumes) == 0 {
+		return nil
+	}
+	volumesRawMap, _ := volumesRaw.(genericMap)
+	result := make(map[string]*Volume, len(volumes))
+	for key, volumeInternal := range volumes {
+		volume := &Volume{
+			Name:        key,
+			XProperties: getXProperties(volumesRawMap[key]),
+		}
+		if volumeInternal != nil {
+			volume.External = volumeInternal.External.Value
+			switch {
+			case volumeInternal.Name != nil:
+				volume.Name = *volumeInternal.Name
+			case volumeInternal.External.Name != nil:
+				volume.Name = *volumeInternal.External.Name
+			}
+		}
+		result[key] = volume
+	}
+	return result
+}
+
+func mergeTopLevelVolumes(into, from map[string]*Volume) map[string]*Volume {
+	if into == nil {
+		into = map[string]*Volume{}
+	}
+	for key, volume := range from {
+		if _, ok := into[key]; !ok {
+			into[key] = volume
+		}
+	}
+	return into
+}
+
+// resolveServiceVolumes checks that the named volumes of services refer to existing top-level volumes.
+func resolveServiceVolumes(services map[string]*serviceInternal, volumes map[string]*Volume) error {
+	for name, s := range services {
+		for _, sv := range s.Volumes {
+			if sv.Short != nil && sv.Short.NamedVolume && volumes[sv.Short.HostPath] == nil {
+				return fmt.Errorf("service %s refers to a non-existing volume: %s", name, sv.Short.HostPath)
+			}
+		}
+	}
+	return nil
+}
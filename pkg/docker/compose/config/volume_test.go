@@ -20,6 +20,7 @@ func TestParsePathMapping_Case2(t *testing.T) {
 	if !reflect.DeepEqual(r, PathMapping{
 		HasHostPath:   true,
 		HostPath:      "aa",
+		NamedVolume:   true,
 		ContainerPath: "bb",
 	}) {
 		t.Fail()
@@ -32,6 +33,7 @@ func TestParsePathMapping_Case3(t *testing.T) {
 		HasHostPath:   true,
 		HasMode:       true,
 		HostPath:      "aa",
+		NamedVolume:   true,
 		Mode:          "cc",
 	}) {
 		t.Logf("pathMapping: %+v\n", r)
@@ -111,3 +113,29 @@ func TestResolveBindMountVolumeHostPath_TildeNotSupported(t *testing.T) {
 	}
 	resolveBindMountVolumeHostPath("/Users/henk/.bash_profile", &sv)
 }
+
+func TestParsePathMapping_WindowsDrive(t *testing.T) {
+	r := parsePathMapping("C:\\data:/data:ro")
+	if !reflect.DeepEqual(r, PathMapping{
+		ContainerPath: "/data",
+		HasHostPath:   true,
+		HasMode:       true,
+		HostPath:      "C:\\data",
+		Mode:          "ro",
+	}) {
+		t.Error(r)
+	}
+}
+
+func TestResolveBindMountVolumeHostPath_Backslashes(t *testing.T) {
+	sv := ServiceVolume{
+		Short: &PathMapping{
+			HasHostPath: true,
+			HostPath:    ".\\Documents\\notes",
+		},
+	}
+	resolveBindMountVolumeHostPath("/Users/henk/.bash_profile", &sv)
+	if sv.Short.HostPath != "/Users/henk/Documents/notes" {
+		t.Error(sv.Short.HostPath)
+	}
+}
@@ -0,0 +1,488 @@
+// Package dockertest provides a fake docker daemon and a fake docker registry, so that flows that pull, push and build images can be tested
+// without a docker daemon. The daemon serves the parts of the docker API that kube-compose uses. To run kube-compose against the daemon,
+// set the environment variable DOCKER_HOST to Daemon.Host:
+//
+//	daemon := dockertest.NewDaemon()
+//	defer daemon.Close()
+//	daemon.AddImage(&dockertest.Image{
+//		ID:       "sha256:...",
+//		RepoTags: []string{"nginx:1.17"},
+//	})
+//	os.Setenv("DOCKER_HOST", daemon.Host())
+package dockertest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+
+	dockerRef "github.com/docker/distribution/reference"
+	dockerTypes "github.com/docker/docker/api/types"
+	dockerContainer "github.com/docker/docker/api/types/container"
+	dockerFilters "github.com/docker/docker/api/types/filters"
+	dockerClient "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+// The API version of clients created with Daemon.NewClient.
+const apiVersion = "1.25"
+
+// The size of image manifests in the output of pushes.
+const manifestSize = 528
+
+var apiVersionRegexp = regexp.MustCompile(`^/v[0-9.]+/`)
+
+// Image is an image of a Daemon.
+type Image struct {
+	// The configuration of the image, such as its healthcheck and exposed ports. Nil is treated as the empty configuration.
+	Config *dockerContainer.Config
+	// The ID of the image, which is a sha256 digest of 64 hexadecimal characters (e.g. sha256:0123...).
+	ID string
+	// The familiar references with a digest of the image (e.g. nginx@sha256:0123...), which are added when the image is pulled or pushed.
+	RepoDigests []string
+	// The familiar references with a tag of the image (e.g. nginx:1.17).
+	RepoTags []string
+}
+
+func (image *Image) copy() *Image {
+	return &Image{
+		Config:      image.Config,
+		ID:          image.ID,
+		RepoDigests: append([]string(nil), image.RepoDigests...),
+		RepoTags:    append([]string(nil), image.RepoTags...),
+	}
+}
+
+// Build is an image build of a Daemon.
+type Build struct {
+	BuildArgs map[string]*string
+	// The build context, which is a tar archive.
+	Context []byte
+	// The path of the Dockerfile in the build context, or the empty string if the default Dockerfile is built.
+	Dockerfile string
+	// The ID of the built image, which is a sha256 digest of the build context.
+	ImageID string
+	Tags    []string
+}
+
+// Daemon is a fake docker daemon that serves the docker API over HTTP. Images are pulled from and pushed to Registry.
+type Daemon struct {
+	Registry *Registry
+	builds   []*Build
+	images   []*Image
+	mu       sync.Mutex
+	pulls    []string
+	pushes   []string
+	server   *httptest.Server
+}
+
+// NewDaemon starts a Daemon without images and with an empty Registry. The daemon must be closed with Close.
+func NewDaemon() *Daemon {
+	d := &Daemon{
+		Registry: NewRegistry(),
+	}
+	d.server = httptest.NewServer(http.HandlerFunc(d.serveHTTP))
+	return d
+}
+
+// Close stops the daemon.
+func (d *Daemon) Close() {
+	d.server.Close()
+}
+
+// Host returns the address of the daemon in the format of DOCKER_HOST.
+func (d *Daemon) Host() string {
+	return "tcp://" + d.server.Listener.Addr().String()
+}
+
+// NewClient creates a docker client of the daemon.
+func (d *Daemon) NewClient() (*dockerClient.Client, error) {
+	return dockerClient.NewClient(d.Host(), apiVersion, d.server.Client(), nil)
+}
+
+// AddImage adds an image to the daemon, as if it was built or pulled.
+func (d *Daemon) AddImage(image *Image) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.images = append(d.images, image.copy())
+}
+
+// Image returns a copy of the image of the daemon with an ID or reference, or nil if the daemon does not have the image.
+func (d *Daemon) Image(nameOrID string) *Image {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	image := d.findImage(nameOrID)
+	if image == nil {
+		return nil
+	}
+	return image.copy()
+}
+
+// Builds returns the builds of images, in the order in which they were performed.
+func (d *Daemon) Builds() []*Build {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*Build(nil), d.builds...)
+}
+
+// Pulls returns the references of the images that were pulled, in the order in which they were pulled.
+func (d *Daemon) Pulls() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.pulls...)
+}
+
+// Pushes returns the references of the images that were pushed, in the order in which they were pushed.
+func (d *Daemon) Pushes() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.pushes...)
+}
+
+// familiarString normalizes a reference and returns it in its familiar form (e.g. nginx:latest for docker.io/library/nginx).
+func familiarString(ref string) (string, error) {
+	_, named, err := parseReference(ref)
+	if err != nil {
+		return "", err
+	}
+	return dockerRef.FamiliarString(named), nil
+}
+
+func containsString(slice []string, s string) bool {
+	for _, s2 := range slice {
+		if s2 == s {
+			return true
+		}
+	}
+	return false
+}
+
+// findImage returns the image with an ID or reference. The caller must hold d.mu.
+func (d *Daemon) findImage(nameOrID string) *Image {
+	ref, err := familiarString(nameOrID)
+	for _, image := range d.images {
+		if image.ID == nameOrID || (err == nil && (containsString(image.RepoTags, ref) || containsString(image.RepoDigests, ref))) {
+			return image
+		}
+	}
+	return nil
+}
+
+// ensureImage returns the image of the daemon with the ID of image, adding the image if the daemon does not have it. The caller must hold
+// d.mu.
+func (d *Daemon) ensureImage(image *Image) *Image {
+	for _, image2 := range d.images {
+		if image2.ID == image.ID {
+			return image2
+		}
+	}
+	image2 := &Image{
+		Config: image.Config,
+		ID:     image.ID,
+	}
+	d.images = append(d.images, image2)
+	return image2
+}
+
+// addImageReference adds a familiar reference with a tag or digest to the image with the ID of image (see ensureImage). A reference with a
+// tag is removed from other images, like docker does. The caller must hold d.mu.
+func (d *Daemon) addImageReference(image *Image, ref string, isDigest bool) {
+	target := d.ensureImage(image)
+	for _, image2 := range d.images {
+		if image2 != target && !isDigest {
+			image2.RepoTags = removeString(image2.RepoTags, ref)
+		}
+	}
+	switch {
+	case isDigest && !containsString(target.RepoDigests, ref):
+		target.RepoDigests = append(target.RepoDigests, ref)
+	case !isDigest && !containsString(target.RepoTags, ref):
+		target.RepoTags = append(target.RepoTags, ref)
+	}
+}
+
+func removeString(slice []string, s string) []string {
+	var result []string
+	for _, s2 := range slice {
+		if s2 != s {
+			result = append(result, s2)
+		}
+	}
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError writes an error response, which docker clients return as an error with the message "Error response from daemon: ...".
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, map[string]string{
+		"message": fmt.Sprintf(format, args...),
+	})
+}
+
+// writeStream writes the JSON messages of a progress stream, such as the output of a pull or push.
+func writeStream(w http.ResponseWriter, messages []*jsonmessage.JSONMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	for _, msg := range messages {
+		_ = encoder.Encode(msg)
+	}
+}
+
+func (d *Daemon) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := apiVersionRegexp.ReplaceAllString(r.URL.Path, "/")
+	switch {
+	case r.Method == http.MethodGet && path == "/_ping":
+		w.Header().Set("API-Version", apiVersion)
+		_, _ = w.Write([]byte("OK"))
+	case r.Method == http.MethodGet && path == "/images/json":
+		d.serveImageList(w, r)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/images/") && strings.HasSuffix(path, "/json"):
+		d.serveImageInspect(w, strings.TrimSuffix(strings.TrimPrefix(path, "/images/"), "/json"))
+	case r.Method == http.MethodPost && path == "/images/create":
+		d.serveImagePull(w, r)
+	case r.Method == http.MethodPost && strings.HasPrefix(path, "/images/") && strings.HasSuffix(path, "/push"):
+		d.serveImagePush(w, r, strings.TrimSuffix(strings.TrimPrefix(path, "/images/"), "/push"))
+	case r.Method == http.MethodPost && strings.HasPrefix(path, "/images/") && strings.HasSuffix(path, "/tag"):
+		d.serveImageTag(w, r, strings.TrimSuffix(strings.TrimPrefix(path, "/images/"), "/tag"))
+	case r.Method == http.MethodPost && path == "/build":
+		d.serveImageBuild(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+// matchesReferenceFilter returns true if and only if an image has a reference whose familiar name or familiar form equals filter.
+func matchesReferenceFilter(image *Image, filter string) bool {
+	for _, ref := range append(append([]string(nil), image.RepoTags...), image.RepoDigests...) {
+		named, err := dockerRef.ParseNormalizedNamed(ref)
+		if err == nil && (dockerRef.FamiliarName(named) == filter || ref == filter) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Daemon) serveImageList(w http.ResponseWriter, r *http.Request) {
+	filters, err := dockerFilters.FromParam(r.URL.Query().Get("filters"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	referenceFilters := filters.Get("reference")
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	summaries := []dockerTypes.ImageSummary{}
+	for _, image := range d.images {
+		matches := len(referenceFilters) == 0
+		for _, filter := range referenceFilters {
+			matches = matches || matchesReferenceFilter(image, filter)
+		}
+		if matches {
+			summaries = append(summaries, dockerTypes.ImageSummary{
+				ID:          image.ID,
+				RepoDigests: image.RepoDigests,
+				RepoTags:    image.RepoTags,
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+func (d *Daemon) serveImageInspect(w http.ResponseWriter, nameOrID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	image := d.findImage(nameOrID)
+	if image == nil {
+		writeError(w, http.StatusNotFound, "No such image: %s", nameOrID)
+		return
+	}
+	config := image.Config
+	if config == nil {
+		config = &dockerContainer.Config{}
+	}
+	writeJSON(w, http.StatusOK, dockerTypes.ImageInspect{
+		Config:      config,
+		ID:          image.ID,
+		RepoDigests: image.RepoDigests,
+		RepoTags:    image.RepoTags,
+	})
+}
+
+// serveImagePull pulls an image from the registry. Errors are returned as error responses, like docker does for missing images and
+// denied pulls.
+func (d *Daemon) serveImagePull(w http.ResponseWriter, r *http.Request) {
+	ref := r.URL.Query().Get("fromImage")
+	if tag := r.URL.Query().Get("tag"); strings.HasPrefix(tag, "sha256:") {
+		ref += "@" + tag
+	} else if tag != "" {
+		ref += ":" + tag
+	}
+	_, named, err := parseReference(ref)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if !d.Registry.authorized(r.Header.Get("X-Registry-Auth")) {
+		writeError(w, http.StatusNotFound, "pull access denied for %s, repository does not exist or may require 'docker login'",
+			dockerRef.FamiliarName(named))
+		return
+	}
+	item, _ := d.Registry.find(ref)
+	if item == nil {
+		writeError(w, http.StatusNotFound, "manifest for %s not found", dockerRef.FamiliarString(named))
+		return
+	}
+	d.mu.Lock()
+	d.pulls = append(d.pulls, dockerRef.FamiliarString(named))
+	if _, isCanonical := named.(dockerRef.Canonical); !isCanonical {
+		d.addImageReference(item.image, dockerRef.FamiliarString(named), false)
+	}
+	d.addImageReference(item.image, dockerRef.FamiliarName(named)+"@"+item.digest, true)
+	d.mu.Unlock()
+	layer := shortID(item.image.ID)
+	writeStream(w, []*jsonmessage.JSONMessage{
+		{Status: "Pulling from " + dockerRef.Path(named), ID: tagOrDigest(named)},
+		{Status: "Pulling fs layer", ID: layer},
+		{Status: "Pull complete", ID: layer},
+		{Status: "Digest: " + item.digest},
+		{Status: "Status: Downloaded newer image for " + dockerRef.FamiliarString(named)},
+	})
+}
+
+func shortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return id
+}
+
+func tagOrDigest(named dockerRef.Named) string {
+	if canonical, isCanonical := named.(dockerRef.Canonical); isCanonical {
+		return canonical.Digest().String()
+	}
+	return named.(dockerRef.Tagged).Tag()
+}
+
+// serveImagePush pushes an image to the registry. Denied pushes are reported in the output stream, like docker does.
+func (d *Daemon) serveImagePush(w http.ResponseWriter, r *http.Request, name string) {
+	tag := r.URL.Query().Get("tag")
+	if tag == "" {
+		tag = "latest"
+	}
+	ref, err := familiarString(name + ":" + tag)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	d.mu.Lock()
+	image := d.findImage(ref)
+	d.mu.Unlock()
+	if image == nil {
+		writeError(w, http.StatusNotFound, "An image does not exist locally with the tag: %s", name)
+		return
+	}
+	_, named, _ := parseReference(ref)
+	messages := []*jsonmessage.JSONMessage{
+		{Status: fmt.Sprintf("The push refers to repository [%s]", named.Name())},
+		{Status: "Preparing", ID: shortID(image.ID)},
+	}
+	if !d.Registry.authorized(r.Header.Get("X-Registry-Auth")) {
+		message := "unauthorized: authentication required"
+		writeStream(w, append(messages, &jsonmessage.JSONMessage{
+			Error:        &jsonmessage.JSONError{Message: message},
+			ErrorMessage: message,
+		}))
+		return
+	}
+	digest, _ := d.Registry.AddImage(ref, image)
+	d.mu.Lock()
+	d.pushes = append(d.pushes, ref)
+	d.addImageReference(image, dockerRef.FamiliarName(named)+"@"+digest, true)
+	d.mu.Unlock()
+	writeStream(w, append(messages,
+		&jsonmessage.JSONMessage{Status: "Pushed", ID: shortID(image.ID)},
+		&jsonmessage.JSONMessage{Status: fmt.Sprintf("%s: digest: %s size: %d", tag, digest, manifestSize)},
+	))
+}
+
+func (d *Daemon) serveImageTag(w http.ResponseWriter, r *http.Request, nameOrID string) {
+	tag := r.URL.Query().Get("tag")
+	if tag == "" {
+		tag = "latest"
+	}
+	ref, err := familiarString(r.URL.Query().Get("repo") + ":" + tag)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	image := d.findImage(nameOrID)
+	if image == nil {
+		writeError(w, http.StatusNotFound, "No such image: %s", nameOrID)
+		return
+	}
+	d.addImageReference(image, ref, false)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// serveImageBuild builds an image whose ID is the digest of the build context. If output is suppressed then the output stream only has
+// the ID of the image, like docker does.
+func (d *Daemon) serveImageBuild(w http.ResponseWriter, r *http.Request) {
+	buildContext, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	build := &Build{
+		BuildArgs:  map[string]*string{},
+		Context:    buildContext,
+		Dockerfile: r.URL.Query().Get("dockerfile"),
+		ImageID:    fmt.Sprintf("sha256:%x", sha256.Sum256(buildContext)),
+		Tags:       r.URL.Query()["t"],
+	}
+	if buildArgs := r.URL.Query().Get("buildargs"); buildArgs != "" {
+		if err = json.Unmarshal([]byte(buildArgs), &build.BuildArgs); err != nil {
+			writeError(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+	}
+	var refs []string
+	for _, tag := range build.Tags {
+		var ref string
+		if ref, err = familiarString(tag); err != nil {
+			writeError(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+		refs = append(refs, ref)
+	}
+	d.mu.Lock()
+	d.builds = append(d.builds, build)
+	image := &Image{
+		ID: build.ImageID,
+	}
+	d.ensureImage(image)
+	for _, ref := range refs {
+		d.addImageReference(image, ref, false)
+	}
+	d.mu.Unlock()
+	stream := build.ImageID + "\n"
+	if r.URL.Query().Get("q") != "1" {
+		stream = "Successfully built " + shortID(build.ImageID) + "\n"
+	}
+	writeStream(w, []*jsonmessage.JSONMessage{
+		{Stream: stream},
+	})
+}
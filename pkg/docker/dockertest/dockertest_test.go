@@ -0,0 +1,232 @@
+package dockertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	dockerContainer "github.com/docker/docker/api/types/container"
+	dockerFilters "github.com/docker/docker/api/types/filters"
+	dockerClient "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+const testImageID = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+
+func withTestDaemon(t *testing.T, cb func(d *Daemon, dc *dockerClient.Client)) {
+	d := NewDaemon()
+	defer d.Close()
+	dc, err := d.NewClient()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	cb(d, dc)
+}
+
+func readMessages(t *testing.T, r io.ReadCloser) []*jsonmessage.JSONMessage {
+	defer r.Close()
+	var messages []*jsonmessage.JSONMessage
+	decoder := json.NewDecoder(r)
+	for {
+		msg := &jsonmessage.JSONMessage{}
+		err := decoder.Decode(msg)
+		if err == io.EOF {
+			return messages
+		}
+		if err != nil {
+			t.Error(err)
+			return messages
+		}
+		messages = append(messages, msg)
+	}
+}
+
+func TestDaemon_ImageInspectWithRaw(t *testing.T) {
+	withTestDaemon(t, func(d *Daemon, dc *dockerClient.Client) {
+		d.AddImage(&Image{
+			Config: &dockerContainer.Config{
+				Cmd: []string{"nginx"},
+			},
+			ID:       testImageID,
+			RepoTags: []string{"nginx:1.17"},
+		})
+		inspect, _, err := dc.ImageInspectWithRaw(context.Background(), "docker.io/library/nginx:1.17")
+		if err != nil || inspect.ID != testImageID || !reflect.DeepEqual([]string(inspect.Config.Cmd), []string{"nginx"}) {
+			t.Error(inspect, err)
+		}
+		_, _, err = dc.ImageInspectWithRaw(context.Background(), "nginx:1.16")
+		if !dockerClient.IsErrImageNotFound(err) {
+			t.Error(err)
+		}
+	})
+}
+
+func TestDaemon_ImageListReferenceFilter(t *testing.T) {
+	withTestDaemon(t, func(d *Daemon, dc *dockerClient.Client) {
+		d.AddImage(&Image{
+			ID:       testImageID,
+			RepoTags: []string{"nginx:1.17"},
+		})
+		d.AddImage(&Image{
+			ID:       "sha256:2222222222222222222222222222222222222222222222222222222222222222",
+			RepoTags: []string{"postgres:11.5"},
+		})
+		filters := dockerFilters.NewArgs()
+		filters.Add("reference", "nginx")
+		summaries, err := dc.ImageList(context.Background(), dockerTypes.ImageListOptions{
+			Filters: filters,
+		})
+		if err != nil || len(summaries) != 1 || summaries[0].ID != testImageID {
+			t.Error(summaries, err)
+		}
+	})
+}
+
+func TestDaemon_ImagePullSuccess(t *testing.T) {
+	withTestDaemon(t, func(d *Daemon, dc *dockerClient.Client) {
+		digest, err := d.Registry.AddImage("nginx:1.17", &Image{
+			ID: testImageID,
+		})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		r, err := dc.ImagePull(context.Background(), "docker.io/library/nginx:1.17", dockerTypes.ImagePullOptions{})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		messages := readMessages(t, r)
+		if len(messages) == 0 || messages[len(messages)-2].Status != "Digest: "+digest {
+			t.Error(messages)
+		}
+		image := d.Image("nginx:1.17")
+		expected := &Image{
+			ID:          testImageID,
+			RepoDigests: []string{"nginx@" + digest},
+			RepoTags:    []string{"nginx:1.17"},
+		}
+		if !reflect.DeepEqual(image, expected) {
+			t.Error(image)
+		}
+		if !reflect.DeepEqual(d.Pulls(), []string{"nginx:1.17"}) {
+			t.Error(d.Pulls())
+		}
+	})
+}
+
+func TestDaemon_ImagePullNotFound(t *testing.T) {
+	withTestDaemon(t, func(d *Daemon, dc *dockerClient.Client) {
+		_, err := dc.ImagePull(context.Background(), "docker.io/library/nginx:1.17", dockerTypes.ImagePullOptions{})
+		if err == nil || !strings.Contains(err.Error(), "manifest for nginx:1.17 not found") {
+			t.Error(err)
+		}
+	})
+}
+
+func TestDaemon_ImagePullUnauthorized(t *testing.T) {
+	withTestDaemon(t, func(d *Daemon, dc *dockerClient.Client) {
+		_, _ = d.Registry.AddImage("nginx:1.17", &Image{
+			ID: testImageID,
+		})
+		d.Registry.RequireAuth("token")
+		_, err := dc.ImagePull(context.Background(), "docker.io/library/nginx:1.17", dockerTypes.ImagePullOptions{
+			RegistryAuth: "invalid",
+		})
+		if err == nil || !strings.Contains(err.Error(), "pull access denied") {
+			t.Error(err)
+		}
+	})
+}
+
+func TestDaemon_ImageTagAndPush(t *testing.T) {
+	withTestDaemon(t, func(d *Daemon, dc *dockerClient.Client) {
+		d.AddImage(&Image{
+			ID: testImageID,
+		})
+		d.Registry.RequireAuth("token")
+		err := dc.ImageTag(context.Background(), testImageID, "my-registry:5000/web:test")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		r, err := dc.ImagePush(context.Background(), "my-registry:5000/web:test", dockerTypes.ImagePushOptions{
+			RegistryAuth: "token",
+		})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		messages := readMessages(t, r)
+		digest := d.Registry.Digest("my-registry:5000/web:test")
+		if digest == "" || len(messages) == 0 || !strings.Contains(messages[len(messages)-1].Status, digest) {
+			t.Error(digest, messages)
+		}
+		if !reflect.DeepEqual(d.Pushes(), []string{"my-registry:5000/web:test"}) {
+			t.Error(d.Pushes())
+		}
+	})
+}
+
+func TestDaemon_ImagePushUnauthorized(t *testing.T) {
+	withTestDaemon(t, func(d *Daemon, dc *dockerClient.Client) {
+		d.AddImage(&Image{
+			ID:       testImageID,
+			RepoTags: []string{"my-registry:5000/web:test"},
+		})
+		d.Registry.RequireAuth("token")
+		r, err := dc.ImagePush(context.Background(), "my-registry:5000/web:test", dockerTypes.ImagePushOptions{})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		messages := readMessages(t, r)
+		if len(messages) == 0 || messages[len(messages)-1].Error == nil || d.Registry.Digest("my-registry:5000/web:test") != "" {
+			t.Error(messages)
+		}
+	})
+}
+
+func TestDaemon_ImageBuild(t *testing.T) {
+	withTestDaemon(t, func(d *Daemon, dc *dockerClient.Client) {
+		baseImage := "ubuntu:latest"
+		response, err := dc.ImageBuild(context.Background(), bytes.NewReader([]byte("context")), dockerTypes.ImageBuildOptions{
+			BuildArgs: map[string]*string{
+				"BASE_IMAGE": &baseImage,
+			},
+			SuppressOutput: true,
+			Tags:           []string{"web"},
+		})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		messages := readMessages(t, response.Body)
+		builds := d.Builds()
+		if len(builds) != 1 || len(messages) != 1 || messages[0].Stream != builds[0].ImageID+"\n" {
+			t.Error(builds, messages)
+			return
+		}
+		if *builds[0].BuildArgs["BASE_IMAGE"] != baseImage || string(builds[0].Context) != "context" {
+			t.Error(builds[0])
+		}
+		if image := d.Image("web:latest"); image == nil || image.ID != builds[0].ImageID {
+			t.Error(image)
+		}
+	})
+}
+
+func TestDaemon_NotFound(t *testing.T) {
+	withTestDaemon(t, func(d *Daemon, dc *dockerClient.Client) {
+		_, err := dc.ContainerCreate(context.Background(), &dockerContainer.Config{}, nil, nil, "")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package dockertest
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+
+	dockerRef "github.com/docker/distribution/reference"
+)
+
+// Registry is a fake docker registry, from which a Daemon pulls images and to which a Daemon pushes images.
+type Registry struct {
+	mu sync.Mutex
+	// The images keyed by normalized references with a tag or digest (e.g. docker.io/library/nginx:1.17).
+	images       map[string]*registryImage
+	registryAuth *string
+}
+
+type registryImage struct {
+	digest string
+	image  *Image
+}
+
+// NewRegistry creates an empty Registry that does not require authentication.
+func NewRegistry() *Registry {
+	return &Registry{
+		images: map[string]*registryImage{},
+	}
+}
+
+// parseReference returns the key of a reference in Registry.images, and the normalized name of the reference.
+func parseReference(ref string) (key string, named dockerRef.Named, err error) {
+	named, err = dockerRef.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, isCanonical := named.(dockerRef.Canonical); isCanonical {
+		return named.String(), named, nil
+	}
+	named = dockerRef.TagNameOnly(named)
+	return named.String(), named, nil
+}
+
+// AddImage adds an image to the registry under a reference with a tag, and returns the digest of the image's manifest. The digest is
+// derived from the name of the reference and the ID of the image.
+func (r *Registry) AddImage(ref string, image *Image) (string, error) {
+	key, named, err := parseReference(ref)
+	if err != nil {
+		return "", err
+	}
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(named.Name()+"@"+image.ID)))
+	item := &registryImage{
+		digest: digest,
+		image: &Image{
+			Config: image.Config,
+			ID:     image.ID,
+		},
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.images[key] = item
+	r.images[named.Name()+"@"+digest] = item
+	return digest, nil
+}
+
+// Digest returns the digest of the manifest of the image with a reference, or the empty string if the registry does not have the image.
+func (r *Registry) Digest(ref string) string {
+	item, _ := r.find(ref)
+	if item == nil {
+		return ""
+	}
+	return item.digest
+}
+
+func (r *Registry) find(ref string) (*registryImage, error) {
+	key, _, err := parseReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.images[key], nil
+}
+
+// RequireAuth makes pulls and pushes fail unless their registry auth (the base64 encoded auth config of the X-Registry-Auth header) equals
+// registryAuth.
+func (r *Registry) RequireAuth(registryAuth string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.registryAuth = &registryAuth
+}
+
+func (r *Registry) authorized(registryAuth string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.registryAuth == nil || *r.registryAuth == registryAuth
+}
@@ -0,0 +1,75 @@
+package kubecompose
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/up"
+)
+
+// EventType is the type of an Event.
+type EventType string
+
+const (
+	// EventPulled means that the image of a docker compose service was resolved, and pulled and pushed if needed.
+	EventPulled EventType = EventType(up.EventPulled)
+	// EventApplied means that the pod of a docker compose service was created, or that its existing pod was kept.
+	EventApplied EventType = EventType(up.EventApplied)
+	// EventReady means that the pod of a docker compose service became ready, or completed.
+	EventReady EventType = EventType(up.EventReady)
+	// EventFailed means that a docker compose service failed. This is reported at most once per service.
+	EventFailed EventType = EventType(up.EventFailed)
+)
+
+// Event is a lifecycle event of a docker compose service during Deployer.Up.
+type Event struct {
+	// The error that caused the failure if Type is EventFailed, or nil otherwise.
+	Err     error
+	Service string
+	Time    time.Time
+	Type    EventType
+}
+
+// Subscribe registers a function that is called with the lifecycle events of docker compose services during Up, so that embedding tools
+// can present progress. Calls are serialized, and should return quickly because Up waits for them. The returned function unregisters the
+// function.
+func (d *Deployer) Subscribe(handler func(*Event)) func() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.subscribers == nil {
+		d.subscribers = map[int]func(*Event){}
+	}
+	id := d.nextSubscriberID
+	d.nextSubscriberID++
+	d.subscribers[id] = handler
+	return func() {
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		delete(d.subscribers, id)
+	}
+}
+
+// publish passes an event of up to the subscribers.
+func (d *Deployer) publish(upEvent *up.Event) {
+	event := &Event{
+		Err:     upEvent.Err,
+		Service: upEvent.Service,
+		Time:    upEvent.Time,
+		Type:    EventType(upEvent.Type),
+	}
+	// Subscribers are called in the order in which they subscribed.
+	d.mutex.Lock()
+	ids := make([]int, 0, len(d.subscribers))
+	for id := range d.subscribers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	handlers := make([]func(*Event), len(ids))
+	for i, id := range ids {
+		handlers[i] = d.subscribers[id]
+	}
+	d.mutex.Unlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
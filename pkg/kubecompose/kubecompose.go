@@ -0,0 +1,275 @@
+// Package kubecompose is the Go API of kube-compose, for tools that embed kube-compose instead of running its command line interface. A
+// docker compose project is loaded (see Load), converted to an environment in a Kubernetes cluster (see Converter), and the environment
+// is then deployed or deleted (see Deployer).
+package kubecompose
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/down"
+	"github.com/kube-compose/kube-compose/internal/app/ephemeral"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/up"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+
+	// Plugin does not export any functions therefore it is ignored IE. "_"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+)
+
+// ExitCode returns the exit code that the kube-compose command line interface would exit with if it failed with err, so that embedding
+// tools can distinguish configuration errors from cluster connectivity errors and the like (see "Exit codes" in the README).
+func ExitCode(err error) int {
+	return int(exitcode.FromError(err))
+}
+
+// LoadOptions are the options of Load.
+type LoadOptions struct {
+	// The docker compose files. If empty then docker-compose.yml and docker-compose.override.yml are loaded from the working directory,
+	// like docker-compose does.
+	Files []string
+	// The developer-specific docker compose file, which is merged last. A relative path is relative to the directory of the first file.
+	LocalFile string
+	// The docker compose services that are deployed, together with their (indirect) dependencies. If empty then all services are
+	// deployed.
+	Services []string
+	// If true then each docker compose file is rendered as a Go text/template before it is parsed.
+	Template bool
+}
+
+// Project is a loaded docker compose project.
+type Project struct {
+	cfg       *config.Config
+	converted bool
+	files     []string
+	mutex     sync.Mutex
+}
+
+// Load loads a docker compose project.
+func Load(ctx context.Context, opts *LoadOptions) (*Project, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cfg, err := config.NewWithOptions(opts.Files, &dockerComposeConfig.LoadOptions{
+		LocalFile: opts.LocalFile,
+		Template:  opts.Template,
+	})
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.Config)
+	}
+	if len(opts.Services) == 0 {
+		for _, service := range cfg.Services {
+			cfg.AddToFilter(service)
+		}
+	}
+	for _, name := range opts.Services {
+		service := cfg.Services[name]
+		if service == nil {
+			return nil, exitcode.Wrap(fmt.Errorf("no service named %#v exists", name), exitcode.Config)
+		}
+		cfg.AddToFilter(service)
+	}
+	return &Project{
+		cfg:   cfg,
+		files: opts.Files,
+	}, nil
+}
+
+// ServiceNames returns the sorted names of the docker compose services of the project.
+func (p *Project) ServiceNames() []string {
+	names := make([]string, 0, len(p.cfg.Services))
+	for name := range p.cfg.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ConvertOptions are the options of a Converter.
+type ConvertOptions struct {
+	// The ID of the environment, which must be a valid label value. Resources are named with "-"+EnvironmentID as a suffix, so that
+	// environments can share a namespace.
+	EnvironmentID string
+	// The Kubernetes client configuration. If nil then the kube config is loaded like kubectl loads it.
+	KubeConfig *rest.Config
+	// The namespace of the environment. If empty then the namespace of the current context of the kube config is used, or "default" if
+	// KubeConfig is set.
+	Namespace string
+	// The name of the project, which prefixes the names of resources and is added as a label to resources, so that projects can share a
+	// namespace. If empty then the COMPOSE_PROJECT_NAME environment variable is used, and if that is not set either then resources are not
+	// scoped to a project.
+	ProjectName string
+	// If not empty, the state of the environment is recorded in this local file instead of in a ConfigMap.
+	StateFile string
+}
+
+// Converter converts docker compose projects to environments in a Kubernetes cluster.
+type Converter struct {
+	opts ConvertOptions
+}
+
+// NewConverter creates a Converter.
+func NewConverter(opts *ConvertOptions) *Converter {
+	return &Converter{
+		opts: *opts,
+	}
+}
+
+// Convert converts a project to an environment. The environment shares the configuration of the project, so a project can be converted
+// at most once.
+func (c *Converter) Convert(ctx context.Context, project *Project) (*Environment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if e := validation.IsValidLabelValue(c.opts.EnvironmentID); len(e) > 0 {
+		return nil, exitcode.Wrap(fmt.Errorf("the environment ID must be a valid label value: %s", e[0]), exitcode.Config)
+	}
+	project.mutex.Lock()
+	defer project.mutex.Unlock()
+	if project.converted {
+		return nil, fmt.Errorf("the project was already converted")
+	}
+	cfg := project.cfg
+	if c.opts.KubeConfig != nil {
+		cfg.KubeConfig = c.opts.KubeConfig
+		cfg.Namespace = "default"
+	} else if err := config.SetFromKubeConfig(cfg); err != nil {
+		return nil, exitcode.Wrap(err, exitcode.Config)
+	}
+	if c.opts.Namespace != "" {
+		cfg.Namespace = c.opts.Namespace
+	}
+	projectName := c.opts.ProjectName
+	if projectName == "" {
+		projectName = ephemeral.LookupProjectName()
+	}
+	cfg.EnvironmentID = c.opts.EnvironmentID
+	cfg.ProjectName = k8smeta.NormalizeProjectName(projectName)
+	cfg.StateFile = c.opts.StateFile
+	project.converted = true
+	return &Environment{
+		cfg: cfg,
+	}, nil
+}
+
+// Environment is a docker compose project converted to an environment in a Kubernetes cluster.
+type Environment struct {
+	cfg *config.Config
+}
+
+// ID returns the ID of the environment.
+func (e *Environment) ID() string {
+	return e.cfg.EnvironmentID
+}
+
+// Namespace returns the namespace of the environment.
+func (e *Environment) Namespace() string {
+	return e.cfg.Namespace
+}
+
+// DeployOptions are the options of a Deployer.
+type DeployOptions struct {
+	// True to also redeploy the (indirect) dependents of a docker compose service when the service is redeployed.
+	CascadeRestart bool
+	// The maximum number of pods that are created concurrently. If not positive then the number of concurrently created pods is not
+	// limited.
+	Concurrency int
+	// True to return as soon as the pods are ready. If false then the logs of the containers are streamed to standard output and Up
+	// returns when the logs end.
+	Detach bool
+	// True to skip docker compose services that did not change since the last deployment and whose pods are ready.
+	Incremental bool
+	// The writer that progress is reported to. If nil then progress is not reported. Logs are written by logrus.
+	Progress io.Writer
+	// True to set runAsUser/runAsGroup of pods based on the users of images and the "user" keys of docker compose services.
+	RunAsUser bool
+	// True to fail if docker compose services set fields that cannot be mapped to Kubernetes, instead of warning about them.
+	Strict bool
+	// True to synthesize TCP readiness probes for docker compose services without a healthcheck.
+	SynthesizeProbes bool
+}
+
+// Deployer deploys environments to, and deletes environments from, Kubernetes clusters.
+type Deployer struct {
+	mutex            sync.Mutex
+	nextSubscriberID int
+	opts             DeployOptions
+	subscribers      map[int]func(*Event)
+}
+
+// NewDeployer creates a Deployer.
+func NewDeployer(opts *DeployOptions) *Deployer {
+	return &Deployer{
+		opts: *opts,
+	}
+}
+
+// Up deploys an environment, like the up command. ctx is used for the operations on images, such as pulling and pushing images.
+func (d *Deployer) Up(ctx context.Context, env *Environment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	progress := d.opts.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+	return up.Run(env.cfg, &up.Options{
+		CascadeRestart:   d.opts.CascadeRestart,
+		Concurrency:      d.opts.Concurrency,
+		Context:          ctx,
+		Detach:           d.opts.Detach,
+		EventHandler:     d.publish,
+		Incremental:      d.opts.Incremental,
+		Reporter:         reporter.New(progress),
+		RunAsUser:        d.opts.RunAsUser,
+		Strict:           d.opts.Strict,
+		SynthesizeProbes: d.opts.SynthesizeProbes,
+	})
+}
+
+// Render writes the pods, PersistentVolumeClaims and Kubernetes services that Up would apply to w as a YAML stream, without connecting to
+// a cluster or docker daemon. Images are not pulled and pods have no host aliases, so the manifests can differ from those applied by Up
+// (see the package kubecomposetest).
+func (d *Deployer) Render(ctx context.Context, env *Environment, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	objects, err := up.Render(env.cfg, &up.Options{
+		RunAsUser:        d.opts.RunAsUser,
+		SynthesizeProbes: d.opts.SynthesizeProbes,
+	})
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		var data []byte
+		data, err = yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "---\n%s", data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down deletes an environment, like the down command. ctx is only checked before the environment is deleted, so that a partially deleted
+// environment is never left behind by a cancellation.
+func (d *Deployer) Down(ctx context.Context, env *Environment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return down.Run(env.cfg)
+}
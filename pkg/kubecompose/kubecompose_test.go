@@ -0,0 +1,165 @@
+package kubecompose
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/up"
+	"github.com/kube-compose/kube-compose/internal/pkg/exitcode"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"k8s.io/client-go/rest"
+)
+
+var dockerComposeYml = "/project/docker-compose.yml"
+var vfs fs.VirtualFileSystem = fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+	dockerComposeYml: {
+		Content: []byte(`version: '2'
+services:
+  db:
+    image: postgres
+  web:
+    image: nginx
+    depends_on: [db]
+  worker:
+    image: busybox
+`),
+	},
+})
+
+func withMockFS(cb func()) {
+	orig := fs.OS
+	defer func() {
+		fs.OS = orig
+	}()
+	fs.OS = vfs
+	cb()
+}
+
+func load(t *testing.T, services ...string) *Project {
+	var project *Project
+	withMockFS(func() {
+		var err error
+		project, err = Load(context.Background(), &LoadOptions{
+			Files:    []string{dockerComposeYml},
+			Services: services,
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	return project
+}
+
+func Test_Load_Success(t *testing.T) {
+	project := load(t, "web")
+	if project == nil {
+		return
+	}
+	if !reflect.DeepEqual(project.ServiceNames(), []string{"db", "web", "worker"}) {
+		t.Error(project.ServiceNames())
+	}
+	for name, expected := range map[string]bool{"db": true, "web": true, "worker": false} {
+		if project.cfg.MatchesFilter(project.cfg.Services[name]) != expected {
+			t.Errorf("service %s", name)
+		}
+	}
+}
+
+func Test_Load_UnknownService(t *testing.T) {
+	withMockFS(func() {
+		_, err := Load(context.Background(), &LoadOptions{
+			Files:    []string{dockerComposeYml},
+			Services: []string{"asdf"},
+		})
+		if ExitCode(err) != int(exitcode.Config) {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_Load_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Load(ctx, &LoadOptions{})
+	if err != context.Canceled {
+		t.Error(err)
+	}
+}
+
+func Test_Converter_Convert_Success(t *testing.T) {
+	project := load(t)
+	if project == nil {
+		return
+	}
+	converter := NewConverter(&ConvertOptions{
+		EnvironmentID: "env1",
+		KubeConfig:    &rest.Config{},
+		Namespace:     "ns1",
+		ProjectName:   "My Project",
+	})
+	env, err := converter.Convert(context.Background(), project)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if env.ID() != "env1" || env.Namespace() != "ns1" || env.cfg.ProjectName != "my-project" {
+		t.Error(env.ID(), env.Namespace(), env.cfg.ProjectName)
+	}
+	_, err = converter.Convert(context.Background(), project)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func Test_Converter_Convert_InvalidEnvironmentID(t *testing.T) {
+	project := load(t)
+	if project == nil {
+		return
+	}
+	_, err := NewConverter(&ConvertOptions{
+		EnvironmentID: "!",
+		KubeConfig:    &rest.Config{},
+	}).Convert(context.Background(), project)
+	if ExitCode(err) != int(exitcode.Config) {
+		t.Error(err)
+	}
+}
+
+func Test_Deployer_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d := NewDeployer(&DeployOptions{})
+	if err := d.Up(ctx, &Environment{}); err != context.Canceled {
+		t.Error(err)
+	}
+	if err := d.Down(ctx, &Environment{}); err != context.Canceled {
+		t.Error(err)
+	}
+}
+
+func Test_Deployer_Subscribe(t *testing.T) {
+	d := NewDeployer(&DeployOptions{})
+	var received []string
+	unsubscribe1 := d.Subscribe(func(event *Event) {
+		received = append(received, "1:"+event.Service)
+	})
+	d.Subscribe(func(event *Event) {
+		if event.Type != EventReady {
+			t.Fail()
+		}
+		received = append(received, "2:"+event.Service)
+	})
+	d.publish(&up.Event{
+		Service: "web",
+		Type:    up.EventReady,
+	})
+	unsubscribe1()
+	d.publish(&up.Event{
+		Service: "db",
+		Type:    up.EventReady,
+	})
+	if !reflect.DeepEqual(received, []string{"1:web", "2:web", "2:db"}) {
+		t.Error(received)
+	}
+}
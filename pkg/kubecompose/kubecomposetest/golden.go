@@ -0,0 +1,135 @@
+// Package kubecomposetest tests the manifests that kube-compose generates for docker compose files against golden files, so that changes
+// of the conversion are reviewable. Projects can use it to test their own docker compose files:
+//
+//	var update = flag.Bool("update", false, "update the golden files")
+//
+//	func TestManifests(t *testing.T) {
+//		kubecomposetest.RunGolden(t, "testdata", *update)
+//	}
+package kubecomposetest
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/kube-compose/kube-compose/pkg/kubecompose"
+	"k8s.io/client-go/rest"
+)
+
+// GoldenExt is the extension of golden files. The golden file of a fixture is the fixture with its extension replaced by GoldenExt.
+const GoldenExt = ".golden.yaml"
+
+// Render renders the manifests of a docker compose file (see kubecompose.Deployer.Render), as they would be applied to an environment
+// with ID "test" in the namespace "default". The project name is the name of the file without its extension.
+func Render(file string) ([]byte, error) {
+	ctx := context.Background()
+	project, err := kubecompose.Load(ctx, &kubecompose.LoadOptions{
+		Files: []string{file},
+	})
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(file)
+	env, err := kubecompose.NewConverter(&kubecompose.ConvertOptions{
+		EnvironmentID: "test",
+		KubeConfig:    &rest.Config{},
+		ProjectName:   strings.TrimSuffix(name, filepath.Ext(name)),
+	}).Convert(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	err = kubecompose.NewDeployer(&kubecompose.DeployOptions{}).Render(ctx, env, &buffer)
+	return buffer.Bytes(), err
+}
+
+// findFixtures returns the sorted fixtures in dir, which are the files with the extension .yml or .yaml that are not golden files.
+func findFixtures(dir string) ([]string, error) {
+	fd, err := fs.OS.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(fd)
+	fileInfos, err := fd.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures []string
+	for _, fileInfo := range fileInfos {
+		name := fileInfo.Name()
+		ext := filepath.Ext(name)
+		if !fileInfo.IsDir() && (ext == ".yml" || ext == ".yaml") && !strings.HasSuffix(name, GoldenExt) {
+			fixtures = append(fixtures, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(fixtures)
+	return fixtures, nil
+}
+
+func readGoldenFile(file string) ([]byte, error) {
+	fd, err := fs.OS.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(fd)
+	return ioutil.ReadAll(fd)
+}
+
+func writeGoldenFile(file string, data []byte) error {
+	fd, err := fs.OS.Create(file)
+	if err != nil {
+		return err
+	}
+	_, err = fd.Write(data)
+	if err != nil {
+		util.CloseAndLogError(fd)
+		return err
+	}
+	return fd.Close()
+}
+
+func runGoldenFixture(t testing.TB, fixture string, update bool) {
+	actual, err := Render(fixture)
+	if err != nil {
+		t.Errorf("fixture %s: %v", fixture, err)
+		return
+	}
+	goldenFile := strings.TrimSuffix(fixture, filepath.Ext(fixture)) + GoldenExt
+	if update {
+		if err = writeGoldenFile(goldenFile, actual); err != nil {
+			t.Error(err)
+		}
+		return
+	}
+	expected, err := readGoldenFile(goldenFile)
+	switch {
+	case os.IsNotExist(err):
+		t.Errorf("fixture %s: golden file %s does not exist (run the tests with update set to create it)", fixture, goldenFile)
+	case err != nil:
+		t.Error(err)
+	case !bytes.Equal(actual, expected):
+		t.Errorf("fixture %s: the manifests differ from golden file %s (run the tests with update set to update it):\n%s", fixture,
+			goldenFile, actual)
+	}
+}
+
+// RunGolden renders the manifests of each docker compose fixture in dir (see Render) and compares them with the golden file of the
+// fixture. If update is true then the golden files are written instead, after which the changes can be reviewed with git diff.
+func RunGolden(t testing.TB, dir string, update bool) {
+	fixtures, err := findFixtures(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, fixture := range fixtures {
+		runGoldenFixture(t, fixture, update)
+	}
+}
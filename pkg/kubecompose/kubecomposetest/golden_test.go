@@ -0,0 +1,51 @@
+package kubecomposetest
+
+import (
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestRunGolden(t *testing.T) {
+	RunGolden(t, "testdata", *update)
+}
+
+func TestRunGolden_MissingGoldenFile(t *testing.T) {
+	orig := fs.OS
+	defer func() {
+		fs.OS = orig
+	}()
+	fs.OS = fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/fixtures/a.yml": {
+			Content: []byte("version: '2'\nservices:\n  a:\n    image: busybox\n"),
+		},
+	})
+	var tb testingTB
+	RunGolden(&tb, "/fixtures", false)
+	if len(tb.errors) != 1 {
+		t.Error(tb.errors)
+	}
+	RunGolden(&tb, "/fixtures", true)
+	RunGolden(&tb, "/fixtures", false)
+	if len(tb.errors) != 1 {
+		t.Error(tb.errors)
+	}
+}
+
+// testingTB records the errors reported by RunGolden.
+type testingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (tb *testingTB) Error(args ...interface{}) {
+	tb.errors = append(tb.errors, fmt.Sprint(args...))
+}
+
+func (tb *testingTB) Errorf(format string, args ...interface{}) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}